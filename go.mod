@@ -13,6 +13,7 @@ require (
 	github.com/spf13/cobra v1.9.1
 	go.uber.org/mock v0.5.2
 	golang.org/x/crypto v0.39.0
+	golang.org/x/sync v0.15.0
 )
 
 require (
@@ -0,0 +1,84 @@
+package apikey
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/pkg/xerror"
+	"github.com/rahadianir/dealls/internal/pkg/xhttp"
+)
+
+type APIKeyHandler struct {
+	deps        *config.CommonDependencies
+	apiKeyLogic APIKeyLogicInterface
+}
+
+func NewAPIKeyHandler(deps *config.CommonDependencies, apiKeyLogic APIKeyLogicInterface) *APIKeyHandler {
+	return &APIKeyHandler{
+		deps:        deps,
+		apiKeyLogic: apiKeyLogic,
+	}
+}
+
+func (handler *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var payload CreateAPIKeyRequest
+	err := xhttp.BindJSONRequest(r, &payload)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: xerror.ErrBadRequest.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	result, err := handler.apiKeyLogic.CreateAPIKey(r.Context(), payload)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to create api key",
+		}, xerror.ParseErrorTypeToCodeInt(err))
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "api key created, store the key now since it can't be shown again",
+		Data:    result,
+	}, http.StatusCreated)
+}
+
+func (handler *APIKeyHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	result, err := handler.apiKeyLogic.GetAPIKeys(r.Context())
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to get api keys",
+		}, xerror.ParseErrorTypeToCodeInt(err))
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "api keys fetched",
+		Data:    result,
+	}, http.StatusOK)
+}
+
+func (handler *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	err := handler.apiKeyLogic.RevokeAPIKey(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		code := xerror.ParseErrorTypeToCodeInt(err)
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			code = http.StatusNotFound
+		}
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to revoke api key",
+		}, code)
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "api key revoked",
+	}, http.StatusOK)
+}
@@ -0,0 +1,256 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/pkg/xaudit"
+	"github.com/rahadianir/dealls/internal/pkg/xcontext"
+	"github.com/rahadianir/dealls/internal/pkg/xerror"
+	"github.com/rahadianir/dealls/internal/user"
+)
+
+// prefixLength is how much of the key is kept in the clear to tell the keys apart
+const prefixLength = len(KeyPrefix) + 8
+
+var errInvalidAPIKey = xerror.AuthError{Err: fmt.Errorf("invalid api key")}
+
+type APIKeyLogic struct {
+	deps       *config.CommonDependencies
+	apiKeyRepo APIKeyRepositoryInterface
+	userRepo   user.UserRepositoryInterface
+	auditor    xaudit.Recorder
+	now        func() time.Time // putting it here so it's easier to be mocked/tested
+}
+
+func NewAPIKeyLogic(deps *config.CommonDependencies, apiKeyRepo APIKeyRepositoryInterface, userRepo user.UserRepositoryInterface, auditor xaudit.Recorder) *APIKeyLogic {
+	return &APIKeyLogic{
+		deps:       deps,
+		apiKeyRepo: apiKeyRepo,
+		userRepo:   userRepo,
+		auditor:    auditor,
+		now:        time.Now,
+	}
+}
+
+// CreateAPIKey creates a key acting on behalf of the admin creating it, limited to the scopes. The key is only
+// returned here, just its hash is stored.
+func (logic *APIKeyLogic) CreateAPIKey(ctx context.Context, req CreateAPIKeyRequest) (CreatedAPIKey, error) {
+	err := logic.checkAdmin(ctx)
+	if err != nil {
+		return CreatedAPIKey{}, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return CreatedAPIKey{}, xerror.ClientError{Err: fmt.Errorf("api key name is required")}
+	}
+
+	scopes, err := toValidScopes(req.Scopes)
+	if err != nil {
+		return CreatedAPIKey{}, err
+	}
+
+	// every key expires, so forgotten keys don't stay valid forever
+	now := logic.now()
+	cfg := logic.deps.Config.APIKey
+	expiresAt := now.Add(cfg.DefaultExpiry)
+	if req.ExpiresAt != nil {
+		expiresAt = *req.ExpiresAt
+	}
+	if !expiresAt.After(now) {
+		return CreatedAPIKey{}, xerror.ClientError{Err: fmt.Errorf("expires_at must be in the future")}
+	}
+	if expiresAt.After(now.Add(cfg.MaxExpiry)) {
+		return CreatedAPIKey{}, xerror.ClientError{Err: fmt.Errorf("expires_at can't be more than %s from now", cfg.MaxExpiry)}
+	}
+
+	key, err := generateKey()
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to generate api key", slog.Any("error", err))
+		return CreatedAPIKey{}, err
+	}
+
+	data := APIKey{
+		ID:        uuid.NewString(),
+		CompanyID: xcontext.GetCompanyIDFromContext(ctx),
+		Name:      name,
+		Prefix:    key[:prefixLength],
+		KeyHash:   hashKey(key),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+		CreatedBy: xcontext.GetUserIDFromContext(ctx),
+	}
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionCreate,
+		Entity:   xaudit.EntityAPIKey,
+		EntityID: data.ID,
+		After:    data,
+	}, func(ctx context.Context) error {
+		return logic.apiKeyRepo.CreateAPIKey(ctx, data)
+	})
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to create api key", slog.Any("error", err))
+		return CreatedAPIKey{}, err
+	}
+	logic.deps.Logger.InfoContext(ctx, "api key created", slog.String("api_key_id", data.ID), slog.Any("scopes", scopes))
+
+	return CreatedAPIKey{APIKey: data, Key: key}, nil
+}
+
+func (logic *APIKeyLogic) GetAPIKeys(ctx context.Context) ([]APIKey, error) {
+	err := logic.checkAdmin(ctx)
+	if err != nil {
+		return []APIKey{}, err
+	}
+
+	result, err := logic.apiKeyRepo.GetAPIKeys(ctx)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get api keys", slog.Any("error", err))
+		return []APIKey{}, err
+	}
+
+	return result, nil
+}
+
+func (logic *APIKeyLogic) RevokeAPIKey(ctx context.Context, id string) error {
+	err := logic.checkAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionRevoke,
+		Entity:   xaudit.EntityAPIKey,
+		EntityID: id,
+	}, func(ctx context.Context) error {
+		return logic.apiKeyRepo.RevokeAPIKey(ctx, id)
+	})
+	if err != nil {
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			return xerror.ClientError{Err: fmt.Errorf("api key %s not found or already revoked: %w", id, err)}
+		}
+
+		logic.deps.Logger.ErrorContext(ctx, "failed to revoke api key", slog.Any("error", err))
+		return err
+	}
+	logic.deps.Logger.InfoContext(ctx, "api key revoked", slog.String("api_key_id", id))
+
+	return nil
+}
+
+// Authenticate returns the key of the request, as long as it's neither revoked nor expired and the admin who
+// created it still is one
+func (logic *APIKeyLogic) Authenticate(ctx context.Context, key string) (APIKey, error) {
+	if !strings.HasPrefix(key, KeyPrefix) {
+		return APIKey{}, errInvalidAPIKey
+	}
+
+	result, err := logic.apiKeyRepo.GetAPIKeyByHash(ctx, hashKey(key))
+	if err != nil {
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			logic.deps.Logger.WarnContext(ctx, "api key not found", slog.String("prefix", key[:min(len(key), prefixLength)]))
+			return APIKey{}, errInvalidAPIKey
+		}
+
+		logic.deps.Logger.ErrorContext(ctx, "failed to get api key", slog.Any("error", err))
+		return APIKey{}, err
+	}
+
+	if result.RevokedAt != nil {
+		return APIKey{}, xerror.AuthError{Err: fmt.Errorf("api key has been revoked")}
+	}
+
+	now := logic.now()
+	if !now.Before(result.ExpiresAt) {
+		return APIKey{}, xerror.AuthError{Err: fmt.Errorf("api key has expired")}
+	}
+
+	// the key can't do more than the admin who created it, keys of admins who lost the role stop working
+	ctx = context.WithValue(ctx, xcontext.CompanyIDKey, result.CompanyID)
+	isAdmin, err := logic.userRepo.IsAdmin(ctx, result.CreatedBy)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to check api key creator admin role", slog.Any("error", err))
+		return APIKey{}, err
+	}
+
+	if !isAdmin {
+		logic.deps.Logger.WarnContext(ctx, "api key creator is no longer an admin", slog.String("api_key_id", result.ID))
+		return APIKey{}, xerror.AuthError{Err: fmt.Errorf("api key creator is no longer an admin")}
+	}
+
+	// the last used time only has to be roughly right, so not every request writes it
+	if result.LastUsedAt == nil || now.Sub(*result.LastUsedAt) >= logic.deps.Config.APIKey.LastUsedInterval {
+		err = logic.apiKeyRepo.UpdateAPIKeyLastUsed(ctx, result.ID, now)
+		if err != nil {
+			logic.deps.Logger.WarnContext(ctx, "failed to update api key last used time", slog.Any("error", err))
+		} else {
+			result.LastUsedAt = &now
+		}
+	}
+
+	return result, nil
+}
+
+func (logic *APIKeyLogic) checkAdmin(ctx context.Context) error {
+	// check admin role of the user
+	isAdmin, err := logic.userRepo.IsAdmin(ctx, xcontext.GetUserIDFromContext(ctx))
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to check user admin role", slog.Any("error", err))
+		return err
+	}
+
+	if !isAdmin {
+		return xerror.AuthError{Err: fmt.Errorf("admin only operation")}
+	}
+
+	return nil
+}
+
+// toValidScopes checks the scopes are known, returning them sorted without duplicates
+func toValidScopes(scopes []string) ([]string, error) {
+	result := []string{}
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if !slices.Contains(Scopes, scope) {
+			return []string{}, xerror.ClientError{Err: fmt.Errorf("unknown scope %q, valid scopes are %s", scope, strings.Join(Scopes, ", "))}
+		}
+		result = append(result, scope)
+	}
+
+	if len(result) == 0 {
+		return []string{}, xerror.ClientError{Err: fmt.Errorf("at least one scope is required")}
+	}
+
+	slices.Sort(result)
+	return slices.Compact(result), nil
+}
+
+// generateKey generates a random 256-bit key, prefixed so leaked keys are easy to spot
+func generateKey() (string, error) {
+	randBytes := make([]byte, 32)
+	_, err := rand.Read(randBytes)
+	if err != nil {
+		return "", err
+	}
+
+	return KeyPrefix + base64.RawURLEncoding.EncodeToString(randBytes), nil
+}
+
+// hashKey hashes the key for storage, SHA-256 is enough since the key is random rather than a password
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
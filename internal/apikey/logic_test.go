@@ -0,0 +1,271 @@
+package apikey
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/pkg/xaudit"
+	"github.com/rahadianir/dealls/internal/pkg/xcontext"
+	"github.com/rahadianir/dealls/internal/pkg/xerror"
+	"github.com/rahadianir/dealls/internal/user"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAPIKeyLogic_CreateAPIKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := NewMockAPIKeyRepositoryInterface(ctrl)
+	mockUserRepo := user.NewMockUserRepositoryInterface(ctrl)
+	mockAuditor := xaudit.NewMockRecorder(ctrl)
+	// the change and its entry are run in the transaction the recorder begins
+	mockAuditor.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, txfunc func(context.Context) error) error {
+		return txfunc(ctx)
+	}).AnyTimes()
+	mockDeps := config.CommonDependencies{
+		Config: config.InitConfig(context.Background()),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	now := time.Date(2025, time.June, 1, 9, 0, 0, 0, time.UTC)
+	expiresAt := now.Add(30 * 24 * time.Hour)
+	tooLate := now.Add(mockDeps.Config.APIKey.MaxExpiry + time.Hour)
+	past := now.Add(-time.Hour)
+
+	ctx := context.WithValue(context.Background(), xcontext.UserIDKey, "admin-id")
+	ctx = context.WithValue(ctx, xcontext.CompanyIDKey, "company-id")
+
+	type args struct {
+		ctx context.Context
+		req CreateAPIKeyRequest
+	}
+	tests := []struct {
+		name       string
+		args       args
+		wantScopes []string
+		wantExpiry time.Time
+		wantErr    bool
+		behaviour  func(a args)
+	}{
+		// TODO: Add test cases.
+		{
+			name:       "success create a key expiring by default",
+			args:       args{ctx: ctx, req: CreateAPIKeyRequest{Name: " HRIS sync ", Scopes: []string{"payroll:read", "Users:Write", "payroll:read"}}},
+			wantScopes: []string{ScopePayrollRead, ScopeUsersWrite},
+			wantExpiry: now.Add(mockDeps.Config.APIKey.DefaultExpiry),
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+				mockRepo.EXPECT().CreateAPIKey(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data APIKey) error {
+					if data.Name != "HRIS sync" || data.CreatedBy != "admin-id" || len(data.KeyHash) != 64 || !strings.HasPrefix(data.Prefix, KeyPrefix) {
+						t.Errorf("unexpected api key: %+v", data)
+					}
+					return nil
+				})
+				mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, entry xaudit.Entry) error {
+					data, ok := entry.After.(APIKey)
+					if entry.Action != xaudit.ActionCreate || entry.Entity != xaudit.EntityAPIKey || !ok || entry.EntityID != data.ID {
+						t.Errorf("unexpected audit log entry: %+v", entry)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name:       "success create a key with an expiry",
+			args:       args{ctx: ctx, req: CreateAPIKeyRequest{Name: "accounting", Scopes: []string{ScopePayrollRead}, ExpiresAt: &expiresAt}},
+			wantScopes: []string{ScopePayrollRead},
+			wantExpiry: expiresAt,
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+				mockRepo.EXPECT().CreateAPIKey(gomock.Any(), gomock.Any()).Return(nil)
+				mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:    "failed unknown scope",
+			args:    args{ctx: ctx, req: CreateAPIKeyRequest{Name: "accounting", Scopes: []string{"payroll:delete"}}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+			},
+		},
+		{
+			name:    "failed no scope",
+			args:    args{ctx: ctx, req: CreateAPIKeyRequest{Name: "accounting"}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+			},
+		},
+		{
+			name:    "failed expiry past the max",
+			args:    args{ctx: ctx, req: CreateAPIKeyRequest{Name: "accounting", Scopes: []string{ScopePayrollRead}, ExpiresAt: &tooLate}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+			},
+		},
+		{
+			name:    "failed expiry in the past",
+			args:    args{ctx: ctx, req: CreateAPIKeyRequest{Name: "accounting", Scopes: []string{ScopePayrollRead}, ExpiresAt: &past}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+			},
+		},
+		{
+			name:    "failed not admin",
+			args:    args{ctx: ctx, req: CreateAPIKeyRequest{Name: "accounting", Scopes: []string{ScopePayrollRead}}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(false, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := NewAPIKeyLogic(&mockDeps, mockRepo, mockUserRepo, mockAuditor)
+			logic.now = func() time.Time { return now }
+			tt.behaviour(tt.args)
+			got, err := logic.CreateAPIKey(tt.args.ctx, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("APIKeyLogic.CreateAPIKey() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !strings.HasPrefix(got.Key, got.Prefix) || got.KeyHash != hashKey(got.Key) {
+				t.Errorf("APIKeyLogic.CreateAPIKey() key doesn't match its prefix and hash: %+v", got)
+			}
+			if !reflect.DeepEqual(got.Scopes, tt.wantScopes) || !got.ExpiresAt.Equal(tt.wantExpiry) {
+				t.Errorf("APIKeyLogic.CreateAPIKey() = %v %v, want %v %v", got.Scopes, got.ExpiresAt, tt.wantScopes, tt.wantExpiry)
+			}
+		})
+	}
+}
+
+func TestAPIKeyLogic_Authenticate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := NewMockAPIKeyRepositoryInterface(ctrl)
+	mockUserRepo := user.NewMockUserRepositoryInterface(ctrl)
+	mockDeps := config.CommonDependencies{
+		Config: config.InitConfig(context.Background()),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	now := time.Date(2025, time.June, 1, 9, 0, 0, 0, time.UTC)
+	justUsed := now.Add(-time.Second)
+	revokedAt := now.Add(-time.Hour)
+
+	key := KeyPrefix + "secret"
+	stored := APIKey{
+		ID:        "key-id",
+		CompanyID: "company-id",
+		KeyHash:   hashKey(key),
+		Scopes:    []string{ScopePayrollRead},
+		ExpiresAt: now.Add(time.Hour),
+		CreatedBy: "admin-id",
+	}
+	recentlyUsed := stored
+	recentlyUsed.LastUsedAt = &justUsed
+	revoked := stored
+	revoked.RevokedAt = &revokedAt
+	expired := stored
+	expired.ExpiresAt = now
+
+	type args struct {
+		ctx context.Context
+		key string
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantErr   bool
+		behaviour func(a args)
+	}{
+		// TODO: Add test cases.
+		{
+			name: "success authenticate and track the use",
+			args: args{ctx: context.Background(), key: key},
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetAPIKeyByHash(gomock.Any(), hashKey(key)).Return(stored, nil)
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").DoAndReturn(func(ctx context.Context, userID string) (bool, error) {
+					if xcontext.GetCompanyIDFromContext(ctx) != "company-id" {
+						t.Errorf("unexpected company %q", xcontext.GetCompanyIDFromContext(ctx))
+					}
+					return true, nil
+				})
+				mockRepo.EXPECT().UpdateAPIKeyLastUsed(gomock.Any(), "key-id", now).Return(nil)
+			},
+		},
+		{
+			name: "success authenticate a key used moments ago without tracking it again",
+			args: args{ctx: context.Background(), key: key},
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetAPIKeyByHash(gomock.Any(), hashKey(key)).Return(recentlyUsed, nil)
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+			},
+		},
+		{
+			name:      "failed not an api key",
+			args:      args{ctx: context.Background(), key: "secret"},
+			wantErr:   true,
+			behaviour: func(a args) {},
+		},
+		{
+			name:    "failed unknown key",
+			args:    args{ctx: context.Background(), key: key},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetAPIKeyByHash(gomock.Any(), hashKey(key)).Return(APIKey{}, xerror.ErrDataNotFound)
+			},
+		},
+		{
+			name:    "failed revoked key",
+			args:    args{ctx: context.Background(), key: key},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetAPIKeyByHash(gomock.Any(), hashKey(key)).Return(revoked, nil)
+			},
+		},
+		{
+			name:    "failed expired key",
+			args:    args{ctx: context.Background(), key: key},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetAPIKeyByHash(gomock.Any(), hashKey(key)).Return(expired, nil)
+			},
+		},
+		{
+			name:    "failed creator is no longer an admin",
+			args:    args{ctx: context.Background(), key: key},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetAPIKeyByHash(gomock.Any(), hashKey(key)).Return(stored, nil)
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(false, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := NewAPIKeyLogic(&mockDeps, mockRepo, mockUserRepo, nil)
+			logic.now = func() time.Time { return now }
+			tt.behaviour(tt.args)
+			got, err := logic.Authenticate(tt.args.ctx, tt.args.key)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("APIKeyLogic.Authenticate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got.ID != "key-id" {
+				t.Errorf("APIKeyLogic.Authenticate() = %+v", got)
+			}
+		})
+	}
+}
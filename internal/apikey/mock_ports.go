@@ -0,0 +1,197 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/apikey/ports.go
+//
+// Generated by this command:
+//
+//	mockgen -source internal/apikey/ports.go -destination internal/apikey/mock_ports.go -package apikey
+//
+
+// Package apikey is a generated GoMock package.
+package apikey
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAPIKeyRepositoryInterface is a mock of APIKeyRepositoryInterface interface.
+type MockAPIKeyRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeyRepositoryInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockAPIKeyRepositoryInterfaceMockRecorder is the mock recorder for MockAPIKeyRepositoryInterface.
+type MockAPIKeyRepositoryInterfaceMockRecorder struct {
+	mock *MockAPIKeyRepositoryInterface
+}
+
+// NewMockAPIKeyRepositoryInterface creates a new mock instance.
+func NewMockAPIKeyRepositoryInterface(ctrl *gomock.Controller) *MockAPIKeyRepositoryInterface {
+	mock := &MockAPIKeyRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockAPIKeyRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKeyRepositoryInterface) EXPECT() *MockAPIKeyRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// CreateAPIKey mocks base method.
+func (m *MockAPIKeyRepositoryInterface) CreateAPIKey(ctx context.Context, data APIKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKey", ctx, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey.
+func (mr *MockAPIKeyRepositoryInterfaceMockRecorder) CreateAPIKey(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockAPIKeyRepositoryInterface)(nil).CreateAPIKey), ctx, data)
+}
+
+// GetAPIKeyByHash mocks base method.
+func (m *MockAPIKeyRepositoryInterface) GetAPIKeyByHash(ctx context.Context, keyHash string) (APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeyByHash", ctx, keyHash)
+	ret0, _ := ret[0].(APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeyByHash indicates an expected call of GetAPIKeyByHash.
+func (mr *MockAPIKeyRepositoryInterfaceMockRecorder) GetAPIKeyByHash(ctx, keyHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeyByHash", reflect.TypeOf((*MockAPIKeyRepositoryInterface)(nil).GetAPIKeyByHash), ctx, keyHash)
+}
+
+// GetAPIKeys mocks base method.
+func (m *MockAPIKeyRepositoryInterface) GetAPIKeys(ctx context.Context) ([]APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeys", ctx)
+	ret0, _ := ret[0].([]APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeys indicates an expected call of GetAPIKeys.
+func (mr *MockAPIKeyRepositoryInterfaceMockRecorder) GetAPIKeys(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeys", reflect.TypeOf((*MockAPIKeyRepositoryInterface)(nil).GetAPIKeys), ctx)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockAPIKeyRepositoryInterface) RevokeAPIKey(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockAPIKeyRepositoryInterfaceMockRecorder) RevokeAPIKey(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockAPIKeyRepositoryInterface)(nil).RevokeAPIKey), ctx, id)
+}
+
+// UpdateAPIKeyLastUsed mocks base method.
+func (m *MockAPIKeyRepositoryInterface) UpdateAPIKeyLastUsed(ctx context.Context, id string, usedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAPIKeyLastUsed", ctx, id, usedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAPIKeyLastUsed indicates an expected call of UpdateAPIKeyLastUsed.
+func (mr *MockAPIKeyRepositoryInterfaceMockRecorder) UpdateAPIKeyLastUsed(ctx, id, usedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAPIKeyLastUsed", reflect.TypeOf((*MockAPIKeyRepositoryInterface)(nil).UpdateAPIKeyLastUsed), ctx, id, usedAt)
+}
+
+// MockAPIKeyLogicInterface is a mock of APIKeyLogicInterface interface.
+type MockAPIKeyLogicInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeyLogicInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockAPIKeyLogicInterfaceMockRecorder is the mock recorder for MockAPIKeyLogicInterface.
+type MockAPIKeyLogicInterfaceMockRecorder struct {
+	mock *MockAPIKeyLogicInterface
+}
+
+// NewMockAPIKeyLogicInterface creates a new mock instance.
+func NewMockAPIKeyLogicInterface(ctrl *gomock.Controller) *MockAPIKeyLogicInterface {
+	mock := &MockAPIKeyLogicInterface{ctrl: ctrl}
+	mock.recorder = &MockAPIKeyLogicInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKeyLogicInterface) EXPECT() *MockAPIKeyLogicInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Authenticate mocks base method.
+func (m *MockAPIKeyLogicInterface) Authenticate(ctx context.Context, key string) (APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", ctx, key)
+	ret0, _ := ret[0].(APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockAPIKeyLogicInterfaceMockRecorder) Authenticate(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockAPIKeyLogicInterface)(nil).Authenticate), ctx, key)
+}
+
+// CreateAPIKey mocks base method.
+func (m *MockAPIKeyLogicInterface) CreateAPIKey(ctx context.Context, req CreateAPIKeyRequest) (CreatedAPIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKey", ctx, req)
+	ret0, _ := ret[0].(CreatedAPIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey.
+func (mr *MockAPIKeyLogicInterfaceMockRecorder) CreateAPIKey(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockAPIKeyLogicInterface)(nil).CreateAPIKey), ctx, req)
+}
+
+// GetAPIKeys mocks base method.
+func (m *MockAPIKeyLogicInterface) GetAPIKeys(ctx context.Context) ([]APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeys", ctx)
+	ret0, _ := ret[0].([]APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeys indicates an expected call of GetAPIKeys.
+func (mr *MockAPIKeyLogicInterfaceMockRecorder) GetAPIKeys(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeys", reflect.TypeOf((*MockAPIKeyLogicInterface)(nil).GetAPIKeys), ctx)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockAPIKeyLogicInterface) RevokeAPIKey(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockAPIKeyLogicInterfaceMockRecorder) RevokeAPIKey(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockAPIKeyLogicInterface)(nil).RevokeAPIKey), ctx, id)
+}
@@ -0,0 +1,63 @@
+package apikey
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// scopes of what an API key can do, on top of what the admin who created it can do
+const (
+	ScopeUsersWrite         = "users:write"
+	ScopePayrollRead        = "payroll:read"
+	ScopePayrollWrite       = "payroll:write"
+	ScopeReimbursementRead  = "reimbursement:read"
+	ScopeReimbursementWrite = "reimbursement:write"
+)
+
+var Scopes = []string{ScopeUsersWrite, ScopePayrollRead, ScopePayrollWrite, ScopeReimbursementRead, ScopeReimbursementWrite}
+
+// KeyPrefix starts every key so leaked keys are easy to spot, e.g. by secret scanners
+const KeyPrefix = "hrk_"
+
+// APIKey lets an integration call the API on behalf of the admin who created it, only the hash of the key is kept
+type APIKey struct {
+	ID         string     `json:"id"`
+	CompanyID  string     `json:"-"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	CreatedBy  string     `json:"created_by"`
+}
+
+type SQLAPIKey struct {
+	ID         sql.NullString `db:"id"`
+	CompanyID  sql.NullString `db:"company_id"`
+	Name       sql.NullString `db:"name"`
+	Prefix     sql.NullString `db:"prefix"`
+	KeyHash    sql.NullString `db:"key_hash"`
+	Scopes     pq.StringArray `db:"scopes"`
+	ExpiresAt  sql.NullTime   `db:"expires_at"`
+	LastUsedAt sql.NullTime   `db:"last_used_at"`
+	RevokedAt  sql.NullTime   `db:"revoked_at"`
+	CreatedAt  sql.NullTime   `db:"created_at"`
+	CreatedBy  sql.NullString `db:"created_by"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"` // API_KEY_DEFAULT_EXPIRY from now when empty
+}
+
+// CreatedAPIKey carries the key itself, which is only shown once
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,21 @@
+package apikey
+
+import (
+	"context"
+	"time"
+)
+
+type APIKeyRepositoryInterface interface {
+	CreateAPIKey(ctx context.Context, data APIKey) error
+	GetAPIKeys(ctx context.Context) ([]APIKey, error)
+	RevokeAPIKey(ctx context.Context, id string) error
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (APIKey, error)
+	UpdateAPIKeyLastUsed(ctx context.Context, id string, usedAt time.Time) error
+}
+
+type APIKeyLogicInterface interface {
+	CreateAPIKey(ctx context.Context, req CreateAPIKeyRequest) (CreatedAPIKey, error)
+	GetAPIKeys(ctx context.Context) ([]APIKey, error)
+	RevokeAPIKey(ctx context.Context, id string) error
+	Authenticate(ctx context.Context, key string) (APIKey, error)
+}
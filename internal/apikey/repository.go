@@ -0,0 +1,167 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/lib/pq"
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/pkg/dbhelper"
+	"github.com/rahadianir/dealls/internal/pkg/xcontext"
+	"github.com/rahadianir/dealls/internal/pkg/xerror"
+)
+
+type APIKeyRepository struct {
+	deps *config.CommonDependencies
+}
+
+func NewAPIKeyRepository(deps *config.CommonDependencies) *APIKeyRepository {
+	return &APIKeyRepository{
+		deps: deps,
+	}
+}
+
+var apiKeyColumns = []string{`id`, `company_id`, `name`, `prefix`, `key_hash`, `scopes`, `expires_at`, `last_used_at`, `revoked_at`, `created_at`, `created_by`}
+
+func (repo *APIKeyRepository) CreateAPIKey(ctx context.Context, data APIKey) error {
+	sq := sqlbuilder.NewInsertBuilder()
+	sq.InsertInto(`hr.api_keys`).
+		Cols(`id`, `company_id`, `name`, `prefix`, `key_hash`, `scopes`, `expires_at`, `created_at`, `created_by`).
+		Values(data.ID, xcontext.GetCompanyIDFromContext(ctx), data.Name, data.Prefix, data.KeyHash, pq.Array(data.Scopes), data.ExpiresAt, data.CreatedAt, xcontext.GetUserIDFromContext(ctx))
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	_, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetAPIKeys returns the keys of the company, newest first, revoked and expired ones included
+func (repo *APIKeyRepository) GetAPIKeys(ctx context.Context) ([]APIKey, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(apiKeyColumns...).From(`hr.api_keys`).
+		Where(sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx))).
+		OrderBy(`created_at DESC`)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	rows, err := tx.QueryxContext(ctx, q, args...)
+	if err != nil {
+		return []APIKey{}, err
+	}
+	defer rows.Close()
+
+	result := []APIKey{}
+	for rows.Next() {
+		var temp SQLAPIKey
+		err := rows.StructScan(&temp)
+		if err != nil {
+			repo.deps.Logger.WarnContext(ctx, "failed to scan api key", slog.Any("error", err))
+			continue
+		}
+		result = append(result, toAPIKey(temp))
+	}
+
+	return result, nil
+}
+
+// RevokeAPIKey revokes the key right away, ErrDataNotFound is returned when there's no such key or it's already revoked
+func (repo *APIKeyRepository) RevokeAPIKey(ctx context.Context, id string) error {
+	sq := sqlbuilder.NewUpdateBuilder()
+	sq.Update(`hr.api_keys`).Set(
+		`revoked_at = now()`,
+		sq.Assign(`revoked_by`, xcontext.GetUserIDFromContext(ctx)),
+	).Where(
+		sq.Equal(`id`, id),
+		sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+		sq.IsNull(`revoked_at`),
+	)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	res, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return xerror.ErrDataNotFound
+	}
+
+	return nil
+}
+
+// GetAPIKeyByHash looks the key up by its hash, which is what identifies the company of a request made with it,
+// so unlike the other queries it isn't scoped to the context company
+func (repo *APIKeyRepository) GetAPIKeyByHash(ctx context.Context, keyHash string) (APIKey, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(apiKeyColumns...).From(`hr.api_keys`).Where(sq.Equal(`key_hash`, keyHash))
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	var temp SQLAPIKey
+	err := tx.QueryRowxContext(ctx, q, args...).StructScan(&temp)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return APIKey{}, xerror.ErrDataNotFound
+		}
+
+		return APIKey{}, err
+	}
+
+	return toAPIKey(temp), nil
+}
+
+func (repo *APIKeyRepository) UpdateAPIKeyLastUsed(ctx context.Context, id string, usedAt time.Time) error {
+	sq := sqlbuilder.NewUpdateBuilder()
+	sq.Update(`hr.api_keys`).Set(
+		sq.Assign(`last_used_at`, usedAt),
+	).Where(
+		sq.Equal(`id`, id),
+		sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+	)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	_, err := tx.ExecContext(ctx, q, args...)
+	return err
+}
+
+func toAPIKey(temp SQLAPIKey) APIKey {
+	result := APIKey{
+		ID:        temp.ID.String,
+		CompanyID: temp.CompanyID.String,
+		Name:      temp.Name.String,
+		Prefix:    temp.Prefix.String,
+		KeyHash:   temp.KeyHash.String,
+		Scopes:    []string(temp.Scopes),
+		ExpiresAt: temp.ExpiresAt.Time,
+		CreatedAt: temp.CreatedAt.Time,
+		CreatedBy: temp.CreatedBy.String,
+	}
+	if temp.LastUsedAt.Valid {
+		result.LastUsedAt = &temp.LastUsedAt.Time
+	}
+	if temp.RevokedAt.Valid {
+		result.RevokedAt = &temp.RevokedAt.Time
+	}
+
+	return result
+}
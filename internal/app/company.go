@@ -0,0 +1,59 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rahadianir/dealls/internal/audit"
+	"github.com/rahadianir/dealls/internal/company"
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/pkg/logger"
+)
+
+// CreateCompany creates a company along with its admin user and exits, companies are set up by the operator rather than over HTTP
+// since every HTTP request is scoped to the company of the logged in user
+func CreateCompany(req company.CreateCompanyRequest) {
+	ctx := context.Background()
+
+	// setup config
+	cfg := config.InitConfig(ctx)
+
+	// init logger
+	logger := logger.InitLogger()
+
+	// init database connection pool
+	db, err := sqlx.Open("postgres", cfg.DB.URL)
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to open db connection", slog.Any("error", err))
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	err = db.Ping()
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to ping db connection", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	deps := config.CommonDependencies{
+		Config: cfg,
+		DB:     db,
+		Logger: logger,
+	}
+
+	// wiring layers
+	companyRepo := company.NewCompanyRepository(&deps)
+	auditRepo := audit.NewAuditRepository(&deps)
+	companyLogic := company.NewCompanyLogic(&deps, companyRepo, auditRepo)
+
+	result, err := companyLogic.CreateCompany(ctx, req)
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to create company", slog.Any("error", err))
+		db.Close()
+		os.Exit(1)
+	}
+
+	logger.InfoContext(ctx, "company created", slog.String("company_id", result.ID), slog.String("code", result.Code), slog.String("admin", req.AdminUsername))
+}
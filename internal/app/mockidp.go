@@ -0,0 +1,52 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/pkg/logger"
+	"github.com/rahadianir/dealls/internal/pkg/xoidc"
+)
+
+// defaultMockIdPIssuer is where the mock identity provider listens when OIDC_ISSUER isn't set
+const defaultMockIdPIssuer = "http://localhost:9000"
+
+// StartMockIdP runs a mock OpenID Connect identity provider for trying single sign-on locally, it signs in the
+// email given as login_hint, or the default email, without asking for a password
+func StartMockIdP(defaultEmail string) {
+	ctx := context.Background()
+
+	// setup config
+	cfg := config.InitConfig(ctx)
+
+	// init logger
+	logger := logger.InitLogger()
+
+	issuer := cfg.OIDC.Issuer
+	if issuer == "" {
+		issuer = defaultMockIdPIssuer
+	}
+
+	issuerURL, err := url.Parse(issuer)
+	if err != nil || issuerURL.Host == "" {
+		logger.ErrorContext(ctx, "invalid mock identity provider issuer", slog.String("issuer", issuer))
+		os.Exit(1)
+	}
+
+	idp, err := xoidc.NewMockIdP(issuer, cfg.OIDC.ClientID, cfg.OIDC.ClientSecret, defaultEmail)
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to init mock identity provider", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	logger.InfoContext(ctx, "mock identity provider starts!", slog.String("issuer", issuer), slog.String("default_email", defaultEmail))
+	err = http.ListenAndServe(issuerURL.Host, idp)
+	if err != nil {
+		logger.ErrorContext(ctx, "mock identity provider fails to listen and serve", slog.Any("error", err))
+		os.Exit(1)
+	}
+}
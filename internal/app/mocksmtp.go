@@ -0,0 +1,57 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/pkg/logger"
+	"github.com/rahadianir/dealls/internal/pkg/xmail"
+)
+
+// StartMockSMTP runs the local SMTP stand-in on MAIL_SMTP_HOST and MAIL_SMTP_PORT for trying the emails locally,
+// every received email is written to the log instead of being delivered
+func StartMockSMTP() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// setup config
+	cfg := config.InitConfig(ctx)
+
+	// init logger
+	logger := logger.InitLogger()
+
+	addr := net.JoinHostPort(cfg.Mail.SMTPHost, strconv.Itoa(cfg.Mail.SMTPPort))
+	server, err := xmail.NewLocalServer(addr)
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to start mock smtp server", slog.Any("error", err))
+		os.Exit(1)
+	}
+	defer server.Close()
+
+	logger.InfoContext(ctx, "mock smtp server starts!", slog.String("addr", server.Addr()))
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	logged := 0
+	for {
+		select {
+		case <-ctx.Done():
+			logger.InfoContext(ctx, "mock smtp server stopped")
+			return
+		case <-ticker.C:
+		}
+
+		received := server.Received()
+		for _, mail := range received[logged:] {
+			logger.InfoContext(ctx, "email received", slog.String("from", mail.From), slog.Any("to", mail.To), slog.String("data", string(mail.Data)))
+		}
+		logged = len(received)
+	}
+}
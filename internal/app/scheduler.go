@@ -0,0 +1,77 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rahadianir/dealls/internal/attendance"
+	"github.com/rahadianir/dealls/internal/audit"
+	"github.com/rahadianir/dealls/internal/company"
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/notification"
+	"github.com/rahadianir/dealls/internal/payroll"
+	"github.com/rahadianir/dealls/internal/pkg/logger"
+	"github.com/rahadianir/dealls/internal/pkg/xnotify"
+	"github.com/rahadianir/dealls/internal/user"
+	"github.com/rahadianir/dealls/internal/webhook"
+)
+
+// RunScheduler runs the payroll period scheduler once and exits, it's meant to be run periodically by cron
+func RunScheduler(preview bool) {
+	ctx := context.Background()
+
+	// setup config
+	cfg := config.InitConfig(ctx)
+
+	// init logger
+	logger := logger.InitLogger()
+
+	// init database connection pool
+	db, err := sqlx.Open("postgres", cfg.DB.URL)
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to open db connection", slog.Any("error", err))
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	err = db.Ping()
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to ping db connection", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	notifier, err := xnotify.NewNotifier(cfg.Notifier, logger)
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to init notifier", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	deps := config.CommonDependencies{
+		Config: cfg,
+		DB:     db,
+		Logger: logger,
+	}
+
+	// wiring layers
+	companyRepo := company.NewCompanyRepository(&deps)
+	userRepo := user.NewUserRepository(&deps)
+	webhookRepo := webhook.NewWebhookRepository(&deps)
+	attRepo := attendance.NewAttendanceRepository(&deps, webhookRepo)
+	payrollRepo := payroll.NewPayrollRepository(&deps, webhookRepo)
+	auditRepo := audit.NewAuditRepository(&deps)
+	notificationRepo := notification.NewNotificationRepository(&deps)
+	payrollLogic := payroll.NewPayrollLogic(&deps, payrollRepo, userRepo, attRepo, auditRepo, notificationRepo)
+	scheduler := payroll.NewPayrollScheduler(&deps, payrollRepo, userRepo, companyRepo, payrollLogic, notifier, auditRepo)
+
+	err = scheduler.Run(ctx, time.Now(), preview || cfg.Payroll.SchedulePreview)
+	if err != nil {
+		logger.ErrorContext(ctx, "payroll scheduler finished with errors", slog.Any("error", err))
+		db.Close()
+		os.Exit(1)
+	}
+
+	logger.InfoContext(ctx, "payroll scheduler finished")
+}
@@ -7,17 +7,28 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
+	"github.com/rahadianir/dealls/internal/apikey"
 	"github.com/rahadianir/dealls/internal/attendance"
+	"github.com/rahadianir/dealls/internal/audit"
+	"github.com/rahadianir/dealls/internal/company"
 	"github.com/rahadianir/dealls/internal/config"
 	"github.com/rahadianir/dealls/internal/middleware"
+	"github.com/rahadianir/dealls/internal/notification"
 	"github.com/rahadianir/dealls/internal/payroll"
 	"github.com/rahadianir/dealls/internal/pkg/logger"
 	"github.com/rahadianir/dealls/internal/pkg/xjwt"
+	"github.com/rahadianir/dealls/internal/pkg/xmail"
+	"github.com/rahadianir/dealls/internal/pkg/xoidc"
+	"github.com/rahadianir/dealls/internal/pkg/xstorage"
+	"github.com/rahadianir/dealls/internal/signingkey"
 	"github.com/rahadianir/dealls/internal/user"
+	"github.com/rahadianir/dealls/internal/webhook"
 )
 
 func StartServer() {
@@ -30,6 +41,16 @@ func StartServer() {
 	// init logger
 	logger := logger.InitLogger()
 
+	// the encryption key protects the stored secrets, the well known default is only good enough for local development
+	if !cfg.App.IsDebug && cfg.App.EncryptionKey == config.DefaultEncryptionKey {
+		logger.ErrorContext(ctx, "refusing to start with the default ENCRYPTION_KEY outside debug mode")
+		os.Exit(1)
+	}
+	if !cfg.App.IsDebug && cfg.Payroll.PayslipSigningSecret == config.DefaultPayslipSigningSecret {
+		logger.ErrorContext(ctx, "refusing to start with the default PAYROLL_PAYSLIP_SIGNING_SECRET outside debug mode")
+		os.Exit(1)
+	}
+
 	// init database connection pool
 	db, err := sqlx.Open("postgres", cfg.DB.URL)
 	if err != nil {
@@ -44,14 +65,50 @@ func StartServer() {
 		os.Exit(1)
 	}
 
+	// init blob storage for uploaded files
+	storage, err := xstorage.NewBlobStorage(cfg.Storage)
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to init blob storage", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	// init mail sender for the queued email notifications
+	sender, err := xmail.NewSender(cfg.Mail, logger)
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to init mail sender", slog.Any("error", err))
+		os.Exit(1)
+	}
+
 	deps := config.CommonDependencies{
 		Config: cfg,
 		DB:     db,
 		Logger: logger,
 	}
 
+	// init the identity provider for single sign-on, left nil when it isn't configured
+	var identityProvider xoidc.Provider
+	if cfg.OIDC.Issuer != "" {
+		identityProvider = xoidc.NewClient(cfg.OIDC)
+	}
+
 	// init http routes
-	routes := initRoutes(&deps)
+	routes, workers, err := initRoutes(ctx, &deps, storage, sender, identityProvider)
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to init routes", slog.Any("error", err))
+		db.Close()
+		os.Exit(1)
+	}
+
+	// run background workers until the server shuts down
+	workerCtx, stopWorkers := context.WithCancel(ctx)
+	var workerWG sync.WaitGroup
+	for _, worker := range workers {
+		workerWG.Add(1)
+		go func() {
+			defer workerWG.Done()
+			worker.Start(workerCtx)
+		}()
+	}
 
 	// setup server
 	var srv http.Server
@@ -65,6 +122,8 @@ func StartServer() {
 
 		// We received an interrupt signal, shutting down.
 		logger.InfoContext(ctx, "HTTP Server is shutting down")
+		stopWorkers()
+		workerWG.Wait()
 		db.Close()
 		if err := srv.Shutdown(ctx); err != nil {
 			// Error from closing listeners, or context timeout:
@@ -87,47 +146,159 @@ func StartServer() {
 	logger.InfoContext(ctx, "Bye!")
 }
 
-func initRoutes(deps *config.CommonDependencies) http.Handler {
+// backgroundWorker is a long running process started along with the HTTP server
+type backgroundWorker interface {
+	Start(ctx context.Context)
+}
+
+func initRoutes(ctx context.Context, deps *config.CommonDependencies, storage xstorage.BlobStorage, sender xmail.Sender, identityProvider xoidc.Provider) (http.Handler, []backgroundWorker, error) {
 	// wiring layers
 	// shared packages
 	jwtHelper := &xjwt.XJWT{}
 
 	// repository
+	companyRepo := company.NewCompanyRepository(deps)
 	userRepo := user.NewUserRepository(deps)
-	attRepo := attendance.NewAttendanceRepository(deps)
-	payrollRepo := payroll.NewPayrollRepository(deps)
+	webhookRepo := webhook.NewWebhookRepository(deps)
+	attRepo := attendance.NewAttendanceRepository(deps, webhookRepo)
+	payrollRepo := payroll.NewPayrollRepository(deps, webhookRepo)
+	keyRepo := signingkey.NewSigningKeyRepository(deps)
+	apiKeyRepo := apikey.NewAPIKeyRepository(deps)
+	auditRepo := audit.NewAuditRepository(deps)
+	notificationRepo := notification.NewNotificationRepository(deps)
 
 	// logic
-	userLogic := user.NewUserLogic(deps, userRepo, jwtHelper)
-	attLogic := attendance.NewAttendanceLogic(deps, attRepo)
-	payrollLogic := payroll.NewPayrollLogic(deps, payrollRepo, userRepo, attRepo)
+	keyLogic := signingkey.NewSigningKeyLogic(deps, keyRepo, companyRepo, jwtHelper, auditRepo)
+	userLogic := user.NewUserLogic(deps, userRepo, companyRepo, jwtHelper, notificationRepo, identityProvider, auditRepo)
+	attLogic := attendance.NewAttendanceLogic(deps, attRepo, userRepo, storage, auditRepo, notificationRepo)
+	payrollLogic := payroll.NewPayrollLogic(deps, payrollRepo, userRepo, attRepo, auditRepo, notificationRepo)
+	apiKeyLogic := apikey.NewAPIKeyLogic(deps, apiKeyRepo, userRepo, auditRepo)
+	auditLogic := audit.NewAuditLogic(deps, auditRepo, userRepo)
+	webhookLogic := webhook.NewWebhookLogic(deps, webhookRepo, userRepo, auditRepo)
+	notificationLogic := notification.NewNotificationLogic(deps, notificationRepo, sender)
 
 	// handler
 	userHandler := user.NewUserHandler(deps, userLogic)
 	attHandler := attendance.NewAttendanceHandler(deps, attLogic)
 	payrollHandler := payroll.NewPayrollHandler(deps, payrollLogic)
+	keyHandler := signingkey.NewSigningKeyHandler(deps, keyLogic)
+	apiKeyHandler := apikey.NewAPIKeyHandler(deps, apiKeyLogic)
+	auditHandler := audit.NewAuditHandler(deps, auditLogic)
+	webhookHandler := webhook.NewWebhookHandler(deps, webhookLogic)
+
+	// tokens can't be issued or verified until the signing keys are loaded
+	err := keyLogic.RotateKeys(ctx, time.Now())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load signing keys: %w", err)
+	}
+
+	// background workers
+	payrollWorker := payroll.NewPayrollJobWorker(deps, payrollRepo, payrollLogic)
+	keyWorker := signingkey.NewKeyRotationWorker(deps, keyLogic)
+	webhookDispatcher := webhook.NewWebhookDispatcher(deps, webhookRepo, webhookLogic)
+	emailDispatcher := notification.NewEmailDispatcher(deps, notificationRepo, notificationLogic)
 
 	// setup middlewares
-	authMW := middleware.NewAuthMiddleware(deps, jwtHelper)
+	authMW := middleware.NewAuthMiddleware(deps, jwtHelper, userRepo, apiKeyLogic)
 	traceMW := middleware.TracerMiddleware{}
 	r := chi.NewRouter()
 
 	r.Use(traceMW.Tracer)
 
 	r.Post("/login", userHandler.Login)
+	r.Post("/login/two-factor", userHandler.VerifyTwoFactorLogin)
+	r.Post("/token/refresh", userHandler.RefreshToken)
+	r.Get("/.well-known/jwks.json", keyHandler.GetJWKS)
+	r.Post("/password/reset", userHandler.ResetPassword)
+	r.Get("/sso/login", userHandler.StartSSOLogin)
+	r.Get("/sso/callback", userHandler.CompleteSSOLogin)
 
 	r.Group(func(r chi.Router) {
 		r.Use(authMW.AuthOnly) // check whether the user is logged in with proper auth and embed user id in context
-		r.Post("/attendance", attHandler.SubmitAttendance)
-		r.Post("/overtime", attHandler.SubmitOvertime)
-		r.Post("/reimbursement", attHandler.SubmitReimbursement)
+		r.Post("/logout", userHandler.Logout)
+		r.Post("/password/change", userHandler.ChangePassword)
+		r.Post("/two-factor/enrolment", userHandler.EnrolTwoFactor)
+		r.Post("/two-factor/confirmation", userHandler.ConfirmTwoFactor)
+
+		r.Group(func(r chi.Router) {
+			r.Use(authMW.PasswordChanged)   // users with a temporary password can only change it
+			r.Use(authMW.TwoFactorEnrolled) // users required to enable two-factor authentication can only enrol
+			r.Delete("/two-factor", userHandler.DisableTwoFactor)
+			r.Post("/users/{id}/password/reset", userHandler.RequestPasswordReset)
+			r.Post("/users/{id}/unlock", userHandler.UnlockUser)
+			r.Put("/users/{id}/two-factor/requirement", userHandler.SetTwoFactorRequirement)
+			r.Delete("/users/{id}/two-factor", userHandler.ResetTwoFactor)
+
+			r.Post("/api-keys", apiKeyHandler.CreateAPIKey)
+			r.Get("/api-keys", apiKeyHandler.GetAPIKeys)
+			r.Delete("/api-keys/{id}", apiKeyHandler.RevokeAPIKey)
+
+			r.Post("/webhooks", webhookHandler.CreateSubscription)
+			r.Get("/webhooks", webhookHandler.GetSubscriptions)
+			r.Delete("/webhooks/{id}", webhookHandler.DeleteSubscription)
+			r.Get("/webhooks/{id}/deliveries", webhookHandler.GetDeliveries)
+
+			r.Get("/audit-logs", auditHandler.GetAuditLogs)
+
+			r.Post("/attendance", attHandler.SubmitAttendance)
+			r.Post("/overtime", attHandler.SubmitOvertime)
+			r.Post("/leave", attHandler.SubmitLeave)
+			r.Post("/reimbursement", attHandler.SubmitReimbursement)
+			r.Post("/reimbursement/{id}/receipts", attHandler.UploadReimbursementReceipts)
+
+			r.Get("/payslip", payrollHandler.GetUserPayslip)
+		})
+	})
+
+	// integrations can call these with an API key having the scope too
+	r.Group(func(r chi.Router) {
+		r.Use(authMW.AuthOrAPIKey)
+		r.Use(authMW.PasswordChanged)
+		r.Use(authMW.TwoFactorEnrolled)
+
+		r.Group(func(r chi.Router) {
+			r.Use(authMW.RequireScope(apikey.ScopeUsersWrite))
+			r.Put("/users/{id}/employment", userHandler.SetEmployment)
+			r.Post("/users/{id}/termination", userHandler.TerminateEmployment)
+			r.Put("/users/{id}/salary", userHandler.SetSalary)
+			r.Put("/users/{id}/email", userHandler.SetEmail)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(authMW.RequireScope(apikey.ScopeReimbursementRead))
+			r.Get("/reimbursement/categories", attHandler.GetReimbursementCategories)
+			r.Get("/reimbursement/{id}/receipts/{receiptID}", attHandler.DownloadReimbursementReceipt)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(authMW.RequireScope(apikey.ScopeReimbursementWrite))
+			r.Put("/reimbursement/categories/{code}", attHandler.SetReimbursementCategory)
+		})
 
-		r.Post("/payroll/period", payrollHandler.SetPayrollPeriod)
-		r.Post("/payroll/calculate", payrollHandler.CalculatePayroll)
-		r.Get("/payroll/summary", payrollHandler.GeneratePayrollSummary)
+		r.Group(func(r chi.Router) {
+			r.Use(authMW.RequireScope(apikey.ScopePayrollRead))
+			r.Get("/payroll/jobs/{id}", payrollHandler.GetPayrollJob)
+			r.Get("/payroll/summary", payrollHandler.GeneratePayrollSummary)
+			r.Get("/payroll/groups", payrollHandler.GetPayGroups)
+			r.Get("/payroll/preview", payrollHandler.PreviewPayroll)
+			r.Get("/payroll/periods/{id}/exchange-rates", payrollHandler.GetExchangeRates)
+			r.Get("/payroll/periods/{id}/verification", payrollHandler.VerifyPayslips)
+			r.Get("/payroll/periods/{id}/journal", payrollHandler.GetPayrollJournal)
+			r.Get("/payroll/journal-accounts", payrollHandler.GetJournalAccounts)
+		})
 
-		r.Get("/payslip", payrollHandler.GetUserPayslip)
+		r.Group(func(r chi.Router) {
+			r.Use(authMW.RequireScope(apikey.ScopePayrollWrite))
+			r.Post("/payroll/period", payrollHandler.SetPayrollPeriod)
+			r.Post("/payroll/calculate", payrollHandler.CalculatePayroll)
+			r.Post("/payroll/deductions", payrollHandler.AddDeduction)
+			r.Post("/payroll/groups", payrollHandler.CreatePayGroup)
+			r.Put("/payroll/groups/{id}", payrollHandler.UpdatePayGroup)
+			r.Put("/payroll/groups/{id}/users", payrollHandler.AssignPayGroupUsers)
+			r.Put("/payroll/periods/{id}/exchange-rates", payrollHandler.SetExchangeRates)
+			r.Put("/payroll/journal-accounts", payrollHandler.SetJournalAccounts)
+		})
 	})
 
-	return r
+	return r, []backgroundWorker{payrollWorker, keyWorker, webhookDispatcher, emailDispatcher}, nil
 }
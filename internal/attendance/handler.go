@@ -1,9 +1,18 @@
 package attendance
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"strconv"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/models"
 	"github.com/rahadianir/dealls/internal/pkg/xerror"
 	"github.com/rahadianir/dealls/internal/pkg/xhttp"
 )
@@ -71,8 +80,8 @@ func (h *AttendanceHandler) SubmitOvertime(w http.ResponseWriter, r *http.Reques
 
 }
 
-func (h *AttendanceHandler) SubmitReimbursement(w http.ResponseWriter, r *http.Request) {
-	var payload ReimbursementRequest
+func (h *AttendanceHandler) SubmitLeave(w http.ResponseWriter, r *http.Request) {
+	var payload LeaveRequest
 	err := xhttp.BindJSONRequest(r, &payload)
 	if err != nil {
 		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
@@ -82,7 +91,77 @@ func (h *AttendanceHandler) SubmitReimbursement(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	err = h.attLogic.SubmitReimbursement(r.Context(), payload.UserID, payload.Amount, payload.Description)
+	paid := true
+	if payload.Paid != nil {
+		paid = *payload.Paid
+	}
+
+	id, err := h.attLogic.SubmitLeave(r.Context(), payload.UserID, payload.StartDate, payload.EndDate, paid, payload.Reason)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to submit leave",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "leave submitted",
+		Data:    LeaveResponse{ID: id},
+	}, http.StatusCreated)
+}
+
+func (h *AttendanceHandler) SubmitReimbursement(w http.ResponseWriter, r *http.Request) {
+	var payload ReimbursementRequest
+	var receipts []ReceiptUpload
+
+	// receipts can only be sent through multipart form, plain JSON is still accepted for reimbursement without receipt
+	if isMultipartRequest(r) {
+		form, err := h.parseReceiptForm(w, r)
+		if err != nil {
+			xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+				Error:   err.Error(),
+				Message: xerror.ErrBadRequest.Error(),
+			}, http.StatusBadRequest)
+			return
+		}
+		defer form.RemoveAll()
+
+		payload.UserID = firstFormValue(form, "user_id")
+		payload.Description = firstFormValue(form, "description")
+		payload.Category = firstFormValue(form, "category")
+		payload.Currency = firstFormValue(form, "currency")
+		payload.Amount, err = strconv.ParseFloat(firstFormValue(form, "amount"), 64)
+		if err != nil {
+			xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+				Error:   fmt.Errorf("invalid amount: %w", err).Error(),
+				Message: xerror.ErrBadRequest.Error(),
+			}, http.StatusBadRequest)
+			return
+		}
+
+		var closeReceipts func()
+		receipts, closeReceipts, err = openReceiptUploads(form)
+		if err != nil {
+			xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+				Error:   err.Error(),
+				Message: xerror.ErrBadRequest.Error(),
+			}, http.StatusBadRequest)
+			return
+		}
+		defer closeReceipts()
+	} else {
+		err := xhttp.BindJSONRequest(r, &payload)
+		if err != nil {
+			xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+				Error:   err.Error(),
+				Message: xerror.ErrBadRequest.Error(),
+			}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	id, err := h.attLogic.SubmitReimbursement(r.Context(), payload.UserID, payload.Amount, payload.Currency, payload.Description, payload.Category, receipts)
 	if err != nil {
 		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
 			Error:   err.Error(),
@@ -93,5 +172,188 @@ func (h *AttendanceHandler) SubmitReimbursement(w http.ResponseWriter, r *http.R
 
 	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
 		Message: "reimbursement submitted",
+		Data: ReimbursementResponse{
+			ID: id,
+		},
 	}, http.StatusCreated)
 }
+
+func (h *AttendanceHandler) UploadReimbursementReceipts(w http.ResponseWriter, r *http.Request) {
+	if !isMultipartRequest(r) {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   "receipts must be sent as multipart/form-data",
+			Message: xerror.ErrBadRequest.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	form, err := h.parseReceiptForm(w, r)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: xerror.ErrBadRequest.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+	defer form.RemoveAll()
+
+	receipts, closeReceipts, err := openReceiptUploads(form)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: xerror.ErrBadRequest.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+	defer closeReceipts()
+
+	stored, err := h.attLogic.UploadReimbursementReceipts(r.Context(), chi.URLParam(r, "id"), receipts)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to upload reimbursement receipts",
+		}, xerror.ParseErrorTypeToCodeInt(err))
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "reimbursement receipts uploaded",
+		Data: ReceiptUploadResponse{
+			Receipts: stored,
+		},
+	}, http.StatusCreated)
+}
+
+func (h *AttendanceHandler) DownloadReimbursementReceipt(w http.ResponseWriter, r *http.Request) {
+	receipt, content, err := h.attLogic.GetReimbursementReceipt(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "receiptID"))
+	if err != nil {
+		code := xerror.ParseErrorTypeToCodeInt(err)
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			code = http.StatusNotFound
+		}
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to download reimbursement receipt",
+		}, code)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", receipt.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": receipt.FileName}))
+	w.Header().Set("Content-Length", strconv.FormatInt(receipt.Size, 10))
+	w.WriteHeader(http.StatusOK)
+
+	_, err = io.Copy(w, content)
+	if err != nil {
+		h.deps.Logger.WarnContext(r.Context(), "failed to stream reimbursement receipt", slog.Any("error", err))
+	}
+}
+
+func (h *AttendanceHandler) GetReimbursementCategories(w http.ResponseWriter, r *http.Request) {
+	data, err := h.attLogic.GetReimbursementCategories(r.Context())
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to get reimbursement categories",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "reimbursement categories fetched",
+		Data:    data,
+	}, http.StatusOK)
+}
+
+func (h *AttendanceHandler) SetReimbursementCategory(w http.ResponseWriter, r *http.Request) {
+	var payload ReimbursementCategoryRequest
+	err := xhttp.BindJSONRequest(r, &payload)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: xerror.ErrBadRequest.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	err = h.attLogic.SetReimbursementCategory(r.Context(), models.ReimbursementCategory{
+		Code:            chi.URLParam(r, "code"),
+		Name:            payload.Name,
+		PerClaimLimit:   payload.PerClaimLimit,
+		PerPeriodLimit:  payload.PerPeriodLimit,
+		ReceiptRequired: payload.ReceiptRequired,
+		Taxable:         payload.Taxable,
+	})
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to set reimbursement category",
+		}, xerror.ParseErrorTypeToCodeInt(err))
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "reimbursement category set",
+	}, http.StatusOK)
+}
+
+// parseReceiptForm parses the multipart body while capping the whole request size
+func (h *AttendanceHandler) parseReceiptForm(w http.ResponseWriter, r *http.Request) (*multipart.Form, error) {
+	maxSize := int64(h.deps.Config.Storage.MaxReceiptSize)
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize*maxReceiptsPerRequest+(1<<20))
+
+	err := r.ParseMultipartForm(maxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(r.MultipartForm.File["receipts"]) > maxReceiptsPerRequest {
+		r.MultipartForm.RemoveAll()
+		return nil, fmt.Errorf("cannot upload more than %d receipts at once", maxReceiptsPerRequest)
+	}
+
+	return r.MultipartForm, nil
+}
+
+const maxReceiptsPerRequest = 5
+
+func isMultipartRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "multipart/form-data"
+}
+
+func firstFormValue(form *multipart.Form, key string) string {
+	if values := form.Value[key]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// openReceiptUploads opens every file sent in the receipts field, the returned func closes all of them
+func openReceiptUploads(form *multipart.Form) ([]ReceiptUpload, func(), error) {
+	var files []multipart.File
+	closeAll := func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}
+
+	var result []ReceiptUpload
+	for _, header := range form.File["receipts"] {
+		file, err := header.Open()
+		if err != nil {
+			closeAll()
+			return nil, func() {}, fmt.Errorf("failed to open receipt %s: %w", header.Filename, err)
+		}
+		files = append(files, file)
+
+		result = append(result, ReceiptUpload{
+			FileName: header.Filename,
+			Size:     header.Size,
+			Content:  file,
+		})
+	}
+
+	return result, closeAll, nil
+}
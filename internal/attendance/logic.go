@@ -2,26 +2,46 @@ package attendance
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
-	"strings"
+	"net/http"
+	"path/filepath"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/models"
+	"github.com/rahadianir/dealls/internal/pkg/xaudit"
+	"github.com/rahadianir/dealls/internal/pkg/xcontext"
+	"github.com/rahadianir/dealls/internal/pkg/xcurrency"
+	"github.com/rahadianir/dealls/internal/pkg/xdate"
 	"github.com/rahadianir/dealls/internal/pkg/xerror"
+	"github.com/rahadianir/dealls/internal/pkg/xmail"
+	"github.com/rahadianir/dealls/internal/pkg/xstorage"
+	"github.com/rahadianir/dealls/internal/user"
 )
 
 type AttendanceLogic struct {
-	deps    *config.CommonDependencies
-	attRepo AttendanceRepositoryInterface
-	today   time.Time // putting it here so it's easier to be mocked/tested
+	deps     *config.CommonDependencies
+	attRepo  AttendanceRepositoryInterface
+	userRepo user.UserRepositoryInterface
+	storage  xstorage.BlobStorage
+	auditor  xaudit.Recorder
+	mails    xmail.Queue
+	now      func() time.Time // putting it here so it's easier to be mocked/tested
 }
 
-func NewAttendanceLogic(deps *config.CommonDependencies, attRepo AttendanceRepositoryInterface) *AttendanceLogic {
+func NewAttendanceLogic(deps *config.CommonDependencies, attRepo AttendanceRepositoryInterface, userRepo user.UserRepositoryInterface, storage xstorage.BlobStorage, auditor xaudit.Recorder, mails xmail.Queue) *AttendanceLogic {
 	return &AttendanceLogic{
-		deps:    deps,
-		attRepo: attRepo,
-		today:   time.Now(),
+		deps:     deps,
+		attRepo:  attRepo,
+		userRepo: userRepo,
+		storage:  storage,
+		auditor:  auditor,
+		mails:    mails,
+		now:      time.Now,
 	}
 }
 
@@ -33,15 +53,29 @@ func (logic *AttendanceLogic) SubmitAttendance(ctx context.Context, userID strin
 		return xerror.ClientError{Err: err}
 	}
 
+	// days and hours are the user's local ones, whatever offset the timestamp was sent with
+	loc, err := logic.userLocation(ctx, userID)
+	if err != nil {
+		return err
+	}
+	submittedTime = submittedTime.In(loc)
+
 	// check whether today is weekend, cuz it's not allowed to submit on weekend
 	// check whether submitted day is working hours/day
-	today := strings.ToLower(logic.today.Weekday().String())
-	submittedTimeDay := strings.ToLower(submittedTime.Weekday().String())
-	if today == "saturday" || today == "sunday" || submittedTimeDay == "saturday" || submittedTimeDay == "sunday" {
+	today := logic.now().In(loc).Weekday()
+	submittedTimeDay := submittedTime.Weekday()
+	if today == time.Saturday || today == time.Sunday || submittedTimeDay == time.Saturday || submittedTimeDay == time.Sunday {
 		return xerror.ClientError{Err: fmt.Errorf("cannot submit attendance in weekend")}
 	}
 
-	err = logic.attRepo.SubmitAttendance(ctx, userID, submittedTime)
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionCreate,
+		Entity:   xaudit.EntityAttendance,
+		EntityID: userID,
+		After:    map[string]any{"date": xdate.Of(submittedTime), "submitted_at": submittedTime},
+	}, func(ctx context.Context) error {
+		return logic.attRepo.SubmitAttendance(ctx, userID, submittedTime, xdate.Of(submittedTime))
+	})
 	if err != nil {
 		logic.deps.Logger.ErrorContext(ctx, "failed to submit attendance", slog.Any("error", err))
 		return err
@@ -58,6 +92,14 @@ func (logic *AttendanceLogic) SubmitOvertime(ctx context.Context, userID string,
 		return xerror.ClientError{Err: err}
 	}
 
+	// days and hours are the user's local ones, whatever offset the timestamp was sent with
+	loc, err := logic.userLocation(ctx, userID)
+	if err != nil {
+		return err
+	}
+	submittedTime = submittedTime.In(loc)
+	overtimeDate := xdate.Of(submittedTime)
+
 	// check whether overtime is submitted after work hours/day
 	submittedDay := submittedTime.Weekday()
 	submittedHour := submittedTime.Hour()
@@ -66,22 +108,24 @@ func (logic *AttendanceLogic) SubmitOvertime(ctx context.Context, userID string,
 		// if overtime is submitted for work days,
 		// then check whether the submitted time is already past work time
 		if submittedHour >= 9 && submittedHour < 17 {
-			return xerror.ClientError{Err: fmt.Errorf("overtime must be submitted outside working hours")}
+			return logic.rejectOvertime(ctx, userID, overtimeDate, hourCount, fmt.Errorf("overtime must be submitted outside working hours"))
 		}
 
 		// check whether the submitted hours is actual from the last working hours
 		// if it's submitted for a different day overtime
+		// overtime finished after midnight belongs to the previous day
 		if submittedHour < 9 {
 			submittedHour += 24
+			overtimeDate = overtimeDate.AddDays(-1)
 		}
 		if submittedHour-hourCount < 17 {
-			return xerror.ClientError{Err: fmt.Errorf("overtime hours overlapped with working hours")}
+			return logic.rejectOvertime(ctx, userID, overtimeDate, hourCount, fmt.Errorf("overtime hours overlapped with working hours"))
 		}
 
 	}
 
 	// get current overtime hours on that day
-	currentOvtHours, err := logic.attRepo.GetUserOvertimeByTime(ctx, userID, submittedTime)
+	currentOvtHours, err := logic.attRepo.GetUserOvertimeByTime(ctx, userID, overtimeDate)
 	if err != nil {
 		logic.deps.Logger.ErrorContext(ctx, "failed to get user's overtime hours", slog.Any("error", err))
 		return err
@@ -89,23 +133,457 @@ func (logic *AttendanceLogic) SubmitOvertime(ctx context.Context, userID string,
 
 	// check whether total overtime hours exceed 3 hours
 	if (currentOvtHours + hourCount) > 3 {
-		return xerror.ClientError{Err: fmt.Errorf("overtime hours per day cannot exceed 3 hours")}
+		return logic.rejectOvertime(ctx, userID, overtimeDate, hourCount, fmt.Errorf("overtime hours per day cannot exceed 3 hours"))
 	}
 
-	err = logic.attRepo.SubmitOvertime(ctx, userID, hourCount, submittedTime)
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionCreate,
+		Entity:   xaudit.EntityOvertime,
+		EntityID: userID,
+		After:    map[string]any{"date": overtimeDate, "hours": hourCount},
+	}, func(ctx context.Context) error {
+		return logic.attRepo.SubmitOvertime(ctx, userID, hourCount, overtimeDate)
+	})
 	if err != nil {
 		logic.deps.Logger.ErrorContext(ctx, "failed to submit overtime hours", slog.Any("error", err))
 		return err
 	}
 
+	// there's no approval step, overtime is approved once it passes the rules on submission
+	xmail.Enqueue(ctx, logic.mails, logic.deps.Logger, xmail.Email{
+		UserID:   userID,
+		Template: xmail.TemplateOvertimeDecision,
+		Data:     xmail.OvertimeDecisionData{Date: overtimeDate.String(), Hours: hourCount, Approved: true},
+	})
+
 	return nil
 }
 
-func (logic *AttendanceLogic) SubmitReimbursement(ctx context.Context, userID string, amount float64, desc string) error {
-	err := logic.attRepo.SubmitReimbursement(ctx, userID, amount, desc)
+// rejectOvertime tells the user why the overtime was rejected, returning the reason as a client error
+func (logic *AttendanceLogic) rejectOvertime(ctx context.Context, userID string, date xdate.Date, hourCount int, reason error) error {
+	xmail.Enqueue(ctx, logic.mails, logic.deps.Logger, xmail.Email{
+		UserID:   userID,
+		Template: xmail.TemplateOvertimeDecision,
+		Data:     xmail.OvertimeDecisionData{Date: date.String(), Hours: hourCount, Reason: reason.Error()},
+	})
+
+	return xerror.ClientError{Err: reason}
+}
+
+// userLocation returns the user's timezone, or the company timezone when the user has none
+func (logic *AttendanceLogic) userLocation(ctx context.Context, userID string) (*time.Location, error) {
+	employments, err := logic.userRepo.GetUsersEmploymentByIDs(ctx, []string{userID})
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get user employment", slog.Any("error", err))
+		return nil, err
+	}
+
+	if len(employments) == 0 || employments[0].Timezone == "" {
+		return logic.deps.Config.App.Timezone, nil
+	}
+
+	loc, err := time.LoadLocation(employments[0].Timezone)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to load user timezone", slog.String("timezone", employments[0].Timezone), slog.Any("error", err))
+		return nil, err
+	}
+
+	return loc, nil
+}
+
+func (logic *AttendanceLogic) SubmitReimbursement(ctx context.Context, userID string, amount float64, currency string, desc string, category string, receipts []ReceiptUpload) (string, error) {
+	if amount <= 0 {
+		return "", xerror.ClientError{Err: fmt.Errorf("reimbursement amount must be greater than 0")}
+	}
+
+	baseCurrency := logic.deps.Config.Payroll.BaseCurrency
+	if currency == "" {
+		currency = baseCurrency
+	}
+	currency, err := xcurrency.Normalize(currency)
+	if err != nil {
+		return "", xerror.ClientError{Err: err}
+	}
+
+	if category == "" {
+		return "", xerror.ClientError{Err: fmt.Errorf("reimbursement category is required")}
+	}
+
+	// validate the claim against the category policy
+	policy, err := logic.attRepo.GetReimbursementCategoryByCode(ctx, category)
+	if err != nil {
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			return "", xerror.ClientError{Err: fmt.Errorf("unknown reimbursement category: %s", category)}
+		}
+		logic.deps.Logger.ErrorContext(ctx, "failed to get reimbursement category", slog.Any("error", err))
+		return "", err
+	}
+
+	claim := xmail.ReimbursementData{Amount: amount, Currency: currency, Category: policy.Code, Description: desc}
+
+	// limits are in the base currency, claims in other currencies are converted with the active period exchange rate
+	baseAmount := amount
+	if currency != baseCurrency && (policy.PerClaimLimit != nil || policy.PerPeriodLimit != nil) {
+		rate, err := logic.attRepo.GetUserActivePeriodExchangeRate(ctx, userID, currency)
+		if err != nil {
+			if errors.Is(err, xerror.ErrDataNotFound) {
+				return "", xerror.ClientError{Err: fmt.Errorf("exchange rate of %s is not set for the active payroll period", currency)}
+			}
+			logic.deps.Logger.ErrorContext(ctx, "failed to get active payroll period exchange rate", slog.Any("error", err))
+			return "", err
+		}
+		baseAmount = amount * rate
+	}
+
+	if policy.PerClaimLimit != nil && baseAmount > *policy.PerClaimLimit {
+		return "", logic.rejectReimbursement(ctx, userID, claim, fmt.Errorf("%s reimbursement cannot exceed %.2f %s per claim", policy.Code, *policy.PerClaimLimit, baseCurrency))
+	}
+
+	if policy.ReceiptRequired && len(receipts) == 0 {
+		return "", logic.rejectReimbursement(ctx, userID, claim, fmt.Errorf("%s reimbursement requires at least one receipt", policy.Code))
+	}
+
+	if policy.PerPeriodLimit != nil {
+		claimed, err := logic.attRepo.GetUserReimbursementTotalInActivePeriod(ctx, userID, policy.ID)
+		if err != nil {
+			logic.deps.Logger.ErrorContext(ctx, "failed to get user's reimbursement total in active period", slog.Any("error", err))
+			return "", err
+		}
+
+		if claimed+baseAmount > *policy.PerPeriodLimit {
+			return "", logic.rejectReimbursement(ctx, userID, claim, fmt.Errorf("%s reimbursement cannot exceed %.2f %s per payroll period, %.2f already claimed", policy.Code, *policy.PerPeriodLimit, baseCurrency, claimed))
+		}
+	}
+
+	reimbursementID := uuid.NewString()
+
+	// upload receipts first, the rows are only stored once all files are safely in the storage
+	storedReceipts, err := logic.storeReceipts(ctx, reimbursementID, receipts)
+	if err != nil {
+		return "", err
+	}
+
+	data := models.Reimbursement{
+		ID:          reimbursementID,
+		UserID:      userID,
+		Amount:      amount,
+		Currency:    currency,
+		Description: desc,
+		CategoryID:  policy.ID,
+		Category:    policy.Code,
+		Receipts:    storedReceipts,
+	}
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionCreate,
+		Entity:   xaudit.EntityReimbursement,
+		EntityID: reimbursementID,
+		After:    data,
+	}, func(ctx context.Context) error {
+		return logic.attRepo.SubmitReimbursement(ctx, data)
+	})
 	if err != nil {
 		logic.deps.Logger.ErrorContext(ctx, "failed to submit reimbursement", slog.Any("error", err))
+		logic.removeReceipts(ctx, storedReceipts)
+		return "", err
+	}
+
+	// there's no approval step, a claim is approved once it passes the category policy on submission
+	claim.ID = reimbursementID
+	xmail.Enqueue(ctx, logic.mails, logic.deps.Logger, xmail.Email{
+		UserID:   userID,
+		Template: xmail.TemplateReimbursementApproved,
+		Data:     claim,
+	})
+
+	return reimbursementID, nil
+}
+
+// rejectReimbursement tells the user why the claim was rejected by its category policy, returning the reason as
+// a client error
+func (logic *AttendanceLogic) rejectReimbursement(ctx context.Context, userID string, claim xmail.ReimbursementData, reason error) error {
+	claim.Reason = reason.Error()
+	xmail.Enqueue(ctx, logic.mails, logic.deps.Logger, xmail.Email{
+		UserID:   userID,
+		Template: xmail.TemplateReimbursementRejected,
+		Data:     claim,
+	})
+
+	return xerror.ClientError{Err: reason}
+}
+
+func (logic *AttendanceLogic) UploadReimbursementReceipts(ctx context.Context, reimbursementID string, receipts []ReceiptUpload) ([]models.ReimbursementReceipt, error) {
+	if len(receipts) == 0 {
+		return nil, xerror.ClientError{Err: fmt.Errorf("no receipt uploaded")}
+	}
+
+	reimbursement, err := logic.attRepo.GetReimbursementByID(ctx, reimbursementID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get reimbursement", slog.Any("error", err))
+		return nil, err
+	}
+
+	err = logic.checkReimbursementAccess(ctx, reimbursement)
+	if err != nil {
+		return nil, err
+	}
+
+	storedReceipts, err := logic.storeReceipts(ctx, reimbursement.ID, receipts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionUploadReceipts,
+		Entity:   xaudit.EntityReimbursement,
+		EntityID: reimbursement.ID,
+		After:    storedReceipts,
+	}, func(ctx context.Context) error {
+		return logic.attRepo.StoreReimbursementReceipts(ctx, storedReceipts)
+	})
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to store reimbursement receipts", slog.Any("error", err))
+		logic.removeReceipts(ctx, storedReceipts)
+		return nil, err
+	}
+
+	return storedReceipts, nil
+}
+
+func (logic *AttendanceLogic) GetReimbursementReceipt(ctx context.Context, reimbursementID string, receiptID string) (models.ReimbursementReceipt, io.ReadCloser, error) {
+	reimbursement, err := logic.attRepo.GetReimbursementByID(ctx, reimbursementID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get reimbursement", slog.Any("error", err))
+		return models.ReimbursementReceipt{}, nil, err
+	}
+
+	err = logic.checkReimbursementAccess(ctx, reimbursement)
+	if err != nil {
+		return models.ReimbursementReceipt{}, nil, err
+	}
+
+	receipt, err := logic.attRepo.GetReimbursementReceiptByID(ctx, reimbursementID, receiptID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get reimbursement receipt", slog.Any("error", err))
+		return models.ReimbursementReceipt{}, nil, err
+	}
+
+	content, err := logic.storage.Get(ctx, receipt.StorageKey)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get reimbursement receipt from storage", slog.Any("error", err))
+		if errors.Is(err, xstorage.ErrObjectNotFound) {
+			return models.ReimbursementReceipt{}, nil, xerror.ErrDataNotFound
+		}
+		return models.ReimbursementReceipt{}, nil, err
+	}
+
+	return receipt, content, nil
+}
+
+func (logic *AttendanceLogic) GetReimbursementCategories(ctx context.Context) ([]models.ReimbursementCategory, error) {
+	result, err := logic.attRepo.GetReimbursementCategories(ctx)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get reimbursement categories", slog.Any("error", err))
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (logic *AttendanceLogic) SetReimbursementCategory(ctx context.Context, data models.ReimbursementCategory) error {
+	// check admin role of the user
+	userID := xcontext.GetUserIDFromContext(ctx)
+	isAdmin, err := logic.userRepo.IsAdmin(ctx, userID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to check user admin role", slog.Any("error", err))
 		return err
 	}
+
+	if !isAdmin {
+		return xerror.AuthError{Err: fmt.Errorf("admin only operation")}
+	}
+
+	if data.Code == "" || data.Name == "" {
+		return xerror.ClientError{Err: fmt.Errorf("category code and name are required")}
+	}
+
+	if (data.PerClaimLimit != nil && *data.PerClaimLimit <= 0) || (data.PerPeriodLimit != nil && *data.PerPeriodLimit <= 0) {
+		return xerror.ClientError{Err: fmt.Errorf("category limits must be greater than 0, leave it empty for no limit")}
+	}
+
+	if data.PerClaimLimit != nil && data.PerPeriodLimit != nil && *data.PerClaimLimit > *data.PerPeriodLimit {
+		return xerror.ClientError{Err: fmt.Errorf("per claim limit cannot be greater than per period limit")}
+	}
+
+	data.ID = uuid.NewString()
+	// categories are upserted by their code, which is what identifies them to users
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionUpdate,
+		Entity:   xaudit.EntityReimbursementCategory,
+		EntityID: data.Code,
+		After:    data,
+	}, func(ctx context.Context) error {
+		return logic.attRepo.UpsertReimbursementCategory(ctx, data)
+	})
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to set reimbursement category", slog.Any("error", err))
+		return err
+	}
+
 	return nil
 }
+
+// checkReimbursementAccess allows only the reimbursement owner and approvers (admins) through
+func (logic *AttendanceLogic) checkReimbursementAccess(ctx context.Context, reimbursement models.Reimbursement) error {
+	userID := xcontext.GetUserIDFromContext(ctx)
+	if userID != "" && userID == reimbursement.UserID {
+		return nil
+	}
+
+	isAdmin, err := logic.userRepo.IsAdmin(ctx, userID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to check user admin role", slog.Any("error", err))
+		return err
+	}
+
+	if !isAdmin {
+		return xerror.AuthError{Err: fmt.Errorf("only the reimbursement owner or approvers can access its receipts")}
+	}
+
+	return nil
+}
+
+// storeReceipts validates all receipts before putting them into the blob storage
+func (logic *AttendanceLogic) storeReceipts(ctx context.Context, reimbursementID string, receipts []ReceiptUpload) ([]models.ReimbursementReceipt, error) {
+	contentTypes := make([]string, len(receipts))
+	for i, receipt := range receipts {
+		contentType, err := logic.validateReceipt(receipt)
+		if err != nil {
+			return nil, err
+		}
+		contentTypes[i] = contentType
+	}
+
+	var result []models.ReimbursementReceipt
+	for i, receipt := range receipts {
+		receiptID := uuid.NewString()
+		stored := models.ReimbursementReceipt{
+			ID:              receiptID,
+			ReimbursementID: reimbursementID,
+			FileName:        filepath.Base(receipt.FileName),
+			ContentType:     contentTypes[i],
+			Size:            receipt.Size,
+			StorageKey:      fmt.Sprintf("reimbursements/%s/%s%s", reimbursementID, receiptID, allowedReceiptTypes[contentTypes[i]]),
+		}
+
+		err := logic.storage.Put(ctx, stored.StorageKey, receipt.Content, receipt.Size, stored.ContentType)
+		if err != nil {
+			logic.deps.Logger.ErrorContext(ctx, "failed to store receipt file", slog.Any("error", err))
+			logic.removeReceipts(ctx, result)
+			return nil, err
+		}
+
+		result = append(result, stored)
+	}
+
+	return result, nil
+}
+
+// removeReceipts cleans up already uploaded receipt files, used when the database write fails
+func (logic *AttendanceLogic) removeReceipts(ctx context.Context, receipts []models.ReimbursementReceipt) {
+	for _, receipt := range receipts {
+		err := logic.storage.Delete(ctx, receipt.StorageKey)
+		if err != nil {
+			logic.deps.Logger.WarnContext(ctx, "failed to remove orphaned receipt file", slog.String("key", receipt.StorageKey), slog.Any("error", err))
+		}
+	}
+}
+
+// validateReceipt checks the receipt size and sniffs its content type, returning the detected type
+func (logic *AttendanceLogic) validateReceipt(receipt ReceiptUpload) (string, error) {
+	if receipt.Size <= 0 {
+		return "", xerror.ClientError{Err: fmt.Errorf("receipt %s is empty", receipt.FileName)}
+	}
+
+	if receipt.Size > int64(logic.deps.Config.Storage.MaxReceiptSize) {
+		return "", xerror.ClientError{Err: fmt.Errorf("receipt %s exceeds the maximum size of %d bytes", receipt.FileName, logic.deps.Config.Storage.MaxReceiptSize)}
+	}
+
+	// don't trust the client content type, detect it from the first 512 bytes instead
+	header := make([]byte, 512)
+	n, err := io.ReadFull(receipt.Content, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", xerror.ClientError{Err: fmt.Errorf("failed to read receipt %s: %w", receipt.FileName, err)}
+	}
+
+	_, err = receipt.Content.Seek(0, io.SeekStart)
+	if err != nil {
+		return "", xerror.ClientError{Err: fmt.Errorf("failed to read receipt %s: %w", receipt.FileName, err)}
+	}
+
+	contentType := http.DetectContentType(header[:n])
+	if _, ok := allowedReceiptTypes[contentType]; !ok {
+		return "", xerror.ClientError{Err: fmt.Errorf("receipt %s has unsupported type %s, only images and PDFs are allowed", receipt.FileName, contentType)}
+	}
+
+	return contentType, nil
+}
+
+// allowedReceiptTypes maps the accepted receipt content types to their file extension
+var allowedReceiptTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+func (logic *AttendanceLogic) SubmitLeave(ctx context.Context, userID string, startDate string, endDate string, paid bool, reason string) (string, error) {
+	start, err := time.Parse(time.DateOnly, startDate)
+	if err != nil {
+		logic.deps.Logger.WarnContext(ctx, "failed to parse leave start date", slog.Any("error", err))
+		return "", xerror.ClientError{Err: fmt.Errorf("invalid start date: %w", err)}
+	}
+
+	end, err := time.Parse(time.DateOnly, endDate)
+	if err != nil {
+		logic.deps.Logger.WarnContext(ctx, "failed to parse leave end date", slog.Any("error", err))
+		return "", xerror.ClientError{Err: fmt.Errorf("invalid end date: %w", err)}
+	}
+
+	if end.Before(start) {
+		return "", xerror.ClientError{Err: fmt.Errorf("leave end date must not be before start date")}
+	}
+
+	// a day can only be covered by one leave, so it's not paid twice
+	overlap, err := logic.attRepo.HasOverlappingLeave(ctx, userID, start, end)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to check overlapping leave", slog.Any("error", err))
+		return "", err
+	}
+
+	if overlap {
+		return "", xerror.ClientError{Err: fmt.Errorf("leave overlaps with another leave")}
+	}
+
+	data := models.Leave{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		StartDate: start,
+		EndDate:   end,
+		Paid:      paid,
+		Reason:    reason,
+	}
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionCreate,
+		Entity:   xaudit.EntityLeave,
+		EntityID: data.ID,
+		After:    map[string]any{"user_id": userID, "start_date": xdate.Of(start), "end_date": xdate.Of(end), "paid": paid, "reason": reason},
+	}, func(ctx context.Context) error {
+		return logic.attRepo.SubmitLeave(ctx, data)
+	})
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to submit leave", slog.Any("error", err))
+		return "", err
+	}
+
+	return data.ID, nil
+}
@@ -1,11 +1,22 @@
 package attendance
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"io"
+	"log/slog"
 	"testing"
 	"time"
 
 	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/models"
+	"github.com/rahadianir/dealls/internal/pkg/xaudit"
+	"github.com/rahadianir/dealls/internal/pkg/xdate"
+	"github.com/rahadianir/dealls/internal/pkg/xerror"
+	"github.com/rahadianir/dealls/internal/pkg/xmail"
+	"github.com/rahadianir/dealls/internal/pkg/xstorage"
+	"github.com/rahadianir/dealls/internal/user"
 	"go.uber.org/mock/gomock"
 )
 
@@ -14,18 +25,27 @@ func TestAttendanceLogic_SubmitAttendance(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := NewMockAttendanceRepositoryInterface(ctrl)
+	mockAuditor := xaudit.NewMockRecorder(ctrl)
+	// the change and its entry are run in the transaction the recorder begins
+	mockAuditor.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, txfunc func(context.Context) error) error {
+		return txfunc(ctx)
+	}).AnyTimes()
+	mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockUserRepo := user.NewMockUserRepositoryInterface(ctrl)
 	mockDeps := config.CommonDependencies{
 		Config: config.InitConfig(context.Background()),
 	}
+	mockDeps.Config.App.Timezone = mustLoadLocation(t, "Asia/Jakarta")
 	tudei, err := time.Parse(time.RFC3339, "2025-06-11T06:29:44+07:00")
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	type fields struct {
-		deps    *config.CommonDependencies
-		attRepo AttendanceRepositoryInterface
-		today   time.Time
+		deps     *config.CommonDependencies
+		attRepo  AttendanceRepositoryInterface
+		userRepo user.UserRepositoryInterface
+		now      func() time.Time
 	}
 	type args struct {
 		ctx       context.Context
@@ -43,9 +63,10 @@ func TestAttendanceLogic_SubmitAttendance(t *testing.T) {
 		{
 			name: "success submit attendance",
 			fields: fields{
-				deps:    &mockDeps,
-				attRepo: mockRepo,
-				today:   tudei,
+				deps:     &mockDeps,
+				attRepo:  mockRepo,
+				userRepo: mockUserRepo,
+				now:      func() time.Time { return tudei },
 			},
 			args: args{
 				ctx:       context.Background(),
@@ -54,16 +75,75 @@ func TestAttendanceLogic_SubmitAttendance(t *testing.T) {
 			},
 			wantErr: false,
 			behaviour: func(f fields, a args) {
-				mockRepo.EXPECT().SubmitAttendance(gomock.Any(), "user-id", gomock.Any()).Return(nil)
+				mockUserRepo.EXPECT().GetUsersEmploymentByIDs(gomock.Any(), []string{"user-id"}).Return([]models.Employment{{UserID: "user-id"}}, nil)
+				mockRepo.EXPECT().SubmitAttendance(gomock.Any(), "user-id", gomock.Any(), xdate.New(2025, time.June, 11)).Return(nil)
+			},
+		},
+		{
+			name: "success submit utc attendance on its company date",
+			fields: fields{
+				deps:     &mockDeps,
+				attRepo:  mockRepo,
+				userRepo: mockUserRepo,
+				now:      func() time.Time { return tudei },
+			},
+			args: args{
+				ctx:       context.Background(),
+				userID:    "user-id",
+				timestamp: "2025-06-10T23:30:00Z",
+			},
+			wantErr: false,
+			behaviour: func(f fields, a args) {
+				mockUserRepo.EXPECT().GetUsersEmploymentByIDs(gomock.Any(), []string{"user-id"}).Return([]models.Employment{{UserID: "user-id"}}, nil)
+				mockRepo.EXPECT().SubmitAttendance(gomock.Any(), "user-id", gomock.Any(), xdate.New(2025, time.June, 11)).Return(nil)
+			},
+		},
+		{
+			name: "success submit attendance on the user timezone date",
+			fields: fields{
+				deps:     &mockDeps,
+				attRepo:  mockRepo,
+				userRepo: mockUserRepo,
+				now:      func() time.Time { return tudei },
+			},
+			args: args{
+				ctx:       context.Background(),
+				userID:    "user-id",
+				timestamp: "2025-06-11T02:00:00Z",
+			},
+			wantErr: false,
+			behaviour: func(f fields, a args) {
+				mockUserRepo.EXPECT().GetUsersEmploymentByIDs(gomock.Any(), []string{"user-id"}).Return([]models.Employment{{UserID: "user-id", Timezone: "America/New_York"}}, nil)
+				mockRepo.EXPECT().SubmitAttendance(gomock.Any(), "user-id", gomock.Any(), xdate.New(2025, time.June, 10)).Return(nil)
+			},
+		},
+		{
+			name: "failed submit attendance on weekend in company timezone",
+			fields: fields{
+				deps:     &mockDeps,
+				attRepo:  mockRepo,
+				userRepo: mockUserRepo,
+				now:      func() time.Time { return tudei },
+			},
+			args: args{
+				ctx:       context.Background(),
+				userID:    "user-id",
+				timestamp: "2025-06-13T18:00:00Z", // saturday 1 AM in jakarta
+			},
+			wantErr: true,
+			behaviour: func(f fields, a args) {
+				mockUserRepo.EXPECT().GetUsersEmploymentByIDs(gomock.Any(), []string{"user-id"}).Return([]models.Employment{{UserID: "user-id"}}, nil)
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			logic := &AttendanceLogic{
-				deps:    tt.fields.deps,
-				attRepo: tt.fields.attRepo,
-				today:   tt.fields.today,
+				deps:     tt.fields.deps,
+				attRepo:  tt.fields.attRepo,
+				auditor:  mockAuditor,
+				userRepo: tt.fields.userRepo,
+				now:      tt.fields.now,
 			}
 			tt.behaviour(tt.fields, tt.args)
 			if err := logic.SubmitAttendance(tt.args.ctx, tt.args.userID, tt.args.timestamp); (err != nil) != tt.wantErr {
@@ -78,18 +158,28 @@ func TestAttendanceLogic_SubmitOvertime(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := NewMockAttendanceRepositoryInterface(ctrl)
+	mockAuditor := xaudit.NewMockRecorder(ctrl)
+	// the change and its entry are run in the transaction the recorder begins
+	mockAuditor.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, txfunc func(context.Context) error) error {
+		return txfunc(ctx)
+	}).AnyTimes()
+	mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockUserRepo := user.NewMockUserRepositoryInterface(ctrl)
+	mockMails := xmail.NewMockQueue(ctrl)
 	mockDeps := config.CommonDependencies{
 		Config: config.InitConfig(context.Background()),
 	}
+	mockDeps.Config.App.Timezone = mustLoadLocation(t, "Asia/Jakarta")
 	tudei, err := time.Parse(time.RFC3339, "2025-06-11T06:29:44+07:00")
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	type fields struct {
-		deps    *config.CommonDependencies
-		attRepo AttendanceRepositoryInterface
-		today   time.Time
+		deps     *config.CommonDependencies
+		attRepo  AttendanceRepositoryInterface
+		userRepo user.UserRepositoryInterface
+		now      func() time.Time
 	}
 	type args struct {
 		ctx                       context.Context
@@ -106,11 +196,12 @@ func TestAttendanceLogic_SubmitOvertime(t *testing.T) {
 	}{
 		// TODO: Add test cases.
 		{
-			name: "success submit overtime",
+			name: "success submit overtime finished after midnight for the previous day",
 			fields: fields{
-				deps:    &mockDeps,
-				attRepo: mockRepo,
-				today:   tudei,
+				deps:     &mockDeps,
+				attRepo:  mockRepo,
+				userRepo: mockUserRepo,
+				now:      func() time.Time { return tudei },
 			},
 			args: args{
 				ctx:                       context.Background(),
@@ -120,17 +211,50 @@ func TestAttendanceLogic_SubmitOvertime(t *testing.T) {
 			},
 			wantErr: false,
 			behaviour: func(f fields, a args) {
-				mockRepo.EXPECT().GetUserOvertimeByTime(gomock.Any(), "user-id", gomock.Any()).Return(0, nil)
-				mockRepo.EXPECT().SubmitOvertime(gomock.Any(), "user-id", 2, gomock.Any()).Return(nil)
+				mockUserRepo.EXPECT().GetUsersEmploymentByIDs(gomock.Any(), []string{"user-id"}).Return([]models.Employment{{UserID: "user-id"}}, nil)
+				mockRepo.EXPECT().GetUserOvertimeByTime(gomock.Any(), "user-id", xdate.New(2025, time.June, 10)).Return(0, nil)
+				mockRepo.EXPECT().SubmitOvertime(gomock.Any(), "user-id", 2, xdate.New(2025, time.June, 10)).Return(nil)
+				mockMails.EXPECT().Enqueue(gomock.Any(), xmail.Email{
+					UserID:   "user-id",
+					Template: xmail.TemplateOvertimeDecision,
+					Data:     xmail.OvertimeDecisionData{Date: "2025-06-10", Hours: 2, Approved: true},
+				}).Return(nil)
+			},
+		},
+		{
+			name: "failed submit utc overtime within company working hours",
+			fields: fields{
+				deps:     &mockDeps,
+				attRepo:  mockRepo,
+				userRepo: mockUserRepo,
+				now:      func() time.Time { return tudei },
+			},
+			args: args{
+				ctx:                       context.Background(),
+				userID:                    "user-id",
+				hourCount:                 1,
+				finishedOvertimeTimestamp: "2025-06-11T03:00:00Z", // 10 AM in jakarta
+			},
+			wantErr: true,
+			behaviour: func(f fields, a args) {
+				mockUserRepo.EXPECT().GetUsersEmploymentByIDs(gomock.Any(), []string{"user-id"}).Return([]models.Employment{{UserID: "user-id"}}, nil)
+				mockMails.EXPECT().Enqueue(gomock.Any(), xmail.Email{
+					UserID:   "user-id",
+					Template: xmail.TemplateOvertimeDecision,
+					Data:     xmail.OvertimeDecisionData{Date: "2025-06-11", Hours: 1, Reason: "overtime must be submitted outside working hours"},
+				}).Return(nil)
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			logic := &AttendanceLogic{
-				deps:    tt.fields.deps,
-				attRepo: tt.fields.attRepo,
-				today:   tt.fields.today,
+				deps:     tt.fields.deps,
+				attRepo:  tt.fields.attRepo,
+				auditor:  mockAuditor,
+				mails:    mockMails,
+				userRepo: tt.fields.userRepo,
+				now:      tt.fields.now,
 			}
 			tt.behaviour(tt.fields, tt.args)
 			if err := logic.SubmitOvertime(tt.args.ctx, tt.args.userID, tt.args.hourCount, tt.args.finishedOvertimeTimestamp); (err != nil) != tt.wantErr {
@@ -145,24 +269,52 @@ func TestAttendanceLogic_SubmitReimbursement(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := NewMockAttendanceRepositoryInterface(ctrl)
+	mockAuditor := xaudit.NewMockRecorder(ctrl)
+	// the change and its entry are run in the transaction the recorder begins
+	mockAuditor.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, txfunc func(context.Context) error) error {
+		return txfunc(ctx)
+	}).AnyTimes()
+	mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockStorage := xstorage.NewMockBlobStorage(ctrl)
+	mockMails := xmail.NewMockQueue(ctrl)
+	mockMails.EXPECT().Enqueue(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 	mockDeps := config.CommonDependencies{
 		Config: config.InitConfig(context.Background()),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
 	}
 	tudei, err := time.Parse(time.RFC3339, "2025-06-11T06:29:44+07:00")
 	if err != nil {
 		t.Fatal(err)
 	}
+	pdfContent := []byte("%PDF-1.4 fake receipt")
+	textContent := []byte("just some text")
+	claimLimit, periodLimit := float64(150000), float64(1500000)
+	meals := models.ReimbursementCategory{
+		ID:             "meals-id",
+		Code:           "meals",
+		PerClaimLimit:  &claimLimit,
+		PerPeriodLimit: &periodLimit,
+	}
+	travel := models.ReimbursementCategory{
+		ID:              "travel-id",
+		Code:            "travel",
+		ReceiptRequired: true,
+	}
 
 	type fields struct {
 		deps    *config.CommonDependencies
 		attRepo AttendanceRepositoryInterface
-		today   time.Time
+		storage xstorage.BlobStorage
+		now     func() time.Time
 	}
 	type args struct {
-		ctx    context.Context
-		userID string
-		amount float64
-		desc   string
+		ctx      context.Context
+		userID   string
+		amount   float64
+		currency string
+		desc     string
+		category string
+		receipts []ReceiptUpload
 	}
 	tests := []struct {
 		name      string
@@ -177,17 +329,271 @@ func TestAttendanceLogic_SubmitReimbursement(t *testing.T) {
 			fields: fields{
 				deps:    &mockDeps,
 				attRepo: mockRepo,
-				today:   tudei,
+				storage: mockStorage,
+				now:     func() time.Time { return tudei },
+			},
+			args: args{
+				ctx:      context.Background(),
+				userID:   "user-id",
+				amount:   100,
+				desc:     "desc",
+				category: "meals",
+			},
+			wantErr: false,
+			behaviour: func(f fields, a args) {
+				mockRepo.EXPECT().GetReimbursementCategoryByCode(gomock.Any(), "meals").Return(meals, nil)
+				mockRepo.EXPECT().GetUserReimbursementTotalInActivePeriod(gomock.Any(), "user-id", "meals-id").Return(float64(0), nil)
+				mockRepo.EXPECT().SubmitReimbursement(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data models.Reimbursement) error {
+					if data.UserID != "user-id" || data.Amount != 100 || data.Description != "desc" || data.CategoryID != "meals-id" {
+						t.Errorf("unexpected reimbursement data: %+v", data)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name: "success submit reimbursement with receipt",
+			fields: fields{
+				deps:    &mockDeps,
+				attRepo: mockRepo,
+				storage: mockStorage,
+				now:     func() time.Time { return tudei },
 			},
 			args: args{
-				ctx:    context.Background(),
-				userID: "user-id",
-				amount: 100,
-				desc:   "desc",
+				ctx:      context.Background(),
+				userID:   "user-id",
+				amount:   100,
+				desc:     "desc",
+				category: "travel",
+				receipts: []ReceiptUpload{
+					{
+						FileName: "receipt.pdf",
+						Size:     int64(len(pdfContent)),
+						Content:  bytes.NewReader(pdfContent),
+					},
+				},
 			},
 			wantErr: false,
 			behaviour: func(f fields, a args) {
-				mockRepo.EXPECT().SubmitReimbursement(gomock.Any(), "user-id", float64(100), "desc").Return(nil)
+				mockRepo.EXPECT().GetReimbursementCategoryByCode(gomock.Any(), "travel").Return(travel, nil)
+				mockStorage.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any(), int64(len(pdfContent)), "application/pdf").Return(nil)
+				mockRepo.EXPECT().SubmitReimbursement(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data models.Reimbursement) error {
+					if len(data.Receipts) != 1 || data.Receipts[0].ReimbursementID != data.ID || data.Receipts[0].ContentType != "application/pdf" {
+						t.Errorf("unexpected reimbursement receipts: %+v", data.Receipts)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name: "failed submit reimbursement with unsupported receipt type",
+			fields: fields{
+				deps:    &mockDeps,
+				attRepo: mockRepo,
+				storage: mockStorage,
+				now:     func() time.Time { return tudei },
+			},
+			args: args{
+				ctx:      context.Background(),
+				userID:   "user-id",
+				amount:   100,
+				desc:     "desc",
+				category: "travel",
+				receipts: []ReceiptUpload{
+					{
+						FileName: "receipt.txt",
+						Size:     int64(len(textContent)),
+						Content:  bytes.NewReader(textContent),
+					},
+				},
+			},
+			wantErr: true,
+			behaviour: func(f fields, a args) {
+				mockRepo.EXPECT().GetReimbursementCategoryByCode(gomock.Any(), "travel").Return(travel, nil)
+			},
+		},
+		{
+			name: "failed store reimbursement removes uploaded receipt",
+			fields: fields{
+				deps:    &mockDeps,
+				attRepo: mockRepo,
+				storage: mockStorage,
+				now:     func() time.Time { return tudei },
+			},
+			args: args{
+				ctx:      context.Background(),
+				userID:   "user-id",
+				amount:   100,
+				desc:     "desc",
+				category: "travel",
+				receipts: []ReceiptUpload{
+					{
+						FileName: "receipt.pdf",
+						Size:     int64(len(pdfContent)),
+						Content:  bytes.NewReader(pdfContent),
+					},
+				},
+			},
+			wantErr: true,
+			behaviour: func(f fields, a args) {
+				mockRepo.EXPECT().GetReimbursementCategoryByCode(gomock.Any(), "travel").Return(travel, nil)
+				mockStorage.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				mockRepo.EXPECT().SubmitReimbursement(gomock.Any(), gomock.Any()).Return(fmt.Errorf("db error"))
+				mockStorage.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name: "success submit reimbursement in foreign currency",
+			fields: fields{
+				deps:    &mockDeps,
+				attRepo: mockRepo,
+				storage: mockStorage,
+				now:     func() time.Time { return tudei },
+			},
+			args: args{
+				ctx:      context.Background(),
+				userID:   "user-id",
+				amount:   5,
+				currency: "usd",
+				desc:     "desc",
+				category: "meals",
+			},
+			wantErr: false,
+			behaviour: func(f fields, a args) {
+				mockRepo.EXPECT().GetReimbursementCategoryByCode(gomock.Any(), "meals").Return(meals, nil)
+				mockRepo.EXPECT().GetUserActivePeriodExchangeRate(gomock.Any(), "user-id", "USD").Return(float64(16000), nil)
+				mockRepo.EXPECT().GetUserReimbursementTotalInActivePeriod(gomock.Any(), "user-id", "meals-id").Return(float64(0), nil)
+				mockRepo.EXPECT().SubmitReimbursement(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data models.Reimbursement) error {
+					if data.Amount != 5 || data.Currency != "USD" {
+						t.Errorf("unexpected reimbursement amount: %v %s", data.Amount, data.Currency)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name: "failed submit reimbursement exceeding per claim limit after conversion",
+			fields: fields{
+				deps:    &mockDeps,
+				attRepo: mockRepo,
+				storage: mockStorage,
+				now:     func() time.Time { return tudei },
+			},
+			args: args{
+				ctx:      context.Background(),
+				userID:   "user-id",
+				amount:   10,
+				currency: "USD",
+				desc:     "desc",
+				category: "meals",
+			},
+			wantErr: true,
+			behaviour: func(f fields, a args) {
+				mockRepo.EXPECT().GetReimbursementCategoryByCode(gomock.Any(), "meals").Return(meals, nil)
+				mockRepo.EXPECT().GetUserActivePeriodExchangeRate(gomock.Any(), "user-id", "USD").Return(float64(16000), nil)
+			},
+		},
+		{
+			name: "failed submit reimbursement without exchange rate",
+			fields: fields{
+				deps:    &mockDeps,
+				attRepo: mockRepo,
+				storage: mockStorage,
+				now:     func() time.Time { return tudei },
+			},
+			args: args{
+				ctx:      context.Background(),
+				userID:   "user-id",
+				amount:   5,
+				currency: "SGD",
+				desc:     "desc",
+				category: "meals",
+			},
+			wantErr: true,
+			behaviour: func(f fields, a args) {
+				mockRepo.EXPECT().GetReimbursementCategoryByCode(gomock.Any(), "meals").Return(meals, nil)
+				mockRepo.EXPECT().GetUserActivePeriodExchangeRate(gomock.Any(), "user-id", "SGD").Return(float64(0), xerror.ErrDataNotFound)
+			},
+		},
+		{
+			name: "failed submit reimbursement exceeding per claim limit",
+			fields: fields{
+				deps:    &mockDeps,
+				attRepo: mockRepo,
+				storage: mockStorage,
+				now:     func() time.Time { return tudei },
+			},
+			args: args{
+				ctx:      context.Background(),
+				userID:   "user-id",
+				amount:   200000,
+				desc:     "team dinner",
+				category: "meals",
+			},
+			wantErr: true,
+			behaviour: func(f fields, a args) {
+				mockRepo.EXPECT().GetReimbursementCategoryByCode(gomock.Any(), "meals").Return(meals, nil)
+			},
+		},
+		{
+			name: "failed submit reimbursement exceeding per period limit",
+			fields: fields{
+				deps:    &mockDeps,
+				attRepo: mockRepo,
+				storage: mockStorage,
+				now:     func() time.Time { return tudei },
+			},
+			args: args{
+				ctx:      context.Background(),
+				userID:   "user-id",
+				amount:   100000,
+				desc:     "lunch",
+				category: "meals",
+			},
+			wantErr: true,
+			behaviour: func(f fields, a args) {
+				mockRepo.EXPECT().GetReimbursementCategoryByCode(gomock.Any(), "meals").Return(meals, nil)
+				mockRepo.EXPECT().GetUserReimbursementTotalInActivePeriod(gomock.Any(), "user-id", "meals-id").Return(float64(1450000), nil)
+			},
+		},
+		{
+			name: "failed submit reimbursement without required receipt",
+			fields: fields{
+				deps:    &mockDeps,
+				attRepo: mockRepo,
+				storage: mockStorage,
+				now:     func() time.Time { return tudei },
+			},
+			args: args{
+				ctx:      context.Background(),
+				userID:   "user-id",
+				amount:   100000,
+				desc:     "taxi",
+				category: "travel",
+			},
+			wantErr: true,
+			behaviour: func(f fields, a args) {
+				mockRepo.EXPECT().GetReimbursementCategoryByCode(gomock.Any(), "travel").Return(travel, nil)
+			},
+		},
+		{
+			name: "failed submit reimbursement with unknown category",
+			fields: fields{
+				deps:    &mockDeps,
+				attRepo: mockRepo,
+				storage: mockStorage,
+				now:     func() time.Time { return tudei },
+			},
+			args: args{
+				ctx:      context.Background(),
+				userID:   "user-id",
+				amount:   100000,
+				desc:     "gadget",
+				category: "gadget",
+			},
+			wantErr: true,
+			behaviour: func(f fields, a args) {
+				mockRepo.EXPECT().GetReimbursementCategoryByCode(gomock.Any(), "gadget").Return(models.ReimbursementCategory{}, xerror.ErrDataNotFound)
 			},
 		},
 	}
@@ -196,12 +602,130 @@ func TestAttendanceLogic_SubmitReimbursement(t *testing.T) {
 			logic := &AttendanceLogic{
 				deps:    tt.fields.deps,
 				attRepo: tt.fields.attRepo,
-				today:   tt.fields.today,
+				auditor: mockAuditor,
+				mails:   mockMails,
+				storage: tt.fields.storage,
+				now:     tt.fields.now,
 			}
 			tt.behaviour(tt.fields, tt.args)
-			if err := logic.SubmitReimbursement(tt.args.ctx, tt.args.userID, tt.args.amount, tt.args.desc); (err != nil) != tt.wantErr {
+			if _, err := logic.SubmitReimbursement(tt.args.ctx, tt.args.userID, tt.args.amount, tt.args.currency, tt.args.desc, tt.args.category, tt.args.receipts); (err != nil) != tt.wantErr {
 				t.Errorf("AttendanceLogic.SubmitReimbursement() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
+
+func TestAttendanceLogic_SubmitLeave(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := NewMockAttendanceRepositoryInterface(ctrl)
+	mockAuditor := xaudit.NewMockRecorder(ctrl)
+	// the change and its entry are run in the transaction the recorder begins
+	mockAuditor.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, txfunc func(context.Context) error) error {
+		return txfunc(ctx)
+	}).AnyTimes()
+	mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockDeps := config.CommonDependencies{
+		Config: config.InitConfig(context.Background()),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	type fields struct {
+		deps    *config.CommonDependencies
+		attRepo AttendanceRepositoryInterface
+	}
+	type args struct {
+		ctx       context.Context
+		userID    string
+		startDate string
+		endDate   string
+		paid      bool
+	}
+	tests := []struct {
+		name      string
+		fields    fields
+		args      args
+		wantErr   bool
+		behaviour func(f fields, a args)
+	}{
+		// TODO: Add test cases.
+		{
+			name: "success submit leave",
+			fields: fields{
+				deps:    &mockDeps,
+				attRepo: mockRepo,
+			},
+			args: args{
+				ctx:       context.Background(),
+				userID:    "user-id",
+				startDate: "2025-06-16",
+				endDate:   "2025-06-20",
+				paid:      true,
+			},
+			wantErr: false,
+			behaviour: func(f fields, a args) {
+				mockRepo.EXPECT().HasOverlappingLeave(gomock.Any(), "user-id", gomock.Any(), gomock.Any()).Return(false, nil)
+				mockRepo.EXPECT().SubmitLeave(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data models.Leave) error {
+					if data.UserID != "user-id" || !data.Paid || data.StartDate.Format(time.DateOnly) != "2025-06-16" || data.EndDate.Format(time.DateOnly) != "2025-06-20" {
+						t.Errorf("unexpected leave: %+v", data)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name: "failed submit leave ending before it starts",
+			fields: fields{
+				deps:    &mockDeps,
+				attRepo: mockRepo,
+			},
+			args: args{
+				ctx:       context.Background(),
+				userID:    "user-id",
+				startDate: "2025-06-20",
+				endDate:   "2025-06-16",
+			},
+			wantErr:   true,
+			behaviour: func(f fields, a args) {},
+		},
+		{
+			name: "failed submit overlapping leave",
+			fields: fields{
+				deps:    &mockDeps,
+				attRepo: mockRepo,
+			},
+			args: args{
+				ctx:       context.Background(),
+				userID:    "user-id",
+				startDate: "2025-06-16",
+				endDate:   "2025-06-20",
+			},
+			wantErr: true,
+			behaviour: func(f fields, a args) {
+				mockRepo.EXPECT().HasOverlappingLeave(gomock.Any(), "user-id", gomock.Any(), gomock.Any()).Return(true, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := &AttendanceLogic{
+				deps:    tt.fields.deps,
+				attRepo: tt.fields.attRepo,
+				auditor: mockAuditor,
+			}
+			tt.behaviour(tt.fields, tt.args)
+			if _, err := logic.SubmitLeave(tt.args.ctx, tt.args.userID, tt.args.startDate, tt.args.endDate, tt.args.paid, ""); (err != nil) != tt.wantErr {
+				t.Errorf("AttendanceLogic.SubmitLeave() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
@@ -11,10 +11,12 @@ package attendance
 
 import (
 	context "context"
+	io "io"
 	reflect "reflect"
 	time "time"
 
 	models "github.com/rahadianir/dealls/internal/models"
+	xdate "github.com/rahadianir/dealls/internal/pkg/xdate"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -42,53 +44,83 @@ func (m *MockAttendanceRepositoryInterface) EXPECT() *MockAttendanceRepositoryIn
 	return m.recorder
 }
 
-// GetAllUserAttendancesByPeriod mocks base method.
-func (m *MockAttendanceRepositoryInterface) GetAllUserAttendancesByPeriod(ctx context.Context, start, end time.Time) ([]models.Attendance, error) {
+// GetReimbursementByID mocks base method.
+func (m *MockAttendanceRepositoryInterface) GetReimbursementByID(ctx context.Context, id string) (models.Reimbursement, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllUserAttendancesByPeriod", ctx, start, end)
-	ret0, _ := ret[0].([]models.Attendance)
+	ret := m.ctrl.Call(m, "GetReimbursementByID", ctx, id)
+	ret0, _ := ret[0].(models.Reimbursement)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAllUserAttendancesByPeriod indicates an expected call of GetAllUserAttendancesByPeriod.
-func (mr *MockAttendanceRepositoryInterfaceMockRecorder) GetAllUserAttendancesByPeriod(ctx, start, end any) *gomock.Call {
+// GetReimbursementByID indicates an expected call of GetReimbursementByID.
+func (mr *MockAttendanceRepositoryInterfaceMockRecorder) GetReimbursementByID(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllUserAttendancesByPeriod", reflect.TypeOf((*MockAttendanceRepositoryInterface)(nil).GetAllUserAttendancesByPeriod), ctx, start, end)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReimbursementByID", reflect.TypeOf((*MockAttendanceRepositoryInterface)(nil).GetReimbursementByID), ctx, id)
 }
 
-// GetAllUserOvertimesByPeriod mocks base method.
-func (m *MockAttendanceRepositoryInterface) GetAllUserOvertimesByPeriod(ctx context.Context, start, end time.Time) ([]models.Overtime, error) {
+// GetReimbursementCategories mocks base method.
+func (m *MockAttendanceRepositoryInterface) GetReimbursementCategories(ctx context.Context) ([]models.ReimbursementCategory, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllUserOvertimesByPeriod", ctx, start, end)
-	ret0, _ := ret[0].([]models.Overtime)
+	ret := m.ctrl.Call(m, "GetReimbursementCategories", ctx)
+	ret0, _ := ret[0].([]models.ReimbursementCategory)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAllUserOvertimesByPeriod indicates an expected call of GetAllUserOvertimesByPeriod.
-func (mr *MockAttendanceRepositoryInterfaceMockRecorder) GetAllUserOvertimesByPeriod(ctx, start, end any) *gomock.Call {
+// GetReimbursementCategories indicates an expected call of GetReimbursementCategories.
+func (mr *MockAttendanceRepositoryInterfaceMockRecorder) GetReimbursementCategories(ctx any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllUserOvertimesByPeriod", reflect.TypeOf((*MockAttendanceRepositoryInterface)(nil).GetAllUserOvertimesByPeriod), ctx, start, end)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReimbursementCategories", reflect.TypeOf((*MockAttendanceRepositoryInterface)(nil).GetReimbursementCategories), ctx)
 }
 
-// GetAllUserReimbursementsByPeriod mocks base method.
-func (m *MockAttendanceRepositoryInterface) GetAllUserReimbursementsByPeriod(ctx context.Context, start, end time.Time) ([]models.Reimbursement, error) {
+// GetReimbursementCategoryByCode mocks base method.
+func (m *MockAttendanceRepositoryInterface) GetReimbursementCategoryByCode(ctx context.Context, code string) (models.ReimbursementCategory, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllUserReimbursementsByPeriod", ctx, start, end)
-	ret0, _ := ret[0].([]models.Reimbursement)
+	ret := m.ctrl.Call(m, "GetReimbursementCategoryByCode", ctx, code)
+	ret0, _ := ret[0].(models.ReimbursementCategory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReimbursementCategoryByCode indicates an expected call of GetReimbursementCategoryByCode.
+func (mr *MockAttendanceRepositoryInterfaceMockRecorder) GetReimbursementCategoryByCode(ctx, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReimbursementCategoryByCode", reflect.TypeOf((*MockAttendanceRepositoryInterface)(nil).GetReimbursementCategoryByCode), ctx, code)
+}
+
+// GetReimbursementReceiptByID mocks base method.
+func (m *MockAttendanceRepositoryInterface) GetReimbursementReceiptByID(ctx context.Context, reimbursementID, receiptID string) (models.ReimbursementReceipt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReimbursementReceiptByID", ctx, reimbursementID, receiptID)
+	ret0, _ := ret[0].(models.ReimbursementReceipt)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAllUserReimbursementsByPeriod indicates an expected call of GetAllUserReimbursementsByPeriod.
-func (mr *MockAttendanceRepositoryInterfaceMockRecorder) GetAllUserReimbursementsByPeriod(ctx, start, end any) *gomock.Call {
+// GetReimbursementReceiptByID indicates an expected call of GetReimbursementReceiptByID.
+func (mr *MockAttendanceRepositoryInterfaceMockRecorder) GetReimbursementReceiptByID(ctx, reimbursementID, receiptID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllUserReimbursementsByPeriod", reflect.TypeOf((*MockAttendanceRepositoryInterface)(nil).GetAllUserReimbursementsByPeriod), ctx, start, end)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReimbursementReceiptByID", reflect.TypeOf((*MockAttendanceRepositoryInterface)(nil).GetReimbursementReceiptByID), ctx, reimbursementID, receiptID)
+}
+
+// GetUserActivePeriodExchangeRate mocks base method.
+func (m *MockAttendanceRepositoryInterface) GetUserActivePeriodExchangeRate(ctx context.Context, userID, currency string) (float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserActivePeriodExchangeRate", ctx, userID, currency)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserActivePeriodExchangeRate indicates an expected call of GetUserActivePeriodExchangeRate.
+func (mr *MockAttendanceRepositoryInterfaceMockRecorder) GetUserActivePeriodExchangeRate(ctx, userID, currency any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserActivePeriodExchangeRate", reflect.TypeOf((*MockAttendanceRepositoryInterface)(nil).GetUserActivePeriodExchangeRate), ctx, userID, currency)
 }
 
 // GetUserOvertimeByTime mocks base method.
-func (m *MockAttendanceRepositoryInterface) GetUserOvertimeByTime(ctx context.Context, userID string, date time.Time) (int, error) {
+func (m *MockAttendanceRepositoryInterface) GetUserOvertimeByTime(ctx context.Context, userID string, date xdate.Date) (int, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetUserOvertimeByTime", ctx, userID, date)
 	ret0, _ := ret[0].(int)
@@ -102,46 +134,178 @@ func (mr *MockAttendanceRepositoryInterfaceMockRecorder) GetUserOvertimeByTime(c
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserOvertimeByTime", reflect.TypeOf((*MockAttendanceRepositoryInterface)(nil).GetUserOvertimeByTime), ctx, userID, date)
 }
 
+// GetUserReimbursementTotalInActivePeriod mocks base method.
+func (m *MockAttendanceRepositoryInterface) GetUserReimbursementTotalInActivePeriod(ctx context.Context, userID, categoryID string) (float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserReimbursementTotalInActivePeriod", ctx, userID, categoryID)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserReimbursementTotalInActivePeriod indicates an expected call of GetUserReimbursementTotalInActivePeriod.
+func (mr *MockAttendanceRepositoryInterfaceMockRecorder) GetUserReimbursementTotalInActivePeriod(ctx, userID, categoryID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserReimbursementTotalInActivePeriod", reflect.TypeOf((*MockAttendanceRepositoryInterface)(nil).GetUserReimbursementTotalInActivePeriod), ctx, userID, categoryID)
+}
+
+// GetUsersAttendancesByPeriod mocks base method.
+func (m *MockAttendanceRepositoryInterface) GetUsersAttendancesByPeriod(ctx context.Context, userIDs []string, start, end time.Time) ([]models.Attendance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsersAttendancesByPeriod", ctx, userIDs, start, end)
+	ret0, _ := ret[0].([]models.Attendance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsersAttendancesByPeriod indicates an expected call of GetUsersAttendancesByPeriod.
+func (mr *MockAttendanceRepositoryInterfaceMockRecorder) GetUsersAttendancesByPeriod(ctx, userIDs, start, end any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersAttendancesByPeriod", reflect.TypeOf((*MockAttendanceRepositoryInterface)(nil).GetUsersAttendancesByPeriod), ctx, userIDs, start, end)
+}
+
+// GetUsersLeaveDaysByPeriod mocks base method.
+func (m *MockAttendanceRepositoryInterface) GetUsersLeaveDaysByPeriod(ctx context.Context, userIDs []string, start, end time.Time) ([]models.LeaveDays, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsersLeaveDaysByPeriod", ctx, userIDs, start, end)
+	ret0, _ := ret[0].([]models.LeaveDays)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsersLeaveDaysByPeriod indicates an expected call of GetUsersLeaveDaysByPeriod.
+func (mr *MockAttendanceRepositoryInterfaceMockRecorder) GetUsersLeaveDaysByPeriod(ctx, userIDs, start, end any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersLeaveDaysByPeriod", reflect.TypeOf((*MockAttendanceRepositoryInterface)(nil).GetUsersLeaveDaysByPeriod), ctx, userIDs, start, end)
+}
+
+// GetUsersOvertimesByPeriod mocks base method.
+func (m *MockAttendanceRepositoryInterface) GetUsersOvertimesByPeriod(ctx context.Context, userIDs []string, start, end time.Time) ([]models.Overtime, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsersOvertimesByPeriod", ctx, userIDs, start, end)
+	ret0, _ := ret[0].([]models.Overtime)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsersOvertimesByPeriod indicates an expected call of GetUsersOvertimesByPeriod.
+func (mr *MockAttendanceRepositoryInterfaceMockRecorder) GetUsersOvertimesByPeriod(ctx, userIDs, start, end any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersOvertimesByPeriod", reflect.TypeOf((*MockAttendanceRepositoryInterface)(nil).GetUsersOvertimesByPeriod), ctx, userIDs, start, end)
+}
+
+// GetUsersReimbursementsByPeriod mocks base method.
+func (m *MockAttendanceRepositoryInterface) GetUsersReimbursementsByPeriod(ctx context.Context, userIDs []string, start, end time.Time) ([]models.Reimbursement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsersReimbursementsByPeriod", ctx, userIDs, start, end)
+	ret0, _ := ret[0].([]models.Reimbursement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsersReimbursementsByPeriod indicates an expected call of GetUsersReimbursementsByPeriod.
+func (mr *MockAttendanceRepositoryInterfaceMockRecorder) GetUsersReimbursementsByPeriod(ctx, userIDs, start, end any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersReimbursementsByPeriod", reflect.TypeOf((*MockAttendanceRepositoryInterface)(nil).GetUsersReimbursementsByPeriod), ctx, userIDs, start, end)
+}
+
+// HasOverlappingLeave mocks base method.
+func (m *MockAttendanceRepositoryInterface) HasOverlappingLeave(ctx context.Context, userID string, start, end time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasOverlappingLeave", ctx, userID, start, end)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasOverlappingLeave indicates an expected call of HasOverlappingLeave.
+func (mr *MockAttendanceRepositoryInterfaceMockRecorder) HasOverlappingLeave(ctx, userID, start, end any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasOverlappingLeave", reflect.TypeOf((*MockAttendanceRepositoryInterface)(nil).HasOverlappingLeave), ctx, userID, start, end)
+}
+
+// StoreReimbursementReceipts mocks base method.
+func (m *MockAttendanceRepositoryInterface) StoreReimbursementReceipts(ctx context.Context, receipts []models.ReimbursementReceipt) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreReimbursementReceipts", ctx, receipts)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreReimbursementReceipts indicates an expected call of StoreReimbursementReceipts.
+func (mr *MockAttendanceRepositoryInterfaceMockRecorder) StoreReimbursementReceipts(ctx, receipts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreReimbursementReceipts", reflect.TypeOf((*MockAttendanceRepositoryInterface)(nil).StoreReimbursementReceipts), ctx, receipts)
+}
+
 // SubmitAttendance mocks base method.
-func (m *MockAttendanceRepositoryInterface) SubmitAttendance(ctx context.Context, userID string, timestamp time.Time) error {
+func (m *MockAttendanceRepositoryInterface) SubmitAttendance(ctx context.Context, userID string, timestamp time.Time, date xdate.Date) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SubmitAttendance", ctx, userID, timestamp)
+	ret := m.ctrl.Call(m, "SubmitAttendance", ctx, userID, timestamp, date)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SubmitAttendance indicates an expected call of SubmitAttendance.
-func (mr *MockAttendanceRepositoryInterfaceMockRecorder) SubmitAttendance(ctx, userID, timestamp any) *gomock.Call {
+func (mr *MockAttendanceRepositoryInterfaceMockRecorder) SubmitAttendance(ctx, userID, timestamp, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubmitAttendance", reflect.TypeOf((*MockAttendanceRepositoryInterface)(nil).SubmitAttendance), ctx, userID, timestamp, date)
+}
+
+// SubmitLeave mocks base method.
+func (m *MockAttendanceRepositoryInterface) SubmitLeave(ctx context.Context, data models.Leave) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubmitLeave", ctx, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SubmitLeave indicates an expected call of SubmitLeave.
+func (mr *MockAttendanceRepositoryInterfaceMockRecorder) SubmitLeave(ctx, data any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubmitAttendance", reflect.TypeOf((*MockAttendanceRepositoryInterface)(nil).SubmitAttendance), ctx, userID, timestamp)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubmitLeave", reflect.TypeOf((*MockAttendanceRepositoryInterface)(nil).SubmitLeave), ctx, data)
 }
 
 // SubmitOvertime mocks base method.
-func (m *MockAttendanceRepositoryInterface) SubmitOvertime(ctx context.Context, userID string, hours int, timestamp time.Time) error {
+func (m *MockAttendanceRepositoryInterface) SubmitOvertime(ctx context.Context, userID string, hours int, date xdate.Date) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SubmitOvertime", ctx, userID, hours, timestamp)
+	ret := m.ctrl.Call(m, "SubmitOvertime", ctx, userID, hours, date)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SubmitOvertime indicates an expected call of SubmitOvertime.
-func (mr *MockAttendanceRepositoryInterfaceMockRecorder) SubmitOvertime(ctx, userID, hours, timestamp any) *gomock.Call {
+func (mr *MockAttendanceRepositoryInterfaceMockRecorder) SubmitOvertime(ctx, userID, hours, date any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubmitOvertime", reflect.TypeOf((*MockAttendanceRepositoryInterface)(nil).SubmitOvertime), ctx, userID, hours, timestamp)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubmitOvertime", reflect.TypeOf((*MockAttendanceRepositoryInterface)(nil).SubmitOvertime), ctx, userID, hours, date)
 }
 
 // SubmitReimbursement mocks base method.
-func (m *MockAttendanceRepositoryInterface) SubmitReimbursement(ctx context.Context, userID string, amount float64, desc string) error {
+func (m *MockAttendanceRepositoryInterface) SubmitReimbursement(ctx context.Context, data models.Reimbursement) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SubmitReimbursement", ctx, userID, amount, desc)
+	ret := m.ctrl.Call(m, "SubmitReimbursement", ctx, data)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SubmitReimbursement indicates an expected call of SubmitReimbursement.
-func (mr *MockAttendanceRepositoryInterfaceMockRecorder) SubmitReimbursement(ctx, userID, amount, desc any) *gomock.Call {
+func (mr *MockAttendanceRepositoryInterfaceMockRecorder) SubmitReimbursement(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubmitReimbursement", reflect.TypeOf((*MockAttendanceRepositoryInterface)(nil).SubmitReimbursement), ctx, data)
+}
+
+// UpsertReimbursementCategory mocks base method.
+func (m *MockAttendanceRepositoryInterface) UpsertReimbursementCategory(ctx context.Context, data models.ReimbursementCategory) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertReimbursementCategory", ctx, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertReimbursementCategory indicates an expected call of UpsertReimbursementCategory.
+func (mr *MockAttendanceRepositoryInterfaceMockRecorder) UpsertReimbursementCategory(ctx, data any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubmitReimbursement", reflect.TypeOf((*MockAttendanceRepositoryInterface)(nil).SubmitReimbursement), ctx, userID, amount, desc)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertReimbursementCategory", reflect.TypeOf((*MockAttendanceRepositoryInterface)(nil).UpsertReimbursementCategory), ctx, data)
 }
 
 // MockAttendanceLogicInterface is a mock of AttendanceLogicInterface interface.
@@ -168,6 +332,51 @@ func (m *MockAttendanceLogicInterface) EXPECT() *MockAttendanceLogicInterfaceMoc
 	return m.recorder
 }
 
+// GetReimbursementCategories mocks base method.
+func (m *MockAttendanceLogicInterface) GetReimbursementCategories(ctx context.Context) ([]models.ReimbursementCategory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReimbursementCategories", ctx)
+	ret0, _ := ret[0].([]models.ReimbursementCategory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReimbursementCategories indicates an expected call of GetReimbursementCategories.
+func (mr *MockAttendanceLogicInterfaceMockRecorder) GetReimbursementCategories(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReimbursementCategories", reflect.TypeOf((*MockAttendanceLogicInterface)(nil).GetReimbursementCategories), ctx)
+}
+
+// GetReimbursementReceipt mocks base method.
+func (m *MockAttendanceLogicInterface) GetReimbursementReceipt(ctx context.Context, reimbursementID, receiptID string) (models.ReimbursementReceipt, io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReimbursementReceipt", ctx, reimbursementID, receiptID)
+	ret0, _ := ret[0].(models.ReimbursementReceipt)
+	ret1, _ := ret[1].(io.ReadCloser)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetReimbursementReceipt indicates an expected call of GetReimbursementReceipt.
+func (mr *MockAttendanceLogicInterfaceMockRecorder) GetReimbursementReceipt(ctx, reimbursementID, receiptID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReimbursementReceipt", reflect.TypeOf((*MockAttendanceLogicInterface)(nil).GetReimbursementReceipt), ctx, reimbursementID, receiptID)
+}
+
+// SetReimbursementCategory mocks base method.
+func (m *MockAttendanceLogicInterface) SetReimbursementCategory(ctx context.Context, data models.ReimbursementCategory) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetReimbursementCategory", ctx, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetReimbursementCategory indicates an expected call of SetReimbursementCategory.
+func (mr *MockAttendanceLogicInterfaceMockRecorder) SetReimbursementCategory(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetReimbursementCategory", reflect.TypeOf((*MockAttendanceLogicInterface)(nil).SetReimbursementCategory), ctx, data)
+}
+
 // SubmitAttendance mocks base method.
 func (m *MockAttendanceLogicInterface) SubmitAttendance(ctx context.Context, userID, timestamp string) error {
 	m.ctrl.T.Helper()
@@ -182,6 +391,21 @@ func (mr *MockAttendanceLogicInterfaceMockRecorder) SubmitAttendance(ctx, userID
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubmitAttendance", reflect.TypeOf((*MockAttendanceLogicInterface)(nil).SubmitAttendance), ctx, userID, timestamp)
 }
 
+// SubmitLeave mocks base method.
+func (m *MockAttendanceLogicInterface) SubmitLeave(ctx context.Context, userID, startDate, endDate string, paid bool, reason string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubmitLeave", ctx, userID, startDate, endDate, paid, reason)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SubmitLeave indicates an expected call of SubmitLeave.
+func (mr *MockAttendanceLogicInterfaceMockRecorder) SubmitLeave(ctx, userID, startDate, endDate, paid, reason any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubmitLeave", reflect.TypeOf((*MockAttendanceLogicInterface)(nil).SubmitLeave), ctx, userID, startDate, endDate, paid, reason)
+}
+
 // SubmitOvertime mocks base method.
 func (m *MockAttendanceLogicInterface) SubmitOvertime(ctx context.Context, userID string, hourCount int, finishedOvertimeTimestamp string) error {
 	m.ctrl.T.Helper()
@@ -197,15 +421,31 @@ func (mr *MockAttendanceLogicInterfaceMockRecorder) SubmitOvertime(ctx, userID,
 }
 
 // SubmitReimbursement mocks base method.
-func (m *MockAttendanceLogicInterface) SubmitReimbursement(ctx context.Context, userID string, amount float64, desc string) error {
+func (m *MockAttendanceLogicInterface) SubmitReimbursement(ctx context.Context, userID string, amount float64, currency, desc, category string, receipts []ReceiptUpload) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SubmitReimbursement", ctx, userID, amount, desc)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "SubmitReimbursement", ctx, userID, amount, currency, desc, category, receipts)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // SubmitReimbursement indicates an expected call of SubmitReimbursement.
-func (mr *MockAttendanceLogicInterfaceMockRecorder) SubmitReimbursement(ctx, userID, amount, desc any) *gomock.Call {
+func (mr *MockAttendanceLogicInterfaceMockRecorder) SubmitReimbursement(ctx, userID, amount, currency, desc, category, receipts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubmitReimbursement", reflect.TypeOf((*MockAttendanceLogicInterface)(nil).SubmitReimbursement), ctx, userID, amount, currency, desc, category, receipts)
+}
+
+// UploadReimbursementReceipts mocks base method.
+func (m *MockAttendanceLogicInterface) UploadReimbursementReceipts(ctx context.Context, reimbursementID string, receipts []ReceiptUpload) ([]models.ReimbursementReceipt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadReimbursementReceipts", ctx, reimbursementID, receipts)
+	ret0, _ := ret[0].([]models.ReimbursementReceipt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UploadReimbursementReceipts indicates an expected call of UploadReimbursementReceipts.
+func (mr *MockAttendanceLogicInterfaceMockRecorder) UploadReimbursementReceipts(ctx, reimbursementID, receipts any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubmitReimbursement", reflect.TypeOf((*MockAttendanceLogicInterface)(nil).SubmitReimbursement), ctx, userID, amount, desc)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadReimbursementReceipts", reflect.TypeOf((*MockAttendanceLogicInterface)(nil).UploadReimbursementReceipts), ctx, reimbursementID, receipts)
 }
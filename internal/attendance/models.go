@@ -1,6 +1,11 @@
 package attendance
 
-import "database/sql"
+import (
+	"database/sql"
+	"io"
+
+	"github.com/rahadianir/dealls/internal/models"
+)
 
 type AttendanceRequest struct {
 	UserID    string `json:"user_id"`
@@ -16,7 +21,54 @@ type OvertimeRequest struct {
 type ReimbursementRequest struct {
 	UserID      string  `json:"user_id"`
 	Amount      float64 `json:"amount"`
+	Currency    string  `json:"currency"` // ISO 4217, the base currency when empty
 	Description string  `json:"description"`
+	Category    string  `json:"category"`
+}
+
+type ReimbursementCategoryRequest struct {
+	Name            string   `json:"name"`
+	PerClaimLimit   *float64 `json:"per_claim_limit"`
+	PerPeriodLimit  *float64 `json:"per_period_limit"`
+	ReceiptRequired bool     `json:"receipt_required"`
+	Taxable         bool     `json:"taxable"`
+}
+
+type ReimbursementResponse struct {
+	ID string `json:"id"`
+}
+
+// ReimbursementEvent is the data of the reimbursement.approved webhook event
+type ReimbursementEvent struct {
+	ID          string  `json:"id"`
+	UserID      string  `json:"user_id"`
+	Amount      float64 `json:"amount"`
+	Currency    string  `json:"currency"`
+	Description string  `json:"description,omitempty"`
+	Category    string  `json:"category,omitempty"`
+	Receipts    int     `json:"receipts"`
+}
+
+type ReceiptUpload struct {
+	FileName string
+	Size     int64
+	Content  io.ReadSeeker
+}
+
+type ReceiptUploadResponse struct {
+	Receipts []models.ReimbursementReceipt `json:"receipts"`
+}
+
+type LeaveRequest struct {
+	UserID    string `json:"user_id"`
+	StartDate string `json:"start_date"` // YYYY-MM-DD
+	EndDate   string `json:"end_date"`   // YYYY-MM-DD
+	Paid      *bool  `json:"paid"`       // paid leave when omitted
+	Reason    string `json:"reason"`
+}
+
+type LeaveResponse struct {
+	ID string `json:"id"`
 }
 
 type SQLAttendance struct {
@@ -28,3 +80,9 @@ type SQLOvertime struct {
 	UserID sql.NullString `db:"user_id"`
 	Count  sql.NullInt64  `db:"count"`
 }
+
+type SQLLeaveDays struct {
+	UserID     sql.NullString `db:"user_id"`
+	PaidDays   sql.NullInt64  `db:"paid_days"`
+	UnpaidDays sql.NullInt64  `db:"unpaid_days"`
+}
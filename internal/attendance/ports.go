@@ -2,23 +2,41 @@ package attendance
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/rahadianir/dealls/internal/models"
+	"github.com/rahadianir/dealls/internal/pkg/xdate"
 )
 
 type AttendanceRepositoryInterface interface {
-	SubmitAttendance(ctx context.Context, userID string, timestamp time.Time) error
-	SubmitOvertime(ctx context.Context, userID string, hours int, timestamp time.Time) error
-	GetUserOvertimeByTime(ctx context.Context, userID string, date time.Time) (int, error)
-	SubmitReimbursement(ctx context.Context, userID string, amount float64, desc string) error
-	GetAllUserAttendancesByPeriod(ctx context.Context, start time.Time, end time.Time) ([]models.Attendance, error)
-	GetAllUserOvertimesByPeriod(ctx context.Context, start time.Time, end time.Time) ([]models.Overtime, error)
-	GetAllUserReimbursementsByPeriod(ctx context.Context, start time.Time, end time.Time) ([]models.Reimbursement, error)
+	SubmitAttendance(ctx context.Context, userID string, timestamp time.Time, date xdate.Date) error
+	SubmitOvertime(ctx context.Context, userID string, hours int, date xdate.Date) error
+	GetUserOvertimeByTime(ctx context.Context, userID string, date xdate.Date) (int, error)
+	SubmitReimbursement(ctx context.Context, data models.Reimbursement) error
+	StoreReimbursementReceipts(ctx context.Context, receipts []models.ReimbursementReceipt) error
+	GetReimbursementByID(ctx context.Context, id string) (models.Reimbursement, error)
+	GetReimbursementReceiptByID(ctx context.Context, reimbursementID string, receiptID string) (models.ReimbursementReceipt, error)
+	GetReimbursementCategories(ctx context.Context) ([]models.ReimbursementCategory, error)
+	GetReimbursementCategoryByCode(ctx context.Context, code string) (models.ReimbursementCategory, error)
+	UpsertReimbursementCategory(ctx context.Context, data models.ReimbursementCategory) error
+	GetUserReimbursementTotalInActivePeriod(ctx context.Context, userID string, categoryID string) (float64, error)
+	GetUserActivePeriodExchangeRate(ctx context.Context, userID string, currency string) (float64, error)
+	GetUsersAttendancesByPeriod(ctx context.Context, userIDs []string, start time.Time, end time.Time) ([]models.Attendance, error)
+	GetUsersOvertimesByPeriod(ctx context.Context, userIDs []string, start time.Time, end time.Time) ([]models.Overtime, error)
+	GetUsersReimbursementsByPeriod(ctx context.Context, userIDs []string, start time.Time, end time.Time) ([]models.Reimbursement, error)
+	SubmitLeave(ctx context.Context, data models.Leave) error
+	HasOverlappingLeave(ctx context.Context, userID string, start time.Time, end time.Time) (bool, error)
+	GetUsersLeaveDaysByPeriod(ctx context.Context, userIDs []string, start time.Time, end time.Time) ([]models.LeaveDays, error)
 }
 
 type AttendanceLogicInterface interface {
 	SubmitAttendance(ctx context.Context, userID string, timestamp string) error
 	SubmitOvertime(ctx context.Context, userID string, hourCount int, finishedOvertimeTimestamp string) error
-	SubmitReimbursement(ctx context.Context, userID string, amount float64, desc string) error
+	SubmitReimbursement(ctx context.Context, userID string, amount float64, currency string, desc string, category string, receipts []ReceiptUpload) (string, error)
+	UploadReimbursementReceipts(ctx context.Context, reimbursementID string, receipts []ReceiptUpload) ([]models.ReimbursementReceipt, error)
+	GetReimbursementReceipt(ctx context.Context, reimbursementID string, receiptID string) (models.ReimbursementReceipt, io.ReadCloser, error)
+	GetReimbursementCategories(ctx context.Context) ([]models.ReimbursementCategory, error)
+	SetReimbursementCategory(ctx context.Context, data models.ReimbursementCategory) error
+	SubmitLeave(ctx context.Context, userID string, startDate string, endDate string, paid bool, reason string) (string, error)
 }
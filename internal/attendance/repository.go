@@ -3,46 +3,47 @@ package attendance
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/huandu/go-sqlbuilder"
+	"github.com/lib/pq"
 	"github.com/rahadianir/dealls/internal/config"
 	"github.com/rahadianir/dealls/internal/models"
 	"github.com/rahadianir/dealls/internal/pkg/dbhelper"
+	"github.com/rahadianir/dealls/internal/pkg/xcontext"
+	"github.com/rahadianir/dealls/internal/pkg/xdate"
+	"github.com/rahadianir/dealls/internal/pkg/xerror"
+	"github.com/rahadianir/dealls/internal/pkg/xwebhook"
 )
 
 type AttendanceRepository struct {
-	deps *config.CommonDependencies
+	deps   *config.CommonDependencies
+	outbox xwebhook.Outbox
 }
 
-func NewAttendanceRepository(deps *config.CommonDependencies) *AttendanceRepository {
+func NewAttendanceRepository(deps *config.CommonDependencies, outbox xwebhook.Outbox) *AttendanceRepository {
 	return &AttendanceRepository{
-		deps: deps,
+		deps:   deps,
+		outbox: outbox,
 	}
 }
 
-func (repo *AttendanceRepository) SubmitAttendance(ctx context.Context, userID string, timestamp time.Time) error {
+// SubmitAttendance stores the attendance timestamp along with its date in the user's timezone
+func (repo *AttendanceRepository) SubmitAttendance(ctx context.Context, userID string, timestamp time.Time, date xdate.Date) error {
 	sq := sqlbuilder.NewInsertBuilder()
 	q, args := sq.InsertInto(`hr.attendances`).
-		Cols(`id`, `user_id`, `attendance_time`, `attendance_date`, `created_at`, `created_by`).
-		Values(uuid.NewString(), userID, timestamp, timestamp, `now()`, userID).
+		Cols(`id`, `company_id`, `user_id`, `attendance_time`, `attendance_date`, `created_at`, `created_by`).
+		Values(uuid.NewString(), xcontext.GetCompanyIDFromContext(ctx), userID, timestamp, date, `now()`, userID).
 		BuildWithFlavor(sqlbuilder.PostgreSQL)
 
-	tx, err := repo.deps.DB.BeginTxx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	_, err = tx.ExecContext(ctx, q, args...)
-	if err != nil {
-		return err
-	}
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
 
-	err = tx.Commit()
+	_, err := tx.ExecContext(ctx, q, args...)
 	if err != nil {
 		return err
 	}
@@ -50,25 +51,16 @@ func (repo *AttendanceRepository) SubmitAttendance(ctx context.Context, userID s
 	return nil
 }
 
-func (repo *AttendanceRepository) SubmitOvertime(ctx context.Context, userID string, hours int, timestamp time.Time) error {
+func (repo *AttendanceRepository) SubmitOvertime(ctx context.Context, userID string, hours int, date xdate.Date) error {
 	sq := sqlbuilder.NewInsertBuilder()
 	q, args := sq.InsertInto(`hr.overtimes`).
-		Cols(`id`, `user_id`, `date`, `hour_count`, `created_at`, `created_by`).
-		Values(uuid.NewString(), userID, timestamp, hours, `now()`, userID).
+		Cols(`id`, `company_id`, `user_id`, `date`, `hour_count`, `created_at`, `created_by`).
+		Values(uuid.NewString(), xcontext.GetCompanyIDFromContext(ctx), userID, date, hours, `now()`, userID).
 		BuildWithFlavor(sqlbuilder.PostgreSQL)
 
-	tx, err := repo.deps.DB.BeginTxx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	_, err = tx.ExecContext(ctx, q, args...)
-	if err != nil {
-		return err
-	}
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
 
-	err = tx.Commit()
+	_, err := tx.ExecContext(ctx, q, args...)
 	if err != nil {
 		return err
 	}
@@ -76,12 +68,13 @@ func (repo *AttendanceRepository) SubmitOvertime(ctx context.Context, userID str
 	return nil
 }
 
-func (repo *AttendanceRepository) GetUserOvertimeByTime(ctx context.Context, userID string, date time.Time) (int, error) {
+func (repo *AttendanceRepository) GetUserOvertimeByTime(ctx context.Context, userID string, date xdate.Date) (int, error) {
 	sq := sqlbuilder.NewSelectBuilder()
 	sq.Select(`SUM(hour_count)`).From(`hr.overtimes`).Where(
 		sq.And(
 			sq.Equal(`date`, date),
 			sq.Equal(`user_id`, userID),
+			sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
 			sq.IsNull(`deleted_at`),
 		),
 	)
@@ -101,25 +94,219 @@ func (repo *AttendanceRepository) GetUserOvertimeByTime(ctx context.Context, use
 
 }
 
-func (repo *AttendanceRepository) SubmitReimbursement(ctx context.Context, userID string, amount float64, desc string) error {
+func (repo *AttendanceRepository) SubmitReimbursement(ctx context.Context, data models.Reimbursement) error {
 	sq := sqlbuilder.NewInsertBuilder()
 	q, args := sq.InsertInto(`hr.reimbursements`).
-		Cols(`id`, `user_id`, `amount`, `description`, `created_at`, `created_by`).
-		Values(uuid.NewString(), userID, amount, desc, `now()`, userID).
+		Cols(`id`, `company_id`, `user_id`, `amount`, `currency`, `description`, `category_id`, `created_at`, `created_by`).
+		Values(data.ID, xcontext.GetCompanyIDFromContext(ctx), data.UserID, data.Amount, data.Currency, data.Description, data.CategoryID, `now()`, data.UserID).
 		BuildWithFlavor(sqlbuilder.PostgreSQL)
 
-	tx, err := repo.deps.DB.BeginTxx(ctx, nil)
+	return dbhelper.WithTransaction(ctx, repo.deps.DB, func(ctx context.Context) error {
+		tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+		_, err := tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			return err
+		}
+
+		// store the receipts within the same transaction so a reimbursement never ends up half-linked
+		err = repo.storeReimbursementReceipts(ctx, tx, data.Receipts, data.UserID)
+		if err != nil {
+			repo.deps.Logger.ErrorContext(ctx, "failed to store reimbursement receipts", slog.Any("error", err))
+			return err
+		}
+
+		// there's no approval step, a claim is approved once it passes the category policy on submission
+		err = repo.outbox.Enqueue(ctx, xwebhook.Event{
+			Type: xwebhook.EventReimbursementApproved,
+			Data: ReimbursementEvent{
+				ID:          data.ID,
+				UserID:      data.UserID,
+				Amount:      data.Amount,
+				Currency:    data.Currency,
+				Description: data.Description,
+				Category:    data.Category,
+				Receipts:    len(data.Receipts),
+			},
+		})
+		if err != nil {
+			repo.deps.Logger.ErrorContext(ctx, "failed to enqueue reimbursement approved event", slog.Any("error", err))
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (repo *AttendanceRepository) StoreReimbursementReceipts(ctx context.Context, receipts []models.ReimbursementReceipt) error {
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	return repo.storeReimbursementReceipts(ctx, tx, receipts, xcontext.GetUserIDFromContext(ctx))
+}
+
+func (repo *AttendanceRepository) storeReimbursementReceipts(ctx context.Context, tx dbhelper.DBTX, receipts []models.ReimbursementReceipt, createdBy string) error {
+	if len(receipts) == 0 {
+		return nil
+	}
+
+	sq := sqlbuilder.NewInsertBuilder()
+	sq.InsertInto(`hr.reimbursement_receipts`).
+		Cols(`id`, `company_id`, `reimbursement_id`, `file_name`, `content_type`, `size_bytes`, `storage_key`, `created_at`, `created_by`)
+	for _, receipt := range receipts {
+		sq.Values(receipt.ID, xcontext.GetCompanyIDFromContext(ctx), receipt.ReimbursementID, receipt.FileName, receipt.ContentType, receipt.Size, receipt.StorageKey, `now()`, createdBy)
+	}
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	_, err := tx.ExecContext(ctx, q, args...)
 	if err != nil {
 		return err
 	}
-	defer tx.Rollback()
 
-	_, err = tx.ExecContext(ctx, q, args...)
+	return nil
+}
+
+func (repo *AttendanceRepository) GetReimbursementByID(ctx context.Context, id string) (models.Reimbursement, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`id`, `user_id`, `amount`, `description`, `category_id`).From(`hr.reimbursements`).
+		Where(
+			sq.And(
+				sq.Equal(`id`, id),
+				sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+				sq.IsNull(`deleted_at`),
+			),
+		)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	var temp models.SQLReimbursement
+	err := tx.QueryRowxContext(ctx, q, args...).StructScan(&temp)
 	if err != nil {
-		return err
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Reimbursement{}, xerror.ErrDataNotFound
+		}
+
+		return models.Reimbursement{}, err
+	}
+
+	result := models.Reimbursement{
+		ID:          temp.ID.String,
+		UserID:      temp.UserID.String,
+		Amount:      temp.Amount.Float64,
+		Description: temp.Description.String,
+		CategoryID:  temp.CategoryID.String,
 	}
 
-	err = tx.Commit()
+	return result, nil
+}
+
+func (repo *AttendanceRepository) GetReimbursementReceiptByID(ctx context.Context, reimbursementID string, receiptID string) (models.ReimbursementReceipt, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`id`, `reimbursement_id`, `file_name`, `content_type`, `size_bytes`, `storage_key`).From(`hr.reimbursement_receipts`).
+		Where(
+			sq.And(
+				sq.Equal(`id`, receiptID),
+				sq.Equal(`reimbursement_id`, reimbursementID),
+				sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+				sq.IsNull(`deleted_at`),
+			),
+		)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	var temp models.SQLReimbursementReceipt
+	err := tx.QueryRowxContext(ctx, q, args...).StructScan(&temp)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.ReimbursementReceipt{}, xerror.ErrDataNotFound
+		}
+
+		return models.ReimbursementReceipt{}, err
+	}
+
+	result := models.ReimbursementReceipt{
+		ID:              temp.ID.String,
+		ReimbursementID: temp.ReimbursementID.String,
+		FileName:        temp.FileName.String,
+		ContentType:     temp.ContentType.String,
+		Size:            temp.Size.Int64,
+		StorageKey:      temp.StorageKey.String,
+	}
+
+	return result, nil
+}
+
+func (repo *AttendanceRepository) GetReimbursementCategories(ctx context.Context) ([]models.ReimbursementCategory, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`id`, `code`, `name`, `per_claim_limit`, `per_period_limit`, `receipt_required`, `taxable`).From(`hr.reimbursement_categories`).
+		Where(
+			sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+			sq.IsNull(`deleted_at`),
+		).
+		OrderBy(`code`)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	rows, err := tx.QueryxContext(ctx, q, args...)
+	if err != nil {
+		return []models.ReimbursementCategory{}, err
+	}
+	defer rows.Close()
+
+	var temp models.SQLReimbursementCategory
+	var result []models.ReimbursementCategory
+	for rows.Next() {
+		err := rows.StructScan(&temp)
+		if err != nil {
+			repo.deps.Logger.WarnContext(ctx, "failed to scan reimbursement category", slog.Any("error", err))
+			continue
+		}
+		result = append(result, toReimbursementCategory(temp))
+	}
+
+	return result, nil
+}
+
+func (repo *AttendanceRepository) GetReimbursementCategoryByCode(ctx context.Context, code string) (models.ReimbursementCategory, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`id`, `code`, `name`, `per_claim_limit`, `per_period_limit`, `receipt_required`, `taxable`).From(`hr.reimbursement_categories`).
+		Where(
+			sq.And(
+				sq.Equal(`code`, code),
+				sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+				sq.IsNull(`deleted_at`),
+			),
+		)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	var temp models.SQLReimbursementCategory
+	err := tx.QueryRowxContext(ctx, q, args...).StructScan(&temp)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.ReimbursementCategory{}, xerror.ErrDataNotFound
+		}
+
+		return models.ReimbursementCategory{}, err
+	}
+
+	return toReimbursementCategory(temp), nil
+}
+
+func (repo *AttendanceRepository) UpsertReimbursementCategory(ctx context.Context, data models.ReimbursementCategory) error {
+	sq := sqlbuilder.NewInsertBuilder()
+	sq.InsertInto(`hr.reimbursement_categories`).
+		Cols(`id`, `company_id`, `code`, `name`, `per_claim_limit`, `per_period_limit`, `receipt_required`, `taxable`, `created_at`, `created_by`).
+		Values(data.ID, xcontext.GetCompanyIDFromContext(ctx), data.Code, data.Name, data.PerClaimLimit, data.PerPeriodLimit, data.ReceiptRequired, data.Taxable, `now()`, xcontext.GetUserIDFromContext(ctx)).
+		SQL(`ON CONFLICT (company_id, code) DO UPDATE SET name = EXCLUDED.name, per_claim_limit = EXCLUDED.per_claim_limit, per_period_limit = EXCLUDED.per_period_limit, receipt_required = EXCLUDED.receipt_required, taxable = EXCLUDED.taxable, updated_at = now(), updated_by = EXCLUDED.created_by, deleted_at = NULL`)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	_, err := tx.ExecContext(ctx, q, args...)
 	if err != nil {
 		return err
 	}
@@ -127,12 +314,99 @@ func (repo *AttendanceRepository) SubmitReimbursement(ctx context.Context, userI
 	return nil
 }
 
-func (repo *AttendanceRepository) GetAllUserAttendancesByPeriod(ctx context.Context, start time.Time, end time.Time) ([]models.Attendance, error) {
+// GetUserReimbursementTotalInActivePeriod sums the user's claims of a category submitted within the active payroll period of the user's pay group,
+// in the base currency converted with the period exchange rates
+func (repo *AttendanceRepository) GetUserReimbursementTotalInActivePeriod(ctx context.Context, userID string, categoryID string) (float64, error) {
+	createdDate := repo.reimbursementDate()
+
+	// base currency claims have no exchange rate
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`COALESCE(SUM(r.amount * COALESCE(er.rate, 1)), 0)`).From(`hr.reimbursements r`).
+		Join(`hr.users u`, `u.id = r.user_id`).
+		Join(`hr.payrolls p`, `p.active`, `p.pay_group_id = u.pay_group_id`, createdDate+` >= p.start_date`, createdDate+` < p.end_date`).
+		JoinWithOption(sqlbuilder.LeftJoin, `hr.exchange_rates er`, `er.payroll_id = p.id`, `er.currency = r.currency`).
+		Where(
+			sq.And(
+				sq.Equal(`r.user_id`, userID),
+				sq.Equal(`r.category_id`, categoryID),
+				sq.Equal(`r.company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+				sq.IsNull(`r.deleted_at`),
+			),
+		)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	var total sql.NullFloat64
+	err := tx.QueryRowxContext(ctx, q, args...).Scan(&total)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+
+		return 0, err
+	}
+
+	return total.Float64, nil
+}
+
+// GetUserActivePeriodExchangeRate gets the currency exchange rate of the active payroll period of the user's pay group
+func (repo *AttendanceRepository) GetUserActivePeriodExchangeRate(ctx context.Context, userID string, currency string) (float64, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`er.rate`).From(`hr.exchange_rates er`).
+		Join(`hr.payrolls p`, `p.id = er.payroll_id`, `p.active`).
+		Join(`hr.users u`, `u.pay_group_id = p.pay_group_id`).
+		Where(
+			sq.And(
+				sq.Equal(`u.id`, userID),
+				sq.Equal(`er.currency`, currency),
+				sq.Equal(`er.company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+			),
+		)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	var rate float64
+	err := tx.QueryRowxContext(ctx, q, args...).Scan(&rate)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, xerror.ErrDataNotFound
+		}
+
+		return 0, err
+	}
+
+	return rate, nil
+}
+
+func toReimbursementCategory(temp models.SQLReimbursementCategory) models.ReimbursementCategory {
+	result := models.ReimbursementCategory{
+		ID:              temp.ID.String,
+		Code:            temp.Code.String,
+		Name:            temp.Name.String,
+		ReceiptRequired: temp.ReceiptRequired.Bool,
+		Taxable:         temp.Taxable.Bool,
+	}
+	if temp.PerClaimLimit.Valid {
+		result.PerClaimLimit = &temp.PerClaimLimit.Float64
+	}
+	if temp.PerPeriodLimit.Valid {
+		result.PerPeriodLimit = &temp.PerPeriodLimit.Float64
+	}
+
+	return result
+}
+
+func (repo *AttendanceRepository) GetUsersAttendancesByPeriod(ctx context.Context, userIDs []string, start time.Time, end time.Time) ([]models.Attendance, error) {
 	sq := sqlbuilder.NewSelectBuilder()
 	sq.Select(`count(distinct(user_id, attendance_date)) as count`, `user_id`).From(`hr.attendances`).
 		Where(
 			sq.And(
-				sq.Between(`attendance_date`, start, end),
+				sq.In(`user_id::text`, sqlbuilder.List(userIDs)),
+				sq.GreaterEqualThan(`attendance_date`, xdate.Of(start)),
+				sq.LessThan(`attendance_date`, xdate.Of(end)),
+				sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
 				sq.IsNull(`deleted_at`),
 			),
 		).
@@ -164,12 +438,15 @@ func (repo *AttendanceRepository) GetAllUserAttendancesByPeriod(ctx context.Cont
 	return result, nil
 }
 
-func (repo *AttendanceRepository) GetAllUserOvertimesByPeriod(ctx context.Context, start time.Time, end time.Time) ([]models.Overtime, error) {
+func (repo *AttendanceRepository) GetUsersOvertimesByPeriod(ctx context.Context, userIDs []string, start time.Time, end time.Time) ([]models.Overtime, error) {
 	sq := sqlbuilder.NewSelectBuilder()
 	sq.Select(`sum(hour_count) as count`, `user_id`).From(`hr.overtimes`).
 		Where(
 			sq.And(
-				sq.Between(`date`, start, end),
+				sq.In(`user_id::text`, sqlbuilder.List(userIDs)),
+				sq.GreaterEqualThan(`date`, xdate.Of(start)),
+				sq.LessThan(`date`, xdate.Of(end)),
+				sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
 				sq.IsNull(`deleted_at`),
 			),
 		).
@@ -201,15 +478,26 @@ func (repo *AttendanceRepository) GetAllUserOvertimesByPeriod(ctx context.Contex
 	return result, nil
 }
 
-func (repo *AttendanceRepository) GetAllUserReimbursementsByPeriod(ctx context.Context, start time.Time, end time.Time) ([]models.Reimbursement, error) {
+func (repo *AttendanceRepository) GetUsersReimbursementsByPeriod(ctx context.Context, userIDs []string, start time.Time, end time.Time) ([]models.Reimbursement, error) {
+	createdDate := repo.reimbursementDate()
+
+	// receipts are aggregated as JSON so they can be snapshotted onto the payslip along with the reimbursement
 	sq := sqlbuilder.NewSelectBuilder()
-	sq.Select(`id`, `user_id`, `amount`, `description`).From(`hr.reimbursements`).
+	sq.Select(`r.id`, `r.user_id`, `r.amount`, `r.currency`, `r.description`, `r.category_id`, `c.code AS category`, `c.name AS category_name`, `c.taxable`,
+		`COALESCE(json_agg(json_build_object('id', rr.id, 'file_name', rr.file_name, 'content_type', rr.content_type, 'size', rr.size_bytes) ORDER BY rr.created_at) FILTER (WHERE rr.id IS NOT NULL), '[]') AS receipts`).
+		From(`hr.reimbursements r`).
+		JoinWithOption(sqlbuilder.LeftJoin, `hr.reimbursement_categories c`, `c.id = r.category_id`).
+		JoinWithOption(sqlbuilder.LeftJoin, `hr.reimbursement_receipts rr`, `rr.reimbursement_id = r.id`, `rr.deleted_at IS NULL`).
 		Where(
 			sq.And(
-				sq.Between(`created_at`, start, end),
-				sq.IsNull(`deleted_at`),
+				sq.In(`r.user_id::text`, sqlbuilder.List(userIDs)),
+				sq.GreaterEqualThan(createdDate, xdate.Of(start)),
+				sq.LessThan(createdDate, xdate.Of(end)),
+				sq.Equal(`r.company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+				sq.IsNull(`r.deleted_at`),
 			),
-		)
+		).
+		GroupBy(`r.id`, `c.id`)
 	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
 
 	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
@@ -227,13 +515,120 @@ func (repo *AttendanceRepository) GetAllUserReimbursementsByPeriod(ctx context.C
 			repo.deps.Logger.WarnContext(ctx, "failed to scan reimbursement data", slog.Any("error", err))
 			continue
 		}
+
+		var receipts []models.ReimbursementReceipt
+		err = json.Unmarshal(temp.Receipts, &receipts)
+		if err != nil {
+			repo.deps.Logger.WarnContext(ctx, "failed to unmarshal reimbursement receipts", slog.Any("error", err))
+		}
+
 		result = append(result, models.Reimbursement{
-			ID:          temp.ID.String,
-			UserID:      temp.UserID.String,
-			Amount:      temp.Amount.Float64,
-			Description: temp.Description.String,
+			ID:           temp.ID.String,
+			UserID:       temp.UserID.String,
+			Amount:       temp.Amount.Float64,
+			Currency:     temp.Currency.String,
+			Description:  temp.Description.String,
+			CategoryID:   temp.CategoryID.String,
+			Category:     temp.Category.String,
+			CategoryName: temp.CategoryName.String,
+			Taxable:      temp.Taxable.Bool,
+			Receipts:     receipts,
+		})
+	}
+
+	return result, nil
+}
+
+func (repo *AttendanceRepository) SubmitLeave(ctx context.Context, data models.Leave) error {
+	sq := sqlbuilder.NewInsertBuilder()
+	q, args := sq.InsertInto(`hr.leaves`).
+		Cols(`id`, `company_id`, `user_id`, `start_date`, `end_date`, `paid`, `reason`, `created_at`, `created_by`).
+		Values(data.ID, xcontext.GetCompanyIDFromContext(ctx), data.UserID, data.StartDate, data.EndDate, data.Paid, data.Reason, `now()`, data.UserID).
+		BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	_, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repo *AttendanceRepository) HasOverlappingLeave(ctx context.Context, userID string, start time.Time, end time.Time) (bool, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`count(*) > 0`).From(`hr.leaves`).
+		Where(
+			sq.And(
+				sq.Equal(`user_id`, userID),
+				sq.LessEqualThan(`start_date`, end),
+				sq.GreaterEqualThan(`end_date`, start),
+				sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+				sq.IsNull(`deleted_at`),
+			),
+		)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	var exists bool
+	err := tx.QueryRowxContext(ctx, q, args...).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// GetUsersLeaveDaysByPeriod counts the leave work days of the users within the period,
+// days the user also submitted attendance on are not counted as leave
+func (repo *AttendanceRepository) GetUsersLeaveDaysByPeriod(ctx context.Context, userIDs []string, start time.Time, end time.Time) ([]models.LeaveDays, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`l.user_id`, `count(DISTINCT d.day) FILTER (WHERE l.paid) AS paid_days`, `count(DISTINCT d.day) FILTER (WHERE NOT l.paid) AS unpaid_days`).
+		From(`hr.leaves l`).
+		Join(fmt.Sprintf(`LATERAL generate_series(GREATEST(l.start_date, %s::date), LEAST(l.end_date, %s::date - 1), interval '1 day') AS d(day)`, sq.Var(xdate.Of(start)), sq.Var(xdate.Of(end))), `true`).
+		Where(
+			sq.And(
+				sq.In(`l.user_id::text`, sqlbuilder.List(userIDs)),
+				sq.LessThan(`l.start_date`, xdate.Of(end)),
+				sq.GreaterEqualThan(`l.end_date`, xdate.Of(start)),
+				sq.Equal(`l.company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+				sq.IsNull(`l.deleted_at`),
+				`extract(isodow FROM d.day) < 6`,
+				`NOT EXISTS (SELECT 1 FROM hr.attendances a WHERE a.user_id = l.user_id AND a.attendance_date = d.day::date AND a.deleted_at IS NULL)`,
+			),
+		).
+		GroupBy(`l.user_id`)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	rows, err := tx.QueryxContext(ctx, q, args...)
+	if err != nil {
+		return []models.LeaveDays{}, err
+	}
+	defer rows.Close()
+
+	var temp SQLLeaveDays
+	var result []models.LeaveDays
+	for rows.Next() {
+		err := rows.StructScan(&temp)
+		if err != nil {
+			repo.deps.Logger.WarnContext(ctx, "failed to scan leave days data", slog.Any("error", err))
+			continue
+		}
+		result = append(result, models.LeaveDays{
+			UserID:     temp.UserID.String,
+			PaidDays:   int(temp.PaidDays.Int64),
+			UnpaidDays: int(temp.UnpaidDays.Int64),
 		})
 	}
 
 	return result, nil
 }
+
+// reimbursementDate is the submission date of the reimbursement r in the company timezone, which decides its payroll period
+func (repo *AttendanceRepository) reimbursementDate() string {
+	return fmt.Sprintf(`(r.created_at AT TIME ZONE %s)::date`, pq.QuoteLiteral(repo.deps.Config.App.Timezone.String()))
+}
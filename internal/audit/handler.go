@@ -0,0 +1,49 @@
+package audit
+
+import (
+	"net/http"
+
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/pkg/xerror"
+	"github.com/rahadianir/dealls/internal/pkg/xhttp"
+)
+
+type AuditHandler struct {
+	deps       *config.CommonDependencies
+	auditLogic AuditLogicInterface
+}
+
+func NewAuditHandler(deps *config.CommonDependencies, auditLogic AuditLogicInterface) *AuditHandler {
+	return &AuditHandler{
+		deps:       deps,
+		auditLogic: auditLogic,
+	}
+}
+
+func (handler *AuditHandler) GetAuditLogs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	result, err := handler.auditLogic.GetAuditLogs(r.Context(), AuditLogRequest{
+		ActorID:   query.Get("actor_id"),
+		APIKeyID:  query.Get("api_key_id"),
+		RequestID: query.Get("request_id"),
+		Action:    query.Get("action"),
+		Entity:    query.Get("entity"),
+		EntityID:  query.Get("entity_id"),
+		From:      query.Get("from"),
+		To:        query.Get("to"),
+		Cursor:    query.Get("cursor"),
+		Limit:     query.Get("limit"),
+	})
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to get audit logs",
+		}, xerror.ParseErrorTypeToCodeInt(err))
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "audit logs fetched",
+		Data:    result,
+	}, http.StatusOK)
+}
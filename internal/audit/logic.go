@@ -0,0 +1,115 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/pkg/xcontext"
+	"github.com/rahadianir/dealls/internal/pkg/xerror"
+	"github.com/rahadianir/dealls/internal/user"
+)
+
+type AuditLogic struct {
+	deps      *config.CommonDependencies
+	auditRepo AuditRepositoryInterface
+	userRepo  user.UserRepositoryInterface
+}
+
+func NewAuditLogic(deps *config.CommonDependencies, auditRepo AuditRepositoryInterface, userRepo user.UserRepositoryInterface) *AuditLogic {
+	return &AuditLogic{
+		deps:      deps,
+		auditRepo: auditRepo,
+		userRepo:  userRepo,
+	}
+}
+
+// GetAuditLogs returns a page of the company audit log entries matching the filter, newest first
+func (logic *AuditLogic) GetAuditLogs(ctx context.Context, req AuditLogRequest) (AuditLogPage, error) {
+	// check admin role of the user
+	isAdmin, err := logic.userRepo.IsAdmin(ctx, xcontext.GetUserIDFromContext(ctx))
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to check user admin role", slog.Any("error", err))
+		return AuditLogPage{}, err
+	}
+
+	if !isAdmin {
+		return AuditLogPage{}, xerror.AuthError{Err: fmt.Errorf("admin only operation")}
+	}
+
+	filter, err := toAuditLogFilter(req)
+	if err != nil {
+		return AuditLogPage{}, err
+	}
+
+	// one more entry than asked for tells whether there's a next page
+	limit := filter.Limit
+	filter.Limit++
+	entries, err := logic.auditRepo.GetAuditLogs(ctx, filter)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get audit logs", slog.Any("error", err))
+		return AuditLogPage{}, err
+	}
+
+	result := AuditLogPage{Entries: entries}
+	if len(entries) > limit {
+		result.Entries = entries[:limit]
+		result.NextCursor = result.Entries[limit-1].ID
+	}
+
+	return result, nil
+}
+
+func toAuditLogFilter(req AuditLogRequest) (AuditLogFilter, error) {
+	filter := AuditLogFilter{
+		ActorID:   req.ActorID,
+		APIKeyID:  req.APIKeyID,
+		RequestID: req.RequestID,
+		Action:    req.Action,
+		Entity:    req.Entity,
+		EntityID:  req.EntityID,
+		Limit:     DefaultLimit,
+	}
+
+	if req.Cursor != "" {
+		_, err := uuid.Parse(req.Cursor)
+		if err != nil {
+			return AuditLogFilter{}, xerror.ClientError{Err: fmt.Errorf("invalid cursor")}
+		}
+		filter.Cursor = req.Cursor
+	}
+
+	if req.Limit != "" {
+		limit, err := strconv.Atoi(req.Limit)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return AuditLogFilter{}, xerror.ClientError{Err: fmt.Errorf("limit must be between 1 and %d", MaxLimit)}
+		}
+		filter.Limit = limit
+	}
+
+	if req.From != "" {
+		from, err := time.Parse(time.RFC3339, req.From)
+		if err != nil {
+			return AuditLogFilter{}, xerror.ClientError{Err: fmt.Errorf("invalid from time: %w", err)}
+		}
+		filter.From = &from
+	}
+
+	if req.To != "" {
+		to, err := time.Parse(time.RFC3339, req.To)
+		if err != nil {
+			return AuditLogFilter{}, xerror.ClientError{Err: fmt.Errorf("invalid to time: %w", err)}
+		}
+		filter.To = &to
+	}
+
+	if filter.From != nil && filter.To != nil && !filter.To.After(*filter.From) {
+		return AuditLogFilter{}, xerror.ClientError{Err: fmt.Errorf("to must be after from")}
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,115 @@
+package audit
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/pkg/xaudit"
+	"github.com/rahadianir/dealls/internal/pkg/xcontext"
+	"github.com/rahadianir/dealls/internal/user"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAuditLogic_GetAuditLogs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := NewMockAuditRepositoryInterface(ctrl)
+	mockUserRepo := user.NewMockUserRepositoryInterface(ctrl)
+	mockDeps := config.CommonDependencies{
+		Config: config.InitConfig(context.Background()),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	ctx := context.WithValue(context.Background(), xcontext.UserIDKey, "admin-id")
+	from := time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)
+	entries := []AuditLog{
+		{ID: "3f1c6f5e-9a4b-4c1e-8f3a-0d6b2a1e7c01", Action: xaudit.ActionUpdate, Entity: xaudit.EntitySalary, EntityID: "user-id"},
+		{ID: "3f1c6f5e-9a4b-4c1e-8f3a-0d6b2a1e7c02", Action: xaudit.ActionCreate, Entity: xaudit.EntitySalary, EntityID: "user-id"},
+		{ID: "3f1c6f5e-9a4b-4c1e-8f3a-0d6b2a1e7c03", Action: xaudit.ActionCreate, Entity: xaudit.EntityUser, EntityID: "user-id"},
+	}
+
+	type args struct {
+		ctx context.Context
+		req AuditLogRequest
+	}
+	tests := []struct {
+		name      string
+		args      args
+		want      AuditLogPage
+		wantErr   bool
+		behaviour func(a args)
+	}{
+		// TODO: Add test cases.
+		{
+			name: "success get a page with a next cursor",
+			args: args{ctx: ctx, req: AuditLogRequest{Entity: xaudit.EntitySalary, From: from.Format(time.RFC3339), To: to.Format(time.RFC3339), Limit: "2"}},
+			want: AuditLogPage{Entries: entries[:2], NextCursor: entries[1].ID},
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+				mockRepo.EXPECT().GetAuditLogs(gomock.Any(), AuditLogFilter{Entity: xaudit.EntitySalary, From: &from, To: &to, Limit: 3}).Return(entries, nil)
+			},
+		},
+		{
+			name: "success get the last page",
+			args: args{ctx: ctx, req: AuditLogRequest{Cursor: entries[0].ID}},
+			want: AuditLogPage{Entries: entries[1:]},
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+				mockRepo.EXPECT().GetAuditLogs(gomock.Any(), AuditLogFilter{Cursor: entries[0].ID, Limit: DefaultLimit + 1}).Return(entries[1:], nil)
+			},
+		},
+		{
+			name:    "failed limit over the max",
+			args:    args{ctx: ctx, req: AuditLogRequest{Limit: "500"}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+			},
+		},
+		{
+			name:    "failed invalid cursor",
+			args:    args{ctx: ctx, req: AuditLogRequest{Cursor: "not-an-id"}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+			},
+		},
+		{
+			name:    "failed to before from",
+			args:    args{ctx: ctx, req: AuditLogRequest{From: to.Format(time.RFC3339), To: from.Format(time.RFC3339)}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+			},
+		},
+		{
+			name:    "failed not admin",
+			args:    args{ctx: ctx, req: AuditLogRequest{}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(false, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := NewAuditLogic(&mockDeps, mockRepo, mockUserRepo)
+			tt.behaviour(tt.args)
+			got, err := logic.GetAuditLogs(tt.args.ctx, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AuditLogic.GetAuditLogs() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AuditLogic.GetAuditLogs() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/audit/ports.go
+//
+// Generated by this command:
+//
+//	mockgen -source internal/audit/ports.go -destination internal/audit/mock_ports.go -package audit
+//
+
+// Package audit is a generated GoMock package.
+package audit
+
+import (
+	context "context"
+	reflect "reflect"
+
+	xaudit "github.com/rahadianir/dealls/internal/pkg/xaudit"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAuditRepositoryInterface is a mock of AuditRepositoryInterface interface.
+type MockAuditRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditRepositoryInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockAuditRepositoryInterfaceMockRecorder is the mock recorder for MockAuditRepositoryInterface.
+type MockAuditRepositoryInterfaceMockRecorder struct {
+	mock *MockAuditRepositoryInterface
+}
+
+// NewMockAuditRepositoryInterface creates a new mock instance.
+func NewMockAuditRepositoryInterface(ctrl *gomock.Controller) *MockAuditRepositoryInterface {
+	mock := &MockAuditRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockAuditRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditRepositoryInterface) EXPECT() *MockAuditRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// GetAuditLogs mocks base method.
+func (m *MockAuditRepositoryInterface) GetAuditLogs(ctx context.Context, filter AuditLogFilter) ([]AuditLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditLogs", ctx, filter)
+	ret0, _ := ret[0].([]AuditLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuditLogs indicates an expected call of GetAuditLogs.
+func (mr *MockAuditRepositoryInterfaceMockRecorder) GetAuditLogs(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditLogs", reflect.TypeOf((*MockAuditRepositoryInterface)(nil).GetAuditLogs), ctx, filter)
+}
+
+// Record mocks base method.
+func (m *MockAuditRepositoryInterface) Record(ctx context.Context, entry xaudit.Entry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Record", ctx, entry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Record indicates an expected call of Record.
+func (mr *MockAuditRepositoryInterfaceMockRecorder) Record(ctx, entry any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockAuditRepositoryInterface)(nil).Record), ctx, entry)
+}
+
+// MockAuditLogicInterface is a mock of AuditLogicInterface interface.
+type MockAuditLogicInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditLogicInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockAuditLogicInterfaceMockRecorder is the mock recorder for MockAuditLogicInterface.
+type MockAuditLogicInterfaceMockRecorder struct {
+	mock *MockAuditLogicInterface
+}
+
+// NewMockAuditLogicInterface creates a new mock instance.
+func NewMockAuditLogicInterface(ctrl *gomock.Controller) *MockAuditLogicInterface {
+	mock := &MockAuditLogicInterface{ctrl: ctrl}
+	mock.recorder = &MockAuditLogicInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditLogicInterface) EXPECT() *MockAuditLogicInterfaceMockRecorder {
+	return m.recorder
+}
+
+// GetAuditLogs mocks base method.
+func (m *MockAuditLogicInterface) GetAuditLogs(ctx context.Context, req AuditLogRequest) (AuditLogPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditLogs", ctx, req)
+	ret0, _ := ret[0].(AuditLogPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuditLogs indicates an expected call of GetAuditLogs.
+func (mr *MockAuditLogicInterfaceMockRecorder) GetAuditLogs(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditLogs", reflect.TypeOf((*MockAuditLogicInterface)(nil).GetAuditLogs), ctx, req)
+}
@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// page sizes of the audit log query
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+// AuditLog is an entry of the append-only audit log, the actor is empty for changes made by the system
+// (e.g. the payroll scheduler) and the API key is set for changes made with one
+type AuditLog struct {
+	ID        string          `json:"id"`
+	ActorID   string          `json:"actor_id"`
+	APIKeyID  string          `json:"api_key_id,omitempty"`
+	RequestID string          `json:"request_id"`
+	IP        string          `json:"ip"`
+	Action    string          `json:"action"`
+	Entity    string          `json:"entity"`
+	EntityID  string          `json:"entity_id"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type SQLAuditLog struct {
+	ID        sql.NullString `db:"id"`
+	ActorID   sql.NullString `db:"actor_id"`
+	APIKeyID  sql.NullString `db:"api_key_id"`
+	RequestID sql.NullString `db:"request_id"`
+	IP        sql.NullString `db:"ip"`
+	Action    sql.NullString `db:"action"`
+	Entity    sql.NullString `db:"entity"`
+	EntityID  sql.NullString `db:"entity_id"`
+	Before    []byte         `db:"before"`
+	After     []byte         `db:"after"`
+	CreatedAt sql.NullTime   `db:"created_at"`
+}
+
+// AuditLogFilter narrows the audit log down, empty fields match everything. Entries are returned newest first,
+// starting after the Cursor entry when it's set.
+type AuditLogFilter struct {
+	ActorID   string
+	APIKeyID  string
+	RequestID string
+	Action    string
+	Entity    string
+	EntityID  string
+	From      *time.Time
+	To        *time.Time
+	Cursor    string
+	Limit     int
+}
+
+type AuditLogRequest struct {
+	ActorID   string
+	APIKeyID  string
+	RequestID string
+	Action    string
+	Entity    string
+	EntityID  string
+	From      string // RFC 3339
+	To        string // RFC 3339, exclusive
+	Cursor    string
+	Limit     string
+}
+
+type AuditLogPage struct {
+	Entries []AuditLog `json:"entries"`
+
+	// cursor of the next page, empty on the last one
+	NextCursor string `json:"next_cursor"`
+}
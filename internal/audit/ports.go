@@ -0,0 +1,16 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/rahadianir/dealls/internal/pkg/xaudit"
+)
+
+type AuditRepositoryInterface interface {
+	Record(ctx context.Context, entry xaudit.Entry) error
+	GetAuditLogs(ctx context.Context, filter AuditLogFilter) ([]AuditLog, error)
+}
+
+type AuditLogicInterface interface {
+	GetAuditLogs(ctx context.Context, req AuditLogRequest) (AuditLogPage, error)
+}
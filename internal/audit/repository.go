@@ -0,0 +1,162 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/pkg/dbhelper"
+	"github.com/rahadianir/dealls/internal/pkg/xaudit"
+	"github.com/rahadianir/dealls/internal/pkg/xcontext"
+)
+
+type AuditRepository struct {
+	deps *config.CommonDependencies
+}
+
+func NewAuditRepository(deps *config.CommonDependencies) *AuditRepository {
+	return &AuditRepository{
+		deps: deps,
+	}
+}
+
+// Record appends the entry to the audit log of the context company
+func (repo *AuditRepository) Record(ctx context.Context, entry xaudit.Entry) error {
+	companyID := xcontext.GetCompanyIDFromContext(ctx)
+	if companyID == "" {
+		return fmt.Errorf("no company in context")
+	}
+
+	before, err := marshalState(entry.Before)
+	if err != nil {
+		return fmt.Errorf("failed to marshal before state: %w", err)
+	}
+
+	after, err := marshalState(entry.After)
+	if err != nil {
+		return fmt.Errorf("failed to marshal after state: %w", err)
+	}
+
+	sq := sqlbuilder.NewInsertBuilder()
+	sq.InsertInto(`hr.audit_logs`).
+		Cols(`id`, `company_id`, `actor_id`, `api_key_id`, `request_id`, `ip`, `action`, `entity`, `entity_id`, `before`, `after`, `created_at`).
+		Values(uuid.NewString(), companyID, nullIfEmpty(xcontext.GetUserIDFromContext(ctx)), nullIfEmpty(xcontext.GetAPIKeyIDFromContext(ctx)),
+			xcontext.GetRequestIDFromContext(ctx), xcontext.GetIPFromContext(ctx), entry.Action, entry.Entity, entry.EntityID, before, after, `now()`)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	_, err = tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// WithTransaction runs txfunc within a transaction, the entries recorded in it are written along with its changes
+func (repo *AuditRepository) WithTransaction(ctx context.Context, txfunc func(context.Context) error) error {
+	return dbhelper.WithTransaction(ctx, repo.deps.DB, txfunc)
+}
+
+// GetAuditLogs returns the entries of the context company matching the filter, newest first
+func (repo *AuditRepository) GetAuditLogs(ctx context.Context, filter AuditLogFilter) ([]AuditLog, error) {
+	companyID := xcontext.GetCompanyIDFromContext(ctx)
+
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`id`, `actor_id`, `api_key_id`, `request_id`, `ip`, `action`, `entity`, `entity_id`, `before`, `after`, `created_at`).
+		From(`hr.audit_logs`).
+		Where(sq.Equal(`company_id`, companyID)).
+		OrderBy(`created_at DESC`, `id DESC`).
+		Limit(filter.Limit)
+
+	if filter.ActorID != "" {
+		sq.Where(sq.Equal(`actor_id::text`, filter.ActorID))
+	}
+	if filter.APIKeyID != "" {
+		sq.Where(sq.Equal(`api_key_id::text`, filter.APIKeyID))
+	}
+	if filter.RequestID != "" {
+		sq.Where(sq.Equal(`request_id`, filter.RequestID))
+	}
+	if filter.Action != "" {
+		sq.Where(sq.Equal(`action`, filter.Action))
+	}
+	if filter.Entity != "" {
+		sq.Where(sq.Equal(`entity`, filter.Entity))
+	}
+	if filter.EntityID != "" {
+		sq.Where(sq.Equal(`entity_id`, filter.EntityID))
+	}
+	if filter.From != nil {
+		sq.Where(sq.GreaterEqualThan(`created_at`, *filter.From))
+	}
+	if filter.To != nil {
+		sq.Where(sq.LessThan(`created_at`, *filter.To))
+	}
+	if filter.Cursor != "" {
+		// keyset pagination, the entries are ordered by creation time and then ID
+		sq.Where(fmt.Sprintf(`(created_at, id) < (SELECT created_at, id FROM hr.audit_logs WHERE id::text = %s AND company_id = %s)`,
+			sq.Var(filter.Cursor), sq.Var(companyID)))
+	}
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	rows, err := tx.QueryxContext(ctx, q, args...)
+	if err != nil {
+		return []AuditLog{}, err
+	}
+	defer rows.Close()
+
+	result := []AuditLog{}
+	for rows.Next() {
+		var temp SQLAuditLog
+		err := rows.StructScan(&temp)
+		if err != nil {
+			repo.deps.Logger.WarnContext(ctx, "failed to scan audit log", slog.Any("error", err))
+			continue
+		}
+		result = append(result, AuditLog{
+			ID:        temp.ID.String,
+			ActorID:   temp.ActorID.String,
+			APIKeyID:  temp.APIKeyID.String,
+			RequestID: temp.RequestID.String,
+			IP:        temp.IP.String,
+			Action:    temp.Action.String,
+			Entity:    temp.Entity.String,
+			EntityID:  temp.EntityID.String,
+			Before:    temp.Before,
+			After:     temp.After,
+			CreatedAt: temp.CreatedAt.Time,
+		})
+	}
+
+	return result, nil
+}
+
+// marshalState marshals the state to JSON, nil states are stored as NULL
+func marshalState(state any) (any, error) {
+	if state == nil {
+		return nil, nil
+	}
+
+	dataBytes, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(dataBytes), nil
+}
+
+func nullIfEmpty(val string) any {
+	if val == "" {
+		return nil
+	}
+
+	return val
+}
@@ -0,0 +1,97 @@
+package company
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/models"
+	"github.com/rahadianir/dealls/internal/pkg/xaudit"
+	"github.com/rahadianir/dealls/internal/pkg/xcontext"
+	"github.com/rahadianir/dealls/internal/pkg/xerror"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type CompanyLogic struct {
+	deps        *config.CommonDependencies
+	companyRepo CompanyRepositoryInterface
+	auditor     xaudit.Recorder
+}
+
+func NewCompanyLogic(deps *config.CommonDependencies, companyRepo CompanyRepositoryInterface, auditor xaudit.Recorder) *CompanyLogic {
+	return &CompanyLogic{
+		deps:        deps,
+		companyRepo: companyRepo,
+		auditor:     auditor,
+	}
+}
+
+// CreateCompany creates the company along with its default pay group and admin user, so the admin can log in and set up the rest
+func (logic *CompanyLogic) CreateCompany(ctx context.Context, req CreateCompanyRequest) (models.Company, error) {
+	code := strings.ToLower(strings.TrimSpace(req.Code))
+	if code == "" {
+		return models.Company{}, xerror.ClientError{Err: fmt.Errorf("company code is required")}
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return models.Company{}, xerror.ClientError{Err: fmt.Errorf("company name is required")}
+	}
+
+	username := strings.TrimSpace(req.AdminUsername)
+	if username == "" || req.AdminPassword == "" {
+		return models.Company{}, xerror.ClientError{Err: fmt.Errorf("admin username and password are required")}
+	}
+
+	// company code is unique
+	_, err := logic.companyRepo.GetCompanyByCode(ctx, code)
+	if err == nil {
+		return models.Company{}, xerror.ClientError{Err: fmt.Errorf("company %s already exists", code)}
+	}
+	if !errors.Is(err, xerror.ErrDataNotFound) {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get company by code", slog.Any("error", err))
+		return models.Company{}, err
+	}
+
+	pwBytes, err := bcrypt.GenerateFromPassword([]byte(req.AdminPassword), 12)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to hash admin password", slog.Any("error", err))
+		return models.Company{}, err
+	}
+
+	company := models.Company{
+		ID:        uuid.NewString(),
+		Code:      code,
+		Name:      name,
+		CreatedAt: time.Now(),
+	}
+	admin := models.User{
+		ID:       uuid.NewString(),
+		Name:     username,
+		Username: username,
+		Password: string(pwBytes),
+
+		// the password is given on the command line, so the admin picks their own on the first login
+		MustChangePassword: true,
+	}
+	// the entry belongs to the new company, there's no actor since it's created from the command line
+	err = xaudit.Record(context.WithValue(ctx, xcontext.CompanyIDKey, company.ID), logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionCreate,
+		Entity:   xaudit.EntityCompany,
+		EntityID: company.ID,
+		After:    map[string]any{"code": company.Code, "name": company.Name, "admin_id": admin.ID, "admin_username": admin.Username},
+	}, func(ctx context.Context) error {
+		return logic.companyRepo.CreateCompany(ctx, company, admin)
+	})
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to create company", slog.Any("error", err))
+		return models.Company{}, err
+	}
+
+	return company, nil
+}
@@ -0,0 +1,107 @@
+package company
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/models"
+	"github.com/rahadianir/dealls/internal/pkg/xaudit"
+	"github.com/rahadianir/dealls/internal/pkg/xcontext"
+	"github.com/rahadianir/dealls/internal/pkg/xerror"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestCompanyLogic_CreateCompany(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := NewMockCompanyRepositoryInterface(ctrl)
+	mockAuditor := xaudit.NewMockRecorder(ctrl)
+	// the change and its entry are run in the transaction the recorder begins
+	mockAuditor.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, txfunc func(context.Context) error) error {
+		return txfunc(ctx)
+	}).AnyTimes()
+	mockDeps := config.CommonDependencies{
+		Config: config.InitConfig(context.Background()),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	type args struct {
+		ctx context.Context
+		req CreateCompanyRequest
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantCode  string
+		wantErr   bool
+		behaviour func(a args)
+	}{
+		// TODO: Add test cases.
+		{
+			name: "success create company with its admin",
+			args: args{
+				ctx: context.Background(),
+				req: CreateCompanyRequest{Code: " Acme ", Name: "Acme Ltd", AdminUsername: "admin", AdminPassword: "secret"},
+			},
+			wantCode: "acme",
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetCompanyByCode(gomock.Any(), "acme").Return(models.Company{}, xerror.ErrDataNotFound)
+				mockRepo.EXPECT().CreateCompany(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data models.Company, admin models.User) error {
+					if data.ID == "" || data.Code != "acme" || data.Name != "Acme Ltd" {
+						t.Errorf("unexpected company: %+v", data)
+					}
+					if admin.Username != "admin" || !admin.MustChangePassword || bcrypt.CompareHashAndPassword([]byte(admin.Password), []byte("secret")) != nil {
+						t.Errorf("unexpected admin: %+v", admin)
+					}
+					return nil
+				})
+				// recorded in the new company
+				mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, entry xaudit.Entry) error {
+					if entry.Action != xaudit.ActionCreate || entry.Entity != xaudit.EntityCompany || entry.EntityID == "" || xcontext.GetCompanyIDFromContext(ctx) != entry.EntityID {
+						t.Errorf("unexpected audit log entry: %+v", entry)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name: "failed existing company code",
+			args: args{
+				ctx: context.Background(),
+				req: CreateCompanyRequest{Code: "acme", Name: "Acme Ltd", AdminUsername: "admin", AdminPassword: "secret"},
+			},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetCompanyByCode(gomock.Any(), "acme").Return(models.Company{ID: "company-id"}, nil)
+			},
+		},
+		{
+			name: "failed missing admin password",
+			args: args{
+				ctx: context.Background(),
+				req: CreateCompanyRequest{Code: "acme", Name: "Acme Ltd", AdminUsername: "admin"},
+			},
+			wantErr:   true,
+			behaviour: func(a args) {},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := NewCompanyLogic(&mockDeps, mockRepo, mockAuditor)
+			tt.behaviour(tt.args)
+			got, err := logic.CreateCompany(tt.args.ctx, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CompanyLogic.CreateCompany() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got.Code != tt.wantCode {
+				t.Errorf("CompanyLogic.CreateCompany() code = %v, want %v", got.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/company/ports.go
+//
+// Generated by this command:
+//
+//	mockgen -source internal/company/ports.go -destination internal/company/mock_ports.go -package company
+//
+
+// Package company is a generated GoMock package.
+package company
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/rahadianir/dealls/internal/models"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCompanyRepositoryInterface is a mock of CompanyRepositoryInterface interface.
+type MockCompanyRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockCompanyRepositoryInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockCompanyRepositoryInterfaceMockRecorder is the mock recorder for MockCompanyRepositoryInterface.
+type MockCompanyRepositoryInterfaceMockRecorder struct {
+	mock *MockCompanyRepositoryInterface
+}
+
+// NewMockCompanyRepositoryInterface creates a new mock instance.
+func NewMockCompanyRepositoryInterface(ctrl *gomock.Controller) *MockCompanyRepositoryInterface {
+	mock := &MockCompanyRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockCompanyRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCompanyRepositoryInterface) EXPECT() *MockCompanyRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// CreateCompany mocks base method.
+func (m *MockCompanyRepositoryInterface) CreateCompany(ctx context.Context, data models.Company, admin models.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCompany", ctx, data, admin)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateCompany indicates an expected call of CreateCompany.
+func (mr *MockCompanyRepositoryInterfaceMockRecorder) CreateCompany(ctx, data, admin any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCompany", reflect.TypeOf((*MockCompanyRepositoryInterface)(nil).CreateCompany), ctx, data, admin)
+}
+
+// GetCompanies mocks base method.
+func (m *MockCompanyRepositoryInterface) GetCompanies(ctx context.Context) ([]models.Company, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCompanies", ctx)
+	ret0, _ := ret[0].([]models.Company)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCompanies indicates an expected call of GetCompanies.
+func (mr *MockCompanyRepositoryInterfaceMockRecorder) GetCompanies(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCompanies", reflect.TypeOf((*MockCompanyRepositoryInterface)(nil).GetCompanies), ctx)
+}
+
+// GetCompanyByCode mocks base method.
+func (m *MockCompanyRepositoryInterface) GetCompanyByCode(ctx context.Context, code string) (models.Company, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCompanyByCode", ctx, code)
+	ret0, _ := ret[0].(models.Company)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCompanyByCode indicates an expected call of GetCompanyByCode.
+func (mr *MockCompanyRepositoryInterfaceMockRecorder) GetCompanyByCode(ctx, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCompanyByCode", reflect.TypeOf((*MockCompanyRepositoryInterface)(nil).GetCompanyByCode), ctx, code)
+}
+
+// GetCompanyByID mocks base method.
+func (m *MockCompanyRepositoryInterface) GetCompanyByID(ctx context.Context, id string) (models.Company, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCompanyByID", ctx, id)
+	ret0, _ := ret[0].(models.Company)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCompanyByID indicates an expected call of GetCompanyByID.
+func (mr *MockCompanyRepositoryInterfaceMockRecorder) GetCompanyByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCompanyByID", reflect.TypeOf((*MockCompanyRepositoryInterface)(nil).GetCompanyByID), ctx, id)
+}
+
+// MockCompanyLogicInterface is a mock of CompanyLogicInterface interface.
+type MockCompanyLogicInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockCompanyLogicInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockCompanyLogicInterfaceMockRecorder is the mock recorder for MockCompanyLogicInterface.
+type MockCompanyLogicInterfaceMockRecorder struct {
+	mock *MockCompanyLogicInterface
+}
+
+// NewMockCompanyLogicInterface creates a new mock instance.
+func NewMockCompanyLogicInterface(ctrl *gomock.Controller) *MockCompanyLogicInterface {
+	mock := &MockCompanyLogicInterface{ctrl: ctrl}
+	mock.recorder = &MockCompanyLogicInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCompanyLogicInterface) EXPECT() *MockCompanyLogicInterfaceMockRecorder {
+	return m.recorder
+}
+
+// CreateCompany mocks base method.
+func (m *MockCompanyLogicInterface) CreateCompany(ctx context.Context, req CreateCompanyRequest) (models.Company, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCompany", ctx, req)
+	ret0, _ := ret[0].(models.Company)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCompany indicates an expected call of CreateCompany.
+func (mr *MockCompanyLogicInterfaceMockRecorder) CreateCompany(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCompany", reflect.TypeOf((*MockCompanyLogicInterface)(nil).CreateCompany), ctx, req)
+}
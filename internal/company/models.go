@@ -0,0 +1,17 @@
+package company
+
+import "database/sql"
+
+type SQLCompany struct {
+	ID        sql.NullString `db:"id"`
+	Code      sql.NullString `db:"code"`
+	Name      sql.NullString `db:"name"`
+	CreatedAt sql.NullTime   `db:"created_at"`
+}
+
+type CreateCompanyRequest struct {
+	Code          string
+	Name          string
+	AdminUsername string
+	AdminPassword string
+}
@@ -0,0 +1,19 @@
+package company
+
+import (
+	"context"
+
+	"github.com/rahadianir/dealls/internal/models"
+)
+
+// CompanyRepositoryInterface queries the companies themselves, so unlike the other repositories it isn't scoped to the context company
+type CompanyRepositoryInterface interface {
+	GetCompanies(ctx context.Context) ([]models.Company, error)
+	GetCompanyByCode(ctx context.Context, code string) (models.Company, error)
+	GetCompanyByID(ctx context.Context, id string) (models.Company, error)
+	CreateCompany(ctx context.Context, data models.Company, admin models.User) error
+}
+
+type CompanyLogicInterface interface {
+	CreateCompany(ctx context.Context, req CreateCompanyRequest) (models.Company, error)
+}
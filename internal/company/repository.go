@@ -0,0 +1,151 @@
+package company
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/models"
+	"github.com/rahadianir/dealls/internal/pkg/dbhelper"
+	"github.com/rahadianir/dealls/internal/pkg/xerror"
+)
+
+type CompanyRepository struct {
+	deps *config.CommonDependencies
+}
+
+func NewCompanyRepository(deps *config.CommonDependencies) *CompanyRepository {
+	return &CompanyRepository{
+		deps: deps,
+	}
+}
+
+func (repo *CompanyRepository) GetCompanies(ctx context.Context) ([]models.Company, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`id`, `code`, `name`, `created_at`).From(`hr.companies`).Where(sq.IsNull(`deleted_at`)).OrderBy(`code`)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	rows, err := tx.QueryxContext(ctx, q, args...)
+	if err != nil {
+		return []models.Company{}, err
+	}
+	defer rows.Close()
+
+	result := []models.Company{}
+	for rows.Next() {
+		var temp SQLCompany
+		err := rows.StructScan(&temp)
+		if err != nil {
+			repo.deps.Logger.WarnContext(ctx, "failed to scan company", slog.Any("error", err))
+			continue
+		}
+		result = append(result, toCompany(temp))
+	}
+
+	return result, nil
+}
+
+func (repo *CompanyRepository) GetCompanyByCode(ctx context.Context, code string) (models.Company, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`id`, `code`, `name`, `created_at`).From(`hr.companies`).Where(
+		sq.Equal(`code`, code),
+		sq.IsNull(`deleted_at`),
+	)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	var temp SQLCompany
+	err := tx.QueryRowxContext(ctx, q, args...).StructScan(&temp)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Company{}, xerror.ErrDataNotFound
+		}
+
+		return models.Company{}, err
+	}
+
+	return toCompany(temp), nil
+}
+
+func (repo *CompanyRepository) GetCompanyByID(ctx context.Context, id string) (models.Company, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`id`, `code`, `name`, `created_at`).From(`hr.companies`).Where(
+		sq.Equal(`id`, id),
+		sq.IsNull(`deleted_at`),
+	)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	var temp SQLCompany
+	err := tx.QueryRowxContext(ctx, q, args...).StructScan(&temp)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Company{}, xerror.ErrDataNotFound
+		}
+
+		return models.Company{}, err
+	}
+
+	return toCompany(temp), nil
+}
+
+// CreateCompany creates the company along with its monthly default pay group, its admin role and the admin user
+func (repo *CompanyRepository) CreateCompany(ctx context.Context, data models.Company, admin models.User) error {
+	return dbhelper.WithTransaction(ctx, repo.deps.DB, func(ctx context.Context) error {
+		tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+		company := sqlbuilder.NewInsertBuilder()
+		company.InsertInto(`hr.companies`).
+			Cols(`id`, `code`, `name`, `created_at`).
+			Values(data.ID, data.Code, data.Name, `now()`)
+
+		payGroupID := uuid.NewString()
+		payGroup := sqlbuilder.NewInsertBuilder()
+		payGroup.InsertInto(`hr.pay_groups`).
+			Cols(`id`, `company_id`, `code`, `name`, `frequency`, `created_at`).
+			Values(payGroupID, data.ID, `default`, `Monthly Staff`, `monthly`, `now()`)
+
+		roleID := uuid.NewString()
+		role := sqlbuilder.NewInsertBuilder()
+		role.InsertInto(`hr.roles`).
+			Cols(`id`, `company_id`, `name`, `created_at`).
+			Values(roleID, data.ID, `admin`, `now()`)
+
+		user := sqlbuilder.NewInsertBuilder()
+		user.InsertInto(`hr.users`).
+			Cols(`id`, `company_id`, `pay_group_id`, `name`, `username`, `password`, `salary`, `must_change_password`, `created_at`).
+			Values(admin.ID, data.ID, payGroupID, admin.Name, admin.Username, admin.Password, admin.Salary, admin.MustChangePassword, `now()`)
+
+		roleMap := sqlbuilder.NewInsertBuilder()
+		roleMap.InsertInto(`hr.user_role_map`).
+			Cols(`id`, `company_id`, `user_id`, `role_id`, `created_at`).
+			Values(uuid.NewString(), data.ID, admin.ID, roleID, `now()`)
+
+		for _, sq := range []*sqlbuilder.InsertBuilder{company, payGroup, role, user, roleMap} {
+			q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+			_, err := tx.ExecContext(ctx, q, args...)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func toCompany(temp SQLCompany) models.Company {
+	return models.Company{
+		ID:        temp.ID.String,
+		Code:      temp.Code.String,
+		Name:      temp.Name.String,
+		CreatedAt: temp.CreatedAt.Time,
+	}
+}
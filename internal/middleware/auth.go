@@ -3,24 +3,36 @@ package middleware
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"slices"
 	"strings"
 
+	"github.com/rahadianir/dealls/internal/apikey"
 	"github.com/rahadianir/dealls/internal/config"
 	"github.com/rahadianir/dealls/internal/pkg/xcontext"
+	"github.com/rahadianir/dealls/internal/pkg/xerror"
 	"github.com/rahadianir/dealls/internal/pkg/xhttp"
 	"github.com/rahadianir/dealls/internal/pkg/xjwt"
+	"github.com/rahadianir/dealls/internal/user"
 )
 
+// APIKeyHeader carries the API key of service-to-service requests
+const APIKeyHeader = "X-API-Key"
+
 type AuthMiddleware struct {
-	deps      *config.CommonDependencies
-	jwtHelper xjwt.JWTHelper
+	deps        *config.CommonDependencies
+	jwtHelper   xjwt.JWTHelper
+	userRepo    user.UserRepositoryInterface
+	apiKeyLogic apikey.APIKeyLogicInterface
 }
 
-func NewAuthMiddleware(deps *config.CommonDependencies, jwtHelper xjwt.JWTHelper) *AuthMiddleware {
+func NewAuthMiddleware(deps *config.CommonDependencies, jwtHelper xjwt.JWTHelper, userRepo user.UserRepositoryInterface, apiKeyLogic apikey.APIKeyLogicInterface) *AuthMiddleware {
 	return &AuthMiddleware{
-		deps:      deps,
-		jwtHelper: jwtHelper,
+		deps:        deps,
+		jwtHelper:   jwtHelper,
+		userRepo:    userRepo,
+		apiKeyLogic: apiKeyLogic,
 	}
 }
 
@@ -45,7 +57,7 @@ func (mw *AuthMiddleware) AuthOnly(next http.Handler) http.Handler {
 		token := strings.TrimPrefix(header, "Bearer ")
 
 		// also calls validate token under
-		claims, err := mw.jwtHelper.GetTokenClaims(token, mw.deps.Config.App.JWTSecretKey)
+		claims, err := mw.jwtHelper.GetTokenClaims(token)
 		if err != nil {
 			xhttp.SendJSONResponse(w, xhttp.BaseResponse{
 				Error:   fmt.Errorf("%s: %w", "invalid token", err).Error(),
@@ -54,17 +66,130 @@ func (mw *AuthMiddleware) AuthOnly(next http.Handler) http.Handler {
 			return
 		}
 
-		userID, err := claims.GetSubject()
+		if claims.Subject == "" || claims.ID == "" {
+			xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+				Error:   "invalid token: missing subject or token ID",
+				Message: "unauthorized",
+			}, http.StatusUnauthorized)
+			return
+		}
+
+		// every repository query is scoped to the token's company
+		if claims.CompanyID == "" {
+			xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+				Error:   "invalid company ID: missing company_id claim",
+				Message: "unauthorized",
+			}, http.StatusUnauthorized)
+			return
+		}
+
+		ctx := r.Context()
+		ctx = context.WithValue(ctx, xcontext.UserIDKey, claims.Subject)
+		ctx = context.WithValue(ctx, xcontext.CompanyIDKey, claims.CompanyID)
+		ctx = context.WithValue(ctx, xcontext.TokenIDKey, claims.ID)
+		ctx = context.WithValue(ctx, xcontext.SessionIDKey, claims.SessionID)
+		ctx = context.WithValue(ctx, xcontext.MustChangePasswordKey, claims.MustChangePassword)
+		ctx = context.WithValue(ctx, xcontext.TwoFactorSetupKey, claims.TwoFactorSetup)
+
+		// logged out tokens are rejected until they expire
+		revoked, err := mw.userRepo.IsTokenRevoked(ctx, claims.ID)
 		if err != nil {
+			mw.deps.Logger.ErrorContext(ctx, "failed to check token revocation", slog.Any("error", err))
 			xhttp.SendJSONResponse(w, xhttp.BaseResponse{
-				Error:   fmt.Errorf("%s: %w", "invalid user ID", err).Error(),
+				Error:   err.Error(),
+				Message: "failed to check token",
+			}, http.StatusInternalServerError)
+			return
+		}
+
+		if revoked {
+			xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+				Error:   "token has been revoked",
 				Message: "unauthorized",
 			}, http.StatusUnauthorized)
 			return
 		}
 
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// AuthOrAPIKey accepts an API key in the X-API-Key header as well as the Bearer token of AuthOnly. Requests made with
+// a key act on behalf of the admin who created it, RequireScope limits them to the scopes of the key.
+func (mw *AuthMiddleware) AuthOrAPIKey(next http.Handler) http.Handler {
+	authOnly := mw.AuthOnly(next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(APIKeyHeader)
+		if key == "" {
+			authOnly.ServeHTTP(w, r)
+			return
+		}
+
+		result, err := mw.apiKeyLogic.Authenticate(r.Context(), key)
+		if err != nil {
+			xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+				Error:   err.Error(),
+				Message: "unauthorized",
+			}, xerror.ParseErrorTypeToCodeInt(err))
+			return
+		}
+
 		ctx := r.Context()
-		ctx = context.WithValue(ctx, xcontext.UserIDKey, userID)
+		ctx = context.WithValue(ctx, xcontext.UserIDKey, result.CreatedBy)
+		ctx = context.WithValue(ctx, xcontext.CompanyIDKey, result.CompanyID)
+		ctx = context.WithValue(ctx, xcontext.APIKeyIDKey, result.ID)
+		ctx = context.WithValue(ctx, xcontext.APIKeyScopesKey, result.Scopes)
+
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// RequireScope rejects requests made with an API key lacking the scope, requests made with a token are let through,
+// it's meant to be used after AuthOrAPIKey
+func (mw *AuthMiddleware) RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if xcontext.GetAPIKeyIDFromContext(r.Context()) != "" && !slices.Contains(xcontext.GetAPIKeyScopesFromContext(r.Context()), scope) {
+				xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+					Error:   fmt.Sprintf("api key is missing the %s scope", scope),
+					Message: "forbidden",
+				}, http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// PasswordChanged rejects users who still have to change their temporary password, it's meant to be used after AuthOnly
+func (mw *AuthMiddleware) PasswordChanged(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if xcontext.GetMustChangePasswordFromContext(r.Context()) {
+			xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+				Error:   "password change required",
+				Message: "forbidden",
+			}, http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// TwoFactorEnrolled rejects users who still have to enable the two-factor authentication an admin required,
+// it's meant to be used after AuthOnly
+func (mw *AuthMiddleware) TwoFactorEnrolled(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if xcontext.GetTwoFactorSetupFromContext(r.Context()) {
+			xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+				Error:   "two-factor authentication enrolment required",
+				Message: "forbidden",
+			}, http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
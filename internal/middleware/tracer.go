@@ -33,7 +33,11 @@ func (tracer *TracerMiddleware) Tracer(next http.Handler) http.Handler {
 		// setup real IP
 		ip := getRealIP(r)
 		if ip == "" {
+			// without the port, so the requests of a client share the IP
 			ip = r.RemoteAddr
+			if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+				ip = host
+			}
 		}
 		ctx = context.WithValue(ctx, xcontext.IPKey, ip)
 		next.ServeHTTP(w, r.WithContext(ctx))
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type Attendance struct {
 	UserID string
 	Count  int
@@ -10,3 +12,18 @@ type Overtime struct {
 	Count  int
 }
 
+type Leave struct {
+	ID        string
+	UserID    string
+	StartDate time.Time
+	EndDate   time.Time
+	Paid      bool
+	Reason    string
+}
+
+// LeaveDays is the number of leave work days of the user within a period
+type LeaveDays struct {
+	UserID     string
+	PaidDays   int
+	UnpaidDays int
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// DefaultCompanyCode is the company users log in to when they don't name one
+const DefaultCompanyCode = "default"
+
+// Company is a tenant, every user, role, payroll period and payslip belongs to one
+type Company struct {
+	ID        string    `json:"id"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
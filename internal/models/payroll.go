@@ -3,17 +3,80 @@ package models
 import "database/sql"
 
 type SQLReimbursement struct {
-	ID          sql.NullString
-	UserID      sql.NullString `db:"user_id"`
-	Amount      sql.NullFloat64
-	Description sql.NullString
+	ID           sql.NullString
+	UserID       sql.NullString `db:"user_id"`
+	Amount       sql.NullFloat64
+	Currency     sql.NullString `db:"currency"`
+	Description  sql.NullString
+	CategoryID   sql.NullString `db:"category_id"`
+	Category     sql.NullString `db:"category"`
+	CategoryName sql.NullString `db:"category_name"`
+	Taxable      sql.NullBool   `db:"taxable"`
+	Receipts     []byte         `db:"receipts"`
+}
+
+type SQLReimbursementReceipt struct {
+	ID              sql.NullString `db:"id"`
+	ReimbursementID sql.NullString `db:"reimbursement_id"`
+	FileName        sql.NullString `db:"file_name"`
+	ContentType     sql.NullString `db:"content_type"`
+	Size            sql.NullInt64  `db:"size_bytes"`
+	StorageKey      sql.NullString `db:"storage_key"`
 }
 
 type Reimbursement struct {
-	ID          string  `json:"id,omitempty"`
-	UserID      string  `json:"user_id,omitempty"`
-	Amount      float64 `json:"amount,omitempty"`
-	Description string  `json:"description,omitempty"`
+	ID           string                 `json:"id,omitempty"`
+	UserID       string                 `json:"user_id,omitempty"`
+	Amount       float64                `json:"amount,omitempty"`
+	Currency     string                 `json:"currency,omitempty"`
+	Description  string                 `json:"description,omitempty"`
+	CategoryID   string                 `json:"-"`
+	Category     string                 `json:"category,omitempty"`
+	CategoryName string                 `json:"category_name,omitempty"`
+	Taxable      bool                   `json:"taxable,omitempty"`
+	Receipts     []ReimbursementReceipt `json:"receipts,omitempty"`
+
+	// the claimed amount when it's paid out in another currency, e.g. on the payslip
+	OriginalAmount   float64 `json:"original_amount,omitempty"`
+	OriginalCurrency string  `json:"original_currency,omitempty"`
+}
+
+type SQLReimbursementCategory struct {
+	ID              sql.NullString  `db:"id"`
+	Code            sql.NullString  `db:"code"`
+	Name            sql.NullString  `db:"name"`
+	PerClaimLimit   sql.NullFloat64 `db:"per_claim_limit"`
+	PerPeriodLimit  sql.NullFloat64 `db:"per_period_limit"`
+	ReceiptRequired sql.NullBool    `db:"receipt_required"`
+	Taxable         sql.NullBool    `db:"taxable"`
+}
+
+// ReimbursementCategory holds the policy applied to reimbursement claims, nil limits mean unlimited
+type ReimbursementCategory struct {
+	ID              string   `json:"id"`
+	Code            string   `json:"code"`
+	Name            string   `json:"name"`
+	PerClaimLimit   *float64 `json:"per_claim_limit"`
+	PerPeriodLimit  *float64 `json:"per_period_limit"`
+	ReceiptRequired bool     `json:"receipt_required"`
+	Taxable         bool     `json:"taxable"`
+}
+
+type ReimbursementCategoryTotal struct {
+	Category string  `json:"category"`
+	Name     string  `json:"name"`
+	Taxable  bool    `json:"taxable"`
+	Count    int     `json:"count"`
+	Amount   float64 `json:"amount"`
+}
+
+type ReimbursementReceipt struct {
+	ID              string `json:"id"`
+	ReimbursementID string `json:"reimbursement_id,omitempty"`
+	FileName        string `json:"file_name"`
+	ContentType     string `json:"content_type"`
+	Size            int64  `json:"size"`
+	StorageKey      string `json:"-"`
 }
 
 type Payslip struct {
@@ -29,4 +92,42 @@ type Payslip struct {
 	ReimbursementList  []Reimbursement `json:"reimbursement_list"`
 	TotalReimbursement float64         `json:"total_reimbursement_amount"`
 	TakeHomePay        float64         `json:"take_home_pay"`
+
+	ReimbursementCategories   []ReimbursementCategoryTotal `json:"reimbursement_by_category"`
+	TotalTaxableReimbursement float64                      `json:"total_taxable_reimbursement"`
+
+	PaidLeaveDays   int      `json:"paid_leave_days"`
+	UnpaidLeaveDays int      `json:"unpaid_leave_days"`
+	ReviewReasons   []string `json:"review_reasons"` // e.g. missing attendance, empty when nothing to review
+
+	Type             string      `json:"type"`
+	EmployedWorkDays int         `json:"employed_work_days"`
+	UnusedLeaveDays  int         `json:"unused_leave_days"`
+	LeavePayout      float64     `json:"leave_payout"`
+	DeductionList    []Deduction `json:"deduction_list"`
+	TotalDeduction   float64     `json:"total_deduction"`
+
+	// amounts are in the payout currency, the base salary is converted from the salary currency with the exchange rate
+	SalaryCurrency     string  `json:"salary_currency"`
+	OriginalSalary     float64 `json:"original_salary"`
+	PayoutCurrency     string  `json:"payout_currency"`
+	SalaryExchangeRate float64 `json:"salary_exchange_rate"`
+
+	// tamper evidence, the hash chains the payslip to the previous one of the period and is signed by the company
+	PrevHash  string `json:"-"`
+	Hash      string `json:"hash,omitempty"`
+	Signature string `json:"signature,omitempty"`
+}
+
+const (
+	PayslipRegular         = "regular"
+	PayslipFinalSettlement = "final_settlement"
+)
+
+// Deduction is an amount owed by the user (e.g. loan, advance), settled on the final payslip
+type Deduction struct {
+	ID          string  `json:"id"`
+	UserID      string  `json:"-"`
+	Amount      float64 `json:"amount"`
+	Description string  `json:"description"`
 }
@@ -13,9 +13,39 @@ type User struct {
 	DeletedAt *time.Time
 	CreatedBy string
 	UpdatedBy string
+
+	// set for temporary passwords, the user can't do anything but change it until then
+	MustChangePassword bool
+
+	// admins can require two-factor authentication, until it's enabled the user can't do anything but enrol
+	TwoFactorRequired bool
+	TwoFactorEnabled  bool
 }
 
+// UserSalary is the user's monthly salary in its own currency, paid out in the payout currency
 type UserSalary struct {
-	UserID string
-	Salary float64
+	UserID         string  `json:"user_id"`
+	Salary         float64 `json:"salary"`
+	Currency       string  `json:"currency"`
+	PayoutCurrency string  `json:"payout_currency,omitempty"` // the salary currency when empty
+}
+
+const (
+	EmploymentActive    = "active"
+	EmploymentSuspended = "suspended"
+	EmploymentInactive  = "inactive"
+)
+
+// Employment decides whether the user is eligible for a payslip in a payroll period,
+// the start date is the hire date and the end date is the termination date
+type Employment struct {
+	UserID            string     `json:"user_id"`
+	Status            string     `json:"status"`
+	StartDate         *time.Time `json:"start_date"`
+	EndDate           *time.Time `json:"end_date"`
+	AnnualLeaveDays   int        `json:"annual_leave_days"` // paid leave entitlement per calendar year
+	TerminationReason string     `json:"termination_reason,omitempty"`
+
+	// IANA timezone of the user's attendance and overtime, the company timezone when empty
+	Timezone string `json:"timezone,omitempty"`
 }
@@ -0,0 +1,155 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/pkg/xcrypto"
+	"github.com/rahadianir/dealls/internal/pkg/xmail"
+)
+
+//go:embed templates/*.tmpl
+var templateFiles embed.FS
+
+// templates of the emails by name, each one defines a "subject" and a "body" template
+var templates = parseTemplates()
+
+func parseTemplates() map[string]*template.Template {
+	result := map[string]*template.Template{}
+	for _, name := range xmail.Templates {
+		result[name] = template.Must(template.ParseFS(templateFiles, "templates/"+name+".tmpl"))
+	}
+
+	return result
+}
+
+type NotificationLogic struct {
+	deps             *config.CommonDependencies
+	notificationRepo NotificationRepositoryInterface
+	sender           xmail.Sender
+	now              func() time.Time // putting it here so it's easier to be mocked/tested
+}
+
+func NewNotificationLogic(deps *config.CommonDependencies, notificationRepo NotificationRepositoryInterface, sender xmail.Sender) *NotificationLogic {
+	return &NotificationLogic{
+		deps:             deps,
+		notificationRepo: notificationRepo,
+		sender:           sender,
+		now:              time.Now,
+	}
+}
+
+// SendEmail renders and sends the queued email and stores the outcome. A failed attempt is retried with an
+// exponential backoff until MAIL_MAX_ATTEMPTS is reached, emails which can't be rendered aren't retried at all.
+func (logic *NotificationLogic) SendEmail(ctx context.Context, email QueuedEmail) error {
+	cfg := logic.deps.Config.Mail
+	email.Attempts++
+
+	msg, err := logic.render(email)
+	if err == nil {
+		err = logic.sender.Send(ctx, msg)
+		if err != nil {
+			err = fmt.Errorf("failed to send email: %w", err)
+		}
+	} else {
+		// rendering fails the same way on every attempt
+		email.Attempts = max(email.Attempts, cfg.MaxAttempts)
+	}
+
+	now := logic.now()
+	switch {
+	case err == nil:
+		email.Status = EmailSent
+		email.SentAt = &now
+		email.LastError = ""
+	case email.Attempts >= cfg.MaxAttempts:
+		email.Status = EmailFailed
+		email.LastError = err.Error()
+	default:
+		email.Status = EmailPending
+		email.LastError = err.Error()
+		nextAttemptAt := now.Add(retryBackoff(email.Attempts, cfg.RetryBackoff, cfg.RetryBackoffMax))
+		email.NextAttemptAt = &nextAttemptAt
+	}
+
+	// the outcome is stored even when shutting down, so a sent email isn't sent again
+	updateErr := logic.notificationRepo.UpdateEmailStatus(context.WithoutCancel(ctx), email)
+	if updateErr != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to update email status", slog.Any("error", updateErr))
+		return updateErr
+	}
+
+	if err != nil {
+		logic.deps.Logger.WarnContext(ctx, "failed to send email", slog.String("email_id", email.ID), slog.String("template", email.Template), slog.Int("attempt", email.Attempts), slog.Any("error", err))
+		return err
+	}
+
+	return nil
+}
+
+// render executes the email template with its data and decrypted secrets
+func (logic *NotificationLogic) render(email QueuedEmail) (xmail.Message, error) {
+	tmpl, ok := templates[email.Template]
+	if !ok {
+		return xmail.Message{}, fmt.Errorf("unknown email template %q", email.Template)
+	}
+
+	data := map[string]any{}
+	if len(email.Data) != 0 {
+		err := json.Unmarshal(email.Data, &data)
+		if err != nil {
+			return xmail.Message{}, fmt.Errorf("failed to unmarshal email data: %w", err)
+		}
+	}
+
+	if len(email.EncryptedSecrets) != 0 {
+		plain, err := xcrypto.Decrypt(email.EncryptedSecrets, logic.deps.Config.App.EncryptionKey)
+		if err != nil {
+			return xmail.Message{}, fmt.Errorf("failed to decrypt email secrets: %w", err)
+		}
+
+		secrets := map[string]string{}
+		err = json.Unmarshal(plain, &secrets)
+		if err != nil {
+			return xmail.Message{}, fmt.Errorf("failed to unmarshal email secrets: %w", err)
+		}
+		for key, value := range secrets {
+			data[key] = value
+		}
+	}
+
+	var subject, body bytes.Buffer
+	err := tmpl.ExecuteTemplate(&subject, "subject", data)
+	if err != nil {
+		return xmail.Message{}, fmt.Errorf("failed to render email subject: %w", err)
+	}
+	err = tmpl.ExecuteTemplate(&body, "body", data)
+	if err != nil {
+		return xmail.Message{}, fmt.Errorf("failed to render email body: %w", err)
+	}
+
+	return xmail.Message{
+		ID:      email.ID,
+		To:      []string{email.Recipient},
+		Subject: strings.TrimSpace(subject.String()),
+		Body:    strings.TrimSpace(body.String()) + "\n",
+	}, nil
+}
+
+// retryBackoff is how long to wait after the failed attempt, doubled per attempt up to max
+func retryBackoff(attempt int, base time.Duration, max time.Duration) time.Duration {
+	backoff := base
+	for i := 1; i < attempt && backoff < max; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, max)
+}
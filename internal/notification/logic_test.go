@@ -0,0 +1,175 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/pkg/xcrypto"
+	"github.com/rahadianir/dealls/internal/pkg/xmail"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNotificationLogic_SendEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := NewMockNotificationRepositoryInterface(ctrl)
+	mockSender := xmail.NewMockSender(ctrl)
+	mockDeps := config.CommonDependencies{
+		Config: config.InitConfig(context.Background()),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	now := time.Date(2025, time.June, 1, 9, 0, 0, 0, time.UTC)
+	cfg := mockDeps.Config.Mail
+
+	// the emails are sent through the local smtp server
+	server, err := xmail.NewLocalServer("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start local smtp server: %v", err)
+	}
+	defer server.Close()
+
+	host, port, err := net.SplitHostPort(server.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	smtpCfg := *cfg
+	smtpCfg.From = "HR Payroll <no-reply@example.com>"
+	smtpCfg.SMTPHost = host
+	smtpCfg.SMTPPort, _ = strconv.Atoi(port)
+	smtpSender, err := xmail.NewSMTPSender(&smtpCfg)
+	if err != nil {
+		t.Fatalf("failed to init smtp sender: %v", err)
+	}
+
+	secrets, err := json.Marshal(map[string]string{"token": "reset-token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := xcrypto.Encrypt(secrets, mockDeps.Config.App.EncryptionKey)
+	if err != nil {
+		t.Fatalf("failed to encrypt secrets: %v", err)
+	}
+
+	email := func(template string, attempts int) QueuedEmail {
+		return QueuedEmail{
+			ID:               "email-id",
+			CompanyID:        "company-id",
+			UserID:           "user-id",
+			Recipient:        "jane@example.com",
+			Template:         template,
+			Data:             json.RawMessage(`{"name":"Jane","expires_at":"2025-06-01T17:00:00+07:00"}`),
+			EncryptedSecrets: encrypted,
+			Status:           EmailPending,
+			Attempts:         attempts,
+		}
+	}
+	retryAt := now.Add(cfg.RetryBackoff * 2)
+
+	type args struct {
+		ctx   context.Context
+		email QueuedEmail
+	}
+	tests := []struct {
+		name      string
+		sender    xmail.Sender
+		args      args
+		wantErr   bool
+		behaviour func(a args)
+	}{
+		// TODO: Add test cases.
+		{
+			name:   "success send the rendered email with its secrets",
+			sender: smtpSender,
+			args:   args{ctx: context.Background(), email: email(xmail.TemplatePasswordReset, 0)},
+			behaviour: func(a args) {
+				mockRepo.EXPECT().UpdateEmailStatus(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data QueuedEmail) error {
+					if data.Status != EmailSent || data.Attempts != 1 || data.SentAt == nil || !data.SentAt.Equal(now) || data.LastError != "" {
+						t.Errorf("unexpected email status: %+v", data)
+					}
+
+					received := server.Received()
+					if len(received) != 1 {
+						t.Fatalf("expected 1 received email, got %d", len(received))
+					}
+					got := received[0]
+					if len(got.To) != 1 || got.To[0] != "jane@example.com" || got.From != "no-reply@example.com" {
+						t.Errorf("unexpected envelope: %+v", got)
+					}
+					body := string(got.Data)
+					if !strings.Contains(body, "Subject: Password reset") || !strings.Contains(body, "Hi Jane") || !strings.Contains(body, "reset-token") {
+						t.Errorf("unexpected email:\n%s", body)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name:    "failed attempt is retried with a backoff",
+			sender:  mockSender,
+			args:    args{ctx: context.Background(), email: email(xmail.TemplatePasswordReset, 1)},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockSender.EXPECT().Send(gomock.Any(), gomock.Any()).Return(fmt.Errorf("connection refused"))
+				mockRepo.EXPECT().UpdateEmailStatus(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data QueuedEmail) error {
+					if data.Status != EmailPending || data.Attempts != 2 || data.NextAttemptAt == nil || !data.NextAttemptAt.Equal(retryAt) || data.LastError == "" {
+						t.Errorf("unexpected email status: %+v", data)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name:    "failed email given up on the last attempt",
+			sender:  mockSender,
+			args:    args{ctx: context.Background(), email: email(xmail.TemplatePasswordReset, cfg.MaxAttempts-1)},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockSender.EXPECT().Send(gomock.Any(), gomock.Any()).Return(fmt.Errorf("mailbox unavailable"))
+				mockRepo.EXPECT().UpdateEmailStatus(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data QueuedEmail) error {
+					if data.Status != EmailFailed || data.Attempts != cfg.MaxAttempts || data.NextAttemptAt != nil {
+						t.Errorf("unexpected email status: %+v", data)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name:    "failed unknown template isn't retried",
+			sender:  mockSender,
+			args:    args{ctx: context.Background(), email: email("unknown", 0)},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().UpdateEmailStatus(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data QueuedEmail) error {
+					if data.Status != EmailFailed || !strings.Contains(data.LastError, "unknown email template") {
+						t.Errorf("unexpected email status: %+v", data)
+					}
+					return nil
+				})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := &NotificationLogic{
+				deps:             &mockDeps,
+				notificationRepo: mockRepo,
+				sender:           tt.sender,
+				now:              func() time.Time { return now },
+			}
+			tt.behaviour(tt.args)
+			if err := logic.SendEmail(tt.args.ctx, tt.args.email); (err != nil) != tt.wantErr {
+				t.Errorf("NotificationLogic.SendEmail() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
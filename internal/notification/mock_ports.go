@@ -0,0 +1,138 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/notification/ports.go
+//
+// Generated by this command:
+//
+//	mockgen -source internal/notification/ports.go -destination internal/notification/mock_ports.go -package notification
+//
+
+// Package notification is a generated GoMock package.
+package notification
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	xmail "github.com/rahadianir/dealls/internal/pkg/xmail"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockNotificationRepositoryInterface is a mock of NotificationRepositoryInterface interface.
+type MockNotificationRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotificationRepositoryInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockNotificationRepositoryInterfaceMockRecorder is the mock recorder for MockNotificationRepositoryInterface.
+type MockNotificationRepositoryInterfaceMockRecorder struct {
+	mock *MockNotificationRepositoryInterface
+}
+
+// NewMockNotificationRepositoryInterface creates a new mock instance.
+func NewMockNotificationRepositoryInterface(ctrl *gomock.Controller) *MockNotificationRepositoryInterface {
+	mock := &MockNotificationRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockNotificationRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotificationRepositoryInterface) EXPECT() *MockNotificationRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// ClaimEmails mocks base method.
+func (m *MockNotificationRepositoryInterface) ClaimEmails(ctx context.Context, limit int, lease time.Duration) ([]QueuedEmail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimEmails", ctx, limit, lease)
+	ret0, _ := ret[0].([]QueuedEmail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimEmails indicates an expected call of ClaimEmails.
+func (mr *MockNotificationRepositoryInterfaceMockRecorder) ClaimEmails(ctx, limit, lease any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimEmails", reflect.TypeOf((*MockNotificationRepositoryInterface)(nil).ClaimEmails), ctx, limit, lease)
+}
+
+// Enqueue mocks base method.
+func (m *MockNotificationRepositoryInterface) Enqueue(ctx context.Context, email xmail.Email) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enqueue", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Enqueue indicates an expected call of Enqueue.
+func (mr *MockNotificationRepositoryInterfaceMockRecorder) Enqueue(ctx, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enqueue", reflect.TypeOf((*MockNotificationRepositoryInterface)(nil).Enqueue), ctx, email)
+}
+
+// EnqueuePayslipEmails mocks base method.
+func (m *MockNotificationRepositoryInterface) EnqueuePayslipEmails(ctx context.Context, payrollID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnqueuePayslipEmails", ctx, payrollID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnqueuePayslipEmails indicates an expected call of EnqueuePayslipEmails.
+func (mr *MockNotificationRepositoryInterfaceMockRecorder) EnqueuePayslipEmails(ctx, payrollID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnqueuePayslipEmails", reflect.TypeOf((*MockNotificationRepositoryInterface)(nil).EnqueuePayslipEmails), ctx, payrollID)
+}
+
+// UpdateEmailStatus mocks base method.
+func (m *MockNotificationRepositoryInterface) UpdateEmailStatus(ctx context.Context, data QueuedEmail) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateEmailStatus", ctx, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateEmailStatus indicates an expected call of UpdateEmailStatus.
+func (mr *MockNotificationRepositoryInterfaceMockRecorder) UpdateEmailStatus(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEmailStatus", reflect.TypeOf((*MockNotificationRepositoryInterface)(nil).UpdateEmailStatus), ctx, data)
+}
+
+// MockNotificationLogicInterface is a mock of NotificationLogicInterface interface.
+type MockNotificationLogicInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotificationLogicInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockNotificationLogicInterfaceMockRecorder is the mock recorder for MockNotificationLogicInterface.
+type MockNotificationLogicInterfaceMockRecorder struct {
+	mock *MockNotificationLogicInterface
+}
+
+// NewMockNotificationLogicInterface creates a new mock instance.
+func NewMockNotificationLogicInterface(ctrl *gomock.Controller) *MockNotificationLogicInterface {
+	mock := &MockNotificationLogicInterface{ctrl: ctrl}
+	mock.recorder = &MockNotificationLogicInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotificationLogicInterface) EXPECT() *MockNotificationLogicInterfaceMockRecorder {
+	return m.recorder
+}
+
+// SendEmail mocks base method.
+func (m *MockNotificationLogicInterface) SendEmail(ctx context.Context, email QueuedEmail) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendEmail", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendEmail indicates an expected call of SendEmail.
+func (mr *MockNotificationLogicInterfaceMockRecorder) SendEmail(ctx, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendEmail", reflect.TypeOf((*MockNotificationLogicInterface)(nil).SendEmail), ctx, email)
+}
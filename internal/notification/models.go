@@ -0,0 +1,45 @@
+package notification
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// statuses of a queued email
+const (
+	EmailPending = "pending"
+	EmailSent    = "sent"
+	EmailFailed  = "failed"
+)
+
+// QueuedEmail is an email to a user waiting in the queue, it's rendered from the template and data when it's sent
+type QueuedEmail struct {
+	ID               string
+	CompanyID        string
+	UserID           string
+	Recipient        string
+	Template         string
+	Data             json.RawMessage
+	EncryptedSecrets []byte
+	Status           string
+	Attempts         int
+	NextAttemptAt    *time.Time
+	LastError        string
+	SentAt           *time.Time
+}
+
+type SQLQueuedEmail struct {
+	ID               sql.NullString `db:"id"`
+	CompanyID        sql.NullString `db:"company_id"`
+	UserID           sql.NullString `db:"user_id"`
+	Recipient        sql.NullString `db:"recipient"`
+	Template         sql.NullString `db:"template"`
+	Data             []byte         `db:"data"`
+	EncryptedSecrets []byte         `db:"secret"`
+	Status           sql.NullString `db:"status"`
+	Attempts         sql.NullInt64  `db:"attempts"`
+	NextAttemptAt    sql.NullTime   `db:"next_attempt_at"`
+	LastError        sql.NullString `db:"last_error"`
+	SentAt           sql.NullTime   `db:"sent_at"`
+}
@@ -0,0 +1,18 @@
+package notification
+
+import (
+	"context"
+	"time"
+
+	"github.com/rahadianir/dealls/internal/pkg/xmail"
+)
+
+type NotificationRepositoryInterface interface {
+	xmail.Queue
+	ClaimEmails(ctx context.Context, limit int, lease time.Duration) ([]QueuedEmail, error)
+	UpdateEmailStatus(ctx context.Context, data QueuedEmail) error
+}
+
+type NotificationLogicInterface interface {
+	SendEmail(ctx context.Context, email QueuedEmail) error
+}
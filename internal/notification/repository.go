@@ -0,0 +1,237 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/pkg/dbhelper"
+	"github.com/rahadianir/dealls/internal/pkg/xcontext"
+	"github.com/rahadianir/dealls/internal/pkg/xcrypto"
+	"github.com/rahadianir/dealls/internal/pkg/xmail"
+)
+
+type NotificationRepository struct {
+	deps *config.CommonDependencies
+}
+
+func NewNotificationRepository(deps *config.CommonDependencies) *NotificationRepository {
+	return &NotificationRepository{
+		deps: deps,
+	}
+}
+
+const emailQueueColumns = `id, company_id, user_id, recipient, template, data, secret, status, next_attempt_at, created_at`
+
+// Enqueue queues the email to the user's email address, the user's name is added to the data.
+// xmail.ErrNoRecipient is returned when the user has no email address.
+func (repo *NotificationRepository) Enqueue(ctx context.Context, email xmail.Email) error {
+	data := []byte(`{}`)
+	if email.Data != nil {
+		var err error
+		data, err = json.Marshal(email.Data)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s email data: %w", email.Template, err)
+		}
+	}
+
+	// secrets are only decrypted to render the email
+	var secrets any
+	if len(email.Secrets) > 0 {
+		plain, err := json.Marshal(email.Secrets)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s email secrets: %w", email.Template, err)
+		}
+		encrypted, err := xcrypto.Encrypt(plain, repo.deps.Config.App.EncryptionKey)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt %s email secrets: %w", email.Template, err)
+		}
+		secrets = encrypted
+	}
+
+	sub := sqlbuilder.NewSelectBuilder()
+	sub.Select(
+		`gen_random_uuid()`,
+		`company_id`,
+		`id`,
+		`email`,
+		fmt.Sprintf(`%s::varchar`, sub.Var(email.Template)),
+		fmt.Sprintf(`jsonb_build_object('name', name) || %s::jsonb`, sub.Var(string(data))),
+		fmt.Sprintf(`%s::bytea`, sub.Var(secrets)),
+		fmt.Sprintf(`%s::varchar`, sub.Var(EmailPending)),
+		`now()`,
+		`now()`,
+	).From(`hr.users`).Where(
+		sub.Equal(`id`, email.UserID),
+		sub.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+		sub.IsNull(`deleted_at`),
+		sub.IsNotNull(`email`),
+	)
+	q, args := sqlbuilder.Build(`INSERT INTO hr.email_queue (`+emailQueueColumns+`) $?`, sub).BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	res, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return xmail.ErrNoRecipient
+	}
+
+	return nil
+}
+
+// EnqueuePayslipEmails queues the payslip available email of every payslip of the period whose user has an email
+// address, terminated users included since they're still paid their final settlement
+func (repo *NotificationRepository) EnqueuePayslipEmails(ctx context.Context, payrollID string) error {
+	sub := sqlbuilder.NewSelectBuilder()
+	sub.Select(
+		`gen_random_uuid()`,
+		`ps.company_id`,
+		`u.id`,
+		`u.email`,
+		fmt.Sprintf(`%s::varchar`, sub.Var(xmail.TemplatePayslipAvailable)),
+		`jsonb_build_object(
+			'name', u.name,
+			'payroll_id', pr.id,
+			'start_date', to_char(pr.start_date, 'YYYY-MM-DD'),
+			'end_date', to_char(pr.end_date, 'YYYY-MM-DD'),
+			'take_home_pay', ps.take_home_pay,
+			'currency', ps.payout_currency
+		)`,
+		`NULL::bytea`,
+		fmt.Sprintf(`%s::varchar`, sub.Var(EmailPending)),
+		`now()`,
+		`now()`,
+	).From(`hr.payslips ps`).
+		Join(`hr.users u`, `u.id = ps.user_id`).
+		Join(`hr.payrolls pr`, `pr.id = ps.payroll_id`).
+		Where(
+			sub.Equal(`ps.payroll_id`, payrollID),
+			sub.Equal(`ps.company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+			sub.IsNotNull(`u.email`),
+		)
+	q, args := sqlbuilder.Build(`INSERT INTO hr.email_queue (`+emailQueueColumns+`) $?`, sub).BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	_, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ClaimEmails claims up to limit due emails of every company, pushing their next attempt back by the lease
+// so an email claimed by a stopped dispatcher is picked up again once the lease is over
+func (repo *NotificationRepository) ClaimEmails(ctx context.Context, limit int, lease time.Duration) ([]QueuedEmail, error) {
+	due := sqlbuilder.NewSelectBuilder()
+	due.Select(`id`).From(`hr.email_queue`).
+		Where(
+			due.Equal(`status`, EmailPending),
+			due.LessEqualThan(`next_attempt_at`, time.Now()),
+		).
+		OrderBy(`next_attempt_at`).Limit(limit).
+		ForUpdate().SQL(`SKIP LOCKED`)
+
+	sq := sqlbuilder.NewUpdateBuilder()
+	sq.Update(`hr.email_queue`).
+		Set(
+			sq.Assign(`next_attempt_at`, time.Now().Add(lease)),
+			`updated_at = now()`,
+		).
+		Where(sq.In(`id`, due)).
+		SQL(`RETURNING id, company_id, user_id, recipient, template, data, secret, status, attempts`)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	rows, err := tx.QueryxContext(ctx, q, args...)
+	if err != nil {
+		return []QueuedEmail{}, err
+	}
+	defer rows.Close()
+
+	result := []QueuedEmail{}
+	for rows.Next() {
+		var temp SQLQueuedEmail
+		err := rows.StructScan(&temp)
+		if err != nil {
+			return []QueuedEmail{}, err
+		}
+		result = append(result, toQueuedEmail(temp))
+	}
+
+	return result, rows.Err()
+}
+
+// UpdateEmailStatus stores the outcome of the attempt: sent, failed for good, or pending again until the next attempt.
+// The secrets aren't needed anymore once the email is sent or given up.
+func (repo *NotificationRepository) UpdateEmailStatus(ctx context.Context, data QueuedEmail) error {
+	var lastError any
+	if data.LastError != "" {
+		lastError = data.LastError
+	}
+
+	sq := sqlbuilder.NewUpdateBuilder()
+	sq.Update(`hr.email_queue`).Set(
+		sq.Assign(`status`, data.Status),
+		sq.Assign(`attempts`, data.Attempts),
+		sq.Assign(`last_error`, lastError),
+		`updated_at = now()`,
+	).Where(
+		sq.Equal(`id`, data.ID),
+		sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+	)
+	switch data.Status {
+	case EmailSent:
+		sq.SetMore(sq.Assign(`sent_at`, data.SentAt), `secret = NULL`)
+	case EmailFailed:
+		sq.SetMore(`secret = NULL`)
+	default:
+		sq.SetMore(sq.Assign(`next_attempt_at`, data.NextAttemptAt))
+	}
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	_, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func toQueuedEmail(temp SQLQueuedEmail) QueuedEmail {
+	result := QueuedEmail{
+		ID:               temp.ID.String,
+		CompanyID:        temp.CompanyID.String,
+		UserID:           temp.UserID.String,
+		Recipient:        temp.Recipient.String,
+		Template:         temp.Template.String,
+		Data:             temp.Data,
+		EncryptedSecrets: temp.EncryptedSecrets,
+		Status:           temp.Status.String,
+		Attempts:         int(temp.Attempts.Int64),
+		LastError:        temp.LastError.String,
+	}
+	if temp.NextAttemptAt.Valid {
+		result.NextAttemptAt = &temp.NextAttemptAt.Time
+	}
+	if temp.SentAt.Valid {
+		result.SentAt = &temp.SentAt.Time
+	}
+
+	return result
+}
@@ -0,0 +1,76 @@
+package notification
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/pkg/xcontext"
+)
+
+// EmailDispatcher sends the queued emails in the background
+type EmailDispatcher struct {
+	deps              *config.CommonDependencies
+	notificationRepo  NotificationRepositoryInterface
+	notificationLogic NotificationLogicInterface
+}
+
+func NewEmailDispatcher(deps *config.CommonDependencies, notificationRepo NotificationRepositoryInterface, notificationLogic NotificationLogicInterface) *EmailDispatcher {
+	return &EmailDispatcher{
+		deps:              deps,
+		notificationRepo:  notificationRepo,
+		notificationLogic: notificationLogic,
+	}
+}
+
+// Start polls for due emails until the context is cancelled.
+// Emails claimed by a stopped dispatcher are picked up again once their lease is over.
+func (w *EmailDispatcher) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.deps.Config.Mail.PollInterval)
+	defer ticker.Stop()
+
+	w.deps.Logger.InfoContext(ctx, "email dispatcher starts")
+	for {
+		w.sendDueEmails(ctx)
+
+		select {
+		case <-ctx.Done():
+			w.deps.Logger.InfoContext(ctx, "email dispatcher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *EmailDispatcher) sendDueEmails(ctx context.Context) {
+	cfg := w.deps.Config.Mail
+	batchSize := max(cfg.BatchSize, 1)
+
+	// the lease outlasts sending the whole batch, so emails aren't claimed twice
+	lease := cfg.SMTPTimeout*time.Duration(batchSize) + time.Minute
+
+	for ctx.Err() == nil {
+		emails, err := w.notificationRepo.ClaimEmails(ctx, batchSize, lease)
+		if err != nil {
+			if ctx.Err() == nil {
+				w.deps.Logger.ErrorContext(ctx, "failed to claim emails", slog.Any("error", err))
+			}
+			return
+		}
+
+		for _, email := range emails {
+			// send within the company of the email, traced by the email ID
+			emailCtx := context.WithValue(ctx, xcontext.RequestIDKey, "email-"+email.ID)
+			emailCtx = context.WithValue(emailCtx, xcontext.CompanyIDKey, email.CompanyID)
+
+			// failures are logged and retried later by the logic
+			_ = w.notificationLogic.SendEmail(emailCtx, email)
+		}
+
+		// last batch
+		if len(emails) < batchSize {
+			return
+		}
+	}
+}
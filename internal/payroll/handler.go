@@ -1,8 +1,16 @@
 package payroll
 
 import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
 	"net/http"
+	"strconv"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/rahadianir/dealls/internal/config"
 	"github.com/rahadianir/dealls/internal/pkg/xerror"
 	"github.com/rahadianir/dealls/internal/pkg/xhttp"
@@ -31,7 +39,7 @@ func (h *PayrollHandler) SetPayrollPeriod(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	err = h.payrollLogic.SetPayrollPeriod(r.Context(), payload.StartDate, payload.EndDate)
+	err = h.payrollLogic.SetPayrollPeriod(r.Context(), payload)
 	if err != nil {
 		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
 			Error:   err.Error(),
@@ -46,7 +54,7 @@ func (h *PayrollHandler) SetPayrollPeriod(w http.ResponseWriter, r *http.Request
 }
 
 func (h *PayrollHandler) CalculatePayroll(w http.ResponseWriter, r *http.Request) {
-	err := h.payrollLogic.CalculatePayroll(r.Context())
+	job, err := h.payrollLogic.CalculatePayroll(r.Context(), r.URL.Query().Get("pay_group_id"))
 	if err != nil {
 		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
 			Error:   err.Error(),
@@ -56,12 +64,35 @@ func (h *PayrollHandler) CalculatePayroll(w http.ResponseWriter, r *http.Request
 	}
 
 	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
-		Message: "payroll in active period calculated",
+		Message: "payroll calculation in active period queued",
+		Data: PayrollJobResponse{
+			JobID: job.ID,
+		},
+	}, http.StatusAccepted)
+}
+
+func (h *PayrollHandler) GetPayrollJob(w http.ResponseWriter, r *http.Request) {
+	job, err := h.payrollLogic.GetPayrollJob(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		code := xerror.ParseErrorTypeToCodeInt(err)
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			code = http.StatusNotFound
+		}
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to get payroll job",
+		}, code)
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "payroll job fetched",
+		Data:    job,
 	}, http.StatusOK)
 }
 
 func (h *PayrollHandler) GeneratePayrollSummary(w http.ResponseWriter, r *http.Request) {
-	resp, err := h.payrollLogic.GetPayrollsSummary(r.Context())
+	resp, err := h.payrollLogic.GetPayrollsSummary(r.Context(), r.URL.Query().Get("pay_group_id"))
 	if err != nil {
 		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
 			Error:   err.Error(),
@@ -99,3 +130,338 @@ func (h *PayrollHandler) GetUserPayslip(w http.ResponseWriter, r *http.Request)
 		Data:    data,
 	}, http.StatusOK)
 }
+
+func (h *PayrollHandler) AddDeduction(w http.ResponseWriter, r *http.Request) {
+	var payload DeductionRequest
+	err := xhttp.BindJSONRequest(r, &payload)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: xerror.ErrBadRequest.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	id, err := h.payrollLogic.AddDeduction(r.Context(), payload)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to add deduction",
+		}, xerror.ParseErrorTypeToCodeInt(err))
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "deduction added",
+		Data:    DeductionResponse{ID: id},
+	}, http.StatusCreated)
+}
+
+func (h *PayrollHandler) CreatePayGroup(w http.ResponseWriter, r *http.Request) {
+	var payload PayGroupRequest
+	err := xhttp.BindJSONRequest(r, &payload)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: xerror.ErrBadRequest.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	payGroup, err := h.payrollLogic.CreatePayGroup(r.Context(), payload)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to create pay group",
+		}, xerror.ParseErrorTypeToCodeInt(err))
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "pay group created",
+		Data:    payGroup,
+	}, http.StatusCreated)
+}
+
+func (h *PayrollHandler) GetPayGroups(w http.ResponseWriter, r *http.Request) {
+	payGroups, err := h.payrollLogic.GetPayGroups(r.Context())
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to get pay groups",
+		}, xerror.ParseErrorTypeToCodeInt(err))
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "pay groups fetched",
+		Data:    payGroups,
+	}, http.StatusOK)
+}
+
+func (h *PayrollHandler) AssignPayGroupUsers(w http.ResponseWriter, r *http.Request) {
+	var payload PayGroupMembersRequest
+	err := xhttp.BindJSONRequest(r, &payload)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: xerror.ErrBadRequest.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	assigned, err := h.payrollLogic.AssignPayGroupUsers(r.Context(), chi.URLParam(r, "id"), payload.UserIDs)
+	if err != nil {
+		code := xerror.ParseErrorTypeToCodeInt(err)
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			code = http.StatusNotFound
+		}
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to assign users to pay group",
+		}, code)
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "users assigned to pay group",
+		Data:    PayGroupMembersResponse{Assigned: assigned},
+	}, http.StatusOK)
+}
+
+func (h *PayrollHandler) UpdatePayGroup(w http.ResponseWriter, r *http.Request) {
+	var payload PayGroupRequest
+	err := xhttp.BindJSONRequest(r, &payload)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: xerror.ErrBadRequest.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	payGroup, err := h.payrollLogic.UpdatePayGroup(r.Context(), chi.URLParam(r, "id"), payload)
+	if err != nil {
+		code := xerror.ParseErrorTypeToCodeInt(err)
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			code = http.StatusNotFound
+		}
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to update pay group",
+		}, code)
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "pay group updated",
+		Data:    payGroup,
+	}, http.StatusOK)
+}
+
+func (h *PayrollHandler) PreviewPayroll(w http.ResponseWriter, r *http.Request) {
+	preview, err := h.payrollLogic.PreviewPayroll(r.Context(), r.URL.Query().Get("pay_group_id"))
+	if err != nil {
+		code := xerror.ParseErrorTypeToCodeInt(err)
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			code = http.StatusNotFound
+		}
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to preview payroll",
+		}, code)
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "payroll previewed",
+		Data:    preview,
+	}, http.StatusOK)
+}
+
+func (h *PayrollHandler) GetExchangeRates(w http.ResponseWriter, r *http.Request) {
+	rates, err := h.payrollLogic.GetExchangeRates(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		code := xerror.ParseErrorTypeToCodeInt(err)
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			code = http.StatusNotFound
+		}
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to get exchange rates",
+		}, code)
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "exchange rates retrieved",
+		Data:    rates,
+	}, http.StatusOK)
+}
+
+func (h *PayrollHandler) SetExchangeRates(w http.ResponseWriter, r *http.Request) {
+	var payload ExchangeRatesRequest
+	err := xhttp.BindJSONRequest(r, &payload)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: xerror.ErrBadRequest.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	rates, err := h.payrollLogic.SetExchangeRates(r.Context(), chi.URLParam(r, "id"), payload)
+	if err != nil {
+		code := xerror.ParseErrorTypeToCodeInt(err)
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			code = http.StatusNotFound
+		}
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to set exchange rates",
+		}, code)
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "exchange rates set",
+		Data:    rates,
+	}, http.StatusOK)
+}
+
+func (h *PayrollHandler) VerifyPayslips(w http.ResponseWriter, r *http.Request) {
+	verification, err := h.payrollLogic.VerifyPayslips(r.Context(), chi.URLParam(r, "id"), r.URL.Query().Get("hash"))
+	if err != nil {
+		code := xerror.ParseErrorTypeToCodeInt(err)
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			code = http.StatusNotFound
+		}
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to verify payslips",
+		}, code)
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "payslips verified",
+		Data:    verification,
+	}, http.StatusOK)
+}
+
+func (h *PayrollHandler) GetJournalAccounts(w http.ResponseWriter, r *http.Request) {
+	accounts, err := h.payrollLogic.GetJournalAccounts(r.Context())
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to get journal accounts",
+		}, xerror.ParseErrorTypeToCodeInt(err))
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "journal accounts retrieved",
+		Data:    accounts,
+	}, http.StatusOK)
+}
+
+func (h *PayrollHandler) SetJournalAccounts(w http.ResponseWriter, r *http.Request) {
+	var payload JournalAccountsRequest
+	err := xhttp.BindJSONRequest(r, &payload)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: xerror.ErrBadRequest.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	accounts, err := h.payrollLogic.SetJournalAccounts(r.Context(), payload)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to set journal accounts",
+		}, xerror.ParseErrorTypeToCodeInt(err))
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "journal accounts set",
+		Data:    accounts,
+	}, http.StatusOK)
+}
+
+// GetPayrollJournal exports the journal of the payroll period as JSON, or as CSV with format=csv
+func (h *PayrollHandler) GetPayrollJournal(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   fmt.Sprintf("unknown format %q, must be json or csv", format),
+			Message: xerror.ErrBadRequest.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	journal, err := h.payrollLogic.GetPayrollJournal(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		code := xerror.ParseErrorTypeToCodeInt(err)
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			code = http.StatusNotFound
+		}
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to get payroll journal",
+		}, code)
+		return
+	}
+
+	if format != "csv" {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Message: "payroll journal retrieved",
+			Data:    journal,
+		}, http.StatusOK)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "payroll-journal-" + journal.PayrollID + ".csv"}))
+	w.WriteHeader(http.StatusOK)
+
+	err = writeJournalCSV(w, journal)
+	if err != nil {
+		h.deps.Logger.WarnContext(r.Context(), "failed to write payroll journal csv", slog.Any("error", err))
+	}
+}
+
+// writeJournalCSV writes a row per journal line, the rows of an entry share its reference
+func writeJournalCSV(w io.Writer, journal Journal) error {
+	writer := csv.NewWriter(w)
+
+	err := writer.Write([]string{"reference", "date", "currency", "description", "account_code", "account_name", "account_type", "line_description", "debit", "credit"})
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range journal.Entries {
+		for _, line := range entry.Lines {
+			err := writer.Write([]string{
+				entry.Reference,
+				entry.Date.String(),
+				entry.Currency,
+				entry.Description,
+				line.AccountCode,
+				line.AccountName,
+				line.AccountType,
+				line.Description,
+				strconv.FormatFloat(line.Debit, 'f', 2, 64),
+				strconv.FormatFloat(line.Credit, 'f', 2, 64),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
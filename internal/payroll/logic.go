@@ -2,9 +2,17 @@ package payroll
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -12,10 +20,15 @@ import (
 	"github.com/rahadianir/dealls/internal/attendance"
 	"github.com/rahadianir/dealls/internal/config"
 	"github.com/rahadianir/dealls/internal/models"
-	"github.com/rahadianir/dealls/internal/pkg/dbhelper"
+	"github.com/rahadianir/dealls/internal/pkg/xaudit"
 	"github.com/rahadianir/dealls/internal/pkg/xcontext"
+	"github.com/rahadianir/dealls/internal/pkg/xcrypto"
+	"github.com/rahadianir/dealls/internal/pkg/xcurrency"
+	"github.com/rahadianir/dealls/internal/pkg/xdate"
 	"github.com/rahadianir/dealls/internal/pkg/xerror"
+	"github.com/rahadianir/dealls/internal/pkg/xmail"
 	"github.com/rahadianir/dealls/internal/user"
+	"golang.org/x/sync/errgroup"
 )
 
 type PayrollLogic struct {
@@ -23,18 +36,25 @@ type PayrollLogic struct {
 	payrollRepo PayrollRepositoryInterface
 	userRepo    user.UserRepositoryInterface
 	attRepo     attendance.AttendanceRepositoryInterface
+	auditor     xaudit.Recorder
+	mails       xmail.Queue
+	jobQueued   chan struct{}
 }
 
-func NewPayrollLogic(deps *config.CommonDependencies, payrollRepo PayrollRepositoryInterface, userRepo user.UserRepositoryInterface, attRepo attendance.AttendanceRepositoryInterface) *PayrollLogic {
+func NewPayrollLogic(deps *config.CommonDependencies, payrollRepo PayrollRepositoryInterface, userRepo user.UserRepositoryInterface, attRepo attendance.AttendanceRepositoryInterface, auditor xaudit.Recorder, mails xmail.Queue) *PayrollLogic {
 	return &PayrollLogic{
 		deps:        deps,
 		payrollRepo: payrollRepo,
 		userRepo:    userRepo,
 		attRepo:     attRepo,
+		auditor:     auditor,
+		mails:       mails,
+		jobQueued:   make(chan struct{}, 1),
 	}
 }
 
-func (logic *PayrollLogic) SetPayrollPeriod(ctx context.Context, start time.Time, end time.Time) error {
+// SetPayrollPeriod sets the active payroll period of the pay group, the end date follows the pay group period template when empty
+func (logic *PayrollLogic) SetPayrollPeriod(ctx context.Context, req PayrollPeriodRequest) error {
 	// check admin role of the user
 	userID := xcontext.GetUserIDFromContext(ctx)
 	isAdmin, err := logic.userRepo.IsAdmin(ctx, userID)
@@ -47,312 +67,1960 @@ func (logic *PayrollLogic) SetPayrollPeriod(ctx context.Context, start time.Time
 		return xerror.AuthError{Err: fmt.Errorf("admin only operation")}
 	}
 
-	totalWorkDay := calculateWorkingDays(start, end)
+	if req.StartDate.IsZero() {
+		return xerror.ClientError{Err: fmt.Errorf("start_date is required")}
+	}
+
+	payGroup, err := logic.getPayGroup(ctx, req.PayGroupID)
+	if err != nil {
+		return err
+	}
+
+	start, end := req.StartDate, req.EndDate
+	if end.IsZero() {
+		_, templateEnd := periodBounds(payGroup, start.Time())
+		end = xdate.Of(templateEnd)
+	}
+
+	// the end date is exclusive, a period of a single day ends the day after it starts
+	if !end.After(start) {
+		return xerror.ClientError{Err: fmt.Errorf("end_date %s must be after start_date %s", end, start)}
+	}
+
+	totalWorkDay := calculateWorkingDays(start.Time(), end.Time())
 	if totalWorkDay <= 0 {
-		return xerror.ClientError{Err: fmt.Errorf("invalid start and end time for payroll period")}
+		return xerror.ClientError{Err: fmt.Errorf("payroll period from %s to %s has no working days", start, end)}
+	}
+
+	err = logic.validatePayrollPeriodSequence(ctx, payGroup.ID, start, end, req.AllowGap)
+	if err != nil {
+		return err
+	}
+
+	period := PayrollPeriod{
+		ID:            uuid.NewString(),
+		PayGroupID:    payGroup.ID,
+		StartDate:     start.Time(),
+		EndDate:       end.Time(),
+		TotalWorkDays: totalWorkDay,
+	}
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionCreate,
+		Entity:   xaudit.EntityPayrollPeriod,
+		EntityID: period.ID,
+		After:    auditPayrollPeriod(period),
+	}, func(ctx context.Context) error {
+		return logic.payrollRepo.SetPayrollPeriod(ctx, period)
+	})
+	if err != nil {
+		if errors.Is(err, ErrPayrollPeriodOverlap) {
+			return xerror.ClientError{Err: err}
+		}
+		logic.deps.Logger.ErrorContext(ctx, "failed to set payroll period", slog.Any("error", err))
+		return err
+	}
+
+	return nil
+}
+
+// validatePayrollPeriodSequence rejects periods overlapping the pay group's existing periods, so no day is paid twice,
+// and periods leaving days unpaid after the previous period unless the gap is allowed
+func (logic *PayrollLogic) validatePayrollPeriodSequence(ctx context.Context, payGroupID string, start xdate.Date, end xdate.Date, allowGap bool) error {
+	overlaps, err := logic.payrollRepo.GetOverlappingPayrollPeriods(ctx, payGroupID, start.Time(), end.Time())
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get overlapping payroll periods", slog.Any("error", err))
+		return err
+	}
+	if len(overlaps) > 0 {
+		overlap := overlaps[0]
+		return xerror.ClientError{Err: fmt.Errorf("%w: period %s from %s to %s", ErrPayrollPeriodOverlap, overlap.ID, xdate.Of(overlap.StartDate), xdate.Of(overlap.EndDate))}
+	}
+
+	if allowGap {
+		return nil
+	}
+
+	previous, err := logic.payrollRepo.GetPreviousPayrollPeriod(ctx, payGroupID, start.Time())
+	if err != nil {
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			return nil
+		}
+		logic.deps.Logger.ErrorContext(ctx, "failed to get previous payroll period", slog.Any("error", err))
+		return err
+	}
+
+	previousEnd := xdate.Of(previous.EndDate)
+	if previousEnd.Before(start) {
+		return xerror.ClientError{Err: fmt.Errorf("payroll period leaves %s to %s unpaid after period %s, start it on %s or set allow_gap", previousEnd, start.AddDays(-1), previous.ID, previousEnd)}
+	}
+
+	return nil
+}
+
+// CalculatePayroll queues a job to calculate the pay group's active payroll period, the job is processed by PayrollJobWorker
+func (logic *PayrollLogic) CalculatePayroll(ctx context.Context, payGroupID string) (PayrollJob, error) {
+	// check admin role of the user
+	userID := xcontext.GetUserIDFromContext(ctx)
+	isAdmin, err := logic.userRepo.IsAdmin(ctx, userID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to check user admin role", slog.Any("error", err))
+		return PayrollJob{}, err
+	}
+
+	if !isAdmin {
+		return PayrollJob{}, xerror.AuthError{Err: fmt.Errorf("admin only operation")}
+	}
+
+	payGroup, err := logic.getPayGroup(ctx, payGroupID)
+	if err != nil {
+		return PayrollJob{}, err
+	}
+
+	// get active payroll period
+	period, err := logic.payrollRepo.GetActivePayrollPeriod(ctx, payGroup.ID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get active payroll period", slog.Any("error", err))
+		return PayrollJob{}, err
+	}
+
+	// check whether payroll is already processed
+	if period.Processed {
+		return PayrollJob{}, xerror.LogicError{Err: fmt.Errorf("payroll processed already!")}
+	}
+
+	// return the queued job instead if the period is already being calculated
+	job, err := logic.payrollRepo.GetUnfinishedPayrollJob(ctx, period.ID)
+	if err == nil {
+		return job, nil
+	}
+	if !errors.Is(err, xerror.ErrDataNotFound) {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get unfinished payroll job", slog.Any("error", err))
+		return PayrollJob{}, err
+	}
+
+	// every currency of the period needs its exchange rate before the payslips are calculated
+	err = logic.checkExchangeRates(ctx, period)
+	if err != nil {
+		return PayrollJob{}, err
+	}
+
+	job = PayrollJob{
+		ID:        uuid.NewString(),
+		PayrollID: period.ID,
+		Status:    PayrollJobPending,
+		CreatedBy: userID,
+	}
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionCreate,
+		Entity:   xaudit.EntityPayrollJob,
+		EntityID: job.ID,
+		After:    job,
+	}, func(ctx context.Context) error {
+		return logic.payrollRepo.CreatePayrollJob(ctx, job)
+	})
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to create payroll job", slog.Any("error", err))
+		return PayrollJob{}, err
+	}
+
+	// wake the worker up instead of waiting for the next poll
+	select {
+	case logic.jobQueued <- struct{}{}:
+	default:
+	}
+
+	return job, nil
+}
+
+// JobQueued signals whenever a new payroll job is queued
+func (logic *PayrollLogic) JobQueued() <-chan struct{} {
+	return logic.jobQueued
+}
+
+func (logic *PayrollLogic) GetPayrollJob(ctx context.Context, jobID string) (PayrollJob, error) {
+	// check admin role of the user
+	userID := xcontext.GetUserIDFromContext(ctx)
+	isAdmin, err := logic.userRepo.IsAdmin(ctx, userID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to check user admin role", slog.Any("error", err))
+		return PayrollJob{}, err
+	}
+
+	if !isAdmin {
+		return PayrollJob{}, xerror.AuthError{Err: fmt.Errorf("admin only operation")}
+	}
+
+	job, err := logic.payrollRepo.GetPayrollJobByID(ctx, jobID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get payroll job", slog.Any("error", err))
+		return PayrollJob{}, err
+	}
+
+	return job, nil
+}
+
+// ProcessPayrollJob calculates and stores payslips of the job's payroll period.
+// Payslips stored by a previous attempt are skipped, so an interrupted job can simply be processed again.
+func (logic *PayrollLogic) ProcessPayrollJob(ctx context.Context, job PayrollJob) error {
+	period, err := logic.payrollRepo.GetPayrollPeriodByID(ctx, job.PayrollID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get payroll period of the job", slog.Any("error", err))
+		return logic.failPayrollJob(ctx, job, err)
+	}
+
+	if period.Processed {
+		return logic.finishPayrollJob(ctx, job, PayrollJobCompleted, nil)
+	}
+
+	// count the eligible employees and the payslips stored by the previous attempt for the job progress
+	total, err := logic.userRepo.CountEligibleUsers(ctx, period.PayGroupID, logic.deps.Config.Payroll.EligibleStatuses, period.StartDate, period.EndDate)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to count eligible users in payroll period", slog.Any("error", err))
+		return logic.failPayrollJob(ctx, job, err)
+	}
+
+	processed, err := logic.payrollRepo.CountPayslips(ctx, period.ID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to count stored payslips", slog.Any("error", err))
+		return logic.failPayrollJob(ctx, job, err)
+	}
+
+	err = logic.payrollRepo.UpdatePayrollJobProgress(ctx, job.ID, total, processed, nil)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to update payroll job progress", slog.Any("error", err))
+		return err
+	}
+
+	rates, err := logic.getExchangeRates(ctx, period.ID)
+	if err != nil {
+		return logic.failPayrollJob(ctx, job, err)
+	}
+
+	// calculate and store the remaining payslips,
+	// the job is picked up again after restart when the context is cancelled
+	source := func(ctx context.Context, yield func(PayrollCalculationData) error) error {
+		return logic.streamPayrollCalculationData(ctx, period, rates, yield)
+	}
+	err = logic.runPayslipPipeline(ctx, job, source, total, processed)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to process payslips", slog.Any("error", err))
+		return logic.failPayrollJob(ctx, job, err)
+	}
+
+	// chain and sign every stored payslip, the head of the chain is stored along with the processed flag
+	chain, err := logic.sealPayslips(ctx, period.ID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to seal payslips", slog.Any("error", err))
+		return logic.failPayrollJob(ctx, job, err)
+	}
+
+	// sum from the stored payslips so payslips of the previous attempts are counted too
+	totalPaidByCurrency, err := logic.payrollRepo.GetPayrollTotalPaid(ctx, period.ID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to sum payroll total paid", slog.Any("error", err))
+		return logic.failPayrollJob(ctx, job, err)
+	}
+
+	totalSalaryPaid, err := totalInBaseCurrency(rates, totalPaidByCurrency)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to convert payroll total paid", slog.Any("error", err))
+		return logic.failPayrollJob(ctx, job, err)
+	}
+
+	// the payslips are recorded as a whole along with the period, the actor is the admin who queued the job
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionProcess,
+		Entity:   xaudit.EntityPayrollPeriod,
+		EntityID: period.ID,
+		After: map[string]any{
+			"payroll_job_id":                job.ID,
+			"payslips":                      total,
+			"total_salary_paid":             totalSalaryPaid,
+			"total_salary_paid_by_currency": totalPaidByCurrency,
+			"payslip_chain_hash":            chain.Hash,
+		},
+	}, func(ctx context.Context) error {
+		return logic.payrollRepo.MarkPayrollProcessed(ctx, period.ID, totalSalaryPaid, totalPaidByCurrency, chain)
+	})
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to mark payroll period processed", slog.Any("error", err))
+		return logic.failPayrollJob(ctx, job, err)
+	}
+
+	// the payroll is done either way, users can still see their payslips without the email
+	err = logic.mails.EnqueuePayslipEmails(ctx, period.ID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to queue payslip emails", slog.Any("error", err), slog.String("payroll_id", period.ID))
+	}
+
+	return logic.finishPayrollJob(ctx, job, PayrollJobCompleted, nil)
+}
+
+// sealPayslips reads the stored payslips of the period in user_id order, chains their hashes and signs them.
+// sealing again gives the same chain as long as the payslips are untouched, so an interrupted job can redo it
+func (logic *PayrollLogic) sealPayslips(ctx context.Context, payrollID string) (PayslipChain, error) {
+	chunkSize := max(logic.deps.Config.Payroll.ChunkSize, 1)
+	secret := logic.deps.Config.Payroll.PayslipSigningSecret
+
+	prevHash := ""
+	afterUserID := ""
+	for {
+		payslips, err := logic.payrollRepo.GetPayslipsAfter(ctx, payrollID, afterUserID, chunkSize)
+		if err != nil {
+			return PayslipChain{}, fmt.Errorf("failed to get payslips: %w", err)
+		}
+		if len(payslips) == 0 {
+			break
+		}
+		afterUserID = payslips[len(payslips)-1].UserID
+
+		for i := range payslips {
+			hash := hashPayslip(payslips[i], prevHash)
+			payslips[i].PrevHash = prevHash
+			payslips[i].Hash = hash
+			payslips[i].Signature = xcrypto.Sign([]byte(hash), secret)
+			prevHash = hash
+		}
+
+		err = logic.payrollRepo.StorePayslipHashes(ctx, payslips)
+		if err != nil {
+			return PayslipChain{}, fmt.Errorf("failed to store %d payslip hashes: %w", len(payslips), err)
+		}
+
+		// last chunk
+		if len(payslips) < chunkSize {
+			break
+		}
+	}
+
+	return PayslipChain{
+		Hash:      prevHash,
+		Signature: xcrypto.Sign(payslipChainMessage(payrollID, prevHash), secret),
+	}, nil
+}
+
+// payrollDataSource feeds the calculation data of every user to be paid to yield,
+// it must stop and return the error once yield fails
+type payrollDataSource func(ctx context.Context, yield func(PayrollCalculationData) error) error
+
+// runPayslipPipeline calculates payslips with a pool of workers and stores them in batches.
+// the first error cancels the rest of the pipeline and is returned once every goroutine is done.
+func (logic *PayrollLogic) runPayslipPipeline(ctx context.Context, job PayrollJob, source payrollDataSource, total int, processed int) error {
+	workerCount := max(logic.deps.Config.Payroll.WorkerCount, 1)
+	batchSize := min(max(logic.deps.Config.Payroll.PayslipBatchSize, 1), maxPayslipBatchSize)
+
+	g, gctx := errgroup.WithContext(ctx)
+
+	// setup channel to pass the calculation data and result
+	jobChan := make(chan PayrollCalculationData)
+	payslipChan := make(chan models.Payslip, batchSize)
+
+	// feed data through channel
+	g.Go(func() error {
+		defer close(jobChan)
+		return source(gctx, func(data PayrollCalculationData) error {
+			select {
+			case jobChan <- data:
+				return nil
+			case <-gctx.Done():
+				return gctx.Err()
+			}
+		})
+	})
+
+	// spawn worker to consume data and process calculation
+	var workers sync.WaitGroup
+	for i := 0; i < workerCount; i++ {
+		workers.Add(1)
+		g.Go(func() error {
+			defer workers.Done()
+			for data := range jobChan {
+				select {
+				case payslipChan <- logic.CalculatePay(gctx, data):
+				case <-gctx.Done():
+					return gctx.Err()
+				}
+			}
+			return nil
+		})
+	}
+
+	// close the result channel once all workers are done
+	g.Go(func() error {
+		workers.Wait()
+		close(payslipChan)
+		return nil
+	})
+
+	// store calculation result in batches while tracking the job progress,
+	// this is the only goroutine touching the processed counter
+	g.Go(func() error {
+		batch := make([]models.Payslip, 0, batchSize)
+		flush := func() error {
+			if len(batch) == 0 {
+				return nil
+			}
+			if err := gctx.Err(); err != nil {
+				return err
+			}
+
+			err := logic.payrollRepo.StorePayslips(gctx, batch)
+			if err != nil {
+				return fmt.Errorf("failed to store %d payslips: %w", len(batch), err)
+			}
+			processed += len(batch)
+			batch = batch[:0]
+
+			err = logic.payrollRepo.UpdatePayrollJobProgress(gctx, job.ID, total, processed, nil)
+			if err != nil {
+				logic.deps.Logger.WarnContext(gctx, "failed to update payroll job progress", slog.Any("error", err))
+			}
+			return nil
+		}
+
+		for payslip := range payslipChan {
+			batch = append(batch, payslip)
+			if len(batch) < batchSize {
+				continue
+			}
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		return flush()
+	})
+
+	return g.Wait()
+}
+
+// streamPayrollCalculationData reads the users eligible for a payslip in the period in chunks
+// and yields their calculation data, so only one chunk is held in memory at a time.
+// eligibility is decided by the employment status and dates, not by the activity in the period,
+// so employees on leave or without any submission still get a payslip.
+func (logic *PayrollLogic) streamPayrollCalculationData(ctx context.Context, period PayrollPeriod, rates xcurrency.Rates, yield func(PayrollCalculationData) error) error {
+	chunkSize := max(logic.deps.Config.Payroll.ChunkSize, 1)
+
+	afterUserID := ""
+	for {
+		userIDs, err := logic.userRepo.GetEligibleUserIDs(ctx, period.PayGroupID, logic.deps.Config.Payroll.EligibleStatuses, period.StartDate, period.EndDate, afterUserID, chunkSize)
+		if err != nil {
+			logic.deps.Logger.ErrorContext(ctx, "failed to get eligible users in payroll period", slog.Any("error", err))
+			return err
+		}
+		if len(userIDs) == 0 {
+			return nil
+		}
+		afterUserID = userIDs[len(userIDs)-1]
+
+		// skip users whose payslip is already stored by the previous attempt
+		storedUserIDs, err := logic.payrollRepo.GetPayslipUserIDs(ctx, period.ID, userIDs)
+		if err != nil {
+			logic.deps.Logger.ErrorContext(ctx, "failed to get stored payslips", slog.Any("error", err))
+			return err
+		}
+		stored := make(map[string]bool, len(storedUserIDs))
+		for _, id := range storedUserIDs {
+			stored[id] = true
+		}
+		pendingUserIDs := make([]string, 0, len(userIDs))
+		for _, id := range userIDs {
+			if !stored[id] {
+				pendingUserIDs = append(pendingUserIDs, id)
+			}
+		}
+
+		if len(pendingUserIDs) != 0 {
+			activeUserMap, err := logic.collectPayrollCalculationData(ctx, period, rates, pendingUserIDs)
+			if err != nil {
+				return err
+			}
+
+			for _, id := range pendingUserIDs {
+				err := yield(activeUserMap[id])
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		// last chunk
+		if len(userIDs) < chunkSize {
+			return nil
+		}
+	}
+}
+
+// collectPayrollCalculationData compiles the given users and other related data in the period,
+// salaries and reimbursements are converted to the users' payout currency with the period exchange rates
+func (logic *PayrollLogic) collectPayrollCalculationData(ctx context.Context, period PayrollPeriod, rates xcurrency.Rates, userIDs []string) (map[string]PayrollCalculationData, error) {
+	// get users attendances in the period
+	usersAttendances, err := logic.attRepo.GetUsersAttendancesByPeriod(ctx, userIDs, period.StartDate, period.EndDate)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get users attendances in payroll period", slog.Any("error", err))
+		return nil, err
+	}
+
+	// get users overtimes in the period
+	usersOvertimes, err := logic.attRepo.GetUsersOvertimesByPeriod(ctx, userIDs, period.StartDate, period.EndDate)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get users overtimes in payroll period", slog.Any("error", err))
+		return nil, err
+	}
+
+	// get users reimbursement in the period
+	usersReimbursements, err := logic.attRepo.GetUsersReimbursementsByPeriod(ctx, userIDs, period.StartDate, period.EndDate)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get users reimbursements in payroll period", slog.Any("error", err))
+		return nil, err
+	}
+
+	// get users leave days in the period
+	usersLeaves, err := logic.attRepo.GetUsersLeaveDaysByPeriod(ctx, userIDs, period.StartDate, period.EndDate)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get users leave days in payroll period", slog.Any("error", err))
+		return nil, err
+	}
+
+	// get users salary
+	userSalaries, err := logic.userRepo.GetUsersSalaryByIDs(ctx, userIDs)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get active users salaries in payroll period", slog.Any("error", err))
+		return nil, err
+	}
+
+	// setup map to store all payroll related data
+	activeUserMap := make(map[string]PayrollCalculationData, len(userIDs))
+	for _, id := range userIDs {
+		activeUserMap[id] = PayrollCalculationData{
+			UserID:             id,
+			PayrollID:          period.ID,
+			TotalWorkDay:       period.TotalWorkDays,
+			SalaryCurrency:     rates.Base,
+			PayoutCurrency:     rates.Base,
+			SalaryExchangeRate: 1,
+		}
+	}
+
+	// populate payroll data with salary data first, as the payout currency is needed to convert the other amounts
+	for _, salary := range userSalaries {
+		activeData, ok := activeUserMap[salary.UserID]
+		if !ok {
+			continue
+		}
+
+		salaryCurrency := salary.Currency
+		if salaryCurrency == "" {
+			salaryCurrency = rates.Base
+		}
+		payoutCurrency := salary.PayoutCurrency
+		if payoutCurrency == "" {
+			payoutCurrency = salaryCurrency
+		}
+
+		rate, err := rates.Rate(salaryCurrency, payoutCurrency)
+		if err != nil {
+			logic.deps.Logger.ErrorContext(ctx, "failed to convert user salary", slog.String("user_id", salary.UserID), slog.Any("error", err))
+			return nil, fmt.Errorf("failed to convert salary of user %s: %w", salary.UserID, err)
+		}
+
+		activeData.SalaryCurrency = salaryCurrency
+		activeData.OriginalSalary = salary.Salary
+		activeData.PayoutCurrency = payoutCurrency
+		activeData.SalaryExchangeRate = rate
+		activeData.Salary = salary.Salary * rate
+		activeUserMap[salary.UserID] = activeData
+	}
+
+	// populate payroll data with attendance data
+	for _, att := range usersAttendances {
+		activeData, ok := activeUserMap[att.UserID]
+		if !ok {
+			continue
+		}
+		activeData.AttendanceCount = att.Count
+		activeUserMap[att.UserID] = activeData
+	}
+
+	// populate payroll data with overtime data
+	for _, ovt := range usersOvertimes {
+		activeData, ok := activeUserMap[ovt.UserID]
+		if !ok {
+			continue
+		}
+		activeData.OvertimeHoursCount = ovt.Count
+		activeUserMap[ovt.UserID] = activeData
+	}
+
+	// populate payroll data with reimbursement data
+	for _, reimbursement := range usersReimbursements {
+		activeData, ok := activeUserMap[reimbursement.UserID]
+		if !ok {
+			continue
+		}
+
+		data := toPayrollReimbursement(reimbursement)
+		if data.OriginalCurrency == "" {
+			data.OriginalCurrency = rates.Base
+		}
+		data.Amount, err = rates.Convert(data.OriginalAmount, data.OriginalCurrency, activeData.PayoutCurrency)
+		if err != nil {
+			logic.deps.Logger.ErrorContext(ctx, "failed to convert user reimbursement", slog.String("reimbursement_id", reimbursement.ID), slog.Any("error", err))
+			return nil, fmt.Errorf("failed to convert reimbursement %s: %w", reimbursement.ID, err)
+		}
+
+		activeData.Reimbursements = append(activeData.Reimbursements, data)
+		activeUserMap[reimbursement.UserID] = activeData
+	}
+
+	// populate payroll data with leave data
+	for _, leave := range usersLeaves {
+		activeData, ok := activeUserMap[leave.UserID]
+		if !ok {
+			continue
+		}
+		activeData.PaidLeaveDays = leave.PaidDays
+		activeData.UnpaidLeaveDays = leave.UnpaidDays
+		activeUserMap[leave.UserID] = activeData
+	}
+
+	// get users employment to prorate joiners and leavers
+	usersEmployment, err := logic.userRepo.GetUsersEmploymentByIDs(ctx, userIDs)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get users employment", slog.Any("error", err))
+		return nil, err
+	}
+
+	// populate payroll data with employment data
+	var terminated []models.Employment
+	for _, employment := range usersEmployment {
+		activeData, ok := activeUserMap[employment.UserID]
+		if !ok {
+			continue
+		}
+		activeData.NotEmployedWorkDays = period.TotalWorkDays - employedWorkDays(period, employment)
+		activeUserMap[employment.UserID] = activeData
+
+		if terminatedInPeriod(period, employment) {
+			terminated = append(terminated, employment)
+		}
+	}
+
+	if len(terminated) != 0 {
+		err = logic.collectFinalSettlementData(ctx, terminated, activeUserMap)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return activeUserMap, nil
+}
+
+// collectFinalSettlementData populates unused leave and outstanding deductions of users terminated in the period
+func (logic *PayrollLogic) collectFinalSettlementData(ctx context.Context, terminated []models.Employment, activeUserMap map[string]PayrollCalculationData) error {
+	userIDs := make([]string, 0, len(terminated))
+	for _, employment := range terminated {
+		userIDs = append(userIDs, employment.UserID)
+	}
+
+	// get outstanding deductions to be settled on the final payslip
+	deductions, err := logic.payrollRepo.GetUsersOutstandingDeductions(ctx, userIDs)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get users outstanding deductions", slog.Any("error", err))
+		return err
+	}
+
+	for _, employment := range terminated {
+		// leave entitlement is yearly, count paid leave taken this year up to the termination date
+		terminationDate := *employment.EndDate
+		yearStart := time.Date(terminationDate.Year(), time.January, 1, 0, 0, 0, 0, terminationDate.Location())
+		leaves, err := logic.attRepo.GetUsersLeaveDaysByPeriod(ctx, []string{employment.UserID}, yearStart, terminationDate.AddDate(0, 0, 1))
+		if err != nil {
+			logic.deps.Logger.ErrorContext(ctx, "failed to get user leave days in the year", slog.Any("error", err))
+			return err
+		}
+
+		var takenLeaveDays int
+		for _, leave := range leaves {
+			takenLeaveDays += leave.PaidDays
+		}
+
+		activeData := activeUserMap[employment.UserID]
+		activeData.FinalSettlement = true
+		activeData.UnusedLeaveDays = max(employment.AnnualLeaveDays-takenLeaveDays, 0)
+		activeUserMap[employment.UserID] = activeData
+	}
+
+	for _, deduction := range deductions {
+		activeData, ok := activeUserMap[deduction.UserID]
+		if !ok {
+			continue
+		}
+		activeData.Deductions = append(activeData.Deductions, deduction)
+		activeUserMap[deduction.UserID] = activeData
+	}
+
+	return nil
+}
+
+func (logic *PayrollLogic) failPayrollJob(ctx context.Context, job PayrollJob, cause error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	err := logic.finishPayrollJob(ctx, job, PayrollJobFailed, []string{cause.Error()})
+	if err != nil {
+		return err
+	}
+
+	return cause
+}
+
+func (logic *PayrollLogic) finishPayrollJob(ctx context.Context, job PayrollJob, status string, jobErrors []string) error {
+	err := logic.payrollRepo.FinishPayrollJob(ctx, job.ID, status, jobErrors)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to finish payroll job", slog.Any("error", err))
+		return err
+	}
+
+	if status == PayrollJobFailed {
+		return xerror.LogicError{Err: fmt.Errorf("payroll job %s failed: %s", job.ID, strings.Join(jobErrors, "; "))}
+	}
+
+	return nil
+}
+
+// proratedSalary = (total attendance + paid leave / total work day) * salary,
+// days outside the employment (hired or terminated mid period) are never paid
+func proratedSalary(payslip models.Payslip) float64 {
+	paidDays := min(payslip.TotalAttendance+payslip.PaidLeaveDays, payslip.EmployedWorkDays)
+	return (float64(paidDays) / float64(payslip.TotalWorkDay)) * (payslip.BaseSalary)
+}
+
+func (logic *PayrollLogic) CalculatePay(ctx context.Context, data PayrollCalculationData) models.Payslip {
+	payslip := models.Payslip{
+		ID:                uuid.NewString(),
+		UserID:            data.UserID,
+		PayrollID:         data.PayrollID,
+		BaseSalary:        data.Salary,
+		TotalAttendance:   data.AttendanceCount,
+		TotalWorkDay:      data.TotalWorkDay,
+		TotalOvertimeHour: data.OvertimeHoursCount,
+		PaidLeaveDays:     data.PaidLeaveDays,
+		UnpaidLeaveDays:   data.UnpaidLeaveDays,
+		ReviewReasons:     []string{},
+		Type:              models.PayslipRegular,
+		EmployedWorkDays:  max(data.TotalWorkDay-data.NotEmployedWorkDays, 0),
+		DeductionList:     []models.Deduction{},
+
+		SalaryCurrency:     data.SalaryCurrency,
+		OriginalSalary:     data.OriginalSalary,
+		PayoutCurrency:     data.PayoutCurrency,
+		SalaryExchangeRate: data.SalaryExchangeRate,
+	}
+
+	// calculate prorated salary
+	salary := proratedSalary(payslip)
+
+	// work days without attendance nor leave are paid as absent, but flagged for review
+	// as it's usually a missed submission rather than an actual absence
+	recordedDays := payslip.TotalAttendance + payslip.PaidLeaveDays + payslip.UnpaidLeaveDays
+	if recordedDays < payslip.EmployedWorkDays {
+		payslip.ReviewReasons = append(payslip.ReviewReasons, fmt.Sprintf("missing attendance on %d of %d work days", payslip.EmployedWorkDays-recordedDays, payslip.EmployedWorkDays))
+	}
+
+	// calculate overtime pay = prorated salary per hour * overtime hour
+	overtime := (payslip.BaseSalary / float64(payslip.TotalWorkDay) / 8) * float64(payslip.TotalOvertimeHour)
+	payslip.OvertimePay = overtime
+
+	// calculate reimbursement, also summarized per category
+	var reimburseAmount float64
+	categoryIndex := make(map[string]int)
+	for _, r := range data.Reimbursements {
+		reimburseAmount += r.Amount
+		reimbursement := models.Reimbursement{
+			ID:           r.ID,
+			Amount:       r.Amount,
+			Currency:     data.PayoutCurrency,
+			Description:  r.Desc,
+			Category:     r.Category,
+			CategoryName: r.CategoryName,
+			Taxable:      r.Taxable,
+			Receipts:     r.Receipts,
+		}
+		// the claimed amount is kept when it's paid out in another currency
+		if r.OriginalCurrency != "" && r.OriginalCurrency != data.PayoutCurrency {
+			reimbursement.OriginalAmount = r.OriginalAmount
+			reimbursement.OriginalCurrency = r.OriginalCurrency
+		}
+		payslip.ReimbursementList = append(payslip.ReimbursementList, reimbursement)
+
+		if r.Taxable {
+			payslip.TotalTaxableReimbursement += r.Amount
+		}
+
+		// claims submitted before categories existed are grouped together
+		category := r.Category
+		if category == "" {
+			category = uncategorizedReimbursement
+		}
+		idx, ok := categoryIndex[category]
+		if !ok {
+			idx = len(payslip.ReimbursementCategories)
+			categoryIndex[category] = idx
+			payslip.ReimbursementCategories = append(payslip.ReimbursementCategories, models.ReimbursementCategoryTotal{
+				Category: category,
+				Name:     r.CategoryName,
+				Taxable:  r.Taxable,
+			})
+		}
+		payslip.ReimbursementCategories[idx].Count++
+		payslip.ReimbursementCategories[idx].Amount += r.Amount
+	}
+	payslip.TotalReimbursement = reimburseAmount
+	sort.Slice(payslip.ReimbursementCategories, func(i, j int) bool {
+		return payslip.ReimbursementCategories[i].Category < payslip.ReimbursementCategories[j].Category
+	})
+
+	payslip.TakeHomePay = salary + overtime + reimburseAmount
+
+	if data.FinalSettlement {
+		payslip.Type = models.PayslipFinalSettlement
+
+		// unused leave is paid out with the daily rate of the period
+		payslip.UnusedLeaveDays = data.UnusedLeaveDays
+		payslip.LeavePayout = float64(data.UnusedLeaveDays) / float64(payslip.TotalWorkDay) * payslip.BaseSalary
+
+		// outstanding deductions are settled from the final pay
+		for _, d := range data.Deductions {
+			payslip.TotalDeduction += d.Amount
+			payslip.DeductionList = append(payslip.DeductionList, d)
+		}
+
+		payslip.TakeHomePay += payslip.LeavePayout - payslip.TotalDeduction
+		if payslip.TakeHomePay < 0 {
+			payslip.ReviewReasons = append(payslip.ReviewReasons, fmt.Sprintf("outstanding deductions exceed the final pay by %.2f", -payslip.TakeHomePay))
+			payslip.TakeHomePay = 0
+		}
+	}
+
+	return payslip
+}
+
+func (logic *PayrollLogic) GetPayrollsSummary(ctx context.Context, payGroupID string) (PayslipSummaryResponse, error) {
+	// check admin role of the user
+	userID := xcontext.GetUserIDFromContext(ctx)
+	isAdmin, err := logic.userRepo.IsAdmin(ctx, userID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to check user admin role", slog.Any("error", err))
+		return PayslipSummaryResponse{}, err
+	}
+
+	if !isAdmin {
+		return PayslipSummaryResponse{}, xerror.AuthError{Err: fmt.Errorf("admin only operation")}
+	}
+
+	payGroup, err := logic.getPayGroup(ctx, payGroupID)
+	if err != nil {
+		return PayslipSummaryResponse{}, err
+	}
+
+	// get active payroll period
+	period, err := logic.payrollRepo.GetActivePayrollPeriod(ctx, payGroup.ID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get active payroll period", slog.Any("error", err))
+		return PayslipSummaryResponse{}, err
+	}
+
+	payslips, err := logic.payrollRepo.GetPayslipsSummary(ctx, period.ID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get payslips summary", slog.Any("error", err))
+		return PayslipSummaryResponse{}, err
+	}
+
+	var response PayslipSummaryResponse
+	for _, slip := range payslips {
+		needsReview := len(slip.ReviewReasons) != 0
+		if needsReview {
+			response.TotalNeedsReview++
+		}
+
+		response.Payslips = append(response.Payslips, PayslipResponse{
+			UserID:      slip.UserID,
+			TakeHomePay: slip.TakeHomePay,
+			Currency:    slip.PayoutCurrency,
+			Name:        slip.Name,
+			NeedsReview: needsReview,
+			Hash:        slip.Hash,
+		})
+	}
+	response.PayrollID = period.ID
+	response.PayGroupID = period.PayGroupID
+	response.BaseCurrency = logic.deps.Config.Payroll.BaseCurrency
+	response.TotalTakeHomePay = period.TotalSalaryPaid
+	response.TotalTakeHomePayByCurrency = period.TotalSalaryPaidByCurrency
+
+	return response, nil
+}
+
+func (logic *PayrollLogic) GetUserPayslipByID(ctx context.Context, userID string) (models.Payslip, error) {
+	// get active payroll period of the user's pay group
+	period, err := logic.payrollRepo.GetUserActivePayrollPeriod(ctx, userID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get user active payroll period", slog.Any("error", err))
+		return models.Payslip{}, err
+	}
+
+	result, err := logic.payrollRepo.GetUserPayslipByID(ctx, userID, period.ID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get user payslip in active payroll period", slog.Any("error", err))
+		return models.Payslip{}, err
+	}
+
+	return result, nil
+}
+
+// AddDeduction records an amount owed by the user, it's settled on the user's final settlement payslip
+func (logic *PayrollLogic) AddDeduction(ctx context.Context, req DeductionRequest) (string, error) {
+	// check admin role of the user
+	userID := xcontext.GetUserIDFromContext(ctx)
+	isAdmin, err := logic.userRepo.IsAdmin(ctx, userID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to check user admin role", slog.Any("error", err))
+		return "", err
+	}
+
+	if !isAdmin {
+		return "", xerror.AuthError{Err: fmt.Errorf("admin only operation")}
+	}
+
+	if req.UserID == "" {
+		return "", xerror.ClientError{Err: fmt.Errorf("user id is required")}
+	}
+
+	if req.Amount <= 0 {
+		return "", xerror.ClientError{Err: fmt.Errorf("deduction amount must be greater than 0")}
+	}
+
+	deduction := models.Deduction{
+		ID:          uuid.NewString(),
+		UserID:      req.UserID,
+		Amount:      req.Amount,
+		Description: req.Description,
+	}
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionCreate,
+		Entity:   xaudit.EntityDeduction,
+		EntityID: deduction.ID,
+		After:    req,
+	}, func(ctx context.Context) error {
+		return logic.payrollRepo.CreateDeduction(ctx, deduction)
+	})
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to create deduction", slog.Any("error", err))
+		return "", err
+	}
+
+	return deduction.ID, nil
+}
+
+func (logic *PayrollLogic) CreatePayGroup(ctx context.Context, req PayGroupRequest) (PayGroup, error) {
+	// check admin role of the user
+	userID := xcontext.GetUserIDFromContext(ctx)
+	isAdmin, err := logic.userRepo.IsAdmin(ctx, userID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to check user admin role", slog.Any("error", err))
+		return PayGroup{}, err
+	}
+
+	if !isAdmin {
+		return PayGroup{}, xerror.AuthError{Err: fmt.Errorf("admin only operation")}
+	}
+
+	code := strings.ToLower(strings.TrimSpace(req.Code))
+	if code == "" {
+		return PayGroup{}, xerror.ClientError{Err: fmt.Errorf("pay group code is required")}
+	}
+
+	payGroup, err := toValidPayGroup(req)
+	if err != nil {
+		return PayGroup{}, err
+	}
+
+	// pay group code is unique
+	_, err = logic.payrollRepo.GetPayGroupByCode(ctx, code)
+	if err == nil {
+		return PayGroup{}, xerror.ClientError{Err: fmt.Errorf("pay group %s already exists", code)}
+	}
+	if !errors.Is(err, xerror.ErrDataNotFound) {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get pay group by code", slog.Any("error", err))
+		return PayGroup{}, err
+	}
+
+	payGroup.ID = uuid.NewString()
+	payGroup.Code = code
+	payGroup.CreatedAt = time.Now()
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionCreate,
+		Entity:   xaudit.EntityPayGroup,
+		EntityID: payGroup.ID,
+		After:    payGroup,
+	}, func(ctx context.Context) error {
+		return logic.payrollRepo.CreatePayGroup(ctx, payGroup)
+	})
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to create pay group", slog.Any("error", err))
+		return PayGroup{}, err
+	}
+
+	return payGroup, nil
+}
+
+// UpdatePayGroup updates the pay group name and period template, upcoming periods follow the new template
+func (logic *PayrollLogic) UpdatePayGroup(ctx context.Context, payGroupID string, req PayGroupRequest) (PayGroup, error) {
+	// check admin role of the user
+	userID := xcontext.GetUserIDFromContext(ctx)
+	isAdmin, err := logic.userRepo.IsAdmin(ctx, userID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to check user admin role", slog.Any("error", err))
+		return PayGroup{}, err
+	}
+
+	if !isAdmin {
+		return PayGroup{}, xerror.AuthError{Err: fmt.Errorf("admin only operation")}
+	}
+
+	current, err := logic.payrollRepo.GetPayGroupByID(ctx, payGroupID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get pay group", slog.Any("error", err))
+		return PayGroup{}, err
+	}
+
+	payGroup, err := toValidPayGroup(req)
+	if err != nil {
+		return PayGroup{}, err
+	}
+	payGroup.ID = current.ID
+	payGroup.Code = current.Code
+	payGroup.CreatedAt = current.CreatedAt
+
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionUpdate,
+		Entity:   xaudit.EntityPayGroup,
+		EntityID: payGroup.ID,
+		Before:   current,
+		After:    payGroup,
+	}, func(ctx context.Context) error {
+		return logic.payrollRepo.UpdatePayGroup(ctx, payGroup)
+	})
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to update pay group", slog.Any("error", err))
+		return PayGroup{}, err
+	}
+
+	return payGroup, nil
+}
+
+func (logic *PayrollLogic) GetPayGroups(ctx context.Context) ([]PayGroup, error) {
+	// check admin role of the user
+	userID := xcontext.GetUserIDFromContext(ctx)
+	isAdmin, err := logic.userRepo.IsAdmin(ctx, userID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to check user admin role", slog.Any("error", err))
+		return nil, err
+	}
+
+	if !isAdmin {
+		return nil, xerror.AuthError{Err: fmt.Errorf("admin only operation")}
+	}
+
+	payGroups, err := logic.payrollRepo.GetPayGroups(ctx)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get pay groups", slog.Any("error", err))
+		return nil, err
+	}
+
+	return payGroups, nil
+}
+
+// AssignPayGroupUsers moves the users to the pay group, they're paid in the pay group's periods from then on
+func (logic *PayrollLogic) AssignPayGroupUsers(ctx context.Context, payGroupID string, userIDs []string) (int, error) {
+	// check admin role of the user
+	userID := xcontext.GetUserIDFromContext(ctx)
+	isAdmin, err := logic.userRepo.IsAdmin(ctx, userID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to check user admin role", slog.Any("error", err))
+		return 0, err
+	}
+
+	if !isAdmin {
+		return 0, xerror.AuthError{Err: fmt.Errorf("admin only operation")}
+	}
+
+	if len(userIDs) == 0 {
+		return 0, xerror.ClientError{Err: fmt.Errorf("user ids are required")}
+	}
+
+	payGroup, err := logic.payrollRepo.GetPayGroupByID(ctx, payGroupID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get pay group", slog.Any("error", err))
+		return 0, err
+	}
+
+	// the count of users assigned is only known once they are, the entry is written after the change
+	var assigned int
+	after := map[string]any{"user_ids": userIDs}
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionAssignUsers,
+		Entity:   xaudit.EntityPayGroup,
+		EntityID: payGroup.ID,
+		After:    after,
+	}, func(ctx context.Context) error {
+		var err error
+		assigned, err = logic.payrollRepo.AssignPayGroupUsers(ctx, payGroup.ID, userIDs)
+		after["assigned"] = assigned
+		return err
+	})
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to assign users to pay group", slog.Any("error", err))
+		return 0, err
+	}
+
+	return assigned, nil
+}
+
+// PreviewPayroll calculates the payslips of the pay group's active payroll period without storing them
+func (logic *PayrollLogic) PreviewPayroll(ctx context.Context, payGroupID string) (PayrollPreview, error) {
+	// check admin role of the user
+	userID := xcontext.GetUserIDFromContext(ctx)
+	isAdmin, err := logic.userRepo.IsAdmin(ctx, userID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to check user admin role", slog.Any("error", err))
+		return PayrollPreview{}, err
+	}
+
+	if !isAdmin {
+		return PayrollPreview{}, xerror.AuthError{Err: fmt.Errorf("admin only operation")}
+	}
+
+	payGroup, err := logic.getPayGroup(ctx, payGroupID)
+	if err != nil {
+		return PayrollPreview{}, err
+	}
+
+	period, err := logic.payrollRepo.GetActivePayrollPeriod(ctx, payGroup.ID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get active payroll period", slog.Any("error", err))
+		return PayrollPreview{}, err
+	}
+
+	if period.Processed {
+		return PayrollPreview{}, xerror.LogicError{Err: fmt.Errorf("payroll processed already!")}
+	}
+
+	return logic.PreviewPayrollPeriod(ctx, period)
+}
+
+// PreviewPayrollPeriod calculates the payslips of the period one by one without storing them,
+// payslips already stored by an interrupted calculation are left out
+func (logic *PayrollLogic) PreviewPayrollPeriod(ctx context.Context, period PayrollPeriod) (PayrollPreview, error) {
+	rates, err := logic.getExchangeRates(ctx, period.ID)
+	if err != nil {
+		return PayrollPreview{}, err
+	}
+
+	preview := PayrollPreview{
+		PayrollID:                  period.ID,
+		PayGroupID:                 period.PayGroupID,
+		StartDate:                  xdate.Of(period.StartDate),
+		EndDate:                    xdate.Of(period.EndDate),
+		BaseCurrency:               rates.Base,
+		TotalTakeHomePayByCurrency: make(map[string]float64),
+	}
+
+	err = logic.streamPayrollCalculationData(ctx, period, rates, func(data PayrollCalculationData) error {
+		payslip := logic.CalculatePay(ctx, data)
+		preview.TotalPayslips++
+		preview.TotalTakeHomePayByCurrency[payslip.PayoutCurrency] += payslip.TakeHomePay
+		if len(payslip.ReviewReasons) != 0 {
+			preview.TotalNeedsReview++
+		}
+		if payslip.Type == models.PayslipFinalSettlement {
+			preview.TotalFinalSettlement++
+		}
+		return nil
+	})
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to preview payroll period", slog.Any("error", err))
+		return PayrollPreview{}, err
+	}
+
+	preview.TotalTakeHomePay, err = totalInBaseCurrency(rates, preview.TotalTakeHomePayByCurrency)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to convert payroll preview total", slog.Any("error", err))
+		return PayrollPreview{}, err
+	}
+
+	return preview, nil
+}
+
+func (logic *PayrollLogic) GetExchangeRates(ctx context.Context, payrollID string) (ExchangeRates, error) {
+	// check admin role of the user
+	userID := xcontext.GetUserIDFromContext(ctx)
+	isAdmin, err := logic.userRepo.IsAdmin(ctx, userID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to check user admin role", slog.Any("error", err))
+		return ExchangeRates{}, err
+	}
+
+	if !isAdmin {
+		return ExchangeRates{}, xerror.AuthError{Err: fmt.Errorf("admin only operation")}
+	}
+
+	period, err := logic.payrollRepo.GetPayrollPeriodByID(ctx, payrollID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get payroll period", slog.Any("error", err))
+		return ExchangeRates{}, err
+	}
+
+	rates, err := logic.getExchangeRates(ctx, period.ID)
+	if err != nil {
+		return ExchangeRates{}, err
+	}
+
+	return ExchangeRates{
+		PayrollID:    period.ID,
+		BaseCurrency: rates.Base,
+		Rates:        rates.Rates,
+	}, nil
+}
+
+// SetExchangeRates sets the exchange rates of the payroll period, rates of other currencies are kept.
+// Rates are frozen once the period is processed so the stored payslips can always be explained.
+func (logic *PayrollLogic) SetExchangeRates(ctx context.Context, payrollID string, req ExchangeRatesRequest) (ExchangeRates, error) {
+	// check admin role of the user
+	userID := xcontext.GetUserIDFromContext(ctx)
+	isAdmin, err := logic.userRepo.IsAdmin(ctx, userID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to check user admin role", slog.Any("error", err))
+		return ExchangeRates{}, err
+	}
+
+	if !isAdmin {
+		return ExchangeRates{}, xerror.AuthError{Err: fmt.Errorf("admin only operation")}
+	}
+
+	if len(req.Rates) == 0 {
+		return ExchangeRates{}, xerror.ClientError{Err: fmt.Errorf("exchange rates are required")}
+	}
+
+	baseCurrency := logic.deps.Config.Payroll.BaseCurrency
+	rates := make(map[string]float64, len(req.Rates))
+	for code, rate := range req.Rates {
+		currency, err := xcurrency.Normalize(code)
+		if err != nil {
+			return ExchangeRates{}, xerror.ClientError{Err: err}
+		}
+		if currency == baseCurrency {
+			return ExchangeRates{}, xerror.ClientError{Err: fmt.Errorf("%s is the base currency, its rate is always 1", currency)}
+		}
+		if rate <= 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+			return ExchangeRates{}, xerror.ClientError{Err: fmt.Errorf("exchange rate of %s must be greater than 0", currency)}
+		}
+		rates[currency] = rate
+	}
+
+	period, err := logic.payrollRepo.GetPayrollPeriodByID(ctx, payrollID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get payroll period", slog.Any("error", err))
+		return ExchangeRates{}, err
+	}
+
+	if period.Processed {
+		return ExchangeRates{}, xerror.LogicError{Err: fmt.Errorf("payroll processed already!")}
+	}
+
+	current, err := logic.getExchangeRates(ctx, period.ID)
+	if err != nil {
+		return ExchangeRates{}, err
+	}
+
+	// rates of other currencies are kept, so they're part of the state after the change too
+	after := maps.Clone(current.Rates)
+	if after == nil {
+		after = make(map[string]float64, len(rates))
+	}
+	maps.Copy(after, rates)
+
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionUpdate,
+		Entity:   xaudit.EntityExchangeRates,
+		EntityID: period.ID,
+		Before:   map[string]any{"base_currency": current.Base, "rates": current.Rates},
+		After:    map[string]any{"base_currency": baseCurrency, "rates": after},
+	}, func(ctx context.Context) error {
+		return logic.payrollRepo.SetExchangeRates(ctx, period.ID, rates)
+	})
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to set payroll period exchange rates", slog.Any("error", err))
+		return ExchangeRates{}, err
+	}
+
+	result, err := logic.getExchangeRates(ctx, period.ID)
+	if err != nil {
+		return ExchangeRates{}, err
+	}
+
+	return ExchangeRates{
+		PayrollID:    period.ID,
+		BaseCurrency: result.Base,
+		Rates:        result.Rates,
+	}, nil
+}
+
+// VerifyPayslips recomputes the payslip hash chain of a processed period and checks it against the stored
+// hashes, signatures and chain head. hash optionally asks whether a payslip hash, e.g. shown to an employee,
+// belongs to a valid payslip of the period.
+func (logic *PayrollLogic) VerifyPayslips(ctx context.Context, payrollID string, hash string) (PayslipVerification, error) {
+	// check admin role of the user
+	userID := xcontext.GetUserIDFromContext(ctx)
+	isAdmin, err := logic.userRepo.IsAdmin(ctx, userID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to check user admin role", slog.Any("error", err))
+		return PayslipVerification{}, err
+	}
+
+	if !isAdmin {
+		return PayslipVerification{}, xerror.AuthError{Err: fmt.Errorf("admin only operation")}
+	}
+
+	period, err := logic.payrollRepo.GetPayrollPeriodByID(ctx, payrollID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get payroll period", slog.Any("error", err))
+		return PayslipVerification{}, err
+	}
+
+	// the chain head is signed even when the period has no payslips
+	if !period.Processed || period.PayslipChain.Signature == "" {
+		return PayslipVerification{}, xerror.ClientError{Err: fmt.Errorf("payslips of the payroll period are not sealed, the period is either not processed yet or processed before payslips were sealed")}
+	}
+
+	chunkSize := max(logic.deps.Config.Payroll.ChunkSize, 1)
+	secret := logic.deps.Config.Payroll.PayslipSigningSecret
+
+	result := PayslipVerification{
+		PayrollID:       period.ID,
+		ChainHash:       period.PayslipChain.Hash,
+		InvalidPayslips: []InvalidPayslip{},
+	}
+	hashFound := false
+
+	prevHash := ""
+	afterUserID := ""
+	for {
+		payslips, err := logic.payrollRepo.GetPayslipsAfter(ctx, period.ID, afterUserID, chunkSize)
+		if err != nil {
+			logic.deps.Logger.ErrorContext(ctx, "failed to get payslips", slog.Any("error", err))
+			return PayslipVerification{}, err
+		}
+		if len(payslips) == 0 {
+			break
+		}
+		afterUserID = payslips[len(payslips)-1].UserID
+
+		for _, payslip := range payslips {
+			result.Payslips++
+
+			reason := verifyPayslip(payslip, prevHash, secret)
+			if reason != "" {
+				result.InvalidPayslips = append(result.InvalidPayslips, InvalidPayslip{ID: payslip.ID, UserID: payslip.UserID, Reason: reason})
+			} else if hash != "" && payslip.Hash == hash {
+				hashFound = true
+			}
+
+			// continue from the stored hash so a changed payslip doesn't invalidate the rest of the chain
+			prevHash = payslip.Hash
+		}
+
+		// last chunk
+		if len(payslips) < chunkSize {
+			break
+		}
 	}
 
-	err = logic.payrollRepo.SetPayrollPeriod(ctx, PayrollPeriod{
-		ID:            uuid.NewString(),
-		StartDate:     start,
-		EndDate:       end,
-		TotalWorkDays: totalWorkDay,
-	})
+	// removing payslips at the end of the chain leaves every remaining payslip valid, only the head tells
+	result.ChainHeadValid = prevHash == period.PayslipChain.Hash && xcrypto.Verify(payslipChainMessage(period.ID, period.PayslipChain.Hash), period.PayslipChain.Signature, secret)
+	result.Valid = result.ChainHeadValid && len(result.InvalidPayslips) == 0
+	if hash != "" {
+		result.HashFound = &hashFound
+	}
+
+	return result, nil
+}
+
+// GetJournalAccounts returns the journal account of every account type, the ACCOUNTING_* account when the company
+// hasn't mapped its own
+func (logic *PayrollLogic) GetJournalAccounts(ctx context.Context) ([]JournalAccount, error) {
+	// check admin role of the user
+	userID := xcontext.GetUserIDFromContext(ctx)
+	isAdmin, err := logic.userRepo.IsAdmin(ctx, userID)
 	if err != nil {
-		logic.deps.Logger.ErrorContext(ctx, "failed to set payroll period", slog.Any("error", err))
-		return err
+		logic.deps.Logger.ErrorContext(ctx, "failed to check user admin role", slog.Any("error", err))
+		return []JournalAccount{}, err
 	}
 
-	return nil
+	if !isAdmin {
+		return []JournalAccount{}, xerror.AuthError{Err: fmt.Errorf("admin only operation")}
+	}
+
+	return logic.getJournalAccounts(ctx)
 }
 
-func (logic *PayrollLogic) CalculatePayroll(ctx context.Context) error {
+// SetJournalAccounts maps the account types to accounts of the company's chart of accounts, the other account
+// types are kept
+func (logic *PayrollLogic) SetJournalAccounts(ctx context.Context, req JournalAccountsRequest) ([]JournalAccount, error) {
 	// check admin role of the user
 	userID := xcontext.GetUserIDFromContext(ctx)
 	isAdmin, err := logic.userRepo.IsAdmin(ctx, userID)
 	if err != nil {
 		logic.deps.Logger.ErrorContext(ctx, "failed to check user admin role", slog.Any("error", err))
-		return err
+		return []JournalAccount{}, err
 	}
 
 	if !isAdmin {
-		return xerror.AuthError{Err: fmt.Errorf("admin only operation")}
+		return []JournalAccount{}, xerror.AuthError{Err: fmt.Errorf("admin only operation")}
 	}
 
-	err = dbhelper.WithTransaction(ctx, logic.deps.DB, func(ctx context.Context) error {
-		// get active payroll period
-		period, err := logic.payrollRepo.GetActivePayrollPeriod(ctx)
-		if err != nil {
-			logic.deps.Logger.ErrorContext(ctx, "failed to get active payroll period", slog.Any("error", err))
-			return err
+	if len(req.Accounts) == 0 {
+		return []JournalAccount{}, xerror.ClientError{Err: fmt.Errorf("journal accounts are required")}
+	}
+
+	for accountType := range req.Accounts {
+		if !slices.Contains(JournalAccountTypes, accountType) {
+			return []JournalAccount{}, xerror.ClientError{Err: fmt.Errorf("unknown journal account type %q, must be one of %s", accountType, strings.Join(JournalAccountTypes, ", "))}
 		}
+	}
 
-		// check whether payroll is already processed
-		if period.Processed {
-			return xerror.LogicError{Err: fmt.Errorf("payroll processed already!")}
+	accounts := make([]JournalAccount, 0, len(req.Accounts))
+	for _, accountType := range JournalAccountTypes {
+		account, ok := req.Accounts[accountType]
+		if !ok {
+			continue
 		}
 
-		// get all users attendances in the period
-		usersAttendances, err := logic.attRepo.GetAllUserAttendancesByPeriod(ctx, period.StartDate, period.EndDate)
-		if err != nil {
-			logic.deps.Logger.ErrorContext(ctx, "failed to get all users attendances in payroll period", slog.Any("error", err))
-			return err
+		code := strings.TrimSpace(account.Code)
+		if code == "" {
+			return []JournalAccount{}, xerror.ClientError{Err: fmt.Errorf("account code of %s is required", accountType)}
 		}
+		name := strings.TrimSpace(account.Name)
+		if name == "" {
+			name = journalAccountNames[accountType]
+		}
+		accounts = append(accounts, JournalAccount{Type: accountType, Code: code, Name: name})
+	}
+
+	current, err := logic.getJournalAccounts(ctx)
+	if err != nil {
+		return []JournalAccount{}, err
+	}
+
+	before := make(map[string]JournalAccount, len(current))
+	for _, account := range current {
+		before[account.Type] = account
+	}
 
-		// get all users overtimes in the period
-		usersOvertimes, err := logic.attRepo.GetAllUserOvertimesByPeriod(ctx, period.StartDate, period.EndDate)
+	// an entry per account, all written in the transaction of the change
+	err = logic.auditor.WithTransaction(ctx, func(ctx context.Context) error {
+		err := logic.payrollRepo.SetJournalAccounts(ctx, accounts)
 		if err != nil {
-			logic.deps.Logger.ErrorContext(ctx, "failed to get all users overtimes in payroll period", slog.Any("error", err))
 			return err
 		}
 
-		// get all users reimbursement in the period
-		usersReimbursements, err := logic.attRepo.GetAllUserReimbursementsByPeriod(ctx, period.StartDate, period.EndDate)
-		if err != nil {
-			logic.deps.Logger.ErrorContext(ctx, "failed to get all users reimbursements in payroll period", slog.Any("error", err))
-			return err
+		for _, account := range accounts {
+			err = logic.auditor.Record(ctx, xaudit.Entry{
+				Action:   xaudit.ActionUpdate,
+				Entity:   xaudit.EntityJournalAccount,
+				EntityID: account.Type,
+				Before:   before[account.Type],
+				After:    account,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to record audit log of journal account %s: %w", account.Type, err)
+			}
 		}
 
-		// compile all active users and other related data in the period
+		return nil
+	})
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to set journal accounts", slog.Any("error", err))
+		return []JournalAccount{}, err
+	}
 
-		// setup map to store all payroll related data
-		activeUserMap := make(map[string]PayrollCalculationData)
+	return logic.getJournalAccounts(ctx)
+}
 
-		// setup list to store all active user IDs (user that worked in the active payroll period)
-		activeUserList := []string{}
+// GetPayrollJournal exports the payslips of a processed period as journal entries, one per payout currency.
+// The salaries, overtime and reimbursements are debited, the net pay and the deductions recovered from the
+// final pay are credited.
+func (logic *PayrollLogic) GetPayrollJournal(ctx context.Context, payrollID string) (Journal, error) {
+	// check admin role of the user
+	userID := xcontext.GetUserIDFromContext(ctx)
+	isAdmin, err := logic.userRepo.IsAdmin(ctx, userID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to check user admin role", slog.Any("error", err))
+		return Journal{}, err
+	}
 
-		// populate payroll and active user data with attendance data
-		for _, att := range usersAttendances {
-			_, ok := activeUserMap[att.UserID]
-			if !ok {
-				activeUserMap[att.UserID] = PayrollCalculationData{
-					UserID:          att.UserID,
-					PayrollID:       period.ID,
-					TotalWorkDay:    period.TotalWorkDays,
-					AttendanceCount: att.Count,
-				}
-				activeUserList = append(activeUserList, att.UserID)
-			}
+	if !isAdmin {
+		return Journal{}, xerror.AuthError{Err: fmt.Errorf("admin only operation")}
+	}
 
-		}
+	period, err := logic.payrollRepo.GetPayrollPeriodByID(ctx, payrollID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get payroll period", slog.Any("error", err))
+		return Journal{}, err
+	}
 
-		// populate payroll and active user data with overtime data
-		for _, ovt := range usersOvertimes {
-			activeData, ok := activeUserMap[ovt.UserID]
-			if !ok {
-				activeUserMap[ovt.UserID] = PayrollCalculationData{
-					UserID:             ovt.UserID,
-					PayrollID:          period.ID,
-					TotalWorkDay:       period.TotalWorkDays,
-					OvertimeHoursCount: ovt.Count,
-				}
-				activeUserList = append(activeUserList, ovt.UserID)
-			} else {
-				activeData.OvertimeHoursCount = ovt.Count
-				activeUserMap[ovt.UserID] = activeData
-			}
-		}
+	// the payslips aren't final until the period is processed
+	if !period.Processed {
+		return Journal{}, xerror.ClientError{Err: fmt.Errorf("payroll period is not processed yet")}
+	}
 
-		// populate payroll and active user data with reimbursement data
-		for _, reimbursement := range usersReimbursements {
-			activeData, ok := activeUserMap[reimbursement.UserID]
-			if !ok {
-				activeUserMap[reimbursement.UserID] = PayrollCalculationData{
-					UserID:       reimbursement.UserID,
-					PayrollID:    period.ID,
-					TotalWorkDay: period.TotalWorkDays,
-					Reimbursements: []Reimbursement{
-						{
-							ID:     reimbursement.ID,
-							Amount: reimbursement.Amount,
-							Desc:   reimbursement.Description,
-						},
-					},
-				}
-				activeUserList = append(activeUserList, reimbursement.UserID)
-			} else {
-				activeData.Reimbursements = append(activeData.Reimbursements, Reimbursement{
-					ID:     reimbursement.ID,
-					Amount: reimbursement.Amount,
-					Desc:   reimbursement.Description,
-				})
-				activeUserMap[reimbursement.UserID] = activeData
-			}
-		}
+	accounts, err := logic.getJournalAccounts(ctx)
+	if err != nil {
+		return Journal{}, err
+	}
+
+	chunkSize := max(logic.deps.Config.Payroll.ChunkSize, 1)
+	baseCurrency := logic.deps.Config.Payroll.BaseCurrency
 
-		// get all active users salary
-		userSalaries, err := logic.userRepo.GetUsersSalaryByIDs(ctx, activeUserList)
+	result := Journal{
+		PayrollID:    period.ID,
+		PayGroupID:   period.PayGroupID,
+		StartDate:    xdate.Of(period.StartDate),
+		EndDate:      xdate.Of(period.EndDate),
+		BaseCurrency: baseCurrency,
+		Entries:      []JournalEntry{},
+	}
+
+	totals := make(map[string]*journalTotals)
+	afterUserID := ""
+	for {
+		payslips, err := logic.payrollRepo.GetPayslipsAfter(ctx, period.ID, afterUserID, chunkSize)
 		if err != nil {
-			logic.deps.Logger.ErrorContext(ctx, "failed to get all active users salaries in payroll period", slog.Any("error", err))
-			return err
+			logic.deps.Logger.ErrorContext(ctx, "failed to get payslips", slog.Any("error", err))
+			return Journal{}, err
+		}
+		if len(payslips) == 0 {
+			break
 		}
+		afterUserID = payslips[len(payslips)-1].UserID
 
-		// populate payroll and active user data with salary data
-		for _, salary := range userSalaries {
-			activeData, ok := activeUserMap[salary.UserID]
-			if !ok {
-				activeUserMap[salary.UserID] = PayrollCalculationData{
-					UserID:       salary.UserID,
-					PayrollID:    period.ID,
-					TotalWorkDay: period.TotalWorkDays,
-					Salary:       salary.Salary,
-				}
+		for _, payslip := range payslips {
+			result.Payslips++
 
-			} else {
-				activeData.Salary = salary.Salary
-				activeUserMap[salary.UserID] = activeData
+			// payslips stored before multi-currency payouts are paid in the base currency
+			currency := payslip.PayoutCurrency
+			if currency == "" {
+				currency = baseCurrency
+			}
+			if _, ok := totals[currency]; !ok {
+				totals[currency] = &journalTotals{}
 			}
+			totals[currency].add(payslip)
+		}
 
+		// last chunk
+		if len(payslips) < chunkSize {
+			break
 		}
+	}
 
-		// setup worker to calculate payroll
-		// setup wait group for flow control
-		var wg sync.WaitGroup
+	currencies := make([]string, 0, len(totals))
+	for currency := range totals {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
 
-		// setup channel to pass the calculation data and result
-		jobChan := make(chan PayrollCalculationData)
-		payslipChan := make(chan models.Payslip)
+	for _, currency := range currencies {
+		result.Entries = append(result.Entries, journalEntry(period, currency, totals[currency], accounts))
+	}
 
-		// spawn worker to consume data and process calculation
-		for i := 0; i < 20; i++ {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				for job := range jobChan {
-					payslipChan <- logic.CalculatePay(ctx, job)
-				}
-			}()
-		}
+	return result, nil
+}
 
-		// feed data through channel
-		go func() {
-			for _, data := range activeUserMap {
-				jobChan <- data
-			}
-			close(jobChan)
-		}()
-
-		// setup total salary paid
-		var totalSalaryPaid float64
-
-		// spawn worker that receives calculation result
-		// and store it to database
-		go func() {
-			for payslip := range payslipChan {
-				totalSalaryPaid += payslip.TakeHomePay
-				err := logic.payrollRepo.StorePayslip(ctx, payslip)
-				if err != nil {
-					logic.deps.Logger.ErrorContext(ctx, "failed to store payslip data", slog.Any("error", err))
-					return
-				}
-			}
-		}()
+// journalAccountNames are the account names of the account types, unless the company names its accounts
+var journalAccountNames = map[string]string{
+	AccountSalaryExpense:        "Salary Expense",
+	AccountOvertimeExpense:      "Overtime Expense",
+	AccountReimbursementExpense: "Reimbursement Expense",
+	AccountTaxPayable:           "Tax Payable",
+	AccountNetPayPayable:        "Net Pay Payable",
+	AccountDeductionReceivable:  "Employee Receivable",
+}
 
-		wg.Wait()
-		close(payslipChan)
+// getJournalAccounts returns the accounts mapped by the company, falling back to the ACCOUNTING_* accounts
+func (logic *PayrollLogic) getJournalAccounts(ctx context.Context) ([]JournalAccount, error) {
+	cfg := logic.deps.Config.Accounting
+	defaults := map[string]string{
+		AccountSalaryExpense:        cfg.SalaryExpenseAccount,
+		AccountOvertimeExpense:      cfg.OvertimeExpenseAccount,
+		AccountReimbursementExpense: cfg.ReimbursementExpenseAccount,
+		AccountTaxPayable:           cfg.TaxPayableAccount,
+		AccountNetPayPayable:        cfg.NetPayPayableAccount,
+		AccountDeductionReceivable:  cfg.DeductionReceivableAccount,
+	}
 
-		err = logic.payrollRepo.MarkPayrollProcessed(ctx, period.ID, totalSalaryPaid)
-		if err != nil {
-			logic.deps.Logger.ErrorContext(ctx, "failed to mark payroll period processed", slog.Any("error", err))
-			return err
-		}
-		return nil
-	})
+	mapped, err := logic.payrollRepo.GetJournalAccounts(ctx)
 	if err != nil {
-		logic.deps.Logger.ErrorContext(ctx, "failed to process payroll", slog.Any("error", err))
-		return err
+		logic.deps.Logger.ErrorContext(ctx, "failed to get journal accounts", slog.Any("error", err))
+		return []JournalAccount{}, err
 	}
 
-	return nil
+	byType := make(map[string]JournalAccount, len(mapped))
+	for _, account := range mapped {
+		byType[account.Type] = account
+	}
+
+	result := make([]JournalAccount, 0, len(JournalAccountTypes))
+	for _, accountType := range JournalAccountTypes {
+		account, ok := byType[accountType]
+		if !ok {
+			account = JournalAccount{
+				Type:    accountType,
+				Code:    defaults[accountType],
+				Name:    journalAccountNames[accountType],
+				Default: true,
+			}
+		}
+		result = append(result, account)
+	}
+
+	return result, nil
 }
 
-func (logic *PayrollLogic) CalculatePay(ctx context.Context, data PayrollCalculationData) models.Payslip {
-	payslip := models.Payslip{
-		ID:                uuid.NewString(),
-		UserID:            data.UserID,
-		PayrollID:         data.PayrollID,
-		BaseSalary:        data.Salary,
-		TotalAttendance:   data.AttendanceCount,
-		TotalWorkDay:      data.TotalWorkDay,
-		TotalOvertimeHour: data.OvertimeHoursCount,
+// journalTotals sums up the payslip amounts of a payout currency
+type journalTotals struct {
+	overtime      float64
+	reimbursement float64
+	deductions    float64
+	netPay        float64
+	tax           float64 // withheld from the pay, nothing until the payroll withholds tax
+}
+
+func (t *journalTotals) add(payslip models.Payslip) {
+	t.overtime += payslip.OvertimePay
+	t.reimbursement += payslip.TotalReimbursement
+	t.netPay += payslip.TakeHomePay
+
+	// deductions exceeding the final pay are only recovered up to it, leaving nothing to take home
+	recovered := payslip.TotalDeduction
+	if recovered > 0 && payslip.TakeHomePay == 0 {
+		recovered = math.Min(recovered, proratedSalary(payslip)+payslip.LeavePayout+payslip.OvertimePay+payslip.TotalReimbursement)
 	}
+	t.deductions += recovered
+}
 
-	// calculate prorated salary = (total attendance / total work day) * salary
-	salary := (float64(payslip.TotalAttendance) / float64(payslip.TotalWorkDay)) * (payslip.BaseSalary)
+// journalEntry builds the journal entry of the totals, balanced in cents. The salary expense is what's left of the
+// credits after the other debits, so it takes up the rounding and includes the leave payouts of final settlements.
+func journalEntry(period PayrollPeriod, currency string, totals *journalTotals, accounts []JournalAccount) JournalEntry {
+	overtime := toCents(totals.overtime)
+	reimbursement := toCents(totals.reimbursement)
+	deductions := toCents(totals.deductions)
+	netPay := toCents(totals.netPay)
+	tax := toCents(totals.tax)
+	salary := netPay + tax + deductions - overtime - reimbursement
 
-	// calculate overtime pay = prorated salary per hour * overtime hour
-	overtime := (payslip.BaseSalary / float64(payslip.TotalWorkDay) / 8) * float64(payslip.TotalOvertimeHour)
-	payslip.OvertimePay = overtime
+	lines := []struct {
+		accountType string
+		description string
+		debit       int64
+		credit      int64
+	}{
+		{AccountSalaryExpense, "salaries and leave payouts", salary, 0},
+		{AccountOvertimeExpense, "overtime pay", overtime, 0},
+		{AccountReimbursementExpense, "reimbursements", reimbursement, 0},
+		{AccountNetPayPayable, "net pay", 0, netPay},
+		{AccountTaxPayable, "tax withheld", 0, tax},
+		{AccountDeductionReceivable, "deductions recovered from final pay", 0, deductions},
+	}
 
-	// calculate reimbursement
-	var reimburseAmount float64
-	for _, r := range data.Reimbursements {
-		reimburseAmount += r.Amount
-		payslip.ReimbursementList = append(payslip.ReimbursementList, models.Reimbursement{
-			ID:          r.ID,
-			Amount:      r.Amount,
-			Description: r.Desc,
+	byType := make(map[string]JournalAccount, len(accounts))
+	for _, account := range accounts {
+		byType[account.Type] = account
+	}
+
+	startDate, endDate := xdate.Of(period.StartDate), xdate.Of(period.EndDate)
+	entry := JournalEntry{
+		Reference:   fmt.Sprintf("PAYROLL-%s-%s", period.ID, currency),
+		Date:        endDate,
+		Currency:    currency,
+		Description: fmt.Sprintf("Payroll %s to %s", startDate, endDate),
+		Lines:       []JournalLine{},
+	}
+
+	// lines without an amount are left out, e.g. the tax payable while no tax is withheld
+	var totalDebit, totalCredit int64
+	for _, line := range lines {
+		if line.debit == 0 && line.credit == 0 {
+			continue
+		}
+
+		account := byType[line.accountType]
+		entry.Lines = append(entry.Lines, JournalLine{
+			AccountType: line.accountType,
+			AccountCode: account.Code,
+			AccountName: account.Name,
+			Description: line.description,
+			Debit:       fromCents(line.debit),
+			Credit:      fromCents(line.credit),
 		})
+		totalDebit += line.debit
+		totalCredit += line.credit
 	}
-	payslip.TotalReimbursement = reimburseAmount
+	entry.TotalDebit = fromCents(totalDebit)
+	entry.TotalCredit = fromCents(totalCredit)
 
-	payslip.TakeHomePay = salary + overtime + reimburseAmount
+	return entry
+}
 
-	return payslip
+func toCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
 }
 
-func (logic *PayrollLogic) GetPayrollsSummary(ctx context.Context) (PayslipSummaryResponse, error) {
-	// check admin role of the user
-	userID := xcontext.GetUserIDFromContext(ctx)
-	isAdmin, err := logic.userRepo.IsAdmin(ctx, userID)
+func fromCents(cents int64) float64 {
+	return float64(cents) / 100
+}
+
+func (logic *PayrollLogic) getExchangeRates(ctx context.Context, payrollID string) (xcurrency.Rates, error) {
+	rates, err := logic.payrollRepo.GetExchangeRates(ctx, payrollID)
 	if err != nil {
-		logic.deps.Logger.ErrorContext(ctx, "failed to check user admin role", slog.Any("error", err))
-		return PayslipSummaryResponse{}, err
+		logic.deps.Logger.ErrorContext(ctx, "failed to get payroll period exchange rates", slog.Any("error", err))
+		return xcurrency.Rates{}, err
 	}
 
-	if !isAdmin {
-		return PayslipSummaryResponse{}, xerror.AuthError{Err: fmt.Errorf("admin only operation")}
-	}
+	return xcurrency.Rates{
+		Base:  logic.deps.Config.Payroll.BaseCurrency,
+		Rates: rates,
+	}, nil
+}
 
-	// get active payroll period
-	period, err := logic.payrollRepo.GetActivePayrollPeriod(ctx)
+// checkExchangeRates makes sure every salary, payout and reimbursement currency of the period has its exchange rate
+func (logic *PayrollLogic) checkExchangeRates(ctx context.Context, period PayrollPeriod) error {
+	rates, err := logic.getExchangeRates(ctx, period.ID)
 	if err != nil {
-		logic.deps.Logger.ErrorContext(ctx, "failed to get active payroll period", slog.Any("error", err))
-		return PayslipSummaryResponse{}, err
+		return err
 	}
 
-	payslips, err := logic.payrollRepo.GetPayslipsSummary(ctx, period.ID)
+	currencies, err := logic.payrollRepo.GetPayrollPeriodCurrencies(ctx, period, logic.deps.Config.Payroll.EligibleStatuses)
 	if err != nil {
-		logic.deps.Logger.ErrorContext(ctx, "failed to get payslips summary", slog.Any("error", err))
-		return PayslipSummaryResponse{}, err
+		logic.deps.Logger.ErrorContext(ctx, "failed to get payroll period currencies", slog.Any("error", err))
+		return err
 	}
 
-	var response PayslipSummaryResponse
-	for _, slip := range payslips {
-		response.Payslips = append(response.Payslips, PayslipResponse{
-			UserID:      slip.UserID,
-			TakeHomePay: slip.TakeHomePay,
-			Name:        slip.Name,
-		})
+	missing := rates.Missing(currencies)
+	if len(missing) != 0 {
+		return xerror.ClientError{Err: fmt.Errorf("missing exchange rates of %s for payroll period %s", strings.Join(missing, ", "), period.ID)}
 	}
-	response.PayrollID = period.ID
-	response.TotalTakeHomePay = period.TotalSalaryPaid
 
-	return response, nil
+	return nil
 }
 
-func (logic *PayrollLogic) GetUserPayslipByID(ctx context.Context, userID string) (models.Payslip, error) {
-	// get active payroll period
-	period, err := logic.payrollRepo.GetActivePayrollPeriod(ctx)
+// getPayGroup gets the pay group by id, or the default pay group when the id is empty
+func (logic *PayrollLogic) getPayGroup(ctx context.Context, payGroupID string) (PayGroup, error) {
+	var payGroup PayGroup
+	var err error
+	if payGroupID == "" {
+		payGroup, err = logic.payrollRepo.GetPayGroupByCode(ctx, DefaultPayGroupCode)
+	} else {
+		payGroup, err = logic.payrollRepo.GetPayGroupByID(ctx, payGroupID)
+	}
 	if err != nil {
-		logic.deps.Logger.ErrorContext(ctx, "failed to get active payroll period", slog.Any("error", err))
-		return models.Payslip{}, err
+		logic.deps.Logger.ErrorContext(ctx, "failed to get pay group", slog.Any("error", err))
+		return PayGroup{}, err
 	}
 
-	result, err := logic.payrollRepo.GetUserPayslipByID(ctx, userID, period.ID)
-	if err != nil {
-		logic.deps.Logger.ErrorContext(ctx, "failed to get user payslip in active payroll period", slog.Any("error", err))
-		return models.Payslip{}, err
+	return payGroup, nil
+}
+
+const (
+	uncategorizedReimbursement = "uncategorized"
+
+	// keeps a payslip batch insert below postgres bind parameters limit
+	maxPayslipBatchSize = 2000
+)
+
+func toPayrollReimbursement(data models.Reimbursement) Reimbursement {
+	return Reimbursement{
+		ID:               data.ID,
+		Amount:           data.Amount,
+		Desc:             data.Description,
+		Category:         data.Category,
+		CategoryName:     data.CategoryName,
+		Taxable:          data.Taxable,
+		Receipts:         data.Receipts,
+		OriginalAmount:   data.Amount,
+		OriginalCurrency: data.Currency,
+	}
+}
+
+// auditPayrollPeriod is the audit log view of a new period, the end date is exclusive like everywhere else
+func auditPayrollPeriod(period PayrollPeriod) map[string]any {
+	return map[string]any{
+		"pay_group_id":    period.PayGroupID,
+		"start_date":      xdate.Of(period.StartDate),
+		"end_date":        xdate.Of(period.EndDate),
+		"total_work_days": period.TotalWorkDays,
+		"scheduled":       period.Scheduled,
+	}
+}
+
+// payslipHashVersion prefixes the hashed payslip fields, bump it whenever the hashed fields change.
+// v2 added the reimbursement descriptions, category names and receipts, v1 chains are still verified.
+const (
+	payslipHashVersion   = "v2"
+	payslipHashVersionV1 = "v1"
+)
+
+// hashPayslip hashes the payslip fields in a fixed order chained to the previous payslip hash of the period,
+// the user name is left out as it isn't part of the payslip record
+func hashPayslip(payslip models.Payslip, prevHash string) string {
+	return hashPayslipVersion(payslipHashVersion, payslip, prevHash)
+}
+
+// hashPayslipVersion hashes the fields of the hash version, so the payslips sealed before a bump still verify
+func hashPayslipVersion(version string, payslip models.Payslip, prevHash string) string {
+	var b strings.Builder
+	b.WriteString(version)
+	text := func(value string) {
+		b.WriteString("|")
+		b.WriteString(strconv.Quote(value))
+	}
+	number := func(value float64) {
+		b.WriteString("|")
+		b.WriteString(strconv.FormatFloat(value, 'f', -1, 64))
+	}
+	integer := func(value int) {
+		b.WriteString("|")
+		b.WriteString(strconv.Itoa(value))
+	}
+	boolean := func(value bool) {
+		b.WriteString("|")
+		b.WriteString(strconv.FormatBool(value))
+	}
+
+	text(prevHash)
+	text(payslip.ID)
+	text(payslip.PayrollID)
+	text(payslip.UserID)
+	text(payslip.Type)
+	text(payslip.SalaryCurrency)
+	number(payslip.OriginalSalary)
+	number(payslip.SalaryExchangeRate)
+	text(payslip.PayoutCurrency)
+	number(payslip.BaseSalary)
+	integer(payslip.TotalAttendance)
+	integer(payslip.TotalWorkDay)
+	integer(payslip.EmployedWorkDays)
+	integer(payslip.PaidLeaveDays)
+	integer(payslip.UnpaidLeaveDays)
+	integer(payslip.UnusedLeaveDays)
+	number(payslip.LeavePayout)
+	integer(payslip.TotalOvertimeHour)
+	number(payslip.OvertimePay)
+
+	// lists are prefixed with their length so the fields of one item can't shift into the next
+	integer(len(payslip.ReimbursementList))
+	for _, reimbursement := range payslip.ReimbursementList {
+		text(reimbursement.ID)
+		number(reimbursement.Amount)
+		text(reimbursement.Currency)
+		number(reimbursement.OriginalAmount)
+		text(reimbursement.OriginalCurrency)
+		text(reimbursement.Category)
+		boolean(reimbursement.Taxable)
+		if version == payslipHashVersionV1 {
+			continue
+		}
+
+		text(reimbursement.Description)
+		text(reimbursement.CategoryName)
+		// the storage key isn't stored on the payslip, the receipt id leads to it
+		integer(len(reimbursement.Receipts))
+		for _, receipt := range reimbursement.Receipts {
+			text(receipt.ID)
+			text(receipt.ReimbursementID)
+			text(receipt.FileName)
+			text(receipt.ContentType)
+			b.WriteString("|")
+			b.WriteString(strconv.FormatInt(receipt.Size, 10))
+		}
+	}
+	number(payslip.TotalReimbursement)
+	integer(len(payslip.ReimbursementCategories))
+	for _, category := range payslip.ReimbursementCategories {
+		text(category.Category)
+		boolean(category.Taxable)
+		integer(category.Count)
+		number(category.Amount)
+	}
+	number(payslip.TotalTaxableReimbursement)
+
+	integer(len(payslip.DeductionList))
+	for _, deduction := range payslip.DeductionList {
+		text(deduction.ID)
+		number(deduction.Amount)
+		text(deduction.Description)
+	}
+	number(payslip.TotalDeduction)
+	number(payslip.TakeHomePay)
+
+	integer(len(payslip.ReviewReasons))
+	for _, reason := range payslip.ReviewReasons {
+		text(reason)
+	}
+
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// verifyPayslip returns why the stored payslip doesn't fit the chain, empty when it's valid
+func verifyPayslip(payslip models.Payslip, prevHash string, secret string) string {
+	if payslip.PrevHash != prevHash {
+		return "previous hash mismatch, a payslip before it was removed or added"
+	}
+
+	// the hash doesn't tell its version, a payslip sealed before the bump only matches the v1 fields
+	if hashPayslip(payslip, payslip.PrevHash) != payslip.Hash && hashPayslipVersion(payslipHashVersionV1, payslip, payslip.PrevHash) != payslip.Hash {
+		return "hash mismatch, the payslip was changed"
+	}
+
+	if !xcrypto.Verify([]byte(payslip.Hash), payslip.Signature, secret) {
+		return "invalid signature"
+	}
+
+	return ""
+}
+
+// payslipChainMessage binds the chain head to the period so it can't be moved to another period
+func payslipChainMessage(payrollID string, hash string) []byte {
+	return []byte(payrollID + ":" + hash)
+}
+
+// totalInBaseCurrency converts the totals per currency to the base currency and sums them up
+func totalInBaseCurrency(rates xcurrency.Rates, totals map[string]float64) (float64, error) {
+	currencies := make([]string, 0, len(totals))
+	for currency := range totals {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	var result float64
+	for _, currency := range currencies {
+		amount, err := rates.Convert(totals[currency], currency, rates.Base)
+		if err != nil {
+			return 0, err
+		}
+		result += amount
 	}
 
 	return result, nil
@@ -374,6 +2042,118 @@ func calculateWorkingDays(startTime time.Time, endTime time.Time) int {
 	return weeks*5 + days
 }
 
+// toValidPayGroup validates the pay group name and period template of the request
+func toValidPayGroup(req PayGroupRequest) (PayGroup, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return PayGroup{}, xerror.ClientError{Err: fmt.Errorf("pay group name is required")}
+	}
+
+	frequency := req.Frequency
+	if frequency == "" {
+		frequency = PayFrequencyMonthly
+	}
+
+	anchorDay := req.PeriodAnchorDay
+	if anchorDay == 0 {
+		anchorDay = 1
+	}
+
+	var maxAnchorDay int
+	switch frequency {
+	case PayFrequencyMonthly:
+		maxAnchorDay = 28
+	case PayFrequencySemimonthly:
+		maxAnchorDay = 13
+	case PayFrequencyBiweekly, PayFrequencyWeekly:
+		maxAnchorDay = 7
+	default:
+		return PayGroup{}, xerror.ClientError{Err: fmt.Errorf("invalid pay frequency %q, must be one of %s, %s, %s or %s", frequency, PayFrequencyMonthly, PayFrequencySemimonthly, PayFrequencyBiweekly, PayFrequencyWeekly)}
+	}
+
+	if anchorDay < 1 || anchorDay > maxAnchorDay {
+		return PayGroup{}, xerror.ClientError{Err: fmt.Errorf("period anchor day of %s pay frequency must be between 1 and %d", frequency, maxAnchorDay)}
+	}
+
+	return PayGroup{
+		Name:            name,
+		Frequency:       frequency,
+		PeriodAnchorDay: anchorDay,
+		AutoSchedule:    req.AutoSchedule,
+	}, nil
+}
+
+// periodBounds returns the period of the pay group template containing the given date, the end date is exclusive
+// as it's also when the next period starts, e.g. monthly on the 26th gives 26 May to 26 June for 10 June
+func periodBounds(payGroup PayGroup, date time.Time) (time.Time, time.Time) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	anchor := max(payGroup.PeriodAnchorDay, 1)
+
+	switch payGroup.Frequency {
+	case PayFrequencyWeekly, PayFrequencyBiweekly:
+		// days since the last anchor weekday
+		offset := (weekday(day) + 1 - anchor + 7) % 7
+		start := day.AddDate(0, 0, -offset)
+		if payGroup.Frequency == PayFrequencyWeekly {
+			return start, start.AddDate(0, 0, 7)
+		}
+		return start, start.AddDate(0, 0, 14)
+	case PayFrequencySemimonthly:
+		first := time.Date(day.Year(), day.Month(), anchor, 0, 0, 0, 0, day.Location())
+		second := first.AddDate(0, 0, 15)
+		switch {
+		case !day.Before(second):
+			return second, first.AddDate(0, 1, 0)
+		case !day.Before(first):
+			return first, second
+		default:
+			return first.AddDate(0, -1, 15), first
+		}
+	default:
+		start := time.Date(day.Year(), day.Month(), anchor, 0, 0, 0, 0, day.Location())
+		if day.Before(start) {
+			start = start.AddDate(0, -1, 0)
+		}
+		return start, start.AddDate(0, 1, 0)
+	}
+}
+
+// employedWorkDays counts the period work days within the user's employment, the termination date is the last employed day
+func employedWorkDays(period PayrollPeriod, employment models.Employment) int {
+	start, end := period.StartDate, period.EndDate
+	prorated := false
+	if employment.StartDate != nil && employment.StartDate.After(start) {
+		start = *employment.StartDate
+		prorated = true
+	}
+	if employment.EndDate != nil {
+		lastDay := employment.EndDate.AddDate(0, 0, 1)
+		if lastDay.Before(end) {
+			end = lastDay
+			prorated = true
+		}
+	}
+
+	if !prorated {
+		return period.TotalWorkDays
+	}
+
+	if !end.After(start) {
+		return 0
+	}
+
+	return min(calculateWorkingDays(start, end), period.TotalWorkDays)
+}
+
+// terminatedInPeriod reports whether the employment ends within the period, making it the final payslip
+func terminatedInPeriod(period PayrollPeriod, employment models.Employment) bool {
+	if employment.EndDate == nil {
+		return false
+	}
+
+	return !employment.EndDate.Before(period.StartDate) && employment.EndDate.Before(period.EndDate)
+}
+
 func weekday(d time.Time) int {
 	wd := d.Weekday()
 	if wd == time.Sunday {
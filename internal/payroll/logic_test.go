@@ -2,13 +2,30 @@ package payroll
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"reflect"
+	"runtime"
+	"runtime/metrics"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/rahadianir/dealls/internal/attendance"
+	"github.com/rahadianir/dealls/internal/company"
 	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/models"
+	"github.com/rahadianir/dealls/internal/pkg/xaudit"
 	"github.com/rahadianir/dealls/internal/pkg/xcontext"
+	"github.com/rahadianir/dealls/internal/pkg/xcrypto"
+	"github.com/rahadianir/dealls/internal/pkg/xdate"
+	"github.com/rahadianir/dealls/internal/pkg/xerror"
+	"github.com/rahadianir/dealls/internal/pkg/xmail"
+	"github.com/rahadianir/dealls/internal/pkg/xnotify"
 	"github.com/rahadianir/dealls/internal/user"
 	"go.uber.org/mock/gomock"
 )
@@ -18,19 +35,20 @@ func TestPayrollLogic_SetPayrollPeriod(t *testing.T) {
 	defer ctrl.Finish()
 	mockDeps := config.CommonDependencies{
 		Config: config.InitConfig(context.Background()),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
 	}
 
 	mockPayrollRepo := NewMockPayrollRepositoryInterface(ctrl)
+	mockAuditor := xaudit.NewMockRecorder(ctrl)
+	// the change and its entry are run in the transaction the recorder begins
+	mockAuditor.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, txfunc func(context.Context) error) error {
+		return txfunc(ctx)
+	}).AnyTimes()
+	mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 	mockUserRepo := user.NewMockUserRepositoryInterface(ctrl)
 	mockAttRepo := attendance.NewMockAttendanceRepositoryInterface(ctrl)
-	startTime, err := time.Parse(time.RFC3339, "2025-05-25T06:29:44+07:00")
-	if err != nil {
-		t.Fatal(err)
-	}
-	endTime, err := time.Parse(time.RFC3339, "2025-06-25T06:29:44+07:00")
-	if err != nil {
-		t.Fatal(err)
-	}
+	startDate := xdate.New(2025, time.May, 25)
+	endDate := xdate.New(2025, time.June, 25)
 
 	type fields struct {
 		deps        *config.CommonDependencies
@@ -39,9 +57,8 @@ func TestPayrollLogic_SetPayrollPeriod(t *testing.T) {
 		attRepo     attendance.AttendanceRepositoryInterface
 	}
 	type args struct {
-		ctx   context.Context
-		start time.Time
-		end   time.Time
+		ctx context.Context
+		req PayrollPeriodRequest
 	}
 	tests := []struct {
 		name      string
@@ -60,16 +77,146 @@ func TestPayrollLogic_SetPayrollPeriod(t *testing.T) {
 				attRepo:     mockAttRepo,
 			},
 			args: args{
-				ctx:   context.WithValue(context.Background(), xcontext.UserIDKey, "user-id"),
-				start: startTime,
-				end:   endTime,
+				ctx: context.WithValue(context.Background(), xcontext.UserIDKey, "user-id"),
+				req: PayrollPeriodRequest{StartDate: startDate, EndDate: endDate},
+			},
+			wantErr: false,
+			behaviour: func(f fields, a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "user-id").Return(true, nil)
+				mockPayrollRepo.EXPECT().GetPayGroupByCode(gomock.Any(), DefaultPayGroupCode).Return(PayGroup{ID: "default-group-id", Frequency: PayFrequencyMonthly}, nil)
+				mockPayrollRepo.EXPECT().GetOverlappingPayrollPeriods(gomock.Any(), "default-group-id", startDate.Time(), endDate.Time()).Return(nil, nil)
+				mockPayrollRepo.EXPECT().GetPreviousPayrollPeriod(gomock.Any(), "default-group-id", startDate.Time()).Return(PayrollPeriod{ID: "previous-id", EndDate: startDate.Time()}, nil)
+				mockPayrollRepo.EXPECT().SetPayrollPeriod(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data PayrollPeriod) error {
+					if data.PayGroupID != "default-group-id" || !data.StartDate.Equal(startDate.Time()) || !data.EndDate.Equal(endDate.Time()) {
+						t.Errorf("unexpected payroll period: %+v", data)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name: "success set payroll period following pay group frequency",
+			fields: fields{
+				deps:        &mockDeps,
+				payrollRepo: mockPayrollRepo,
+				userRepo:    mockUserRepo,
+				attRepo:     mockAttRepo,
+			},
+			args: args{
+				ctx: context.WithValue(context.Background(), xcontext.UserIDKey, "user-id"),
+				req: PayrollPeriodRequest{PayGroupID: "group-id", StartDate: startDate},
 			},
 			wantErr: false,
 			behaviour: func(f fields, a args) {
 				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "user-id").Return(true, nil)
+				mockPayrollRepo.EXPECT().GetPayGroupByID(gomock.Any(), "group-id").Return(PayGroup{ID: "group-id", Frequency: PayFrequencyBiweekly, PeriodAnchorDay: 7}, nil)
+				mockPayrollRepo.EXPECT().GetOverlappingPayrollPeriods(gomock.Any(), "group-id", gomock.Any(), gomock.Any()).Return(nil, nil)
+				mockPayrollRepo.EXPECT().GetPreviousPayrollPeriod(gomock.Any(), "group-id", gomock.Any()).Return(PayrollPeriod{}, xerror.ErrDataNotFound)
+				mockPayrollRepo.EXPECT().SetPayrollPeriod(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data PayrollPeriod) error {
+					if data.PayGroupID != "group-id" || !data.EndDate.Equal(startDate.AddDays(14).Time()) || data.TotalWorkDays != 10 {
+						t.Errorf("unexpected payroll period: %+v", data)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name: "success set payroll period with an allowed gap",
+			fields: fields{
+				deps:        &mockDeps,
+				payrollRepo: mockPayrollRepo,
+				userRepo:    mockUserRepo,
+				attRepo:     mockAttRepo,
+			},
+			args: args{
+				ctx: context.WithValue(context.Background(), xcontext.UserIDKey, "user-id"),
+				req: PayrollPeriodRequest{StartDate: startDate, EndDate: endDate, AllowGap: true},
+			},
+			wantErr: false,
+			behaviour: func(f fields, a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "user-id").Return(true, nil)
+				mockPayrollRepo.EXPECT().GetPayGroupByCode(gomock.Any(), DefaultPayGroupCode).Return(PayGroup{ID: "default-group-id", Frequency: PayFrequencyMonthly}, nil)
+				mockPayrollRepo.EXPECT().GetOverlappingPayrollPeriods(gomock.Any(), "default-group-id", startDate.Time(), endDate.Time()).Return(nil, nil)
 				mockPayrollRepo.EXPECT().SetPayrollPeriod(gomock.Any(), gomock.Any()).Return(nil)
 			},
 		},
+		{
+			name: "failed set payroll period ending before it starts",
+			fields: fields{
+				deps:        &mockDeps,
+				payrollRepo: mockPayrollRepo,
+				userRepo:    mockUserRepo,
+				attRepo:     mockAttRepo,
+			},
+			args: args{
+				ctx: context.WithValue(context.Background(), xcontext.UserIDKey, "user-id"),
+				req: PayrollPeriodRequest{StartDate: endDate, EndDate: startDate},
+			},
+			wantErr: true,
+			behaviour: func(f fields, a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "user-id").Return(true, nil)
+				mockPayrollRepo.EXPECT().GetPayGroupByCode(gomock.Any(), DefaultPayGroupCode).Return(PayGroup{ID: "default-group-id", Frequency: PayFrequencyMonthly}, nil)
+			},
+		},
+		{
+			name: "failed set payroll period overlapping an existing period",
+			fields: fields{
+				deps:        &mockDeps,
+				payrollRepo: mockPayrollRepo,
+				userRepo:    mockUserRepo,
+				attRepo:     mockAttRepo,
+			},
+			args: args{
+				ctx: context.WithValue(context.Background(), xcontext.UserIDKey, "user-id"),
+				req: PayrollPeriodRequest{StartDate: startDate, EndDate: endDate},
+			},
+			wantErr: true,
+			behaviour: func(f fields, a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "user-id").Return(true, nil)
+				mockPayrollRepo.EXPECT().GetPayGroupByCode(gomock.Any(), DefaultPayGroupCode).Return(PayGroup{ID: "default-group-id", Frequency: PayFrequencyMonthly}, nil)
+				mockPayrollRepo.EXPECT().GetOverlappingPayrollPeriods(gomock.Any(), "default-group-id", startDate.Time(), endDate.Time()).Return([]PayrollPeriod{
+					{ID: "existing-id", StartDate: startDate.AddDays(-10).Time(), EndDate: startDate.AddDays(1).Time()},
+				}, nil)
+			},
+		},
+		{
+			name: "failed set payroll period leaving a gap after the previous period",
+			fields: fields{
+				deps:        &mockDeps,
+				payrollRepo: mockPayrollRepo,
+				userRepo:    mockUserRepo,
+				attRepo:     mockAttRepo,
+			},
+			args: args{
+				ctx: context.WithValue(context.Background(), xcontext.UserIDKey, "user-id"),
+				req: PayrollPeriodRequest{StartDate: startDate, EndDate: endDate},
+			},
+			wantErr: true,
+			behaviour: func(f fields, a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "user-id").Return(true, nil)
+				mockPayrollRepo.EXPECT().GetPayGroupByCode(gomock.Any(), DefaultPayGroupCode).Return(PayGroup{ID: "default-group-id", Frequency: PayFrequencyMonthly}, nil)
+				mockPayrollRepo.EXPECT().GetOverlappingPayrollPeriods(gomock.Any(), "default-group-id", startDate.Time(), endDate.Time()).Return(nil, nil)
+				mockPayrollRepo.EXPECT().GetPreviousPayrollPeriod(gomock.Any(), "default-group-id", startDate.Time()).Return(PayrollPeriod{ID: "previous-id", EndDate: startDate.AddDays(-3).Time()}, nil)
+			},
+		},
+		{
+			name: "failed set payroll period of unknown pay group",
+			fields: fields{
+				deps:        &mockDeps,
+				payrollRepo: mockPayrollRepo,
+				userRepo:    mockUserRepo,
+				attRepo:     mockAttRepo,
+			},
+			args: args{
+				ctx: context.WithValue(context.Background(), xcontext.UserIDKey, "user-id"),
+				req: PayrollPeriodRequest{PayGroupID: "unknown-group-id", StartDate: startDate, EndDate: endDate},
+			},
+			wantErr: true,
+			behaviour: func(f fields, a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "user-id").Return(true, nil)
+				mockPayrollRepo.EXPECT().GetPayGroupByID(gomock.Any(), "unknown-group-id").Return(PayGroup{}, xerror.ErrDataNotFound)
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -78,9 +225,10 @@ func TestPayrollLogic_SetPayrollPeriod(t *testing.T) {
 				payrollRepo: tt.fields.payrollRepo,
 				userRepo:    tt.fields.userRepo,
 				attRepo:     tt.fields.attRepo,
+				auditor:     mockAuditor,
 			}
 			tt.behaviour(tt.fields, tt.args)
-			if err := logic.SetPayrollPeriod(tt.args.ctx, tt.args.start, tt.args.end); (err != nil) != tt.wantErr {
+			if err := logic.SetPayrollPeriod(tt.args.ctx, tt.args.req); (err != nil) != tt.wantErr {
 				t.Errorf("PayrollLogic.SetPayrollPeriod() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -108,11 +256,12 @@ func TestPayrollLogic_CalculatePay(t *testing.T) {
 		data PayrollCalculationData
 	}
 	tests := []struct {
-		name      string
-		fields    fields
-		args      args
-		want      float64 // take home pay amount
-		behaviour func(f fields, a args)
+		name       string
+		fields     fields
+		args       args
+		want       float64 // take home pay amount
+		wantReview bool
+		behaviour  func(f fields, a args)
 	}{
 		// TODO: Add test cases.
 		{
@@ -146,6 +295,111 @@ func TestPayrollLogic_CalculatePay(t *testing.T) {
 			want:      11025000,
 			behaviour: func(f fields, a args) {},
 		},
+		{
+			name: "success calculate take home pay with paid leave",
+			fields: fields{
+				deps:        &mockDeps,
+				payrollRepo: mockPayrollRepo,
+				userRepo:    mockUserRepo,
+				attRepo:     mockAttRepo,
+			},
+			args: args{
+				ctx: context.Background(),
+				data: PayrollCalculationData{
+					TotalWorkDay:    20,
+					AttendanceCount: 15,
+					PaidLeaveDays:   3,
+					UnpaidLeaveDays: 2,
+					Salary:          10000000,
+				},
+			},
+			want:      9000000,
+			behaviour: func(f fields, a args) {},
+		},
+		{
+			name: "success calculate take home pay on full period of paid leave",
+			fields: fields{
+				deps:        &mockDeps,
+				payrollRepo: mockPayrollRepo,
+				userRepo:    mockUserRepo,
+				attRepo:     mockAttRepo,
+			},
+			args: args{
+				ctx: context.Background(),
+				data: PayrollCalculationData{
+					TotalWorkDay:  20,
+					PaidLeaveDays: 20,
+					Salary:        10000000,
+				},
+			},
+			want:      10000000,
+			behaviour: func(f fields, a args) {},
+		},
+		{
+			name: "success calculate zero payslip flagged for missing attendance",
+			fields: fields{
+				deps:        &mockDeps,
+				payrollRepo: mockPayrollRepo,
+				userRepo:    mockUserRepo,
+				attRepo:     mockAttRepo,
+			},
+			args: args{
+				ctx: context.Background(),
+				data: PayrollCalculationData{
+					TotalWorkDay: 20,
+					Salary:       10000000,
+				},
+			},
+			want:       0,
+			wantReview: true,
+			behaviour:  func(f fields, a args) {},
+		},
+		{
+			name: "success calculate take home pay of user hired mid period",
+			fields: fields{
+				deps:        &mockDeps,
+				payrollRepo: mockPayrollRepo,
+				userRepo:    mockUserRepo,
+				attRepo:     mockAttRepo,
+			},
+			args: args{
+				ctx: context.Background(),
+				data: PayrollCalculationData{
+					TotalWorkDay:        20,
+					NotEmployedWorkDays: 5,
+					AttendanceCount:     15,
+					Salary:              10000000,
+				},
+			},
+			want:      7500000,
+			behaviour: func(f fields, a args) {},
+		},
+		{
+			name: "success calculate final settlement flagged when deductions exceed the pay",
+			fields: fields{
+				deps:        &mockDeps,
+				payrollRepo: mockPayrollRepo,
+				userRepo:    mockUserRepo,
+				attRepo:     mockAttRepo,
+			},
+			args: args{
+				ctx: context.Background(),
+				data: PayrollCalculationData{
+					TotalWorkDay:        20,
+					NotEmployedWorkDays: 18,
+					AttendanceCount:     2,
+					Salary:              10000000,
+					FinalSettlement:     true,
+					UnusedLeaveDays:     2,
+					Deductions: []models.Deduction{
+						{ID: "deduction-1", Amount: 3000000, Description: "laptop"},
+					},
+				},
+			},
+			want:       0,
+			wantReview: true,
+			behaviour:  func(f fields, a args) {},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -155,9 +409,1852 @@ func TestPayrollLogic_CalculatePay(t *testing.T) {
 				userRepo:    tt.fields.userRepo,
 				attRepo:     tt.fields.attRepo,
 			}
-			if got := logic.CalculatePay(tt.args.ctx, tt.args.data); !reflect.DeepEqual(got.TakeHomePay, tt.want) {
+			got := logic.CalculatePay(tt.args.ctx, tt.args.data)
+			if !reflect.DeepEqual(got.TakeHomePay, tt.want) {
 				t.Errorf("PayrollLogic.CalculatePay() = %v, want %v", got.TakeHomePay, tt.want)
 			}
+			if (len(got.ReviewReasons) != 0) != tt.wantReview {
+				t.Errorf("PayrollLogic.CalculatePay() review reasons = %v, wantReview %v", got.ReviewReasons, tt.wantReview)
+			}
+		})
+	}
+}
+
+func TestPayrollLogic_CalculatePayroll(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockDeps := config.CommonDependencies{
+		Config: config.InitConfig(context.Background()),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	mockPayrollRepo := NewMockPayrollRepositoryInterface(ctrl)
+	mockAuditor := xaudit.NewMockRecorder(ctrl)
+	// the change and its entry are run in the transaction the recorder begins
+	mockAuditor.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, txfunc func(context.Context) error) error {
+		return txfunc(ctx)
+	}).AnyTimes()
+	mockMails := xmail.NewMockQueue(ctrl)
+	mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockUserRepo := user.NewMockUserRepositoryInterface(ctrl)
+	mockAttRepo := attendance.NewMockAttendanceRepositoryInterface(ctrl)
+
+	type fields struct {
+		deps        *config.CommonDependencies
+		payrollRepo PayrollRepositoryInterface
+		userRepo    user.UserRepositoryInterface
+		attRepo     attendance.AttendanceRepositoryInterface
+	}
+	type args struct {
+		ctx        context.Context
+		payGroupID string
+	}
+	tests := []struct {
+		name      string
+		fields    fields
+		args      args
+		wantJobID string
+		wantErr   bool
+		behaviour func(f fields, a args)
+	}{
+		// TODO: Add test cases.
+		{
+			name: "success queue payroll job",
+			fields: fields{
+				deps:        &mockDeps,
+				payrollRepo: mockPayrollRepo,
+				userRepo:    mockUserRepo,
+				attRepo:     mockAttRepo,
+			},
+			args: args{
+				ctx:        context.WithValue(context.Background(), xcontext.UserIDKey, "user-id"),
+				payGroupID: "group-id",
+			},
+			wantErr: false,
+			behaviour: func(f fields, a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "user-id").Return(true, nil)
+				mockPayrollRepo.EXPECT().GetPayGroupByID(gomock.Any(), "group-id").Return(PayGroup{ID: "group-id"}, nil)
+				mockPayrollRepo.EXPECT().GetActivePayrollPeriod(gomock.Any(), "group-id").Return(PayrollPeriod{ID: "payroll-id"}, nil)
+				mockPayrollRepo.EXPECT().GetUnfinishedPayrollJob(gomock.Any(), "payroll-id").Return(PayrollJob{}, xerror.ErrDataNotFound)
+				mockPayrollRepo.EXPECT().GetExchangeRates(gomock.Any(), "payroll-id").Return(map[string]float64{"USD": 16000}, nil)
+				mockPayrollRepo.EXPECT().GetPayrollPeriodCurrencies(gomock.Any(), gomock.Any(), []string{"active"}).Return([]string{"IDR", "USD"}, nil)
+				mockPayrollRepo.EXPECT().CreatePayrollJob(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, job PayrollJob) error {
+					if job.PayrollID != "payroll-id" || job.Status != PayrollJobPending || job.CreatedBy != "user-id" {
+						t.Errorf("unexpected payroll job: %+v", job)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name: "return unfinished payroll job",
+			fields: fields{
+				deps:        &mockDeps,
+				payrollRepo: mockPayrollRepo,
+				userRepo:    mockUserRepo,
+				attRepo:     mockAttRepo,
+			},
+			args: args{
+				ctx:        context.WithValue(context.Background(), xcontext.UserIDKey, "user-id"),
+				payGroupID: "group-id",
+			},
+			wantJobID: "job-id",
+			wantErr:   false,
+			behaviour: func(f fields, a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "user-id").Return(true, nil)
+				mockPayrollRepo.EXPECT().GetPayGroupByID(gomock.Any(), "group-id").Return(PayGroup{ID: "group-id"}, nil)
+				mockPayrollRepo.EXPECT().GetActivePayrollPeriod(gomock.Any(), "group-id").Return(PayrollPeriod{ID: "payroll-id"}, nil)
+				mockPayrollRepo.EXPECT().GetUnfinishedPayrollJob(gomock.Any(), "payroll-id").Return(PayrollJob{ID: "job-id"}, nil)
+			},
+		},
+		{
+			name: "failed queue payroll without exchange rate",
+			fields: fields{
+				deps:        &mockDeps,
+				payrollRepo: mockPayrollRepo,
+				userRepo:    mockUserRepo,
+				attRepo:     mockAttRepo,
+			},
+			args: args{
+				ctx:        context.WithValue(context.Background(), xcontext.UserIDKey, "user-id"),
+				payGroupID: "group-id",
+			},
+			wantErr: true,
+			behaviour: func(f fields, a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "user-id").Return(true, nil)
+				mockPayrollRepo.EXPECT().GetPayGroupByID(gomock.Any(), "group-id").Return(PayGroup{ID: "group-id"}, nil)
+				mockPayrollRepo.EXPECT().GetActivePayrollPeriod(gomock.Any(), "group-id").Return(PayrollPeriod{ID: "payroll-id"}, nil)
+				mockPayrollRepo.EXPECT().GetUnfinishedPayrollJob(gomock.Any(), "payroll-id").Return(PayrollJob{}, xerror.ErrDataNotFound)
+				mockPayrollRepo.EXPECT().GetExchangeRates(gomock.Any(), "payroll-id").Return(map[string]float64{"USD": 16000}, nil)
+				mockPayrollRepo.EXPECT().GetPayrollPeriodCurrencies(gomock.Any(), gomock.Any(), []string{"active"}).Return([]string{"IDR", "SGD", "USD"}, nil)
+			},
+		},
+		{
+			name: "failed queue processed payroll",
+			fields: fields{
+				deps:        &mockDeps,
+				payrollRepo: mockPayrollRepo,
+				userRepo:    mockUserRepo,
+				attRepo:     mockAttRepo,
+			},
+			args: args{
+				ctx:        context.WithValue(context.Background(), xcontext.UserIDKey, "user-id"),
+				payGroupID: "group-id",
+			},
+			wantErr: true,
+			behaviour: func(f fields, a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "user-id").Return(true, nil)
+				mockPayrollRepo.EXPECT().GetPayGroupByID(gomock.Any(), "group-id").Return(PayGroup{ID: "group-id"}, nil)
+				mockPayrollRepo.EXPECT().GetActivePayrollPeriod(gomock.Any(), "group-id").Return(PayrollPeriod{ID: "payroll-id", Processed: true}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := NewPayrollLogic(tt.fields.deps, tt.fields.payrollRepo, tt.fields.userRepo, tt.fields.attRepo, mockAuditor, mockMails)
+			tt.behaviour(tt.fields, tt.args)
+			got, err := logic.CalculatePayroll(tt.args.ctx, tt.args.payGroupID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PayrollLogic.CalculatePayroll() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantJobID != "" && got.ID != tt.wantJobID {
+				t.Errorf("PayrollLogic.CalculatePayroll() job = %v, want %v", got.ID, tt.wantJobID)
+			}
 		})
 	}
 }
+
+func TestPayrollLogic_ProcessPayrollJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockDeps := config.CommonDependencies{
+		Config: config.InitConfig(context.Background()),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	mockPayrollRepo := NewMockPayrollRepositoryInterface(ctrl)
+	mockAuditor := xaudit.NewMockRecorder(ctrl)
+	// the change and its entry are run in the transaction the recorder begins
+	mockAuditor.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, txfunc func(context.Context) error) error {
+		return txfunc(ctx)
+	}).AnyTimes()
+	mockMails := xmail.NewMockQueue(ctrl)
+	mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockUserRepo := user.NewMockUserRepositoryInterface(ctrl)
+	mockAttRepo := attendance.NewMockAttendanceRepositoryInterface(ctrl)
+
+	type fields struct {
+		deps        *config.CommonDependencies
+		payrollRepo PayrollRepositoryInterface
+		userRepo    user.UserRepositoryInterface
+		attRepo     attendance.AttendanceRepositoryInterface
+	}
+	type args struct {
+		ctx context.Context
+		job PayrollJob
+	}
+	tests := []struct {
+		name      string
+		fields    fields
+		args      args
+		wantErr   bool
+		behaviour func(f fields, a args)
+	}{
+		// TODO: Add test cases.
+		{
+			name: "success resume payroll job",
+			fields: fields{
+				deps:        &mockDeps,
+				payrollRepo: mockPayrollRepo,
+				userRepo:    mockUserRepo,
+				attRepo:     mockAttRepo,
+			},
+			args: args{
+				ctx: context.Background(),
+				job: PayrollJob{ID: "job-id", PayrollID: "payroll-id"},
+			},
+			wantErr: false,
+			behaviour: func(f fields, a args) {
+				mockPayrollRepo.EXPECT().GetPayrollPeriodByID(gomock.Any(), "payroll-id").Return(PayrollPeriod{ID: "payroll-id", PayGroupID: "group-id", TotalWorkDays: 20}, nil)
+				mockUserRepo.EXPECT().CountEligibleUsers(gomock.Any(), "group-id", []string{"active"}, gomock.Any(), gomock.Any()).Return(2, nil)
+				// user-1 payslip is stored by the interrupted attempt
+				mockPayrollRepo.EXPECT().CountPayslips(gomock.Any(), "payroll-id").Return(1, nil)
+				mockPayrollRepo.EXPECT().GetExchangeRates(gomock.Any(), "payroll-id").Return(nil, nil)
+				mockUserRepo.EXPECT().GetEligibleUserIDs(gomock.Any(), "group-id", []string{"active"}, gomock.Any(), gomock.Any(), "", gomock.Any()).Return([]string{"user-1", "user-2"}, nil)
+				mockPayrollRepo.EXPECT().GetPayslipUserIDs(gomock.Any(), "payroll-id", []string{"user-1", "user-2"}).Return([]string{"user-1"}, nil)
+				mockAttRepo.EXPECT().GetUsersAttendancesByPeriod(gomock.Any(), []string{"user-2"}, gomock.Any(), gomock.Any()).Return([]models.Attendance{
+					{UserID: "user-2", Count: 10},
+				}, nil)
+				mockAttRepo.EXPECT().GetUsersOvertimesByPeriod(gomock.Any(), []string{"user-2"}, gomock.Any(), gomock.Any()).Return(nil, nil)
+				mockAttRepo.EXPECT().GetUsersReimbursementsByPeriod(gomock.Any(), []string{"user-2"}, gomock.Any(), gomock.Any()).Return(nil, nil)
+				mockAttRepo.EXPECT().GetUsersLeaveDaysByPeriod(gomock.Any(), []string{"user-2"}, gomock.Any(), gomock.Any()).Return(nil, nil)
+				mockUserRepo.EXPECT().GetUsersSalaryByIDs(gomock.Any(), []string{"user-2"}).Return([]models.UserSalary{
+					{UserID: "user-2", Salary: 2000},
+				}, nil)
+				mockUserRepo.EXPECT().GetUsersEmploymentByIDs(gomock.Any(), []string{"user-2"}).Return([]models.Employment{
+					{UserID: "user-2", Status: models.EmploymentActive},
+				}, nil)
+				mockPayrollRepo.EXPECT().UpdatePayrollJobProgress(gomock.Any(), "job-id", 2, gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+				mockPayrollRepo.EXPECT().StorePayslips(gomock.Any(), gomock.Len(1)).DoAndReturn(func(ctx context.Context, payslips []models.Payslip) error {
+					if payslips[0].UserID != "user-2" || payslips[0].TakeHomePay != 1000 {
+						t.Errorf("unexpected payslip: %+v", payslips[0])
+					}
+					return nil
+				})
+				// both payslips are chained in user_id order
+				mockPayrollRepo.EXPECT().GetPayslipsAfter(gomock.Any(), "payroll-id", "", gomock.Any()).Return([]models.Payslip{
+					{ID: "payslip-1", UserID: "user-1", PayrollID: "payroll-id", TakeHomePay: 1000},
+					{ID: "payslip-2", UserID: "user-2", PayrollID: "payroll-id", TakeHomePay: 1000},
+				}, nil)
+				var chainHash string
+				mockPayrollRepo.EXPECT().StorePayslipHashes(gomock.Any(), gomock.Len(2)).DoAndReturn(func(ctx context.Context, payslips []models.Payslip) error {
+					if payslips[0].PrevHash != "" || payslips[1].PrevHash != payslips[0].Hash || payslips[0].Hash == payslips[1].Hash || payslips[1].Signature == "" {
+						t.Errorf("unexpected payslip chain: %+v", payslips)
+					}
+					chainHash = payslips[1].Hash
+					return nil
+				})
+				mockPayrollRepo.EXPECT().GetPayrollTotalPaid(gomock.Any(), "payroll-id").Return(map[string]float64{"IDR": 2000}, nil)
+				mockPayrollRepo.EXPECT().MarkPayrollProcessed(gomock.Any(), "payroll-id", float64(2000), map[string]float64{"IDR": 2000}, gomock.Any()).DoAndReturn(func(ctx context.Context, id string, totalPaid float64, totalPaidByCurrency map[string]float64, chain PayslipChain) error {
+					if chain.Hash != chainHash || chain.Signature == "" {
+						t.Errorf("unexpected payslip chain head: %+v", chain)
+					}
+					return nil
+				})
+				mockMails.EXPECT().EnqueuePayslipEmails(gomock.Any(), "payroll-id").Return(nil)
+				mockPayrollRepo.EXPECT().FinishPayrollJob(gomock.Any(), "job-id", PayrollJobCompleted, gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name: "failed payroll job on payslip store error",
+			fields: fields{
+				deps:        &mockDeps,
+				payrollRepo: mockPayrollRepo,
+				userRepo:    mockUserRepo,
+				attRepo:     mockAttRepo,
+			},
+			args: args{
+				ctx: context.Background(),
+				job: PayrollJob{ID: "job-id", PayrollID: "payroll-id"},
+			},
+			wantErr: true,
+			behaviour: func(f fields, a args) {
+				mockPayrollRepo.EXPECT().GetPayrollPeriodByID(gomock.Any(), "payroll-id").Return(PayrollPeriod{ID: "payroll-id", PayGroupID: "group-id", TotalWorkDays: 20}, nil)
+				mockUserRepo.EXPECT().CountEligibleUsers(gomock.Any(), "group-id", []string{"active"}, gomock.Any(), gomock.Any()).Return(1, nil)
+				mockPayrollRepo.EXPECT().CountPayslips(gomock.Any(), "payroll-id").Return(0, nil)
+				mockPayrollRepo.EXPECT().GetExchangeRates(gomock.Any(), "payroll-id").Return(nil, nil)
+				mockUserRepo.EXPECT().GetEligibleUserIDs(gomock.Any(), "group-id", []string{"active"}, gomock.Any(), gomock.Any(), "", gomock.Any()).Return([]string{"user-1"}, nil)
+				mockPayrollRepo.EXPECT().GetPayslipUserIDs(gomock.Any(), "payroll-id", []string{"user-1"}).Return(nil, nil)
+				mockAttRepo.EXPECT().GetUsersAttendancesByPeriod(gomock.Any(), []string{"user-1"}, gomock.Any(), gomock.Any()).Return([]models.Attendance{
+					{UserID: "user-1", Count: 20},
+				}, nil)
+				mockAttRepo.EXPECT().GetUsersOvertimesByPeriod(gomock.Any(), []string{"user-1"}, gomock.Any(), gomock.Any()).Return(nil, nil)
+				mockAttRepo.EXPECT().GetUsersReimbursementsByPeriod(gomock.Any(), []string{"user-1"}, gomock.Any(), gomock.Any()).Return(nil, nil)
+				mockAttRepo.EXPECT().GetUsersLeaveDaysByPeriod(gomock.Any(), []string{"user-1"}, gomock.Any(), gomock.Any()).Return(nil, nil)
+				mockUserRepo.EXPECT().GetUsersSalaryByIDs(gomock.Any(), []string{"user-1"}).Return([]models.UserSalary{
+					{UserID: "user-1", Salary: 1000},
+				}, nil)
+				mockUserRepo.EXPECT().GetUsersEmploymentByIDs(gomock.Any(), []string{"user-1"}).Return(nil, nil)
+				mockPayrollRepo.EXPECT().UpdatePayrollJobProgress(gomock.Any(), "job-id", 1, gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+				mockPayrollRepo.EXPECT().StorePayslips(gomock.Any(), gomock.Any()).Return(fmt.Errorf("db error"))
+				mockPayrollRepo.EXPECT().FinishPayrollJob(gomock.Any(), "job-id", PayrollJobFailed, gomock.Len(1)).Return(nil)
+			},
+		},
+		{
+			name: "success final settlement of user terminated in the period",
+			fields: fields{
+				deps:        &mockDeps,
+				payrollRepo: mockPayrollRepo,
+				userRepo:    mockUserRepo,
+				attRepo:     mockAttRepo,
+			},
+			args: args{
+				ctx: context.Background(),
+				job: PayrollJob{ID: "job-id", PayrollID: "payroll-id"},
+			},
+			wantErr: false,
+			behaviour: func(f fields, a args) {
+				period := PayrollPeriod{ID: "payroll-id", PayGroupID: "group-id", StartDate: time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC), TotalWorkDays: 20}
+				terminationDate := time.Date(2025, 6, 13, 0, 0, 0, 0, time.UTC)
+				mockPayrollRepo.EXPECT().GetPayrollPeriodByID(gomock.Any(), "payroll-id").Return(period, nil)
+				mockUserRepo.EXPECT().CountEligibleUsers(gomock.Any(), "group-id", []string{"active"}, gomock.Any(), gomock.Any()).Return(1, nil)
+				mockPayrollRepo.EXPECT().CountPayslips(gomock.Any(), "payroll-id").Return(0, nil)
+				mockPayrollRepo.EXPECT().GetExchangeRates(gomock.Any(), "payroll-id").Return(nil, nil)
+				mockUserRepo.EXPECT().GetEligibleUserIDs(gomock.Any(), "group-id", []string{"active"}, gomock.Any(), gomock.Any(), "", gomock.Any()).Return([]string{"user-3"}, nil)
+				mockPayrollRepo.EXPECT().GetPayslipUserIDs(gomock.Any(), "payroll-id", []string{"user-3"}).Return(nil, nil)
+				mockAttRepo.EXPECT().GetUsersAttendancesByPeriod(gomock.Any(), []string{"user-3"}, gomock.Any(), gomock.Any()).Return([]models.Attendance{
+					{UserID: "user-3", Count: 10},
+				}, nil)
+				mockAttRepo.EXPECT().GetUsersOvertimesByPeriod(gomock.Any(), []string{"user-3"}, gomock.Any(), gomock.Any()).Return(nil, nil)
+				mockAttRepo.EXPECT().GetUsersReimbursementsByPeriod(gomock.Any(), []string{"user-3"}, gomock.Any(), gomock.Any()).Return(nil, nil)
+				mockAttRepo.EXPECT().GetUsersLeaveDaysByPeriod(gomock.Any(), []string{"user-3"}, period.StartDate, period.EndDate).Return(nil, nil)
+				mockUserRepo.EXPECT().GetUsersSalaryByIDs(gomock.Any(), []string{"user-3"}).Return([]models.UserSalary{
+					{UserID: "user-3", Salary: 2000000},
+				}, nil)
+				mockUserRepo.EXPECT().GetUsersEmploymentByIDs(gomock.Any(), []string{"user-3"}).Return([]models.Employment{
+					{UserID: "user-3", Status: models.EmploymentActive, EndDate: &terminationDate, AnnualLeaveDays: 12},
+				}, nil)
+				mockPayrollRepo.EXPECT().GetUsersOutstandingDeductions(gomock.Any(), []string{"user-3"}).Return([]models.Deduction{
+					{ID: "deduction-1", UserID: "user-3", Amount: 500000, Description: "salary advance"},
+				}, nil)
+				// 2 days of paid leave taken earlier in the year
+				mockAttRepo.EXPECT().GetUsersLeaveDaysByPeriod(gomock.Any(), []string{"user-3"}, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), terminationDate.AddDate(0, 0, 1)).Return([]models.LeaveDays{
+					{UserID: "user-3", PaidDays: 2},
+				}, nil)
+				mockPayrollRepo.EXPECT().UpdatePayrollJobProgress(gomock.Any(), "job-id", 1, gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+				mockPayrollRepo.EXPECT().StorePayslips(gomock.Any(), gomock.Len(1)).DoAndReturn(func(ctx context.Context, payslips []models.Payslip) error {
+					// 10 of 20 days worked + 10 unused leave days - 500.000 deduction
+					got := payslips[0]
+					if got.Type != models.PayslipFinalSettlement || got.EmployedWorkDays != 10 || got.UnusedLeaveDays != 10 || got.TakeHomePay != 1500000 || len(got.ReviewReasons) != 0 {
+						t.Errorf("unexpected payslip: %+v", got)
+					}
+					return nil
+				})
+				mockPayrollRepo.EXPECT().GetPayslipsAfter(gomock.Any(), "payroll-id", "", gomock.Any()).Return([]models.Payslip{
+					{ID: "payslip-3", UserID: "user-3", PayrollID: "payroll-id", TakeHomePay: 1500000},
+				}, nil)
+				mockPayrollRepo.EXPECT().StorePayslipHashes(gomock.Any(), gomock.Len(1)).Return(nil)
+				mockPayrollRepo.EXPECT().GetPayrollTotalPaid(gomock.Any(), "payroll-id").Return(map[string]float64{"IDR": 1500000}, nil)
+				mockPayrollRepo.EXPECT().MarkPayrollProcessed(gomock.Any(), "payroll-id", float64(1500000), map[string]float64{"IDR": 1500000}, gomock.Any()).Return(nil)
+				// the payroll is still completed when the payslip emails can't be queued
+				mockMails.EXPECT().EnqueuePayslipEmails(gomock.Any(), "payroll-id").Return(fmt.Errorf("db error"))
+				mockPayrollRepo.EXPECT().FinishPayrollJob(gomock.Any(), "job-id", PayrollJobCompleted, gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name: "success payslip converted to payout currency",
+			fields: fields{
+				deps:        &mockDeps,
+				payrollRepo: mockPayrollRepo,
+				userRepo:    mockUserRepo,
+				attRepo:     mockAttRepo,
+			},
+			args: args{
+				ctx: context.Background(),
+				job: PayrollJob{ID: "job-id", PayrollID: "payroll-id"},
+			},
+			wantErr: false,
+			behaviour: func(f fields, a args) {
+				mockPayrollRepo.EXPECT().GetPayrollPeriodByID(gomock.Any(), "payroll-id").Return(PayrollPeriod{ID: "payroll-id", PayGroupID: "group-id", TotalWorkDays: 20}, nil)
+				mockUserRepo.EXPECT().CountEligibleUsers(gomock.Any(), "group-id", []string{"active"}, gomock.Any(), gomock.Any()).Return(1, nil)
+				mockPayrollRepo.EXPECT().CountPayslips(gomock.Any(), "payroll-id").Return(0, nil)
+				mockPayrollRepo.EXPECT().GetExchangeRates(gomock.Any(), "payroll-id").Return(map[string]float64{"USD": 16000, "SGD": 12000}, nil)
+				mockUserRepo.EXPECT().GetEligibleUserIDs(gomock.Any(), "group-id", []string{"active"}, gomock.Any(), gomock.Any(), "", gomock.Any()).Return([]string{"user-4"}, nil)
+				mockPayrollRepo.EXPECT().GetPayslipUserIDs(gomock.Any(), "payroll-id", []string{"user-4"}).Return(nil, nil)
+				mockAttRepo.EXPECT().GetUsersAttendancesByPeriod(gomock.Any(), []string{"user-4"}, gomock.Any(), gomock.Any()).Return([]models.Attendance{
+					{UserID: "user-4", Count: 20},
+				}, nil)
+				mockAttRepo.EXPECT().GetUsersOvertimesByPeriod(gomock.Any(), []string{"user-4"}, gomock.Any(), gomock.Any()).Return(nil, nil)
+				mockAttRepo.EXPECT().GetUsersReimbursementsByPeriod(gomock.Any(), []string{"user-4"}, gomock.Any(), gomock.Any()).Return([]models.Reimbursement{
+					{ID: "reimbursement-1", UserID: "user-4", Amount: 10, Currency: "SGD"},
+				}, nil)
+				mockAttRepo.EXPECT().GetUsersLeaveDaysByPeriod(gomock.Any(), []string{"user-4"}, gomock.Any(), gomock.Any()).Return(nil, nil)
+				// salary in USD paid out in IDR
+				mockUserRepo.EXPECT().GetUsersSalaryByIDs(gomock.Any(), []string{"user-4"}).Return([]models.UserSalary{
+					{UserID: "user-4", Salary: 1000, Currency: "USD", PayoutCurrency: "IDR"},
+				}, nil)
+				mockUserRepo.EXPECT().GetUsersEmploymentByIDs(gomock.Any(), []string{"user-4"}).Return(nil, nil)
+				mockPayrollRepo.EXPECT().UpdatePayrollJobProgress(gomock.Any(), "job-id", 1, gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+				mockPayrollRepo.EXPECT().StorePayslips(gomock.Any(), gomock.Len(1)).DoAndReturn(func(ctx context.Context, payslips []models.Payslip) error {
+					got := payslips[0]
+					if got.SalaryCurrency != "USD" || got.OriginalSalary != 1000 || got.PayoutCurrency != "IDR" || got.SalaryExchangeRate != 16000 || got.BaseSalary != 16000000 {
+						t.Errorf("unexpected payslip salary: %+v", got)
+					}
+					r := got.ReimbursementList[0]
+					if r.Amount != 120000 || r.Currency != "IDR" || r.OriginalAmount != 10 || r.OriginalCurrency != "SGD" {
+						t.Errorf("unexpected payslip reimbursement: %+v", r)
+					}
+					if got.TakeHomePay != 16120000 {
+						t.Errorf("unexpected payslip take home pay: %v", got.TakeHomePay)
+					}
+					return nil
+				})
+				mockPayrollRepo.EXPECT().GetPayslipsAfter(gomock.Any(), "payroll-id", "", gomock.Any()).Return([]models.Payslip{
+					{ID: "payslip-4", UserID: "user-4", PayrollID: "payroll-id", TakeHomePay: 16120000},
+				}, nil)
+				mockPayrollRepo.EXPECT().StorePayslipHashes(gomock.Any(), gomock.Len(1)).Return(nil)
+				// payslips of the previous attempt paid out in USD are reported in the base currency too
+				mockPayrollRepo.EXPECT().GetPayrollTotalPaid(gomock.Any(), "payroll-id").Return(map[string]float64{"IDR": 16120000, "USD": 500}, nil)
+				mockPayrollRepo.EXPECT().MarkPayrollProcessed(gomock.Any(), "payroll-id", float64(24120000), map[string]float64{"IDR": 16120000, "USD": 500}, gomock.Any()).Return(nil)
+				mockMails.EXPECT().EnqueuePayslipEmails(gomock.Any(), "payroll-id").Return(nil)
+				mockPayrollRepo.EXPECT().FinishPayrollJob(gomock.Any(), "job-id", PayrollJobCompleted, gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name: "failed payroll job on payslip seal error",
+			fields: fields{
+				deps:        &mockDeps,
+				payrollRepo: mockPayrollRepo,
+				userRepo:    mockUserRepo,
+				attRepo:     mockAttRepo,
+			},
+			args: args{
+				ctx: context.Background(),
+				job: PayrollJob{ID: "job-id", PayrollID: "payroll-id"},
+			},
+			wantErr: true,
+			behaviour: func(f fields, a args) {
+				// every payslip is stored by the previous attempt
+				mockPayrollRepo.EXPECT().GetPayrollPeriodByID(gomock.Any(), "payroll-id").Return(PayrollPeriod{ID: "payroll-id", PayGroupID: "group-id", TotalWorkDays: 20}, nil)
+				mockUserRepo.EXPECT().CountEligibleUsers(gomock.Any(), "group-id", []string{"active"}, gomock.Any(), gomock.Any()).Return(1, nil)
+				mockPayrollRepo.EXPECT().CountPayslips(gomock.Any(), "payroll-id").Return(1, nil)
+				mockPayrollRepo.EXPECT().GetExchangeRates(gomock.Any(), "payroll-id").Return(nil, nil)
+				mockUserRepo.EXPECT().GetEligibleUserIDs(gomock.Any(), "group-id", []string{"active"}, gomock.Any(), gomock.Any(), "", gomock.Any()).Return([]string{"user-1"}, nil)
+				mockPayrollRepo.EXPECT().GetPayslipUserIDs(gomock.Any(), "payroll-id", []string{"user-1"}).Return([]string{"user-1"}, nil)
+				mockPayrollRepo.EXPECT().UpdatePayrollJobProgress(gomock.Any(), "job-id", 1, gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+				mockPayrollRepo.EXPECT().GetPayslipsAfter(gomock.Any(), "payroll-id", "", gomock.Any()).Return([]models.Payslip{
+					{ID: "payslip-1", UserID: "user-1", PayrollID: "payroll-id", TakeHomePay: 1000},
+				}, nil)
+				mockPayrollRepo.EXPECT().StorePayslipHashes(gomock.Any(), gomock.Len(1)).Return(fmt.Errorf("db error"))
+				mockPayrollRepo.EXPECT().FinishPayrollJob(gomock.Any(), "job-id", PayrollJobFailed, gomock.Len(1)).Return(nil)
+			},
+		},
+		{
+			name: "failed payroll job without exchange rate",
+			fields: fields{
+				deps:        &mockDeps,
+				payrollRepo: mockPayrollRepo,
+				userRepo:    mockUserRepo,
+				attRepo:     mockAttRepo,
+			},
+			args: args{
+				ctx: context.Background(),
+				job: PayrollJob{ID: "job-id", PayrollID: "payroll-id"},
+			},
+			wantErr: true,
+			behaviour: func(f fields, a args) {
+				mockPayrollRepo.EXPECT().GetPayrollPeriodByID(gomock.Any(), "payroll-id").Return(PayrollPeriod{ID: "payroll-id", PayGroupID: "group-id", TotalWorkDays: 20}, nil)
+				mockUserRepo.EXPECT().CountEligibleUsers(gomock.Any(), "group-id", []string{"active"}, gomock.Any(), gomock.Any()).Return(1, nil)
+				mockPayrollRepo.EXPECT().CountPayslips(gomock.Any(), "payroll-id").Return(0, nil)
+				mockPayrollRepo.EXPECT().GetExchangeRates(gomock.Any(), "payroll-id").Return(nil, nil)
+				mockUserRepo.EXPECT().GetEligibleUserIDs(gomock.Any(), "group-id", []string{"active"}, gomock.Any(), gomock.Any(), "", gomock.Any()).Return([]string{"user-4"}, nil)
+				mockPayrollRepo.EXPECT().GetPayslipUserIDs(gomock.Any(), "payroll-id", []string{"user-4"}).Return(nil, nil)
+				mockAttRepo.EXPECT().GetUsersAttendancesByPeriod(gomock.Any(), []string{"user-4"}, gomock.Any(), gomock.Any()).Return(nil, nil)
+				mockAttRepo.EXPECT().GetUsersOvertimesByPeriod(gomock.Any(), []string{"user-4"}, gomock.Any(), gomock.Any()).Return(nil, nil)
+				mockAttRepo.EXPECT().GetUsersReimbursementsByPeriod(gomock.Any(), []string{"user-4"}, gomock.Any(), gomock.Any()).Return([]models.Reimbursement{
+					{ID: "reimbursement-1", UserID: "user-4", Amount: 10, Currency: "SGD"},
+				}, nil)
+				mockAttRepo.EXPECT().GetUsersLeaveDaysByPeriod(gomock.Any(), []string{"user-4"}, gomock.Any(), gomock.Any()).Return(nil, nil)
+				mockUserRepo.EXPECT().GetUsersSalaryByIDs(gomock.Any(), []string{"user-4"}).Return([]models.UserSalary{
+					{UserID: "user-4", Salary: 1000},
+				}, nil)
+				mockPayrollRepo.EXPECT().UpdatePayrollJobProgress(gomock.Any(), "job-id", 1, gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+				mockPayrollRepo.EXPECT().FinishPayrollJob(gomock.Any(), "job-id", PayrollJobFailed, gomock.Len(1)).Return(nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := NewPayrollLogic(tt.fields.deps, tt.fields.payrollRepo, tt.fields.userRepo, tt.fields.attRepo, mockAuditor, mockMails)
+			tt.behaviour(tt.fields, tt.args)
+			if err := logic.ProcessPayrollJob(tt.args.ctx, tt.args.job); (err != nil) != tt.wantErr {
+				t.Errorf("PayrollLogic.ProcessPayrollJob() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPayrollLogic_VerifyPayslips(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockDeps := config.CommonDependencies{
+		Config: config.InitConfig(context.Background()),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	mockPayrollRepo := NewMockPayrollRepositoryInterface(ctrl)
+	mockAuditor := xaudit.NewMockRecorder(ctrl)
+	// the change and its entry are run in the transaction the recorder begins
+	mockAuditor.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, txfunc func(context.Context) error) error {
+		return txfunc(ctx)
+	}).AnyTimes()
+	mockMails := xmail.NewMockQueue(ctrl)
+	mockUserRepo := user.NewMockUserRepositoryInterface(ctrl)
+	mockAttRepo := attendance.NewMockAttendanceRepositoryInterface(ctrl)
+
+	// seal two payslips the way the payroll job does, with the hash version given
+	secret := mockDeps.Config.Payroll.PayslipSigningSecret
+	seal := func(version string) ([]models.Payslip, PayrollPeriod) {
+		payslips := []models.Payslip{
+			{ID: "payslip-1", UserID: "user-1", PayrollID: "payroll-id", TakeHomePay: 1075, TotalReimbursement: 75, ReimbursementList: []models.Reimbursement{
+				{ID: "reimbursement-1", Amount: 75, Currency: "IDR", Description: "taxi to client", Category: "travel", CategoryName: "Travel"},
+			}},
+			{ID: "payslip-2", UserID: "user-2", PayrollID: "payroll-id", TakeHomePay: 2000},
+		}
+		prevHash := ""
+		for i := range payslips {
+			hash := hashPayslipVersion(version, payslips[i], prevHash)
+			payslips[i].PrevHash = prevHash
+			payslips[i].Hash = hash
+			payslips[i].Signature = xcrypto.Sign([]byte(hash), secret)
+			prevHash = hash
+		}
+		return payslips, PayrollPeriod{
+			ID:        "payroll-id",
+			Processed: true,
+			PayslipChain: PayslipChain{
+				Hash:      prevHash,
+				Signature: xcrypto.Sign(payslipChainMessage("payroll-id", prevHash), secret),
+			},
+		}
+	}
+	payslips, period := seal(payslipHashVersion)
+	prevHash := period.PayslipChain.Hash
+	v1Payslips, v1Period := seal(payslipHashVersionV1)
+	changed := append([]models.Payslip{}, payslips...)
+	changed[0].TakeHomePay = 5000
+	hashFound := true
+
+	// the reimbursement of a sealed payslip described as another expense
+	redescribed := append([]models.Payslip{}, payslips...)
+	redescribed[0].ReimbursementList = []models.Reimbursement{redescribed[0].ReimbursementList[0]}
+	redescribed[0].ReimbursementList[0].Description = "flight to bali"
+	v1Changed := append([]models.Payslip{}, v1Payslips...)
+	v1Changed[1].TakeHomePay = 5000
+
+	ctx := context.WithValue(context.Background(), xcontext.UserIDKey, "admin-id")
+
+	type args struct {
+		ctx       context.Context
+		payrollID string
+		hash      string
+	}
+	tests := []struct {
+		name      string
+		args      args
+		want      PayslipVerification
+		wantErr   bool
+		behaviour func(a args)
+	}{
+		// TODO: Add test cases.
+		{
+			name: "success valid chain with the asked hash",
+			args: args{ctx: ctx, payrollID: "payroll-id", hash: payslips[1].Hash},
+			want: PayslipVerification{PayrollID: "payroll-id", Valid: true, Payslips: 2, ChainHash: prevHash, ChainHeadValid: true, InvalidPayslips: []InvalidPayslip{}, HashFound: &hashFound},
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+				mockPayrollRepo.EXPECT().GetPayrollPeriodByID(gomock.Any(), "payroll-id").Return(period, nil)
+				mockPayrollRepo.EXPECT().GetPayslipsAfter(gomock.Any(), "payroll-id", "", gomock.Any()).Return(payslips, nil)
+			},
+		},
+		{
+			name: "success changed payslip is invalid",
+			args: args{ctx: ctx, payrollID: "payroll-id"},
+			want: PayslipVerification{PayrollID: "payroll-id", Payslips: 2, ChainHash: prevHash, ChainHeadValid: true, InvalidPayslips: []InvalidPayslip{
+				{ID: "payslip-1", UserID: "user-1", Reason: "hash mismatch, the payslip was changed"},
+			}},
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+				mockPayrollRepo.EXPECT().GetPayrollPeriodByID(gomock.Any(), "payroll-id").Return(period, nil)
+				mockPayrollRepo.EXPECT().GetPayslipsAfter(gomock.Any(), "payroll-id", "", gomock.Any()).Return(changed, nil)
+			},
+		},
+		{
+			name: "success payslip with a changed reimbursement description is invalid",
+			args: args{ctx: ctx, payrollID: "payroll-id"},
+			want: PayslipVerification{PayrollID: "payroll-id", Payslips: 2, ChainHash: prevHash, ChainHeadValid: true, InvalidPayslips: []InvalidPayslip{
+				{ID: "payslip-1", UserID: "user-1", Reason: "hash mismatch, the payslip was changed"},
+			}},
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+				mockPayrollRepo.EXPECT().GetPayrollPeriodByID(gomock.Any(), "payroll-id").Return(period, nil)
+				mockPayrollRepo.EXPECT().GetPayslipsAfter(gomock.Any(), "payroll-id", "", gomock.Any()).Return(redescribed, nil)
+			},
+		},
+		{
+			name: "success valid v1 chain sealed before the hash version bump",
+			args: args{ctx: ctx, payrollID: "payroll-id"},
+			want: PayslipVerification{PayrollID: "payroll-id", Valid: true, Payslips: 2, ChainHash: v1Period.PayslipChain.Hash, ChainHeadValid: true, InvalidPayslips: []InvalidPayslip{}},
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+				mockPayrollRepo.EXPECT().GetPayrollPeriodByID(gomock.Any(), "payroll-id").Return(v1Period, nil)
+				mockPayrollRepo.EXPECT().GetPayslipsAfter(gomock.Any(), "payroll-id", "", gomock.Any()).Return(v1Payslips, nil)
+			},
+		},
+		{
+			name: "success changed payslip of a v1 chain is invalid",
+			args: args{ctx: ctx, payrollID: "payroll-id"},
+			want: PayslipVerification{PayrollID: "payroll-id", Payslips: 2, ChainHash: v1Period.PayslipChain.Hash, ChainHeadValid: true, InvalidPayslips: []InvalidPayslip{
+				{ID: "payslip-2", UserID: "user-2", Reason: "hash mismatch, the payslip was changed"},
+			}},
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+				mockPayrollRepo.EXPECT().GetPayrollPeriodByID(gomock.Any(), "payroll-id").Return(v1Period, nil)
+				mockPayrollRepo.EXPECT().GetPayslipsAfter(gomock.Any(), "payroll-id", "", gomock.Any()).Return(v1Changed, nil)
+			},
+		},
+		{
+			name: "success removed last payslip breaks the chain head",
+			args: args{ctx: ctx, payrollID: "payroll-id"},
+			want: PayslipVerification{PayrollID: "payroll-id", Payslips: 1, ChainHash: prevHash, InvalidPayslips: []InvalidPayslip{}},
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+				mockPayrollRepo.EXPECT().GetPayrollPeriodByID(gomock.Any(), "payroll-id").Return(period, nil)
+				mockPayrollRepo.EXPECT().GetPayslipsAfter(gomock.Any(), "payroll-id", "", gomock.Any()).Return(payslips[:1], nil)
+			},
+		},
+		{
+			name:    "failed period not sealed",
+			args:    args{ctx: ctx, payrollID: "payroll-id"},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+				mockPayrollRepo.EXPECT().GetPayrollPeriodByID(gomock.Any(), "payroll-id").Return(PayrollPeriod{ID: "payroll-id"}, nil)
+			},
+		},
+		{
+			name:    "failed not admin",
+			args:    args{ctx: ctx, payrollID: "payroll-id"},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(false, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := NewPayrollLogic(&mockDeps, mockPayrollRepo, mockUserRepo, mockAttRepo, mockAuditor, mockMails)
+			tt.behaviour(tt.args)
+			got, err := logic.VerifyPayslips(tt.args.ctx, tt.args.payrollID, tt.args.hash)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PayrollLogic.VerifyPayslips() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PayrollLogic.VerifyPayslips() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_hashPayslip(t *testing.T) {
+	payslip := models.Payslip{
+		ID:                "payslip-1",
+		Name:              "Jane",
+		UserID:            "user-1",
+		PayrollID:         "payroll-id",
+		BaseSalary:        1500000,
+		TotalAttendance:   20,
+		TotalWorkDay:      22,
+		TotalOvertimeHour: 3,
+		OvertimePay:       51136.36,
+		ReimbursementList: []models.Reimbursement{{
+			ID: "reimbursement-1", Amount: 75000, Currency: "IDR", Description: "taxi to client", Category: "travel", CategoryName: "Travel", Taxable: true,
+			Receipts: []models.ReimbursementReceipt{{ID: "receipt-1", ReimbursementID: "reimbursement-1", FileName: "taxi.pdf", ContentType: "application/pdf", Size: 2048, StorageKey: "receipts/receipt-1"}},
+		}},
+		TotalReimbursement: 75000,
+		TakeHomePay:        1576136.36,
+		ReimbursementCategories: []models.ReimbursementCategoryTotal{
+			{Category: "travel", Name: "Travel", Taxable: true, Count: 1, Amount: 75000},
+		},
+		TotalTaxableReimbursement: 75000,
+		PaidLeaveDays:             1,
+		ReviewReasons:             []string{"missing attendance"},
+		Type:                      models.PayslipRegular,
+		EmployedWorkDays:          22,
+		DeductionList:             []models.Deduction{{ID: "deduction-1", Amount: 50000, Description: "loan"}},
+		TotalDeduction:            50000,
+		SalaryCurrency:            "IDR",
+		OriginalSalary:            1500000,
+		PayoutCurrency:            "IDR",
+		SalaryExchangeRate:        1,
+	}
+	renamed := payslip
+	renamed.Name = "Jane Doe"
+	renamed.Hash = "stored-hash"
+	renamed.Signature = "stored-signature"
+	changed := payslip
+	changed.TakeHomePay = 1576136.37
+	// the storage key isn't stored on the payslip
+	moved := withReimbursement(payslip, func(r *models.Reimbursement) {
+		r.Receipts = []models.ReimbursementReceipt{r.Receipts[0]}
+		r.Receipts[0].StorageKey = "receipts/elsewhere"
+	})
+
+	tests := []struct {
+		name     string
+		version  string
+		payslip  models.Payslip
+		prevHash string
+		want     string
+	}{
+		// TODO: Add test cases.
+		{
+			name:     "pinned hash of a fixed payslip",
+			version:  payslipHashVersion,
+			payslip:  payslip,
+			prevHash: "prev-hash",
+			want:     "8971d1714fa8a0341dae0fbda6c0defba6f0b10609853a1ed65cfacd85979d0c",
+		},
+		{
+			name:     "name, storage keys and stored hashes aren't hashed",
+			version:  payslipHashVersion,
+			payslip:  withReimbursement(renamed, func(r *models.Reimbursement) { r.Receipts = moved.ReimbursementList[0].Receipts }),
+			prevHash: "prev-hash",
+			want:     "8971d1714fa8a0341dae0fbda6c0defba6f0b10609853a1ed65cfacd85979d0c",
+		},
+		{
+			name:     "changed take home pay",
+			version:  payslipHashVersion,
+			payslip:  changed,
+			prevHash: "prev-hash",
+			want:     "37361af18bf2d5c77baa9fb4b52d9a2c75577619f09b7856806f50cf36e7fd65",
+		},
+		{
+			name:     "chained to another payslip",
+			version:  payslipHashVersion,
+			payslip:  payslip,
+			prevHash: "other-hash",
+			want:     "47843bc817e8dd3a2d81aa9d146fdc36306cad099236fa588803f55a9473e458",
+		},
+		{
+			name:     "pinned v1 hash, the reimbursement descriptions, category names and receipts weren't hashed",
+			version:  payslipHashVersionV1,
+			payslip:  withReimbursement(payslip, func(r *models.Reimbursement) { r.Description, r.CategoryName, r.Receipts = "", "", nil }),
+			prevHash: "prev-hash",
+			want:     "136e51e56ae0a3203f88b3dbf5f7b610bb440fbd733e280bed8bfa2e256df493",
+		},
+		{
+			name:     "pinned v1 hash of a changed take home pay",
+			version:  payslipHashVersionV1,
+			payslip:  changed,
+			prevHash: "prev-hash",
+			want:     "e807e52c53726ab050b08beb5bf360d8757e8cff223c4c3538c2496c4328a9e3",
+		},
+		{
+			name:     "pinned v1 hash chained to another payslip",
+			version:  payslipHashVersionV1,
+			payslip:  renamed,
+			prevHash: "other-hash",
+			want:     "8849234f601d4458f50fec08ac71eabc0be9bfa73e02f3cb50d8fdc2ee20c9b2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hashPayslipVersion(tt.version, tt.payslip, tt.prevHash); got != tt.want {
+				t.Errorf("hashPayslipVersion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// every reimbursement field is hashed since v2
+	tampered := map[string]models.Payslip{
+		"description":   withReimbursement(payslip, func(r *models.Reimbursement) { r.Description = "taxi to the airport" }),
+		"category name": withReimbursement(payslip, func(r *models.Reimbursement) { r.CategoryName = "Client Travel" }),
+		"receipt":       withReimbursement(payslip, func(r *models.Reimbursement) { r.Receipts = nil }),
+		"receipt file": withReimbursement(payslip, func(r *models.Reimbursement) {
+			r.Receipts = []models.ReimbursementReceipt{r.Receipts[0]}
+			r.Receipts[0].FileName = "other.pdf"
+		}),
+	}
+	for field, tamperedPayslip := range tampered {
+		if hashPayslip(tamperedPayslip, "prev-hash") == hashPayslip(payslip, "prev-hash") {
+			t.Errorf("hashPayslip() ignores the reimbursement %s", field)
+		}
+	}
+}
+
+// withReimbursement copies the payslip with its first reimbursement changed, leaving the original untouched
+func withReimbursement(payslip models.Payslip, change func(r *models.Reimbursement)) models.Payslip {
+	payslip.ReimbursementList = slices.Clone(payslip.ReimbursementList)
+	change(&payslip.ReimbursementList[0])
+	return payslip
+}
+
+func TestPayrollLogic_GetPayrollJournal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockDeps := config.CommonDependencies{
+		Config: config.InitConfig(context.Background()),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	mockDeps.Config.Payroll.BaseCurrency = "IDR"
+	cfg := mockDeps.Config.Accounting
+
+	mockPayrollRepo := NewMockPayrollRepositoryInterface(ctrl)
+	mockAuditor := xaudit.NewMockRecorder(ctrl)
+	// the change and its entry are run in the transaction the recorder begins
+	mockAuditor.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, txfunc func(context.Context) error) error {
+		return txfunc(ctx)
+	}).AnyTimes()
+	mockMails := xmail.NewMockQueue(ctrl)
+	mockUserRepo := user.NewMockUserRepositoryInterface(ctrl)
+	mockAttRepo := attendance.NewMockAttendanceRepositoryInterface(ctrl)
+
+	period := PayrollPeriod{
+		ID:         "payroll-id",
+		PayGroupID: "pay-group-id",
+		StartDate:  time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:    time.Date(2025, time.June, 30, 0, 0, 0, 0, time.UTC),
+		Processed:  true,
+	}
+	payslips := []models.Payslip{
+		// stored before multi-currency payouts, paid in the base currency
+		{ID: "payslip-1", UserID: "user-1", BaseSalary: 2000000, TotalWorkDay: 20, EmployedWorkDays: 20, TotalAttendance: 20, OvertimePay: 37500.333, TotalReimbursement: 150000, TakeHomePay: 2187500.333},
+		// final settlement of 1.000.000 salary and 100.000 leave payout, 1.500.000 deductions are only recovered up to it
+		{ID: "payslip-2", UserID: "user-2", BaseSalary: 2000000, TotalWorkDay: 20, EmployedWorkDays: 10, TotalAttendance: 10, LeavePayout: 100000, TotalDeduction: 1500000, PayoutCurrency: "IDR", Type: models.PayslipFinalSettlement},
+		{ID: "payslip-3", UserID: "user-3", BaseSalary: 924.5, TotalWorkDay: 20, EmployedWorkDays: 20, TotalAttendance: 20, OvertimePay: 50, TotalReimbursement: 25.5, TakeHomePay: 1000, PayoutCurrency: "USD"},
+	}
+	wages := JournalAccount{Type: AccountSalaryExpense, Code: "5000", Name: "Wages"}
+
+	ctx := context.WithValue(context.Background(), xcontext.UserIDKey, "admin-id")
+
+	type args struct {
+		ctx       context.Context
+		payrollID string
+	}
+	tests := []struct {
+		name      string
+		args      args
+		want      Journal
+		wantErr   bool
+		behaviour func(a args)
+	}{
+		// TODO: Add test cases.
+		{
+			name: "success balanced entry per payout currency",
+			args: args{ctx: ctx, payrollID: "payroll-id"},
+			want: Journal{
+				PayrollID:    "payroll-id",
+				PayGroupID:   "pay-group-id",
+				StartDate:    xdate.New(2025, time.June, 1),
+				EndDate:      xdate.New(2025, time.June, 30),
+				BaseCurrency: "IDR",
+				Payslips:     3,
+				Entries: []JournalEntry{
+					{
+						Reference:   "PAYROLL-payroll-id-IDR",
+						Date:        xdate.New(2025, time.June, 30),
+						Currency:    "IDR",
+						Description: "Payroll 2025-06-01 to 2025-06-30",
+						Lines: []JournalLine{
+							{AccountType: AccountSalaryExpense, AccountCode: "5000", AccountName: "Wages", Description: "salaries and leave payouts", Debit: 3100000},
+							{AccountType: AccountOvertimeExpense, AccountCode: cfg.OvertimeExpenseAccount, AccountName: "Overtime Expense", Description: "overtime pay", Debit: 37500.33},
+							{AccountType: AccountReimbursementExpense, AccountCode: cfg.ReimbursementExpenseAccount, AccountName: "Reimbursement Expense", Description: "reimbursements", Debit: 150000},
+							{AccountType: AccountNetPayPayable, AccountCode: cfg.NetPayPayableAccount, AccountName: "Net Pay Payable", Description: "net pay", Credit: 2187500.33},
+							{AccountType: AccountDeductionReceivable, AccountCode: cfg.DeductionReceivableAccount, AccountName: "Employee Receivable", Description: "deductions recovered from final pay", Credit: 1100000},
+						},
+						TotalDebit:  3287500.33,
+						TotalCredit: 3287500.33,
+					},
+					{
+						Reference:   "PAYROLL-payroll-id-USD",
+						Date:        xdate.New(2025, time.June, 30),
+						Currency:    "USD",
+						Description: "Payroll 2025-06-01 to 2025-06-30",
+						Lines: []JournalLine{
+							{AccountType: AccountSalaryExpense, AccountCode: "5000", AccountName: "Wages", Description: "salaries and leave payouts", Debit: 924.5},
+							{AccountType: AccountOvertimeExpense, AccountCode: cfg.OvertimeExpenseAccount, AccountName: "Overtime Expense", Description: "overtime pay", Debit: 50},
+							{AccountType: AccountReimbursementExpense, AccountCode: cfg.ReimbursementExpenseAccount, AccountName: "Reimbursement Expense", Description: "reimbursements", Debit: 25.5},
+							{AccountType: AccountNetPayPayable, AccountCode: cfg.NetPayPayableAccount, AccountName: "Net Pay Payable", Description: "net pay", Credit: 1000},
+						},
+						TotalDebit:  1000,
+						TotalCredit: 1000,
+					},
+				},
+			},
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+				mockPayrollRepo.EXPECT().GetPayrollPeriodByID(gomock.Any(), "payroll-id").Return(period, nil)
+				mockPayrollRepo.EXPECT().GetJournalAccounts(gomock.Any()).Return([]JournalAccount{wages}, nil)
+				mockPayrollRepo.EXPECT().GetPayslipsAfter(gomock.Any(), "payroll-id", "", gomock.Any()).Return(payslips, nil)
+			},
+		},
+		{
+			name: "success period without payslips",
+			args: args{ctx: ctx, payrollID: "payroll-id"},
+			want: Journal{
+				PayrollID:    "payroll-id",
+				PayGroupID:   "pay-group-id",
+				StartDate:    xdate.New(2025, time.June, 1),
+				EndDate:      xdate.New(2025, time.June, 30),
+				BaseCurrency: "IDR",
+				Entries:      []JournalEntry{},
+			},
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+				mockPayrollRepo.EXPECT().GetPayrollPeriodByID(gomock.Any(), "payroll-id").Return(period, nil)
+				mockPayrollRepo.EXPECT().GetJournalAccounts(gomock.Any()).Return([]JournalAccount{}, nil)
+				mockPayrollRepo.EXPECT().GetPayslipsAfter(gomock.Any(), "payroll-id", "", gomock.Any()).Return([]models.Payslip{}, nil)
+			},
+		},
+		{
+			name:    "failed period not processed",
+			args:    args{ctx: ctx, payrollID: "payroll-id"},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+				mockPayrollRepo.EXPECT().GetPayrollPeriodByID(gomock.Any(), "payroll-id").Return(PayrollPeriod{ID: "payroll-id"}, nil)
+			},
+		},
+		{
+			name:    "failed not admin",
+			args:    args{ctx: ctx, payrollID: "payroll-id"},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(false, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := NewPayrollLogic(&mockDeps, mockPayrollRepo, mockUserRepo, mockAttRepo, mockAuditor, mockMails)
+			tt.behaviour(tt.args)
+			got, err := logic.GetPayrollJournal(tt.args.ctx, tt.args.payrollID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PayrollLogic.GetPayrollJournal() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PayrollLogic.GetPayrollJournal() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_journalEntry(t *testing.T) {
+	period := PayrollPeriod{
+		ID:        "payroll-id",
+		StartDate: time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2025, time.June, 30, 0, 0, 0, 0, time.UTC),
+	}
+
+	// amounts in thirds never add up to whole cents
+	thirds := make([]models.Payslip, 0, 100)
+	for i := 1; i <= 100; i++ {
+		overtime := float64(i) / 3
+		reimbursement := float64(i*7) / 3
+		salary := float64(i*1000) / 3
+		thirds = append(thirds, models.Payslip{
+			BaseSalary:         salary,
+			TotalWorkDay:       20,
+			EmployedWorkDays:   20,
+			TotalAttendance:    20,
+			OvertimePay:        overtime,
+			TotalReimbursement: reimbursement,
+			TakeHomePay:        salary + overtime + reimbursement,
+		})
+	}
+
+	tests := []struct {
+		name       string
+		payslips   []models.Payslip
+		tax        float64
+		wantNetPay float64
+	}{
+		// TODO: Add test cases.
+		{
+			name:       "balanced with amounts rounded to cents",
+			payslips:   thirds,
+			wantNetPay: 1696800, // 1.683.333,33 salary + 1.683,33 overtime + 11.783,33 reimbursement, the salary takes up the cent
+		},
+		{
+			name: "balanced with deductions recovered from the final pay",
+			payslips: []models.Payslip{
+				{BaseSalary: 3000000, TotalWorkDay: 21, EmployedWorkDays: 7, TotalAttendance: 7, LeavePayout: 142857.14, OvertimePay: 17857.14, TotalDeduction: 500000, TakeHomePay: 660714.28},
+				{BaseSalary: 3000000, TotalWorkDay: 21, EmployedWorkDays: 7, TotalAttendance: 5, TotalDeduction: 9000000},
+			},
+			wantNetPay: 660714.28,
+		},
+		{
+			name:       "balanced with the tax withheld credited to the tax payable",
+			payslips:   thirds,
+			tax:        12345.67,
+			wantNetPay: 1696800,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			totals := &journalTotals{}
+			for _, payslip := range tt.payslips {
+				totals.add(payslip)
+			}
+			totals.tax = tt.tax
+
+			got := journalEntry(period, "IDR", totals, nil)
+
+			var debit, credit, netPay, tax int64
+			taxLine := false
+			for _, line := range got.Lines {
+				if line.Debit < 0 || line.Credit < 0 {
+					t.Errorf("negative journal line: %+v", line)
+				}
+				debit += toCents(line.Debit)
+				credit += toCents(line.Credit)
+				if line.AccountType == AccountNetPayPayable {
+					netPay += toCents(line.Credit)
+				}
+				if line.AccountType == AccountTaxPayable {
+					taxLine = true
+					tax += toCents(line.Credit)
+				}
+			}
+			if taxLine != (tt.tax != 0) || tax != toCents(tt.tax) {
+				t.Errorf("journalEntry() tax payable = %v, want %v", fromCents(tax), tt.tax)
+			}
+			if debit != credit || got.TotalDebit != got.TotalCredit || toCents(got.TotalDebit) != debit {
+				t.Errorf("journalEntry() isn't balanced: debit %d, credit %d, totals %v and %v", debit, credit, got.TotalDebit, got.TotalCredit)
+			}
+			if netPay != toCents(tt.wantNetPay) {
+				t.Errorf("journalEntry() net pay = %v, want %v", fromCents(netPay), tt.wantNetPay)
+			}
+		})
+	}
+}
+
+func TestPayrollLogic_SetExchangeRates(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockDeps := config.CommonDependencies{
+		Config: config.InitConfig(context.Background()),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	base := mockDeps.Config.Payroll.BaseCurrency
+
+	mockPayrollRepo := NewMockPayrollRepositoryInterface(ctrl)
+	mockAuditor := xaudit.NewMockRecorder(ctrl)
+	// the change and its entry are run in the transaction the recorder begins
+	mockAuditor.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, txfunc func(context.Context) error) error {
+		return txfunc(ctx)
+	}).AnyTimes()
+	mockMails := xmail.NewMockQueue(ctrl)
+	mockUserRepo := user.NewMockUserRepositoryInterface(ctrl)
+	mockAttRepo := attendance.NewMockAttendanceRepositoryInterface(ctrl)
+
+	ctx := context.WithValue(context.Background(), xcontext.UserIDKey, "admin-id")
+
+	type args struct {
+		ctx       context.Context
+		payrollID string
+		req       ExchangeRatesRequest
+	}
+	tests := []struct {
+		name      string
+		args      args
+		want      ExchangeRates
+		wantErr   bool
+		behaviour func(a args)
+	}{
+		// TODO: Add test cases.
+		{
+			name: "success set a rate, the other rates are kept",
+			args: args{ctx: ctx, payrollID: "payroll-id", req: ExchangeRatesRequest{Rates: map[string]float64{"usd": 16500}}},
+			want: ExchangeRates{PayrollID: "payroll-id", BaseCurrency: base, Rates: map[string]float64{"USD": 16500, "SGD": 12000}},
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+				mockPayrollRepo.EXPECT().GetPayrollPeriodByID(gomock.Any(), "payroll-id").Return(PayrollPeriod{ID: "payroll-id"}, nil)
+				mockPayrollRepo.EXPECT().GetExchangeRates(gomock.Any(), "payroll-id").Return(map[string]float64{"USD": 16000, "SGD": 12000}, nil)
+				mockPayrollRepo.EXPECT().SetExchangeRates(gomock.Any(), "payroll-id", map[string]float64{"USD": 16500}).Return(nil)
+				mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, entry xaudit.Entry) error {
+					wantBefore := map[string]any{"base_currency": base, "rates": map[string]float64{"USD": 16000, "SGD": 12000}}
+					wantAfter := map[string]any{"base_currency": base, "rates": map[string]float64{"USD": 16500, "SGD": 12000}}
+					if entry.Entity != xaudit.EntityExchangeRates || entry.EntityID != "payroll-id" || !reflect.DeepEqual(entry.Before, wantBefore) || !reflect.DeepEqual(entry.After, wantAfter) {
+						t.Errorf("unexpected audit log entry: %+v", entry)
+					}
+					return nil
+				})
+				mockPayrollRepo.EXPECT().GetExchangeRates(gomock.Any(), "payroll-id").Return(map[string]float64{"USD": 16500, "SGD": 12000}, nil)
+			},
+		},
+		{
+			name: "success set the first rates",
+			args: args{ctx: ctx, payrollID: "payroll-id", req: ExchangeRatesRequest{Rates: map[string]float64{"USD": 16500}}},
+			want: ExchangeRates{PayrollID: "payroll-id", BaseCurrency: base, Rates: map[string]float64{"USD": 16500}},
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+				mockPayrollRepo.EXPECT().GetPayrollPeriodByID(gomock.Any(), "payroll-id").Return(PayrollPeriod{ID: "payroll-id"}, nil)
+				mockPayrollRepo.EXPECT().GetExchangeRates(gomock.Any(), "payroll-id").Return(nil, nil)
+				mockPayrollRepo.EXPECT().SetExchangeRates(gomock.Any(), "payroll-id", map[string]float64{"USD": 16500}).Return(nil)
+				mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, entry xaudit.Entry) error {
+					wantAfter := map[string]any{"base_currency": base, "rates": map[string]float64{"USD": 16500}}
+					if !reflect.DeepEqual(entry.After, wantAfter) {
+						t.Errorf("unexpected audit log entry: %+v", entry)
+					}
+					return nil
+				})
+				mockPayrollRepo.EXPECT().GetExchangeRates(gomock.Any(), "payroll-id").Return(map[string]float64{"USD": 16500}, nil)
+			},
+		},
+		{
+			name:    "failed processed payroll period",
+			args:    args{ctx: ctx, payrollID: "payroll-id", req: ExchangeRatesRequest{Rates: map[string]float64{"USD": 16500}}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+				mockPayrollRepo.EXPECT().GetPayrollPeriodByID(gomock.Any(), "payroll-id").Return(PayrollPeriod{ID: "payroll-id", Processed: true}, nil)
+			},
+		},
+		{
+			name:    "failed rate of the base currency",
+			args:    args{ctx: ctx, payrollID: "payroll-id", req: ExchangeRatesRequest{Rates: map[string]float64{base: 1}}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := NewPayrollLogic(&mockDeps, mockPayrollRepo, mockUserRepo, mockAttRepo, mockAuditor, mockMails)
+			tt.behaviour(tt.args)
+			got, err := logic.SetExchangeRates(tt.args.ctx, tt.args.payrollID, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PayrollLogic.SetExchangeRates() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PayrollLogic.SetExchangeRates() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPayrollLogic_SetJournalAccounts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockDeps := config.CommonDependencies{
+		Config: config.InitConfig(context.Background()),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	cfg := mockDeps.Config.Accounting
+
+	mockPayrollRepo := NewMockPayrollRepositoryInterface(ctrl)
+	mockAuditor := xaudit.NewMockRecorder(ctrl)
+	// the change and its entry are run in the transaction the recorder begins
+	mockAuditor.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, txfunc func(context.Context) error) error {
+		return txfunc(ctx)
+	}).AnyTimes()
+	mockMails := xmail.NewMockQueue(ctrl)
+	mockUserRepo := user.NewMockUserRepositoryInterface(ctrl)
+	mockAttRepo := attendance.NewMockAttendanceRepositoryInterface(ctrl)
+
+	previous := []JournalAccount{
+		{Type: AccountSalaryExpense, Code: "5100", Name: "Salaries"},
+	}
+	mapped := []JournalAccount{
+		{Type: AccountSalaryExpense, Code: "5000", Name: "Wages"},
+		{Type: AccountNetPayPayable, Code: "2100", Name: "Net Pay Payable"},
+	}
+
+	ctx := context.WithValue(context.Background(), xcontext.UserIDKey, "admin-id")
+
+	type args struct {
+		ctx context.Context
+		req JournalAccountsRequest
+	}
+	tests := []struct {
+		name      string
+		args      args
+		want      []JournalAccount
+		wantErr   bool
+		behaviour func(a args)
+	}{
+		// TODO: Add test cases.
+		{
+			name: "success map accounts, the others are the default ones",
+			args: args{ctx: ctx, req: JournalAccountsRequest{Accounts: map[string]JournalAccountRequest{
+				AccountSalaryExpense: {Code: " 5000 ", Name: "Wages"},
+				AccountNetPayPayable: {Code: "2100"},
+			}}},
+			want: []JournalAccount{
+				{Type: AccountSalaryExpense, Code: "5000", Name: "Wages"},
+				{Type: AccountOvertimeExpense, Code: cfg.OvertimeExpenseAccount, Name: "Overtime Expense", Default: true},
+				{Type: AccountReimbursementExpense, Code: cfg.ReimbursementExpenseAccount, Name: "Reimbursement Expense", Default: true},
+				{Type: AccountNetPayPayable, Code: "2100", Name: "Net Pay Payable"},
+				{Type: AccountTaxPayable, Code: cfg.TaxPayableAccount, Name: "Tax Payable", Default: true},
+				{Type: AccountDeductionReceivable, Code: cfg.DeductionReceivableAccount, Name: "Employee Receivable", Default: true},
+			},
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+				mockPayrollRepo.EXPECT().GetJournalAccounts(gomock.Any()).Return(previous, nil)
+				mockPayrollRepo.EXPECT().SetJournalAccounts(gomock.Any(), mapped).Return(nil)
+				mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, entry xaudit.Entry) error {
+					// the mapped account replaces the previous mapping, the unmapped one its default
+					want := map[string]xaudit.Entry{
+						AccountSalaryExpense: {Before: previous[0], After: mapped[0]},
+						AccountNetPayPayable: {
+							Before: JournalAccount{Type: AccountNetPayPayable, Code: cfg.NetPayPayableAccount, Name: "Net Pay Payable", Default: true},
+							After:  mapped[1],
+						},
+					}[entry.EntityID]
+					if entry.Action != xaudit.ActionUpdate || entry.Entity != xaudit.EntityJournalAccount || entry.Before != want.Before || entry.After != want.After {
+						t.Errorf("unexpected audit log entry: %+v", entry)
+					}
+					return nil
+				}).Times(2)
+				mockPayrollRepo.EXPECT().GetJournalAccounts(gomock.Any()).Return(mapped, nil)
+			},
+		},
+		{
+			name: "failed audit log, the accounts are rolled back along with it",
+			args: args{ctx: ctx, req: JournalAccountsRequest{Accounts: map[string]JournalAccountRequest{
+				AccountSalaryExpense: {Code: "5000", Name: "Wages"},
+			}}},
+			want:    []JournalAccount{},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+				mockPayrollRepo.EXPECT().GetJournalAccounts(gomock.Any()).Return(previous, nil)
+				mockPayrollRepo.EXPECT().SetJournalAccounts(gomock.Any(), mapped[:1]).Return(nil)
+				mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any()).Return(errors.New("db down"))
+			},
+		},
+		{
+			name: "failed unknown account type",
+			args: args{ctx: ctx, req: JournalAccountsRequest{Accounts: map[string]JournalAccountRequest{
+				"bonus_expense": {Code: "6300"},
+			}}},
+			want:    []JournalAccount{},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+			},
+		},
+		{
+			name: "failed empty account code",
+			args: args{ctx: ctx, req: JournalAccountsRequest{Accounts: map[string]JournalAccountRequest{
+				AccountTaxPayable: {Code: " "},
+			}}},
+			want:    []JournalAccount{},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+			},
+		},
+		{
+			name:    "failed not admin",
+			args:    args{ctx: ctx, req: JournalAccountsRequest{Accounts: map[string]JournalAccountRequest{AccountTaxPayable: {Code: "2210"}}}},
+			want:    []JournalAccount{},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(false, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := NewPayrollLogic(&mockDeps, mockPayrollRepo, mockUserRepo, mockAttRepo, mockAuditor, mockMails)
+			tt.behaviour(tt.args)
+			got, err := logic.SetJournalAccounts(tt.args.ctx, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PayrollLogic.SetJournalAccounts() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PayrollLogic.SetJournalAccounts() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_writeJournalCSV(t *testing.T) {
+	journal := Journal{
+		PayrollID: "payroll-id",
+		Entries: []JournalEntry{
+			{
+				Reference:   "PAYROLL-payroll-id-IDR",
+				Date:        xdate.New(2025, time.June, 30),
+				Currency:    "IDR",
+				Description: "Payroll 2025-06-01 to 2025-06-30",
+				Lines: []JournalLine{
+					{AccountType: AccountSalaryExpense, AccountCode: "5000", AccountName: "Wages, Salaries", Description: "salaries and leave payouts", Debit: 1000000},
+					{AccountType: AccountNetPayPayable, AccountCode: "2200", AccountName: "Net Pay Payable", Description: "net pay", Credit: 1000000},
+				},
+				TotalDebit:  1000000,
+				TotalCredit: 1000000,
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		journal Journal
+		want    string
+	}{
+		// TODO: Add test cases.
+		{
+			name:    "row per journal line",
+			journal: journal,
+			want: "reference,date,currency,description,account_code,account_name,account_type,line_description,debit,credit\n" +
+				"PAYROLL-payroll-id-IDR,2025-06-30,IDR,Payroll 2025-06-01 to 2025-06-30,5000,\"Wages, Salaries\",salary_expense,salaries and leave payouts,1000000.00,0.00\n" +
+				"PAYROLL-payroll-id-IDR,2025-06-30,IDR,Payroll 2025-06-01 to 2025-06-30,2200,Net Pay Payable,net_pay_payable,net pay,0.00,1000000.00\n",
+		},
+		{
+			name:    "header only without entries",
+			journal: Journal{PayrollID: "payroll-id", Entries: []JournalEntry{}},
+			want:    "reference,date,currency,description,account_code,account_name,account_type,line_description,debit,credit\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got strings.Builder
+			if err := writeJournalCSV(&got, tt.journal); err != nil {
+				t.Fatalf("writeJournalCSV() error = %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("writeJournalCSV() = %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestPayrollLogic_runPayslipPipeline(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := *config.InitConfig(context.Background())
+	cfg.Payroll = &config.Payroll{
+		WorkerCount:      4,
+		PayslipBatchSize: 7,
+	}
+	mockDeps := config.CommonDependencies{
+		Config: &cfg,
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	mockPayrollRepo := NewMockPayrollRepositoryInterface(ctrl)
+
+	// generate calculation data of n users
+	newSource := func(n int) payrollDataSource {
+		return func(ctx context.Context, yield func(PayrollCalculationData) error) error {
+			for i := 0; i < n; i++ {
+				err := yield(PayrollCalculationData{
+					UserID:          fmt.Sprintf("user-%d", i),
+					PayrollID:       "payroll-id",
+					Salary:          1000,
+					TotalWorkDay:    20,
+					AttendanceCount: 20,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	type args struct {
+		ctx    context.Context
+		source payrollDataSource
+		total  int
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantErr   error
+		behaviour func(a args)
+	}{
+		// TODO: Add test cases.
+		{
+			name: "success store all payslips in batches",
+			args: args{
+				ctx:    context.Background(),
+				source: newSource(50),
+				total:  50,
+			},
+			behaviour: func(a args) {
+				var mu sync.Mutex
+				stored := make(map[string]int)
+				mockPayrollRepo.EXPECT().StorePayslips(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, payslips []models.Payslip) error {
+					if len(payslips) > 7 {
+						t.Errorf("batch size = %d, want at most 7", len(payslips))
+					}
+					mu.Lock()
+					defer mu.Unlock()
+					for _, payslip := range payslips {
+						stored[payslip.UserID]++
+					}
+					return nil
+				}).Times(8) // 50 payslips in batches of 7
+				mockPayrollRepo.EXPECT().UpdatePayrollJobProgress(gomock.Any(), "job-id", 50, gomock.Any(), gomock.Any()).Return(nil).Times(8)
+				t.Cleanup(func() {
+					if len(stored) != 50 {
+						t.Errorf("stored payslips = %d, want 50", len(stored))
+					}
+					for userID, count := range stored {
+						if count != 1 {
+							t.Errorf("payslip of %s stored %d times", userID, count)
+						}
+					}
+				})
+			},
+		},
+		{
+			name: "failed store payslips stops the pipeline",
+			args: args{
+				ctx:    context.Background(),
+				source: newSource(50),
+				total:  50,
+			},
+			wantErr: errStorePayslips,
+			behaviour: func(a args) {
+				mockPayrollRepo.EXPECT().StorePayslips(gomock.Any(), gomock.Any()).Return(errStorePayslips)
+			},
+		},
+		{
+			name: "failed on cancelled context",
+			args: args{
+				ctx: func() context.Context {
+					ctx, cancel := context.WithCancel(context.Background())
+					cancel()
+					return ctx
+				}(),
+				source: newSource(50),
+				total:  50,
+			},
+			wantErr:   context.Canceled,
+			behaviour: func(a args) {},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := NewPayrollLogic(&mockDeps, mockPayrollRepo, nil, nil, nil, nil)
+			tt.behaviour(tt.args)
+			err := logic.runPayslipPipeline(tt.args.ctx, PayrollJob{ID: "job-id"}, tt.args.source, tt.args.total, 0)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("PayrollLogic.runPayslipPipeline() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_periodBounds(t *testing.T) {
+	date := func(s string) time.Time {
+		d, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	tests := []struct {
+		name      string
+		payGroup  PayGroup
+		date      time.Time
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		// TODO: Add test cases.
+		{
+			name:      "monthly on the 26th after the anchor day",
+			payGroup:  PayGroup{Frequency: PayFrequencyMonthly, PeriodAnchorDay: 26},
+			date:      date("2025-05-28"),
+			wantStart: date("2025-05-26"),
+			wantEnd:   date("2025-06-26"),
+		},
+		{
+			name:      "monthly on the 26th before the anchor day",
+			payGroup:  PayGroup{Frequency: PayFrequencyMonthly, PeriodAnchorDay: 26},
+			date:      date("2025-06-10"),
+			wantStart: date("2025-05-26"),
+			wantEnd:   date("2025-06-26"),
+		},
+		{
+			name:      "semimonthly first half",
+			payGroup:  PayGroup{Frequency: PayFrequencySemimonthly, PeriodAnchorDay: 1},
+			date:      date("2025-02-10"),
+			wantStart: date("2025-02-01"),
+			wantEnd:   date("2025-02-16"),
+		},
+		{
+			name:      "semimonthly second half",
+			payGroup:  PayGroup{Frequency: PayFrequencySemimonthly, PeriodAnchorDay: 1},
+			date:      date("2025-02-20"),
+			wantStart: date("2025-02-16"),
+			wantEnd:   date("2025-03-01"),
+		},
+		{
+			name:      "weekly on monday",
+			payGroup:  PayGroup{Frequency: PayFrequencyWeekly, PeriodAnchorDay: 1},
+			date:      date("2025-06-05"), // thursday
+			wantStart: date("2025-06-02"),
+			wantEnd:   date("2025-06-09"),
+		},
+		{
+			name:      "biweekly on friday",
+			payGroup:  PayGroup{Frequency: PayFrequencyBiweekly, PeriodAnchorDay: 5},
+			date:      date("2025-06-05"), // thursday
+			wantStart: date("2025-05-30"),
+			wantEnd:   date("2025-06-13"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := periodBounds(tt.payGroup, tt.date)
+			if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+				t.Errorf("periodBounds() = %s - %s, want %s - %s", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestPayrollScheduler_Run(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := *config.InitConfig(context.Background())
+	cfg.Payroll = &config.Payroll{
+		ScheduleAhead: 72 * time.Hour,
+	}
+	mockDeps := config.CommonDependencies{
+		Config: &cfg,
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	mockPayrollRepo := NewMockPayrollRepositoryInterface(ctrl)
+	mockAuditor := xaudit.NewMockRecorder(ctrl)
+	// the change and its entry are run in the transaction the recorder begins
+	mockAuditor.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, txfunc func(context.Context) error) error {
+		return txfunc(ctx)
+	}).AnyTimes()
+	mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockUserRepo := user.NewMockUserRepositoryInterface(ctrl)
+	mockCompanyRepo := company.NewMockCompanyRepositoryInterface(ctrl)
+	mockPayrollLogic := NewMockPayrollLogicInterface(ctrl)
+	mockNotifier := xnotify.NewMockNotifier(ctrl)
+
+	date := func(s string) time.Time {
+		d, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	companies := []models.Company{{ID: "company-id", Code: models.DefaultCompanyCode}}
+	payGroup := PayGroup{ID: "group-id", Code: "staff", Name: "Staff", Frequency: PayFrequencyMonthly, PeriodAnchorDay: 26, AutoSchedule: true}
+	current := PayrollPeriod{ID: "current-id", PayGroupID: "group-id", StartDate: date("2025-05-26"), EndDate: date("2025-06-26")}
+
+	type args struct {
+		now     time.Time
+		preview bool
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantErr   bool
+		behaviour func(a args)
+	}{
+		// TODO: Add test cases.
+		{
+			name: "success create and activate the first period",
+			args: args{now: date("2025-06-10")},
+			behaviour: func(a args) {
+				mockCompanyRepo.EXPECT().GetCompanies(gomock.Any()).Return(companies, nil)
+				mockPayrollRepo.EXPECT().GetPayGroups(gomock.Any()).Return([]PayGroup{payGroup, {ID: "manual-group-id"}}, nil)
+				mockPayrollRepo.EXPECT().GetLatestPayrollPeriod(gomock.Any(), "group-id").Return(PayrollPeriod{}, xerror.ErrDataNotFound)
+				mockPayrollRepo.EXPECT().CreatePayrollPeriod(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data PayrollPeriod) error {
+					if !data.StartDate.Equal(current.StartDate) || !data.EndDate.Equal(current.EndDate) || !data.Scheduled || data.TotalWorkDays != 23 {
+						t.Errorf("unexpected payroll period: %+v", data)
+					}
+					return nil
+				})
+				mockPayrollRepo.EXPECT().GetActivePayrollPeriod(gomock.Any(), "group-id").Return(PayrollPeriod{}, xerror.ErrDataNotFound)
+				mockPayrollRepo.EXPECT().GetNextPayrollPeriod(gomock.Any(), "group-id", time.Time{}).Return(current, nil)
+				mockPayrollRepo.EXPECT().ActivatePayrollPeriod(gomock.Any(), current).Return(nil)
+			},
+		},
+		{
+			name: "success create the next period ahead and notify the ended period with preview",
+			args: args{now: date("2025-06-26"), preview: true},
+			behaviour: func(a args) {
+				mockCompanyRepo.EXPECT().GetCompanies(gomock.Any()).Return(companies, nil)
+				mockPayrollRepo.EXPECT().GetPayGroups(gomock.Any()).Return([]PayGroup{payGroup}, nil)
+				mockPayrollRepo.EXPECT().GetLatestPayrollPeriod(gomock.Any(), "group-id").Return(current, nil)
+				mockPayrollRepo.EXPECT().CreatePayrollPeriod(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data PayrollPeriod) error {
+					if !data.StartDate.Equal(date("2025-06-26")) || !data.EndDate.Equal(date("2025-07-26")) {
+						t.Errorf("unexpected payroll period: %+v", data)
+					}
+					return nil
+				})
+				mockPayrollRepo.EXPECT().GetActivePayrollPeriod(gomock.Any(), "group-id").Return(current, nil)
+				mockUserRepo.EXPECT().GetAdminUsernames(gomock.Any()).Return([]string{"admin"}, nil)
+				mockPayrollLogic.EXPECT().PreviewPayrollPeriod(gomock.Any(), current).Return(PayrollPreview{TotalPayslips: 2, TotalTakeHomePay: 3000}, nil)
+				mockNotifier.EXPECT().Notify(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, msg xnotify.Message) error {
+					if !reflect.DeepEqual(msg.Recipients, []string{"admin"}) || !strings.Contains(msg.Body, "2 payslips") {
+						t.Errorf("unexpected message: %+v", msg)
+					}
+					return nil
+				})
+				mockPayrollRepo.EXPECT().MarkPayrollReadyNotified(gomock.Any(), "current-id").Return(nil)
+			},
+		},
+		{
+			name: "success keep the unprocessed period active and skip notified period",
+			args: args{now: date("2025-06-27")},
+			behaviour: func(a args) {
+				notifiedAt := date("2025-06-26")
+				notified := current
+				notified.ReadyNotifiedAt = &notifiedAt
+				mockCompanyRepo.EXPECT().GetCompanies(gomock.Any()).Return(companies, nil)
+				mockPayrollRepo.EXPECT().GetPayGroups(gomock.Any()).Return([]PayGroup{payGroup}, nil)
+				mockPayrollRepo.EXPECT().GetLatestPayrollPeriod(gomock.Any(), "group-id").Return(PayrollPeriod{StartDate: date("2025-06-26"), EndDate: date("2025-07-26")}, nil)
+				mockPayrollRepo.EXPECT().GetActivePayrollPeriod(gomock.Any(), "group-id").Return(notified, nil)
+			},
+		},
+		{
+			name:    "failed notify admins",
+			args:    args{now: date("2025-06-26")},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockCompanyRepo.EXPECT().GetCompanies(gomock.Any()).Return(companies, nil)
+				mockPayrollRepo.EXPECT().GetPayGroups(gomock.Any()).Return([]PayGroup{payGroup}, nil)
+				mockPayrollRepo.EXPECT().GetLatestPayrollPeriod(gomock.Any(), "group-id").Return(PayrollPeriod{StartDate: date("2025-06-26"), EndDate: date("2025-07-26")}, nil)
+				mockPayrollRepo.EXPECT().GetActivePayrollPeriod(gomock.Any(), "group-id").Return(current, nil)
+				mockUserRepo.EXPECT().GetAdminUsernames(gomock.Any()).Return([]string{"admin"}, nil)
+				mockNotifier.EXPECT().Notify(gomock.Any(), gomock.Any()).Return(errors.New("webhook error"))
+			},
+		},
+		{
+			name:    "failed company doesn't hold the next company back",
+			args:    args{now: date("2025-06-27")},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockCompanyRepo.EXPECT().GetCompanies(gomock.Any()).Return([]models.Company{{ID: "company-a", Code: "a"}, {ID: "company-b", Code: "b"}}, nil)
+				mockPayrollRepo.EXPECT().GetPayGroups(gomock.Any()).DoAndReturn(func(ctx context.Context) ([]PayGroup, error) {
+					if xcontext.GetCompanyIDFromContext(ctx) != "company-a" {
+						t.Errorf("unexpected company %q", xcontext.GetCompanyIDFromContext(ctx))
+					}
+					return nil, errors.New("db error")
+				})
+				mockPayrollRepo.EXPECT().GetPayGroups(gomock.Any()).DoAndReturn(func(ctx context.Context) ([]PayGroup, error) {
+					if xcontext.GetCompanyIDFromContext(ctx) != "company-b" {
+						t.Errorf("unexpected company %q", xcontext.GetCompanyIDFromContext(ctx))
+					}
+					return []PayGroup{}, nil
+				})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheduler := NewPayrollScheduler(&mockDeps, mockPayrollRepo, mockUserRepo, mockCompanyRepo, mockPayrollLogic, mockNotifier, mockAuditor)
+			tt.behaviour(tt.args)
+			if err := scheduler.Run(context.Background(), tt.args.now, tt.args.preview); (err != nil) != tt.wantErr {
+				t.Errorf("PayrollScheduler.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+var errStorePayslips = errors.New("db error")
+
+// BenchmarkPayrollLogic_ProcessPayrollJob runs whole payroll jobs against in-memory repositories and
+// reports the peak heap, which should stay flat with the headcount as long as the chunk size is fixed.
+// run with: go test ./internal/payroll/ -run ^$ -bench ProcessPayrollJob -benchtime 3x
+func BenchmarkPayrollLogic_ProcessPayrollJob(b *testing.B) {
+	benchmarks := []struct {
+		name      string
+		employees int
+		chunkSize int
+	}{
+		{name: "employees=10000/chunk=1000", employees: 10000, chunkSize: 1000},
+		{name: "employees=100000/chunk=1000", employees: 100000, chunkSize: 1000},
+		// reads the whole period at once like the unchunked implementation did
+		{name: "employees=100000/chunk=100000", employees: 100000, chunkSize: 100000},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			ctrl := gomock.NewController(b)
+			defer ctrl.Finish()
+
+			cfg := *config.InitConfig(context.Background())
+			cfg.Payroll = &config.Payroll{
+				WorkerCount:      20,
+				PayslipBatchSize: 500,
+				ChunkSize:        bm.chunkSize,
+			}
+			mockDeps := config.CommonDependencies{
+				Config: &cfg,
+				Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+			}
+
+			mockPayrollRepo := NewMockPayrollRepositoryInterface(ctrl)
+			mockAuditor := xaudit.NewMockRecorder(ctrl)
+			// the change and its entry are run in the transaction the recorder begins
+			mockAuditor.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, txfunc func(context.Context) error) error {
+				return txfunc(ctx)
+			}).AnyTimes()
+			mockMails := xmail.NewMockQueue(ctrl)
+			mockMails.EXPECT().EnqueuePayslipEmails(gomock.Any(), "payroll-id").Return(nil).AnyTimes()
+			mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			mockUserRepo := user.NewMockUserRepositoryInterface(ctrl)
+			mockAttRepo := attendance.NewMockAttendanceRepositoryInterface(ctrl)
+			mockBenchmarkPayrollRepositories(bm.employees, mockPayrollRepo, mockUserRepo, mockAttRepo)
+
+			logic := NewPayrollLogic(&mockDeps, mockPayrollRepo, mockUserRepo, mockAttRepo, mockAuditor, mockMails)
+			job := PayrollJob{ID: "job-id", PayrollID: "payroll-id"}
+
+			var peak uint64
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				runtime.GC()
+				stop := samplePeakHeap()
+				err := logic.ProcessPayrollJob(context.Background(), job)
+				peak = max(peak, stop())
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(peak)/(1<<20), "peak-heap-MB")
+		})
+	}
+}
+
+// mockBenchmarkPayrollRepositories serves n employees, each with attendance, overtime,
+// reimbursement and salary data, generated per requested chunk
+func mockBenchmarkPayrollRepositories(n int, payrollRepo *MockPayrollRepositoryInterface, userRepo *user.MockUserRepositoryInterface, attRepo *attendance.MockAttendanceRepositoryInterface) {
+	payrollRepo.EXPECT().GetPayrollPeriodByID(gomock.Any(), "payroll-id").Return(PayrollPeriod{ID: "payroll-id", PayGroupID: "group-id", TotalWorkDays: 20}, nil).AnyTimes()
+	userRepo.EXPECT().CountEligibleUsers(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(n, nil).AnyTimes()
+	payrollRepo.EXPECT().CountPayslips(gomock.Any(), "payroll-id").Return(0, nil).AnyTimes()
+	userRepo.EXPECT().GetEligibleUserIDs(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, payGroupID string, statuses []string, start, end time.Time, afterUserID string, limit int) ([]string, error) {
+			from := 0
+			if afterUserID != "" {
+				fmt.Sscanf(afterUserID, "user-%d", &from)
+				from++
+			}
+			userIDs := make([]string, 0, limit)
+			for i := from; i < n && len(userIDs) < limit; i++ {
+				userIDs = append(userIDs, fmt.Sprintf("user-%07d", i))
+			}
+			return userIDs, nil
+		}).AnyTimes()
+	payrollRepo.EXPECT().GetPayslipUserIDs(gomock.Any(), "payroll-id", gomock.Any()).Return(nil, nil).AnyTimes()
+	attRepo.EXPECT().GetUsersAttendancesByPeriod(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, userIDs []string, start, end time.Time) ([]models.Attendance, error) {
+			result := make([]models.Attendance, len(userIDs))
+			for i, id := range userIDs {
+				result[i] = models.Attendance{UserID: id, Count: 18}
+			}
+			return result, nil
+		}).AnyTimes()
+	attRepo.EXPECT().GetUsersOvertimesByPeriod(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, userIDs []string, start, end time.Time) ([]models.Overtime, error) {
+			result := make([]models.Overtime, len(userIDs))
+			for i, id := range userIDs {
+				result[i] = models.Overtime{UserID: id, Count: 6}
+			}
+			return result, nil
+		}).AnyTimes()
+	attRepo.EXPECT().GetUsersReimbursementsByPeriod(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, userIDs []string, start, end time.Time) ([]models.Reimbursement, error) {
+			result := make([]models.Reimbursement, len(userIDs))
+			for i, id := range userIDs {
+				result[i] = models.Reimbursement{ID: "reimbursement-" + id, UserID: id, Amount: 150000, Description: "lunch", Category: "meals", CategoryName: "Meals", Taxable: true}
+			}
+			return result, nil
+		}).AnyTimes()
+	attRepo.EXPECT().GetUsersLeaveDaysByPeriod(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, userIDs []string, start, end time.Time) ([]models.LeaveDays, error) {
+			result := make([]models.LeaveDays, len(userIDs))
+			for i, id := range userIDs {
+				result[i] = models.LeaveDays{UserID: id, PaidDays: 2}
+			}
+			return result, nil
+		}).AnyTimes()
+	userRepo.EXPECT().GetUsersSalaryByIDs(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, userIDs []string) ([]models.UserSalary, error) {
+			result := make([]models.UserSalary, len(userIDs))
+			for i, id := range userIDs {
+				result[i] = models.UserSalary{UserID: id, Salary: 10000000}
+			}
+			return result, nil
+		}).AnyTimes()
+	userRepo.EXPECT().GetUsersEmploymentByIDs(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, userIDs []string) ([]models.Employment, error) {
+			result := make([]models.Employment, len(userIDs))
+			for i, id := range userIDs {
+				result[i] = models.Employment{UserID: id, Status: models.EmploymentActive, AnnualLeaveDays: 12}
+			}
+			return result, nil
+		}).AnyTimes()
+	payrollRepo.EXPECT().StorePayslips(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	payrollRepo.EXPECT().UpdatePayrollJobProgress(gomock.Any(), "job-id", n, gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	payrollRepo.EXPECT().GetExchangeRates(gomock.Any(), "payroll-id").Return(nil, nil).AnyTimes()
+	payrollRepo.EXPECT().GetPayrollTotalPaid(gomock.Any(), "payroll-id").Return(map[string]float64{}, nil).AnyTimes()
+	payrollRepo.EXPECT().GetPayslipsAfter(gomock.Any(), "payroll-id", gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, payrollID string, afterUserID string, limit int) ([]models.Payslip, error) {
+			from := 0
+			if afterUserID != "" {
+				fmt.Sscanf(afterUserID, "user-%d", &from)
+				from++
+			}
+			payslips := make([]models.Payslip, 0, limit)
+			for i := from; i < n && len(payslips) < limit; i++ {
+				userID := fmt.Sprintf("user-%07d", i)
+				payslips = append(payslips, models.Payslip{ID: "payslip-" + userID, UserID: userID, PayrollID: payrollID, BaseSalary: 10000000, TakeHomePay: 10000000})
+			}
+			return payslips, nil
+		}).AnyTimes()
+	payrollRepo.EXPECT().StorePayslipHashes(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	payrollRepo.EXPECT().MarkPayrollProcessed(gomock.Any(), "payroll-id", gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	payrollRepo.EXPECT().FinishPayrollJob(gomock.Any(), "job-id", PayrollJobCompleted, gomock.Any()).Return(nil).AnyTimes()
+}
+
+// samplePeakHeap samples the heap until the returned func is called, which returns the peak seen
+func samplePeakHeap() func() uint64 {
+	samples := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	done := make(chan struct{})
+	result := make(chan uint64)
+	go func() {
+		var peak uint64
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			metrics.Read(samples)
+			peak = max(peak, samples[0].Value.Uint64())
+			select {
+			case <-ticker.C:
+			case <-done:
+				result <- peak
+				return
+			}
+		}
+	}()
+
+	return func() uint64 {
+		close(done)
+		return <-result
+	}
+}
@@ -42,19 +42,358 @@ func (m *MockPayrollRepositoryInterface) EXPECT() *MockPayrollRepositoryInterfac
 	return m.recorder
 }
 
+// ActivatePayrollPeriod mocks base method.
+func (m *MockPayrollRepositoryInterface) ActivatePayrollPeriod(ctx context.Context, data PayrollPeriod) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ActivatePayrollPeriod", ctx, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ActivatePayrollPeriod indicates an expected call of ActivatePayrollPeriod.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) ActivatePayrollPeriod(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActivatePayrollPeriod", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).ActivatePayrollPeriod), ctx, data)
+}
+
+// AssignPayGroupUsers mocks base method.
+func (m *MockPayrollRepositoryInterface) AssignPayGroupUsers(ctx context.Context, payGroupID string, userIDs []string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignPayGroupUsers", ctx, payGroupID, userIDs)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AssignPayGroupUsers indicates an expected call of AssignPayGroupUsers.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) AssignPayGroupUsers(ctx, payGroupID, userIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignPayGroupUsers", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).AssignPayGroupUsers), ctx, payGroupID, userIDs)
+}
+
+// ClaimPayrollJob mocks base method.
+func (m *MockPayrollRepositoryInterface) ClaimPayrollJob(ctx context.Context, staleAfter time.Duration) (PayrollJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimPayrollJob", ctx, staleAfter)
+	ret0, _ := ret[0].(PayrollJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimPayrollJob indicates an expected call of ClaimPayrollJob.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) ClaimPayrollJob(ctx, staleAfter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimPayrollJob", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).ClaimPayrollJob), ctx, staleAfter)
+}
+
+// CountPayslips mocks base method.
+func (m *MockPayrollRepositoryInterface) CountPayslips(ctx context.Context, payrollID string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountPayslips", ctx, payrollID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountPayslips indicates an expected call of CountPayslips.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) CountPayslips(ctx, payrollID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountPayslips", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).CountPayslips), ctx, payrollID)
+}
+
+// CreateDeduction mocks base method.
+func (m *MockPayrollRepositoryInterface) CreateDeduction(ctx context.Context, data models.Deduction) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDeduction", ctx, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateDeduction indicates an expected call of CreateDeduction.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) CreateDeduction(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDeduction", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).CreateDeduction), ctx, data)
+}
+
+// CreatePayGroup mocks base method.
+func (m *MockPayrollRepositoryInterface) CreatePayGroup(ctx context.Context, data PayGroup) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePayGroup", ctx, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePayGroup indicates an expected call of CreatePayGroup.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) CreatePayGroup(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePayGroup", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).CreatePayGroup), ctx, data)
+}
+
+// CreatePayrollJob mocks base method.
+func (m *MockPayrollRepositoryInterface) CreatePayrollJob(ctx context.Context, job PayrollJob) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePayrollJob", ctx, job)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePayrollJob indicates an expected call of CreatePayrollJob.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) CreatePayrollJob(ctx, job any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePayrollJob", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).CreatePayrollJob), ctx, job)
+}
+
+// CreatePayrollPeriod mocks base method.
+func (m *MockPayrollRepositoryInterface) CreatePayrollPeriod(ctx context.Context, data PayrollPeriod) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePayrollPeriod", ctx, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePayrollPeriod indicates an expected call of CreatePayrollPeriod.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) CreatePayrollPeriod(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePayrollPeriod", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).CreatePayrollPeriod), ctx, data)
+}
+
+// FinishPayrollJob mocks base method.
+func (m *MockPayrollRepositoryInterface) FinishPayrollJob(ctx context.Context, id, status string, jobErrors []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishPayrollJob", ctx, id, status, jobErrors)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FinishPayrollJob indicates an expected call of FinishPayrollJob.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) FinishPayrollJob(ctx, id, status, jobErrors any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishPayrollJob", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).FinishPayrollJob), ctx, id, status, jobErrors)
+}
+
 // GetActivePayrollPeriod mocks base method.
-func (m *MockPayrollRepositoryInterface) GetActivePayrollPeriod(ctx context.Context) (PayrollPeriod, error) {
+func (m *MockPayrollRepositoryInterface) GetActivePayrollPeriod(ctx context.Context, payGroupID string) (PayrollPeriod, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetActivePayrollPeriod", ctx)
+	ret := m.ctrl.Call(m, "GetActivePayrollPeriod", ctx, payGroupID)
 	ret0, _ := ret[0].(PayrollPeriod)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetActivePayrollPeriod indicates an expected call of GetActivePayrollPeriod.
-func (mr *MockPayrollRepositoryInterfaceMockRecorder) GetActivePayrollPeriod(ctx any) *gomock.Call {
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) GetActivePayrollPeriod(ctx, payGroupID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActivePayrollPeriod", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).GetActivePayrollPeriod), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActivePayrollPeriod", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).GetActivePayrollPeriod), ctx, payGroupID)
+}
+
+// GetExchangeRates mocks base method.
+func (m *MockPayrollRepositoryInterface) GetExchangeRates(ctx context.Context, payrollID string) (map[string]float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExchangeRates", ctx, payrollID)
+	ret0, _ := ret[0].(map[string]float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExchangeRates indicates an expected call of GetExchangeRates.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) GetExchangeRates(ctx, payrollID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExchangeRates", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).GetExchangeRates), ctx, payrollID)
+}
+
+// GetJournalAccounts mocks base method.
+func (m *MockPayrollRepositoryInterface) GetJournalAccounts(ctx context.Context) ([]JournalAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJournalAccounts", ctx)
+	ret0, _ := ret[0].([]JournalAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJournalAccounts indicates an expected call of GetJournalAccounts.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) GetJournalAccounts(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJournalAccounts", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).GetJournalAccounts), ctx)
+}
+
+// GetLatestPayrollPeriod mocks base method.
+func (m *MockPayrollRepositoryInterface) GetLatestPayrollPeriod(ctx context.Context, payGroupID string) (PayrollPeriod, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatestPayrollPeriod", ctx, payGroupID)
+	ret0, _ := ret[0].(PayrollPeriod)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestPayrollPeriod indicates an expected call of GetLatestPayrollPeriod.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) GetLatestPayrollPeriod(ctx, payGroupID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestPayrollPeriod", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).GetLatestPayrollPeriod), ctx, payGroupID)
+}
+
+// GetNextPayrollPeriod mocks base method.
+func (m *MockPayrollRepositoryInterface) GetNextPayrollPeriod(ctx context.Context, payGroupID string, from time.Time) (PayrollPeriod, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNextPayrollPeriod", ctx, payGroupID, from)
+	ret0, _ := ret[0].(PayrollPeriod)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNextPayrollPeriod indicates an expected call of GetNextPayrollPeriod.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) GetNextPayrollPeriod(ctx, payGroupID, from any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNextPayrollPeriod", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).GetNextPayrollPeriod), ctx, payGroupID, from)
+}
+
+// GetOverlappingPayrollPeriods mocks base method.
+func (m *MockPayrollRepositoryInterface) GetOverlappingPayrollPeriods(ctx context.Context, payGroupID string, start, end time.Time) ([]PayrollPeriod, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOverlappingPayrollPeriods", ctx, payGroupID, start, end)
+	ret0, _ := ret[0].([]PayrollPeriod)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOverlappingPayrollPeriods indicates an expected call of GetOverlappingPayrollPeriods.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) GetOverlappingPayrollPeriods(ctx, payGroupID, start, end any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOverlappingPayrollPeriods", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).GetOverlappingPayrollPeriods), ctx, payGroupID, start, end)
+}
+
+// GetPayGroupByCode mocks base method.
+func (m *MockPayrollRepositoryInterface) GetPayGroupByCode(ctx context.Context, code string) (PayGroup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPayGroupByCode", ctx, code)
+	ret0, _ := ret[0].(PayGroup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPayGroupByCode indicates an expected call of GetPayGroupByCode.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) GetPayGroupByCode(ctx, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPayGroupByCode", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).GetPayGroupByCode), ctx, code)
+}
+
+// GetPayGroupByID mocks base method.
+func (m *MockPayrollRepositoryInterface) GetPayGroupByID(ctx context.Context, id string) (PayGroup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPayGroupByID", ctx, id)
+	ret0, _ := ret[0].(PayGroup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPayGroupByID indicates an expected call of GetPayGroupByID.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) GetPayGroupByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPayGroupByID", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).GetPayGroupByID), ctx, id)
+}
+
+// GetPayGroups mocks base method.
+func (m *MockPayrollRepositoryInterface) GetPayGroups(ctx context.Context) ([]PayGroup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPayGroups", ctx)
+	ret0, _ := ret[0].([]PayGroup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPayGroups indicates an expected call of GetPayGroups.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) GetPayGroups(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPayGroups", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).GetPayGroups), ctx)
+}
+
+// GetPayrollJobByID mocks base method.
+func (m *MockPayrollRepositoryInterface) GetPayrollJobByID(ctx context.Context, id string) (PayrollJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPayrollJobByID", ctx, id)
+	ret0, _ := ret[0].(PayrollJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPayrollJobByID indicates an expected call of GetPayrollJobByID.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) GetPayrollJobByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPayrollJobByID", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).GetPayrollJobByID), ctx, id)
+}
+
+// GetPayrollPeriodByID mocks base method.
+func (m *MockPayrollRepositoryInterface) GetPayrollPeriodByID(ctx context.Context, id string) (PayrollPeriod, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPayrollPeriodByID", ctx, id)
+	ret0, _ := ret[0].(PayrollPeriod)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPayrollPeriodByID indicates an expected call of GetPayrollPeriodByID.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) GetPayrollPeriodByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPayrollPeriodByID", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).GetPayrollPeriodByID), ctx, id)
+}
+
+// GetPayrollPeriodCurrencies mocks base method.
+func (m *MockPayrollRepositoryInterface) GetPayrollPeriodCurrencies(ctx context.Context, period PayrollPeriod, statuses []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPayrollPeriodCurrencies", ctx, period, statuses)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPayrollPeriodCurrencies indicates an expected call of GetPayrollPeriodCurrencies.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) GetPayrollPeriodCurrencies(ctx, period, statuses any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPayrollPeriodCurrencies", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).GetPayrollPeriodCurrencies), ctx, period, statuses)
+}
+
+// GetPayrollTotalPaid mocks base method.
+func (m *MockPayrollRepositoryInterface) GetPayrollTotalPaid(ctx context.Context, payrollID string) (map[string]float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPayrollTotalPaid", ctx, payrollID)
+	ret0, _ := ret[0].(map[string]float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPayrollTotalPaid indicates an expected call of GetPayrollTotalPaid.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) GetPayrollTotalPaid(ctx, payrollID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPayrollTotalPaid", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).GetPayrollTotalPaid), ctx, payrollID)
+}
+
+// GetPayslipUserIDs mocks base method.
+func (m *MockPayrollRepositoryInterface) GetPayslipUserIDs(ctx context.Context, payrollID string, userIDs []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPayslipUserIDs", ctx, payrollID, userIDs)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPayslipUserIDs indicates an expected call of GetPayslipUserIDs.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) GetPayslipUserIDs(ctx, payrollID, userIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPayslipUserIDs", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).GetPayslipUserIDs), ctx, payrollID, userIDs)
+}
+
+// GetPayslipsAfter mocks base method.
+func (m *MockPayrollRepositoryInterface) GetPayslipsAfter(ctx context.Context, payrollID, afterUserID string, limit int) ([]models.Payslip, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPayslipsAfter", ctx, payrollID, afterUserID, limit)
+	ret0, _ := ret[0].([]models.Payslip)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPayslipsAfter indicates an expected call of GetPayslipsAfter.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) GetPayslipsAfter(ctx, payrollID, afterUserID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPayslipsAfter", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).GetPayslipsAfter), ctx, payrollID, afterUserID, limit)
 }
 
 // GetPayslipsSummary mocks base method.
@@ -72,6 +411,51 @@ func (mr *MockPayrollRepositoryInterfaceMockRecorder) GetPayslipsSummary(ctx, pa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPayslipsSummary", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).GetPayslipsSummary), ctx, payrollID)
 }
 
+// GetPreviousPayrollPeriod mocks base method.
+func (m *MockPayrollRepositoryInterface) GetPreviousPayrollPeriod(ctx context.Context, payGroupID string, before time.Time) (PayrollPeriod, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPreviousPayrollPeriod", ctx, payGroupID, before)
+	ret0, _ := ret[0].(PayrollPeriod)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPreviousPayrollPeriod indicates an expected call of GetPreviousPayrollPeriod.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) GetPreviousPayrollPeriod(ctx, payGroupID, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPreviousPayrollPeriod", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).GetPreviousPayrollPeriod), ctx, payGroupID, before)
+}
+
+// GetUnfinishedPayrollJob mocks base method.
+func (m *MockPayrollRepositoryInterface) GetUnfinishedPayrollJob(ctx context.Context, payrollID string) (PayrollJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUnfinishedPayrollJob", ctx, payrollID)
+	ret0, _ := ret[0].(PayrollJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUnfinishedPayrollJob indicates an expected call of GetUnfinishedPayrollJob.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) GetUnfinishedPayrollJob(ctx, payrollID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUnfinishedPayrollJob", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).GetUnfinishedPayrollJob), ctx, payrollID)
+}
+
+// GetUserActivePayrollPeriod mocks base method.
+func (m *MockPayrollRepositoryInterface) GetUserActivePayrollPeriod(ctx context.Context, userID string) (PayrollPeriod, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserActivePayrollPeriod", ctx, userID)
+	ret0, _ := ret[0].(PayrollPeriod)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserActivePayrollPeriod indicates an expected call of GetUserActivePayrollPeriod.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) GetUserActivePayrollPeriod(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserActivePayrollPeriod", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).GetUserActivePayrollPeriod), ctx, userID)
+}
+
 // GetUserPayslipByID mocks base method.
 func (m *MockPayrollRepositoryInterface) GetUserPayslipByID(ctx context.Context, userID, payrollID string) (models.Payslip, error) {
 	m.ctrl.T.Helper()
@@ -87,18 +471,89 @@ func (mr *MockPayrollRepositoryInterfaceMockRecorder) GetUserPayslipByID(ctx, us
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserPayslipByID", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).GetUserPayslipByID), ctx, userID, payrollID)
 }
 
+// GetUsersOutstandingDeductions mocks base method.
+func (m *MockPayrollRepositoryInterface) GetUsersOutstandingDeductions(ctx context.Context, userIDs []string) ([]models.Deduction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsersOutstandingDeductions", ctx, userIDs)
+	ret0, _ := ret[0].([]models.Deduction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsersOutstandingDeductions indicates an expected call of GetUsersOutstandingDeductions.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) GetUsersOutstandingDeductions(ctx, userIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersOutstandingDeductions", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).GetUsersOutstandingDeductions), ctx, userIDs)
+}
+
 // MarkPayrollProcessed mocks base method.
-func (m *MockPayrollRepositoryInterface) MarkPayrollProcessed(ctx context.Context, id string, totalPaid float64) error {
+func (m *MockPayrollRepositoryInterface) MarkPayrollProcessed(ctx context.Context, id string, totalPaid float64, totalPaidByCurrency map[string]float64, chain PayslipChain) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "MarkPayrollProcessed", ctx, id, totalPaid)
+	ret := m.ctrl.Call(m, "MarkPayrollProcessed", ctx, id, totalPaid, totalPaidByCurrency, chain)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // MarkPayrollProcessed indicates an expected call of MarkPayrollProcessed.
-func (mr *MockPayrollRepositoryInterfaceMockRecorder) MarkPayrollProcessed(ctx, id, totalPaid any) *gomock.Call {
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) MarkPayrollProcessed(ctx, id, totalPaid, totalPaidByCurrency, chain any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkPayrollProcessed", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).MarkPayrollProcessed), ctx, id, totalPaid)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkPayrollProcessed", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).MarkPayrollProcessed), ctx, id, totalPaid, totalPaidByCurrency, chain)
+}
+
+// MarkPayrollReadyNotified mocks base method.
+func (m *MockPayrollRepositoryInterface) MarkPayrollReadyNotified(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkPayrollReadyNotified", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkPayrollReadyNotified indicates an expected call of MarkPayrollReadyNotified.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) MarkPayrollReadyNotified(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkPayrollReadyNotified", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).MarkPayrollReadyNotified), ctx, id)
+}
+
+// ReleasePayrollJob mocks base method.
+func (m *MockPayrollRepositoryInterface) ReleasePayrollJob(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleasePayrollJob", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleasePayrollJob indicates an expected call of ReleasePayrollJob.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) ReleasePayrollJob(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleasePayrollJob", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).ReleasePayrollJob), ctx, id)
+}
+
+// SetExchangeRates mocks base method.
+func (m *MockPayrollRepositoryInterface) SetExchangeRates(ctx context.Context, payrollID string, rates map[string]float64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetExchangeRates", ctx, payrollID, rates)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetExchangeRates indicates an expected call of SetExchangeRates.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) SetExchangeRates(ctx, payrollID, rates any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetExchangeRates", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).SetExchangeRates), ctx, payrollID, rates)
+}
+
+// SetJournalAccounts mocks base method.
+func (m *MockPayrollRepositoryInterface) SetJournalAccounts(ctx context.Context, accounts []JournalAccount) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetJournalAccounts", ctx, accounts)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetJournalAccounts indicates an expected call of SetJournalAccounts.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) SetJournalAccounts(ctx, accounts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetJournalAccounts", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).SetJournalAccounts), ctx, accounts)
 }
 
 // SetPayrollPeriod mocks base method.
@@ -115,18 +570,60 @@ func (mr *MockPayrollRepositoryInterfaceMockRecorder) SetPayrollPeriod(ctx, data
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPayrollPeriod", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).SetPayrollPeriod), ctx, data)
 }
 
-// StorePayslip mocks base method.
-func (m *MockPayrollRepositoryInterface) StorePayslip(ctx context.Context, payslip models.Payslip) error {
+// StorePayslipHashes mocks base method.
+func (m *MockPayrollRepositoryInterface) StorePayslipHashes(ctx context.Context, payslips []models.Payslip) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StorePayslipHashes", ctx, payslips)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StorePayslipHashes indicates an expected call of StorePayslipHashes.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) StorePayslipHashes(ctx, payslips any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StorePayslipHashes", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).StorePayslipHashes), ctx, payslips)
+}
+
+// StorePayslips mocks base method.
+func (m *MockPayrollRepositoryInterface) StorePayslips(ctx context.Context, payslips []models.Payslip) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StorePayslips", ctx, payslips)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StorePayslips indicates an expected call of StorePayslips.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) StorePayslips(ctx, payslips any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StorePayslips", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).StorePayslips), ctx, payslips)
+}
+
+// UpdatePayGroup mocks base method.
+func (m *MockPayrollRepositoryInterface) UpdatePayGroup(ctx context.Context, data PayGroup) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "StorePayslip", ctx, payslip)
+	ret := m.ctrl.Call(m, "UpdatePayGroup", ctx, data)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// StorePayslip indicates an expected call of StorePayslip.
-func (mr *MockPayrollRepositoryInterfaceMockRecorder) StorePayslip(ctx, payslip any) *gomock.Call {
+// UpdatePayGroup indicates an expected call of UpdatePayGroup.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) UpdatePayGroup(ctx, data any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StorePayslip", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).StorePayslip), ctx, payslip)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePayGroup", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).UpdatePayGroup), ctx, data)
+}
+
+// UpdatePayrollJobProgress mocks base method.
+func (m *MockPayrollRepositoryInterface) UpdatePayrollJobProgress(ctx context.Context, id string, total, processed int, jobErrors []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePayrollJobProgress", ctx, id, total, processed, jobErrors)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePayrollJobProgress indicates an expected call of UpdatePayrollJobProgress.
+func (mr *MockPayrollRepositoryInterfaceMockRecorder) UpdatePayrollJobProgress(ctx, id, total, processed, jobErrors any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePayrollJobProgress", reflect.TypeOf((*MockPayrollRepositoryInterface)(nil).UpdatePayrollJobProgress), ctx, id, total, processed, jobErrors)
 }
 
 // MockPayrollLogicInterface is a mock of PayrollLogicInterface interface.
@@ -153,6 +650,36 @@ func (m *MockPayrollLogicInterface) EXPECT() *MockPayrollLogicInterfaceMockRecor
 	return m.recorder
 }
 
+// AddDeduction mocks base method.
+func (m *MockPayrollLogicInterface) AddDeduction(ctx context.Context, req DeductionRequest) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddDeduction", ctx, req)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddDeduction indicates an expected call of AddDeduction.
+func (mr *MockPayrollLogicInterfaceMockRecorder) AddDeduction(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddDeduction", reflect.TypeOf((*MockPayrollLogicInterface)(nil).AddDeduction), ctx, req)
+}
+
+// AssignPayGroupUsers mocks base method.
+func (m *MockPayrollLogicInterface) AssignPayGroupUsers(ctx context.Context, payGroupID string, userIDs []string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignPayGroupUsers", ctx, payGroupID, userIDs)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AssignPayGroupUsers indicates an expected call of AssignPayGroupUsers.
+func (mr *MockPayrollLogicInterfaceMockRecorder) AssignPayGroupUsers(ctx, payGroupID, userIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignPayGroupUsers", reflect.TypeOf((*MockPayrollLogicInterface)(nil).AssignPayGroupUsers), ctx, payGroupID, userIDs)
+}
+
 // CalculatePay mocks base method.
 func (m *MockPayrollLogicInterface) CalculatePay(ctx context.Context, data PayrollCalculationData) models.Payslip {
 	m.ctrl.T.Helper()
@@ -168,32 +695,123 @@ func (mr *MockPayrollLogicInterfaceMockRecorder) CalculatePay(ctx, data any) *go
 }
 
 // CalculatePayroll mocks base method.
-func (m *MockPayrollLogicInterface) CalculatePayroll(ctx context.Context) error {
+func (m *MockPayrollLogicInterface) CalculatePayroll(ctx context.Context, payGroupID string) (PayrollJob, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CalculatePayroll", ctx)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "CalculatePayroll", ctx, payGroupID)
+	ret0, _ := ret[0].(PayrollJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // CalculatePayroll indicates an expected call of CalculatePayroll.
-func (mr *MockPayrollLogicInterfaceMockRecorder) CalculatePayroll(ctx any) *gomock.Call {
+func (mr *MockPayrollLogicInterfaceMockRecorder) CalculatePayroll(ctx, payGroupID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CalculatePayroll", reflect.TypeOf((*MockPayrollLogicInterface)(nil).CalculatePayroll), ctx, payGroupID)
+}
+
+// CreatePayGroup mocks base method.
+func (m *MockPayrollLogicInterface) CreatePayGroup(ctx context.Context, req PayGroupRequest) (PayGroup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePayGroup", ctx, req)
+	ret0, _ := ret[0].(PayGroup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePayGroup indicates an expected call of CreatePayGroup.
+func (mr *MockPayrollLogicInterfaceMockRecorder) CreatePayGroup(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePayGroup", reflect.TypeOf((*MockPayrollLogicInterface)(nil).CreatePayGroup), ctx, req)
+}
+
+// GetExchangeRates mocks base method.
+func (m *MockPayrollLogicInterface) GetExchangeRates(ctx context.Context, payrollID string) (ExchangeRates, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExchangeRates", ctx, payrollID)
+	ret0, _ := ret[0].(ExchangeRates)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExchangeRates indicates an expected call of GetExchangeRates.
+func (mr *MockPayrollLogicInterfaceMockRecorder) GetExchangeRates(ctx, payrollID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExchangeRates", reflect.TypeOf((*MockPayrollLogicInterface)(nil).GetExchangeRates), ctx, payrollID)
+}
+
+// GetJournalAccounts mocks base method.
+func (m *MockPayrollLogicInterface) GetJournalAccounts(ctx context.Context) ([]JournalAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJournalAccounts", ctx)
+	ret0, _ := ret[0].([]JournalAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJournalAccounts indicates an expected call of GetJournalAccounts.
+func (mr *MockPayrollLogicInterfaceMockRecorder) GetJournalAccounts(ctx any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CalculatePayroll", reflect.TypeOf((*MockPayrollLogicInterface)(nil).CalculatePayroll), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJournalAccounts", reflect.TypeOf((*MockPayrollLogicInterface)(nil).GetJournalAccounts), ctx)
+}
+
+// GetPayGroups mocks base method.
+func (m *MockPayrollLogicInterface) GetPayGroups(ctx context.Context) ([]PayGroup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPayGroups", ctx)
+	ret0, _ := ret[0].([]PayGroup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPayGroups indicates an expected call of GetPayGroups.
+func (mr *MockPayrollLogicInterfaceMockRecorder) GetPayGroups(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPayGroups", reflect.TypeOf((*MockPayrollLogicInterface)(nil).GetPayGroups), ctx)
+}
+
+// GetPayrollJob mocks base method.
+func (m *MockPayrollLogicInterface) GetPayrollJob(ctx context.Context, jobID string) (PayrollJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPayrollJob", ctx, jobID)
+	ret0, _ := ret[0].(PayrollJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPayrollJob indicates an expected call of GetPayrollJob.
+func (mr *MockPayrollLogicInterfaceMockRecorder) GetPayrollJob(ctx, jobID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPayrollJob", reflect.TypeOf((*MockPayrollLogicInterface)(nil).GetPayrollJob), ctx, jobID)
+}
+
+// GetPayrollJournal mocks base method.
+func (m *MockPayrollLogicInterface) GetPayrollJournal(ctx context.Context, payrollID string) (Journal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPayrollJournal", ctx, payrollID)
+	ret0, _ := ret[0].(Journal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPayrollJournal indicates an expected call of GetPayrollJournal.
+func (mr *MockPayrollLogicInterfaceMockRecorder) GetPayrollJournal(ctx, payrollID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPayrollJournal", reflect.TypeOf((*MockPayrollLogicInterface)(nil).GetPayrollJournal), ctx, payrollID)
 }
 
 // GetPayrollsSummary mocks base method.
-func (m *MockPayrollLogicInterface) GetPayrollsSummary(ctx context.Context) (PayslipSummaryResponse, error) {
+func (m *MockPayrollLogicInterface) GetPayrollsSummary(ctx context.Context, payGroupID string) (PayslipSummaryResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPayrollsSummary", ctx)
+	ret := m.ctrl.Call(m, "GetPayrollsSummary", ctx, payGroupID)
 	ret0, _ := ret[0].(PayslipSummaryResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetPayrollsSummary indicates an expected call of GetPayrollsSummary.
-func (mr *MockPayrollLogicInterfaceMockRecorder) GetPayrollsSummary(ctx any) *gomock.Call {
+func (mr *MockPayrollLogicInterfaceMockRecorder) GetPayrollsSummary(ctx, payGroupID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPayrollsSummary", reflect.TypeOf((*MockPayrollLogicInterface)(nil).GetPayrollsSummary), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPayrollsSummary", reflect.TypeOf((*MockPayrollLogicInterface)(nil).GetPayrollsSummary), ctx, payGroupID)
 }
 
 // GetUserPayslipByID mocks base method.
@@ -211,16 +829,134 @@ func (mr *MockPayrollLogicInterfaceMockRecorder) GetUserPayslipByID(ctx, userID
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserPayslipByID", reflect.TypeOf((*MockPayrollLogicInterface)(nil).GetUserPayslipByID), ctx, userID)
 }
 
+// JobQueued mocks base method.
+func (m *MockPayrollLogicInterface) JobQueued() <-chan struct{} {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JobQueued")
+	ret0, _ := ret[0].(<-chan struct{})
+	return ret0
+}
+
+// JobQueued indicates an expected call of JobQueued.
+func (mr *MockPayrollLogicInterfaceMockRecorder) JobQueued() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JobQueued", reflect.TypeOf((*MockPayrollLogicInterface)(nil).JobQueued))
+}
+
+// PreviewPayroll mocks base method.
+func (m *MockPayrollLogicInterface) PreviewPayroll(ctx context.Context, payGroupID string) (PayrollPreview, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PreviewPayroll", ctx, payGroupID)
+	ret0, _ := ret[0].(PayrollPreview)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PreviewPayroll indicates an expected call of PreviewPayroll.
+func (mr *MockPayrollLogicInterfaceMockRecorder) PreviewPayroll(ctx, payGroupID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PreviewPayroll", reflect.TypeOf((*MockPayrollLogicInterface)(nil).PreviewPayroll), ctx, payGroupID)
+}
+
+// PreviewPayrollPeriod mocks base method.
+func (m *MockPayrollLogicInterface) PreviewPayrollPeriod(ctx context.Context, period PayrollPeriod) (PayrollPreview, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PreviewPayrollPeriod", ctx, period)
+	ret0, _ := ret[0].(PayrollPreview)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PreviewPayrollPeriod indicates an expected call of PreviewPayrollPeriod.
+func (mr *MockPayrollLogicInterfaceMockRecorder) PreviewPayrollPeriod(ctx, period any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PreviewPayrollPeriod", reflect.TypeOf((*MockPayrollLogicInterface)(nil).PreviewPayrollPeriod), ctx, period)
+}
+
+// ProcessPayrollJob mocks base method.
+func (m *MockPayrollLogicInterface) ProcessPayrollJob(ctx context.Context, job PayrollJob) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessPayrollJob", ctx, job)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ProcessPayrollJob indicates an expected call of ProcessPayrollJob.
+func (mr *MockPayrollLogicInterfaceMockRecorder) ProcessPayrollJob(ctx, job any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessPayrollJob", reflect.TypeOf((*MockPayrollLogicInterface)(nil).ProcessPayrollJob), ctx, job)
+}
+
+// SetExchangeRates mocks base method.
+func (m *MockPayrollLogicInterface) SetExchangeRates(ctx context.Context, payrollID string, req ExchangeRatesRequest) (ExchangeRates, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetExchangeRates", ctx, payrollID, req)
+	ret0, _ := ret[0].(ExchangeRates)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetExchangeRates indicates an expected call of SetExchangeRates.
+func (mr *MockPayrollLogicInterfaceMockRecorder) SetExchangeRates(ctx, payrollID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetExchangeRates", reflect.TypeOf((*MockPayrollLogicInterface)(nil).SetExchangeRates), ctx, payrollID, req)
+}
+
+// SetJournalAccounts mocks base method.
+func (m *MockPayrollLogicInterface) SetJournalAccounts(ctx context.Context, req JournalAccountsRequest) ([]JournalAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetJournalAccounts", ctx, req)
+	ret0, _ := ret[0].([]JournalAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetJournalAccounts indicates an expected call of SetJournalAccounts.
+func (mr *MockPayrollLogicInterfaceMockRecorder) SetJournalAccounts(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetJournalAccounts", reflect.TypeOf((*MockPayrollLogicInterface)(nil).SetJournalAccounts), ctx, req)
+}
+
 // SetPayrollPeriod mocks base method.
-func (m *MockPayrollLogicInterface) SetPayrollPeriod(ctx context.Context, start, end time.Time) error {
+func (m *MockPayrollLogicInterface) SetPayrollPeriod(ctx context.Context, req PayrollPeriodRequest) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetPayrollPeriod", ctx, start, end)
+	ret := m.ctrl.Call(m, "SetPayrollPeriod", ctx, req)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SetPayrollPeriod indicates an expected call of SetPayrollPeriod.
-func (mr *MockPayrollLogicInterfaceMockRecorder) SetPayrollPeriod(ctx, start, end any) *gomock.Call {
+func (mr *MockPayrollLogicInterfaceMockRecorder) SetPayrollPeriod(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPayrollPeriod", reflect.TypeOf((*MockPayrollLogicInterface)(nil).SetPayrollPeriod), ctx, req)
+}
+
+// UpdatePayGroup mocks base method.
+func (m *MockPayrollLogicInterface) UpdatePayGroup(ctx context.Context, payGroupID string, req PayGroupRequest) (PayGroup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePayGroup", ctx, payGroupID, req)
+	ret0, _ := ret[0].(PayGroup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePayGroup indicates an expected call of UpdatePayGroup.
+func (mr *MockPayrollLogicInterfaceMockRecorder) UpdatePayGroup(ctx, payGroupID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePayGroup", reflect.TypeOf((*MockPayrollLogicInterface)(nil).UpdatePayGroup), ctx, payGroupID, req)
+}
+
+// VerifyPayslips mocks base method.
+func (m *MockPayrollLogicInterface) VerifyPayslips(ctx context.Context, payrollID, hash string) (PayslipVerification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyPayslips", ctx, payrollID, hash)
+	ret0, _ := ret[0].(PayslipVerification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyPayslips indicates an expected call of VerifyPayslips.
+func (mr *MockPayrollLogicInterfaceMockRecorder) VerifyPayslips(ctx, payrollID, hash any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPayrollPeriod", reflect.TypeOf((*MockPayrollLogicInterface)(nil).SetPayrollPeriod), ctx, start, end)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyPayslips", reflect.TypeOf((*MockPayrollLogicInterface)(nil).VerifyPayslips), ctx, payrollID, hash)
 }
@@ -2,36 +2,88 @@ package payroll
 
 import (
 	"database/sql"
+	"fmt"
 	"time"
+
+	"github.com/rahadianir/dealls/internal/models"
+	"github.com/rahadianir/dealls/internal/pkg/xdate"
 )
 
+var ErrPayrollPeriodOverlap = fmt.Errorf("payroll period overlaps an existing period")
+
 type PayrollPeriodRequest struct {
-	StartDate time.Time `json:"start_date"`
-	EndDate   time.Time `json:"end_date"`
+	PayGroupID string     `json:"pay_group_id"` // default pay group when empty
+	StartDate  xdate.Date `json:"start_date"`
+	EndDate    xdate.Date `json:"end_date"`  // exclusive, follows the pay group period template when empty
+	AllowGap   bool       `json:"allow_gap"` // accept days left unpaid after the previous period
 }
 
+// PayrollPeriod dates are company dates kept as midnight UTC, the end date is exclusive as it's the next period start date
 type PayrollPeriod struct {
 	ID              string
+	PayGroupID      string
 	StartDate       time.Time
 	EndDate         time.Time
 	TotalWorkDays   int
 	Processed       bool
-	TotalSalaryPaid float64
+	TotalSalaryPaid float64    // in the base currency
+	Scheduled       bool       // created by the payroll scheduler
+	ReadyNotifiedAt *time.Time // when admins were notified the period is ready to process
+
+	TotalSalaryPaidByCurrency map[string]float64 // per payout currency
+
+	PayslipChain PayslipChain // head of the payslip hash chain, set once processed
+}
+
+// PayslipChain is the hash of the last payslip of a period in user_id order, signed along with the period id
+type PayslipChain struct {
+	Hash      string
+	Signature string
 }
 
 type SQLPayrollPeriod struct {
 	ID              sql.NullString  `db:"id"`
+	PayGroupID      sql.NullString  `db:"pay_group_id"`
 	StartDate       sql.NullTime    `db:"start_date"`
 	EndDate         sql.NullTime    `db:"end_date"`
 	TotalWorkDays   sql.NullInt64   `db:"total_work_days"`
 	Processed       sql.NullBool    `db:"processed"`
 	TotalSalaryPaid sql.NullFloat64 `db:"total_salary_paid"`
+	Scheduled       sql.NullBool    `db:"scheduled"`
+	ReadyNotifiedAt sql.NullTime    `db:"ready_notified_at"`
+
+	TotalSalaryPaidByCurrency []byte `db:"total_salary_paid_by_currency"`
+
+	PayslipChainHash      sql.NullString `db:"payslip_chain_hash"`
+	PayslipChainSignature sql.NullString `db:"payslip_chain_signature"`
+}
+
+// PayrollPeriodEvent is the data of the payroll_period.* webhook events, the totals are only set once processed
+type PayrollPeriodEvent struct {
+	ID            string `json:"id"`
+	PayGroupID    string `json:"pay_group_id"`
+	StartDate     string `json:"start_date"`
+	EndDate       string `json:"end_date"`
+	TotalWorkDays int    `json:"total_work_days,omitempty"`
+	Scheduled     bool   `json:"scheduled"`
+
+	TotalSalaryPaid           *float64           `json:"total_salary_paid,omitempty"`
+	TotalSalaryPaidByCurrency map[string]float64 `json:"total_salary_paid_by_currency,omitempty"`
+	PayslipChainHash          string             `json:"payslip_chain_hash,omitempty"`
 }
 
 type Reimbursement struct {
-	ID     string
-	Amount float64
-	Desc   string
+	ID           string
+	Amount       float64
+	Desc         string
+	Category     string
+	CategoryName string
+	Taxable      bool
+	Receipts     []models.ReimbursementReceipt
+
+	// the amount is converted to the payout currency from the claimed amount
+	OriginalAmount   float64
+	OriginalCurrency string
 }
 type PayrollCalculationData struct {
 	UserID             string
@@ -40,7 +92,23 @@ type PayrollCalculationData struct {
 	AttendanceCount    int
 	OvertimeHoursCount int
 	Reimbursements     []Reimbursement
-	Salary             float64
+	Salary             float64 // in the payout currency
+	PaidLeaveDays      int
+	UnpaidLeaveDays    int
+
+	// work days of the period outside the user's employment (before hire or after termination)
+	NotEmployedWorkDays int
+
+	// final settlement of an employment terminated within the period
+	FinalSettlement bool
+	UnusedLeaveDays int
+	Deductions      []models.Deduction
+
+	// every amount is calculated in the payout currency, the salary is converted with the period exchange rate
+	SalaryCurrency     string
+	OriginalSalary     float64
+	PayoutCurrency     string
+	SalaryExchangeRate float64
 }
 
 type SQLPayslip struct {
@@ -56,6 +124,46 @@ type SQLPayslip struct {
 	OvertimePay        sql.NullFloat64 `db:"overtime_bonus"`
 	ReimbursementList  []byte          `db:"reimbursement_list"`
 	TotalReimbursement sql.NullFloat64 `db:"total_reimbursement"`
+
+	ReimbursementByCategory   []byte          `db:"reimbursement_by_category"`
+	TotalTaxableReimbursement sql.NullFloat64 `db:"total_taxable_reimbursement"`
+
+	PaidLeaveDays   sql.NullInt64 `db:"paid_leave_days"`
+	UnpaidLeaveDays sql.NullInt64 `db:"unpaid_leave_days"`
+	ReviewReasons   []byte        `db:"review_reasons"`
+
+	Type             sql.NullString  `db:"payslip_type"`
+	EmployedWorkDays sql.NullInt64   `db:"employed_work_days"`
+	UnusedLeaveDays  sql.NullInt64   `db:"unused_leave_days"`
+	LeavePayout      sql.NullFloat64 `db:"leave_payout"`
+	DeductionList    []byte          `db:"deduction_list"`
+	TotalDeduction   sql.NullFloat64 `db:"total_deduction"`
+
+	SalaryCurrency     sql.NullString  `db:"salary_currency"`
+	OriginalSalary     sql.NullFloat64 `db:"original_salary"`
+	PayoutCurrency     sql.NullString  `db:"payout_currency"`
+	SalaryExchangeRate sql.NullFloat64 `db:"salary_exchange_rate"`
+
+	PrevHash  sql.NullString `db:"prev_hash"`
+	Hash      sql.NullString `db:"hash"`
+	Signature sql.NullString `db:"signature"`
+}
+
+type DeductionRequest struct {
+	UserID      string  `json:"user_id"`
+	Amount      float64 `json:"amount"`
+	Description string  `json:"description"`
+}
+
+type DeductionResponse struct {
+	ID string `json:"id"`
+}
+
+type SQLDeduction struct {
+	ID          sql.NullString  `db:"id"`
+	UserID      sql.NullString  `db:"user_id"`
+	Amount      sql.NullFloat64 `db:"amount"`
+	Description sql.NullString  `db:"description"`
 }
 
 type Payslip struct {
@@ -76,14 +184,229 @@ type Payslip struct {
 type PayslipResponse struct {
 	UserID      string  `json:"user_id"`
 	TakeHomePay float64 `json:"take_home_pay"`
+	Currency    string  `json:"currency"`
 	Name        string  `json:"name"`
+	NeedsReview bool    `json:"needs_review"`
+	Hash        string  `json:"hash,omitempty"`
 }
 type PayslipSummaryResponse struct {
-	PayrollID        string            `json:"payroll_id"`
-	TotalTakeHomePay float64           `json:"total_take_home_pay"`
-	Payslips         []PayslipResponse `json:"payslips"`
+	PayrollID                  string             `json:"payroll_id"`
+	PayGroupID                 string             `json:"pay_group_id"`
+	BaseCurrency               string             `json:"base_currency"`
+	TotalTakeHomePay           float64            `json:"total_take_home_pay"` // in the base currency
+	TotalTakeHomePayByCurrency map[string]float64 `json:"total_take_home_pay_by_currency"`
+	TotalNeedsReview           int                `json:"total_needs_review"`
+	Payslips                   []PayslipResponse  `json:"payslips"`
 }
 
 type UserPayslipRequest struct {
 	UserID string `json:"user_id"`
 }
+
+const (
+	PayrollJobPending   = "pending"
+	PayrollJobRunning   = "running"
+	PayrollJobCompleted = "completed"
+	PayrollJobFailed    = "failed"
+)
+
+type PayrollJob struct {
+	ID         string     `json:"id"`
+	CompanyID  string     `json:"-"`
+	PayrollID  string     `json:"payroll_id"`
+	Status     string     `json:"status"`
+	Total      int        `json:"total"`
+	Processed  int        `json:"processed"`
+	Errors     []string   `json:"errors"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	CreatedBy  string     `json:"created_by"`
+}
+
+type SQLPayrollJob struct {
+	ID         sql.NullString `db:"id"`
+	CompanyID  sql.NullString `db:"company_id"`
+	PayrollID  sql.NullString `db:"payroll_id"`
+	Status     sql.NullString `db:"status"`
+	Total      sql.NullInt64  `db:"total"`
+	Processed  sql.NullInt64  `db:"processed"`
+	Errors     []byte         `db:"errors"`
+	StartedAt  sql.NullTime   `db:"started_at"`
+	FinishedAt sql.NullTime   `db:"finished_at"`
+	CreatedAt  sql.NullTime   `db:"created_at"`
+	CreatedBy  sql.NullString `db:"created_by"`
+}
+
+type PayrollJobResponse struct {
+	JobID string `json:"job_id"`
+}
+
+const (
+	DefaultPayGroupCode = "default"
+
+	PayFrequencyMonthly     = "monthly"
+	PayFrequencySemimonthly = "semimonthly"
+	PayFrequencyBiweekly    = "biweekly"
+	PayFrequencyWeekly      = "weekly"
+)
+
+// PayGroup is a group of users sharing the same payroll period schedule.
+// The frequency and anchor day make up the period template, e.g. monthly on the 26th runs from the 26th to the 25th.
+type PayGroup struct {
+	ID        string `json:"id"`
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+	Frequency string `json:"frequency"`
+
+	// day of month for monthly (1-28) and semimonthly (1-13, the second half starts 15 days later),
+	// ISO weekday for weekly and biweekly (1 is monday)
+	PeriodAnchorDay int `json:"period_anchor_day"`
+
+	// periods are created and activated by the payroll scheduler
+	AutoSchedule bool `json:"auto_schedule"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type SQLPayGroup struct {
+	ID              sql.NullString `db:"id"`
+	Code            sql.NullString `db:"code"`
+	Name            sql.NullString `db:"name"`
+	Frequency       sql.NullString `db:"frequency"`
+	PeriodAnchorDay sql.NullInt64  `db:"period_anchor_day"`
+	AutoSchedule    sql.NullBool   `db:"auto_schedule"`
+	CreatedAt       sql.NullTime   `db:"created_at"`
+}
+
+type PayGroupRequest struct {
+	Code            string `json:"code"` // ignored on update
+	Name            string `json:"name"`
+	Frequency       string `json:"frequency"`
+	PeriodAnchorDay int    `json:"period_anchor_day"` // 1 when empty
+	AutoSchedule    bool   `json:"auto_schedule"`
+}
+
+type PayGroupMembersRequest struct {
+	UserIDs []string `json:"user_ids"`
+}
+
+type PayGroupMembersResponse struct {
+	Assigned int `json:"assigned"`
+}
+
+// PayrollPreview sums up the payslips of a period without storing them
+type PayrollPreview struct {
+	PayrollID                  string             `json:"payroll_id"`
+	PayGroupID                 string             `json:"pay_group_id"`
+	StartDate                  xdate.Date         `json:"start_date"`
+	EndDate                    xdate.Date         `json:"end_date"`
+	BaseCurrency               string             `json:"base_currency"`
+	TotalPayslips              int                `json:"total_payslips"`
+	TotalTakeHomePay           float64            `json:"total_take_home_pay"` // in the base currency
+	TotalTakeHomePayByCurrency map[string]float64 `json:"total_take_home_pay_by_currency"`
+	TotalNeedsReview           int                `json:"total_needs_review"`
+	TotalFinalSettlement       int                `json:"total_final_settlement"`
+}
+
+type ExchangeRatesRequest struct {
+	Rates map[string]float64 `json:"rates"` // base currency amount of 1 unit of the currency, e.g. {"USD": 16250}
+}
+
+// ExchangeRates of a payroll period convert salaries and reimbursements to the payout currencies through the base currency
+type ExchangeRates struct {
+	PayrollID    string             `json:"payroll_id"`
+	BaseCurrency string             `json:"base_currency"`
+	Rates        map[string]float64 `json:"rates"`
+}
+
+type PayslipVerification struct {
+	PayrollID       string           `json:"payroll_id"`
+	Valid           bool             `json:"valid"`
+	Payslips        int              `json:"payslips"`
+	ChainHash       string           `json:"chain_hash"`
+	ChainHeadValid  bool             `json:"chain_head_valid"` // false when payslips were removed from the end or the head was changed
+	InvalidPayslips []InvalidPayslip `json:"invalid_payslips"`
+
+	// whether the hash asked for belongs to a valid payslip of the chain, left out when no hash is asked for
+	HashFound *bool `json:"hash_found,omitempty"`
+}
+
+type InvalidPayslip struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+	Reason string `json:"reason"`
+}
+
+// general ledger accounts of the payroll journal entries
+const (
+	AccountSalaryExpense        = "salary_expense"
+	AccountOvertimeExpense      = "overtime_expense"
+	AccountReimbursementExpense = "reimbursement_expense"
+	AccountTaxPayable           = "tax_payable"
+	AccountNetPayPayable        = "net_pay_payable"
+	AccountDeductionReceivable  = "deduction_receivable"
+)
+
+// JournalAccountTypes in the order of the journal lines, debits first
+var JournalAccountTypes = []string{
+	AccountSalaryExpense,
+	AccountOvertimeExpense,
+	AccountReimbursementExpense,
+	AccountNetPayPayable,
+	AccountTaxPayable,
+	AccountDeductionReceivable,
+}
+
+// JournalAccount maps a journal account type to an account of the company's chart of accounts
+type JournalAccount struct {
+	Type    string `json:"type"`
+	Code    string `json:"code"`
+	Name    string `json:"name"`
+	Default bool   `json:"default"` // not mapped by the company, the ACCOUNTING_* account is used
+}
+
+type JournalAccountsRequest struct {
+	Accounts map[string]JournalAccountRequest `json:"accounts"` // by account type
+}
+
+type JournalAccountRequest struct {
+	Code string `json:"code"`
+	Name string `json:"name"` // optional, the account type name by default
+}
+
+type SQLJournalAccount struct {
+	Type sql.NullString `db:"account_type"`
+	Code sql.NullString `db:"code"`
+	Name sql.NullString `db:"name"`
+}
+
+// Journal of a processed payroll period, with a balanced journal entry per payout currency
+type Journal struct {
+	PayrollID    string         `json:"payroll_id"`
+	PayGroupID   string         `json:"pay_group_id"`
+	StartDate    xdate.Date     `json:"start_date"`
+	EndDate      xdate.Date     `json:"end_date"`
+	BaseCurrency string         `json:"base_currency"`
+	Payslips     int            `json:"payslips"`
+	Entries      []JournalEntry `json:"entries"`
+}
+
+type JournalEntry struct {
+	Reference   string        `json:"reference"`
+	Date        xdate.Date    `json:"date"` // posted on the last day of the period
+	Currency    string        `json:"currency"`
+	Description string        `json:"description"`
+	Lines       []JournalLine `json:"lines"`
+	TotalDebit  float64       `json:"total_debit"`
+	TotalCredit float64       `json:"total_credit"`
+}
+
+type JournalLine struct {
+	AccountType string  `json:"account_type"`
+	AccountCode string  `json:"account_code"`
+	AccountName string  `json:"account_name"`
+	Description string  `json:"description"`
+	Debit       float64 `json:"debit"`
+	Credit      float64 `json:"credit"`
+}
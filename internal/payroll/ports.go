@@ -9,18 +9,67 @@ import (
 
 type PayrollRepositoryInterface interface {
 	SetPayrollPeriod(ctx context.Context, data PayrollPeriod) error
-	GetActivePayrollPeriod(ctx context.Context) (PayrollPeriod, error)
-	StorePayslip(ctx context.Context, payslip models.Payslip) error
-	MarkPayrollProcessed(ctx context.Context, id string, totalPaid float64) error
+	GetActivePayrollPeriod(ctx context.Context, payGroupID string) (PayrollPeriod, error)
+	GetUserActivePayrollPeriod(ctx context.Context, userID string) (PayrollPeriod, error)
+	CreatePayrollPeriod(ctx context.Context, data PayrollPeriod) error
+	GetLatestPayrollPeriod(ctx context.Context, payGroupID string) (PayrollPeriod, error)
+	GetNextPayrollPeriod(ctx context.Context, payGroupID string, from time.Time) (PayrollPeriod, error)
+	GetOverlappingPayrollPeriods(ctx context.Context, payGroupID string, start time.Time, end time.Time) ([]PayrollPeriod, error)
+	GetPreviousPayrollPeriod(ctx context.Context, payGroupID string, before time.Time) (PayrollPeriod, error)
+	ActivatePayrollPeriod(ctx context.Context, data PayrollPeriod) error
+	MarkPayrollReadyNotified(ctx context.Context, id string) error
+	StorePayslips(ctx context.Context, payslips []models.Payslip) error
+	MarkPayrollProcessed(ctx context.Context, id string, totalPaid float64, totalPaidByCurrency map[string]float64, chain PayslipChain) error
 	GetPayslipsSummary(ctx context.Context, payrollID string) ([]models.Payslip, error)
 	GetUserPayslipByID(ctx context.Context, userID string, payrollID string) (models.Payslip, error)
+	GetPayslipsAfter(ctx context.Context, payrollID string, afterUserID string, limit int) ([]models.Payslip, error)
+	StorePayslipHashes(ctx context.Context, payslips []models.Payslip) error
+	GetPayrollPeriodByID(ctx context.Context, id string) (PayrollPeriod, error)
+	CreatePayrollJob(ctx context.Context, job PayrollJob) error
+	GetPayrollJobByID(ctx context.Context, id string) (PayrollJob, error)
+	GetUnfinishedPayrollJob(ctx context.Context, payrollID string) (PayrollJob, error)
+	ClaimPayrollJob(ctx context.Context, staleAfter time.Duration) (PayrollJob, error)
+	UpdatePayrollJobProgress(ctx context.Context, id string, total int, processed int, jobErrors []string) error
+	FinishPayrollJob(ctx context.Context, id string, status string, jobErrors []string) error
+	ReleasePayrollJob(ctx context.Context, id string) error
+	GetPayslipUserIDs(ctx context.Context, payrollID string, userIDs []string) ([]string, error)
+	CountPayslips(ctx context.Context, payrollID string) (int, error)
+	GetPayrollTotalPaid(ctx context.Context, payrollID string) (map[string]float64, error)
+	GetExchangeRates(ctx context.Context, payrollID string) (map[string]float64, error)
+	SetExchangeRates(ctx context.Context, payrollID string, rates map[string]float64) error
+	GetJournalAccounts(ctx context.Context) ([]JournalAccount, error)
+	SetJournalAccounts(ctx context.Context, accounts []JournalAccount) error
+	GetPayrollPeriodCurrencies(ctx context.Context, period PayrollPeriod, statuses []string) ([]string, error)
+	CreateDeduction(ctx context.Context, data models.Deduction) error
+	GetUsersOutstandingDeductions(ctx context.Context, userIDs []string) ([]models.Deduction, error)
+	CreatePayGroup(ctx context.Context, data PayGroup) error
+	UpdatePayGroup(ctx context.Context, data PayGroup) error
+	GetPayGroups(ctx context.Context) ([]PayGroup, error)
+	GetPayGroupByID(ctx context.Context, id string) (PayGroup, error)
+	GetPayGroupByCode(ctx context.Context, code string) (PayGroup, error)
+	AssignPayGroupUsers(ctx context.Context, payGroupID string, userIDs []string) (int, error)
 }
 
 type PayrollLogicInterface interface {
-	SetPayrollPeriod(ctx context.Context, start time.Time, end time.Time) error
-	CalculatePayroll(ctx context.Context) error
+	SetPayrollPeriod(ctx context.Context, req PayrollPeriodRequest) error
+	CalculatePayroll(ctx context.Context, payGroupID string) (PayrollJob, error)
+	JobQueued() <-chan struct{}
+	GetPayrollJob(ctx context.Context, jobID string) (PayrollJob, error)
+	ProcessPayrollJob(ctx context.Context, job PayrollJob) error
 	CalculatePay(ctx context.Context, data PayrollCalculationData) models.Payslip
-	GetPayrollsSummary(ctx context.Context) (PayslipSummaryResponse, error)
+	GetPayrollsSummary(ctx context.Context, payGroupID string) (PayslipSummaryResponse, error)
+	PreviewPayroll(ctx context.Context, payGroupID string) (PayrollPreview, error)
+	PreviewPayrollPeriod(ctx context.Context, period PayrollPeriod) (PayrollPreview, error)
 	GetUserPayslipByID(ctx context.Context, userID string) (models.Payslip, error)
+	AddDeduction(ctx context.Context, req DeductionRequest) (string, error)
+	CreatePayGroup(ctx context.Context, req PayGroupRequest) (PayGroup, error)
+	UpdatePayGroup(ctx context.Context, payGroupID string, req PayGroupRequest) (PayGroup, error)
+	GetPayGroups(ctx context.Context) ([]PayGroup, error)
+	AssignPayGroupUsers(ctx context.Context, payGroupID string, userIDs []string) (int, error)
+	GetExchangeRates(ctx context.Context, payrollID string) (ExchangeRates, error)
+	SetExchangeRates(ctx context.Context, payrollID string, req ExchangeRatesRequest) (ExchangeRates, error)
+	VerifyPayslips(ctx context.Context, payrollID string, hash string) (PayslipVerification, error)
+	GetJournalAccounts(ctx context.Context) ([]JournalAccount, error)
+	SetJournalAccounts(ctx context.Context, req JournalAccountsRequest) ([]JournalAccount, error)
+	GetPayrollJournal(ctx context.Context, payrollID string) (Journal, error)
 }
-
@@ -7,101 +7,251 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
+	"time"
 
 	"github.com/huandu/go-sqlbuilder"
+	"github.com/lib/pq"
 	"github.com/rahadianir/dealls/internal/config"
 	"github.com/rahadianir/dealls/internal/models"
 	"github.com/rahadianir/dealls/internal/pkg/dbhelper"
+	"github.com/rahadianir/dealls/internal/pkg/xcontext"
+	"github.com/rahadianir/dealls/internal/pkg/xdate"
 	"github.com/rahadianir/dealls/internal/pkg/xerror"
+	"github.com/rahadianir/dealls/internal/pkg/xwebhook"
 )
 
 type PayrollRepository struct {
-	deps *config.CommonDependencies
+	deps   *config.CommonDependencies
+	outbox xwebhook.Outbox
 }
 
-func NewPayrollRepository(deps *config.CommonDependencies) *PayrollRepository {
+func NewPayrollRepository(deps *config.CommonDependencies, outbox xwebhook.Outbox) *PayrollRepository {
 	return &PayrollRepository{
-		deps: deps,
+		deps:   deps,
+		outbox: outbox,
 	}
 }
 
+// SetPayrollPeriod stores the period as the active one of its pay group. Periods of the pay group are locked while
+// storing it, so ErrPayrollPeriodOverlap is returned when an overlapping period was stored concurrently.
 func (repo *PayrollRepository) SetPayrollPeriod(ctx context.Context, data PayrollPeriod) error {
-	ins := sqlbuilder.NewInsertBuilder()
-	insertQ, insertArgs := ins.InsertInto(`hr.payrolls`).
-		Cols(`id`, `start_date`, `end_date`, `active`, `created_at`, `total_work_days`).
-		Values(data.ID, data.StartDate, data.EndDate, true, `now()`, data.TotalWorkDays).BuildWithFlavor(sqlbuilder.PostgreSQL)
-	update := sqlbuilder.NewUpdateBuilder()
-	updateQ, updateArgs := update.Update(`hr.payrolls`).Set(update.Assign(`active`, nil)).BuildWithFlavor(sqlbuilder.PostgreSQL)
+	return dbhelper.WithTransaction(ctx, repo.deps.DB, func(ctx context.Context) error {
+		tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
 
-	tx, err := repo.deps.DB.BeginTxx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
+		lock := sqlbuilder.NewSelectBuilder()
+		lock.Select(`id`).From(`hr.pay_groups`).Where(lock.Equal(`id`, data.PayGroupID), lock.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx))).ForUpdate()
+		q, args := lock.BuildWithFlavor(sqlbuilder.PostgreSQL)
 
-	_, err = tx.ExecContext(ctx, updateQ, updateArgs...)
-	if err != nil {
-		repo.deps.Logger.ErrorContext(ctx, "failed to set all payroll period inactive", slog.Any("error", err))
-		return err
-	}
+		var payGroupID string
+		err := tx.QueryRowxContext(ctx, q, args...).Scan(&payGroupID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return xerror.ErrDataNotFound
+			}
+			repo.deps.Logger.ErrorContext(ctx, "failed to lock pay group payroll periods", slog.Any("error", err))
+			return err
+		}
 
-	_, err = tx.ExecContext(ctx, insertQ, insertArgs...)
-	if err != nil {
-		repo.deps.Logger.ErrorContext(ctx, "failed to insert new payroll period", slog.Any("error", err))
-		return err
-	}
+		overlaps, err := repo.GetOverlappingPayrollPeriods(ctx, data.PayGroupID, data.StartDate, data.EndDate)
+		if err != nil {
+			repo.deps.Logger.ErrorContext(ctx, "failed to get overlapping payroll periods", slog.Any("error", err))
+			return err
+		}
+		if len(overlaps) > 0 {
+			return ErrPayrollPeriodOverlap
+		}
 
-	err = tx.Commit()
-	if err != nil {
-		repo.deps.Logger.ErrorContext(ctx, "failed to commit new payroll period", slog.Any("error", err))
-		return err
-	}
+		update := sqlbuilder.NewUpdateBuilder()
+		q, args = update.Update(`hr.payrolls`).Set(update.Assign(`active`, nil)).Where(update.Equal(`pay_group_id`, data.PayGroupID), update.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx))).BuildWithFlavor(sqlbuilder.PostgreSQL)
 
-	return nil
+		_, err = tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			repo.deps.Logger.ErrorContext(ctx, "failed to set pay group payroll periods inactive", slog.Any("error", err))
+			return err
+		}
+
+		ins := sqlbuilder.NewInsertBuilder()
+		q, args = ins.InsertInto(`hr.payrolls`).
+			Cols(`id`, `company_id`, `pay_group_id`, `start_date`, `end_date`, `active`, `created_at`, `total_work_days`, `created_by`).
+			Values(data.ID, xcontext.GetCompanyIDFromContext(ctx), data.PayGroupID, xdate.Of(data.StartDate), xdate.Of(data.EndDate), true, `now()`, data.TotalWorkDays, xcontext.GetUserIDFromContext(ctx)).BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+		_, err = tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			repo.deps.Logger.ErrorContext(ctx, "failed to insert new payroll period", slog.Any("error", err))
+			return err
+		}
+
+		err = repo.outbox.Enqueue(ctx, xwebhook.Event{Type: xwebhook.EventPayrollPeriodCreated, Data: toPayrollPeriodEvent(data)})
+		if err != nil {
+			repo.deps.Logger.ErrorContext(ctx, "failed to enqueue payroll period created event", slog.Any("error", err))
+			return err
+		}
+
+		return nil
+	})
 }
 
-func (repo *PayrollRepository) GetActivePayrollPeriod(ctx context.Context) (PayrollPeriod, error) {
+// GetOverlappingPayrollPeriods returns the pay group's payroll periods sharing a day with the given period, end dates are exclusive
+func (repo *PayrollRepository) GetOverlappingPayrollPeriods(ctx context.Context, payGroupID string, start time.Time, end time.Time) ([]PayrollPeriod, error) {
 	sq := sqlbuilder.NewSelectBuilder()
-	sq.Select(`id`, `start_date`, `end_date`, `total_work_days`, `processed`, `total_salary_paid`).From(`hr.payrolls`).Where(sq.Equal(`active`, true))
+	sq.Select(payrollPeriodColumns...).From(`hr.payrolls`).Where(
+		sq.Equal(`pay_group_id`, payGroupID),
+		sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+		sq.LessThan(`start_date`, xdate.Of(end)),
+		sq.GreaterThan(`end_date`, xdate.Of(start)),
+		sq.IsNull(`deleted_at`),
+	).OrderBy(`start_date`)
 	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
 
 	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
 
-	var temp SQLPayrollPeriod
-	err := tx.QueryRowxContext(ctx, q, args...).StructScan(&temp)
+	rows, err := tx.QueryxContext(ctx, q, args...)
 	if err != nil {
-		return PayrollPeriod{}, err
+		return []PayrollPeriod{}, err
 	}
+	defer rows.Close()
 
-	result := PayrollPeriod{
-		ID:              temp.ID.String,
-		StartDate:       temp.StartDate.Time,
-		EndDate:         temp.EndDate.Time,
-		TotalWorkDays:   int(temp.TotalWorkDays.Int64),
-		Processed:       temp.Processed.Bool,
-		TotalSalaryPaid: temp.TotalSalaryPaid.Float64,
+	var result []PayrollPeriod
+	for rows.Next() {
+		var temp SQLPayrollPeriod
+		err := rows.StructScan(&temp)
+		if err != nil {
+			return []PayrollPeriod{}, err
+		}
+		result = append(result, toPayrollPeriod(temp))
 	}
 
-	return result, nil
+	return result, rows.Err()
+}
+
+// GetPreviousPayrollPeriod returns the pay group's payroll period starting last before the given date
+func (repo *PayrollRepository) GetPreviousPayrollPeriod(ctx context.Context, payGroupID string, before time.Time) (PayrollPeriod, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(payrollPeriodColumns...).From(`hr.payrolls`).Where(
+		sq.Equal(`pay_group_id`, payGroupID),
+		sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+		sq.LessThan(`start_date`, xdate.Of(before)),
+		sq.IsNull(`deleted_at`),
+	).OrderBy(`start_date`).Desc().Limit(1)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	return repo.getPayrollPeriod(ctx, q, args)
+}
+
+func (repo *PayrollRepository) GetActivePayrollPeriod(ctx context.Context, payGroupID string) (PayrollPeriod, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(payrollPeriodColumns...).From(`hr.payrolls`).Where(
+		sq.Equal(`pay_group_id`, payGroupID),
+		sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+		sq.Equal(`active`, true),
+	)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	return repo.getPayrollPeriod(ctx, q, args)
+}
+
+// GetUserActivePayrollPeriod returns the active payroll period of the user's pay group
+func (repo *PayrollRepository) GetUserActivePayrollPeriod(ctx context.Context, userID string) (PayrollPeriod, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(payrollPeriodColumns...).From(`hr.payrolls`).Where(
+		sq.Equal(`active`, true),
+		sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+		fmt.Sprintf(`pay_group_id = (SELECT pay_group_id FROM hr.users WHERE id = %s AND company_id = %s)`, sq.Var(userID), sq.Var(xcontext.GetCompanyIDFromContext(ctx))),
+	)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	return repo.getPayrollPeriod(ctx, q, args)
 }
 
-func (repo *PayrollRepository) StorePayslip(ctx context.Context, payslip models.Payslip) error {
-	// convert reimbursement list to JSON first
-	reimbursementList := `{}`
-	if len(payslip.ReimbursementList) != 0 {
-		dataBytes, err := json.Marshal(payslip.ReimbursementList)
+// CreatePayrollPeriod stores an upcoming inactive payroll period, it's activated later on by ActivatePayrollPeriod
+func (repo *PayrollRepository) CreatePayrollPeriod(ctx context.Context, data PayrollPeriod) error {
+	sq := sqlbuilder.NewInsertBuilder()
+	sq.InsertInto(`hr.payrolls`).
+		Cols(`id`, `company_id`, `pay_group_id`, `start_date`, `end_date`, `active`, `total_work_days`, `scheduled`, `created_at`, `created_by`).
+		Values(data.ID, xcontext.GetCompanyIDFromContext(ctx), data.PayGroupID, xdate.Of(data.StartDate), xdate.Of(data.EndDate), nil, data.TotalWorkDays, data.Scheduled, `now()`, xcontext.GetUserIDFromContext(ctx))
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	return dbhelper.WithTransaction(ctx, repo.deps.DB, func(ctx context.Context) error {
+		tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+		_, err := tx.ExecContext(ctx, q, args...)
 		if err != nil {
-			repo.deps.Logger.ErrorContext(ctx, "failed to marshal reimbursement list to payslip", slog.Any("error", err))
 			return err
 		}
-		reimbursementList = string(dataBytes)
+
+		return repo.outbox.Enqueue(ctx, xwebhook.Event{Type: xwebhook.EventPayrollPeriodCreated, Data: toPayrollPeriodEvent(data)})
+	})
+}
+
+// GetLatestPayrollPeriod returns the pay group's payroll period starting last, active or not
+func (repo *PayrollRepository) GetLatestPayrollPeriod(ctx context.Context, payGroupID string) (PayrollPeriod, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(payrollPeriodColumns...).From(`hr.payrolls`).Where(
+		sq.Equal(`pay_group_id`, payGroupID),
+		sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+		sq.IsNull(`deleted_at`),
+	).OrderBy(`start_date`).Desc().Limit(1)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	return repo.getPayrollPeriod(ctx, q, args)
+}
+
+// GetNextPayrollPeriod returns the pay group's earliest unprocessed payroll period starting on or after the given date
+func (repo *PayrollRepository) GetNextPayrollPeriod(ctx context.Context, payGroupID string, from time.Time) (PayrollPeriod, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(payrollPeriodColumns...).From(`hr.payrolls`).Where(
+		sq.Equal(`pay_group_id`, payGroupID),
+		sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+		sq.Equal(`processed`, false),
+		sq.IsNull(`deleted_at`),
+	).OrderBy(`start_date`).Asc().Limit(1)
+	if !from.IsZero() {
+		sq.Where(sq.GreaterEqualThan(`start_date`, xdate.Of(from)))
 	}
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
 
-	sq := sqlbuilder.NewInsertBuilder()
-	sq.InsertInto(`hr.payslips`).
-		Cols(`id`, `payroll_id`, `user_id`, `base_salary`, `attendance_days`, `total_work_days`, `overtime_hours`, `overtime_bonus`, `reimbursement_list`, `total_reimbursement`, `take_home_pay`, `created_at`).
-		Values(payslip.ID, payslip.PayrollID, payslip.UserID, payslip.BaseSalary, payslip.TotalAttendance, payslip.TotalWorkDay, payslip.TotalOvertimeHour, payslip.OvertimePay, reimbursementList, payslip.TotalReimbursement, payslip.TakeHomePay, `now()`)
+	return repo.getPayrollPeriod(ctx, q, args)
+}
+
+// ActivatePayrollPeriod makes the period the active one of its pay group
+func (repo *PayrollRepository) ActivatePayrollPeriod(ctx context.Context, data PayrollPeriod) error {
+	return dbhelper.WithTransaction(ctx, repo.deps.DB, func(ctx context.Context) error {
+		tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+		deactivate := sqlbuilder.NewUpdateBuilder()
+		deactivate.Update(`hr.payrolls`).Set(deactivate.Assign(`active`, nil)).Where(deactivate.Equal(`pay_group_id`, data.PayGroupID), deactivate.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)))
+		q, args := deactivate.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+		_, err := tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			return err
+		}
 
+		activate := sqlbuilder.NewUpdateBuilder()
+		activate.Update(`hr.payrolls`).Set(
+			activate.Assign(`active`, true),
+			`updated_at = now()`,
+			activate.Assign(`updated_by`, xcontext.GetUserIDFromContext(ctx)),
+		).Where(activate.Equal(`id`, data.ID), activate.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)))
+		q, args = activate.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+		_, err = tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (repo *PayrollRepository) MarkPayrollReadyNotified(ctx context.Context, id string) error {
+	sq := sqlbuilder.NewUpdateBuilder()
+	sq.Update(`hr.payrolls`).Set(
+		`ready_notified_at = now()`,
+		`updated_at = now()`,
+		sq.Assign(`updated_by`, xcontext.GetUserIDFromContext(ctx)),
+	).Where(sq.Equal(`id`, id), sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)))
 	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
 
 	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
@@ -114,13 +264,112 @@ func (repo *PayrollRepository) StorePayslip(ctx context.Context, payslip models.
 	return nil
 }
 
-func (repo *PayrollRepository) MarkPayrollProcessed(ctx context.Context, id string, totalPaid float64) error {
+// StorePayslips stores the payslips in a single multi-row insert,
+// payslips already stored for the user in the payroll period are left as is
+func (repo *PayrollRepository) StorePayslips(ctx context.Context, payslips []models.Payslip) error {
+	if len(payslips) == 0 {
+		return nil
+	}
+
+	sq := sqlbuilder.NewInsertBuilder()
+	sq.InsertInto(`hr.payslips`).
+		Cols(`id`, `company_id`, `payroll_id`, `user_id`, `base_salary`, `attendance_days`, `total_work_days`, `overtime_hours`, `overtime_bonus`, `reimbursement_list`, `total_reimbursement`, `reimbursement_by_category`, `total_taxable_reimbursement`, `take_home_pay`, `paid_leave_days`, `unpaid_leave_days`, `review_reasons`, `payslip_type`, `employed_work_days`, `unused_leave_days`, `leave_payout`, `deduction_list`, `total_deduction`, `salary_currency`, `original_salary`, `payout_currency`, `salary_exchange_rate`, `created_at`, `created_by`)
+
+	companyID := xcontext.GetCompanyIDFromContext(ctx)
+	createdBy := xcontext.GetUserIDFromContext(ctx)
+
+	var settledDeductionIDs []string
+	for _, payslip := range payslips {
+		// convert reimbursement list to JSON first
+		reimbursementList := `[]`
+		if len(payslip.ReimbursementList) != 0 {
+			dataBytes, err := json.Marshal(payslip.ReimbursementList)
+			if err != nil {
+				repo.deps.Logger.ErrorContext(ctx, "failed to marshal reimbursement list to payslip", slog.Any("error", err))
+				return err
+			}
+			reimbursementList = string(dataBytes)
+		}
+
+		reimbursementByCategory := `[]`
+		if len(payslip.ReimbursementCategories) != 0 {
+			dataBytes, err := json.Marshal(payslip.ReimbursementCategories)
+			if err != nil {
+				repo.deps.Logger.ErrorContext(ctx, "failed to marshal reimbursement categories to payslip", slog.Any("error", err))
+				return err
+			}
+			reimbursementByCategory = string(dataBytes)
+		}
+
+		reviewReasons := `[]`
+		if len(payslip.ReviewReasons) != 0 {
+			dataBytes, err := json.Marshal(payslip.ReviewReasons)
+			if err != nil {
+				repo.deps.Logger.ErrorContext(ctx, "failed to marshal review reasons to payslip", slog.Any("error", err))
+				return err
+			}
+			reviewReasons = string(dataBytes)
+		}
+
+		deductionList := `[]`
+		if len(payslip.DeductionList) != 0 {
+			dataBytes, err := json.Marshal(payslip.DeductionList)
+			if err != nil {
+				repo.deps.Logger.ErrorContext(ctx, "failed to marshal deduction list to payslip", slog.Any("error", err))
+				return err
+			}
+			deductionList = string(dataBytes)
+
+			for _, d := range payslip.DeductionList {
+				settledDeductionIDs = append(settledDeductionIDs, d.ID)
+			}
+		}
+
+		payslipType := payslip.Type
+		if payslipType == "" {
+			payslipType = models.PayslipRegular
+		}
+
+		sq.Values(payslip.ID, companyID, payslip.PayrollID, payslip.UserID, payslip.BaseSalary, payslip.TotalAttendance, payslip.TotalWorkDay, payslip.TotalOvertimeHour, payslip.OvertimePay, reimbursementList, payslip.TotalReimbursement, reimbursementByCategory, payslip.TotalTaxableReimbursement, payslip.TakeHomePay, payslip.PaidLeaveDays, payslip.UnpaidLeaveDays, reviewReasons, payslipType, payslip.EmployedWorkDays, payslip.UnusedLeaveDays, payslip.LeavePayout, deductionList, payslip.TotalDeduction, payslip.SalaryCurrency, payslip.OriginalSalary, payslip.PayoutCurrency, payslip.SalaryExchangeRate, `now()`, createdBy)
+	}
+	sq.SQL(`ON CONFLICT (payroll_id, user_id) DO NOTHING`)
+
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	if len(settledDeductionIDs) == 0 {
+		tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+		_, err := tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+
+	// deductions are marked settled together with the final payslips so they're never deducted twice
+	return dbhelper.WithTransaction(ctx, repo.deps.DB, func(ctx context.Context) error {
+		tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+		_, err := tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			return err
+		}
+
+		return repo.settleDeductions(ctx, payslips[0].PayrollID, settledDeductionIDs)
+	})
+}
+
+func (repo *PayrollRepository) settleDeductions(ctx context.Context, payrollID string, deductionIDs []string) error {
 	sq := sqlbuilder.NewUpdateBuilder()
-	sq.Update(`hr.payrolls`).Set(
-		sq.Assign(`processed`, true),
-		sq.Assign(`total_salary_paid`, totalPaid),
+	sq.Update(`hr.deductions`).Set(
+		sq.Assign(`settled_payroll_id`, payrollID),
+		`updated_at = now()`,
+		sq.Assign(`updated_by`, xcontext.GetUserIDFromContext(ctx)),
 	).Where(
-		sq.EQ(`id`, id),
+		sq.In(`id::text`, sqlbuilder.List(deductionIDs)),
+		sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+		sq.IsNull(`settled_payroll_id`),
 	)
 	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
 
@@ -130,12 +379,114 @@ func (repo *PayrollRepository) MarkPayrollProcessed(ctx context.Context, id stri
 	if err != nil {
 		return err
 	}
+
+	return nil
+}
+
+func (repo *PayrollRepository) CreateDeduction(ctx context.Context, data models.Deduction) error {
+	sq := sqlbuilder.NewInsertBuilder()
+	sq.InsertInto(`hr.deductions`).
+		Cols(`id`, `company_id`, `user_id`, `amount`, `description`, `created_at`, `created_by`).
+		Values(data.ID, xcontext.GetCompanyIDFromContext(ctx), data.UserID, data.Amount, data.Description, `now()`, xcontext.GetUserIDFromContext(ctx))
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	_, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
+func (repo *PayrollRepository) GetUsersOutstandingDeductions(ctx context.Context, userIDs []string) ([]models.Deduction, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`id`, `user_id`, `amount`, `description`).
+		From(`hr.deductions`).
+		Where(
+			sq.In(`user_id::text`, sqlbuilder.List(userIDs)),
+			sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+			sq.IsNull(`settled_payroll_id`),
+			sq.IsNull(`deleted_at`),
+		).
+		OrderBy(`created_at`)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	rows, err := tx.QueryxContext(ctx, q, args...)
+	if err != nil {
+		return []models.Deduction{}, err
+	}
+	defer rows.Close()
+
+	var result []models.Deduction
+	for rows.Next() {
+		var temp SQLDeduction
+		err := rows.StructScan(&temp)
+		if err != nil {
+			repo.deps.Logger.WarnContext(ctx, "failed to scan deduction", slog.Any("error", err))
+			continue
+		}
+
+		result = append(result, models.Deduction{
+			ID:          temp.ID.String,
+			UserID:      temp.UserID.String,
+			Amount:      temp.Amount.Float64,
+			Description: temp.Description.String,
+		})
+	}
+
+	return result, nil
+}
+
+// MarkPayrollProcessed stores the total paid in the base currency along with the totals per payout currency
+func (repo *PayrollRepository) MarkPayrollProcessed(ctx context.Context, id string, totalPaid float64, totalPaidByCurrency map[string]float64, chain PayslipChain) error {
+	totalByCurrency, err := json.Marshal(totalPaidByCurrency)
+	if err != nil {
+		return err
+	}
+
+	sq := sqlbuilder.NewUpdateBuilder()
+	sq.Update(`hr.payrolls`).Set(
+		sq.Assign(`processed`, true),
+		sq.Assign(`total_salary_paid`, totalPaid),
+		sq.Assign(`total_salary_paid_by_currency`, string(totalByCurrency)),
+		sq.Assign(`payslip_chain_hash`, chain.Hash),
+		sq.Assign(`payslip_chain_signature`, chain.Signature),
+		`updated_at = now()`,
+		sq.Assign(`updated_by`, xcontext.GetUserIDFromContext(ctx)),
+	).Where(
+		sq.EQ(`id`, id),
+		sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+	).SQL(`RETURNING id, pay_group_id, start_date, end_date, total_work_days, scheduled`)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	return dbhelper.WithTransaction(ctx, repo.deps.DB, func(ctx context.Context) error {
+		tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+		var temp SQLPayrollPeriod
+		err := tx.QueryRowxContext(ctx, q, args...).StructScan(&temp)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return xerror.ErrDataNotFound
+			}
+			return err
+		}
+
+		event := toPayrollPeriodEvent(toPayrollPeriod(temp))
+		event.TotalSalaryPaid = &totalPaid
+		event.TotalSalaryPaidByCurrency = totalPaidByCurrency
+		event.PayslipChainHash = chain.Hash
+
+		return repo.outbox.Enqueue(ctx, xwebhook.Event{Type: xwebhook.EventPayrollPeriodProcessed, Data: event})
+	})
+}
+
 func (repo *PayrollRepository) GetPayslipsSummary(ctx context.Context, payrollID string) ([]models.Payslip, error) {
 	sq := sqlbuilder.NewSelectBuilder()
-	sq.Select(`p.user_id`, `p.take_home_pay`, `u.name`).From(`hr.payslips p `).Join(`hr.users u`, `p.user_id = u.id`).Where(sq.Equal(`p.payroll_id`, payrollID))
+	sq.Select(`p.user_id`, `p.take_home_pay`, `p.payout_currency`, `u.name`, `p.review_reasons`, `p.hash`).From(`hr.payslips p `).Join(`hr.users u`, `p.user_id = u.id`).Where(sq.Equal(`p.payroll_id`, payrollID), sq.Equal(`p.company_id`, xcontext.GetCompanyIDFromContext(ctx)))
 	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
 
 	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
@@ -155,23 +506,37 @@ func (repo *PayrollRepository) GetPayslipsSummary(ctx context.Context, payrollID
 			continue
 		}
 
+		var reviewReasons []string
+		if len(temp.ReviewReasons) != 0 {
+			err := json.Unmarshal(temp.ReviewReasons, &reviewReasons)
+			if err != nil {
+				repo.deps.Logger.WarnContext(ctx, "failed to unmarshal payslip review reasons", slog.Any("error", err))
+			}
+		}
+
 		result = append(result, models.Payslip{
-			UserID:      temp.UserID.String,
-			Name:        temp.Name.String,
-			TakeHomePay: temp.TakeHomePay.Float64,
+			UserID:         temp.UserID.String,
+			Name:           temp.Name.String,
+			TakeHomePay:    temp.TakeHomePay.Float64,
+			PayoutCurrency: temp.PayoutCurrency.String,
+			ReviewReasons:  reviewReasons,
+			Hash:           temp.Hash.String,
 		})
 	}
 
 	return result, nil
 }
 
+var payslipColumns = []string{`p.id`, `p.payroll_id`, `u.name`, `p.user_id`, `p.base_salary`, `p.attendance_days`, `p.total_work_days`, `p.overtime_hours`, `p.overtime_bonus`, `p.reimbursement_list`, `p.total_reimbursement`, `p.reimbursement_by_category`, `p.total_taxable_reimbursement`, `p.take_home_pay`, `p.paid_leave_days`, `p.unpaid_leave_days`, `p.review_reasons`, `p.payslip_type`, `p.employed_work_days`, `p.unused_leave_days`, `p.leave_payout`, `p.deduction_list`, `p.total_deduction`, `p.salary_currency`, `p.original_salary`, `p.payout_currency`, `p.salary_exchange_rate`, `p.prev_hash`, `p.hash`, `p.signature`}
+
 func (repo *PayrollRepository) GetUserPayslipByID(ctx context.Context, userID string, payrollID string) (models.Payslip, error) {
 	sq := sqlbuilder.NewSelectBuilder()
-	sq.Select(`p.id`, `p.payroll_id`, `u.name`, `user_id`, `p.base_salary`, `attendance_days`, `total_work_days`, `overtime_hours`, `overtime_bonus`, `reimbursement_list`, `total_reimbursement`, `take_home_pay`).
+	sq.Select(payslipColumns...).
 		From(`hr.payslips p`).Join(`hr.users u`, `p.user_id = u.id`).Where(
 		sq.And(
-			sq.Equal(`user_id`, userID),
-			sq.Equal(`payroll_id`, payrollID),
+			sq.Equal(`p.user_id`, userID),
+			sq.Equal(`p.payroll_id`, payrollID),
+			sq.Equal(`p.company_id`, xcontext.GetCompanyIDFromContext(ctx)),
 		),
 	)
 	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
@@ -188,28 +553,850 @@ func (repo *PayrollRepository) GetUserPayslipByID(ctx context.Context, userID st
 		return models.Payslip{}, err
 	}
 
-	var list []models.Reimbursement
-	if len(temp.ReimbursementList) != 0 {
-		err := json.Unmarshal(temp.ReimbursementList, &list)
+	return toPayslip(temp)
+}
+
+// GetPayslipsAfter returns up to limit payslips of the payroll period in user_id order, starting after afterUserID
+func (repo *PayrollRepository) GetPayslipsAfter(ctx context.Context, payrollID string, afterUserID string, limit int) ([]models.Payslip, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(payslipColumns...).
+		From(`hr.payslips p`).Join(`hr.users u`, `p.user_id = u.id`).Where(
+		sq.Equal(`p.payroll_id`, payrollID),
+		sq.Equal(`p.company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+	)
+	if afterUserID != "" {
+		sq.Where(sq.GreaterThan(`p.user_id`, afterUserID))
+	}
+	sq.OrderBy(`p.user_id`).Limit(limit)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	rows, err := tx.QueryxContext(ctx, q, args...)
+	if err != nil {
+		return []models.Payslip{}, err
+	}
+	defer rows.Close()
+
+	result := make([]models.Payslip, 0, limit)
+	for rows.Next() {
+		var temp SQLPayslip
+		err := rows.StructScan(&temp)
 		if err != nil {
-			return models.Payslip{}, fmt.Errorf("failed to unmarshal reimbursement list: %w", err)
+			return []models.Payslip{}, err
+		}
+
+		payslip, err := toPayslip(temp)
+		if err != nil {
+			return []models.Payslip{}, err
 		}
+		result = append(result, payslip)
 	}
 
-	result := models.Payslip{
-		ID:                 temp.ID.String,
-		Name:               temp.Name.String,
-		UserID:             temp.UserID.String,
-		PayrollID:          temp.PayrollID.String,
-		BaseSalary:         temp.BaseSalary.Float64,
-		TotalAttendance:    int(temp.TotalAttendance.Int64),
-		TotalWorkDay:       int(temp.TotalWorkDay.Int64),
-		TotalOvertimeHour:  int(temp.TotalOvertimeHour.Int64),
-		OvertimePay:        temp.OvertimePay.Float64,
-		ReimbursementList:  list,
-		TotalReimbursement: temp.TotalReimbursement.Float64,
-		TakeHomePay:        temp.TakeHomePay.Float64,
+	return result, rows.Err()
+}
+
+// StorePayslipHashes sets the prev_hash, hash and signature of the stored payslips by id
+func (repo *PayrollRepository) StorePayslipHashes(ctx context.Context, payslips []models.Payslip) error {
+	if len(payslips) == 0 {
+		return nil
 	}
 
-	return result, nil
+	ids := make([]string, len(payslips))
+	prevHashes := make([]string, len(payslips))
+	hashes := make([]string, len(payslips))
+	signatures := make([]string, len(payslips))
+	for i, payslip := range payslips {
+		ids[i] = payslip.ID
+		prevHashes[i] = payslip.PrevHash
+		hashes[i] = payslip.Hash
+		signatures[i] = payslip.Signature
+	}
+
+	// update every payslip of the chunk in one statement
+	sq := sqlbuilder.NewUpdateBuilder()
+	sq.Update(`hr.payslips p`).Set(
+		`prev_hash = v.prev_hash`,
+		`hash = v.hash`,
+		`signature = v.signature`,
+	)
+	sq.SQL(fmt.Sprintf(`FROM unnest(%s::uuid[], %s::varchar[], %s::varchar[], %s::varchar[]) AS v (id, prev_hash, hash, signature)`,
+		sq.Var(pq.Array(ids)), sq.Var(pq.Array(prevHashes)), sq.Var(pq.Array(hashes)), sq.Var(pq.Array(signatures))))
+	sq.Where(
+		`p.id = v.id`,
+		sq.Equal(`p.company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+	)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	_, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (repo *PayrollRepository) GetPayrollPeriodByID(ctx context.Context, id string) (PayrollPeriod, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(payrollPeriodColumns...).From(`hr.payrolls`).Where(sq.Equal(`id`, id), sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)))
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	return repo.getPayrollPeriod(ctx, q, args)
+}
+
+func (repo *PayrollRepository) CreatePayrollJob(ctx context.Context, job PayrollJob) error {
+	sq := sqlbuilder.NewInsertBuilder()
+	sq.InsertInto(`hr.payroll_jobs`).
+		Cols(`id`, `company_id`, `payroll_id`, `status`, `created_at`, `created_by`).
+		Values(job.ID, xcontext.GetCompanyIDFromContext(ctx), job.PayrollID, job.Status, `now()`, job.CreatedBy)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	_, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repo *PayrollRepository) GetPayrollJobByID(ctx context.Context, id string) (PayrollJob, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(payrollJobColumns...).From(`hr.payroll_jobs`).
+		Where(
+			sq.And(
+				sq.Equal(`id`, id),
+				sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+				sq.IsNull(`deleted_at`),
+			),
+		)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	return repo.getPayrollJob(ctx, q, args)
+}
+
+func (repo *PayrollRepository) GetUnfinishedPayrollJob(ctx context.Context, payrollID string) (PayrollJob, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(payrollJobColumns...).From(`hr.payroll_jobs`).
+		Where(
+			sq.And(
+				sq.Equal(`payroll_id`, payrollID),
+				sq.In(`status`, PayrollJobPending, PayrollJobRunning),
+				sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+				sq.IsNull(`deleted_at`),
+			),
+		)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	return repo.getPayrollJob(ctx, q, args)
+}
+
+// ClaimPayrollJob marks the oldest pending job (or a running job left behind by a dead worker) as running and returns it.
+// The worker serves every company, so unlike the other queries it isn't scoped to the context company.
+func (repo *PayrollRepository) ClaimPayrollJob(ctx context.Context, staleAfter time.Duration) (PayrollJob, error) {
+	sub := sqlbuilder.NewSelectBuilder()
+	sub.Select(`id`).From(`hr.payroll_jobs`).
+		Where(
+			sub.And(
+				sub.IsNull(`deleted_at`),
+				sub.Or(
+					sub.Equal(`status`, PayrollJobPending),
+					sub.And(
+						sub.Equal(`status`, PayrollJobRunning),
+						sub.LessThan(`updated_at`, time.Now().Add(-staleAfter)),
+					),
+				),
+			),
+		).
+		OrderBy(`created_at`).Limit(1).
+		ForUpdate().SQL(`SKIP LOCKED`)
+
+	sq := sqlbuilder.NewUpdateBuilder()
+	sq.Update(`hr.payroll_jobs`).
+		Set(
+			sq.Assign(`status`, PayrollJobRunning),
+			`started_at = COALESCE(started_at, now())`,
+			`updated_at = now()`,
+		).
+		Where(sq.In(`id`, sub)).
+		SQL(`RETURNING ` + strings.Join(payrollJobColumns, `, `))
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	return repo.getPayrollJob(ctx, q, args)
+}
+
+func (repo *PayrollRepository) UpdatePayrollJobProgress(ctx context.Context, id string, total int, processed int, jobErrors []string) error {
+	errorList, err := json.Marshal(jobErrors)
+	if err != nil {
+		return err
+	}
+
+	sq := sqlbuilder.NewUpdateBuilder()
+	sq.Update(`hr.payroll_jobs`).
+		Set(
+			sq.Assign(`total`, total),
+			sq.Assign(`processed`, processed),
+			sq.Assign(`errors`, string(errorList)),
+			`updated_at = now()`,
+			sq.Assign(`updated_by`, xcontext.GetUserIDFromContext(ctx)),
+		).
+		Where(sq.Equal(`id`, id), sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)))
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	_, err = tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repo *PayrollRepository) FinishPayrollJob(ctx context.Context, id string, status string, jobErrors []string) error {
+	errorList, err := json.Marshal(jobErrors)
+	if err != nil {
+		return err
+	}
+
+	sq := sqlbuilder.NewUpdateBuilder()
+	sq.Update(`hr.payroll_jobs`).
+		Set(
+			sq.Assign(`status`, status),
+			sq.Assign(`errors`, string(errorList)),
+			`finished_at = now()`,
+			`updated_at = now()`,
+			sq.Assign(`updated_by`, xcontext.GetUserIDFromContext(ctx)),
+		).
+		Where(sq.Equal(`id`, id), sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)))
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	_, err = tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ReleasePayrollJob puts a running job back to the queue, used when the worker is shutting down
+func (repo *PayrollRepository) ReleasePayrollJob(ctx context.Context, id string) error {
+	sq := sqlbuilder.NewUpdateBuilder()
+	sq.Update(`hr.payroll_jobs`).
+		Set(
+			sq.Assign(`status`, PayrollJobPending),
+			`updated_at = now()`,
+			sq.Assign(`updated_by`, xcontext.GetUserIDFromContext(ctx)),
+		).
+		Where(
+			sq.And(
+				sq.Equal(`id`, id),
+				sq.Equal(`status`, PayrollJobRunning),
+				sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+			),
+		)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	_, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetPayslipUserIDs returns which of the given users already have a payslip in the payroll period
+func (repo *PayrollRepository) GetPayslipUserIDs(ctx context.Context, payrollID string, userIDs []string) ([]string, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`user_id`).From(`hr.payslips`).
+		Where(
+			sq.And(
+				sq.Equal(`payroll_id`, payrollID),
+				sq.In(`user_id::text`, sqlbuilder.List(userIDs)),
+				sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+				sq.IsNull(`deleted_at`),
+			),
+		)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	rows, err := tx.QueryxContext(ctx, q, args...)
+	if err != nil {
+		return []string{}, err
+	}
+	defer rows.Close()
+
+	var result []string
+	for rows.Next() {
+		var userID string
+		err := rows.Scan(&userID)
+		if err != nil {
+			repo.deps.Logger.WarnContext(ctx, "failed to scan payslip user id", slog.Any("error", err))
+			continue
+		}
+		result = append(result, userID)
+	}
+
+	return result, nil
+}
+
+func (repo *PayrollRepository) CountPayslips(ctx context.Context, payrollID string) (int, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`count(*)`).From(`hr.payslips`).
+		Where(
+			sq.And(
+				sq.Equal(`payroll_id`, payrollID),
+				sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+				sq.IsNull(`deleted_at`),
+			),
+		)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	var count int
+	err := tx.QueryRowxContext(ctx, q, args...).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetPayrollTotalPaid sums the take home pay of the stored payslips per payout currency
+func (repo *PayrollRepository) GetPayrollTotalPaid(ctx context.Context, payrollID string) (map[string]float64, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`payout_currency`, `COALESCE(SUM(take_home_pay), 0)`).From(`hr.payslips`).
+		Where(
+			sq.And(
+				sq.Equal(`payroll_id`, payrollID),
+				sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+				sq.IsNull(`deleted_at`),
+			),
+		).
+		GroupBy(`payout_currency`)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	rows, err := tx.QueryxContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string]float64)
+	for rows.Next() {
+		var currency string
+		var total float64
+		err := rows.Scan(&currency, &total)
+		if err != nil {
+			return nil, err
+		}
+		result[currency] = total
+	}
+
+	return result, rows.Err()
+}
+
+func (repo *PayrollRepository) GetExchangeRates(ctx context.Context, payrollID string) (map[string]float64, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`currency`, `rate`).From(`hr.exchange_rates`).Where(sq.Equal(`payroll_id`, payrollID), sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)))
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	rows, err := tx.QueryxContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string]float64)
+	for rows.Next() {
+		var currency string
+		var rate float64
+		err := rows.Scan(&currency, &rate)
+		if err != nil {
+			return nil, err
+		}
+		result[currency] = rate
+	}
+
+	return result, rows.Err()
+}
+
+// SetExchangeRates inserts the rates of the payroll period, replacing the ones already set
+func (repo *PayrollRepository) SetExchangeRates(ctx context.Context, payrollID string, rates map[string]float64) error {
+	userID := xcontext.GetUserIDFromContext(ctx)
+	companyID := xcontext.GetCompanyIDFromContext(ctx)
+
+	sq := sqlbuilder.NewInsertBuilder()
+	sq.InsertInto(`hr.exchange_rates`).Cols(`company_id`, `payroll_id`, `currency`, `rate`, `created_at`, `created_by`)
+	for currency, rate := range rates {
+		sq.Values(companyID, payrollID, currency, rate, `now()`, userID)
+	}
+	sq.SQL(`ON CONFLICT (payroll_id, currency) DO UPDATE SET rate = EXCLUDED.rate, updated_at = now(), updated_by = EXCLUDED.created_by`)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	_, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetJournalAccounts returns the journal accounts mapped by the company
+func (repo *PayrollRepository) GetJournalAccounts(ctx context.Context) ([]JournalAccount, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`account_type`, `code`, `name`).From(`hr.journal_accounts`).Where(sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)))
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	rows, err := tx.QueryxContext(ctx, q, args...)
+	if err != nil {
+		return []JournalAccount{}, err
+	}
+	defer rows.Close()
+
+	result := []JournalAccount{}
+	for rows.Next() {
+		var temp SQLJournalAccount
+		err := rows.StructScan(&temp)
+		if err != nil {
+			return []JournalAccount{}, err
+		}
+		result = append(result, JournalAccount{
+			Type: temp.Type.String,
+			Code: temp.Code.String,
+			Name: temp.Name.String,
+		})
+	}
+
+	return result, rows.Err()
+}
+
+// SetJournalAccounts maps the journal accounts of the company, replacing the ones already mapped
+func (repo *PayrollRepository) SetJournalAccounts(ctx context.Context, accounts []JournalAccount) error {
+	userID := xcontext.GetUserIDFromContext(ctx)
+	companyID := xcontext.GetCompanyIDFromContext(ctx)
+
+	sq := sqlbuilder.NewInsertBuilder()
+	sq.InsertInto(`hr.journal_accounts`).Cols(`company_id`, `account_type`, `code`, `name`, `created_at`, `created_by`)
+	for _, account := range accounts {
+		sq.Values(companyID, account.Type, account.Code, account.Name, `now()`, userID)
+	}
+	sq.SQL(`ON CONFLICT (company_id, account_type) DO UPDATE SET code = EXCLUDED.code, name = EXCLUDED.name, updated_at = now(), updated_by = EXCLUDED.created_by`)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	_, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetPayrollPeriodCurrencies returns the salary, payout and reimbursement currencies of the period's eligible users
+func (repo *PayrollRepository) GetPayrollPeriodCurrencies(ctx context.Context, period PayrollPeriod, statuses []string) ([]string, error) {
+	users := sqlbuilder.NewSelectBuilder()
+	users.Select(`id`, `salary_currency`, `payout_currency`).From(`hr.users`).Where(
+		users.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+		users.Equal(`pay_group_id`, period.PayGroupID),
+		users.In(`employment_status`, sqlbuilder.List(statuses)),
+		users.Or(
+			users.IsNull(`employment_start_date`),
+			users.LessThan(`employment_start_date`, xdate.Of(period.EndDate)),
+		),
+		users.Or(
+			users.IsNull(`employment_end_date`),
+			users.GreaterEqualThan(`employment_end_date`, xdate.Of(period.StartDate)),
+		),
+		users.IsNull(`deleted_at`),
+	)
+
+	// reimbursements belong to the period of their submission date in the company timezone
+	createdDate := fmt.Sprintf(`(r.created_at AT TIME ZONE %s)::date`, pq.QuoteLiteral(repo.deps.Config.App.Timezone.String()))
+
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`DISTINCT c.currency`).
+		From(sq.BuilderAs(users, `u`)).
+		JoinWithOption(sqlbuilder.LeftJoin, `hr.reimbursements r`, `r.user_id = u.id`, `r.deleted_at IS NULL`,
+			sq.GreaterEqualThan(createdDate, xdate.Of(period.StartDate)),
+			sq.LessThan(createdDate, xdate.Of(period.EndDate)),
+		).
+		Join(`LATERAL (VALUES (u.salary_currency), (u.payout_currency), (r.currency)) c (currency)`, `c.currency IS NOT NULL`).
+		OrderBy(`c.currency`)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	rows, err := tx.QueryxContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []string
+	for rows.Next() {
+		var currency string
+		err := rows.Scan(&currency)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, currency)
+	}
+
+	return result, rows.Err()
+}
+
+var payrollPeriodColumns = []string{`id`, `pay_group_id`, `start_date`, `end_date`, `total_work_days`, `processed`, `total_salary_paid`, `total_salary_paid_by_currency`, `scheduled`, `ready_notified_at`, `payslip_chain_hash`, `payslip_chain_signature`}
+
+func (repo *PayrollRepository) getPayrollPeriod(ctx context.Context, q string, args []interface{}) (PayrollPeriod, error) {
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	var temp SQLPayrollPeriod
+	err := tx.QueryRowxContext(ctx, q, args...).StructScan(&temp)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return PayrollPeriod{}, xerror.ErrDataNotFound
+		}
+
+		return PayrollPeriod{}, err
+	}
+
+	return toPayrollPeriod(temp), nil
+}
+
+func toPayrollPeriod(temp SQLPayrollPeriod) PayrollPeriod {
+	result := PayrollPeriod{
+		ID:              temp.ID.String,
+		PayGroupID:      temp.PayGroupID.String,
+		StartDate:       xdate.Of(temp.StartDate.Time).Time(),
+		EndDate:         xdate.Of(temp.EndDate.Time).Time(),
+		TotalWorkDays:   int(temp.TotalWorkDays.Int64),
+		Processed:       temp.Processed.Bool,
+		TotalSalaryPaid: temp.TotalSalaryPaid.Float64,
+		Scheduled:       temp.Scheduled.Bool,
+		PayslipChain: PayslipChain{
+			Hash:      temp.PayslipChainHash.String,
+			Signature: temp.PayslipChainSignature.String,
+		},
+	}
+	if temp.ReadyNotifiedAt.Valid {
+		result.ReadyNotifiedAt = &temp.ReadyNotifiedAt.Time
+	}
+	if len(temp.TotalSalaryPaidByCurrency) != 0 {
+		// the totals are only informative, a broken value shouldn't make the period unreadable
+		_ = json.Unmarshal(temp.TotalSalaryPaidByCurrency, &result.TotalSalaryPaidByCurrency)
+	}
+
+	return result
+}
+
+func toPayrollPeriodEvent(data PayrollPeriod) PayrollPeriodEvent {
+	return PayrollPeriodEvent{
+		ID:            data.ID,
+		PayGroupID:    data.PayGroupID,
+		StartDate:     xdate.Of(data.StartDate).String(),
+		EndDate:       xdate.Of(data.EndDate).String(),
+		TotalWorkDays: data.TotalWorkDays,
+		Scheduled:     data.Scheduled,
+	}
+}
+
+func toPayslip(temp SQLPayslip) (models.Payslip, error) {
+	// payslips without reimbursements used to be stored with an empty object
+	var list []models.Reimbursement
+	if len(temp.ReimbursementList) != 0 && string(temp.ReimbursementList) != `{}` {
+		err := json.Unmarshal(temp.ReimbursementList, &list)
+		if err != nil {
+			return models.Payslip{}, fmt.Errorf("failed to unmarshal reimbursement list: %w", err)
+		}
+	}
+
+	var categories []models.ReimbursementCategoryTotal
+	if len(temp.ReimbursementByCategory) != 0 {
+		err := json.Unmarshal(temp.ReimbursementByCategory, &categories)
+		if err != nil {
+			return models.Payslip{}, fmt.Errorf("failed to unmarshal reimbursement categories: %w", err)
+		}
+	}
+
+	reviewReasons := []string{}
+	if len(temp.ReviewReasons) != 0 {
+		err := json.Unmarshal(temp.ReviewReasons, &reviewReasons)
+		if err != nil {
+			return models.Payslip{}, fmt.Errorf("failed to unmarshal review reasons: %w", err)
+		}
+	}
+
+	deductions := []models.Deduction{}
+	if len(temp.DeductionList) != 0 {
+		err := json.Unmarshal(temp.DeductionList, &deductions)
+		if err != nil {
+			return models.Payslip{}, fmt.Errorf("failed to unmarshal deduction list: %w", err)
+		}
+	}
+
+	result := models.Payslip{
+		ID:                 temp.ID.String,
+		Name:               temp.Name.String,
+		UserID:             temp.UserID.String,
+		PayrollID:          temp.PayrollID.String,
+		BaseSalary:         temp.BaseSalary.Float64,
+		TotalAttendance:    int(temp.TotalAttendance.Int64),
+		TotalWorkDay:       int(temp.TotalWorkDay.Int64),
+		TotalOvertimeHour:  int(temp.TotalOvertimeHour.Int64),
+		OvertimePay:        temp.OvertimePay.Float64,
+		ReimbursementList:  list,
+		TotalReimbursement: temp.TotalReimbursement.Float64,
+		TakeHomePay:        temp.TakeHomePay.Float64,
+
+		ReimbursementCategories:   categories,
+		TotalTaxableReimbursement: temp.TotalTaxableReimbursement.Float64,
+
+		PaidLeaveDays:   int(temp.PaidLeaveDays.Int64),
+		UnpaidLeaveDays: int(temp.UnpaidLeaveDays.Int64),
+		ReviewReasons:   reviewReasons,
+
+		Type:             temp.Type.String,
+		EmployedWorkDays: int(temp.EmployedWorkDays.Int64),
+		UnusedLeaveDays:  int(temp.UnusedLeaveDays.Int64),
+		LeavePayout:      temp.LeavePayout.Float64,
+		DeductionList:    deductions,
+		TotalDeduction:   temp.TotalDeduction.Float64,
+
+		SalaryCurrency:     temp.SalaryCurrency.String,
+		OriginalSalary:     temp.OriginalSalary.Float64,
+		PayoutCurrency:     temp.PayoutCurrency.String,
+		SalaryExchangeRate: temp.SalaryExchangeRate.Float64,
+
+		PrevHash:  temp.PrevHash.String,
+		Hash:      temp.Hash.String,
+		Signature: temp.Signature.String,
+	}
+
+	return result, nil
+}
+
+var payrollJobColumns = []string{`id`, `company_id`, `payroll_id`, `status`, `total`, `processed`, `errors`, `started_at`, `finished_at`, `created_at`, `created_by`}
+
+func (repo *PayrollRepository) getPayrollJob(ctx context.Context, q string, args []interface{}) (PayrollJob, error) {
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	var temp SQLPayrollJob
+	err := tx.QueryRowxContext(ctx, q, args...).StructScan(&temp)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return PayrollJob{}, xerror.ErrDataNotFound
+		}
+
+		return PayrollJob{}, err
+	}
+
+	var jobErrors []string
+	if len(temp.Errors) != 0 {
+		err := json.Unmarshal(temp.Errors, &jobErrors)
+		if err != nil {
+			return PayrollJob{}, fmt.Errorf("failed to unmarshal payroll job errors: %w", err)
+		}
+	}
+
+	result := PayrollJob{
+		ID:        temp.ID.String,
+		CompanyID: temp.CompanyID.String,
+		PayrollID: temp.PayrollID.String,
+		Status:    temp.Status.String,
+		Total:     int(temp.Total.Int64),
+		Processed: int(temp.Processed.Int64),
+		Errors:    jobErrors,
+		CreatedAt: temp.CreatedAt.Time,
+		CreatedBy: temp.CreatedBy.String,
+	}
+	if temp.StartedAt.Valid {
+		result.StartedAt = &temp.StartedAt.Time
+	}
+	if temp.FinishedAt.Valid {
+		result.FinishedAt = &temp.FinishedAt.Time
+	}
+
+	return result, nil
+}
+
+func (repo *PayrollRepository) CreatePayGroup(ctx context.Context, data PayGroup) error {
+	sq := sqlbuilder.NewInsertBuilder()
+	sq.InsertInto(`hr.pay_groups`).
+		Cols(`id`, `company_id`, `code`, `name`, `frequency`, `period_anchor_day`, `auto_schedule`, `created_at`, `created_by`).
+		Values(data.ID, xcontext.GetCompanyIDFromContext(ctx), data.Code, data.Name, data.Frequency, data.PeriodAnchorDay, data.AutoSchedule, `now()`, xcontext.GetUserIDFromContext(ctx))
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	_, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repo *PayrollRepository) UpdatePayGroup(ctx context.Context, data PayGroup) error {
+	sq := sqlbuilder.NewUpdateBuilder()
+	sq.Update(`hr.pay_groups`).Set(
+		sq.Assign(`name`, data.Name),
+		sq.Assign(`frequency`, data.Frequency),
+		sq.Assign(`period_anchor_day`, data.PeriodAnchorDay),
+		sq.Assign(`auto_schedule`, data.AutoSchedule),
+		`updated_at = now()`,
+		sq.Assign(`updated_by`, xcontext.GetUserIDFromContext(ctx)),
+	).Where(
+		sq.Equal(`id`, data.ID),
+		sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+		sq.IsNull(`deleted_at`),
+	)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	res, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return xerror.ErrDataNotFound
+	}
+
+	return nil
+}
+
+func (repo *PayrollRepository) GetPayGroups(ctx context.Context) ([]PayGroup, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(payGroupColumns...).From(`hr.pay_groups`).Where(sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)), sq.IsNull(`deleted_at`)).OrderBy(`code`)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	rows, err := tx.QueryxContext(ctx, q, args...)
+	if err != nil {
+		return []PayGroup{}, err
+	}
+	defer rows.Close()
+
+	result := []PayGroup{}
+	for rows.Next() {
+		var temp SQLPayGroup
+		err := rows.StructScan(&temp)
+		if err != nil {
+			repo.deps.Logger.WarnContext(ctx, "failed to scan pay group", slog.Any("error", err))
+			continue
+		}
+		result = append(result, toPayGroup(temp))
+	}
+
+	return result, nil
+}
+
+func (repo *PayrollRepository) GetPayGroupByID(ctx context.Context, id string) (PayGroup, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(payGroupColumns...).From(`hr.pay_groups`).Where(
+		sq.Equal(`id`, id),
+		sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+		sq.IsNull(`deleted_at`),
+	)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	return repo.getPayGroup(ctx, q, args)
+}
+
+func (repo *PayrollRepository) GetPayGroupByCode(ctx context.Context, code string) (PayGroup, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(payGroupColumns...).From(`hr.pay_groups`).Where(
+		sq.Equal(`code`, code),
+		sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+		sq.IsNull(`deleted_at`),
+	)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	return repo.getPayGroup(ctx, q, args)
+}
+
+// AssignPayGroupUsers moves the users to the pay group, returning how many users are assigned
+func (repo *PayrollRepository) AssignPayGroupUsers(ctx context.Context, payGroupID string, userIDs []string) (int, error) {
+	sq := sqlbuilder.NewUpdateBuilder()
+	sq.Update(`hr.users`).Set(
+		sq.Assign(`pay_group_id`, payGroupID),
+		`updated_at = now()`,
+		sq.Assign(`updated_by`, xcontext.GetUserIDFromContext(ctx)),
+	).Where(
+		sq.In(`id::text`, sqlbuilder.List(userIDs)),
+		sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+		sq.IsNull(`deleted_at`),
+	)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	res, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(affected), nil
+}
+
+var payGroupColumns = []string{`id`, `code`, `name`, `frequency`, `period_anchor_day`, `auto_schedule`, `created_at`}
+
+func (repo *PayrollRepository) getPayGroup(ctx context.Context, q string, args []interface{}) (PayGroup, error) {
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	var temp SQLPayGroup
+	err := tx.QueryRowxContext(ctx, q, args...).StructScan(&temp)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return PayGroup{}, xerror.ErrDataNotFound
+		}
+
+		return PayGroup{}, err
+	}
+
+	return toPayGroup(temp), nil
+}
+
+func toPayGroup(temp SQLPayGroup) PayGroup {
+	return PayGroup{
+		ID:        temp.ID.String,
+		Code:      temp.Code.String,
+		Name:      temp.Name.String,
+		Frequency: temp.Frequency.String,
+
+		PeriodAnchorDay: int(temp.PeriodAnchorDay.Int64),
+		AutoSchedule:    temp.AutoSchedule.Bool,
+
+		CreatedAt: temp.CreatedAt.Time,
+	}
 }
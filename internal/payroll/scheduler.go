@@ -0,0 +1,234 @@
+package payroll
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rahadianir/dealls/internal/company"
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/pkg/xaudit"
+	"github.com/rahadianir/dealls/internal/pkg/xcontext"
+	"github.com/rahadianir/dealls/internal/pkg/xdate"
+	"github.com/rahadianir/dealls/internal/pkg/xerror"
+	"github.com/rahadianir/dealls/internal/pkg/xnotify"
+	"github.com/rahadianir/dealls/internal/user"
+)
+
+// PayrollScheduler creates, activates and announces the payroll periods of auto scheduled pay groups
+type PayrollScheduler struct {
+	deps         *config.CommonDependencies
+	payrollRepo  PayrollRepositoryInterface
+	userRepo     user.UserRepositoryInterface
+	companyRepo  company.CompanyRepositoryInterface
+	payrollLogic PayrollLogicInterface
+	notifier     xnotify.Notifier
+	auditor      xaudit.Recorder
+}
+
+func NewPayrollScheduler(deps *config.CommonDependencies, payrollRepo PayrollRepositoryInterface, userRepo user.UserRepositoryInterface, companyRepo company.CompanyRepositoryInterface, payrollLogic PayrollLogicInterface, notifier xnotify.Notifier, auditor xaudit.Recorder) *PayrollScheduler {
+	return &PayrollScheduler{
+		deps:         deps,
+		payrollRepo:  payrollRepo,
+		userRepo:     userRepo,
+		companyRepo:  companyRepo,
+		payrollLogic: payrollLogic,
+		notifier:     notifier,
+		auditor:      auditor,
+	}
+}
+
+// Run schedules the payroll periods of every company's auto scheduled pay groups once, it's meant to be run periodically (e.g. daily by cron).
+// A failing company doesn't hold the others back.
+func (s *PayrollScheduler) Run(ctx context.Context, now time.Time, preview bool) error {
+	companies, err := s.companyRepo.GetCompanies(ctx)
+	if err != nil {
+		s.deps.Logger.ErrorContext(ctx, "failed to get companies", slog.Any("error", err))
+		return err
+	}
+
+	// there's no user behind the run, the audit log tells its changes apart by the request id
+	ctx = context.WithValue(ctx, xcontext.RequestIDKey, "payroll-scheduler-"+uuid.NewString())
+
+	var errs []error
+	for _, c := range companies {
+		// the repositories are scoped to the company in context
+		companyCtx := context.WithValue(ctx, xcontext.CompanyIDKey, c.ID)
+
+		err := s.scheduleCompany(companyCtx, now, preview)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("company %s: %w", c.Code, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// scheduleCompany schedules the payroll periods of the company's auto scheduled pay groups.
+// For each pay group it:
+//   - creates the next period following the pay group template once it starts within the configured schedule ahead duration
+//   - activates the next period once it started and the active one is processed
+//   - notifies admins once the active period ended and is ready to process, along with its preview if asked to
+func (s *PayrollScheduler) scheduleCompany(ctx context.Context, now time.Time, preview bool) error {
+	payGroups, err := s.payrollRepo.GetPayGroups(ctx)
+	if err != nil {
+		s.deps.Logger.ErrorContext(ctx, "failed to get pay groups", slog.Any("error", err))
+		return err
+	}
+
+	// periods are company dates, so the schedule works on the current date in the company timezone
+	today := xdate.In(now, s.deps.Config.App.Timezone).Time()
+
+	var errs []error
+	for _, payGroup := range payGroups {
+		if !payGroup.AutoSchedule {
+			continue
+		}
+
+		err := s.schedulePayGroup(ctx, payGroup, today, preview)
+		if err != nil {
+			s.deps.Logger.ErrorContext(ctx, "failed to schedule pay group payroll periods", slog.String("pay_group", payGroup.Code), slog.Any("error", err))
+			errs = append(errs, fmt.Errorf("pay group %s: %w", payGroup.Code, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *PayrollScheduler) schedulePayGroup(ctx context.Context, payGroup PayGroup, today time.Time, preview bool) error {
+	err := s.createNextPeriod(ctx, payGroup, today)
+	if err != nil {
+		return err
+	}
+
+	active, err := s.payrollRepo.GetActivePayrollPeriod(ctx, payGroup.ID)
+	hasActive := err == nil
+	if err != nil && !errors.Is(err, xerror.ErrDataNotFound) {
+		return fmt.Errorf("failed to get active payroll period: %w", err)
+	}
+
+	// the active period is kept until it's processed, so its payroll is never skipped
+	if !hasActive || active.Processed {
+		var from time.Time
+		if hasActive {
+			from = active.EndDate
+		}
+
+		next, err := s.payrollRepo.GetNextPayrollPeriod(ctx, payGroup.ID, from)
+		if err != nil && !errors.Is(err, xerror.ErrDataNotFound) {
+			return fmt.Errorf("failed to get next payroll period: %w", err)
+		}
+
+		if err == nil && !next.StartDate.After(today) {
+			err = xaudit.Record(ctx, s.auditor, xaudit.Entry{
+				Action:   xaudit.ActionActivate,
+				Entity:   xaudit.EntityPayrollPeriod,
+				EntityID: next.ID,
+			}, func(ctx context.Context) error {
+				return s.payrollRepo.ActivatePayrollPeriod(ctx, next)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to activate payroll period: %w", err)
+			}
+			s.deps.Logger.InfoContext(ctx, "payroll period activated", slog.String("pay_group", payGroup.Code), slog.String("payroll_id", next.ID))
+
+			active, hasActive = next, true
+		}
+	}
+
+	// the end date is exclusive, so the period is over on its end date
+	if hasActive && !active.Processed && !active.EndDate.After(today) && active.ReadyNotifiedAt == nil {
+		err = s.notifyPeriodReady(ctx, payGroup, active, preview)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createNextPeriod creates the period following the latest one, or the one of the current date if there's none yet
+func (s *PayrollScheduler) createNextPeriod(ctx context.Context, payGroup PayGroup, today time.Time) error {
+	latest, err := s.payrollRepo.GetLatestPayrollPeriod(ctx, payGroup.ID)
+	if err != nil && !errors.Is(err, xerror.ErrDataNotFound) {
+		return fmt.Errorf("failed to get latest payroll period: %w", err)
+	}
+
+	var start, end time.Time
+	if err == nil {
+		start = latest.EndDate
+		_, end = periodBounds(payGroup, start)
+	} else {
+		start, end = periodBounds(payGroup, today)
+	}
+
+	if start.After(today.Add(s.deps.Config.Payroll.ScheduleAhead)) {
+		return nil
+	}
+
+	period := PayrollPeriod{
+		ID:            uuid.NewString(),
+		PayGroupID:    payGroup.ID,
+		StartDate:     start,
+		EndDate:       end,
+		TotalWorkDays: calculateWorkingDays(start, end),
+		Scheduled:     true,
+	}
+	err = xaudit.Record(ctx, s.auditor, xaudit.Entry{
+		Action:   xaudit.ActionCreate,
+		Entity:   xaudit.EntityPayrollPeriod,
+		EntityID: period.ID,
+		After:    auditPayrollPeriod(period),
+	}, func(ctx context.Context) error {
+		return s.payrollRepo.CreatePayrollPeriod(ctx, period)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create payroll period: %w", err)
+	}
+	s.deps.Logger.InfoContext(ctx, "payroll period created", slog.String("pay_group", payGroup.Code), slog.String("payroll_id", period.ID), slog.Time("start_date", start), slog.Time("end_date", end))
+
+	return nil
+}
+
+func (s *PayrollScheduler) notifyPeriodReady(ctx context.Context, payGroup PayGroup, period PayrollPeriod, preview bool) error {
+	admins, err := s.userRepo.GetAdminUsernames(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get admins: %w", err)
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "The %s payroll period from %s to %s is over and ready to be processed (payroll id %s).",
+		payGroup.Name, period.StartDate.Format(time.DateOnly), period.EndDate.AddDate(0, 0, -1).Format(time.DateOnly), period.ID)
+
+	// a failed preview shouldn't hold the notification back
+	if preview {
+		result, err := s.payrollLogic.PreviewPayrollPeriod(ctx, period)
+		if err != nil {
+			s.deps.Logger.WarnContext(ctx, "failed to preview payroll period", slog.String("payroll_id", period.ID), slog.Any("error", err))
+			body.WriteString("\nThe payroll preview failed, please check the logs.")
+		} else {
+			fmt.Fprintf(&body, "\nPreview: %d payslips, %.2f %s total take home pay, %d need review, %d final settlements.",
+				result.TotalPayslips, result.TotalTakeHomePay, result.BaseCurrency, result.TotalNeedsReview, result.TotalFinalSettlement)
+		}
+	}
+
+	err = s.notifier.Notify(ctx, xnotify.Message{
+		Recipients: admins,
+		Subject:    fmt.Sprintf("%s payroll period is ready to process", payGroup.Name),
+		Body:       body.String(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to notify admins: %w", err)
+	}
+
+	err = s.payrollRepo.MarkPayrollReadyNotified(ctx, period.ID)
+	if err != nil {
+		return fmt.Errorf("failed to mark payroll period notified: %w", err)
+	}
+
+	return nil
+}
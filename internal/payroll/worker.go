@@ -0,0 +1,82 @@
+package payroll
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/pkg/xcontext"
+	"github.com/rahadianir/dealls/internal/pkg/xerror"
+)
+
+// PayrollJobWorker processes the queued payroll jobs in the background
+type PayrollJobWorker struct {
+	deps         *config.CommonDependencies
+	payrollRepo  PayrollRepositoryInterface
+	payrollLogic PayrollLogicInterface
+}
+
+func NewPayrollJobWorker(deps *config.CommonDependencies, payrollRepo PayrollRepositoryInterface, payrollLogic PayrollLogicInterface) *PayrollJobWorker {
+	return &PayrollJobWorker{
+		deps:         deps,
+		payrollRepo:  payrollRepo,
+		payrollLogic: payrollLogic,
+	}
+}
+
+// Start polls for queued jobs until the context is cancelled.
+// Jobs left running by a previous server run are picked up again once they're considered stale.
+func (w *PayrollJobWorker) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.deps.Config.Payroll.JobPollInterval)
+	defer ticker.Stop()
+
+	w.deps.Logger.InfoContext(ctx, "payroll job worker starts")
+	for {
+		w.processQueuedJobs(ctx)
+
+		select {
+		case <-ctx.Done():
+			w.deps.Logger.InfoContext(ctx, "payroll job worker stopped")
+			return
+		case <-ticker.C:
+		case <-w.payrollLogic.JobQueued():
+		}
+	}
+}
+
+func (w *PayrollJobWorker) processQueuedJobs(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := w.payrollRepo.ClaimPayrollJob(ctx, w.deps.Config.Payroll.JobStaleAfter)
+		if err != nil {
+			if !errors.Is(err, xerror.ErrDataNotFound) && ctx.Err() == nil {
+				w.deps.Logger.ErrorContext(ctx, "failed to claim payroll job", slog.Any("error", err))
+			}
+			return
+		}
+
+		// run the job on behalf of the admin who queued it within their company, traced by the job ID
+		jobCtx := context.WithValue(ctx, xcontext.RequestIDKey, "payroll-job-"+job.ID)
+		jobCtx = context.WithValue(jobCtx, xcontext.UserIDKey, job.CreatedBy)
+		jobCtx = context.WithValue(jobCtx, xcontext.CompanyIDKey, job.CompanyID)
+
+		w.deps.Logger.InfoContext(jobCtx, "processing payroll job", slog.String("payroll_id", job.PayrollID))
+		err = w.payrollLogic.ProcessPayrollJob(jobCtx, job)
+		if err != nil {
+			if ctx.Err() != nil {
+				// shutting down, put the job back so it's resumed right away on the next start
+				releaseErr := w.payrollRepo.ReleasePayrollJob(context.WithoutCancel(jobCtx), job.ID)
+				if releaseErr != nil {
+					w.deps.Logger.ErrorContext(jobCtx, "failed to release payroll job", slog.Any("error", releaseErr))
+				}
+				return
+			}
+
+			w.deps.Logger.ErrorContext(jobCtx, "failed to process payroll job", slog.Any("error", err))
+			continue
+		}
+
+		w.deps.Logger.InfoContext(jobCtx, "payroll job completed")
+	}
+}
@@ -21,7 +21,7 @@ type DBTX interface {
 	QueryRowxContext(context.Context, string, ...interface{}) *sqlx.Row
 }
 
-func InjectTx(ctx context.Context, tx *sql.Tx) context.Context {
+func InjectTx(ctx context.Context, tx *sqlx.Tx) context.Context {
 	return context.WithValue(ctx, TXKey, tx)
 }
 
@@ -34,8 +34,14 @@ func ExtractTx(ctx context.Context, dbConn *sqlx.DB) (tx DBTX) {
 	return tx
 }
 
+// WithTransaction runs txfunc within a transaction, joining the one in the context if there is one so nested
+// calls commit or roll back together
 func WithTransaction(ctx context.Context, dbConn *sqlx.DB, txfunc func(context.Context) error) error {
-	tx, err := dbConn.BeginTx(ctx, nil)
+	if _, ok := ctx.Value(TXKey).(*sqlx.Tx); ok {
+		return txfunc(ctx)
+	}
+
+	tx, err := dbConn.BeginTxx(ctx, nil)
 	if err != nil {
 		return xerror.ServerError{Err: err}
 	}
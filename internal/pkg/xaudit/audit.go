@@ -0,0 +1,87 @@
+package xaudit
+
+import (
+	"context"
+	"fmt"
+)
+
+// entities changed by the audited operations
+const (
+	EntityUser                  = "user"
+	EntityEmployment            = "employment"
+	EntitySalary                = "salary"
+	EntityTwoFactor             = "two_factor"
+	EntitySession               = "session"
+	EntityUserIdentity          = "user_identity"
+	EntityAttendance            = "attendance"
+	EntityOvertime              = "overtime"
+	EntityLeave                 = "leave"
+	EntityReimbursement         = "reimbursement"
+	EntityReimbursementCategory = "reimbursement_category"
+	EntityPayrollPeriod         = "payroll_period"
+	EntityPayrollJob            = "payroll_job"
+	EntityDeduction             = "deduction"
+	EntityPayGroup              = "pay_group"
+	EntityExchangeRates         = "exchange_rates"
+	EntityJournalAccount        = "journal_account"
+	EntityAPIKey                = "api_key"
+	EntityCompany               = "company"
+	EntityWebhookSubscription   = "webhook_subscription"
+	EntitySigningKey            = "signing_key"
+)
+
+// actions of the audited operations, on top of creating and updating the entity
+const (
+	ActionCreate               = "create"
+	ActionUpdate               = "update"
+	ActionDelete               = "delete"
+	ActionActivate             = "activate"
+	ActionTerminate            = "terminate"
+	ActionProcess              = "process"
+	ActionRevoke               = "revoke"
+	ActionEnable               = "enable"
+	ActionDisable              = "disable"
+	ActionEnrol                = "enrol"
+	ActionReset                = "reset"
+	ActionUnlock               = "unlock"
+	ActionChangePassword       = "change_password"
+	ActionRequestPasswordReset = "request_password_reset"
+	ActionResetPassword        = "reset_password"
+	ActionAssignUsers          = "assign_users"
+	ActionUploadReceipts       = "upload_receipts"
+)
+
+// Recorder appends entries to the audit log, the actor, API key, request ID and IP are taken from the context
+type Recorder interface {
+	Record(ctx context.Context, entry Entry) error
+	// WithTransaction runs txfunc within the transaction the entries are written in
+	WithTransaction(ctx context.Context, txfunc func(context.Context) error) error
+}
+
+// Entry is a change made by an operation. Before is nil for creations, and both are left out when the change
+// can't be shown, e.g. passwords.
+type Entry struct {
+	Action   string
+	Entity   string
+	EntityID string
+	Before   any
+	After    any
+}
+
+// Record makes the change and records its entry within one transaction, like the webhook outbox, so the change
+// is rolled back when its entry can't be recorded
+func Record(ctx context.Context, recorder Recorder, entry Entry, change func(context.Context) error) error {
+	return recorder.WithTransaction(ctx, func(ctx context.Context) error {
+		err := change(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = recorder.Record(ctx, entry)
+		if err != nil {
+			return fmt.Errorf("failed to record audit log of %s %s: %w", entry.Action, entry.Entity, err)
+		}
+
+		return nil
+	})
+}
@@ -7,6 +7,31 @@ type contextKey string
 const RequestIDKey contextKey = "request.id"
 const IPKey contextKey = "ip"
 const UserIDKey contextKey = "user.id"
+const CompanyIDKey contextKey = "company.id"
+const TokenIDKey contextKey = "token.id"
+const SessionIDKey contextKey = "session.id"
+const MustChangePasswordKey contextKey = "user.must_change_password"
+const TwoFactorSetupKey contextKey = "user.two_factor_setup"
+const APIKeyIDKey contextKey = "api_key.id"
+const APIKeyScopesKey contextKey = "api_key.scopes"
+
+func GetRequestIDFromContext(ctx context.Context) string {
+	requestID, ok := ctx.Value(RequestIDKey).(string)
+	if !ok {
+		return ""
+	}
+
+	return requestID
+}
+
+func GetIPFromContext(ctx context.Context) string {
+	ip, ok := ctx.Value(IPKey).(string)
+	if !ok {
+		return ""
+	}
+
+	return ip
+}
 
 func GetUserIDFromContext(ctx context.Context) string {
 	userID, ok := ctx.Value(UserIDKey).(string)
@@ -16,3 +41,67 @@ func GetUserIDFromContext(ctx context.Context) string {
 
 	return userID
 }
+
+func GetCompanyIDFromContext(ctx context.Context) string {
+	companyID, ok := ctx.Value(CompanyIDKey).(string)
+	if !ok {
+		return ""
+	}
+
+	return companyID
+}
+
+func GetTokenIDFromContext(ctx context.Context) string {
+	tokenID, ok := ctx.Value(TokenIDKey).(string)
+	if !ok {
+		return ""
+	}
+
+	return tokenID
+}
+
+func GetSessionIDFromContext(ctx context.Context) string {
+	sessionID, ok := ctx.Value(SessionIDKey).(string)
+	if !ok {
+		return ""
+	}
+
+	return sessionID
+}
+
+func GetMustChangePasswordFromContext(ctx context.Context) bool {
+	mustChange, ok := ctx.Value(MustChangePasswordKey).(bool)
+	if !ok {
+		return false
+	}
+
+	return mustChange
+}
+
+func GetTwoFactorSetupFromContext(ctx context.Context) bool {
+	setup, ok := ctx.Value(TwoFactorSetupKey).(bool)
+	if !ok {
+		return false
+	}
+
+	return setup
+}
+
+// GetAPIKeyIDFromContext returns the API key the request was made with, empty for requests made with a token
+func GetAPIKeyIDFromContext(ctx context.Context) string {
+	apiKeyID, ok := ctx.Value(APIKeyIDKey).(string)
+	if !ok {
+		return ""
+	}
+
+	return apiKeyID
+}
+
+func GetAPIKeyScopesFromContext(ctx context.Context) []string {
+	scopes, ok := ctx.Value(APIKeyScopesKey).([]string)
+	if !ok {
+		return []string{}
+	}
+
+	return scopes
+}
@@ -0,0 +1,98 @@
+package xcrypto
+
+import (
+	"testing"
+)
+
+func TestSign(t *testing.T) {
+	type args struct {
+		message []byte
+		secret  string
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		// TODO: Add test cases.
+		{
+			// RFC 4231 test case 2
+			name: "success sign rfc 4231 vector",
+			args: args{message: []byte("what do ya want for nothing?"), secret: "Jefe"},
+			want: "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sign(tt.args.message, tt.args.secret); got != tt.want {
+				t.Errorf("Sign() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	message := []byte("payroll-id:5b0f3c7e2a94d1f6")
+	secret := "payslip-signing-secret"
+	signature := Sign(message, secret)
+
+	type args struct {
+		message   []byte
+		signature string
+		secret    string
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		// TODO: Add test cases.
+		{
+			name: "success round trip",
+			args: args{message: message, signature: signature, secret: secret},
+			want: true,
+		},
+		{
+			name: "failed tampered message",
+			args: args{message: []byte("payroll-id:5b0f3c7e2a94d1f7"), signature: signature, secret: secret},
+			want: false,
+		},
+		{
+			name: "failed tampered signature",
+			args: args{message: message, signature: "0" + signature[1:], secret: secret},
+			want: false,
+		},
+		{
+			name: "failed other secret",
+			args: args{message: message, signature: signature, secret: "other-secret"},
+			want: false,
+		},
+		{
+			name: "failed truncated signature",
+			args: args{message: message, signature: signature[:len(signature)-2], secret: secret},
+			want: false,
+		},
+		{
+			name: "failed extended signature",
+			args: args{message: message, signature: signature + "00", secret: secret},
+			want: false,
+		},
+		{
+			name: "failed empty signature",
+			args: args{message: message, signature: "", secret: secret},
+			want: false,
+		},
+		{
+			name: "failed non hex signature",
+			args: args{message: message, signature: "zz" + signature[2:], secret: secret},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Verify(tt.args.message, tt.args.signature, tt.args.secret); got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package xcurrency
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Normalize upper-cases the ISO 4217 currency code and validates its format, e.g. "usd" is "USD"
+func Normalize(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 {
+		return "", fmt.Errorf("invalid currency %q, must be a 3 letter ISO 4217 code", code)
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return "", fmt.Errorf("invalid currency %q, must be a 3 letter ISO 4217 code", code)
+		}
+	}
+
+	return code, nil
+}
+
+// MissingRateError is returned when a conversion needs a rate that isn't set
+type MissingRateError struct {
+	Currency string
+}
+
+func (e MissingRateError) Error() string {
+	return fmt.Sprintf("missing exchange rate of %s", e.Currency)
+}
+
+// Rates converts amounts between currencies through the base currency.
+// A rate is the amount of base currency one unit of the currency is worth, e.g. 1 USD is 16250 IDR.
+type Rates struct {
+	Base  string
+	Rates map[string]float64
+}
+
+// Rate returns the rate converting an amount in from into to
+func (r Rates) Rate(from string, to string) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+
+	fromRate, err := r.toBase(from)
+	if err != nil {
+		return 0, err
+	}
+
+	toRate, err := r.toBase(to)
+	if err != nil {
+		return 0, err
+	}
+
+	return fromRate / toRate, nil
+}
+
+// Convert converts the amount in from into to
+func (r Rates) Convert(amount float64, from string, to string) (float64, error) {
+	rate, err := r.Rate(from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	return amount * rate, nil
+}
+
+// Missing returns the currencies without a rate, sorted
+func (r Rates) Missing(currencies []string) []string {
+	var result []string
+	for _, currency := range currencies {
+		_, err := r.toBase(currency)
+		if err != nil {
+			result = append(result, currency)
+		}
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+func (r Rates) toBase(currency string) (float64, error) {
+	if currency == r.Base {
+		return 1, nil
+	}
+
+	rate, ok := r.Rates[currency]
+	if !ok || rate <= 0 {
+		return 0, MissingRateError{Currency: currency}
+	}
+
+	return rate, nil
+}
@@ -0,0 +1,136 @@
+package xdate
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Date is a calendar date without time of day and timezone, e.g. a payroll period start date.
+// It's kept as midnight UTC so dates compare and subtract in whole days.
+type Date struct {
+	t time.Time
+}
+
+func New(year int, month time.Month, day int) Date {
+	return Date{t: time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+// Of returns the date shown by t in its own location
+func Of(t time.Time) Date {
+	if t.IsZero() {
+		return Date{}
+	}
+	return New(t.Year(), t.Month(), t.Day())
+}
+
+// In returns the date of the instant t in the location, e.g. today in the company timezone
+func In(t time.Time, loc *time.Location) Date {
+	return Of(t.In(loc))
+}
+
+// Parse parses a YYYY-MM-DD date
+func Parse(s string) (Date, error) {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return Date{}, fmt.Errorf("invalid date %q, must be in YYYY-MM-DD format", s)
+	}
+	return Of(t), nil
+}
+
+// Time returns the date as midnight UTC
+func (d Date) Time() time.Time {
+	return d.t
+}
+
+func (d Date) IsZero() bool {
+	return d.t.IsZero()
+}
+
+func (d Date) AddDays(days int) Date {
+	return Date{t: d.t.AddDate(0, 0, days)}
+}
+
+func (d Date) Before(other Date) bool {
+	return d.t.Before(other.t)
+}
+
+func (d Date) After(other Date) bool {
+	return d.t.After(other.t)
+}
+
+func (d Date) Equal(other Date) bool {
+	return d.t.Equal(other.t)
+}
+
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.t.Format(time.DateOnly)
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte(`null`), nil
+	}
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON only accepts YYYY-MM-DD dates, timestamps are rejected as their date depends on the timezone
+func (d *Date) UnmarshalJSON(data []byte) error {
+	if string(data) == `null` {
+		*d = Date{}
+		return nil
+	}
+
+	var s string
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		return fmt.Errorf("invalid date %s, must be a YYYY-MM-DD string", data)
+	}
+	if s == "" {
+		*d = Date{}
+		return nil
+	}
+
+	date, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*d = date
+	return nil
+}
+
+// Value stores the date as a postgres DATE literal, so it's never shifted by the session timezone
+func (d Date) Value() (driver.Value, error) {
+	if d.IsZero() {
+		return nil, nil
+	}
+	return d.String(), nil
+}
+
+func (d *Date) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Date{}
+	case time.Time:
+		*d = Of(v)
+	case string:
+		date, err := Parse(v)
+		if err != nil {
+			return err
+		}
+		*d = date
+	case []byte:
+		date, err := Parse(string(v))
+		if err != nil {
+			return err
+		}
+		*d = date
+	default:
+		return fmt.Errorf("unsupported date type %T", src)
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 var (
@@ -43,6 +44,16 @@ func (e AuthError) Error() string {
 	return e.Err.Error()
 }
 
+// RateLimitError is returned when the client has to wait before trying again
+type RateLimitError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e RateLimitError) Error() string {
+	return e.Err.Error()
+}
+
 func ParseErrorTypeToCodeInt(err error) int {
 	switch {
 	case errors.As(err, &LogicError{}): //200
@@ -51,6 +62,8 @@ func ParseErrorTypeToCodeInt(err error) int {
 		return http.StatusBadRequest
 	case errors.As(err, &AuthError{}): //401
 		return http.StatusUnauthorized
+	case errors.As(err, &RateLimitError{}): //429
+		return http.StatusTooManyRequests
 	case errors.As(err, &ServerError{}): //500
 		return http.StatusInternalServerError
 	case errors.Is(err, ErrDataNotFound):
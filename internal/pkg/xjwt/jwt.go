@@ -2,39 +2,103 @@ package xjwt
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
+var ErrNoSigningKey = fmt.Errorf("no signing key")
+
 type JWTHelper interface {
-	GenerateJWT(issuer string, userID string, expiryTime time.Duration, key string) (string, error)
-	ValidateJWT(token string, secret string) (*jwt.Token, error)
-	GetTokenClaims(token string, secret string) (jwt.Claims, error)
-}
-
-type XJWT struct{}
-
-func (x *XJWT) GenerateJWT(issuer string, userID string, expiryTime time.Duration, key string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS512, jwt.RegisteredClaims{
-		ID:        uuid.NewString(),
-		Issuer:    issuer,
-		Subject:   userID,
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiryTime)),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
-	})
-	tokenString, err := token.SignedString([]byte(key))
+	GenerateJWT(claims Claims, expiryTime time.Duration) (string, error)
+	ValidateJWT(token string) (*jwt.Token, error)
+	GetTokenClaims(token string) (Claims, error)
+	SetKeys(signingKey Key, verificationKeys []Key)
+	JWKS() JSONWebKeySet
+}
+
+// XJWT signs tokens with the current signing key and verifies them with any of the published keys, picked by the token's kid header
+type XJWT struct {
+	mu         sync.RWMutex
+	signingKey *Key
+	keys       map[string]Key
+}
+
+// Claims are the registered claims plus the company the user belongs to and the login session of the token
+type Claims struct {
+	CompanyID          string `json:"company_id"`
+	SessionID          string `json:"sid,omitempty"`
+	MustChangePassword bool   `json:"pwd_change,omitempty"` // the token is only good for changing the temporary password
+	TwoFactorSetup     bool   `json:"2fa_setup,omitempty"`  // the token is only good for enrolling the required two-factor authentication
+	jwt.RegisteredClaims
+}
+
+// SetKeys replaces the key set, tokens signed with keys left out of the verification keys are no longer valid
+func (x *XJWT) SetKeys(signingKey Key, verificationKeys []Key) {
+	keys := make(map[string]Key, len(verificationKeys)+1)
+	for _, key := range verificationKeys {
+		keys[key.ID] = key
+	}
+	keys[signingKey.ID] = signingKey
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.signingKey = &signingKey
+	x.keys = keys
+}
+
+// GenerateJWT signs the claims with a new token ID (jti) that expires after the expiry time
+func (x *XJWT) GenerateJWT(claims Claims, expiryTime time.Duration) (string, error) {
+	x.mu.RLock()
+	key := x.signingKey
+	x.mu.RUnlock()
+	if key == nil || key.PrivateKey == nil {
+		return "", ErrNoSigningKey
+	}
+
+	method, err := key.SigningMethod()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims.ID = uuid.NewString()
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(expiryTime))
+
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = key.ID
+	tokenString, err := token.SignedString(key.PrivateKey)
 	if err != nil {
 		return tokenString, err
 	}
 	return tokenString, nil
 }
 
-func (x *XJWT) ValidateJWT(token string, secret string) (*jwt.Token, error) {
-	parsedToken, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
-		return []byte(secret), nil
-	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS512.Alg()}))
+func (x *XJWT) ValidateJWT(token string) (*jwt.Token, error) {
+	parsedToken, err := jwt.ParseWithClaims(token, &Claims{}, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+
+		x.mu.RLock()
+		key, ok := x.keys[kid]
+		x.mu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+
+		// the algorithm is bound to the key so a token can't pick a weaker one
+		method, err := key.SigningMethod()
+		if err != nil {
+			return nil, err
+		}
+		if t.Method.Alg() != method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s for key %q", t.Method.Alg(), kid)
+		}
+
+		return key.PublicKey, nil
+	}, jwt.WithValidMethods([]string{AlgorithmRS256, AlgorithmEdDSA}))
 	if err != nil {
 		return nil, err
 	}
@@ -43,18 +107,35 @@ func (x *XJWT) ValidateJWT(token string, secret string) (*jwt.Token, error) {
 		return parsedToken, fmt.Errorf("invalid token")
 	}
 
-	if _, ok := parsedToken.Claims.(jwt.MapClaims); !ok {
+	if _, ok := parsedToken.Claims.(*Claims); !ok {
 		return parsedToken, fmt.Errorf("invalid jwt claims")
 	}
 
 	return parsedToken, nil
 }
 
-func (x *XJWT) GetTokenClaims(token string, secret string) (jwt.Claims, error) {
-	parsedToken, err := x.ValidateJWT(token, secret)
+func (x *XJWT) GetTokenClaims(token string) (Claims, error) {
+	parsedToken, err := x.ValidateJWT(token)
 	if err != nil {
-		return jwt.MapClaims{}, err
+		return Claims{}, err
+	}
+
+	return *parsedToken.Claims.(*Claims), nil
+}
+
+// JWKS returns the public keys tokens are verified with, for other services to verify our tokens
+func (x *XJWT) JWKS() JSONWebKeySet {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+	for _, key := range x.keys {
+		jwk, err := key.jwk()
+		if err != nil {
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
 	}
 
-	return parsedToken.Claims, nil
+	return set
 }
@@ -0,0 +1,184 @@
+package xjwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+
+	rsaKeyBits = 2048
+)
+
+// Key is a signing key pair identified by its kid, keys only used for verification have no private key
+type Key struct {
+	ID         string
+	Algorithm  string
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+}
+
+// JSONWebKey is the public part of a key as described in RFC 7517, RSA keys have n and e while Ed25519 keys have crv and x
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// GenerateKey creates a new key pair for the algorithm (RS256 or EdDSA)
+func GenerateKey(id string, algorithm string) (Key, error) {
+	switch algorithm {
+	case AlgorithmRS256:
+		private, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return Key{}, err
+		}
+		return Key{ID: id, Algorithm: algorithm, PrivateKey: private, PublicKey: private.Public()}, nil
+	case AlgorithmEdDSA:
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return Key{}, err
+		}
+		return Key{ID: id, Algorithm: algorithm, PrivateKey: private, PublicKey: public}, nil
+	default:
+		return Key{}, fmt.Errorf("unsupported signing algorithm: %s", algorithm)
+	}
+}
+
+// MarshalKey encodes the private key as PKCS #8 and the public key as PKIX, both DER
+func MarshalKey(key Key) (private []byte, public []byte, err error) {
+	private, err = x509.MarshalPKCS8PrivateKey(key.PrivateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	public, err = x509.MarshalPKIXPublicKey(key.PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return private, public, nil
+}
+
+// ParseKey decodes a key encoded by MarshalKey, the private key is optional for verification only keys
+func ParseKey(id string, algorithm string, private []byte, public []byte) (Key, error) {
+	key := Key{ID: id, Algorithm: algorithm}
+
+	publicKey, err := x509.ParsePKIXPublicKey(public)
+	if err != nil {
+		return Key{}, err
+	}
+	key.PublicKey = publicKey
+
+	if len(private) > 0 {
+		privateKey, err := x509.ParsePKCS8PrivateKey(private)
+		if err != nil {
+			return Key{}, err
+		}
+		signer, ok := privateKey.(crypto.Signer)
+		if !ok {
+			return Key{}, fmt.Errorf("key %s is not a signing key", id)
+		}
+		key.PrivateKey = signer
+	}
+
+	if _, err := key.SigningMethod(); err != nil {
+		return Key{}, err
+	}
+
+	return key, nil
+}
+
+// SigningMethod is the JWT signing method of the key's algorithm, checking the key type matches it
+func (key Key) SigningMethod() (jwt.SigningMethod, error) {
+	switch key.Algorithm {
+	case AlgorithmRS256:
+		if _, ok := key.PublicKey.(*rsa.PublicKey); ok {
+			return jwt.SigningMethodRS256, nil
+		}
+	case AlgorithmEdDSA:
+		if _, ok := key.PublicKey.(ed25519.PublicKey); ok {
+			return jwt.SigningMethodEdDSA, nil
+		}
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", key.Algorithm)
+	}
+
+	return nil, fmt.Errorf("key %s doesn't match algorithm %s", key.ID, key.Algorithm)
+}
+
+func (key Key) jwk() (JSONWebKey, error) {
+	jwk := JSONWebKey{KeyID: key.ID, Use: "sig", Algorithm: key.Algorithm}
+
+	switch public := key.PublicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	default:
+		return JSONWebKey{}, fmt.Errorf("unsupported public key type %T", key.PublicKey)
+	}
+
+	return jwk, nil
+}
+
+// ParseJWK decodes the public key of a JSON web key published by another service, e.g. an identity provider
+func ParseJWK(jwk JSONWebKey) (Key, error) {
+	key := Key{ID: jwk.KeyID, Algorithm: jwk.Algorithm}
+
+	switch jwk.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return Key{}, fmt.Errorf("invalid modulus of key %s: %w", jwk.KeyID, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return Key{}, fmt.Errorf("invalid exponent of key %s: %w", jwk.KeyID, err)
+		}
+		key.PublicKey = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if key.Algorithm == "" {
+			key.Algorithm = AlgorithmRS256
+		}
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil || jwk.Curve != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return Key{}, fmt.Errorf("invalid Ed25519 key %s", jwk.KeyID)
+		}
+		key.PublicKey = ed25519.PublicKey(x)
+		if key.Algorithm == "" {
+			key.Algorithm = AlgorithmEdDSA
+		}
+	default:
+		return Key{}, fmt.Errorf("unsupported key type %s of key %s", jwk.KeyType, jwk.KeyID)
+	}
+
+	if _, err := key.SigningMethod(); err != nil {
+		return Key{}, err
+	}
+
+	return key, nil
+}
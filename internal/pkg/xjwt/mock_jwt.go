@@ -42,46 +42,72 @@ func (m *MockJWTHelper) EXPECT() *MockJWTHelperMockRecorder {
 }
 
 // GenerateJWT mocks base method.
-func (m *MockJWTHelper) GenerateJWT(issuer, userID string, expiryTime time.Duration, key string) (string, error) {
+func (m *MockJWTHelper) GenerateJWT(claims Claims, expiryTime time.Duration) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateJWT", issuer, userID, expiryTime, key)
+	ret := m.ctrl.Call(m, "GenerateJWT", claims, expiryTime)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GenerateJWT indicates an expected call of GenerateJWT.
-func (mr *MockJWTHelperMockRecorder) GenerateJWT(issuer, userID, expiryTime, key any) *gomock.Call {
+func (mr *MockJWTHelperMockRecorder) GenerateJWT(claims, expiryTime any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateJWT", reflect.TypeOf((*MockJWTHelper)(nil).GenerateJWT), issuer, userID, expiryTime, key)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateJWT", reflect.TypeOf((*MockJWTHelper)(nil).GenerateJWT), claims, expiryTime)
 }
 
 // GetTokenClaims mocks base method.
-func (m *MockJWTHelper) GetTokenClaims(token, secret string) (jwt.Claims, error) {
+func (m *MockJWTHelper) GetTokenClaims(token string) (Claims, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTokenClaims", token, secret)
-	ret0, _ := ret[0].(jwt.Claims)
+	ret := m.ctrl.Call(m, "GetTokenClaims", token)
+	ret0, _ := ret[0].(Claims)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetTokenClaims indicates an expected call of GetTokenClaims.
-func (mr *MockJWTHelperMockRecorder) GetTokenClaims(token, secret any) *gomock.Call {
+func (mr *MockJWTHelperMockRecorder) GetTokenClaims(token any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTokenClaims", reflect.TypeOf((*MockJWTHelper)(nil).GetTokenClaims), token, secret)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTokenClaims", reflect.TypeOf((*MockJWTHelper)(nil).GetTokenClaims), token)
+}
+
+// JWKS mocks base method.
+func (m *MockJWTHelper) JWKS() JSONWebKeySet {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JWKS")
+	ret0, _ := ret[0].(JSONWebKeySet)
+	return ret0
+}
+
+// JWKS indicates an expected call of JWKS.
+func (mr *MockJWTHelperMockRecorder) JWKS() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JWKS", reflect.TypeOf((*MockJWTHelper)(nil).JWKS))
+}
+
+// SetKeys mocks base method.
+func (m *MockJWTHelper) SetKeys(signingKey Key, verificationKeys []Key) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetKeys", signingKey, verificationKeys)
+}
+
+// SetKeys indicates an expected call of SetKeys.
+func (mr *MockJWTHelperMockRecorder) SetKeys(signingKey, verificationKeys any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetKeys", reflect.TypeOf((*MockJWTHelper)(nil).SetKeys), signingKey, verificationKeys)
 }
 
 // ValidateJWT mocks base method.
-func (m *MockJWTHelper) ValidateJWT(token, secret string) (*jwt.Token, error) {
+func (m *MockJWTHelper) ValidateJWT(token string) (*jwt.Token, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ValidateJWT", token, secret)
+	ret := m.ctrl.Call(m, "ValidateJWT", token)
 	ret0, _ := ret[0].(*jwt.Token)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ValidateJWT indicates an expected call of ValidateJWT.
-func (mr *MockJWTHelperMockRecorder) ValidateJWT(token, secret any) *gomock.Call {
+func (mr *MockJWTHelperMockRecorder) ValidateJWT(token any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateJWT", reflect.TypeOf((*MockJWTHelper)(nil).ValidateJWT), token, secret)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateJWT", reflect.TypeOf((*MockJWTHelper)(nil).ValidateJWT), token)
 }
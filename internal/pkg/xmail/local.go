@@ -0,0 +1,170 @@
+package xmail
+
+import (
+	"errors"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+// ReceivedMail is an email received by the LocalServer, the data is the raw message
+type ReceivedMail struct {
+	From string
+	To   []string
+	Data []byte
+}
+
+// LocalServer is a minimal in-process SMTP server keeping the received emails in memory, a stand-in for a real SMTP
+// server in tests and local development. It supports neither TLS nor authentication.
+type LocalServer struct {
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu       sync.Mutex
+	received []ReceivedMail
+}
+
+// NewLocalServer starts listening on the address, e.g. "127.0.0.1:0" for a random port
+func NewLocalServer(addr string) (*LocalServer, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &LocalServer{listener: listener}
+	s.wg.Add(1)
+	go s.serve()
+
+	return s, nil
+}
+
+// Addr is the address the server is listening on
+func (s *LocalServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Received returns the emails received so far
+func (s *LocalServer) Received() []ReceivedMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]ReceivedMail{}, s.received...)
+}
+
+// Close stops the server once the open sessions are over
+func (s *LocalServer) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *LocalServer) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			s.handle(textproto.NewConn(conn))
+		}()
+	}
+}
+
+// handle speaks just enough SMTP for the net/smtp client
+func (s *LocalServer) handle(conn *textproto.Conn) {
+	var mail ReceivedMail
+	reply := func(format string, args ...any) bool {
+		return conn.PrintfLine(format, args...) == nil
+	}
+
+	if !reply("220 localhost ESMTP ready") {
+		return
+	}
+
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			if !reply("250-localhost") || !reply("250 8BITMIME") {
+				return
+			}
+		case "HELO", "NOOP":
+			if !reply("250 OK") {
+				return
+			}
+		case "RSET":
+			mail = ReceivedMail{}
+			if !reply("250 OK") {
+				return
+			}
+		case "MAIL":
+			mail = ReceivedMail{From: address(arg)}
+			if !reply("250 OK") {
+				return
+			}
+		case "RCPT":
+			if mail.From == "" {
+				if !reply("503 MAIL first") {
+					return
+				}
+				continue
+			}
+			mail.To = append(mail.To, address(arg))
+			if !reply("250 OK") {
+				return
+			}
+		case "DATA":
+			if len(mail.To) == 0 {
+				if !reply("503 RCPT first") {
+					return
+				}
+				continue
+			}
+			if !reply("354 end data with <CR><LF>.<CR><LF>") {
+				return
+			}
+			mail.Data, err = conn.ReadDotBytes()
+			if err != nil {
+				return
+			}
+
+			s.mu.Lock()
+			s.received = append(s.received, mail)
+			s.mu.Unlock()
+
+			mail = ReceivedMail{}
+			if !reply("250 OK queued") {
+				return
+			}
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			if !reply("502 command not implemented") {
+				return
+			}
+		}
+	}
+}
+
+// address extracts the address of "FROM:<address> BODY=8BITMIME" and alike
+func address(arg string) string {
+	_, addr, _ := strings.Cut(arg, "<")
+	addr, _, _ = strings.Cut(addr, ">")
+	return addr
+}
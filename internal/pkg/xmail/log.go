@@ -0,0 +1,28 @@
+package xmail
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LogSender writes the emails to the application log, useful for development
+type LogSender struct {
+	logger *slog.Logger
+}
+
+func NewLogSender(logger *slog.Logger) *LogSender {
+	return &LogSender{
+		logger: logger,
+	}
+}
+
+func (s *LogSender) Send(ctx context.Context, msg Message) error {
+	s.logger.InfoContext(ctx, "email",
+		slog.String("id", msg.ID),
+		slog.Any("to", msg.To),
+		slog.String("subject", msg.Subject),
+		slog.String("body", msg.Body),
+	)
+
+	return nil
+}
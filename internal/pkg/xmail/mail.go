@@ -0,0 +1,106 @@
+package xmail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/rahadianir/dealls/internal/config"
+)
+
+// templates of the employee emails
+const (
+	TemplatePayslipAvailable      = "payslip_available"
+	TemplateReimbursementApproved = "reimbursement_approved"
+	TemplateReimbursementRejected = "reimbursement_rejected"
+	TemplateOvertimeDecision      = "overtime_decision"
+	TemplatePasswordReset         = "password_reset"
+)
+
+var Templates = []string{TemplatePayslipAvailable, TemplateReimbursementApproved, TemplateReimbursementRejected, TemplateOvertimeDecision, TemplatePasswordReset}
+
+// ErrNoRecipient is returned when the user has no email address to send the email to
+var ErrNoRecipient = errors.New("user has no email address")
+
+// Message is a rendered plain text email, the ID ends up in the Message-ID header
+type Message struct {
+	ID      string
+	To      []string
+	Subject string
+	Body    string
+}
+
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewSender picks the sender implementation based on the configured driver
+func NewSender(cfg *config.Mail, logger *slog.Logger) (Sender, error) {
+	switch cfg.Driver {
+	case "log", "":
+		return NewLogSender(logger), nil
+	case "smtp":
+		return NewSMTPSender(cfg)
+	default:
+		return nil, fmt.Errorf("unknown mail driver: %s", cfg.Driver)
+	}
+}
+
+// Email is a templated email to a user, rendered with the data when it's sent.
+// Secrets are merged into the data when rendering, they're stored encrypted until then.
+type Email struct {
+	UserID   string
+	Template string
+	Data     any
+	Secrets  map[string]string
+}
+
+// ReimbursementData is the data of the reimbursement emails, the reason is only set when it's rejected
+type ReimbursementData struct {
+	ID          string  `json:"id,omitempty"`
+	Amount      float64 `json:"amount"`
+	Currency    string  `json:"currency"`
+	Category    string  `json:"category"`
+	Description string  `json:"description,omitempty"`
+	Reason      string  `json:"reason,omitempty"`
+}
+
+// OvertimeDecisionData is the data of the overtime decision email, the reason is only set when it's rejected
+type OvertimeDecisionData struct {
+	Date     string `json:"date"`
+	Hours    int    `json:"hours"`
+	Approved bool   `json:"approved"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// PasswordResetData is the data of the password reset email, the token itself is passed as the "token" secret
+type PasswordResetData struct {
+	ExpiresAt string `json:"expires_at"`
+}
+
+// Queue queues the emails of the users in the company in the context, they're sent and retried in the background
+type Queue interface {
+	Enqueue(ctx context.Context, email Email) error
+
+	// EnqueuePayslipEmails queues the payslip available email of every payslip of the payroll period in one go
+	EnqueuePayslipEmails(ctx context.Context, payrollID string) error
+}
+
+// Enqueue queues the email, failing to do so is logged without failing the operation.
+// Users without an email address are simply skipped.
+func Enqueue(ctx context.Context, queue Queue, logger *slog.Logger, email Email) {
+	err := queue.Enqueue(ctx, email)
+	if err != nil {
+		if errors.Is(err, ErrNoRecipient) {
+			logger.DebugContext(ctx, "email skipped, user has no email address", slog.String("user_id", email.UserID), slog.String("template", email.Template))
+			return
+		}
+
+		logger.ErrorContext(ctx, "failed to queue email",
+			slog.Any("error", err),
+			slog.String("user_id", email.UserID),
+			slog.String("template", email.Template),
+		)
+	}
+}
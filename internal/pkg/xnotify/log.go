@@ -0,0 +1,27 @@
+package xnotify
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LogNotifier writes the notifications to the application log, useful for development
+type LogNotifier struct {
+	logger *slog.Logger
+}
+
+func NewLogNotifier(logger *slog.Logger) *LogNotifier {
+	return &LogNotifier{
+		logger: logger,
+	}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, msg Message) error {
+	n.logger.InfoContext(ctx, "notification",
+		slog.Any("recipients", msg.Recipients),
+		slog.String("subject", msg.Subject),
+		slog.String("body", msg.Body),
+	)
+
+	return nil
+}
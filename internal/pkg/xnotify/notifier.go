@@ -0,0 +1,32 @@
+package xnotify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/rahadianir/dealls/internal/config"
+)
+
+// Message is a notification sent to the recipients, e.g. admins usernames
+type Message struct {
+	Recipients []string `json:"recipients"`
+	Subject    string   `json:"subject"`
+	Body       string   `json:"body"`
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// NewNotifier picks the notifier implementation based on the configured driver
+func NewNotifier(cfg *config.Notifier, logger *slog.Logger) (Notifier, error) {
+	switch cfg.Driver {
+	case "log", "":
+		return NewLogNotifier(logger), nil
+	case "webhook":
+		return NewWebhookNotifier(cfg.WebhookURL)
+	default:
+		return nil, fmt.Errorf("unknown notifier driver: %s", cfg.Driver)
+	}
+}
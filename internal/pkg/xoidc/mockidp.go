@@ -0,0 +1,226 @@
+package xoidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rahadianir/dealls/internal/pkg/xjwt"
+)
+
+// MockIdP is a minimal OpenID Connect identity provider for local development and tests. It signs in the email named
+// by the login_hint, or the default email, right away without asking for a password.
+type MockIdP struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	defaultEmail string
+	key          xjwt.Key
+	jwks         xjwt.JSONWebKeySet
+	mux          *http.ServeMux
+
+	mu    sync.Mutex
+	codes map[string]mockCode
+}
+
+// mockCode is an issued authorization code waiting to be exchanged
+type mockCode struct {
+	email         string
+	nonce         string
+	redirectURI   string
+	codeChallenge string
+	expiresAt     time.Time
+}
+
+func NewMockIdP(issuer string, clientID string, clientSecret string, defaultEmail string) (*MockIdP, error) {
+	key, err := xjwt.GenerateKey("mock-idp", xjwt.AlgorithmRS256)
+	if err != nil {
+		return nil, err
+	}
+
+	// the jwt helper publishes the key set the same way this server does
+	helper := &xjwt.XJWT{}
+	helper.SetKeys(key, nil)
+
+	m := &MockIdP{
+		issuer:       strings.TrimSuffix(issuer, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		defaultEmail: defaultEmail,
+		key:          key,
+		jwks:         helper.JWKS(),
+		mux:          http.NewServeMux(),
+		codes:        map[string]mockCode{},
+	}
+	m.mux.HandleFunc("GET /.well-known/openid-configuration", m.discovery)
+	m.mux.HandleFunc("GET /authorize", m.authorize)
+	m.mux.HandleFunc("POST /token", m.token)
+	m.mux.HandleFunc("GET /jwks", m.keys)
+
+	return m, nil
+}
+
+func (m *MockIdP) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mux.ServeHTTP(w, r)
+}
+
+func (m *MockIdP) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, Discovery{
+		Issuer:                m.issuer,
+		AuthorizationEndpoint: m.issuer + "/authorize",
+		TokenEndpoint:         m.issuer + "/token",
+		JWKSURI:               m.issuer + "/jwks",
+	}, http.StatusOK)
+}
+
+func (m *MockIdP) keys(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, m.jwks, http.StatusOK)
+}
+
+// authorize signs the user in and redirects back with the code, like a real provider would after the login form
+func (m *MockIdP) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != m.clientID || query.Get("response_type") != "code" {
+		http.Error(w, "unknown client or unsupported response type", http.StatusBadRequest)
+		return
+	}
+
+	redirectURI, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || !redirectURI.IsAbs() {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	if query.Get("code_challenge") == "" || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "S256 code challenge is required", http.StatusBadRequest)
+		return
+	}
+
+	email := query.Get("login_hint")
+	if email == "" {
+		email = m.defaultEmail
+	}
+
+	code, err := randomToken()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	m.mu.Lock()
+	m.codes[code] = mockCode{
+		email:         email,
+		nonce:         query.Get("nonce"),
+		redirectURI:   redirectURI.String(),
+		codeChallenge: query.Get("code_challenge"),
+		expiresAt:     time.Now().Add(time.Minute),
+	}
+	m.mu.Unlock()
+
+	params := redirectURI.Query()
+	params.Set("code", code)
+	params.Set("state", query.Get("state"))
+	redirectURI.RawQuery = params.Encode()
+
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+// token exchanges the code for an ID token, codes are single use and bound to the redirect URI and the PKCE challenge
+func (m *MockIdP) token(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		writeJSON(w, map[string]string{"error": "invalid_request"}, http.StatusBadRequest)
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if ok {
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != m.clientID || subtle.ConstantTimeCompare([]byte(clientSecret), []byte(m.clientSecret)) != 1 {
+		writeJSON(w, map[string]string{"error": "invalid_client"}, http.StatusUnauthorized)
+		return
+	}
+
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, map[string]string{"error": "unsupported_grant_type"}, http.StatusBadRequest)
+		return
+	}
+
+	m.mu.Lock()
+	code, ok := m.codes[r.PostForm.Get("code")]
+	delete(m.codes, r.PostForm.Get("code"))
+	m.mu.Unlock()
+
+	if !ok || time.Now().After(code.expiresAt) || code.redirectURI != r.PostForm.Get("redirect_uri") ||
+		CodeChallenge(r.PostForm.Get("code_verifier")) != code.codeChallenge {
+		writeJSON(w, map[string]string{"error": "invalid_grant"}, http.StatusBadRequest)
+		return
+	}
+
+	// the subject stays the same for the email, like the stable user ID of a real provider
+	sum := sha256.Sum256([]byte(strings.ToLower(code.email)))
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, IDTokenClaims{
+		Nonce:         code.nonce,
+		Email:         code.email,
+		EmailVerified: true,
+		Name:          code.email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    m.issuer,
+			Subject:   "mock-" + hex.EncodeToString(sum[:8]),
+			Audience:  jwt.ClaimStrings{m.clientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(5 * time.Minute)),
+		},
+	})
+	token.Header["kid"] = m.key.ID
+
+	idToken, err := token.SignedString(m.key.PrivateKey)
+	if err != nil {
+		writeJSON(w, map[string]string{"error": "server_error"}, http.StatusInternalServerError)
+		return
+	}
+
+	// the access token isn't used, there's no userinfo endpoint
+	accessToken, err := randomToken()
+	if err != nil {
+		writeJSON(w, map[string]string{"error": "server_error"}, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, map[string]any{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	}, http.StatusOK)
+}
+
+func randomToken() (string, error) {
+	randBytes := make([]byte, 32)
+	_, err := rand.Read(randBytes)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(randBytes), nil
+}
+
+func writeJSON(w http.ResponseWriter, data any, code int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(data)
+}
@@ -0,0 +1,267 @@
+package xoidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/pkg/xjwt"
+)
+
+// Provider signs users in with an OpenID Connect identity provider through the authorization code flow with PKCE
+type Provider interface {
+	// AuthCodeURL is where the user signs in at the identity provider, which redirects back with the code
+	AuthCodeURL(ctx context.Context, state string, nonce string, codeChallenge string) (string, error)
+	// Exchange redeems the code for the ID token of the user and verifies it
+	Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (IDTokenClaims, error)
+}
+
+// IDTokenClaims are the claims of the ID token that identify the user, the subject is unique within the issuer
+type IDTokenClaims struct {
+	Nonce         string `json:"nonce"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	jwt.RegisteredClaims
+}
+
+// Discovery is the part of the provider metadata the authorization code flow needs
+type Discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// keyRefreshInterval limits how often unknown key IDs refetch the keys of the provider
+const keyRefreshInterval = time.Minute
+
+// Client discovers the provider on first use, so the server starts even while the provider is down
+type Client struct {
+	cfg    *config.OIDC
+	client *http.Client
+
+	mu            sync.Mutex
+	discovery     *Discovery
+	keys          map[string]xjwt.Key
+	keysFetchedAt time.Time
+}
+
+func NewClient(cfg *config.OIDC) *Client {
+	return &Client{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *Client) AuthCodeURL(ctx context.Context, state string, nonce string, codeChallenge string) (string, error) {
+	discovery, err := c.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", c.cfg.ClientID)
+	params.Set("redirect_uri", c.cfg.RedirectURL)
+	params.Set("scope", strings.Join(c.cfg.Scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", codeChallenge)
+	params.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return discovery.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+func (c *Client) Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (IDTokenClaims, error) {
+	discovery, err := c.discover(ctx)
+	if err != nil {
+		return IDTokenClaims{}, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", c.cfg.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return IDTokenClaims{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(c.cfg.ClientID), url.QueryEscape(c.cfg.ClientSecret))
+
+	var token struct {
+		IDToken string `json:"id_token"`
+	}
+	err = c.doJSON(req, &token)
+	if err != nil {
+		return IDTokenClaims{}, fmt.Errorf("failed to exchange code: %w", err)
+	}
+
+	if token.IDToken == "" {
+		return IDTokenClaims{}, fmt.Errorf("identity provider returned no ID token")
+	}
+
+	return c.verifyIDToken(ctx, discovery, token.IDToken, nonce)
+}
+
+// verifyIDToken checks the signature with the provider keys along with the issuer, audience, expiry and nonce
+func (c *Client) verifyIDToken(ctx context.Context, discovery *Discovery, idToken string, nonce string) (IDTokenClaims, error) {
+	var claims IDTokenClaims
+	_, err := jwt.ParseWithClaims(idToken, &claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+
+		key, err := c.key(ctx, discovery, kid)
+		if err != nil {
+			return nil, err
+		}
+
+		// the algorithm is bound to the key so a token can't pick a weaker one
+		method, err := key.SigningMethod()
+		if err != nil {
+			return nil, err
+		}
+		if t.Method.Alg() != method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s for key %q", t.Method.Alg(), kid)
+		}
+
+		return key.PublicKey, nil
+	},
+		jwt.WithValidMethods([]string{xjwt.AlgorithmRS256, xjwt.AlgorithmEdDSA}),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(c.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return IDTokenClaims{}, fmt.Errorf("invalid ID token: %w", err)
+	}
+
+	if claims.Subject == "" {
+		return IDTokenClaims{}, fmt.Errorf("invalid ID token: missing subject")
+	}
+
+	// the nonce ties the token to the sign-in started by this server
+	if claims.Nonce != nonce {
+		return IDTokenClaims{}, fmt.Errorf("invalid ID token: nonce mismatch")
+	}
+
+	return claims, nil
+}
+
+// discover fetches the provider metadata once, a failed fetch is retried on the next sign-in
+func (c *Client) discover(ctx context.Context) (*Discovery, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.discovery != nil {
+		return c.discovery, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(c.cfg.Issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var discovery Discovery
+	err = c.doJSON(req, &discovery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover identity provider: %w", err)
+	}
+
+	// the issuer in the tokens has to be the configured one
+	if discovery.Issuer != c.cfg.Issuer {
+		return nil, fmt.Errorf("identity provider issuer %q doesn't match the configured %q", discovery.Issuer, c.cfg.Issuer)
+	}
+
+	c.discovery = &discovery
+	return c.discovery, nil
+}
+
+// key looks the key up by its ID, refetching the provider keys for unknown IDs since providers rotate them
+func (c *Client) key(ctx context.Context, discovery *Discovery, kid string) (xjwt.Key, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key, ok := c.keys[kid]
+	if ok {
+		return key, nil
+	}
+
+	if time.Since(c.keysFetchedAt) < keyRefreshInterval {
+		return xjwt.Key{}, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discovery.JWKSURI, nil)
+	if err != nil {
+		return xjwt.Key{}, err
+	}
+
+	var set xjwt.JSONWebKeySet
+	err = c.doJSON(req, &set)
+	if err != nil {
+		return xjwt.Key{}, fmt.Errorf("failed to fetch identity provider keys: %w", err)
+	}
+
+	keys := make(map[string]xjwt.Key, len(set.Keys))
+	for _, jwk := range set.Keys {
+		// keys of other types or uses are skipped, they can't have signed the token
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		parsed, err := xjwt.ParseJWK(jwk)
+		if err != nil {
+			continue
+		}
+		keys[parsed.ID] = parsed
+	}
+	c.keys = keys
+	c.keysFetchedAt = time.Now()
+
+	key, ok = c.keys[kid]
+	if !ok {
+		return xjwt.Key{}, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	return key, nil
+}
+
+func (c *Client) doJSON(req *http.Request, dest any) error {
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("identity provider responded %d: %s", resp.StatusCode, respBody)
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(dest)
+}
+
+// CodeChallenge is the S256 PKCE challenge of the code verifier
+func CodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
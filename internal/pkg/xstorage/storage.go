@@ -0,0 +1,36 @@
+package xstorage
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/rahadianir/dealls/internal/config"
+)
+
+var ErrObjectNotFound = fmt.Errorf("object not found")
+
+type BlobStorage interface {
+	Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// NewBlobStorage picks the blob storage implementation based on the configured driver
+func NewBlobStorage(cfg *config.Storage) (BlobStorage, error) {
+	switch cfg.Driver {
+	case "local", "":
+		return NewLocalStorage(cfg.LocalDir)
+	case "s3":
+		return NewS3Storage(S3Options{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PathStyle: cfg.S3PathStyle,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage driver: %s", cfg.Driver)
+	}
+}
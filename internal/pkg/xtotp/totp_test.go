@@ -0,0 +1,117 @@
+package xtotp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the base32 of the RFC 6238 appendix B SHA1 seed "12345678901234567890"
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	// RFC 6238 appendix B SHA1 vectors, the 8 digit codes truncated to their last 6 digits
+	tests := []struct {
+		name    string
+		secret  string
+		time    int64
+		want    string
+		wantErr bool
+	}{
+		// TODO: Add test cases.
+		{name: "success rfc vector at 59 (94287082)", secret: rfcSecret, time: 59, want: "287082"},
+		{name: "success rfc vector at 1111111109 (07081804)", secret: rfcSecret, time: 1111111109, want: "081804"},
+		{name: "success rfc vector at 1111111111 (14050471)", secret: rfcSecret, time: 1111111111, want: "050471"},
+		{name: "success rfc vector at 1234567890 (89005924)", secret: rfcSecret, time: 1234567890, want: "005924"},
+		{name: "success rfc vector at 2000000000 (69279037)", secret: rfcSecret, time: 2000000000, want: "279037"},
+		{name: "success rfc vector at 20000000000 (65353130)", secret: rfcSecret, time: 20000000000, want: "353130"},
+		{name: "success lower case secret", secret: "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", time: 59, want: "287082"},
+		{name: "failed invalid secret", secret: "not base32!", time: 59, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Code(tt.secret, Step(time.Unix(tt.time, 0)))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Code() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Code() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	// the code of step 1, valid from 30 to 59
+	const code = "287082"
+
+	type args struct {
+		secret string
+		code   string
+		time   int64
+	}
+	tests := []struct {
+		name     string
+		args     args
+		wantStep int64
+		want     bool
+		wantErr  bool
+	}{
+		// TODO: Add test cases.
+		{
+			name:     "success code of the current step",
+			args:     args{secret: rfcSecret, code: code, time: 59},
+			wantStep: 1,
+			want:     true,
+		},
+		{
+			name:     "success code of the previous step within the skew",
+			args:     args{secret: rfcSecret, code: code, time: 89},
+			wantStep: 1,
+			want:     true,
+		},
+		{
+			name:     "success code of the next step within the skew",
+			args:     args{secret: rfcSecret, code: code, time: 0},
+			wantStep: 1,
+			want:     true,
+		},
+		{
+			name: "failed code two steps old",
+			args: args{secret: rfcSecret, code: code, time: 90},
+			want: false,
+		},
+		{
+			name: "failed code two steps ahead",
+			args: args{secret: rfcSecret, code: "081804", time: 1111111109 - 60},
+			want: false,
+		},
+		{
+			name: "failed wrong code",
+			args: args{secret: rfcSecret, code: "000000", time: 59},
+			want: false,
+		},
+		{
+			name: "failed 8 digit code",
+			args: args{secret: rfcSecret, code: "94287082", time: 59},
+			want: false,
+		},
+		{
+			name:    "failed invalid secret",
+			args:    args{secret: "not base32!", code: code, time: 59},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, got, err := Validate(tt.args.secret, tt.args.code, time.Unix(tt.args.time, 0))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want || step != tt.wantStep {
+				t.Errorf("Validate() = %v, %v, want %v, %v", step, got, tt.wantStep, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package xwebhook
+
+import (
+	"context"
+)
+
+// event types webhook subscriptions can subscribe to
+const (
+	EventPayrollPeriodCreated   = "payroll_period.created"
+	EventPayrollPeriodProcessed = "payroll_period.processed"
+	EventReimbursementApproved  = "reimbursement.approved"
+)
+
+var EventTypes = []string{EventPayrollPeriodCreated, EventPayrollPeriodProcessed, EventReimbursementApproved}
+
+// Event is a business change published to the webhook subscriptions of the company in the context
+type Event struct {
+	Type string
+	Data any
+}
+
+// Outbox queues events for delivery to the subscriptions. It has to be called with the transaction of the business
+// change in the context (see dbhelper.WithTransaction), so events are only sent for committed changes and a
+// committed change always sends its events.
+type Outbox interface {
+	Enqueue(ctx context.Context, event Event) error
+}
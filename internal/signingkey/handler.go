@@ -0,0 +1,28 @@
+package signingkey
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/pkg/xhttp"
+)
+
+type SigningKeyHandler struct {
+	deps     *config.CommonDependencies
+	keyLogic SigningKeyLogicInterface
+}
+
+func NewSigningKeyHandler(deps *config.CommonDependencies, keyLogic SigningKeyLogicInterface) *SigningKeyHandler {
+	return &SigningKeyHandler{
+		deps:     deps,
+		keyLogic: keyLogic,
+	}
+}
+
+// GetJWKS serves the key set as is instead of wrapping it in the base response, so any JWKS client can read it
+func (handler *SigningKeyHandler) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	// verifiers may cache the keys for as long as the server instances do
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(handler.deps.Config.App.KeyRefreshInterval.Seconds())))
+	xhttp.SendJSONResponse(w, handler.keyLogic.GetJWKS(r.Context()), http.StatusOK)
+}
@@ -0,0 +1,264 @@
+package signingkey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rahadianir/dealls/internal/company"
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/pkg/xaudit"
+	"github.com/rahadianir/dealls/internal/pkg/xcontext"
+	"github.com/rahadianir/dealls/internal/pkg/xcrypto"
+	"github.com/rahadianir/dealls/internal/pkg/xerror"
+	"github.com/rahadianir/dealls/internal/pkg/xjwt"
+)
+
+type SigningKeyLogic struct {
+	deps        *config.CommonDependencies
+	keyRepo     SigningKeyRepositoryInterface
+	companyRepo company.CompanyRepositoryInterface
+	jwtHelper   xjwt.JWTHelper
+	auditor     xaudit.Recorder
+}
+
+func NewSigningKeyLogic(deps *config.CommonDependencies, keyRepo SigningKeyRepositoryInterface, companyRepo company.CompanyRepositoryInterface, jwtHelper xjwt.JWTHelper, auditor xaudit.Recorder) *SigningKeyLogic {
+	return &SigningKeyLogic{
+		deps:        deps,
+		keyRepo:     keyRepo,
+		companyRepo: companyRepo,
+		jwtHelper:   jwtHelper,
+		auditor:     auditor,
+	}
+}
+
+// keySet is the stored keys sorted out at a point in time
+type keySet struct {
+	latestID     string     // latest stored key, active or not
+	signing      *xjwt.Key  // latest active key that can sign
+	signingSince time.Time  // when the signing key activated
+	pending      bool       // a key activating later is already published
+	verification []xjwt.Key // keys tokens may still be signed with, including the pending ones
+	expired      []string   // keys replaced long enough ago that their tokens have expired
+}
+
+// RotateKeys creates the next signing key when the current one is due for rotation, then loads the keys into the jwt helper.
+// The next key is published in the JWKS ahead of signing with it, so every server instance and verifier knows it by then.
+func (logic *SigningKeyLogic) RotateKeys(ctx context.Context, now time.Time) error {
+	// rotations run in the background, their audit log entries are told apart by the request ID
+	ctx = context.WithValue(ctx, xcontext.RequestIDKey, "signing-key-rotation-"+uuid.NewString())
+
+	keys, err := logic.keyRepo.GetSigningKeys(ctx)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get signing keys", slog.Any("error", err))
+		return err
+	}
+	set := logic.sortKeys(ctx, keys, now)
+
+	activatesAt, due := logic.nextActivation(set, now)
+	if due {
+		next, err := logic.generateKey(activatesAt, now)
+		if err != nil {
+			logic.deps.Logger.ErrorContext(ctx, "failed to generate signing key", slog.Any("error", err))
+			return err
+		}
+
+		// the private key is never written to the audit log, not even encrypted
+		err = logic.auditor.WithTransaction(ctx, func(ctx context.Context) error {
+			err := logic.keyRepo.RotateSigningKey(ctx, set.latestID, next)
+			if err != nil {
+				return err
+			}
+
+			return logic.recordKeyChanges(ctx, xaudit.Entry{
+				Action:   xaudit.ActionCreate,
+				Entity:   xaudit.EntitySigningKey,
+				EntityID: next.ID,
+				After:    map[string]any{"algorithm": next.Algorithm, "activates_at": next.ActivatesAt},
+			})
+		})
+		if err != nil && !errors.Is(err, xerror.ErrDataNotFound) {
+			logic.deps.Logger.ErrorContext(ctx, "failed to rotate signing key", slog.Any("error", err))
+			return err
+		}
+		if err == nil {
+			logic.deps.Logger.InfoContext(ctx, "signing key rotated", slog.String("kid", next.ID), slog.Time("activates_at", activatesAt))
+		}
+
+		// reload either way, another server instance may have rotated it first
+		keys, err = logic.keyRepo.GetSigningKeys(ctx)
+		if err != nil {
+			logic.deps.Logger.ErrorContext(ctx, "failed to get signing keys", slog.Any("error", err))
+			return err
+		}
+		set = logic.sortKeys(ctx, keys, now)
+	}
+
+	if set.signing == nil {
+		return xjwt.ErrNoSigningKey
+	}
+	logic.jwtHelper.SetKeys(*set.signing, set.verification)
+
+	if len(set.expired) == 0 {
+		return nil
+	}
+
+	err = logic.auditor.WithTransaction(ctx, func(ctx context.Context) error {
+		deleted, err := logic.keyRepo.DeleteSigningKeys(ctx, set.expired)
+		if err != nil {
+			return err
+		}
+
+		entries := make([]xaudit.Entry, 0, len(deleted))
+		for _, id := range deleted {
+			entries = append(entries, xaudit.Entry{
+				Action:   xaudit.ActionDelete,
+				Entity:   xaudit.EntitySigningKey,
+				EntityID: id,
+			})
+		}
+
+		return logic.recordKeyChanges(ctx, entries...)
+	})
+	if err != nil {
+		// expired keys are no longer loaded, they're deleted on the next run
+		logic.deps.Logger.WarnContext(ctx, "failed to delete expired signing keys", slog.Any("error", err))
+	}
+
+	return nil
+}
+
+// recordKeyChanges records the entries in the audit log of every company, since the keys sign the tokens of all of them
+func (logic *SigningKeyLogic) recordKeyChanges(ctx context.Context, entries ...xaudit.Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	companies, err := logic.companyRepo.GetCompanies(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get companies: %w", err)
+	}
+
+	for _, c := range companies {
+		companyCtx := context.WithValue(ctx, xcontext.CompanyIDKey, c.ID)
+		for _, entry := range entries {
+			err = logic.auditor.Record(companyCtx, entry)
+			if err != nil {
+				return fmt.Errorf("failed to record audit log of %s %s: %w", entry.Action, entry.Entity, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (logic *SigningKeyLogic) GetJWKS(ctx context.Context) xjwt.JSONWebKeySet {
+	return logic.jwtHelper.JWKS()
+}
+
+// sortKeys walks the keys in activation order, every key signs until the next one activates
+func (logic *SigningKeyLogic) sortKeys(ctx context.Context, keys []SigningKey, now time.Time) keySet {
+	set := keySet{}
+	expiredBefore := now.Add(-logic.deps.Config.App.ExpiryTime)
+
+	for i, stored := range keys {
+		set.latestID = stored.ID
+
+		// keys replaced before the access token lifetime can't have signed a valid token anymore
+		if i+1 < len(keys) && keys[i+1].ActivatesAt.Before(expiredBefore) {
+			set.expired = append(set.expired, stored.ID)
+			continue
+		}
+
+		key, err := logic.parseKey(ctx, stored)
+		if err != nil {
+			logic.deps.Logger.WarnContext(ctx, "failed to load signing key", slog.String("kid", stored.ID), slog.Any("error", err))
+			continue
+		}
+		set.verification = append(set.verification, key)
+
+		if stored.ActivatesAt.After(now) {
+			set.pending = true
+			continue
+		}
+		set.signing = &key
+		set.signingSince = stored.ActivatesAt
+		if key.PrivateKey == nil {
+			// the key still verifies its tokens but can't sign, a new one takes over
+			set.signing = nil
+		}
+	}
+
+	return set
+}
+
+// nextActivation tells whether a new key is due and when it activates.
+// The first key or a replacement for one that can't sign activates right away since there's no working key to overlap with.
+func (logic *SigningKeyLogic) nextActivation(set keySet, now time.Time) (time.Time, bool) {
+	cfg := logic.deps.Config.App
+
+	if set.signing == nil {
+		return now, true
+	}
+
+	if set.pending {
+		return time.Time{}, false
+	}
+
+	rotateAt := set.signingSince.Add(cfg.KeyRotationInterval)
+	if set.signing.Algorithm != cfg.JWTAlgorithm {
+		// switching algorithms rotates early
+		rotateAt = now
+	}
+
+	if now.Before(rotateAt.Add(-cfg.KeyPublishAhead)) {
+		return time.Time{}, false
+	}
+
+	activatesAt := now.Add(cfg.KeyPublishAhead)
+	if rotateAt.After(activatesAt) {
+		activatesAt = rotateAt
+	}
+
+	return activatesAt, true
+}
+
+func (logic *SigningKeyLogic) generateKey(activatesAt time.Time, now time.Time) (SigningKey, error) {
+	key, err := xjwt.GenerateKey(uuid.NewString(), logic.deps.Config.App.JWTAlgorithm)
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	private, public, err := xjwt.MarshalKey(key)
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	encrypted, err := xcrypto.Encrypt(private, logic.deps.Config.App.EncryptionKey)
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	return SigningKey{
+		ID:          key.ID,
+		Algorithm:   key.Algorithm,
+		PrivateKey:  encrypted,
+		PublicKey:   public,
+		ActivatesAt: activatesAt,
+		CreatedAt:   now,
+	}, nil
+}
+
+// parseKey loads the key pair, keys encrypted with another secret key are only loaded for verification
+func (logic *SigningKeyLogic) parseKey(ctx context.Context, stored SigningKey) (xjwt.Key, error) {
+	private, err := xcrypto.Decrypt(stored.PrivateKey, logic.deps.Config.App.EncryptionKey)
+	if err != nil {
+		logic.deps.Logger.WarnContext(ctx, "failed to decrypt signing key, it's only used for verification", slog.String("kid", stored.ID), slog.Any("error", err))
+		private = nil
+	}
+
+	return xjwt.ParseKey(stored.ID, stored.Algorithm, private, stored.PublicKey)
+}
@@ -0,0 +1,259 @@
+package signingkey
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rahadianir/dealls/internal/company"
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/models"
+	"github.com/rahadianir/dealls/internal/pkg/xaudit"
+	"github.com/rahadianir/dealls/internal/pkg/xcontext"
+	"github.com/rahadianir/dealls/internal/pkg/xcrypto"
+	"github.com/rahadianir/dealls/internal/pkg/xerror"
+	"github.com/rahadianir/dealls/internal/pkg/xjwt"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSigningKeyLogic_RotateKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := NewMockSigningKeyRepositoryInterface(ctrl)
+	mockCompanyRepo := company.NewMockCompanyRepositoryInterface(ctrl)
+	mockJwt := xjwt.NewMockJWTHelper(ctrl)
+	mockAuditor := xaudit.NewMockRecorder(ctrl)
+	// the change and its entries are run in the transaction the recorder begins
+	mockAuditor.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, txfunc func(context.Context) error) error {
+		return txfunc(ctx)
+	}).AnyTimes()
+	mockDeps := config.CommonDependencies{
+		Config: config.InitConfig(context.Background()),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	cfg := mockDeps.Config.App
+	now := time.Date(2025, time.June, 1, 9, 0, 0, 0, time.UTC)
+
+	current := testSigningKey(t, "11111111-1111-1111-1111-111111111111", now.Add(-24*time.Hour), cfg.EncryptionKey)
+	dueSoon := testSigningKey(t, "22222222-2222-2222-2222-222222222222", now.Add(-cfg.KeyRotationInterval+30*time.Minute), cfg.EncryptionKey)
+	replaced := testSigningKey(t, "33333333-3333-3333-3333-333333333333", now.Add(-2*cfg.KeyRotationInterval), cfg.EncryptionKey)
+	otherSecret := testSigningKey(t, "44444444-4444-4444-4444-444444444444", now.Add(-24*time.Hour), "another secret")
+
+	// the keys are shared, so their changes are recorded in the audit log of every company
+	companies := []models.Company{{ID: "company-a"}, {ID: "company-b"}}
+	expectEntries := func(action string, id func() string) {
+		mockCompanyRepo.EXPECT().GetCompanies(gomock.Any()).Return(companies, nil)
+		for _, c := range companies {
+			mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, entry xaudit.Entry) error {
+				if xcontext.GetCompanyIDFromContext(ctx) != c.ID || !strings.HasPrefix(xcontext.GetRequestIDFromContext(ctx), "signing-key-rotation-") {
+					t.Errorf("unexpected audit log context of %s", c.ID)
+				}
+				if entry.Action != action || entry.Entity != xaudit.EntitySigningKey || entry.EntityID != id() || entry.Before != nil {
+					t.Errorf("unexpected audit log entry: %+v", entry)
+				}
+				// only the algorithm and activation, never the key pair
+				if after, ok := entry.After.(map[string]any); entry.After != nil && (!ok || len(after) != 2) {
+					t.Errorf("unexpected audit log state: %+v", entry.After)
+				}
+				return nil
+			})
+		}
+	}
+
+	type args struct {
+		ctx context.Context
+		now time.Time
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantErr   bool
+		behaviour func(a args)
+	}{
+		// TODO: Add test cases.
+		{
+			name: "success create the first key active right away",
+			args: args{ctx: context.Background(), now: now},
+			behaviour: func(a args) {
+				var created SigningKey
+				mockRepo.EXPECT().GetSigningKeys(gomock.Any()).Return([]SigningKey{}, nil)
+				mockRepo.EXPECT().RotateSigningKey(gomock.Any(), "", gomock.Any()).DoAndReturn(func(ctx context.Context, latestID string, next SigningKey) error {
+					if !next.ActivatesAt.Equal(now) || next.Algorithm != cfg.JWTAlgorithm {
+						t.Errorf("unexpected first key: %+v", next)
+					}
+					created = next
+					return nil
+				})
+				expectEntries(xaudit.ActionCreate, func() string { return created.ID })
+				mockRepo.EXPECT().GetSigningKeys(gomock.Any()).DoAndReturn(func(ctx context.Context) ([]SigningKey, error) {
+					return []SigningKey{created}, nil
+				})
+				mockJwt.EXPECT().SetKeys(gomock.Any(), gomock.Any()).Do(func(signingKey xjwt.Key, verificationKeys []xjwt.Key) {
+					if signingKey.ID != created.ID || signingKey.PrivateKey == nil || len(verificationKeys) != 1 {
+						t.Errorf("unexpected keys: %v %v", signingKey.ID, len(verificationKeys))
+					}
+				})
+			},
+		},
+		{
+			name: "success keep signing with a key not due for rotation",
+			args: args{ctx: context.Background(), now: now},
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetSigningKeys(gomock.Any()).Return([]SigningKey{current}, nil)
+				mockJwt.EXPECT().SetKeys(gomock.Any(), gomock.Len(1)).Do(func(signingKey xjwt.Key, verificationKeys []xjwt.Key) {
+					if signingKey.ID != current.ID {
+						t.Errorf("unexpected signing key: %v", signingKey.ID)
+					}
+				})
+			},
+		},
+		{
+			name: "success publish the next key ahead and keep signing with the current one",
+			args: args{ctx: context.Background(), now: now},
+			behaviour: func(a args) {
+				var created SigningKey
+				mockRepo.EXPECT().GetSigningKeys(gomock.Any()).Return([]SigningKey{dueSoon}, nil)
+				mockRepo.EXPECT().RotateSigningKey(gomock.Any(), dueSoon.ID, gomock.Any()).DoAndReturn(func(ctx context.Context, latestID string, next SigningKey) error {
+					if !next.ActivatesAt.Equal(now.Add(cfg.KeyPublishAhead)) {
+						t.Errorf("unexpected activation: %v", next.ActivatesAt)
+					}
+					created = next
+					return nil
+				})
+				expectEntries(xaudit.ActionCreate, func() string { return created.ID })
+				mockRepo.EXPECT().GetSigningKeys(gomock.Any()).DoAndReturn(func(ctx context.Context) ([]SigningKey, error) {
+					return []SigningKey{dueSoon, created}, nil
+				})
+				mockJwt.EXPECT().SetKeys(gomock.Any(), gomock.Len(2)).Do(func(signingKey xjwt.Key, verificationKeys []xjwt.Key) {
+					if signingKey.ID != dueSoon.ID {
+						t.Errorf("unexpected signing key: %v", signingKey.ID)
+					}
+				})
+			},
+		},
+		{
+			name: "success load the key rotated by another server instance",
+			args: args{ctx: context.Background(), now: now},
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetSigningKeys(gomock.Any()).Return([]SigningKey{}, nil)
+				mockRepo.EXPECT().RotateSigningKey(gomock.Any(), "", gomock.Any()).Return(xerror.ErrDataNotFound)
+				mockRepo.EXPECT().GetSigningKeys(gomock.Any()).Return([]SigningKey{current}, nil)
+				mockJwt.EXPECT().SetKeys(gomock.Any(), gomock.Len(1))
+			},
+		},
+		{
+			name: "success delete keys whose tokens have expired",
+			args: args{ctx: context.Background(), now: now},
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetSigningKeys(gomock.Any()).Return([]SigningKey{replaced, current}, nil)
+				mockJwt.EXPECT().SetKeys(gomock.Any(), gomock.Len(1))
+				mockRepo.EXPECT().DeleteSigningKeys(gomock.Any(), []string{replaced.ID}).Return([]string{replaced.ID}, nil)
+				expectEntries(xaudit.ActionDelete, func() string { return replaced.ID })
+			},
+		},
+		{
+			name: "success skip recording keys another server instance deleted first",
+			args: args{ctx: context.Background(), now: now},
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetSigningKeys(gomock.Any()).Return([]SigningKey{replaced, current}, nil)
+				mockJwt.EXPECT().SetKeys(gomock.Any(), gomock.Len(1))
+				mockRepo.EXPECT().DeleteSigningKeys(gomock.Any(), []string{replaced.ID}).Return([]string{}, nil)
+			},
+		},
+		{
+			name: "success keep signing when the deletion can't be recorded, it's retried on the next run",
+			args: args{ctx: context.Background(), now: now},
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetSigningKeys(gomock.Any()).Return([]SigningKey{replaced, current}, nil)
+				mockJwt.EXPECT().SetKeys(gomock.Any(), gomock.Len(1))
+				mockRepo.EXPECT().DeleteSigningKeys(gomock.Any(), []string{replaced.ID}).Return([]string{replaced.ID}, nil)
+				mockCompanyRepo.EXPECT().GetCompanies(gomock.Any()).Return(companies, nil)
+				mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any()).Return(errors.New("db down"))
+			},
+		},
+		{
+			name: "success replace a key encrypted with another secret right away",
+			args: args{ctx: context.Background(), now: now},
+			behaviour: func(a args) {
+				var created SigningKey
+				mockRepo.EXPECT().GetSigningKeys(gomock.Any()).Return([]SigningKey{otherSecret}, nil)
+				mockRepo.EXPECT().RotateSigningKey(gomock.Any(), otherSecret.ID, gomock.Any()).DoAndReturn(func(ctx context.Context, latestID string, next SigningKey) error {
+					if !next.ActivatesAt.Equal(now) {
+						t.Errorf("unexpected activation: %v", next.ActivatesAt)
+					}
+					created = next
+					return nil
+				})
+				expectEntries(xaudit.ActionCreate, func() string { return created.ID })
+				mockRepo.EXPECT().GetSigningKeys(gomock.Any()).DoAndReturn(func(ctx context.Context) ([]SigningKey, error) {
+					return []SigningKey{otherSecret, created}, nil
+				})
+				// the old key still verifies the tokens it signed
+				mockJwt.EXPECT().SetKeys(gomock.Any(), gomock.Len(2)).Do(func(signingKey xjwt.Key, verificationKeys []xjwt.Key) {
+					if signingKey.ID != created.ID {
+						t.Errorf("unexpected signing key: %v", signingKey.ID)
+					}
+				})
+			},
+		},
+		{
+			name:    "failed audit log, the new key is rolled back along with it",
+			args:    args{ctx: context.Background(), now: now},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetSigningKeys(gomock.Any()).Return([]SigningKey{}, nil)
+				mockRepo.EXPECT().RotateSigningKey(gomock.Any(), "", gomock.Any()).Return(nil)
+				mockCompanyRepo.EXPECT().GetCompanies(gomock.Any()).Return(companies, nil)
+				mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any()).Return(errors.New("db down"))
+			},
+		},
+		{
+			name:    "failed get signing keys",
+			args:    args{ctx: context.Background(), now: now},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetSigningKeys(gomock.Any()).Return([]SigningKey{}, xerror.ServerError{Err: context.DeadlineExceeded})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := NewSigningKeyLogic(&mockDeps, mockRepo, mockCompanyRepo, mockJwt, mockAuditor)
+			tt.behaviour(tt.args)
+			if err := logic.RotateKeys(tt.args.ctx, tt.args.now); (err != nil) != tt.wantErr {
+				t.Errorf("SigningKeyLogic.RotateKeys() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func testSigningKey(t *testing.T, id string, activatesAt time.Time, secret string) SigningKey {
+	key, err := xjwt.GenerateKey(id, xjwt.AlgorithmEdDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	private, public, err := xjwt.MarshalKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted, err := xcrypto.Encrypt(private, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return SigningKey{
+		ID:          id,
+		Algorithm:   key.Algorithm,
+		PrivateKey:  encrypted,
+		PublicKey:   public,
+		ActivatesAt: activatesAt,
+		CreatedAt:   activatesAt,
+	}
+}
@@ -0,0 +1,139 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/signingkey/ports.go
+//
+// Generated by this command:
+//
+//	mockgen -source internal/signingkey/ports.go -destination internal/signingkey/mock_ports.go -package signingkey
+//
+
+// Package signingkey is a generated GoMock package.
+package signingkey
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	xjwt "github.com/rahadianir/dealls/internal/pkg/xjwt"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSigningKeyRepositoryInterface is a mock of SigningKeyRepositoryInterface interface.
+type MockSigningKeyRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockSigningKeyRepositoryInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockSigningKeyRepositoryInterfaceMockRecorder is the mock recorder for MockSigningKeyRepositoryInterface.
+type MockSigningKeyRepositoryInterfaceMockRecorder struct {
+	mock *MockSigningKeyRepositoryInterface
+}
+
+// NewMockSigningKeyRepositoryInterface creates a new mock instance.
+func NewMockSigningKeyRepositoryInterface(ctrl *gomock.Controller) *MockSigningKeyRepositoryInterface {
+	mock := &MockSigningKeyRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockSigningKeyRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSigningKeyRepositoryInterface) EXPECT() *MockSigningKeyRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// DeleteSigningKeys mocks base method.
+func (m *MockSigningKeyRepositoryInterface) DeleteSigningKeys(ctx context.Context, ids []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSigningKeys", ctx, ids)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteSigningKeys indicates an expected call of DeleteSigningKeys.
+func (mr *MockSigningKeyRepositoryInterfaceMockRecorder) DeleteSigningKeys(ctx, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSigningKeys", reflect.TypeOf((*MockSigningKeyRepositoryInterface)(nil).DeleteSigningKeys), ctx, ids)
+}
+
+// GetSigningKeys mocks base method.
+func (m *MockSigningKeyRepositoryInterface) GetSigningKeys(ctx context.Context) ([]SigningKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSigningKeys", ctx)
+	ret0, _ := ret[0].([]SigningKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSigningKeys indicates an expected call of GetSigningKeys.
+func (mr *MockSigningKeyRepositoryInterfaceMockRecorder) GetSigningKeys(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSigningKeys", reflect.TypeOf((*MockSigningKeyRepositoryInterface)(nil).GetSigningKeys), ctx)
+}
+
+// RotateSigningKey mocks base method.
+func (m *MockSigningKeyRepositoryInterface) RotateSigningKey(ctx context.Context, latestID string, next SigningKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateSigningKey", ctx, latestID, next)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RotateSigningKey indicates an expected call of RotateSigningKey.
+func (mr *MockSigningKeyRepositoryInterfaceMockRecorder) RotateSigningKey(ctx, latestID, next any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateSigningKey", reflect.TypeOf((*MockSigningKeyRepositoryInterface)(nil).RotateSigningKey), ctx, latestID, next)
+}
+
+// MockSigningKeyLogicInterface is a mock of SigningKeyLogicInterface interface.
+type MockSigningKeyLogicInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockSigningKeyLogicInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockSigningKeyLogicInterfaceMockRecorder is the mock recorder for MockSigningKeyLogicInterface.
+type MockSigningKeyLogicInterfaceMockRecorder struct {
+	mock *MockSigningKeyLogicInterface
+}
+
+// NewMockSigningKeyLogicInterface creates a new mock instance.
+func NewMockSigningKeyLogicInterface(ctrl *gomock.Controller) *MockSigningKeyLogicInterface {
+	mock := &MockSigningKeyLogicInterface{ctrl: ctrl}
+	mock.recorder = &MockSigningKeyLogicInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSigningKeyLogicInterface) EXPECT() *MockSigningKeyLogicInterfaceMockRecorder {
+	return m.recorder
+}
+
+// GetJWKS mocks base method.
+func (m *MockSigningKeyLogicInterface) GetJWKS(ctx context.Context) xjwt.JSONWebKeySet {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJWKS", ctx)
+	ret0, _ := ret[0].(xjwt.JSONWebKeySet)
+	return ret0
+}
+
+// GetJWKS indicates an expected call of GetJWKS.
+func (mr *MockSigningKeyLogicInterfaceMockRecorder) GetJWKS(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJWKS", reflect.TypeOf((*MockSigningKeyLogicInterface)(nil).GetJWKS), ctx)
+}
+
+// RotateKeys mocks base method.
+func (m *MockSigningKeyLogicInterface) RotateKeys(ctx context.Context, now time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateKeys", ctx, now)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RotateKeys indicates an expected call of RotateKeys.
+func (mr *MockSigningKeyLogicInterfaceMockRecorder) RotateKeys(ctx, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateKeys", reflect.TypeOf((*MockSigningKeyLogicInterface)(nil).RotateKeys), ctx, now)
+}
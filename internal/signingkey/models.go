@@ -0,0 +1,25 @@
+package signingkey
+
+import (
+	"database/sql"
+	"time"
+)
+
+// SigningKey is a stored jwt signing key, the private key is encrypted with the JWT secret key
+type SigningKey struct {
+	ID          string
+	Algorithm   string
+	PrivateKey  []byte
+	PublicKey   []byte
+	ActivatesAt time.Time
+	CreatedAt   time.Time
+}
+
+type SQLSigningKey struct {
+	ID          sql.NullString `db:"id"`
+	Algorithm   sql.NullString `db:"algorithm"`
+	PrivateKey  []byte         `db:"private_key"`
+	PublicKey   []byte         `db:"public_key"`
+	ActivatesAt sql.NullTime   `db:"activates_at"`
+	CreatedAt   sql.NullTime   `db:"created_at"`
+}
@@ -0,0 +1,20 @@
+package signingkey
+
+import (
+	"context"
+	"time"
+
+	"github.com/rahadianir/dealls/internal/pkg/xjwt"
+)
+
+// SigningKeyRepositoryInterface stores the signing keys shared by every company, so it isn't scoped to the context company
+type SigningKeyRepositoryInterface interface {
+	GetSigningKeys(ctx context.Context) ([]SigningKey, error)
+	RotateSigningKey(ctx context.Context, latestID string, next SigningKey) error
+	DeleteSigningKeys(ctx context.Context, ids []string) ([]string, error)
+}
+
+type SigningKeyLogicInterface interface {
+	RotateKeys(ctx context.Context, now time.Time) error
+	GetJWKS(ctx context.Context) xjwt.JSONWebKeySet
+}
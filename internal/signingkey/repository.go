@@ -0,0 +1,126 @@
+package signingkey
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/pkg/dbhelper"
+	"github.com/rahadianir/dealls/internal/pkg/xerror"
+)
+
+type SigningKeyRepository struct {
+	deps *config.CommonDependencies
+}
+
+func NewSigningKeyRepository(deps *config.CommonDependencies) *SigningKeyRepository {
+	return &SigningKeyRepository{
+		deps: deps,
+	}
+}
+
+// GetSigningKeys returns every stored key ordered by activation, there are only a handful since expired keys are deleted
+func (repo *SigningKeyRepository) GetSigningKeys(ctx context.Context) ([]SigningKey, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`id`, `algorithm`, `private_key`, `public_key`, `activates_at`, `created_at`).
+		From(`hr.signing_keys`).
+		OrderBy(`activates_at`)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	rows, err := tx.QueryxContext(ctx, q, args...)
+	if err != nil {
+		return []SigningKey{}, err
+	}
+	defer rows.Close()
+
+	result := []SigningKey{}
+	for rows.Next() {
+		var temp SQLSigningKey
+		err := rows.StructScan(&temp)
+		if err != nil {
+			repo.deps.Logger.WarnContext(ctx, "failed to scan signing key", slog.Any("error", err))
+			continue
+		}
+		result = append(result, SigningKey{
+			ID:          temp.ID.String,
+			Algorithm:   temp.Algorithm.String,
+			PrivateKey:  temp.PrivateKey,
+			PublicKey:   temp.PublicKey,
+			ActivatesAt: temp.ActivatesAt.Time,
+			CreatedAt:   temp.CreatedAt.Time,
+		})
+	}
+
+	return result, nil
+}
+
+// RotateSigningKey stores the next key as long as the latest key is still latestID (empty when there's no key yet),
+// the table lock makes server instances rotating at the same time create only one key, the others get ErrDataNotFound
+func (repo *SigningKeyRepository) RotateSigningKey(ctx context.Context, latestID string, next SigningKey) error {
+	return dbhelper.WithTransaction(ctx, repo.deps.DB, func(ctx context.Context) error {
+		tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+		_, err := tx.ExecContext(ctx, `LOCK TABLE hr.signing_keys IN SHARE ROW EXCLUSIVE MODE`)
+		if err != nil {
+			return err
+		}
+
+		sq := sqlbuilder.NewSelectBuilder()
+		sq.Select(`id`).From(`hr.signing_keys`).OrderBy(`activates_at DESC`).Limit(1)
+		q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+		var currentID string
+		err = tx.QueryRowxContext(ctx, q, args...).Scan(&currentID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if currentID != latestID {
+			return xerror.ErrDataNotFound
+		}
+
+		ib := sqlbuilder.NewInsertBuilder()
+		ib.InsertInto(`hr.signing_keys`).
+			Cols(`id`, `algorithm`, `private_key`, `public_key`, `activates_at`, `created_at`).
+			Values(next.ID, next.Algorithm, next.PrivateKey, next.PublicKey, next.ActivatesAt, next.CreatedAt)
+		q, args = ib.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+		_, err = tx.ExecContext(ctx, q, args...)
+		return err
+	})
+}
+
+// DeleteSigningKeys returns the ids of the keys deleted, the ones another server instance deleted first are left out
+func (repo *SigningKeyRepository) DeleteSigningKeys(ctx context.Context, ids []string) ([]string, error) {
+	if len(ids) == 0 {
+		return []string{}, nil
+	}
+
+	db := sqlbuilder.NewDeleteBuilder()
+	db.DeleteFrom(`hr.signing_keys`).Where(db.In(`id::text`, sqlbuilder.List(ids)))
+	q, args := db.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	rows, err := tx.QueryxContext(ctx, q+` RETURNING id`, args...)
+	if err != nil {
+		return []string{}, err
+	}
+	defer rows.Close()
+
+	result := []string{}
+	for rows.Next() {
+		var id string
+		err := rows.Scan(&id)
+		if err != nil {
+			return []string{}, err
+		}
+		result = append(result, id)
+	}
+
+	return result, rows.Err()
+}
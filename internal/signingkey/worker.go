@@ -0,0 +1,44 @@
+package signingkey
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/rahadianir/dealls/internal/config"
+)
+
+// KeyRotationWorker rotates the signing keys when due and reloads the ones rotated by other server instances
+type KeyRotationWorker struct {
+	deps     *config.CommonDependencies
+	keyLogic SigningKeyLogicInterface
+}
+
+func NewKeyRotationWorker(deps *config.CommonDependencies, keyLogic SigningKeyLogicInterface) *KeyRotationWorker {
+	return &KeyRotationWorker{
+		deps:     deps,
+		keyLogic: keyLogic,
+	}
+}
+
+// Start reloads the keys every refresh interval until the context is cancelled, the keys are loaded once before the server starts
+func (w *KeyRotationWorker) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.deps.Config.App.KeyRefreshInterval)
+	defer ticker.Stop()
+
+	w.deps.Logger.InfoContext(ctx, "signing key rotation worker starts")
+	for {
+		select {
+		case <-ctx.Done():
+			w.deps.Logger.InfoContext(ctx, "signing key rotation worker stopped")
+			return
+		case <-ticker.C:
+		}
+
+		// keep signing with the loaded keys when this fails, it's retried on the next tick
+		err := w.keyLogic.RotateKeys(ctx, time.Now())
+		if err != nil && ctx.Err() == nil {
+			w.deps.Logger.ErrorContext(ctx, "failed to rotate signing keys", slog.Any("error", err))
+		}
+	}
+}
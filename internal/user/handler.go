@@ -1,8 +1,12 @@
 package user
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/rahadianir/dealls/internal/config"
 	"github.com/rahadianir/dealls/internal/pkg/xerror"
 	"github.com/rahadianir/dealls/internal/pkg/xhttp"
@@ -31,8 +35,182 @@ func (handler *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := handler.userLogic.Login(r.Context(), payload.Username, payload.Password)
+	result, err := handler.userLogic.Login(r.Context(), payload.Company, payload.Username, payload.Password)
 	if err != nil {
+		var rateLimitErr xerror.RateLimitError
+		if errors.As(err, &rateLimitErr) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(rateLimitErr.RetryAfter.Seconds()))))
+		}
+
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to login",
+		}, xerror.ParseErrorTypeToCodeInt(err))
+		return
+	}
+
+	message := "login success"
+	if result.ChallengeToken != "" {
+		message = "two-factor code required"
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: message,
+		Data:    result,
+	}, http.StatusOK)
+}
+
+func (handler *UserHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var payload RefreshTokenRequest
+	err := xhttp.BindJSONRequest(r, &payload)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: xerror.ErrBadRequest.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	result, err := handler.userLogic.RefreshToken(r.Context(), payload.RefreshToken)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to refresh token",
+		}, xerror.ParseErrorTypeToCodeInt(err))
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "token refreshed",
+		Data:    result,
+	}, http.StatusOK)
+}
+
+func (handler *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	err := handler.userLogic.Logout(r.Context())
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to logout",
+		}, xerror.ParseErrorTypeToCodeInt(err))
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "logout success",
+	}, http.StatusOK)
+}
+
+func (handler *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	var payload ChangePasswordRequest
+	err := xhttp.BindJSONRequest(r, &payload)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: xerror.ErrBadRequest.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	result, err := handler.userLogic.ChangePassword(r.Context(), payload)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to change password",
+		}, xerror.ParseErrorTypeToCodeInt(err))
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "password changed",
+		Data:    result,
+	}, http.StatusOK)
+}
+
+func (handler *UserHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	result, err := handler.userLogic.RequestPasswordReset(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		code := xerror.ParseErrorTypeToCodeInt(err)
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			code = http.StatusNotFound
+		}
+
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to request password reset",
+		}, code)
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "password reset token sent",
+		Data:    result,
+	}, http.StatusOK)
+}
+
+func (handler *UserHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var payload ResetPasswordRequest
+	err := xhttp.BindJSONRequest(r, &payload)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: xerror.ErrBadRequest.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	err = handler.userLogic.ResetPassword(r.Context(), payload)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to reset password",
+		}, xerror.ParseErrorTypeToCodeInt(err))
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "password reset success",
+	}, http.StatusOK)
+}
+
+func (handler *UserHandler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	err := handler.userLogic.UnlockUser(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		code := xerror.ParseErrorTypeToCodeInt(err)
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			code = http.StatusNotFound
+		}
+
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to unlock user",
+		}, code)
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "user unlocked",
+	}, http.StatusOK)
+}
+
+func (handler *UserHandler) VerifyTwoFactorLogin(w http.ResponseWriter, r *http.Request) {
+	var payload TwoFactorLoginRequest
+	err := xhttp.BindJSONRequest(r, &payload)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: xerror.ErrBadRequest.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	result, err := handler.userLogic.VerifyTwoFactorLogin(r.Context(), payload)
+	if err != nil {
+		var rateLimitErr xerror.RateLimitError
+		if errors.As(err, &rateLimitErr) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(rateLimitErr.RetryAfter.Seconds()))))
+		}
+
 		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
 			Error:   err.Error(),
 			Message: "failed to login",
@@ -45,3 +223,281 @@ func (handler *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 		Data:    result,
 	}, http.StatusOK)
 }
+
+// StartSSOLogin redirects the browser to the identity provider to sign in
+func (handler *UserHandler) StartSSOLogin(w http.ResponseWriter, r *http.Request) {
+	authURL, err := handler.userLogic.StartSSOLogin(r.Context())
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to start single sign-on",
+		}, xerror.ParseErrorTypeToCodeInt(err))
+		return
+	}
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// CompleteSSOLogin is where the identity provider redirects the browser back to after signing in
+func (handler *UserHandler) CompleteSSOLogin(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	result, err := handler.userLogic.CompleteSSOLogin(r.Context(), SSOCallbackRequest{
+		Code:             query.Get("code"),
+		State:            query.Get("state"),
+		Error:            query.Get("error"),
+		ErrorDescription: query.Get("error_description"),
+	})
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to login",
+		}, xerror.ParseErrorTypeToCodeInt(err))
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "login success",
+		Data:    result,
+	}, http.StatusOK)
+}
+
+func (handler *UserHandler) EnrolTwoFactor(w http.ResponseWriter, r *http.Request) {
+	result, err := handler.userLogic.EnrolTwoFactor(r.Context())
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to enrol two-factor authentication",
+		}, xerror.ParseErrorTypeToCodeInt(err))
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "two-factor authentication enrolment started",
+		Data:    result,
+	}, http.StatusOK)
+}
+
+func (handler *UserHandler) ConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var payload ConfirmTwoFactorRequest
+	err := xhttp.BindJSONRequest(r, &payload)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: xerror.ErrBadRequest.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	result, err := handler.userLogic.ConfirmTwoFactor(r.Context(), payload)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to enable two-factor authentication",
+		}, xerror.ParseErrorTypeToCodeInt(err))
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "two-factor authentication enabled",
+		Data:    result,
+	}, http.StatusOK)
+}
+
+func (handler *UserHandler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var payload DisableTwoFactorRequest
+	err := xhttp.BindJSONRequest(r, &payload)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: xerror.ErrBadRequest.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	err = handler.userLogic.DisableTwoFactor(r.Context(), payload)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to disable two-factor authentication",
+		}, xerror.ParseErrorTypeToCodeInt(err))
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "two-factor authentication disabled",
+	}, http.StatusOK)
+}
+
+func (handler *UserHandler) SetTwoFactorRequirement(w http.ResponseWriter, r *http.Request) {
+	var payload TwoFactorRequirementRequest
+	err := xhttp.BindJSONRequest(r, &payload)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: xerror.ErrBadRequest.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	err = handler.userLogic.SetTwoFactorRequirement(r.Context(), chi.URLParam(r, "id"), payload)
+	if err != nil {
+		code := xerror.ParseErrorTypeToCodeInt(err)
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			code = http.StatusNotFound
+		}
+
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to set two-factor requirement",
+		}, code)
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "two-factor requirement set",
+	}, http.StatusOK)
+}
+
+func (handler *UserHandler) ResetTwoFactor(w http.ResponseWriter, r *http.Request) {
+	err := handler.userLogic.ResetTwoFactor(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		code := xerror.ParseErrorTypeToCodeInt(err)
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			code = http.StatusNotFound
+		}
+
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to reset two-factor authentication",
+		}, code)
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "two-factor authentication reset",
+	}, http.StatusOK)
+}
+
+func (handler *UserHandler) SetEmployment(w http.ResponseWriter, r *http.Request) {
+	var payload EmploymentRequest
+	err := xhttp.BindJSONRequest(r, &payload)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: xerror.ErrBadRequest.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	result, err := handler.userLogic.SetEmployment(r.Context(), chi.URLParam(r, "id"), payload)
+	if err != nil {
+		code := xerror.ParseErrorTypeToCodeInt(err)
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			code = http.StatusNotFound
+		}
+
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to set user employment",
+		}, code)
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "user employment set",
+		Data:    result,
+	}, http.StatusOK)
+}
+
+func (handler *UserHandler) TerminateEmployment(w http.ResponseWriter, r *http.Request) {
+	var payload TerminationRequest
+	err := xhttp.BindJSONRequest(r, &payload)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: xerror.ErrBadRequest.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	result, err := handler.userLogic.TerminateEmployment(r.Context(), chi.URLParam(r, "id"), payload)
+	if err != nil {
+		code := xerror.ParseErrorTypeToCodeInt(err)
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			code = http.StatusNotFound
+		}
+
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to terminate user employment",
+		}, code)
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "user employment terminated",
+		Data:    result,
+	}, http.StatusOK)
+}
+
+func (handler *UserHandler) SetSalary(w http.ResponseWriter, r *http.Request) {
+	var payload SalaryRequest
+	err := xhttp.BindJSONRequest(r, &payload)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: xerror.ErrBadRequest.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	result, err := handler.userLogic.SetSalary(r.Context(), chi.URLParam(r, "id"), payload)
+	if err != nil {
+		code := xerror.ParseErrorTypeToCodeInt(err)
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			code = http.StatusNotFound
+		}
+
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to set user salary",
+		}, code)
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "user salary set",
+		Data:    result,
+	}, http.StatusOK)
+}
+
+func (handler *UserHandler) SetEmail(w http.ResponseWriter, r *http.Request) {
+	var payload EmailRequest
+	err := xhttp.BindJSONRequest(r, &payload)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: xerror.ErrBadRequest.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	result, err := handler.userLogic.SetEmail(r.Context(), chi.URLParam(r, "id"), payload)
+	if err != nil {
+		code := xerror.ParseErrorTypeToCodeInt(err)
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			code = http.StatusNotFound
+		}
+
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to set user email",
+		}, code)
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "user email set",
+		Data:    result,
+	}, http.StatusOK)
+}
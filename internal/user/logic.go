@@ -2,36 +2,106 @@ package user
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log/slog"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/rahadianir/dealls/internal/company"
 	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/models"
+	"github.com/rahadianir/dealls/internal/pkg/xaudit"
+	"github.com/rahadianir/dealls/internal/pkg/xcontext"
+	"github.com/rahadianir/dealls/internal/pkg/xcrypto"
+	"github.com/rahadianir/dealls/internal/pkg/xcurrency"
 	"github.com/rahadianir/dealls/internal/pkg/xerror"
 	"github.com/rahadianir/dealls/internal/pkg/xjwt"
+	"github.com/rahadianir/dealls/internal/pkg/xmail"
+	"github.com/rahadianir/dealls/internal/pkg/xoidc"
+	"github.com/rahadianir/dealls/internal/pkg/xtotp"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserLogic struct {
-	deps      *config.CommonDependencies
-	userRepo  UserRepositoryInterface
-	jwtHelper xjwt.JWTHelper
+	deps             *config.CommonDependencies
+	userRepo         UserRepositoryInterface
+	companyRepo      company.CompanyRepositoryInterface
+	jwtHelper        xjwt.JWTHelper
+	mails            xmail.Queue
+	identityProvider xoidc.Provider // nil when single sign-on isn't configured
+	auditor          xaudit.Recorder
 }
 
-func NewUserLogic(deps *config.CommonDependencies, userRepo UserRepositoryInterface, jwtHelper xjwt.JWTHelper) *UserLogic {
+func NewUserLogic(deps *config.CommonDependencies, userRepo UserRepositoryInterface, companyRepo company.CompanyRepositoryInterface, jwtHelper xjwt.JWTHelper, mails xmail.Queue, identityProvider xoidc.Provider, auditor xaudit.Recorder) *UserLogic {
 	return &UserLogic{
-		deps:      deps,
-		userRepo:  userRepo,
-		jwtHelper: jwtHelper,
+		deps:             deps,
+		userRepo:         userRepo,
+		companyRepo:      companyRepo,
+		jwtHelper:        jwtHelper,
+		mails:            mails,
+		identityProvider: identityProvider,
+		auditor:          auditor,
 	}
 }
 
-func (logic *UserLogic) Login(ctx context.Context, username string, password string) (LoginResponse, error) {
+// errInvalidCredentials is the same for unknown companies, unknown usernames and wrong passwords,
+// so failed logins don't tell which companies and usernames exist
+var errInvalidCredentials = xerror.AuthError{Err: fmt.Errorf("invalid company, username or password")}
+
+// dummyPasswordHash is compared against when there's no user, so unknown usernames take as long as wrong passwords
+const dummyPasswordHash = "$2a$12$W6ReddAg.ciyGWNd68p9MehtW.dW1x32kR6cKJpob/8/avx.41jpe"
+
+// Login logs the user in to the company, the default company when it's empty.
+// Failed attempts are throttled per account and per client IP, see checkLoginThrottle.
+func (logic *UserLogic) Login(ctx context.Context, companyCode string, username string, password string) (LoginResponse, error) {
+	companyCode = strings.ToLower(strings.TrimSpace(companyCode))
+	if companyCode == "" {
+		companyCode = models.DefaultCompanyCode
+	}
+
+	// the identity provider can be the only way in for its company
+	oidcCfg := logic.deps.Config.OIDC
+	if logic.identityProvider != nil && !oidcCfg.PasswordLogin && companyCode == strings.ToLower(oidcCfg.CompanyCode) {
+		return LoginResponse{}, xerror.AuthError{Err: fmt.Errorf("password login is disabled, please login with single sign-on")}
+	}
+
+	accountKey, throttleKeys := loginThrottleKeys(ctx, companyCode, username)
+	err := logic.checkLoginThrottle(ctx, throttleKeys)
+	if err != nil {
+		return LoginResponse{}, err
+	}
+
+	userCompany, err := logic.companyRepo.GetCompanyByCode(ctx, companyCode)
+	if err != nil {
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			logic.deps.Logger.WarnContext(ctx, "company not found", slog.String("company", companyCode))
+			_ = bcrypt.CompareHashAndPassword([]byte(dummyPasswordHash), []byte(password))
+			return LoginResponse{}, logic.failLogin(ctx, throttleKeys, errInvalidCredentials)
+		}
+
+		return LoginResponse{}, err
+	}
+
+	// usernames are unique within the company only
+	ctx = context.WithValue(ctx, xcontext.CompanyIDKey, userCompany.ID)
+
 	userDetails, err := logic.userRepo.GetUserDetailsByUsername(ctx, username)
 	if err != nil {
 		if errors.Is(err, xerror.ErrDataNotFound) {
 			logic.deps.Logger.WarnContext(ctx, "username not found", slog.Any("error", err))
-			return LoginResponse{}, err
+			_ = bcrypt.CompareHashAndPassword([]byte(dummyPasswordHash), []byte(password))
+			return LoginResponse{}, logic.failLogin(ctx, throttleKeys, errInvalidCredentials)
 		}
 
 		return LoginResponse{}, err
@@ -40,18 +110,1534 @@ func (logic *UserLogic) Login(ctx context.Context, username string, password str
 	err = bcrypt.CompareHashAndPassword([]byte(userDetails.Password), []byte(password))
 	if err != nil {
 		logic.deps.Logger.WarnContext(ctx, "invalid password", slog.Any("error", err))
-		return LoginResponse{}, xerror.ClientError{Err: fmt.Errorf("invalid password")}
+		return LoginResponse{}, logic.failLogin(ctx, throttleKeys, errInvalidCredentials)
+	}
+
+	// the failed attempts are only cleared once the second factor is verified too
+	if userDetails.TwoFactorEnabled {
+		return logic.startLoginChallenge(ctx, userDetails, companyCode)
 	}
 
-	token, err := logic.jwtHelper.GenerateJWT(logic.deps.Config.App.Name, userDetails.ID, logic.deps.Config.App.ExpiryTime, logic.deps.Config.App.JWTSecretKey)
+	// the IP keeps its failures, otherwise logging in to an own account would reset them in between guesses
+	err = logic.userRepo.ClearLoginThrottle(ctx, accountKey)
 	if err != nil {
-		logic.deps.Logger.ErrorContext(ctx, "failed to generate JWT", slog.Any("error", err))
+		logic.deps.Logger.ErrorContext(ctx, "failed to clear failed login attempts", slog.Any("error", err))
 		return LoginResponse{}, err
 	}
 
-	result := LoginResponse{
-		Token: token,
+	// a login starts a new session, refreshed tokens carry on with it
+	result, refreshToken, err := logic.issueTokens(userDetails, userCompany.ID, uuid.NewString())
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to issue tokens", slog.Any("error", err))
+		return LoginResponse{}, err
+	}
+
+	err = logic.userRepo.CreateRefreshToken(ctx, refreshToken)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to store refresh token", slog.Any("error", err))
+		return LoginResponse{}, err
+	}
+
+	return result, nil
+}
+
+// loginThrottleKeys returns the key the failed attempts of the account are counted under,
+// along with the keys to check and count, which include the client IP when it's known
+func loginThrottleKeys(ctx context.Context, companyCode string, username string) (LoginThrottleKey, []LoginThrottleKey) {
+	accountKey := LoginThrottleKey{Scope: LoginThrottleAccount, CompanyCode: strings.ToLower(companyCode), Subject: strings.ToLower(strings.TrimSpace(username))}
+	keys := []LoginThrottleKey{accountKey}
+	if ip := xcontext.GetIPFromContext(ctx); ip != "" {
+		keys = append(keys, LoginThrottleKey{Scope: LoginThrottleIP, Subject: ip})
+	}
+
+	return accountKey, keys
+}
+
+// userThrottleKeys returns the login throttle keys of the logged in user, so checking the password of a logged in
+// session is throttled along with the logins of the account
+func (logic *UserLogic) userThrottleKeys(ctx context.Context, username string) (LoginThrottleKey, []LoginThrottleKey, error) {
+	userCompany, err := logic.companyRepo.GetCompanyByID(ctx, xcontext.GetCompanyIDFromContext(ctx))
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get company", slog.Any("error", err))
+		return LoginThrottleKey{}, nil, err
+	}
+
+	accountKey, keys := loginThrottleKeys(ctx, userCompany.Code, username)
+	return accountKey, keys, nil
+}
+
+// checkLoginThrottle rejects the attempt while the account or the IP is locked, without checking the password at all
+func (logic *UserLogic) checkLoginThrottle(ctx context.Context, keys []LoginThrottleKey) error {
+	now := time.Now()
+	for _, key := range keys {
+		throttle, err := logic.userRepo.GetLoginThrottle(ctx, key)
+		if err != nil {
+			if errors.Is(err, xerror.ErrDataNotFound) {
+				continue
+			}
+
+			logic.deps.Logger.ErrorContext(ctx, "failed to get failed login attempts", slog.Any("error", err))
+			return err
+		}
+
+		if throttle.LockedUntil != nil && throttle.LockedUntil.After(now) {
+			logic.deps.Logger.WarnContext(ctx, "login throttled", slog.String("scope", key.Scope), slog.Time("locked_until", *throttle.LockedUntil))
+			return xerror.RateLimitError{
+				Err:        fmt.Errorf("too many failed login attempts, try again later"),
+				RetryAfter: throttle.LockedUntil.Sub(now),
+			}
+		}
+	}
+
+	return nil
+}
+
+// failLogin counts the failed attempt for the account and the IP, locking them once they pass the thresholds,
+// then returns the cause of the failure
+func (logic *UserLogic) failLogin(ctx context.Context, keys []LoginThrottleKey, cause error) error {
+	cfg := logic.deps.Config.Login
+
+	for _, key := range keys {
+		failures, err := logic.userRepo.RecordLoginFailure(ctx, key, cfg.FailureWindow)
+		if err != nil {
+			logic.deps.Logger.ErrorContext(ctx, "failed to record failed login attempt", slog.Any("error", err))
+			return err
+		}
+
+		var lockFor time.Duration
+		switch {
+		case key.Scope == LoginThrottleAccount && failures >= cfg.LockoutAfter:
+			lockFor = cfg.LockoutDuration
+		case key.Scope == LoginThrottleAccount && failures >= cfg.BackoffAfter:
+			lockFor = loginBackoff(cfg, failures-cfg.BackoffAfter)
+		case key.Scope == LoginThrottleIP && failures >= cfg.IPBackoffAfter:
+			lockFor = loginBackoff(cfg, failures-cfg.IPBackoffAfter)
+		default:
+			continue
+		}
+
+		err = logic.userRepo.LockLogin(ctx, key, time.Now().Add(lockFor))
+		if err != nil {
+			logic.deps.Logger.ErrorContext(ctx, "failed to lock login", slog.Any("error", err))
+			return err
+		}
+	}
+
+	return cause
+}
+
+// loginBackoff doubles the base wait for every failed attempt past the threshold, up to the max wait
+func loginBackoff(cfg *config.Login, exceeded int) time.Duration {
+	wait := cfg.BackoffBase
+	for i := 0; i < exceeded && wait < cfg.BackoffMax; i++ {
+		wait *= 2
+	}
+
+	return min(wait, cfg.BackoffMax)
+}
+
+// UnlockUser clears the failed login attempts of the user, so a locked out user can log in right away
+func (logic *UserLogic) UnlockUser(ctx context.Context, userID string) error {
+	// check admin role of the user
+	isAdmin, err := logic.userRepo.IsAdmin(ctx, xcontext.GetUserIDFromContext(ctx))
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to check user admin role", slog.Any("error", err))
+		return err
+	}
+
+	if !isAdmin {
+		return xerror.AuthError{Err: fmt.Errorf("admin only operation")}
+	}
+
+	userDetails, err := logic.userRepo.GetUserDetailsByID(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, xerror.ErrDataNotFound) {
+			logic.deps.Logger.ErrorContext(ctx, "failed to get user details", slog.Any("error", err))
+		}
+		return err
+	}
+
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionUnlock,
+		Entity:   xaudit.EntityUser,
+		EntityID: userDetails.ID,
+	}, func(ctx context.Context) error {
+		return logic.userRepo.UnlockAccount(ctx, userDetails.Username)
+	})
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to unlock user", slog.Any("error", err))
+		return err
+	}
+
+	return nil
+}
+
+// RefreshToken exchanges the refresh token for a new access token and refresh token of the same session.
+// Refresh tokens are single use, using a replaced one again revokes the whole session since the token may have been stolen.
+func (logic *UserLogic) RefreshToken(ctx context.Context, refreshToken string) (LoginResponse, error) {
+	if refreshToken == "" {
+		return LoginResponse{}, xerror.ClientError{Err: fmt.Errorf("refresh token is required")}
+	}
+
+	stored, err := logic.userRepo.GetRefreshTokenByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			logic.deps.Logger.WarnContext(ctx, "refresh token not found", slog.Any("error", err))
+			return LoginResponse{}, xerror.AuthError{Err: fmt.Errorf("invalid refresh token")}
+		}
+
+		logic.deps.Logger.ErrorContext(ctx, "failed to get refresh token", slog.Any("error", err))
+		return LoginResponse{}, err
+	}
+
+	// the token identifies the company and the user of the request
+	ctx = context.WithValue(ctx, xcontext.CompanyIDKey, stored.CompanyID)
+	ctx = context.WithValue(ctx, xcontext.UserIDKey, stored.UserID)
+
+	if stored.RevokedAt != nil {
+		if stored.ReplacedBy == "" {
+			return LoginResponse{}, xerror.AuthError{Err: fmt.Errorf("refresh token has been revoked")}
+		}
+
+		return LoginResponse{}, logic.revokeReusedSession(ctx, stored.SessionID)
+	}
+
+	if !stored.ExpiresAt.After(time.Now()) {
+		return LoginResponse{}, xerror.AuthError{Err: fmt.Errorf("refresh token has expired")}
+	}
+
+	// deleted users can't carry on with their sessions
+	userDetails, err := logic.userRepo.GetUserDetailsByID(ctx, stored.UserID)
+	if err != nil {
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			return LoginResponse{}, xerror.AuthError{Err: fmt.Errorf("user not found")}
+		}
+
+		logic.deps.Logger.ErrorContext(ctx, "failed to get user details", slog.Any("error", err))
+		return LoginResponse{}, err
+	}
+
+	result, next, err := logic.issueTokens(userDetails, stored.CompanyID, stored.SessionID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to issue tokens", slog.Any("error", err))
+		return LoginResponse{}, err
+	}
+
+	err = logic.userRepo.RotateRefreshToken(ctx, stored.ID, next)
+	if err != nil {
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			// refreshed concurrently with the same token
+			return LoginResponse{}, logic.revokeReusedSession(ctx, stored.SessionID)
+		}
+
+		logic.deps.Logger.ErrorContext(ctx, "failed to rotate refresh token", slog.Any("error", err))
+		return LoginResponse{}, err
+	}
+
+	return result, nil
+}
+
+// Logout revokes the access token of the request along with the refresh tokens of its session
+func (logic *UserLogic) Logout(ctx context.Context) error {
+	tokenID := xcontext.GetTokenIDFromContext(ctx)
+	sessionID := xcontext.GetSessionIDFromContext(ctx)
+
+	// a token without a session is the only thing revoked, it stands in for the session in the audit log
+	entityID := sessionID
+	if entityID == "" {
+		entityID = tokenID
+	}
+
+	return xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionRevoke,
+		Entity:   xaudit.EntitySession,
+		EntityID: entityID,
+	}, func(ctx context.Context) error {
+		// the access token can't outlive its expiry time, so it only needs to be kept revoked that long
+		err := logic.userRepo.RevokeToken(ctx, tokenID, time.Now().Add(logic.deps.Config.App.ExpiryTime))
+		if err != nil {
+			logic.deps.Logger.ErrorContext(ctx, "failed to revoke access token", slog.Any("error", err))
+			return err
+		}
+
+		if sessionID == "" {
+			return nil
+		}
+
+		err = logic.userRepo.RevokeSession(ctx, sessionID)
+		if err != nil {
+			logic.deps.Logger.ErrorContext(ctx, "failed to revoke session", slog.Any("error", err))
+			return err
+		}
+
+		return nil
+	})
+}
+
+// ChangePassword replaces the password of the logged in user, which logs every session of the user out,
+// so the user carries on with the new session returned. Wrong current passwords count as failed login attempts of the account.
+func (logic *UserLogic) ChangePassword(ctx context.Context, req ChangePasswordRequest) (LoginResponse, error) {
+	userDetails, err := logic.userRepo.GetUserDetailsByID(ctx, xcontext.GetUserIDFromContext(ctx))
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get user details", slog.Any("error", err))
+		return LoginResponse{}, err
+	}
+
+	accountKey, throttleKeys, err := logic.userThrottleKeys(ctx, userDetails.Username)
+	if err != nil {
+		return LoginResponse{}, err
+	}
+
+	err = logic.checkLoginThrottle(ctx, throttleKeys)
+	if err != nil {
+		return LoginResponse{}, err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(userDetails.Password), []byte(req.CurrentPassword))
+	if err != nil {
+		logic.deps.Logger.WarnContext(ctx, "invalid current password", slog.Any("error", err))
+		return LoginResponse{}, logic.failLogin(ctx, throttleKeys, xerror.ClientError{Err: fmt.Errorf("invalid current password")})
+	}
+
+	err = logic.userRepo.ClearLoginThrottle(ctx, accountKey)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to clear failed login attempts", slog.Any("error", err))
+		return LoginResponse{}, err
+	}
+
+	pwHash, err := logic.checkNewPassword(ctx, userDetails, req.NewPassword)
+	if err != nil {
+		return LoginResponse{}, err
+	}
+
+	// passwords are never written to the audit log, not even hashed
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionChangePassword,
+		Entity:   xaudit.EntityUser,
+		EntityID: userDetails.ID,
+	}, func(ctx context.Context) error {
+		return logic.userRepo.UpdatePassword(ctx, PasswordChange{
+			UserID:      userDetails.ID,
+			OldPassword: userDetails.Password,
+			NewPassword: pwHash,
+		})
+	})
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to update password", slog.Any("error", err))
+		return LoginResponse{}, err
+	}
+
+	// the refresh tokens are revoked along with the password, the access token of the request goes too
+	err = logic.userRepo.RevokeToken(ctx, xcontext.GetTokenIDFromContext(ctx), time.Now().Add(logic.deps.Config.App.ExpiryTime))
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to revoke access token", slog.Any("error", err))
+		return LoginResponse{}, err
+	}
+
+	// the user picked the password, so it's no longer a temporary one
+	userDetails.MustChangePassword = false
+	result, refreshToken, err := logic.issueTokens(userDetails, xcontext.GetCompanyIDFromContext(ctx), uuid.NewString())
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to issue tokens", slog.Any("error", err))
+		return LoginResponse{}, err
+	}
+
+	err = logic.userRepo.CreateRefreshToken(ctx, refreshToken)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to store refresh token", slog.Any("error", err))
+		return LoginResponse{}, err
 	}
 
 	return result, nil
 }
+
+// RequestPasswordReset issues a one-time password reset token for the user, the token is only delivered to the user
+// by email and replaces the unused tokens issued before
+func (logic *UserLogic) RequestPasswordReset(ctx context.Context, userID string) (PasswordResetResponse, error) {
+	// check admin role of the user
+	isAdmin, err := logic.userRepo.IsAdmin(ctx, xcontext.GetUserIDFromContext(ctx))
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to check user admin role", slog.Any("error", err))
+		return PasswordResetResponse{}, err
+	}
+
+	if !isAdmin {
+		return PasswordResetResponse{}, xerror.AuthError{Err: fmt.Errorf("admin only operation")}
+	}
+
+	userDetails, err := logic.userRepo.GetUserDetailsByID(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, xerror.ErrDataNotFound) {
+			logic.deps.Logger.ErrorContext(ctx, "failed to get user details", slog.Any("error", err))
+		}
+		return PasswordResetResponse{}, err
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to generate password reset token", slog.Any("error", err))
+		return PasswordResetResponse{}, err
+	}
+
+	expiresAt := time.Now().Add(logic.deps.Config.Password.ResetTokenExpiry)
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionRequestPasswordReset,
+		Entity:   xaudit.EntityUser,
+		EntityID: userDetails.ID,
+		After:    map[string]any{"expires_at": expiresAt},
+	}, func(ctx context.Context) error {
+		return logic.userRepo.CreatePasswordResetToken(ctx, PasswordResetToken{
+			ID:        uuid.NewString(),
+			UserID:    userDetails.ID,
+			TokenHash: hashToken(token),
+			ExpiresAt: expiresAt,
+		})
+	})
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to store password reset token", slog.Any("error", err))
+		return PasswordResetResponse{}, err
+	}
+
+	err = logic.mails.Enqueue(ctx, xmail.Email{
+		UserID:   userDetails.ID,
+		Template: xmail.TemplatePasswordReset,
+		Data:     xmail.PasswordResetData{ExpiresAt: expiresAt.In(logic.deps.Config.App.Timezone).Format(time.RFC3339)},
+		Secrets:  map[string]string{"token": token},
+	})
+	if err != nil {
+		// the token is unusable without being delivered, the admin can simply request another one
+		if errors.Is(err, xmail.ErrNoRecipient) {
+			return PasswordResetResponse{}, xerror.ClientError{Err: fmt.Errorf("the reset token can't be sent, %w", err)}
+		}
+		logic.deps.Logger.ErrorContext(ctx, "failed to send password reset token", slog.Any("error", err))
+		return PasswordResetResponse{}, err
+	}
+
+	return PasswordResetResponse{ExpiresAt: expiresAt}, nil
+}
+
+// ResetPassword sets the password of the user the reset token was issued for, which logs every session of the user out
+func (logic *UserLogic) ResetPassword(ctx context.Context, req ResetPasswordRequest) error {
+	if req.Token == "" {
+		return xerror.ClientError{Err: fmt.Errorf("reset token is required")}
+	}
+
+	stored, err := logic.userRepo.GetPasswordResetTokenByHash(ctx, hashToken(req.Token))
+	if err != nil {
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			logic.deps.Logger.WarnContext(ctx, "password reset token not found", slog.Any("error", err))
+			return xerror.AuthError{Err: fmt.Errorf("invalid reset token")}
+		}
+
+		logic.deps.Logger.ErrorContext(ctx, "failed to get password reset token", slog.Any("error", err))
+		return err
+	}
+
+	// the token identifies the company and the user of the request
+	ctx = context.WithValue(ctx, xcontext.CompanyIDKey, stored.CompanyID)
+	ctx = context.WithValue(ctx, xcontext.UserIDKey, stored.UserID)
+
+	if stored.UsedAt != nil {
+		return xerror.AuthError{Err: fmt.Errorf("reset token has already been used")}
+	}
+
+	if !stored.ExpiresAt.After(time.Now()) {
+		return xerror.AuthError{Err: fmt.Errorf("reset token has expired")}
+	}
+
+	userDetails, err := logic.userRepo.GetUserDetailsByID(ctx, stored.UserID)
+	if err != nil {
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			return xerror.AuthError{Err: fmt.Errorf("user not found")}
+		}
+
+		logic.deps.Logger.ErrorContext(ctx, "failed to get user details", slog.Any("error", err))
+		return err
+	}
+
+	pwHash, err := logic.checkNewPassword(ctx, userDetails, req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	// the user picked the password, so it's no longer a temporary one
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionResetPassword,
+		Entity:   xaudit.EntityUser,
+		EntityID: userDetails.ID,
+	}, func(ctx context.Context) error {
+		return logic.userRepo.ResetPassword(ctx, stored.ID, PasswordChange{
+			UserID:      userDetails.ID,
+			OldPassword: userDetails.Password,
+			NewPassword: pwHash,
+		})
+	})
+	if err != nil {
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			// used concurrently or expired meanwhile
+			return xerror.AuthError{Err: fmt.Errorf("reset token has already been used")}
+		}
+
+		logic.deps.Logger.ErrorContext(ctx, "failed to reset password", slog.Any("error", err))
+		return err
+	}
+
+	return nil
+}
+
+// errInvalidTwoFactorCode is returned for wrong, reused and unknown recovery codes alike
+var errInvalidTwoFactorCode = xerror.AuthError{Err: fmt.Errorf("invalid two-factor code")}
+
+// startLoginChallenge holds the login of a user with two-factor authentication until it's completed with a code,
+// the challenge token returned stands in for the password in VerifyTwoFactorLogin
+func (logic *UserLogic) startLoginChallenge(ctx context.Context, userDetails models.User, companyCode string) (LoginResponse, error) {
+	token, err := generateToken()
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to generate login challenge token", slog.Any("error", err))
+		return LoginResponse{}, err
+	}
+
+	err = logic.userRepo.CreateLoginChallenge(ctx, LoginChallenge{
+		ID:          uuid.NewString(),
+		CompanyCode: companyCode,
+		UserID:      userDetails.ID,
+		TokenHash:   hashToken(token),
+		ExpiresAt:   time.Now().Add(logic.deps.Config.TwoFactor.ChallengeExpiry),
+	})
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to store login challenge", slog.Any("error", err))
+		return LoginResponse{}, err
+	}
+
+	return LoginResponse{ChallengeToken: token}, nil
+}
+
+// VerifyTwoFactorLogin completes the login waiting for the second factor with a code of the authenticator app or
+// a recovery code. Wrong codes count as failed login attempts of the account.
+func (logic *UserLogic) VerifyTwoFactorLogin(ctx context.Context, req TwoFactorLoginRequest) (LoginResponse, error) {
+	if req.ChallengeToken == "" {
+		return LoginResponse{}, xerror.ClientError{Err: fmt.Errorf("challenge token is required")}
+	}
+
+	stored, err := logic.userRepo.GetLoginChallengeByHash(ctx, hashToken(req.ChallengeToken))
+	if err != nil {
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			logic.deps.Logger.WarnContext(ctx, "login challenge not found", slog.Any("error", err))
+			return LoginResponse{}, xerror.AuthError{Err: fmt.Errorf("invalid challenge token")}
+		}
+
+		logic.deps.Logger.ErrorContext(ctx, "failed to get login challenge", slog.Any("error", err))
+		return LoginResponse{}, err
+	}
+
+	// the challenge identifies the company and the user of the request
+	ctx = context.WithValue(ctx, xcontext.CompanyIDKey, stored.CompanyID)
+	ctx = context.WithValue(ctx, xcontext.UserIDKey, stored.UserID)
+
+	if stored.UsedAt != nil {
+		return LoginResponse{}, xerror.AuthError{Err: fmt.Errorf("challenge token has already been used")}
+	}
+
+	if !stored.ExpiresAt.After(time.Now()) {
+		return LoginResponse{}, xerror.AuthError{Err: fmt.Errorf("challenge token has expired, please login again")}
+	}
+
+	userDetails, err := logic.userRepo.GetUserDetailsByID(ctx, stored.UserID)
+	if err != nil {
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			return LoginResponse{}, xerror.AuthError{Err: fmt.Errorf("user not found")}
+		}
+
+		logic.deps.Logger.ErrorContext(ctx, "failed to get user details", slog.Any("error", err))
+		return LoginResponse{}, err
+	}
+
+	accountKey, throttleKeys := loginThrottleKeys(ctx, stored.CompanyCode, userDetails.Username)
+	err = logic.checkLoginThrottle(ctx, throttleKeys)
+	if err != nil {
+		return LoginResponse{}, err
+	}
+
+	valid, err := logic.verifyTwoFactorCode(ctx, userDetails.ID, req.Code)
+	if err != nil {
+		return LoginResponse{}, err
+	}
+
+	if !valid {
+		logic.deps.Logger.WarnContext(ctx, "invalid two-factor code")
+		return LoginResponse{}, logic.failLogin(ctx, throttleKeys, errInvalidTwoFactorCode)
+	}
+
+	err = logic.userRepo.CompleteLoginChallenge(ctx, stored.ID)
+	if err != nil {
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			// completed concurrently or expired meanwhile
+			return LoginResponse{}, xerror.AuthError{Err: fmt.Errorf("challenge token has already been used")}
+		}
+
+		logic.deps.Logger.ErrorContext(ctx, "failed to complete login challenge", slog.Any("error", err))
+		return LoginResponse{}, err
+	}
+
+	err = logic.userRepo.ClearLoginThrottle(ctx, accountKey)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to clear failed login attempts", slog.Any("error", err))
+		return LoginResponse{}, err
+	}
+
+	result, refreshToken, err := logic.issueTokens(userDetails, stored.CompanyID, uuid.NewString())
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to issue tokens", slog.Any("error", err))
+		return LoginResponse{}, err
+	}
+
+	err = logic.userRepo.CreateRefreshToken(ctx, refreshToken)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to store refresh token", slog.Any("error", err))
+		return LoginResponse{}, err
+	}
+
+	return result, nil
+}
+
+// EnrolTwoFactor generates a new TOTP secret for the logged in user, two-factor authentication is only enabled once
+// the user confirms it with a code of the authenticator app
+func (logic *UserLogic) EnrolTwoFactor(ctx context.Context) (TwoFactorEnrolment, error) {
+	userDetails, err := logic.userRepo.GetUserDetailsByID(ctx, xcontext.GetUserIDFromContext(ctx))
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get user details", slog.Any("error", err))
+		return TwoFactorEnrolment{}, err
+	}
+
+	if userDetails.TwoFactorEnabled {
+		return TwoFactorEnrolment{}, xerror.ClientError{Err: fmt.Errorf("two-factor authentication is already enabled")}
+	}
+
+	secret, err := xtotp.GenerateSecret()
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to generate two-factor secret", slog.Any("error", err))
+		return TwoFactorEnrolment{}, err
+	}
+
+	encrypted, err := xcrypto.Encrypt([]byte(secret), logic.deps.Config.App.EncryptionKey)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to encrypt two-factor secret", slog.Any("error", err))
+		return TwoFactorEnrolment{}, err
+	}
+
+	// the secret is never written to the audit log, not even encrypted
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionEnrol,
+		Entity:   xaudit.EntityTwoFactor,
+		EntityID: userDetails.ID,
+	}, func(ctx context.Context) error {
+		return logic.userRepo.SaveTwoFactorSecret(ctx, TwoFactorSecret{
+			UserID: userDetails.ID,
+			Secret: encrypted,
+		})
+	})
+	if err != nil {
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			// confirmed concurrently
+			return TwoFactorEnrolment{}, xerror.ClientError{Err: fmt.Errorf("two-factor authentication is already enabled")}
+		}
+
+		logic.deps.Logger.ErrorContext(ctx, "failed to store two-factor secret", slog.Any("error", err))
+		return TwoFactorEnrolment{}, err
+	}
+
+	return TwoFactorEnrolment{
+		Secret:          secret,
+		ProvisioningURI: xtotp.ProvisioningURI(secret, logic.deps.Config.TwoFactor.Issuer, userDetails.Username),
+	}, nil
+}
+
+// ConfirmTwoFactor enables two-factor authentication with the first code of the enrolled secret and issues the recovery
+// codes. Like changing the password it logs every session of the user out, so the user carries on with the new session returned.
+func (logic *UserLogic) ConfirmTwoFactor(ctx context.Context, req ConfirmTwoFactorRequest) (TwoFactorConfirmation, error) {
+	userDetails, err := logic.userRepo.GetUserDetailsByID(ctx, xcontext.GetUserIDFromContext(ctx))
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get user details", slog.Any("error", err))
+		return TwoFactorConfirmation{}, err
+	}
+
+	if userDetails.TwoFactorEnabled {
+		return TwoFactorConfirmation{}, xerror.ClientError{Err: fmt.Errorf("two-factor authentication is already enabled")}
+	}
+
+	stored, err := logic.userRepo.GetTwoFactorSecret(ctx, userDetails.ID)
+	if err != nil {
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			return TwoFactorConfirmation{}, xerror.ClientError{Err: fmt.Errorf("two-factor authentication enrolment has not been started")}
+		}
+
+		logic.deps.Logger.ErrorContext(ctx, "failed to get two-factor secret", slog.Any("error", err))
+		return TwoFactorConfirmation{}, err
+	}
+
+	step, valid, err := logic.validateTOTP(ctx, stored, req.Code)
+	if err != nil {
+		return TwoFactorConfirmation{}, err
+	}
+
+	if !valid {
+		return TwoFactorConfirmation{}, xerror.ClientError{Err: fmt.Errorf("invalid two-factor code")}
+	}
+
+	recoveryCodes := make([]string, logic.deps.Config.TwoFactor.RecoveryCodes)
+	recoveryCodeHashes := make([]string, len(recoveryCodes))
+	for i := range recoveryCodes {
+		recoveryCodes[i], err = generateRecoveryCode()
+		if err != nil {
+			logic.deps.Logger.ErrorContext(ctx, "failed to generate recovery code", slog.Any("error", err))
+			return TwoFactorConfirmation{}, err
+		}
+		recoveryCodeHashes[i] = hashToken(normalizeRecoveryCode(recoveryCodes[i]))
+	}
+
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionEnable,
+		Entity:   xaudit.EntityTwoFactor,
+		EntityID: userDetails.ID,
+	}, func(ctx context.Context) error {
+		return logic.userRepo.EnableTwoFactor(ctx, userDetails.ID, step, recoveryCodeHashes)
+	})
+	if err != nil {
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			// confirmed concurrently
+			return TwoFactorConfirmation{}, xerror.ClientError{Err: fmt.Errorf("two-factor authentication is already enabled")}
+		}
+
+		logic.deps.Logger.ErrorContext(ctx, "failed to enable two-factor authentication", slog.Any("error", err))
+		return TwoFactorConfirmation{}, err
+	}
+
+	// the refresh tokens are revoked along with enabling it, the access token of the request goes too
+	err = logic.userRepo.RevokeToken(ctx, xcontext.GetTokenIDFromContext(ctx), time.Now().Add(logic.deps.Config.App.ExpiryTime))
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to revoke access token", slog.Any("error", err))
+		return TwoFactorConfirmation{}, err
+	}
+
+	userDetails.TwoFactorEnabled = true
+	result, refreshToken, err := logic.issueTokens(userDetails, xcontext.GetCompanyIDFromContext(ctx), uuid.NewString())
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to issue tokens", slog.Any("error", err))
+		return TwoFactorConfirmation{}, err
+	}
+
+	err = logic.userRepo.CreateRefreshToken(ctx, refreshToken)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to store refresh token", slog.Any("error", err))
+		return TwoFactorConfirmation{}, err
+	}
+
+	return TwoFactorConfirmation{
+		LoginResponse: result,
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
+
+// DisableTwoFactor turns two-factor authentication of the logged in user off, unless an admin requires it.
+// Wrong passwords and codes count as failed login attempts of the account.
+func (logic *UserLogic) DisableTwoFactor(ctx context.Context, req DisableTwoFactorRequest) error {
+	userDetails, err := logic.userRepo.GetUserDetailsByID(ctx, xcontext.GetUserIDFromContext(ctx))
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get user details", slog.Any("error", err))
+		return err
+	}
+
+	if !userDetails.TwoFactorEnabled {
+		return xerror.ClientError{Err: fmt.Errorf("two-factor authentication is not enabled")}
+	}
+
+	if userDetails.TwoFactorRequired {
+		return xerror.ClientError{Err: fmt.Errorf("two-factor authentication is required by an admin")}
+	}
+
+	accountKey, throttleKeys, err := logic.userThrottleKeys(ctx, userDetails.Username)
+	if err != nil {
+		return err
+	}
+
+	err = logic.checkLoginThrottle(ctx, throttleKeys)
+	if err != nil {
+		return err
+	}
+
+	// a stolen access token alone can't turn it off
+	err = bcrypt.CompareHashAndPassword([]byte(userDetails.Password), []byte(req.Password))
+	if err != nil {
+		logic.deps.Logger.WarnContext(ctx, "invalid password", slog.Any("error", err))
+		return logic.failLogin(ctx, throttleKeys, xerror.ClientError{Err: fmt.Errorf("invalid password")})
+	}
+
+	valid, err := logic.verifyTwoFactorCode(ctx, userDetails.ID, req.Code)
+	if err != nil {
+		return err
+	}
+
+	if !valid {
+		logic.deps.Logger.WarnContext(ctx, "invalid two-factor code")
+		return logic.failLogin(ctx, throttleKeys, xerror.ClientError{Err: fmt.Errorf("invalid two-factor code")})
+	}
+
+	err = logic.userRepo.ClearLoginThrottle(ctx, accountKey)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to clear failed login attempts", slog.Any("error", err))
+		return err
+	}
+
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionDisable,
+		Entity:   xaudit.EntityTwoFactor,
+		EntityID: userDetails.ID,
+	}, func(ctx context.Context) error {
+		return logic.userRepo.DeleteTwoFactor(ctx, userDetails.ID)
+	})
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to disable two-factor authentication", slog.Any("error", err))
+		return err
+	}
+
+	return nil
+}
+
+// SetTwoFactorRequirement requires the user to enable two-factor authentication or lifts the requirement, users who
+// haven't enabled it yet can only enrol once their session is refreshed or they log in again
+func (logic *UserLogic) SetTwoFactorRequirement(ctx context.Context, userID string, req TwoFactorRequirementRequest) error {
+	// check admin role of the user
+	isAdmin, err := logic.userRepo.IsAdmin(ctx, xcontext.GetUserIDFromContext(ctx))
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to check user admin role", slog.Any("error", err))
+		return err
+	}
+
+	if !isAdmin {
+		return xerror.AuthError{Err: fmt.Errorf("admin only operation")}
+	}
+
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionUpdate,
+		Entity:   xaudit.EntityTwoFactor,
+		EntityID: userID,
+		After:    map[string]any{"required": req.Required},
+	}, func(ctx context.Context) error {
+		return logic.userRepo.SetTwoFactorRequired(ctx, userID, req.Required)
+	})
+	if err != nil {
+		if !errors.Is(err, xerror.ErrDataNotFound) {
+			logic.deps.Logger.ErrorContext(ctx, "failed to set two-factor requirement", slog.Any("error", err))
+		}
+		return err
+	}
+
+	return nil
+}
+
+// ResetTwoFactor turns two-factor authentication of a user who lost the authenticator and the recovery codes off,
+// the user has to enrol again on the next login if it's required
+func (logic *UserLogic) ResetTwoFactor(ctx context.Context, userID string) error {
+	// check admin role of the user
+	isAdmin, err := logic.userRepo.IsAdmin(ctx, xcontext.GetUserIDFromContext(ctx))
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to check user admin role", slog.Any("error", err))
+		return err
+	}
+
+	if !isAdmin {
+		return xerror.AuthError{Err: fmt.Errorf("admin only operation")}
+	}
+
+	userDetails, err := logic.userRepo.GetUserDetailsByID(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, xerror.ErrDataNotFound) {
+			logic.deps.Logger.ErrorContext(ctx, "failed to get user details", slog.Any("error", err))
+		}
+		return err
+	}
+
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionReset,
+		Entity:   xaudit.EntityTwoFactor,
+		EntityID: userDetails.ID,
+	}, func(ctx context.Context) error {
+		return logic.userRepo.DeleteTwoFactor(ctx, userDetails.ID)
+	})
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to reset two-factor authentication", slog.Any("error", err))
+		return err
+	}
+
+	return nil
+}
+
+// verifyTwoFactorCode checks a code of the authenticator app or a recovery code of the user, using it up
+func (logic *UserLogic) verifyTwoFactorCode(ctx context.Context, userID string, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+
+	if len(code) != xtotp.Digits {
+		err := logic.userRepo.UseRecoveryCode(ctx, userID, hashToken(normalizeRecoveryCode(code)))
+		if err != nil {
+			if errors.Is(err, xerror.ErrDataNotFound) {
+				return false, nil
+			}
+
+			logic.deps.Logger.ErrorContext(ctx, "failed to use recovery code", slog.Any("error", err))
+			return false, err
+		}
+
+		logic.deps.Logger.InfoContext(ctx, "recovery code used")
+		return true, nil
+	}
+
+	stored, err := logic.userRepo.GetTwoFactorSecret(ctx, userID)
+	if err != nil {
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			// reset by an admin meanwhile
+			return false, nil
+		}
+
+		logic.deps.Logger.ErrorContext(ctx, "failed to get two-factor secret", slog.Any("error", err))
+		return false, err
+	}
+
+	if stored.ConfirmedAt == nil {
+		return false, nil
+	}
+
+	step, valid, err := logic.validateTOTP(ctx, stored, code)
+	if err != nil || !valid {
+		return false, err
+	}
+
+	// the code can't be replayed within its validity window
+	err = logic.userRepo.UseTwoFactorStep(ctx, userID, step)
+	if err != nil {
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			logic.deps.Logger.WarnContext(ctx, "two-factor code reused")
+			return false, nil
+		}
+
+		logic.deps.Logger.ErrorContext(ctx, "failed to use two-factor code", slog.Any("error", err))
+		return false, err
+	}
+
+	return true, nil
+}
+
+// validateTOTP checks the code against the stored secret, returning the time step of the code
+func (logic *UserLogic) validateTOTP(ctx context.Context, stored TwoFactorSecret, code string) (int64, bool, error) {
+	secret, err := xcrypto.Decrypt(stored.Secret, logic.deps.Config.App.EncryptionKey)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to decrypt two-factor secret", slog.Any("error", err))
+		return 0, false, err
+	}
+
+	step, valid, err := xtotp.Validate(string(secret), strings.TrimSpace(code), time.Now())
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to validate two-factor code", slog.Any("error", err))
+		return 0, false, err
+	}
+
+	return step, valid, nil
+}
+
+// StartSSOLogin starts a single sign-in with the identity provider, returning where the user signs in
+func (logic *UserLogic) StartSSOLogin(ctx context.Context) (string, error) {
+	if logic.identityProvider == nil {
+		return "", xerror.ClientError{Err: fmt.Errorf("single sign-on is not configured")}
+	}
+
+	// the state ties the redirect back to this sign-in, the nonce ties the ID token to it and the verifier the code
+	state, err := generateToken()
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to generate sso state", slog.Any("error", err))
+		return "", err
+	}
+
+	nonce, err := generateToken()
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to generate sso nonce", slog.Any("error", err))
+		return "", err
+	}
+
+	codeVerifier, err := generateToken()
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to generate sso code verifier", slog.Any("error", err))
+		return "", err
+	}
+
+	err = logic.userRepo.CreateSSOState(ctx, SSOState{
+		ID:           uuid.NewString(),
+		StateHash:    hashToken(state),
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		ExpiresAt:    time.Now().Add(logic.deps.Config.OIDC.StateExpiry),
+	})
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to store sso state", slog.Any("error", err))
+		return "", err
+	}
+
+	authURL, err := logic.identityProvider.AuthCodeURL(ctx, state, nonce, xoidc.CodeChallenge(codeVerifier))
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to build identity provider sign-in url", slog.Any("error", err))
+		return "", xerror.ServerError{Err: fmt.Errorf("identity provider is unavailable")}
+	}
+
+	return authURL, nil
+}
+
+// CompleteSSOLogin logs in the user the identity provider signed in. The account is looked up by the issuer and
+// subject it was linked with, accounts signing in for the first time are linked to the user whose username is their
+// verified email.
+func (logic *UserLogic) CompleteSSOLogin(ctx context.Context, req SSOCallbackRequest) (LoginResponse, error) {
+	if logic.identityProvider == nil {
+		return LoginResponse{}, xerror.ClientError{Err: fmt.Errorf("single sign-on is not configured")}
+	}
+
+	if req.Error != "" {
+		logic.deps.Logger.WarnContext(ctx, "identity provider sign-in failed", slog.String("error", req.Error), slog.String("description", req.ErrorDescription))
+		return LoginResponse{}, xerror.AuthError{Err: fmt.Errorf("identity provider sign-in failed: %s", req.Error)}
+	}
+
+	if req.Code == "" || req.State == "" {
+		return LoginResponse{}, xerror.ClientError{Err: fmt.Errorf("code and state are required")}
+	}
+
+	stored, err := logic.userRepo.UseSSOState(ctx, hashToken(req.State))
+	if err != nil {
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			logic.deps.Logger.WarnContext(ctx, "sso state not found", slog.Any("error", err))
+			return LoginResponse{}, xerror.AuthError{Err: fmt.Errorf("invalid or expired sign-in, please sign in again")}
+		}
+
+		logic.deps.Logger.ErrorContext(ctx, "failed to use sso state", slog.Any("error", err))
+		return LoginResponse{}, err
+	}
+
+	claims, err := logic.identityProvider.Exchange(ctx, req.Code, stored.CodeVerifier, stored.Nonce)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to verify identity provider sign-in", slog.Any("error", err))
+		return LoginResponse{}, xerror.AuthError{Err: fmt.Errorf("failed to verify identity provider sign-in")}
+	}
+
+	userCompany, err := logic.companyRepo.GetCompanyByCode(ctx, logic.deps.Config.OIDC.CompanyCode)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get single sign-on company", slog.Any("error", err))
+		return LoginResponse{}, err
+	}
+	ctx = context.WithValue(ctx, xcontext.CompanyIDKey, userCompany.ID)
+
+	userDetails, err := logic.userRepo.GetUserDetailsByIdentity(ctx, claims.Issuer, claims.Subject)
+	if err != nil && !errors.Is(err, xerror.ErrDataNotFound) {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get user details", slog.Any("error", err))
+		return LoginResponse{}, err
+	}
+
+	if errors.Is(err, xerror.ErrDataNotFound) {
+		userDetails, err = logic.linkIdentity(ctx, claims)
+		if err != nil {
+			return LoginResponse{}, err
+		}
+	}
+
+	// the identity provider is the source of truth for the credentials and their second factor,
+	// so the restrictions of local passwords don't apply
+	userDetails.MustChangePassword = false
+	userDetails.TwoFactorRequired = false
+
+	result, refreshToken, err := logic.issueTokens(userDetails, userCompany.ID, uuid.NewString())
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to issue tokens", slog.Any("error", err))
+		return LoginResponse{}, err
+	}
+
+	err = logic.userRepo.CreateRefreshToken(ctx, refreshToken)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to store refresh token", slog.Any("error", err))
+		return LoginResponse{}, err
+	}
+
+	return result, nil
+}
+
+// linkIdentity links the identity provider account signing in for the first time to the user whose username is its
+// email, only verified emails are trusted since anyone can claim any email at some providers
+func (logic *UserLogic) linkIdentity(ctx context.Context, claims xoidc.IDTokenClaims) (models.User, error) {
+	if claims.Email == "" || !claims.EmailVerified {
+		logic.deps.Logger.WarnContext(ctx, "identity provider account without a verified email", slog.String("subject", claims.Subject))
+		return models.User{}, xerror.AuthError{Err: fmt.Errorf("no user is linked to the identity provider account")}
+	}
+
+	email := strings.ToLower(claims.Email)
+	userDetails, err := logic.userRepo.GetUserDetailsByUsername(ctx, email)
+	if err != nil {
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			logic.deps.Logger.WarnContext(ctx, "no user for identity provider account", slog.String("subject", claims.Subject))
+			return models.User{}, xerror.AuthError{Err: fmt.Errorf("no user is linked to the identity provider account")}
+		}
+
+		logic.deps.Logger.ErrorContext(ctx, "failed to get user details", slog.Any("error", err))
+		return models.User{}, err
+	}
+
+	identity := UserIdentity{
+		UserID:  userDetails.ID,
+		Issuer:  claims.Issuer,
+		Subject: claims.Subject,
+		Email:   email,
+	}
+	// the user signing in links the account
+	err = xaudit.Record(context.WithValue(ctx, xcontext.UserIDKey, userDetails.ID), logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionCreate,
+		Entity:   xaudit.EntityUserIdentity,
+		EntityID: userDetails.ID,
+		After:    map[string]any{"issuer": identity.Issuer, "subject": identity.Subject, "email": identity.Email},
+	}, func(ctx context.Context) error {
+		return logic.userRepo.LinkIdentity(ctx, identity)
+	})
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to link identity provider account", slog.Any("error", err))
+		return models.User{}, err
+	}
+	logic.deps.Logger.InfoContext(ctx, "identity provider account linked", slog.String("user_id", userDetails.ID), slog.String("subject", claims.Subject))
+
+	return userDetails, nil
+}
+
+// checkNewPassword checks the new password against the policy and the recent passwords of the user, then hashes it
+func (logic *UserLogic) checkNewPassword(ctx context.Context, userDetails models.User, password string) (string, error) {
+	policy := logic.deps.Config.Password
+
+	err := validatePassword(policy, password)
+	if err != nil {
+		return "", err
+	}
+
+	if policy.History > 0 {
+		// the current password counts as the most recent one
+		recent := []string{userDetails.Password}
+		if policy.History > 1 {
+			history, err := logic.userRepo.GetPasswordHistory(ctx, userDetails.ID, policy.History-1)
+			if err != nil {
+				logic.deps.Logger.ErrorContext(ctx, "failed to get password history", slog.Any("error", err))
+				return "", err
+			}
+			recent = append(recent, history...)
+		}
+
+		for _, pwHash := range recent {
+			if bcrypt.CompareHashAndPassword([]byte(pwHash), []byte(password)) == nil {
+				return "", xerror.ClientError{Err: fmt.Errorf("password must not be one of the last %d passwords", policy.History)}
+			}
+		}
+	}
+
+	pwBytes, err := bcrypt.GenerateFromPassword([]byte(password), 12)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to hash password", slog.Any("error", err))
+		return "", err
+	}
+
+	return string(pwBytes), nil
+}
+
+// validatePassword checks the password against the configured policy, listing every unmet requirement
+func validatePassword(policy *config.Password, password string) error {
+	// bcrypt only takes up to 72 bytes
+	if len(password) > 72 {
+		return xerror.ClientError{Err: fmt.Errorf("password must be at most 72 bytes")}
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+
+	var unmet []string
+	if utf8.RuneCountInString(password) < policy.MinLength {
+		unmet = append(unmet, fmt.Sprintf("be at least %d characters long", policy.MinLength))
+	}
+	if policy.RequireUpper && !hasUpper {
+		unmet = append(unmet, "contain an uppercase letter")
+	}
+	if policy.RequireLower && !hasLower {
+		unmet = append(unmet, "contain a lowercase letter")
+	}
+	if policy.RequireDigit && !hasDigit {
+		unmet = append(unmet, "contain a digit")
+	}
+	if policy.RequireSymbol && !hasSymbol {
+		unmet = append(unmet, "contain a symbol")
+	}
+
+	if len(unmet) > 0 {
+		return xerror.ClientError{Err: fmt.Errorf("password must %s", strings.Join(unmet, ", "))}
+	}
+
+	return nil
+}
+
+// issueTokens generates an access token of the session along with its next refresh token, the refresh token is returned
+// in the response as is and only its hash is meant to be stored
+func (logic *UserLogic) issueTokens(userDetails models.User, companyID string, sessionID string) (LoginResponse, RefreshToken, error) {
+	userID := userDetails.ID
+	token, err := logic.jwtHelper.GenerateJWT(xjwt.Claims{
+		CompanyID:          companyID,
+		SessionID:          sessionID,
+		MustChangePassword: userDetails.MustChangePassword,
+		TwoFactorSetup:     userDetails.TwoFactorRequired && !userDetails.TwoFactorEnabled,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:  logic.deps.Config.App.Name,
+			Subject: userID,
+		},
+	}, logic.deps.Config.App.ExpiryTime)
+	if err != nil {
+		return LoginResponse{}, RefreshToken{}, fmt.Errorf("failed to generate JWT: %w", err)
+	}
+
+	refreshToken, err := generateToken()
+	if err != nil {
+		return LoginResponse{}, RefreshToken{}, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	result := LoginResponse{
+		Token:        token,
+		ExpiresIn:    int(logic.deps.Config.App.ExpiryTime.Seconds()),
+		RefreshToken: refreshToken,
+	}
+	stored := RefreshToken{
+		ID:        uuid.NewString(),
+		CompanyID: companyID,
+		UserID:    userID,
+		SessionID: sessionID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: time.Now().Add(logic.deps.Config.App.RefreshExpiryTime),
+	}
+
+	return result, stored, nil
+}
+
+// revokeReusedSession revokes the session whose replaced refresh token is used again
+func (logic *UserLogic) revokeReusedSession(ctx context.Context, sessionID string) error {
+	logic.deps.Logger.WarnContext(ctx, "replaced refresh token reused, revoking its session", slog.String("session_id", sessionID))
+
+	err := logic.userRepo.RevokeSession(ctx, sessionID)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to revoke session", slog.Any("error", err))
+		return err
+	}
+
+	return xerror.AuthError{Err: fmt.Errorf("refresh token has already been used, please login again")}
+}
+
+// generateToken generates a random opaque token, e.g. a refresh token or a password reset token
+func generateToken() (string, error) {
+	randBytes := make([]byte, 32)
+	_, err := rand.Read(randBytes)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(randBytes), nil
+}
+
+// generateRecoveryCode generates a random one-time recovery code, 60 bits grouped like xxxx-xxxx-xxxx for reading it out
+func generateRecoveryCode() (string, error) {
+	randBytes := make([]byte, 8)
+	_, err := rand.Read(randBytes)
+	if err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(randBytes))[:12]
+	return code[:4] + "-" + code[4:8] + "-" + code[8:], nil
+}
+
+// normalizeRecoveryCode drops the grouping and the case, so recovery codes can be typed in either way
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// hashToken hashes the generated token for storage, it's random enough that a plain SHA-256 suffices
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (logic *UserLogic) SetEmployment(ctx context.Context, userID string, req EmploymentRequest) (models.Employment, error) {
+	// check admin role of the user
+	isAdmin, err := logic.userRepo.IsAdmin(ctx, xcontext.GetUserIDFromContext(ctx))
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to check user admin role", slog.Any("error", err))
+		return models.Employment{}, err
+	}
+
+	if !isAdmin {
+		return models.Employment{}, xerror.AuthError{Err: fmt.Errorf("admin only operation")}
+	}
+
+	switch req.Status {
+	case models.EmploymentActive, models.EmploymentSuspended, models.EmploymentInactive:
+	default:
+		return models.Employment{}, xerror.ClientError{Err: fmt.Errorf("invalid employment status %q", req.Status)}
+	}
+
+	data, err := logic.getEmployment(ctx, userID)
+	if err != nil {
+		return models.Employment{}, err
+	}
+	before := data
+	data.Status = req.Status
+
+	if req.AnnualLeaveDays != nil {
+		if *req.AnnualLeaveDays < 0 {
+			return models.Employment{}, xerror.ClientError{Err: fmt.Errorf("annual leave days must not be negative")}
+		}
+		data.AnnualLeaveDays = *req.AnnualLeaveDays
+	}
+
+	if req.Timezone != nil {
+		// validated here so attendance never falls back silently on a typo
+		if *req.Timezone != "" {
+			_, err := time.LoadLocation(*req.Timezone)
+			if err != nil {
+				return models.Employment{}, xerror.ClientError{Err: fmt.Errorf("invalid timezone %q", *req.Timezone)}
+			}
+		}
+		data.Timezone = *req.Timezone
+	}
+
+	data.StartDate, err = parseOptionalDate(req.StartDate)
+	if err != nil {
+		return models.Employment{}, xerror.ClientError{Err: fmt.Errorf("invalid start date: %w", err)}
+	}
+
+	data.EndDate, err = parseOptionalDate(req.EndDate)
+	if err != nil {
+		return models.Employment{}, xerror.ClientError{Err: fmt.Errorf("invalid end date: %w", err)}
+	}
+
+	if data.StartDate != nil && data.EndDate != nil && data.EndDate.Before(*data.StartDate) {
+		return models.Employment{}, xerror.ClientError{Err: fmt.Errorf("end date must not be before start date")}
+	}
+
+	// the termination reason only makes sense along with the end date
+	if data.EndDate == nil {
+		data.TerminationReason = ""
+	}
+
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionUpdate,
+		Entity:   xaudit.EntityEmployment,
+		EntityID: userID,
+		Before:   before,
+		After:    data,
+	}, func(ctx context.Context) error {
+		return logic.userRepo.UpdateEmployment(ctx, data)
+	})
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to update user employment", slog.Any("error", err))
+		return models.Employment{}, err
+	}
+
+	return data, nil
+}
+
+// TerminateEmployment sets the last employed day of the user, the payroll period covering that day
+// produces a final settlement payslip for the user
+func (logic *UserLogic) TerminateEmployment(ctx context.Context, userID string, req TerminationRequest) (models.Employment, error) {
+	// check admin role of the user
+	isAdmin, err := logic.userRepo.IsAdmin(ctx, xcontext.GetUserIDFromContext(ctx))
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to check user admin role", slog.Any("error", err))
+		return models.Employment{}, err
+	}
+
+	if !isAdmin {
+		return models.Employment{}, xerror.AuthError{Err: fmt.Errorf("admin only operation")}
+	}
+
+	terminationDate, err := time.Parse(time.DateOnly, req.TerminationDate)
+	if err != nil {
+		return models.Employment{}, xerror.ClientError{Err: fmt.Errorf("invalid termination date: %w", err)}
+	}
+
+	data, err := logic.getEmployment(ctx, userID)
+	if err != nil {
+		return models.Employment{}, err
+	}
+
+	if data.StartDate != nil && terminationDate.Before(*data.StartDate) {
+		return models.Employment{}, xerror.ClientError{Err: fmt.Errorf("termination date must not be before hire date")}
+	}
+
+	before := data
+	data.EndDate = &terminationDate
+	data.TerminationReason = req.Reason
+
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionTerminate,
+		Entity:   xaudit.EntityEmployment,
+		EntityID: userID,
+		Before:   before,
+		After:    data,
+	}, func(ctx context.Context) error {
+		return logic.userRepo.UpdateEmployment(ctx, data)
+	})
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to terminate user employment", slog.Any("error", err))
+		return models.Employment{}, err
+	}
+
+	return data, nil
+}
+
+// SetSalary sets the user's salary, its currency and the currency it's paid out in
+func (logic *UserLogic) SetSalary(ctx context.Context, userID string, req SalaryRequest) (models.UserSalary, error) {
+	// check admin role of the user
+	isAdmin, err := logic.userRepo.IsAdmin(ctx, xcontext.GetUserIDFromContext(ctx))
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to check user admin role", slog.Any("error", err))
+		return models.UserSalary{}, err
+	}
+
+	if !isAdmin {
+		return models.UserSalary{}, xerror.AuthError{Err: fmt.Errorf("admin only operation")}
+	}
+
+	salaries, err := logic.userRepo.GetUsersSalaryByIDs(ctx, []string{userID})
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get user salary", slog.Any("error", err))
+		return models.UserSalary{}, err
+	}
+
+	if len(salaries) == 0 {
+		return models.UserSalary{}, xerror.ErrDataNotFound
+	}
+	before := salaries[0]
+	data := before
+
+	if req.Salary != nil {
+		if *req.Salary < 0 {
+			return models.UserSalary{}, xerror.ClientError{Err: fmt.Errorf("salary must not be negative")}
+		}
+		data.Salary = *req.Salary
+	}
+
+	if req.Currency != "" {
+		data.Currency, err = xcurrency.Normalize(req.Currency)
+		if err != nil {
+			return models.UserSalary{}, xerror.ClientError{Err: err}
+		}
+	}
+
+	if req.PayoutCurrency != nil {
+		data.PayoutCurrency = ""
+		if *req.PayoutCurrency != "" {
+			data.PayoutCurrency, err = xcurrency.Normalize(*req.PayoutCurrency)
+			if err != nil {
+				return models.UserSalary{}, xerror.ClientError{Err: err}
+			}
+		}
+	}
+
+	// paying out in the salary currency needs no exchange rate
+	if data.PayoutCurrency == data.Currency {
+		data.PayoutCurrency = ""
+	}
+
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionUpdate,
+		Entity:   xaudit.EntitySalary,
+		EntityID: userID,
+		Before:   before,
+		After:    data,
+	}, func(ctx context.Context) error {
+		return logic.userRepo.UpdateSalary(ctx, data)
+	})
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to update user salary", slog.Any("error", err))
+		return models.UserSalary{}, err
+	}
+
+	return data, nil
+}
+
+// SetEmail sets the email address the user is sent emails to, an empty one stops the emails
+func (logic *UserLogic) SetEmail(ctx context.Context, userID string, req EmailRequest) (UserEmail, error) {
+	// check admin role of the user
+	isAdmin, err := logic.userRepo.IsAdmin(ctx, xcontext.GetUserIDFromContext(ctx))
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to check user admin role", slog.Any("error", err))
+		return UserEmail{}, err
+	}
+
+	if !isAdmin {
+		return UserEmail{}, xerror.AuthError{Err: fmt.Errorf("admin only operation")}
+	}
+
+	email := strings.TrimSpace(req.Email)
+	if email != "" {
+		addr, err := mail.ParseAddress(email)
+		if err != nil || addr.Address != email {
+			return UserEmail{}, xerror.ClientError{Err: fmt.Errorf("invalid email address %q", email)}
+		}
+	}
+
+	data := UserEmail{UserID: userID, Email: email}
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionUpdate,
+		Entity:   xaudit.EntityUser,
+		EntityID: userID,
+		After:    data,
+	}, func(ctx context.Context) error {
+		return logic.userRepo.UpdateEmail(ctx, userID, email)
+	})
+	if err != nil {
+		if !errors.Is(err, xerror.ErrDataNotFound) {
+			logic.deps.Logger.ErrorContext(ctx, "failed to update user email", slog.Any("error", err))
+		}
+		return UserEmail{}, err
+	}
+
+	return data, nil
+}
+
+func (logic *UserLogic) getEmployment(ctx context.Context, userID string) (models.Employment, error) {
+	employments, err := logic.userRepo.GetUsersEmploymentByIDs(ctx, []string{userID})
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get user employment", slog.Any("error", err))
+		return models.Employment{}, err
+	}
+
+	if len(employments) == 0 {
+		return models.Employment{}, xerror.ErrDataNotFound
+	}
+
+	return employments[0], nil
+}
+
+func parseOptionalDate(val string) (*time.Time, error) {
+	if val == "" {
+		return nil, nil
+	}
+
+	date, err := time.Parse(time.DateOnly, val)
+	if err != nil {
+		return nil, err
+	}
+
+	return &date, nil
+}
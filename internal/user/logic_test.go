@@ -2,13 +2,28 @@ package user
 
 import (
 	"context"
+	"errors"
+	"io"
+	"log/slog"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rahadianir/dealls/internal/company"
 	"github.com/rahadianir/dealls/internal/config"
 	"github.com/rahadianir/dealls/internal/models"
+	"github.com/rahadianir/dealls/internal/pkg/xaudit"
+	"github.com/rahadianir/dealls/internal/pkg/xcontext"
+	"github.com/rahadianir/dealls/internal/pkg/xcrypto"
+	"github.com/rahadianir/dealls/internal/pkg/xerror"
 	"github.com/rahadianir/dealls/internal/pkg/xjwt"
+	"github.com/rahadianir/dealls/internal/pkg/xmail"
+	"github.com/rahadianir/dealls/internal/pkg/xoidc"
+	"github.com/rahadianir/dealls/internal/pkg/xtotp"
 	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestUserLogic_Login(t *testing.T) {
@@ -16,18 +31,27 @@ func TestUserLogic_Login(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := NewMockUserRepositoryInterface(ctrl)
+	mockCompanyRepo := company.NewMockCompanyRepositoryInterface(ctrl)
 	mockJwt := xjwt.NewMockJWTHelper(ctrl)
 	mockDeps := config.CommonDependencies{
 		Config: config.InitConfig(context.Background()),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
 	}
 
+	adminKey := LoginThrottleKey{Scope: LoginThrottleAccount, CompanyCode: models.DefaultCompanyCode, Subject: "admin"}
+	ipKey := LoginThrottleKey{Scope: LoginThrottleIP, Subject: "10.0.0.1"}
+	ipCtx := context.WithValue(context.Background(), xcontext.IPKey, "10.0.0.1")
+	lockedUntil := time.Now().Add(time.Hour)
+
 	type fields struct {
-		deps      *config.CommonDependencies
-		userRepo  UserRepositoryInterface
-		jwtHelper xjwt.JWTHelper
+		deps        *config.CommonDependencies
+		userRepo    UserRepositoryInterface
+		companyRepo company.CompanyRepositoryInterface
+		jwtHelper   xjwt.JWTHelper
 	}
 	type args struct {
 		ctx      context.Context
+		company  string
 		username string
 		password string
 	}
@@ -43,9 +67,55 @@ func TestUserLogic_Login(t *testing.T) {
 		{
 			name: "success login",
 			fields: fields{
-				deps:      &mockDeps,
-				userRepo:  mockRepo,
-				jwtHelper: mockJwt,
+				deps:        &mockDeps,
+				userRepo:    mockRepo,
+				companyRepo: mockCompanyRepo,
+				jwtHelper:   mockJwt,
+			},
+			args: args{
+				ctx:      context.Background(),
+				username: "admin",
+				password: "admin",
+			},
+			want: LoginResponse{
+				Token:     "token",
+				ExpiresIn: int(mockDeps.Config.App.ExpiryTime.Seconds()),
+			},
+			wantErr: false,
+			behaviour: func() {
+				mockRepo.EXPECT().GetLoginThrottle(gomock.Any(), adminKey).Return(LoginThrottle{}, xerror.ErrDataNotFound)
+				mockCompanyRepo.EXPECT().GetCompanyByCode(gomock.Any(), models.DefaultCompanyCode).Return(models.Company{ID: "company-id"}, nil)
+				mockRepo.EXPECT().GetUserDetailsByUsername(gomock.Any(), "admin").DoAndReturn(func(ctx context.Context, username string) (models.User, error) {
+					if xcontext.GetCompanyIDFromContext(ctx) != "company-id" {
+						t.Errorf("unexpected company %q", xcontext.GetCompanyIDFromContext(ctx))
+					}
+					return models.User{
+						ID:       "1",
+						Password: "$2a$12$x57I28hfnEEJGXE5splrqeNLwWSlhXyFaoDZamMJc9oElJgpUPbwe", // hashed "admin"
+					}, nil
+				})
+				mockRepo.EXPECT().ClearLoginThrottle(gomock.Any(), adminKey).Return(nil)
+				mockJwt.EXPECT().GenerateJWT(gomock.Any(), gomock.Any()).DoAndReturn(func(claims xjwt.Claims, expiryTime time.Duration) (string, error) {
+					if claims.Subject != "1" || claims.CompanyID != "company-id" || claims.SessionID == "" {
+						t.Errorf("unexpected claims: %+v", claims)
+					}
+					return "token", nil
+				})
+				mockRepo.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data RefreshToken) error {
+					if data.UserID != "1" || data.TokenHash == "" || !data.ExpiresAt.After(time.Now()) {
+						t.Errorf("unexpected refresh token: %+v", data)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name: "success login with a temporary password only allows changing it",
+			fields: fields{
+				deps:        &mockDeps,
+				userRepo:    mockRepo,
+				companyRepo: mockCompanyRepo,
+				jwtHelper:   mockJwt,
 			},
 			args: args{
 				ctx:      context.Background(),
@@ -53,34 +123,1440 @@ func TestUserLogic_Login(t *testing.T) {
 				password: "admin",
 			},
 			want: LoginResponse{
-				Token: "token",
+				Token:     "token",
+				ExpiresIn: int(mockDeps.Config.App.ExpiryTime.Seconds()),
 			},
 			wantErr: false,
 			behaviour: func() {
+				mockRepo.EXPECT().GetLoginThrottle(gomock.Any(), adminKey).Return(LoginThrottle{}, xerror.ErrDataNotFound)
+				mockCompanyRepo.EXPECT().GetCompanyByCode(gomock.Any(), models.DefaultCompanyCode).Return(models.Company{ID: "company-id"}, nil)
+				mockRepo.EXPECT().GetUserDetailsByUsername(gomock.Any(), "admin").Return(models.User{
+					ID:                 "1",
+					Password:           "$2a$12$x57I28hfnEEJGXE5splrqeNLwWSlhXyFaoDZamMJc9oElJgpUPbwe", // hashed "admin"
+					MustChangePassword: true,
+				}, nil)
+				mockRepo.EXPECT().ClearLoginThrottle(gomock.Any(), adminKey).Return(nil)
+				mockJwt.EXPECT().GenerateJWT(gomock.Any(), gomock.Any()).DoAndReturn(func(claims xjwt.Claims, expiryTime time.Duration) (string, error) {
+					if !claims.MustChangePassword {
+						t.Errorf("missing password change claim: %+v", claims)
+					}
+					return "token", nil
+				})
+				mockRepo.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name: "failed unknown company",
+			fields: fields{
+				deps:        &mockDeps,
+				userRepo:    mockRepo,
+				companyRepo: mockCompanyRepo,
+				jwtHelper:   mockJwt,
+			},
+			args: args{
+				ctx:      context.Background(),
+				company:  "acme",
+				username: "admin",
+				password: "admin",
+			},
+			want:    LoginResponse{},
+			wantErr: true,
+			behaviour: func() {
+				acmeKey := LoginThrottleKey{Scope: LoginThrottleAccount, CompanyCode: "acme", Subject: "admin"}
+				mockRepo.EXPECT().GetLoginThrottle(gomock.Any(), acmeKey).Return(LoginThrottle{}, xerror.ErrDataNotFound)
+				mockCompanyRepo.EXPECT().GetCompanyByCode(gomock.Any(), "acme").Return(models.Company{}, xerror.ErrDataNotFound)
+				mockRepo.EXPECT().RecordLoginFailure(gomock.Any(), acmeKey, gomock.Any()).Return(1, nil)
+			},
+		},
+		{
+			name: "failed unknown username counts as a failed attempt",
+			fields: fields{
+				deps:        &mockDeps,
+				userRepo:    mockRepo,
+				companyRepo: mockCompanyRepo,
+				jwtHelper:   mockJwt,
+			},
+			args: args{
+				ctx:      context.Background(),
+				username: "Admin ",
+				password: "admin",
+			},
+			want:    LoginResponse{},
+			wantErr: true,
+			behaviour: func() {
+				mockRepo.EXPECT().GetLoginThrottle(gomock.Any(), adminKey).Return(LoginThrottle{}, xerror.ErrDataNotFound)
+				mockCompanyRepo.EXPECT().GetCompanyByCode(gomock.Any(), models.DefaultCompanyCode).Return(models.Company{ID: "company-id"}, nil)
+				mockRepo.EXPECT().GetUserDetailsByUsername(gomock.Any(), "Admin ").Return(models.User{}, xerror.ErrDataNotFound)
+				mockRepo.EXPECT().RecordLoginFailure(gomock.Any(), adminKey, gomock.Any()).Return(1, nil)
+			},
+		},
+		{
+			name: "failed wrong password past the backoff threshold locks the account",
+			fields: fields{
+				deps:        &mockDeps,
+				userRepo:    mockRepo,
+				companyRepo: mockCompanyRepo,
+				jwtHelper:   mockJwt,
+			},
+			args: args{
+				ctx:      ipCtx,
+				username: "admin",
+				password: "wrong",
+			},
+			want:    LoginResponse{},
+			wantErr: true,
+			behaviour: func() {
+				mockRepo.EXPECT().GetLoginThrottle(gomock.Any(), adminKey).Return(LoginThrottle{}, xerror.ErrDataNotFound)
+				mockRepo.EXPECT().GetLoginThrottle(gomock.Any(), ipKey).Return(LoginThrottle{Failures: 1}, nil)
+				mockCompanyRepo.EXPECT().GetCompanyByCode(gomock.Any(), models.DefaultCompanyCode).Return(models.Company{ID: "company-id"}, nil)
 				mockRepo.EXPECT().GetUserDetailsByUsername(gomock.Any(), "admin").Return(models.User{
 					ID:       "1",
 					Password: "$2a$12$x57I28hfnEEJGXE5splrqeNLwWSlhXyFaoDZamMJc9oElJgpUPbwe", // hashed "admin"
 				}, nil)
-				mockJwt.EXPECT().GenerateJWT(gomock.Any(), "1", gomock.Any(), gomock.Any()).Return("token", nil)
+				mockRepo.EXPECT().RecordLoginFailure(gomock.Any(), adminKey, gomock.Any()).Return(mockDeps.Config.Login.BackoffAfter+1, nil)
+				mockRepo.EXPECT().LockLogin(gomock.Any(), adminKey, gomock.Any()).DoAndReturn(func(ctx context.Context, key LoginThrottleKey, until time.Time) error {
+					// second failure past the threshold doubles the base wait
+					wait := time.Until(until)
+					if wait <= mockDeps.Config.Login.BackoffBase || wait > 2*mockDeps.Config.Login.BackoffBase {
+						t.Errorf("unexpected lock wait %v", wait)
+					}
+					return nil
+				})
+				mockRepo.EXPECT().RecordLoginFailure(gomock.Any(), ipKey, gomock.Any()).Return(2, nil)
+			},
+		},
+		{
+			name: "success login with two-factor authentication waits for the code",
+			fields: fields{
+				deps:        &mockDeps,
+				userRepo:    mockRepo,
+				companyRepo: mockCompanyRepo,
+				jwtHelper:   mockJwt,
+			},
+			args: args{
+				ctx:      context.Background(),
+				username: "admin",
+				password: "admin",
+			},
+			want:    LoginResponse{ChallengeToken: "challenge"},
+			wantErr: false,
+			behaviour: func() {
+				mockRepo.EXPECT().GetLoginThrottle(gomock.Any(), adminKey).Return(LoginThrottle{}, xerror.ErrDataNotFound)
+				mockCompanyRepo.EXPECT().GetCompanyByCode(gomock.Any(), models.DefaultCompanyCode).Return(models.Company{ID: "company-id"}, nil)
+				mockRepo.EXPECT().GetUserDetailsByUsername(gomock.Any(), "admin").Return(models.User{
+					ID:               "1",
+					Password:         "$2a$12$x57I28hfnEEJGXE5splrqeNLwWSlhXyFaoDZamMJc9oElJgpUPbwe", // hashed "admin"
+					TwoFactorEnabled: true,
+				}, nil)
+				// no tokens and the failed attempts aren't cleared until the code is verified
+				mockRepo.EXPECT().CreateLoginChallenge(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data LoginChallenge) error {
+					if data.UserID != "1" || data.CompanyCode != models.DefaultCompanyCode || data.TokenHash == "" || !data.ExpiresAt.After(time.Now()) {
+						t.Errorf("unexpected login challenge: %+v", data)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name: "failed locked account without checking the password",
+			fields: fields{
+				deps:        &mockDeps,
+				userRepo:    mockRepo,
+				companyRepo: mockCompanyRepo,
+				jwtHelper:   mockJwt,
+			},
+			args: args{
+				ctx:      context.Background(),
+				username: "admin",
+				password: "admin",
+			},
+			want:    LoginResponse{},
+			wantErr: true,
+			behaviour: func() {
+				mockRepo.EXPECT().GetLoginThrottle(gomock.Any(), adminKey).Return(LoginThrottle{Failures: 10, LockedUntil: &lockedUntil}, nil)
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			logic := &UserLogic{
-				deps:      tt.fields.deps,
-				userRepo:  tt.fields.userRepo,
-				jwtHelper: tt.fields.jwtHelper,
+				deps:        tt.fields.deps,
+				userRepo:    tt.fields.userRepo,
+				companyRepo: tt.fields.companyRepo,
+				jwtHelper:   tt.fields.jwtHelper,
 			}
 			tt.behaviour()
-			got, err := logic.Login(tt.args.ctx, tt.args.username, tt.args.password)
+			got, err := logic.Login(tt.args.ctx, tt.args.company, tt.args.username, tt.args.password)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("UserLogic.Login() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			// refresh tokens and challenge tokens are random, so only their presence is checked
+			if !tt.wantErr && got.RefreshToken == "" && got.ChallengeToken == "" {
+				t.Errorf("UserLogic.Login() missing refresh token")
+			}
+			got.RefreshToken = ""
+			if got.ChallengeToken != "" {
+				got.ChallengeToken = "challenge"
+			}
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("UserLogic.Login() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func TestUserLogic_RefreshToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := NewMockUserRepositoryInterface(ctrl)
+	mockJwt := xjwt.NewMockJWTHelper(ctrl)
+	mockDeps := config.CommonDependencies{
+		Config: config.InitConfig(context.Background()),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	revokedAt := time.Now().Add(-time.Minute)
+	stored := RefreshToken{
+		ID:        "token-id",
+		CompanyID: "company-id",
+		UserID:    "user-id",
+		SessionID: "session-id",
+		TokenHash: hashToken("refresh"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	type args struct {
+		ctx          context.Context
+		refreshToken string
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantErr   bool
+		behaviour func(a args)
+	}{
+		// TODO: Add test cases.
+		{
+			name: "success rotate refresh token within the session",
+			args: args{ctx: context.Background(), refreshToken: "refresh"},
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetRefreshTokenByHash(gomock.Any(), hashToken("refresh")).Return(stored, nil)
+				mockRepo.EXPECT().GetUserDetailsByID(gomock.Any(), "user-id").Return(models.User{ID: "user-id"}, nil)
+				mockJwt.EXPECT().GenerateJWT(gomock.Any(), gomock.Any()).DoAndReturn(func(claims xjwt.Claims, expiryTime time.Duration) (string, error) {
+					if claims.Subject != "user-id" || claims.CompanyID != "company-id" || claims.SessionID != "session-id" {
+						t.Errorf("unexpected claims: %+v", claims)
+					}
+					return "token", nil
+				})
+				mockRepo.EXPECT().RotateRefreshToken(gomock.Any(), "token-id", gomock.Any()).DoAndReturn(func(ctx context.Context, id string, next RefreshToken) error {
+					if xcontext.GetCompanyIDFromContext(ctx) != "company-id" || next.SessionID != "session-id" || next.TokenHash == stored.TokenHash {
+						t.Errorf("unexpected rotation: %+v", next)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name:    "failed reused replaced refresh token revokes the session",
+			args:    args{ctx: context.Background(), refreshToken: "refresh"},
+			wantErr: true,
+			behaviour: func(a args) {
+				replaced := stored
+				replaced.RevokedAt = &revokedAt
+				replaced.ReplacedBy = "next-token-id"
+				mockRepo.EXPECT().GetRefreshTokenByHash(gomock.Any(), hashToken("refresh")).Return(replaced, nil)
+				mockRepo.EXPECT().RevokeSession(gomock.Any(), "session-id").Return(nil)
+			},
+		},
+		{
+			name:    "failed concurrent refresh with the same token revokes the session",
+			args:    args{ctx: context.Background(), refreshToken: "refresh"},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetRefreshTokenByHash(gomock.Any(), hashToken("refresh")).Return(stored, nil)
+				mockRepo.EXPECT().GetUserDetailsByID(gomock.Any(), "user-id").Return(models.User{ID: "user-id"}, nil)
+				mockJwt.EXPECT().GenerateJWT(gomock.Any(), gomock.Any()).Return("token", nil)
+				mockRepo.EXPECT().RotateRefreshToken(gomock.Any(), "token-id", gomock.Any()).Return(xerror.ErrDataNotFound)
+				mockRepo.EXPECT().RevokeSession(gomock.Any(), "session-id").Return(nil)
+			},
+		},
+		{
+			name:    "failed logged out refresh token",
+			args:    args{ctx: context.Background(), refreshToken: "refresh"},
+			wantErr: true,
+			behaviour: func(a args) {
+				revoked := stored
+				revoked.RevokedAt = &revokedAt
+				mockRepo.EXPECT().GetRefreshTokenByHash(gomock.Any(), hashToken("refresh")).Return(revoked, nil)
+			},
+		},
+		{
+			name:    "failed expired refresh token",
+			args:    args{ctx: context.Background(), refreshToken: "refresh"},
+			wantErr: true,
+			behaviour: func(a args) {
+				expired := stored
+				expired.ExpiresAt = revokedAt
+				mockRepo.EXPECT().GetRefreshTokenByHash(gomock.Any(), hashToken("refresh")).Return(expired, nil)
+			},
+		},
+		{
+			name:    "failed unknown refresh token",
+			args:    args{ctx: context.Background(), refreshToken: "unknown"},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetRefreshTokenByHash(gomock.Any(), hashToken("unknown")).Return(RefreshToken{}, xerror.ErrDataNotFound)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := NewUserLogic(&mockDeps, mockRepo, nil, mockJwt, nil, nil, nil)
+			tt.behaviour(tt.args)
+			got, err := logic.RefreshToken(tt.args.ctx, tt.args.refreshToken)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UserLogic.RefreshToken() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && (got.Token != "token" || got.RefreshToken == "" || got.RefreshToken == tt.args.refreshToken) {
+				t.Errorf("UserLogic.RefreshToken() = %+v", got)
+			}
+		})
+	}
+}
+
+func TestUserLogic_Logout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := NewMockUserRepositoryInterface(ctrl)
+	mockAuditor := xaudit.NewMockRecorder(ctrl)
+	// the change and its entry are run in the transaction the recorder begins
+	mockAuditor.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, txfunc func(context.Context) error) error {
+		return txfunc(ctx)
+	}).AnyTimes()
+	mockDeps := config.CommonDependencies{
+		Config: config.InitConfig(context.Background()),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	ctx := context.WithValue(context.Background(), xcontext.UserIDKey, "user-id")
+	ctx = context.WithValue(ctx, xcontext.TokenIDKey, "token-id")
+
+	type args struct {
+		ctx context.Context
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantErr   bool
+		behaviour func(a args)
+	}{
+		// TODO: Add test cases.
+		{
+			name: "success revoke the token and its session",
+			args: args{ctx: context.WithValue(ctx, xcontext.SessionIDKey, "session-id")},
+			behaviour: func(a args) {
+				mockRepo.EXPECT().RevokeToken(gomock.Any(), "token-id", gomock.Any()).Return(nil)
+				mockRepo.EXPECT().RevokeSession(gomock.Any(), "session-id").Return(nil)
+				mockAuditor.EXPECT().Record(gomock.Any(), xaudit.Entry{Action: xaudit.ActionRevoke, Entity: xaudit.EntitySession, EntityID: "session-id"}).Return(nil)
+			},
+		},
+		{
+			name: "success revoke a token without a session",
+			args: args{ctx: ctx},
+			behaviour: func(a args) {
+				mockRepo.EXPECT().RevokeToken(gomock.Any(), "token-id", gomock.Any()).Return(nil)
+				mockAuditor.EXPECT().Record(gomock.Any(), xaudit.Entry{Action: xaudit.ActionRevoke, Entity: xaudit.EntitySession, EntityID: "token-id"}).Return(nil)
+			},
+		},
+		{
+			name:    "failed revoke session",
+			args:    args{ctx: context.WithValue(ctx, xcontext.SessionIDKey, "session-id")},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().RevokeToken(gomock.Any(), "token-id", gomock.Any()).Return(nil)
+				mockRepo.EXPECT().RevokeSession(gomock.Any(), "session-id").Return(errors.New("db down"))
+			},
+		},
+		{
+			name:    "failed audit log, the logout is rolled back along with it",
+			args:    args{ctx: context.WithValue(ctx, xcontext.SessionIDKey, "session-id")},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().RevokeToken(gomock.Any(), "token-id", gomock.Any()).Return(nil)
+				mockRepo.EXPECT().RevokeSession(gomock.Any(), "session-id").Return(nil)
+				mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any()).Return(errors.New("db down"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := NewUserLogic(&mockDeps, mockRepo, nil, nil, nil, nil, mockAuditor)
+			tt.behaviour(tt.args)
+			if err := logic.Logout(tt.args.ctx); (err != nil) != tt.wantErr {
+				t.Errorf("UserLogic.Logout() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUserLogic_ChangePassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := NewMockUserRepositoryInterface(ctrl)
+	mockCompanyRepo := company.NewMockCompanyRepositoryInterface(ctrl)
+	mockJwt := xjwt.NewMockJWTHelper(ctrl)
+	mockAuditor := xaudit.NewMockRecorder(ctrl)
+	// the change and its entry are run in the transaction the recorder begins
+	mockAuditor.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, txfunc func(context.Context) error) error {
+		return txfunc(ctx)
+	}).AnyTimes()
+	mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockDeps := config.CommonDependencies{
+		Config: config.InitConfig(context.Background()),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	current := testPasswordHash(t, "Current-Passw0rd")
+	previous := testPasswordHash(t, "Previous-Passw0rd")
+	user := models.User{ID: "user-id", Username: "Ani", Password: current, MustChangePassword: true}
+	accountKey := LoginThrottleKey{Scope: LoginThrottleAccount, CompanyCode: "acme", Subject: "ani"}
+	lockedUntil := time.Now().Add(time.Hour)
+
+	// the current password is only checked once the account isn't throttled
+	checkThrottle := func() {
+		mockRepo.EXPECT().GetUserDetailsByID(gomock.Any(), "user-id").Return(user, nil)
+		mockCompanyRepo.EXPECT().GetCompanyByID(gomock.Any(), "company-id").Return(models.Company{ID: "company-id", Code: "acme"}, nil)
+		mockRepo.EXPECT().GetLoginThrottle(gomock.Any(), accountKey).Return(LoginThrottle{}, xerror.ErrDataNotFound)
+	}
+
+	ctx := context.WithValue(context.Background(), xcontext.UserIDKey, "user-id")
+	ctx = context.WithValue(ctx, xcontext.CompanyIDKey, "company-id")
+	ctx = context.WithValue(ctx, xcontext.TokenIDKey, "token-id")
+
+	type args struct {
+		ctx context.Context
+		req ChangePasswordRequest
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantErr   bool
+		behaviour func(a args)
+	}{
+		// TODO: Add test cases.
+		{
+			name: "success change temporary password and start a new session",
+			args: args{ctx: ctx, req: ChangePasswordRequest{CurrentPassword: "Current-Passw0rd", NewPassword: "Brand-New-Passw0rd"}},
+			behaviour: func(a args) {
+				checkThrottle()
+				mockRepo.EXPECT().ClearLoginThrottle(gomock.Any(), accountKey).Return(nil)
+				mockRepo.EXPECT().GetPasswordHistory(gomock.Any(), "user-id", mockDeps.Config.Password.History-1).Return([]string{previous}, nil)
+				mockRepo.EXPECT().UpdatePassword(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data PasswordChange) error {
+					if data.OldPassword != current || data.MustChangePassword || bcrypt.CompareHashAndPassword([]byte(data.NewPassword), []byte("Brand-New-Passw0rd")) != nil {
+						t.Errorf("unexpected password change: %+v", data)
+					}
+					return nil
+				})
+				mockRepo.EXPECT().RevokeToken(gomock.Any(), "token-id", gomock.Any()).Return(nil)
+				mockJwt.EXPECT().GenerateJWT(gomock.Any(), gomock.Any()).DoAndReturn(func(claims xjwt.Claims, expiryTime time.Duration) (string, error) {
+					if claims.MustChangePassword || claims.Subject != "user-id" || claims.CompanyID != "company-id" {
+						t.Errorf("unexpected claims: %+v", claims)
+					}
+					return "token", nil
+				})
+				mockRepo.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:    "failed invalid current password counts as a failed login",
+			args:    args{ctx: ctx, req: ChangePasswordRequest{CurrentPassword: "wrong", NewPassword: "Brand-New-Passw0rd"}},
+			wantErr: true,
+			behaviour: func(a args) {
+				checkThrottle()
+				mockRepo.EXPECT().RecordLoginFailure(gomock.Any(), accountKey, mockDeps.Config.Login.FailureWindow).Return(1, nil)
+			},
+		},
+		{
+			name:    "failed throttled account without checking the password",
+			args:    args{ctx: ctx, req: ChangePasswordRequest{CurrentPassword: "Current-Passw0rd", NewPassword: "Brand-New-Passw0rd"}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetUserDetailsByID(gomock.Any(), "user-id").Return(user, nil)
+				mockCompanyRepo.EXPECT().GetCompanyByID(gomock.Any(), "company-id").Return(models.Company{ID: "company-id", Code: "acme"}, nil)
+				mockRepo.EXPECT().GetLoginThrottle(gomock.Any(), accountKey).Return(LoginThrottle{LoginThrottleKey: accountKey, Failures: 5, LockedUntil: &lockedUntil}, nil)
+			},
+		},
+		{
+			name:    "failed password against the policy",
+			args:    args{ctx: ctx, req: ChangePasswordRequest{CurrentPassword: "Current-Passw0rd", NewPassword: "short"}},
+			wantErr: true,
+			behaviour: func(a args) {
+				checkThrottle()
+				mockRepo.EXPECT().ClearLoginThrottle(gomock.Any(), accountKey).Return(nil)
+			},
+		},
+		{
+			name:    "failed reuse a recent password",
+			args:    args{ctx: ctx, req: ChangePasswordRequest{CurrentPassword: "Current-Passw0rd", NewPassword: "Previous-Passw0rd"}},
+			wantErr: true,
+			behaviour: func(a args) {
+				checkThrottle()
+				mockRepo.EXPECT().ClearLoginThrottle(gomock.Any(), accountKey).Return(nil)
+				mockRepo.EXPECT().GetPasswordHistory(gomock.Any(), "user-id", mockDeps.Config.Password.History-1).Return([]string{previous}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := NewUserLogic(&mockDeps, mockRepo, mockCompanyRepo, mockJwt, nil, nil, mockAuditor)
+			tt.behaviour(tt.args)
+			got, err := logic.ChangePassword(tt.args.ctx, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UserLogic.ChangePassword() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && (got.Token != "token" || got.RefreshToken == "") {
+				t.Errorf("UserLogic.ChangePassword() = %+v", got)
+			}
+		})
+	}
+}
+
+func TestUserLogic_RequestPasswordReset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := NewMockUserRepositoryInterface(ctrl)
+	mockMails := xmail.NewMockQueue(ctrl)
+	mockAuditor := xaudit.NewMockRecorder(ctrl)
+	// the change and its entry are run in the transaction the recorder begins
+	mockAuditor.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, txfunc func(context.Context) error) error {
+		return txfunc(ctx)
+	}).AnyTimes()
+	mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockDeps := config.CommonDependencies{
+		Config: config.InitConfig(context.Background()),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	ctx := context.WithValue(context.Background(), xcontext.UserIDKey, "admin-id")
+
+	type args struct {
+		ctx    context.Context
+		userID string
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantErr   bool
+		behaviour func(a args)
+	}{
+		// TODO: Add test cases.
+		{
+			name: "success send the reset token to the user only",
+			args: args{ctx: ctx, userID: "user-id"},
+			behaviour: func(a args) {
+				var tokenHash string
+				mockRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+				mockRepo.EXPECT().GetUserDetailsByID(gomock.Any(), "user-id").Return(models.User{ID: "user-id", Username: "ani"}, nil)
+				mockRepo.EXPECT().CreatePasswordResetToken(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data PasswordResetToken) error {
+					tokenHash = data.TokenHash
+					return nil
+				})
+				mockMails.EXPECT().Enqueue(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, email xmail.Email) error {
+					if email.UserID != "user-id" || email.Template != xmail.TemplatePasswordReset || hashToken(email.Secrets["token"]) != tokenHash {
+						t.Errorf("unexpected email: %+v", email)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name:    "failed user has no email address",
+			args:    args{ctx: ctx, userID: "user-id"},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+				mockRepo.EXPECT().GetUserDetailsByID(gomock.Any(), "user-id").Return(models.User{ID: "user-id", Username: "ani"}, nil)
+				mockRepo.EXPECT().CreatePasswordResetToken(gomock.Any(), gomock.Any()).Return(nil)
+				mockMails.EXPECT().Enqueue(gomock.Any(), gomock.Any()).Return(xmail.ErrNoRecipient)
+			},
+		},
+		{
+			name:    "failed non admin user",
+			args:    args{ctx: ctx, userID: "user-id"},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(false, nil)
+			},
+		},
+		{
+			name:    "failed unknown user",
+			args:    args{ctx: ctx, userID: "unknown"},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+				mockRepo.EXPECT().GetUserDetailsByID(gomock.Any(), "unknown").Return(models.User{}, xerror.ErrDataNotFound)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := NewUserLogic(&mockDeps, mockRepo, nil, nil, mockMails, nil, mockAuditor)
+			tt.behaviour(tt.args)
+			got, err := logic.RequestPasswordReset(tt.args.ctx, tt.args.userID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UserLogic.RequestPasswordReset() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !got.ExpiresAt.After(time.Now()) {
+				t.Errorf("UserLogic.RequestPasswordReset() = %+v", got)
+			}
+		})
+	}
+}
+
+func TestUserLogic_ResetPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := NewMockUserRepositoryInterface(ctrl)
+	mockAuditor := xaudit.NewMockRecorder(ctrl)
+	// the change and its entry are run in the transaction the recorder begins
+	mockAuditor.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, txfunc func(context.Context) error) error {
+		return txfunc(ctx)
+	}).AnyTimes()
+	mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockDeps := config.CommonDependencies{
+		Config: config.InitConfig(context.Background()),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	usedAt := time.Now().Add(-time.Minute)
+	current := testPasswordHash(t, "Current-Passw0rd")
+	stored := PasswordResetToken{
+		ID:        "reset-id",
+		CompanyID: "company-id",
+		UserID:    "user-id",
+		TokenHash: hashToken("reset"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	type args struct {
+		ctx context.Context
+		req ResetPasswordRequest
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantErr   bool
+		behaviour func(a args)
+	}{
+		// TODO: Add test cases.
+		{
+			name: "success reset password within the token company",
+			args: args{ctx: context.Background(), req: ResetPasswordRequest{Token: "reset", NewPassword: "Brand-New-Passw0rd"}},
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetPasswordResetTokenByHash(gomock.Any(), hashToken("reset")).Return(stored, nil)
+				mockRepo.EXPECT().GetUserDetailsByID(gomock.Any(), "user-id").Return(models.User{ID: "user-id", Password: current, MustChangePassword: true}, nil)
+				mockRepo.EXPECT().GetPasswordHistory(gomock.Any(), "user-id", gomock.Any()).Return([]string{}, nil)
+				mockRepo.EXPECT().ResetPassword(gomock.Any(), "reset-id", gomock.Any()).DoAndReturn(func(ctx context.Context, tokenID string, data PasswordChange) error {
+					if xcontext.GetCompanyIDFromContext(ctx) != "company-id" || data.MustChangePassword || data.OldPassword != current {
+						t.Errorf("unexpected password reset: %+v", data)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name:    "failed used reset token",
+			args:    args{ctx: context.Background(), req: ResetPasswordRequest{Token: "reset", NewPassword: "Brand-New-Passw0rd"}},
+			wantErr: true,
+			behaviour: func(a args) {
+				used := stored
+				used.UsedAt = &usedAt
+				mockRepo.EXPECT().GetPasswordResetTokenByHash(gomock.Any(), hashToken("reset")).Return(used, nil)
+			},
+		},
+		{
+			name:    "failed expired reset token",
+			args:    args{ctx: context.Background(), req: ResetPasswordRequest{Token: "reset", NewPassword: "Brand-New-Passw0rd"}},
+			wantErr: true,
+			behaviour: func(a args) {
+				expired := stored
+				expired.ExpiresAt = usedAt
+				mockRepo.EXPECT().GetPasswordResetTokenByHash(gomock.Any(), hashToken("reset")).Return(expired, nil)
+			},
+		},
+		{
+			name:    "failed reset token used concurrently",
+			args:    args{ctx: context.Background(), req: ResetPasswordRequest{Token: "reset", NewPassword: "Brand-New-Passw0rd"}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetPasswordResetTokenByHash(gomock.Any(), hashToken("reset")).Return(stored, nil)
+				mockRepo.EXPECT().GetUserDetailsByID(gomock.Any(), "user-id").Return(models.User{ID: "user-id", Password: current}, nil)
+				mockRepo.EXPECT().GetPasswordHistory(gomock.Any(), "user-id", gomock.Any()).Return([]string{}, nil)
+				mockRepo.EXPECT().ResetPassword(gomock.Any(), "reset-id", gomock.Any()).Return(xerror.ErrDataNotFound)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := NewUserLogic(&mockDeps, mockRepo, nil, nil, nil, nil, mockAuditor)
+			tt.behaviour(tt.args)
+			if err := logic.ResetPassword(tt.args.ctx, tt.args.req); (err != nil) != tt.wantErr {
+				t.Errorf("UserLogic.ResetPassword() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func testPasswordHash(t *testing.T, password string) string {
+	pwBytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(pwBytes)
+}
+
+func TestUserLogic_UnlockUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := NewMockUserRepositoryInterface(ctrl)
+	mockAuditor := xaudit.NewMockRecorder(ctrl)
+	// the change and its entry are run in the transaction the recorder begins
+	mockAuditor.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, txfunc func(context.Context) error) error {
+		return txfunc(ctx)
+	}).AnyTimes()
+	mockDeps := config.CommonDependencies{
+		Config: config.InitConfig(context.Background()),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	ctx := context.WithValue(context.Background(), xcontext.UserIDKey, "admin-id")
+
+	type args struct {
+		ctx    context.Context
+		userID string
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantErr   bool
+		behaviour func(a args)
+	}{
+		// TODO: Add test cases.
+		{
+			name: "success unlock the user account",
+			args: args{ctx: ctx, userID: "user-id"},
+			behaviour: func(a args) {
+				mockRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+				mockRepo.EXPECT().GetUserDetailsByID(gomock.Any(), "user-id").Return(models.User{ID: "user-id", Username: "ani"}, nil)
+				mockRepo.EXPECT().UnlockAccount(gomock.Any(), "ani").Return(nil)
+				mockAuditor.EXPECT().Record(gomock.Any(), xaudit.Entry{Action: xaudit.ActionUnlock, Entity: xaudit.EntityUser, EntityID: "user-id"}).Return(nil)
+			},
+		},
+		{
+			name:    "failed audit log, the unlock is rolled back along with it",
+			args:    args{ctx: ctx, userID: "user-id"},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+				mockRepo.EXPECT().GetUserDetailsByID(gomock.Any(), "user-id").Return(models.User{ID: "user-id", Username: "ani"}, nil)
+				mockRepo.EXPECT().UnlockAccount(gomock.Any(), "ani").Return(nil)
+				mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any()).Return(errors.New("db down"))
+			},
+		},
+		{
+			name:    "failed non admin user",
+			args:    args{ctx: ctx, userID: "user-id"},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(false, nil)
+			},
+		},
+		{
+			name:    "failed unknown user",
+			args:    args{ctx: ctx, userID: "unknown"},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+				mockRepo.EXPECT().GetUserDetailsByID(gomock.Any(), "unknown").Return(models.User{}, xerror.ErrDataNotFound)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := NewUserLogic(&mockDeps, mockRepo, nil, nil, nil, nil, mockAuditor)
+			tt.behaviour(tt.args)
+			if err := logic.UnlockUser(tt.args.ctx, tt.args.userID); (err != nil) != tt.wantErr {
+				t.Errorf("UserLogic.UnlockUser() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUserLogic_VerifyTwoFactorLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := NewMockUserRepositoryInterface(ctrl)
+	mockJwt := xjwt.NewMockJWTHelper(ctrl)
+	mockDeps := config.CommonDependencies{
+		Config: config.InitConfig(context.Background()),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	secret, stored := testTwoFactorSecret(t, mockDeps.Config.App.EncryptionKey)
+	step := xtotp.Step(time.Now())
+	code, err := xtotp.Code(secret, step)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	challenge := LoginChallenge{
+		ID:          "challenge-id",
+		CompanyID:   "company-id",
+		CompanyCode: models.DefaultCompanyCode,
+		UserID:      "user-id",
+		ExpiresAt:   time.Now().Add(time.Minute),
+	}
+	usedAt := time.Now().Add(-time.Minute)
+	used := challenge
+	used.UsedAt = &usedAt
+	expired := challenge
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+
+	accountKey := LoginThrottleKey{Scope: LoginThrottleAccount, CompanyCode: models.DefaultCompanyCode, Subject: "ani"}
+	userDetails := models.User{ID: "user-id", Username: "Ani", TwoFactorEnabled: true}
+
+	type args struct {
+		ctx context.Context
+		req TwoFactorLoginRequest
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantErr   bool
+		behaviour func(a args)
+	}{
+		// TODO: Add test cases.
+		{
+			name: "success login with a code of the authenticator app",
+			args: args{ctx: context.Background(), req: TwoFactorLoginRequest{ChallengeToken: "challenge", Code: code}},
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetLoginChallengeByHash(gomock.Any(), hashToken("challenge")).Return(challenge, nil)
+				mockRepo.EXPECT().GetUserDetailsByID(gomock.Any(), "user-id").DoAndReturn(func(ctx context.Context, userID string) (models.User, error) {
+					if xcontext.GetCompanyIDFromContext(ctx) != "company-id" {
+						t.Errorf("unexpected company %q", xcontext.GetCompanyIDFromContext(ctx))
+					}
+					return userDetails, nil
+				})
+				mockRepo.EXPECT().GetLoginThrottle(gomock.Any(), accountKey).Return(LoginThrottle{}, xerror.ErrDataNotFound)
+				mockRepo.EXPECT().GetTwoFactorSecret(gomock.Any(), "user-id").Return(stored, nil)
+				mockRepo.EXPECT().UseTwoFactorStep(gomock.Any(), "user-id", step).Return(nil)
+				mockRepo.EXPECT().CompleteLoginChallenge(gomock.Any(), "challenge-id").Return(nil)
+				mockRepo.EXPECT().ClearLoginThrottle(gomock.Any(), accountKey).Return(nil)
+				mockJwt.EXPECT().GenerateJWT(gomock.Any(), gomock.Any()).DoAndReturn(func(claims xjwt.Claims, expiryTime time.Duration) (string, error) {
+					if claims.Subject != "user-id" || claims.CompanyID != "company-id" || claims.TwoFactorSetup {
+						t.Errorf("unexpected claims: %+v", claims)
+					}
+					return "token", nil
+				})
+				mockRepo.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name: "success login with a recovery code typed in any case",
+			args: args{ctx: context.Background(), req: TwoFactorLoginRequest{ChallengeToken: "challenge", Code: "ABCD-EFGH-IJKL"}},
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetLoginChallengeByHash(gomock.Any(), hashToken("challenge")).Return(challenge, nil)
+				mockRepo.EXPECT().GetUserDetailsByID(gomock.Any(), "user-id").Return(userDetails, nil)
+				mockRepo.EXPECT().GetLoginThrottle(gomock.Any(), accountKey).Return(LoginThrottle{}, xerror.ErrDataNotFound)
+				mockRepo.EXPECT().UseRecoveryCode(gomock.Any(), "user-id", hashToken("abcdefghijkl")).Return(nil)
+				mockRepo.EXPECT().CompleteLoginChallenge(gomock.Any(), "challenge-id").Return(nil)
+				mockRepo.EXPECT().ClearLoginThrottle(gomock.Any(), accountKey).Return(nil)
+				mockJwt.EXPECT().GenerateJWT(gomock.Any(), gomock.Any()).Return("token", nil)
+				mockRepo.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:    "failed reused code counts as a failed attempt",
+			args:    args{ctx: context.Background(), req: TwoFactorLoginRequest{ChallengeToken: "challenge", Code: code}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetLoginChallengeByHash(gomock.Any(), hashToken("challenge")).Return(challenge, nil)
+				mockRepo.EXPECT().GetUserDetailsByID(gomock.Any(), "user-id").Return(userDetails, nil)
+				mockRepo.EXPECT().GetLoginThrottle(gomock.Any(), accountKey).Return(LoginThrottle{}, xerror.ErrDataNotFound)
+				mockRepo.EXPECT().GetTwoFactorSecret(gomock.Any(), "user-id").Return(stored, nil)
+				mockRepo.EXPECT().UseTwoFactorStep(gomock.Any(), "user-id", gomock.Any()).Return(xerror.ErrDataNotFound)
+				mockRepo.EXPECT().RecordLoginFailure(gomock.Any(), accountKey, gomock.Any()).Return(1, nil)
+			},
+		},
+		{
+			name:    "failed locked account without checking the code",
+			args:    args{ctx: context.Background(), req: TwoFactorLoginRequest{ChallengeToken: "challenge", Code: code}},
+			wantErr: true,
+			behaviour: func(a args) {
+				lockedUntil := time.Now().Add(time.Hour)
+				mockRepo.EXPECT().GetLoginChallengeByHash(gomock.Any(), hashToken("challenge")).Return(challenge, nil)
+				mockRepo.EXPECT().GetUserDetailsByID(gomock.Any(), "user-id").Return(userDetails, nil)
+				mockRepo.EXPECT().GetLoginThrottle(gomock.Any(), accountKey).Return(LoginThrottle{LockedUntil: &lockedUntil}, nil)
+			},
+		},
+		{
+			name:    "failed used challenge",
+			args:    args{ctx: context.Background(), req: TwoFactorLoginRequest{ChallengeToken: "challenge", Code: code}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetLoginChallengeByHash(gomock.Any(), hashToken("challenge")).Return(used, nil)
+			},
+		},
+		{
+			name:    "failed expired challenge",
+			args:    args{ctx: context.Background(), req: TwoFactorLoginRequest{ChallengeToken: "challenge", Code: code}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetLoginChallengeByHash(gomock.Any(), hashToken("challenge")).Return(expired, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := NewUserLogic(&mockDeps, mockRepo, nil, mockJwt, nil, nil, nil)
+			tt.behaviour(tt.args)
+			got, err := logic.VerifyTwoFactorLogin(tt.args.ctx, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UserLogic.VerifyTwoFactorLogin() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && (got.Token != "token" || got.RefreshToken == "") {
+				t.Errorf("UserLogic.VerifyTwoFactorLogin() = %+v", got)
+			}
+		})
+	}
+}
+
+func TestUserLogic_EnrolTwoFactor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := NewMockUserRepositoryInterface(ctrl)
+	mockAuditor := xaudit.NewMockRecorder(ctrl)
+	// the change and its entry are run in the transaction the recorder begins
+	mockAuditor.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, txfunc func(context.Context) error) error {
+		return txfunc(ctx)
+	}).AnyTimes()
+	mockDeps := config.CommonDependencies{
+		Config: config.InitConfig(context.Background()),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	ctx := context.WithValue(context.Background(), xcontext.UserIDKey, "user-id")
+
+	type args struct {
+		ctx context.Context
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantErr   bool
+		behaviour func(a args)
+	}{
+		// TODO: Add test cases.
+		{
+			name: "success enrol a secret, which is left out of the audit log",
+			args: args{ctx: ctx},
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetUserDetailsByID(gomock.Any(), "user-id").Return(models.User{ID: "user-id", Username: "ani"}, nil)
+				mockRepo.EXPECT().SaveTwoFactorSecret(gomock.Any(), gomock.Any()).Return(nil)
+				mockAuditor.EXPECT().Record(gomock.Any(), xaudit.Entry{Action: xaudit.ActionEnrol, Entity: xaudit.EntityTwoFactor, EntityID: "user-id"}).Return(nil)
+			},
+		},
+		{
+			name:    "failed already enabled",
+			args:    args{ctx: ctx},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetUserDetailsByID(gomock.Any(), "user-id").Return(models.User{ID: "user-id", Username: "ani", TwoFactorEnabled: true}, nil)
+			},
+		},
+		{
+			name:    "failed audit log, the secret is rolled back along with it",
+			args:    args{ctx: ctx},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetUserDetailsByID(gomock.Any(), "user-id").Return(models.User{ID: "user-id", Username: "ani"}, nil)
+				mockRepo.EXPECT().SaveTwoFactorSecret(gomock.Any(), gomock.Any()).Return(nil)
+				mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any()).Return(errors.New("db down"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := NewUserLogic(&mockDeps, mockRepo, nil, nil, nil, nil, mockAuditor)
+			tt.behaviour(tt.args)
+			got, err := logic.EnrolTwoFactor(tt.args.ctx)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UserLogic.EnrolTwoFactor() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && (got.Secret == "" || !strings.Contains(got.ProvisioningURI, got.Secret)) {
+				t.Errorf("UserLogic.EnrolTwoFactor() = %+v", got)
+			}
+		})
+	}
+}
+
+func TestUserLogic_ConfirmTwoFactor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := NewMockUserRepositoryInterface(ctrl)
+	mockJwt := xjwt.NewMockJWTHelper(ctrl)
+	mockAuditor := xaudit.NewMockRecorder(ctrl)
+	// the change and its entry are run in the transaction the recorder begins
+	mockAuditor.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, txfunc func(context.Context) error) error {
+		return txfunc(ctx)
+	}).AnyTimes()
+	mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockDeps := config.CommonDependencies{
+		Config: config.InitConfig(context.Background()),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	secret, stored := testTwoFactorSecret(t, mockDeps.Config.App.EncryptionKey)
+	stored.ConfirmedAt = nil
+	step := xtotp.Step(time.Now())
+	code, err := xtotp.Code(secret, step)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.WithValue(context.Background(), xcontext.UserIDKey, "user-id")
+	ctx = context.WithValue(ctx, xcontext.CompanyIDKey, "company-id")
+	ctx = context.WithValue(ctx, xcontext.TokenIDKey, "jti")
+
+	type args struct {
+		ctx context.Context
+		req ConfirmTwoFactorRequest
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantErr   bool
+		behaviour func(a args)
+	}{
+		// TODO: Add test cases.
+		{
+			name: "success enable it and lift the enrolment restriction",
+			args: args{ctx: ctx, req: ConfirmTwoFactorRequest{Code: code}},
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetUserDetailsByID(gomock.Any(), "user-id").Return(models.User{ID: "user-id", Username: "ani", TwoFactorRequired: true}, nil)
+				mockRepo.EXPECT().GetTwoFactorSecret(gomock.Any(), "user-id").Return(stored, nil)
+				mockRepo.EXPECT().EnableTwoFactor(gomock.Any(), "user-id", step, gomock.Len(mockDeps.Config.TwoFactor.RecoveryCodes)).Return(nil)
+				mockRepo.EXPECT().RevokeToken(gomock.Any(), "jti", gomock.Any()).Return(nil)
+				mockJwt.EXPECT().GenerateJWT(gomock.Any(), gomock.Any()).DoAndReturn(func(claims xjwt.Claims, expiryTime time.Duration) (string, error) {
+					if claims.TwoFactorSetup {
+						t.Errorf("unexpected claims: %+v", claims)
+					}
+					return "token", nil
+				})
+				mockRepo.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:    "failed invalid code",
+			args:    args{ctx: ctx, req: ConfirmTwoFactorRequest{Code: "000000"}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetUserDetailsByID(gomock.Any(), "user-id").Return(models.User{ID: "user-id", Username: "ani"}, nil)
+				mockRepo.EXPECT().GetTwoFactorSecret(gomock.Any(), "user-id").Return(stored, nil)
+			},
+		},
+		{
+			name:    "failed enrolment not started",
+			args:    args{ctx: ctx, req: ConfirmTwoFactorRequest{Code: code}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetUserDetailsByID(gomock.Any(), "user-id").Return(models.User{ID: "user-id", Username: "ani"}, nil)
+				mockRepo.EXPECT().GetTwoFactorSecret(gomock.Any(), "user-id").Return(TwoFactorSecret{}, xerror.ErrDataNotFound)
+			},
+		},
+		{
+			name:    "failed already enabled",
+			args:    args{ctx: ctx, req: ConfirmTwoFactorRequest{Code: code}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetUserDetailsByID(gomock.Any(), "user-id").Return(models.User{ID: "user-id", Username: "ani", TwoFactorEnabled: true}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := NewUserLogic(&mockDeps, mockRepo, nil, mockJwt, nil, nil, mockAuditor)
+			tt.behaviour(tt.args)
+			got, err := logic.ConfirmTwoFactor(tt.args.ctx, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UserLogic.ConfirmTwoFactor() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && (got.Token != "token" || len(got.RecoveryCodes) != mockDeps.Config.TwoFactor.RecoveryCodes) {
+				t.Errorf("UserLogic.ConfirmTwoFactor() = %+v", got)
+			}
+		})
+	}
+}
+
+func TestUserLogic_DisableTwoFactor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := NewMockUserRepositoryInterface(ctrl)
+	mockCompanyRepo := company.NewMockCompanyRepositoryInterface(ctrl)
+	mockAuditor := xaudit.NewMockRecorder(ctrl)
+	// the change and its entry are run in the transaction the recorder begins
+	mockAuditor.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, txfunc func(context.Context) error) error {
+		return txfunc(ctx)
+	}).AnyTimes()
+	mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockDeps := config.CommonDependencies{
+		Config: config.InitConfig(context.Background()),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	secret, stored := testTwoFactorSecret(t, mockDeps.Config.App.EncryptionKey)
+	step := xtotp.Step(time.Now())
+	code, err := xtotp.Code(secret, step)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := models.User{ID: "user-id", Username: "ani", Password: testPasswordHash(t, "Current-Passw0rd"), TwoFactorEnabled: true}
+	accountKey := LoginThrottleKey{Scope: LoginThrottleAccount, CompanyCode: "acme", Subject: "ani"}
+	ipKey := LoginThrottleKey{Scope: LoginThrottleIP, Subject: "10.0.0.1"}
+	lockedUntil := time.Now().Add(time.Hour)
+
+	ctx := context.WithValue(context.Background(), xcontext.UserIDKey, "user-id")
+	ctx = context.WithValue(ctx, xcontext.CompanyIDKey, "company-id")
+	ctx = context.WithValue(ctx, xcontext.IPKey, "10.0.0.1")
+
+	// the password and the code are only checked once neither the account nor the IP is throttled
+	checkThrottle := func() {
+		mockRepo.EXPECT().GetUserDetailsByID(gomock.Any(), "user-id").Return(user, nil)
+		mockCompanyRepo.EXPECT().GetCompanyByID(gomock.Any(), "company-id").Return(models.Company{ID: "company-id", Code: "acme"}, nil)
+		mockRepo.EXPECT().GetLoginThrottle(gomock.Any(), accountKey).Return(LoginThrottle{}, xerror.ErrDataNotFound)
+		mockRepo.EXPECT().GetLoginThrottle(gomock.Any(), ipKey).Return(LoginThrottle{}, xerror.ErrDataNotFound)
+	}
+	recordFailure := func() {
+		mockRepo.EXPECT().RecordLoginFailure(gomock.Any(), accountKey, mockDeps.Config.Login.FailureWindow).Return(1, nil)
+		mockRepo.EXPECT().RecordLoginFailure(gomock.Any(), ipKey, mockDeps.Config.Login.FailureWindow).Return(1, nil)
+	}
+
+	type args struct {
+		ctx context.Context
+		req DisableTwoFactorRequest
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantErr   bool
+		behaviour func(a args)
+	}{
+		// TODO: Add test cases.
+		{
+			name: "success disable it and clear the failed attempts",
+			args: args{ctx: ctx, req: DisableTwoFactorRequest{Password: "Current-Passw0rd", Code: code}},
+			behaviour: func(a args) {
+				checkThrottle()
+				mockRepo.EXPECT().GetTwoFactorSecret(gomock.Any(), "user-id").Return(stored, nil)
+				mockRepo.EXPECT().UseTwoFactorStep(gomock.Any(), "user-id", step).Return(nil)
+				mockRepo.EXPECT().ClearLoginThrottle(gomock.Any(), accountKey).Return(nil)
+				mockRepo.EXPECT().DeleteTwoFactor(gomock.Any(), "user-id").Return(nil)
+			},
+		},
+		{
+			name:    "failed invalid password counts as a failed login",
+			args:    args{ctx: ctx, req: DisableTwoFactorRequest{Password: "wrong", Code: code}},
+			wantErr: true,
+			behaviour: func(a args) {
+				checkThrottle()
+				recordFailure()
+			},
+		},
+		{
+			name:    "failed invalid code counts as a failed login",
+			args:    args{ctx: ctx, req: DisableTwoFactorRequest{Password: "Current-Passw0rd", Code: "000000"}},
+			wantErr: true,
+			behaviour: func(a args) {
+				checkThrottle()
+				mockRepo.EXPECT().GetTwoFactorSecret(gomock.Any(), "user-id").Return(stored, nil)
+				recordFailure()
+			},
+		},
+		{
+			name:    "failed throttled account without checking the password",
+			args:    args{ctx: ctx, req: DisableTwoFactorRequest{Password: "Current-Passw0rd", Code: code}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().GetUserDetailsByID(gomock.Any(), "user-id").Return(user, nil)
+				mockCompanyRepo.EXPECT().GetCompanyByID(gomock.Any(), "company-id").Return(models.Company{ID: "company-id", Code: "acme"}, nil)
+				mockRepo.EXPECT().GetLoginThrottle(gomock.Any(), accountKey).Return(LoginThrottle{LoginThrottleKey: accountKey, Failures: 5, LockedUntil: &lockedUntil}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := NewUserLogic(&mockDeps, mockRepo, mockCompanyRepo, nil, nil, nil, mockAuditor)
+			tt.behaviour(tt.args)
+			if err := logic.DisableTwoFactor(tt.args.ctx, tt.args.req); (err != nil) != tt.wantErr {
+				t.Errorf("UserLogic.DisableTwoFactor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUserLogic_CompleteSSOLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := NewMockUserRepositoryInterface(ctrl)
+	mockCompanyRepo := company.NewMockCompanyRepositoryInterface(ctrl)
+	mockJwt := xjwt.NewMockJWTHelper(ctrl)
+	mockProvider := xoidc.NewMockProvider(ctrl)
+	mockAuditor := xaudit.NewMockRecorder(ctrl)
+	// the change and its entry are run in the transaction the recorder begins
+	mockAuditor.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, txfunc func(context.Context) error) error {
+		return txfunc(ctx)
+	}).AnyTimes()
+	mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockDeps := config.CommonDependencies{
+		Config: config.InitConfig(context.Background()),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	stored := SSOState{ID: "state-id", Nonce: "nonce", CodeVerifier: "verifier"}
+	claims := xoidc.IDTokenClaims{
+		Email:            "Ani@Example.com",
+		EmailVerified:    true,
+		RegisteredClaims: jwt.RegisteredClaims{Issuer: "https://idp.example.com", Subject: "subject"},
+	}
+	unverified := claims
+	unverified.EmailVerified = false
+
+	type args struct {
+		ctx context.Context
+		req SSOCallbackRequest
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantErr   bool
+		behaviour func(a args)
+	}{
+		// TODO: Add test cases.
+		{
+			name: "success login the linked user",
+			args: args{ctx: context.Background(), req: SSOCallbackRequest{Code: "code", State: "state"}},
+			behaviour: func(a args) {
+				mockRepo.EXPECT().UseSSOState(gomock.Any(), hashToken("state")).Return(stored, nil)
+				mockProvider.EXPECT().Exchange(gomock.Any(), "code", "verifier", "nonce").Return(claims, nil)
+				mockCompanyRepo.EXPECT().GetCompanyByCode(gomock.Any(), mockDeps.Config.OIDC.CompanyCode).Return(models.Company{ID: "company-id"}, nil)
+				mockRepo.EXPECT().GetUserDetailsByIdentity(gomock.Any(), "https://idp.example.com", "subject").DoAndReturn(func(ctx context.Context, issuer string, subject string) (models.User, error) {
+					if xcontext.GetCompanyIDFromContext(ctx) != "company-id" {
+						t.Errorf("unexpected company %q", xcontext.GetCompanyIDFromContext(ctx))
+					}
+					// the identity provider owns the credentials so local restrictions are lifted
+					return models.User{ID: "user-id", Username: "ani@example.com", MustChangePassword: true, TwoFactorRequired: true}, nil
+				})
+				mockJwt.EXPECT().GenerateJWT(gomock.Any(), gomock.Any()).DoAndReturn(func(c xjwt.Claims, expiryTime time.Duration) (string, error) {
+					if c.Subject != "user-id" || c.MustChangePassword || c.TwoFactorSetup {
+						t.Errorf("unexpected claims: %+v", c)
+					}
+					return "token", nil
+				})
+				mockRepo.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name: "success link the user with the verified email on the first login",
+			args: args{ctx: context.Background(), req: SSOCallbackRequest{Code: "code", State: "state"}},
+			behaviour: func(a args) {
+				mockRepo.EXPECT().UseSSOState(gomock.Any(), hashToken("state")).Return(stored, nil)
+				mockProvider.EXPECT().Exchange(gomock.Any(), "code", "verifier", "nonce").Return(claims, nil)
+				mockCompanyRepo.EXPECT().GetCompanyByCode(gomock.Any(), mockDeps.Config.OIDC.CompanyCode).Return(models.Company{ID: "company-id"}, nil)
+				mockRepo.EXPECT().GetUserDetailsByIdentity(gomock.Any(), "https://idp.example.com", "subject").Return(models.User{}, xerror.ErrDataNotFound)
+				mockRepo.EXPECT().GetUserDetailsByUsername(gomock.Any(), "ani@example.com").Return(models.User{ID: "user-id", Username: "ani@example.com"}, nil)
+				mockRepo.EXPECT().LinkIdentity(gomock.Any(), UserIdentity{UserID: "user-id", Issuer: "https://idp.example.com", Subject: "subject", Email: "ani@example.com"}).Return(nil)
+				mockJwt.EXPECT().GenerateJWT(gomock.Any(), gomock.Any()).Return("token", nil)
+				mockRepo.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:    "failed unverified email isn't linked",
+			args:    args{ctx: context.Background(), req: SSOCallbackRequest{Code: "code", State: "state"}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().UseSSOState(gomock.Any(), hashToken("state")).Return(stored, nil)
+				mockProvider.EXPECT().Exchange(gomock.Any(), "code", "verifier", "nonce").Return(unverified, nil)
+				mockCompanyRepo.EXPECT().GetCompanyByCode(gomock.Any(), mockDeps.Config.OIDC.CompanyCode).Return(models.Company{ID: "company-id"}, nil)
+				mockRepo.EXPECT().GetUserDetailsByIdentity(gomock.Any(), "https://idp.example.com", "subject").Return(models.User{}, xerror.ErrDataNotFound)
+			},
+		},
+		{
+			name:    "failed state used or expired",
+			args:    args{ctx: context.Background(), req: SSOCallbackRequest{Code: "code", State: "state"}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().UseSSOState(gomock.Any(), hashToken("state")).Return(SSOState{}, xerror.ErrDataNotFound)
+			},
+		},
+		{
+			name:    "failed invalid ID token",
+			args:    args{ctx: context.Background(), req: SSOCallbackRequest{Code: "code", State: "state"}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().UseSSOState(gomock.Any(), hashToken("state")).Return(stored, nil)
+				mockProvider.EXPECT().Exchange(gomock.Any(), "code", "verifier", "nonce").Return(xoidc.IDTokenClaims{}, errors.New("invalid ID token: nonce mismatch"))
+			},
+		},
+		{
+			name:      "failed sign-in denied at the identity provider",
+			args:      args{ctx: context.Background(), req: SSOCallbackRequest{Error: "access_denied"}},
+			wantErr:   true,
+			behaviour: func(a args) {},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := NewUserLogic(&mockDeps, mockRepo, mockCompanyRepo, mockJwt, nil, mockProvider, mockAuditor)
+			tt.behaviour(tt.args)
+			got, err := logic.CompleteSSOLogin(tt.args.ctx, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UserLogic.CompleteSSOLogin() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got.Token != "token" {
+				t.Errorf("UserLogic.CompleteSSOLogin() = %+v", got)
+			}
+		})
+	}
+}
+
+// testTwoFactorSecret generates a confirmed secret, returning it along with the stored one
+func testTwoFactorSecret(t *testing.T, encryptionKey string) (string, TwoFactorSecret) {
+	secret, err := xtotp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted, err := xcrypto.Encrypt([]byte(secret), encryptionKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	confirmedAt := time.Now().Add(-24 * time.Hour)
+	return secret, TwoFactorSecret{
+		UserID:      "user-id",
+		Secret:      encrypted,
+		ConfirmedAt: &confirmedAt,
+	}
+}
+
+func TestUserLogic_SetEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := NewMockUserRepositoryInterface(ctrl)
+	mockAuditor := xaudit.NewMockRecorder(ctrl)
+	// the change and its entry are run in the transaction the recorder begins
+	mockAuditor.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, txfunc func(context.Context) error) error {
+		return txfunc(ctx)
+	}).AnyTimes()
+	mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockDeps := config.CommonDependencies{
+		Config: config.InitConfig(context.Background()),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	ctx := context.WithValue(context.Background(), xcontext.UserIDKey, "admin-id")
+
+	type args struct {
+		ctx    context.Context
+		userID string
+		req    EmailRequest
+	}
+	tests := []struct {
+		name      string
+		args      args
+		want      UserEmail
+		wantErr   bool
+		behaviour func(a args)
+	}{
+		// TODO: Add test cases.
+		{
+			name: "success set the email address",
+			args: args{ctx: ctx, userID: "user-id", req: EmailRequest{Email: " ani@example.com "}},
+			want: UserEmail{UserID: "user-id", Email: "ani@example.com"},
+			behaviour: func(a args) {
+				mockRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+				mockRepo.EXPECT().UpdateEmail(gomock.Any(), "user-id", "ani@example.com").Return(nil)
+			},
+		},
+		{
+			name: "success remove the email address",
+			args: args{ctx: ctx, userID: "user-id", req: EmailRequest{}},
+			want: UserEmail{UserID: "user-id"},
+			behaviour: func(a args) {
+				mockRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+				mockRepo.EXPECT().UpdateEmail(gomock.Any(), "user-id", "").Return(nil)
+			},
+		},
+		{
+			name:    "failed invalid email address",
+			args:    args{ctx: ctx, userID: "user-id", req: EmailRequest{Email: "Ani <ani@example.com>"}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+			},
+		},
+		{
+			name:    "failed unknown user",
+			args:    args{ctx: ctx, userID: "unknown", req: EmailRequest{Email: "ani@example.com"}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+				mockRepo.EXPECT().UpdateEmail(gomock.Any(), "unknown", "ani@example.com").Return(xerror.ErrDataNotFound)
+			},
+		},
+		{
+			name:    "failed non admin user",
+			args:    args{ctx: ctx, userID: "user-id", req: EmailRequest{Email: "ani@example.com"}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(false, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := NewUserLogic(&mockDeps, mockRepo, nil, nil, nil, nil, mockAuditor)
+			tt.behaviour(tt.args)
+			got, err := logic.SetEmail(tt.args.ctx, tt.args.userID, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UserLogic.SetEmail() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("UserLogic.SetEmail() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -12,6 +12,7 @@ package user
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	models "github.com/rahadianir/dealls/internal/models"
 	gomock "go.uber.org/mock/gomock"
@@ -41,6 +42,133 @@ func (m *MockUserRepositoryInterface) EXPECT() *MockUserRepositoryInterfaceMockR
 	return m.recorder
 }
 
+// ClearLoginThrottle mocks base method.
+func (m *MockUserRepositoryInterface) ClearLoginThrottle(ctx context.Context, key LoginThrottleKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearLoginThrottle", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearLoginThrottle indicates an expected call of ClearLoginThrottle.
+func (mr *MockUserRepositoryInterfaceMockRecorder) ClearLoginThrottle(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearLoginThrottle", reflect.TypeOf((*MockUserRepositoryInterface)(nil).ClearLoginThrottle), ctx, key)
+}
+
+// CompleteLoginChallenge mocks base method.
+func (m *MockUserRepositoryInterface) CompleteLoginChallenge(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteLoginChallenge", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteLoginChallenge indicates an expected call of CompleteLoginChallenge.
+func (mr *MockUserRepositoryInterfaceMockRecorder) CompleteLoginChallenge(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteLoginChallenge", reflect.TypeOf((*MockUserRepositoryInterface)(nil).CompleteLoginChallenge), ctx, id)
+}
+
+// CountEligibleUsers mocks base method.
+func (m *MockUserRepositoryInterface) CountEligibleUsers(ctx context.Context, payGroupID string, statuses []string, start, end time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountEligibleUsers", ctx, payGroupID, statuses, start, end)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountEligibleUsers indicates an expected call of CountEligibleUsers.
+func (mr *MockUserRepositoryInterfaceMockRecorder) CountEligibleUsers(ctx, payGroupID, statuses, start, end any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountEligibleUsers", reflect.TypeOf((*MockUserRepositoryInterface)(nil).CountEligibleUsers), ctx, payGroupID, statuses, start, end)
+}
+
+// CreateLoginChallenge mocks base method.
+func (m *MockUserRepositoryInterface) CreateLoginChallenge(ctx context.Context, data LoginChallenge) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateLoginChallenge", ctx, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateLoginChallenge indicates an expected call of CreateLoginChallenge.
+func (mr *MockUserRepositoryInterfaceMockRecorder) CreateLoginChallenge(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLoginChallenge", reflect.TypeOf((*MockUserRepositoryInterface)(nil).CreateLoginChallenge), ctx, data)
+}
+
+// CreatePasswordResetToken mocks base method.
+func (m *MockUserRepositoryInterface) CreatePasswordResetToken(ctx context.Context, data PasswordResetToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePasswordResetToken", ctx, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePasswordResetToken indicates an expected call of CreatePasswordResetToken.
+func (mr *MockUserRepositoryInterfaceMockRecorder) CreatePasswordResetToken(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePasswordResetToken", reflect.TypeOf((*MockUserRepositoryInterface)(nil).CreatePasswordResetToken), ctx, data)
+}
+
+// CreateRefreshToken mocks base method.
+func (m *MockUserRepositoryInterface) CreateRefreshToken(ctx context.Context, data RefreshToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRefreshToken", ctx, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRefreshToken indicates an expected call of CreateRefreshToken.
+func (mr *MockUserRepositoryInterfaceMockRecorder) CreateRefreshToken(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRefreshToken", reflect.TypeOf((*MockUserRepositoryInterface)(nil).CreateRefreshToken), ctx, data)
+}
+
+// CreateSSOState mocks base method.
+func (m *MockUserRepositoryInterface) CreateSSOState(ctx context.Context, data SSOState) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSSOState", ctx, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSSOState indicates an expected call of CreateSSOState.
+func (mr *MockUserRepositoryInterfaceMockRecorder) CreateSSOState(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSSOState", reflect.TypeOf((*MockUserRepositoryInterface)(nil).CreateSSOState), ctx, data)
+}
+
+// DeleteTwoFactor mocks base method.
+func (m *MockUserRepositoryInterface) DeleteTwoFactor(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTwoFactor", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTwoFactor indicates an expected call of DeleteTwoFactor.
+func (mr *MockUserRepositoryInterfaceMockRecorder) DeleteTwoFactor(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTwoFactor", reflect.TypeOf((*MockUserRepositoryInterface)(nil).DeleteTwoFactor), ctx, userID)
+}
+
+// EnableTwoFactor mocks base method.
+func (m *MockUserRepositoryInterface) EnableTwoFactor(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableTwoFactor", ctx, userID, step, recoveryCodeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableTwoFactor indicates an expected call of EnableTwoFactor.
+func (mr *MockUserRepositoryInterfaceMockRecorder) EnableTwoFactor(ctx, userID, step, recoveryCodeHashes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableTwoFactor", reflect.TypeOf((*MockUserRepositoryInterface)(nil).EnableTwoFactor), ctx, userID, step, recoveryCodeHashes)
+}
+
 // GetAdminRole mocks base method.
 func (m *MockUserRepositoryInterface) GetAdminRole(ctx context.Context) (string, error) {
 	m.ctrl.T.Helper()
@@ -56,6 +184,156 @@ func (mr *MockUserRepositoryInterfaceMockRecorder) GetAdminRole(ctx any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAdminRole", reflect.TypeOf((*MockUserRepositoryInterface)(nil).GetAdminRole), ctx)
 }
 
+// GetAdminUsernames mocks base method.
+func (m *MockUserRepositoryInterface) GetAdminUsernames(ctx context.Context) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAdminUsernames", ctx)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAdminUsernames indicates an expected call of GetAdminUsernames.
+func (mr *MockUserRepositoryInterfaceMockRecorder) GetAdminUsernames(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAdminUsernames", reflect.TypeOf((*MockUserRepositoryInterface)(nil).GetAdminUsernames), ctx)
+}
+
+// GetEligibleUserIDs mocks base method.
+func (m *MockUserRepositoryInterface) GetEligibleUserIDs(ctx context.Context, payGroupID string, statuses []string, start, end time.Time, afterUserID string, limit int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEligibleUserIDs", ctx, payGroupID, statuses, start, end, afterUserID, limit)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEligibleUserIDs indicates an expected call of GetEligibleUserIDs.
+func (mr *MockUserRepositoryInterfaceMockRecorder) GetEligibleUserIDs(ctx, payGroupID, statuses, start, end, afterUserID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEligibleUserIDs", reflect.TypeOf((*MockUserRepositoryInterface)(nil).GetEligibleUserIDs), ctx, payGroupID, statuses, start, end, afterUserID, limit)
+}
+
+// GetLoginChallengeByHash mocks base method.
+func (m *MockUserRepositoryInterface) GetLoginChallengeByHash(ctx context.Context, tokenHash string) (LoginChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginChallengeByHash", ctx, tokenHash)
+	ret0, _ := ret[0].(LoginChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginChallengeByHash indicates an expected call of GetLoginChallengeByHash.
+func (mr *MockUserRepositoryInterfaceMockRecorder) GetLoginChallengeByHash(ctx, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginChallengeByHash", reflect.TypeOf((*MockUserRepositoryInterface)(nil).GetLoginChallengeByHash), ctx, tokenHash)
+}
+
+// GetLoginThrottle mocks base method.
+func (m *MockUserRepositoryInterface) GetLoginThrottle(ctx context.Context, key LoginThrottleKey) (LoginThrottle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginThrottle", ctx, key)
+	ret0, _ := ret[0].(LoginThrottle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginThrottle indicates an expected call of GetLoginThrottle.
+func (mr *MockUserRepositoryInterfaceMockRecorder) GetLoginThrottle(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginThrottle", reflect.TypeOf((*MockUserRepositoryInterface)(nil).GetLoginThrottle), ctx, key)
+}
+
+// GetPasswordHistory mocks base method.
+func (m *MockUserRepositoryInterface) GetPasswordHistory(ctx context.Context, userID string, limit int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPasswordHistory", ctx, userID, limit)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPasswordHistory indicates an expected call of GetPasswordHistory.
+func (mr *MockUserRepositoryInterfaceMockRecorder) GetPasswordHistory(ctx, userID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPasswordHistory", reflect.TypeOf((*MockUserRepositoryInterface)(nil).GetPasswordHistory), ctx, userID, limit)
+}
+
+// GetPasswordResetTokenByHash mocks base method.
+func (m *MockUserRepositoryInterface) GetPasswordResetTokenByHash(ctx context.Context, tokenHash string) (PasswordResetToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPasswordResetTokenByHash", ctx, tokenHash)
+	ret0, _ := ret[0].(PasswordResetToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPasswordResetTokenByHash indicates an expected call of GetPasswordResetTokenByHash.
+func (mr *MockUserRepositoryInterfaceMockRecorder) GetPasswordResetTokenByHash(ctx, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPasswordResetTokenByHash", reflect.TypeOf((*MockUserRepositoryInterface)(nil).GetPasswordResetTokenByHash), ctx, tokenHash)
+}
+
+// GetRefreshTokenByHash mocks base method.
+func (m *MockUserRepositoryInterface) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRefreshTokenByHash", ctx, tokenHash)
+	ret0, _ := ret[0].(RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRefreshTokenByHash indicates an expected call of GetRefreshTokenByHash.
+func (mr *MockUserRepositoryInterfaceMockRecorder) GetRefreshTokenByHash(ctx, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRefreshTokenByHash", reflect.TypeOf((*MockUserRepositoryInterface)(nil).GetRefreshTokenByHash), ctx, tokenHash)
+}
+
+// GetTwoFactorSecret mocks base method.
+func (m *MockUserRepositoryInterface) GetTwoFactorSecret(ctx context.Context, userID string) (TwoFactorSecret, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTwoFactorSecret", ctx, userID)
+	ret0, _ := ret[0].(TwoFactorSecret)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTwoFactorSecret indicates an expected call of GetTwoFactorSecret.
+func (mr *MockUserRepositoryInterfaceMockRecorder) GetTwoFactorSecret(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTwoFactorSecret", reflect.TypeOf((*MockUserRepositoryInterface)(nil).GetTwoFactorSecret), ctx, userID)
+}
+
+// GetUserDetailsByID mocks base method.
+func (m *MockUserRepositoryInterface) GetUserDetailsByID(ctx context.Context, userID string) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserDetailsByID", ctx, userID)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserDetailsByID indicates an expected call of GetUserDetailsByID.
+func (mr *MockUserRepositoryInterfaceMockRecorder) GetUserDetailsByID(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserDetailsByID", reflect.TypeOf((*MockUserRepositoryInterface)(nil).GetUserDetailsByID), ctx, userID)
+}
+
+// GetUserDetailsByIdentity mocks base method.
+func (m *MockUserRepositoryInterface) GetUserDetailsByIdentity(ctx context.Context, issuer, subject string) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserDetailsByIdentity", ctx, issuer, subject)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserDetailsByIdentity indicates an expected call of GetUserDetailsByIdentity.
+func (mr *MockUserRepositoryInterfaceMockRecorder) GetUserDetailsByIdentity(ctx, issuer, subject any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserDetailsByIdentity", reflect.TypeOf((*MockUserRepositoryInterface)(nil).GetUserDetailsByIdentity), ctx, issuer, subject)
+}
+
 // GetUserDetailsByUsername mocks base method.
 func (m *MockUserRepositoryInterface) GetUserDetailsByUsername(ctx context.Context, username string) (models.User, error) {
 	m.ctrl.T.Helper()
@@ -86,6 +364,21 @@ func (mr *MockUserRepositoryInterfaceMockRecorder) GetUserRolesbyID(ctx, userID
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserRolesbyID", reflect.TypeOf((*MockUserRepositoryInterface)(nil).GetUserRolesbyID), ctx, userID)
 }
 
+// GetUsersEmploymentByIDs mocks base method.
+func (m *MockUserRepositoryInterface) GetUsersEmploymentByIDs(ctx context.Context, userIDs []string) ([]models.Employment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsersEmploymentByIDs", ctx, userIDs)
+	ret0, _ := ret[0].([]models.Employment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsersEmploymentByIDs indicates an expected call of GetUsersEmploymentByIDs.
+func (mr *MockUserRepositoryInterfaceMockRecorder) GetUsersEmploymentByIDs(ctx, userIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersEmploymentByIDs", reflect.TypeOf((*MockUserRepositoryInterface)(nil).GetUsersEmploymentByIDs), ctx, userIDs)
+}
+
 // GetUsersSalaryByIDs mocks base method.
 func (m *MockUserRepositoryInterface) GetUsersSalaryByIDs(ctx context.Context, userIDs []string) ([]models.UserSalary, error) {
 	m.ctrl.T.Helper()
@@ -116,6 +409,261 @@ func (mr *MockUserRepositoryInterfaceMockRecorder) IsAdmin(ctx, userID any) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsAdmin", reflect.TypeOf((*MockUserRepositoryInterface)(nil).IsAdmin), ctx, userID)
 }
 
+// IsTokenRevoked mocks base method.
+func (m *MockUserRepositoryInterface) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsTokenRevoked", ctx, jti)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsTokenRevoked indicates an expected call of IsTokenRevoked.
+func (mr *MockUserRepositoryInterfaceMockRecorder) IsTokenRevoked(ctx, jti any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsTokenRevoked", reflect.TypeOf((*MockUserRepositoryInterface)(nil).IsTokenRevoked), ctx, jti)
+}
+
+// LinkIdentity mocks base method.
+func (m *MockUserRepositoryInterface) LinkIdentity(ctx context.Context, data UserIdentity) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LinkIdentity", ctx, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LinkIdentity indicates an expected call of LinkIdentity.
+func (mr *MockUserRepositoryInterfaceMockRecorder) LinkIdentity(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkIdentity", reflect.TypeOf((*MockUserRepositoryInterface)(nil).LinkIdentity), ctx, data)
+}
+
+// LockLogin mocks base method.
+func (m *MockUserRepositoryInterface) LockLogin(ctx context.Context, key LoginThrottleKey, until time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockLogin", ctx, key, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockLogin indicates an expected call of LockLogin.
+func (mr *MockUserRepositoryInterfaceMockRecorder) LockLogin(ctx, key, until any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockLogin", reflect.TypeOf((*MockUserRepositoryInterface)(nil).LockLogin), ctx, key, until)
+}
+
+// RecordLoginFailure mocks base method.
+func (m *MockUserRepositoryInterface) RecordLoginFailure(ctx context.Context, key LoginThrottleKey, window time.Duration) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordLoginFailure", ctx, key, window)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordLoginFailure indicates an expected call of RecordLoginFailure.
+func (mr *MockUserRepositoryInterfaceMockRecorder) RecordLoginFailure(ctx, key, window any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLoginFailure", reflect.TypeOf((*MockUserRepositoryInterface)(nil).RecordLoginFailure), ctx, key, window)
+}
+
+// ResetPassword mocks base method.
+func (m *MockUserRepositoryInterface) ResetPassword(ctx context.Context, tokenID string, data PasswordChange) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", ctx, tokenID, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockUserRepositoryInterfaceMockRecorder) ResetPassword(ctx, tokenID, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockUserRepositoryInterface)(nil).ResetPassword), ctx, tokenID, data)
+}
+
+// RevokeSession mocks base method.
+func (m *MockUserRepositoryInterface) RevokeSession(ctx context.Context, sessionID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", ctx, sessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockUserRepositoryInterfaceMockRecorder) RevokeSession(ctx, sessionID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockUserRepositoryInterface)(nil).RevokeSession), ctx, sessionID)
+}
+
+// RevokeToken mocks base method.
+func (m *MockUserRepositoryInterface) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeToken", ctx, jti, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeToken indicates an expected call of RevokeToken.
+func (mr *MockUserRepositoryInterfaceMockRecorder) RevokeToken(ctx, jti, expiresAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeToken", reflect.TypeOf((*MockUserRepositoryInterface)(nil).RevokeToken), ctx, jti, expiresAt)
+}
+
+// RotateRefreshToken mocks base method.
+func (m *MockUserRepositoryInterface) RotateRefreshToken(ctx context.Context, id string, next RefreshToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateRefreshToken", ctx, id, next)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RotateRefreshToken indicates an expected call of RotateRefreshToken.
+func (mr *MockUserRepositoryInterfaceMockRecorder) RotateRefreshToken(ctx, id, next any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateRefreshToken", reflect.TypeOf((*MockUserRepositoryInterface)(nil).RotateRefreshToken), ctx, id, next)
+}
+
+// SaveTwoFactorSecret mocks base method.
+func (m *MockUserRepositoryInterface) SaveTwoFactorSecret(ctx context.Context, data TwoFactorSecret) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveTwoFactorSecret", ctx, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveTwoFactorSecret indicates an expected call of SaveTwoFactorSecret.
+func (mr *MockUserRepositoryInterfaceMockRecorder) SaveTwoFactorSecret(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveTwoFactorSecret", reflect.TypeOf((*MockUserRepositoryInterface)(nil).SaveTwoFactorSecret), ctx, data)
+}
+
+// SetTwoFactorRequired mocks base method.
+func (m *MockUserRepositoryInterface) SetTwoFactorRequired(ctx context.Context, userID string, required bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetTwoFactorRequired", ctx, userID, required)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetTwoFactorRequired indicates an expected call of SetTwoFactorRequired.
+func (mr *MockUserRepositoryInterfaceMockRecorder) SetTwoFactorRequired(ctx, userID, required any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTwoFactorRequired", reflect.TypeOf((*MockUserRepositoryInterface)(nil).SetTwoFactorRequired), ctx, userID, required)
+}
+
+// UnlockAccount mocks base method.
+func (m *MockUserRepositoryInterface) UnlockAccount(ctx context.Context, username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnlockAccount", ctx, username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnlockAccount indicates an expected call of UnlockAccount.
+func (mr *MockUserRepositoryInterfaceMockRecorder) UnlockAccount(ctx, username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnlockAccount", reflect.TypeOf((*MockUserRepositoryInterface)(nil).UnlockAccount), ctx, username)
+}
+
+// UpdateEmail mocks base method.
+func (m *MockUserRepositoryInterface) UpdateEmail(ctx context.Context, userID, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateEmail", ctx, userID, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateEmail indicates an expected call of UpdateEmail.
+func (mr *MockUserRepositoryInterfaceMockRecorder) UpdateEmail(ctx, userID, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEmail", reflect.TypeOf((*MockUserRepositoryInterface)(nil).UpdateEmail), ctx, userID, email)
+}
+
+// UpdateEmployment mocks base method.
+func (m *MockUserRepositoryInterface) UpdateEmployment(ctx context.Context, data models.Employment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateEmployment", ctx, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateEmployment indicates an expected call of UpdateEmployment.
+func (mr *MockUserRepositoryInterfaceMockRecorder) UpdateEmployment(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEmployment", reflect.TypeOf((*MockUserRepositoryInterface)(nil).UpdateEmployment), ctx, data)
+}
+
+// UpdatePassword mocks base method.
+func (m *MockUserRepositoryInterface) UpdatePassword(ctx context.Context, data PasswordChange) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockUserRepositoryInterfaceMockRecorder) UpdatePassword(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockUserRepositoryInterface)(nil).UpdatePassword), ctx, data)
+}
+
+// UpdateSalary mocks base method.
+func (m *MockUserRepositoryInterface) UpdateSalary(ctx context.Context, data models.UserSalary) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSalary", ctx, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSalary indicates an expected call of UpdateSalary.
+func (mr *MockUserRepositoryInterfaceMockRecorder) UpdateSalary(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSalary", reflect.TypeOf((*MockUserRepositoryInterface)(nil).UpdateSalary), ctx, data)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockUserRepositoryInterface) UseRecoveryCode(ctx context.Context, userID, codeHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", ctx, userID, codeHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockUserRepositoryInterfaceMockRecorder) UseRecoveryCode(ctx, userID, codeHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockUserRepositoryInterface)(nil).UseRecoveryCode), ctx, userID, codeHash)
+}
+
+// UseSSOState mocks base method.
+func (m *MockUserRepositoryInterface) UseSSOState(ctx context.Context, stateHash string) (SSOState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseSSOState", ctx, stateHash)
+	ret0, _ := ret[0].(SSOState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseSSOState indicates an expected call of UseSSOState.
+func (mr *MockUserRepositoryInterfaceMockRecorder) UseSSOState(ctx, stateHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseSSOState", reflect.TypeOf((*MockUserRepositoryInterface)(nil).UseSSOState), ctx, stateHash)
+}
+
+// UseTwoFactorStep mocks base method.
+func (m *MockUserRepositoryInterface) UseTwoFactorStep(ctx context.Context, userID string, step int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseTwoFactorStep", ctx, userID, step)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseTwoFactorStep indicates an expected call of UseTwoFactorStep.
+func (mr *MockUserRepositoryInterfaceMockRecorder) UseTwoFactorStep(ctx, userID, step any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseTwoFactorStep", reflect.TypeOf((*MockUserRepositoryInterface)(nil).UseTwoFactorStep), ctx, userID, step)
+}
+
 // MockUserLogicInterface is a mock of UserLogicInterface interface.
 type MockUserLogicInterface struct {
 	ctrl     *gomock.Controller
@@ -140,17 +688,281 @@ func (m *MockUserLogicInterface) EXPECT() *MockUserLogicInterfaceMockRecorder {
 	return m.recorder
 }
 
+// ChangePassword mocks base method.
+func (m *MockUserLogicInterface) ChangePassword(ctx context.Context, req ChangePasswordRequest) (LoginResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, req)
+	ret0, _ := ret[0].(LoginResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockUserLogicInterfaceMockRecorder) ChangePassword(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockUserLogicInterface)(nil).ChangePassword), ctx, req)
+}
+
+// CompleteSSOLogin mocks base method.
+func (m *MockUserLogicInterface) CompleteSSOLogin(ctx context.Context, req SSOCallbackRequest) (LoginResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteSSOLogin", ctx, req)
+	ret0, _ := ret[0].(LoginResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteSSOLogin indicates an expected call of CompleteSSOLogin.
+func (mr *MockUserLogicInterfaceMockRecorder) CompleteSSOLogin(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteSSOLogin", reflect.TypeOf((*MockUserLogicInterface)(nil).CompleteSSOLogin), ctx, req)
+}
+
+// ConfirmTwoFactor mocks base method.
+func (m *MockUserLogicInterface) ConfirmTwoFactor(ctx context.Context, req ConfirmTwoFactorRequest) (TwoFactorConfirmation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmTwoFactor", ctx, req)
+	ret0, _ := ret[0].(TwoFactorConfirmation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmTwoFactor indicates an expected call of ConfirmTwoFactor.
+func (mr *MockUserLogicInterfaceMockRecorder) ConfirmTwoFactor(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTwoFactor", reflect.TypeOf((*MockUserLogicInterface)(nil).ConfirmTwoFactor), ctx, req)
+}
+
+// DisableTwoFactor mocks base method.
+func (m *MockUserLogicInterface) DisableTwoFactor(ctx context.Context, req DisableTwoFactorRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTwoFactor", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableTwoFactor indicates an expected call of DisableTwoFactor.
+func (mr *MockUserLogicInterfaceMockRecorder) DisableTwoFactor(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTwoFactor", reflect.TypeOf((*MockUserLogicInterface)(nil).DisableTwoFactor), ctx, req)
+}
+
+// EnrolTwoFactor mocks base method.
+func (m *MockUserLogicInterface) EnrolTwoFactor(ctx context.Context) (TwoFactorEnrolment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrolTwoFactor", ctx)
+	ret0, _ := ret[0].(TwoFactorEnrolment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnrolTwoFactor indicates an expected call of EnrolTwoFactor.
+func (mr *MockUserLogicInterfaceMockRecorder) EnrolTwoFactor(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrolTwoFactor", reflect.TypeOf((*MockUserLogicInterface)(nil).EnrolTwoFactor), ctx)
+}
+
 // Login mocks base method.
-func (m *MockUserLogicInterface) Login(ctx context.Context, username, password string) (LoginResponse, error) {
+func (m *MockUserLogicInterface) Login(ctx context.Context, companyCode, username, password string) (LoginResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Login", ctx, username, password)
+	ret := m.ctrl.Call(m, "Login", ctx, companyCode, username, password)
 	ret0, _ := ret[0].(LoginResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Login indicates an expected call of Login.
-func (mr *MockUserLogicInterfaceMockRecorder) Login(ctx, username, password any) *gomock.Call {
+func (mr *MockUserLogicInterfaceMockRecorder) Login(ctx, companyCode, username, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockUserLogicInterface)(nil).Login), ctx, companyCode, username, password)
+}
+
+// Logout mocks base method.
+func (m *MockUserLogicInterface) Logout(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logout", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Logout indicates an expected call of Logout.
+func (mr *MockUserLogicInterfaceMockRecorder) Logout(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockUserLogicInterface)(nil).Logout), ctx)
+}
+
+// RefreshToken mocks base method.
+func (m *MockUserLogicInterface) RefreshToken(ctx context.Context, refreshToken string) (LoginResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshToken", ctx, refreshToken)
+	ret0, _ := ret[0].(LoginResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshToken indicates an expected call of RefreshToken.
+func (mr *MockUserLogicInterfaceMockRecorder) RefreshToken(ctx, refreshToken any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshToken", reflect.TypeOf((*MockUserLogicInterface)(nil).RefreshToken), ctx, refreshToken)
+}
+
+// RequestPasswordReset mocks base method.
+func (m *MockUserLogicInterface) RequestPasswordReset(ctx context.Context, userID string) (PasswordResetResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestPasswordReset", ctx, userID)
+	ret0, _ := ret[0].(PasswordResetResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequestPasswordReset indicates an expected call of RequestPasswordReset.
+func (mr *MockUserLogicInterfaceMockRecorder) RequestPasswordReset(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestPasswordReset", reflect.TypeOf((*MockUserLogicInterface)(nil).RequestPasswordReset), ctx, userID)
+}
+
+// ResetPassword mocks base method.
+func (m *MockUserLogicInterface) ResetPassword(ctx context.Context, req ResetPasswordRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockUserLogicInterfaceMockRecorder) ResetPassword(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockUserLogicInterface)(nil).ResetPassword), ctx, req)
+}
+
+// ResetTwoFactor mocks base method.
+func (m *MockUserLogicInterface) ResetTwoFactor(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetTwoFactor", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetTwoFactor indicates an expected call of ResetTwoFactor.
+func (mr *MockUserLogicInterfaceMockRecorder) ResetTwoFactor(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetTwoFactor", reflect.TypeOf((*MockUserLogicInterface)(nil).ResetTwoFactor), ctx, userID)
+}
+
+// SetEmail mocks base method.
+func (m *MockUserLogicInterface) SetEmail(ctx context.Context, userID string, req EmailRequest) (UserEmail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetEmail", ctx, userID, req)
+	ret0, _ := ret[0].(UserEmail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetEmail indicates an expected call of SetEmail.
+func (mr *MockUserLogicInterfaceMockRecorder) SetEmail(ctx, userID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEmail", reflect.TypeOf((*MockUserLogicInterface)(nil).SetEmail), ctx, userID, req)
+}
+
+// SetEmployment mocks base method.
+func (m *MockUserLogicInterface) SetEmployment(ctx context.Context, userID string, req EmploymentRequest) (models.Employment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetEmployment", ctx, userID, req)
+	ret0, _ := ret[0].(models.Employment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetEmployment indicates an expected call of SetEmployment.
+func (mr *MockUserLogicInterfaceMockRecorder) SetEmployment(ctx, userID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEmployment", reflect.TypeOf((*MockUserLogicInterface)(nil).SetEmployment), ctx, userID, req)
+}
+
+// SetSalary mocks base method.
+func (m *MockUserLogicInterface) SetSalary(ctx context.Context, userID string, req SalaryRequest) (models.UserSalary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSalary", ctx, userID, req)
+	ret0, _ := ret[0].(models.UserSalary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetSalary indicates an expected call of SetSalary.
+func (mr *MockUserLogicInterfaceMockRecorder) SetSalary(ctx, userID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSalary", reflect.TypeOf((*MockUserLogicInterface)(nil).SetSalary), ctx, userID, req)
+}
+
+// SetTwoFactorRequirement mocks base method.
+func (m *MockUserLogicInterface) SetTwoFactorRequirement(ctx context.Context, userID string, req TwoFactorRequirementRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetTwoFactorRequirement", ctx, userID, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetTwoFactorRequirement indicates an expected call of SetTwoFactorRequirement.
+func (mr *MockUserLogicInterfaceMockRecorder) SetTwoFactorRequirement(ctx, userID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTwoFactorRequirement", reflect.TypeOf((*MockUserLogicInterface)(nil).SetTwoFactorRequirement), ctx, userID, req)
+}
+
+// StartSSOLogin mocks base method.
+func (m *MockUserLogicInterface) StartSSOLogin(ctx context.Context) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartSSOLogin", ctx)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartSSOLogin indicates an expected call of StartSSOLogin.
+func (mr *MockUserLogicInterfaceMockRecorder) StartSSOLogin(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartSSOLogin", reflect.TypeOf((*MockUserLogicInterface)(nil).StartSSOLogin), ctx)
+}
+
+// TerminateEmployment mocks base method.
+func (m *MockUserLogicInterface) TerminateEmployment(ctx context.Context, userID string, req TerminationRequest) (models.Employment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TerminateEmployment", ctx, userID, req)
+	ret0, _ := ret[0].(models.Employment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TerminateEmployment indicates an expected call of TerminateEmployment.
+func (mr *MockUserLogicInterfaceMockRecorder) TerminateEmployment(ctx, userID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TerminateEmployment", reflect.TypeOf((*MockUserLogicInterface)(nil).TerminateEmployment), ctx, userID, req)
+}
+
+// UnlockUser mocks base method.
+func (m *MockUserLogicInterface) UnlockUser(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnlockUser", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnlockUser indicates an expected call of UnlockUser.
+func (mr *MockUserLogicInterfaceMockRecorder) UnlockUser(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnlockUser", reflect.TypeOf((*MockUserLogicInterface)(nil).UnlockUser), ctx, userID)
+}
+
+// VerifyTwoFactorLogin mocks base method.
+func (m *MockUserLogicInterface) VerifyTwoFactorLogin(ctx context.Context, req TwoFactorLoginRequest) (LoginResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyTwoFactorLogin", ctx, req)
+	ret0, _ := ret[0].(LoginResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyTwoFactorLogin indicates an expected call of VerifyTwoFactorLogin.
+func (mr *MockUserLogicInterfaceMockRecorder) VerifyTwoFactorLogin(ctx, req any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockUserLogicInterface)(nil).Login), ctx, username, password)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyTwoFactorLogin", reflect.TypeOf((*MockUserLogicInterface)(nil).VerifyTwoFactorLogin), ctx, req)
 }
@@ -2,6 +2,7 @@ package user
 
 import (
 	"database/sql"
+	"time"
 )
 
 type SQLUser struct {
@@ -15,18 +16,266 @@ type SQLUser struct {
 	DeletedAt sql.NullTime   `db:"deleted_at"`
 	CreatedBy sql.NullString `db:"created_by"`
 	UpdatedBy sql.NullString `db:"updated_by"`
+
+	MustChangePassword sql.NullBool `db:"must_change_password"`
+	TwoFactorRequired  sql.NullBool `db:"two_factor_required"`
+	TwoFactorEnabled   sql.NullBool `db:"two_factor_enabled"`
 }
 
 type LoginRequest struct {
+	Company  string `json:"company"` // company code, the default company when empty
 	Username string `json:"username"`
 	Password string `json:"password"`
 }
 
 type LoginResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`         // short lived access token
+	ExpiresIn    int    `json:"expires_in"`    // access token lifetime in seconds
+	RefreshToken string `json:"refresh_token"` // single use, a new one is issued on every refresh
+
+	// set instead of the tokens for users with two-factor authentication, the login is completed with it and a code
+	ChallengeToken string `json:"challenge_token,omitempty"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RefreshToken is a stored refresh token, only its SHA-256 hash is kept.
+// The tokens of a login session share the session ID, which access tokens carry in their sid claim.
+type RefreshToken struct {
+	ID         string
+	CompanyID  string
+	UserID     string
+	SessionID  string
+	TokenHash  string
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+	ReplacedBy string
+}
+
+type SQLRefreshToken struct {
+	ID         sql.NullString `db:"id"`
+	CompanyID  sql.NullString `db:"company_id"`
+	UserID     sql.NullString `db:"user_id"`
+	SessionID  sql.NullString `db:"session_id"`
+	TokenHash  sql.NullString `db:"token_hash"`
+	ExpiresAt  sql.NullTime   `db:"expires_at"`
+	RevokedAt  sql.NullTime   `db:"revoked_at"`
+	ReplacedBy sql.NullString `db:"replaced_by"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token"` // one-time token delivered to the user by email
+	NewPassword string `json:"new_password"`
+}
+
+type PasswordResetResponse struct {
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// PasswordChange replaces the user's password, the old one goes to the password history
+type PasswordChange struct {
+	UserID             string
+	OldPassword        string
+	NewPassword        string
+	MustChangePassword bool
+}
+
+// PasswordResetToken is a stored one-time password reset token, only its SHA-256 hash is kept
+type PasswordResetToken struct {
+	ID        string
+	CompanyID string
+	UserID    string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+type SQLPasswordResetToken struct {
+	ID        sql.NullString `db:"id"`
+	CompanyID sql.NullString `db:"company_id"`
+	UserID    sql.NullString `db:"user_id"`
+	TokenHash sql.NullString `db:"token_hash"`
+	ExpiresAt sql.NullTime   `db:"expires_at"`
+	UsedAt    sql.NullTime   `db:"used_at"`
+}
+
+const (
+	LoginThrottleAccount = "account"
+	LoginThrottleIP      = "ip"
+)
+
+// LoginThrottleKey identifies whose failed login attempts are counted, the lowercased username within the login
+// company code for accounts and the client IP (with an empty company code) for IPs
+type LoginThrottleKey struct {
+	Scope       string
+	CompanyCode string
+	Subject     string
+}
+
+type LoginThrottle struct {
+	LoginThrottleKey
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+}
+
+type SQLLoginThrottle struct {
+	Scope         sql.NullString `db:"scope"`
+	CompanyCode   sql.NullString `db:"company_code"`
+	Subject       sql.NullString `db:"subject"`
+	Failures      sql.NullInt64  `db:"failures"`
+	LastFailureAt sql.NullTime   `db:"last_failure_at"`
+	LockedUntil   sql.NullTime   `db:"locked_until"`
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"` // code of the authenticator app or a recovery code
+}
+
+type TwoFactorEnrolment struct {
+	Secret          string `json:"secret"`           // base32 secret, for entering it into the authenticator app by hand
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth URI, usually shown as a QR code
+}
+
+type ConfirmTwoFactorRequest struct {
+	Code string `json:"code"`
+}
+
+// TwoFactorConfirmation is the new session of the user along with the recovery codes, which are only shown once
+type TwoFactorConfirmation struct {
+	LoginResponse
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"` // code of the authenticator app or a recovery code
+}
+
+type TwoFactorRequirementRequest struct {
+	Required bool `json:"required"`
+}
+
+// TwoFactorSecret is the TOTP secret of the user encrypted with the JWT secret key, it's enabled once confirmed
+type TwoFactorSecret struct {
+	UserID       string
+	Secret       []byte
+	ConfirmedAt  *time.Time
+	LastUsedStep int64
+}
+
+type SQLTwoFactorSecret struct {
+	UserID       sql.NullString `db:"user_id"`
+	Secret       []byte         `db:"secret"`
+	ConfirmedAt  sql.NullTime   `db:"confirmed_at"`
+	LastUsedStep sql.NullInt64  `db:"last_used_step"`
+}
+
+// LoginChallenge is a stored login waiting for the second factor, only the SHA-256 hash of its token is kept
+type LoginChallenge struct {
+	ID          string
+	CompanyID   string
+	CompanyCode string // login company code, for the failed attempts of the account
+	UserID      string
+	TokenHash   string
+	ExpiresAt   time.Time
+	UsedAt      *time.Time
+}
+
+type SQLLoginChallenge struct {
+	ID          sql.NullString `db:"id"`
+	CompanyID   sql.NullString `db:"company_id"`
+	CompanyCode sql.NullString `db:"company_code"`
+	UserID      sql.NullString `db:"user_id"`
+	TokenHash   sql.NullString `db:"token_hash"`
+	ExpiresAt   sql.NullTime   `db:"expires_at"`
+	UsedAt      sql.NullTime   `db:"used_at"`
+}
+
+// SSOCallbackRequest is what the identity provider redirects back with, the error instead of the code when the
+// sign-in failed there
+type SSOCallbackRequest struct {
+	Code             string
+	State            string
+	Error            string
+	ErrorDescription string
+}
+
+// SSOState is a stored single sign-in waiting for the identity provider to redirect back, only the SHA-256 hash of
+// the state is kept
+type SSOState struct {
+	ID           string
+	StateHash    string
+	Nonce        string
+	CodeVerifier string // PKCE verifier the code is exchanged with
+	ExpiresAt    time.Time
+}
+
+type SQLSSOState struct {
+	ID           sql.NullString `db:"id"`
+	StateHash    sql.NullString `db:"state_hash"`
+	Nonce        sql.NullString `db:"nonce"`
+	CodeVerifier sql.NullString `db:"code_verifier"`
+	ExpiresAt    sql.NullTime   `db:"expires_at"`
+}
+
+// UserIdentity links an identity provider account to the user
+type UserIdentity struct {
+	UserID  string
+	Issuer  string
+	Subject string
+	Email   string
 }
 
 type SQLUserSalary struct {
-	ID     sql.NullString
-	Salary sql.NullFloat64
+	ID             sql.NullString
+	Salary         sql.NullFloat64
+	Currency       sql.NullString `db:"salary_currency"`
+	PayoutCurrency sql.NullString `db:"payout_currency"`
+}
+
+type EmailRequest struct {
+	Email string `json:"email"` // removed when empty
+}
+
+type UserEmail struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+type SalaryRequest struct {
+	Salary         *float64 `json:"salary"`          // unchanged when omitted
+	Currency       string   `json:"currency"`        // ISO 4217, unchanged when empty
+	PayoutCurrency *string  `json:"payout_currency"` // ISO 4217, unchanged when omitted, salary currency when empty
+}
+
+type EmploymentRequest struct {
+	Status          string  `json:"status"`
+	StartDate       string  `json:"start_date"`        // YYYY-MM-DD, empty when unknown
+	EndDate         string  `json:"end_date"`          // YYYY-MM-DD, empty while still employed
+	AnnualLeaveDays *int    `json:"annual_leave_days"` // unchanged when omitted
+	Timezone        *string `json:"timezone"`          // IANA timezone, unchanged when omitted, company timezone when empty
+}
+
+type TerminationRequest struct {
+	TerminationDate string `json:"termination_date"` // YYYY-MM-DD, last employed day
+	Reason          string `json:"reason"`
+}
+
+type SQLEmployment struct {
+	UserID            sql.NullString `db:"id"`
+	Status            sql.NullString `db:"employment_status"`
+	StartDate         sql.NullTime   `db:"employment_start_date"`
+	EndDate           sql.NullTime   `db:"employment_end_date"`
+	AnnualLeaveDays   sql.NullInt64  `db:"annual_leave_days"`
+	TerminationReason sql.NullString `db:"termination_reason"`
+	Timezone          sql.NullString `db:"timezone"`
 }
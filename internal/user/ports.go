@@ -2,18 +2,75 @@ package user
 
 import (
 	"context"
+	"time"
 
 	"github.com/rahadianir/dealls/internal/models"
 )
 
 type UserRepositoryInterface interface {
 	GetUserDetailsByUsername(ctx context.Context, username string) (models.User, error)
+	GetUserDetailsByID(ctx context.Context, userID string) (models.User, error)
 	GetUserRolesbyID(ctx context.Context, userID string) ([]string, error)
 	GetAdminRole(ctx context.Context) (string, error)
 	IsAdmin(ctx context.Context, userID string) (bool, error)
+	GetAdminUsernames(ctx context.Context) ([]string, error)
 	GetUsersSalaryByIDs(ctx context.Context, userIDs []string) ([]models.UserSalary, error)
+	GetEligibleUserIDs(ctx context.Context, payGroupID string, statuses []string, start time.Time, end time.Time, afterUserID string, limit int) ([]string, error)
+	CountEligibleUsers(ctx context.Context, payGroupID string, statuses []string, start time.Time, end time.Time) (int, error)
+	GetUsersEmploymentByIDs(ctx context.Context, userIDs []string) ([]models.Employment, error)
+	UpdateEmployment(ctx context.Context, data models.Employment) error
+	UpdateSalary(ctx context.Context, data models.UserSalary) error
+	UpdateEmail(ctx context.Context, userID string, email string) error
+	CreateRefreshToken(ctx context.Context, data RefreshToken) error
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, id string, next RefreshToken) error
+	RevokeSession(ctx context.Context, sessionID string) error
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+	GetPasswordHistory(ctx context.Context, userID string, limit int) ([]string, error)
+	UpdatePassword(ctx context.Context, data PasswordChange) error
+	CreatePasswordResetToken(ctx context.Context, data PasswordResetToken) error
+	GetPasswordResetTokenByHash(ctx context.Context, tokenHash string) (PasswordResetToken, error)
+	ResetPassword(ctx context.Context, tokenID string, data PasswordChange) error
+	GetLoginThrottle(ctx context.Context, key LoginThrottleKey) (LoginThrottle, error)
+	RecordLoginFailure(ctx context.Context, key LoginThrottleKey, window time.Duration) (int, error)
+	LockLogin(ctx context.Context, key LoginThrottleKey, until time.Time) error
+	ClearLoginThrottle(ctx context.Context, key LoginThrottleKey) error
+	UnlockAccount(ctx context.Context, username string) error
+	GetTwoFactorSecret(ctx context.Context, userID string) (TwoFactorSecret, error)
+	SaveTwoFactorSecret(ctx context.Context, data TwoFactorSecret) error
+	EnableTwoFactor(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) error
+	UseTwoFactorStep(ctx context.Context, userID string, step int64) error
+	UseRecoveryCode(ctx context.Context, userID string, codeHash string) error
+	DeleteTwoFactor(ctx context.Context, userID string) error
+	SetTwoFactorRequired(ctx context.Context, userID string, required bool) error
+	CreateLoginChallenge(ctx context.Context, data LoginChallenge) error
+	GetLoginChallengeByHash(ctx context.Context, tokenHash string) (LoginChallenge, error)
+	CompleteLoginChallenge(ctx context.Context, id string) error
+	CreateSSOState(ctx context.Context, data SSOState) error
+	UseSSOState(ctx context.Context, stateHash string) (SSOState, error)
+	GetUserDetailsByIdentity(ctx context.Context, issuer string, subject string) (models.User, error)
+	LinkIdentity(ctx context.Context, data UserIdentity) error
 }
 
 type UserLogicInterface interface {
-	Login(ctx context.Context, username string, password string) (LoginResponse, error)
+	Login(ctx context.Context, companyCode string, username string, password string) (LoginResponse, error)
+	RefreshToken(ctx context.Context, refreshToken string) (LoginResponse, error)
+	Logout(ctx context.Context) error
+	ChangePassword(ctx context.Context, req ChangePasswordRequest) (LoginResponse, error)
+	RequestPasswordReset(ctx context.Context, userID string) (PasswordResetResponse, error)
+	ResetPassword(ctx context.Context, req ResetPasswordRequest) error
+	UnlockUser(ctx context.Context, userID string) error
+	VerifyTwoFactorLogin(ctx context.Context, req TwoFactorLoginRequest) (LoginResponse, error)
+	EnrolTwoFactor(ctx context.Context) (TwoFactorEnrolment, error)
+	ConfirmTwoFactor(ctx context.Context, req ConfirmTwoFactorRequest) (TwoFactorConfirmation, error)
+	DisableTwoFactor(ctx context.Context, req DisableTwoFactorRequest) error
+	SetTwoFactorRequirement(ctx context.Context, userID string, req TwoFactorRequirementRequest) error
+	ResetTwoFactor(ctx context.Context, userID string) error
+	StartSSOLogin(ctx context.Context) (string, error)
+	CompleteSSOLogin(ctx context.Context, req SSOCallbackRequest) (LoginResponse, error)
+	SetEmployment(ctx context.Context, userID string, req EmploymentRequest) (models.Employment, error)
+	TerminateEmployment(ctx context.Context, userID string, req TerminationRequest) (models.Employment, error)
+	SetSalary(ctx context.Context, userID string, req SalaryRequest) (models.UserSalary, error)
+	SetEmail(ctx context.Context, userID string, req EmailRequest) (UserEmail, error)
 }
@@ -4,12 +4,18 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"log/slog"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/huandu/go-sqlbuilder"
 	"github.com/rahadianir/dealls/internal/config"
 	"github.com/rahadianir/dealls/internal/models"
 	"github.com/rahadianir/dealls/internal/pkg/dbhelper"
+	"github.com/rahadianir/dealls/internal/pkg/xcontext"
+	"github.com/rahadianir/dealls/internal/pkg/xdate"
 	"github.com/rahadianir/dealls/internal/pkg/xerror"
 )
 
@@ -25,11 +31,12 @@ func NewUserRepository(deps *config.CommonDependencies) *UserRepository {
 
 func (repo *UserRepository) GetUserDetailsByUsername(ctx context.Context, username string) (models.User, error) {
 	sq := sqlbuilder.NewSelectBuilder()
-	sq.Select(`id`, `name`, `username`, `password`, `salary`, `created_at`, `updated_at`, `deleted_at`, `created_by`, `updated_by`).
+	sq.Select(`id`, `name`, `username`, `password`, `salary`, `created_at`, `updated_at`, `deleted_at`, `created_by`, `updated_by`, `must_change_password`, `two_factor_required`, twoFactorEnabledColumn).
 		From(`hr.users`).
 		Where(
 			sq.And(
 				sq.Equal(`username`, username),
+				sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
 				sq.IsNull(`deleted_at`),
 			),
 		)
@@ -45,20 +52,56 @@ func (repo *UserRepository) GetUserDetailsByUsername(ctx context.Context, userna
 		return models.User{}, err
 	}
 
-	user := models.User{
-		ID:        sqlUser.ID.String,
-		Name:      sqlUser.Name.String,
-		Username:  sqlUser.Username.String,
-		Password:  sqlUser.Password.String,
-		Salary:    sqlUser.Salary.Float64,
-		CreatedAt: sqlUser.CreatedAt.Time,
-		UpdatedAt: &sqlUser.DeletedAt.Time,
-		DeletedAt: &sqlUser.DeletedAt.Time,
-		CreatedBy: sqlUser.CreatedBy.String,
-		UpdatedBy: sqlUser.UpdatedBy.String,
+	return toUser(sqlUser), nil
+}
+
+func (repo *UserRepository) GetUserDetailsByID(ctx context.Context, userID string) (models.User, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`id`, `name`, `username`, `password`, `salary`, `created_at`, `updated_at`, `deleted_at`, `created_by`, `updated_by`, `must_change_password`, `two_factor_required`, twoFactorEnabledColumn).
+		From(`hr.users`).
+		Where(
+			sq.And(
+				sq.Equal(`id`, userID),
+				sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+				sq.IsNull(`deleted_at`),
+			),
+		)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	var sqlUser SQLUser
+	err := tx.QueryRowxContext(ctx, q, args...).StructScan(&sqlUser)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.User{}, xerror.ErrDataNotFound
+		}
+
+		return models.User{}, err
 	}
 
-	return user, nil
+	return toUser(sqlUser), nil
+}
+
+// twoFactorEnabledColumn tells whether the user has confirmed a two-factor secret
+const twoFactorEnabledColumn = `EXISTS (SELECT 1 FROM hr.two_factor_secrets t WHERE t.user_id = users.id AND t.confirmed_at IS NOT NULL) AS two_factor_enabled`
+
+func toUser(sqlUser SQLUser) models.User {
+	return models.User{
+		ID:                 sqlUser.ID.String,
+		Name:               sqlUser.Name.String,
+		Username:           sqlUser.Username.String,
+		Password:           sqlUser.Password.String,
+		Salary:             sqlUser.Salary.Float64,
+		CreatedAt:          sqlUser.CreatedAt.Time,
+		UpdatedAt:          &sqlUser.DeletedAt.Time,
+		DeletedAt:          &sqlUser.DeletedAt.Time,
+		CreatedBy:          sqlUser.CreatedBy.String,
+		UpdatedBy:          sqlUser.UpdatedBy.String,
+		MustChangePassword: sqlUser.MustChangePassword.Bool,
+		TwoFactorRequired:  sqlUser.TwoFactorRequired.Bool,
+		TwoFactorEnabled:   sqlUser.TwoFactorEnabled.Bool,
+	}
 }
 
 func (repo *UserRepository) GetUserRolesbyID(ctx context.Context, userID string) ([]string, error) {
@@ -68,6 +111,7 @@ func (repo *UserRepository) GetUserRolesbyID(ctx context.Context, userID string)
 		Where(
 			sq.And(
 				sq.Equal(`user_id`, userID),
+				sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
 				sq.IsNull(`deleted_at`),
 			),
 		)
@@ -100,6 +144,7 @@ func (repo *UserRepository) GetAdminRole(ctx context.Context) (string, error) {
 		Where(
 			sq.And(
 				sq.Equal(`name`, `admin`),
+				sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
 				sq.IsNull(`deleted_at`),
 			),
 		)
@@ -147,13 +192,53 @@ func (repo *UserRepository) IsAdmin(ctx context.Context, userID string) (bool, e
 	return false, nil
 }
 
+// GetAdminUsernames returns the usernames of admins, e.g. to be notified
+func (repo *UserRepository) GetAdminUsernames(ctx context.Context) ([]string, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`DISTINCT u.username`).
+		From(`hr.users u`).
+		Join(`hr.user_role_map m`, `m.user_id = u.id`, `m.deleted_at IS NULL`).
+		Join(`hr.roles r`, `r.id = m.role_id`, `r.deleted_at IS NULL`).
+		Where(
+			sq.And(
+				sq.Equal(`r.name`, `admin`),
+				sq.Equal(`u.company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+				sq.Equal(`r.company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+				sq.IsNull(`u.deleted_at`),
+			),
+		).
+		OrderBy(`u.username`)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	rows, err := tx.QueryxContext(ctx, q, args...)
+	if err != nil {
+		return []string{}, err
+	}
+	defer rows.Close()
+
+	var result []string
+	for rows.Next() {
+		var username string
+		err := rows.Scan(&username)
+		if err != nil {
+			return []string{}, err
+		}
+		result = append(result, username)
+	}
+
+	return result, rows.Err()
+}
+
 func (repo *UserRepository) GetUsersSalaryByIDs(ctx context.Context, userIDs []string) ([]models.UserSalary, error) {
 	sq := sqlbuilder.NewSelectBuilder()
-	sq.Select(`id`, `salary`).
+	sq.Select(`id`, `salary`, `salary_currency`, `payout_currency`).
 		From(`hr.users`).
 		Where(
 			sq.And(
 				sq.In(`id::text`, sqlbuilder.List(userIDs)),
+				sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
 				sq.IsNull(`deleted_at`),
 			),
 		)
@@ -176,10 +261,1150 @@ func (repo *UserRepository) GetUsersSalaryByIDs(ctx context.Context, userIDs []s
 		}
 
 		result = append(result, models.UserSalary{
-			UserID: temp.ID.String,
-			Salary: temp.Salary.Float64,
+			UserID:         temp.ID.String,
+			Salary:         temp.Salary.Float64,
+			Currency:       temp.Currency.String,
+			PayoutCurrency: temp.PayoutCurrency.String,
 		})
 	}
 
 	return result, nil
 }
+
+// GetEligibleUserIDs returns a page of the pay group's users eligible for a payslip in the period, ordered by id
+// and starting after afterUserID so the whole period can be read in bounded chunks
+func (repo *UserRepository) GetEligibleUserIDs(ctx context.Context, payGroupID string, statuses []string, start time.Time, end time.Time, afterUserID string, limit int) ([]string, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`id`).From(`hr.users`).Where(eligibleUsers(sq, xcontext.GetCompanyIDFromContext(ctx), payGroupID, statuses, start, end))
+	if afterUserID != "" {
+		sq.Where(sq.GreaterThan(`id`, afterUserID))
+	}
+	sq.OrderBy(`id`).Limit(limit)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	rows, err := tx.QueryxContext(ctx, q, args...)
+	if err != nil {
+		return []string{}, err
+	}
+	defer rows.Close()
+
+	result := make([]string, 0, limit)
+	for rows.Next() {
+		var userID string
+		err := rows.Scan(&userID)
+		if err != nil {
+			return []string{}, err
+		}
+		result = append(result, userID)
+	}
+
+	return result, rows.Err()
+}
+
+func (repo *UserRepository) CountEligibleUsers(ctx context.Context, payGroupID string, statuses []string, start time.Time, end time.Time) (int, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`count(*)`).From(`hr.users`).Where(eligibleUsers(sq, xcontext.GetCompanyIDFromContext(ctx), payGroupID, statuses, start, end))
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	var count int
+	err := tx.QueryRowxContext(ctx, q, args...).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// eligibleUsers matches users of the pay group with one of the statuses whose employment overlaps the period
+func eligibleUsers(sq *sqlbuilder.SelectBuilder, companyID string, payGroupID string, statuses []string, start time.Time, end time.Time) string {
+	return sq.And(
+		sq.Equal(`company_id`, companyID),
+		sq.Equal(`pay_group_id`, payGroupID),
+		sq.In(`employment_status`, sqlbuilder.List(statuses)),
+		sq.Or(
+			sq.IsNull(`employment_start_date`),
+			sq.LessThan(`employment_start_date`, xdate.Of(end)),
+		),
+		sq.Or(
+			sq.IsNull(`employment_end_date`),
+			sq.GreaterEqualThan(`employment_end_date`, xdate.Of(start)),
+		),
+		sq.IsNull(`deleted_at`),
+	)
+}
+
+func (repo *UserRepository) GetUsersEmploymentByIDs(ctx context.Context, userIDs []string) ([]models.Employment, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`id`, `employment_status`, `employment_start_date`, `employment_end_date`, `annual_leave_days`, `termination_reason`, `timezone`).
+		From(`hr.users`).
+		Where(
+			sq.And(
+				sq.In(`id::text`, sqlbuilder.List(userIDs)),
+				sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+				sq.IsNull(`deleted_at`),
+			),
+		)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	rows, err := tx.QueryxContext(ctx, q, args...)
+	if err != nil {
+		return []models.Employment{}, err
+	}
+	defer rows.Close()
+
+	var result []models.Employment
+	for rows.Next() {
+		var temp SQLEmployment
+		err := rows.StructScan(&temp)
+		if err != nil {
+			repo.deps.Logger.WarnContext(ctx, "failed to scan user employment", slog.Any("error", err))
+			continue
+		}
+
+		data := models.Employment{
+			UserID:            temp.UserID.String,
+			Status:            temp.Status.String,
+			AnnualLeaveDays:   int(temp.AnnualLeaveDays.Int64),
+			TerminationReason: temp.TerminationReason.String,
+			Timezone:          temp.Timezone.String,
+		}
+		if temp.StartDate.Valid {
+			data.StartDate = &temp.StartDate.Time
+		}
+		if temp.EndDate.Valid {
+			data.EndDate = &temp.EndDate.Time
+		}
+		result = append(result, data)
+	}
+
+	return result, nil
+}
+
+func (repo *UserRepository) UpdateEmployment(ctx context.Context, data models.Employment) error {
+	sq := sqlbuilder.NewUpdateBuilder()
+	sq.Update(`hr.users`).Set(
+		sq.Assign(`employment_status`, data.Status),
+		sq.Assign(`employment_start_date`, data.StartDate),
+		sq.Assign(`employment_end_date`, data.EndDate),
+		sq.Assign(`annual_leave_days`, data.AnnualLeaveDays),
+		sq.Assign(`termination_reason`, data.TerminationReason),
+		sq.Assign(`timezone`, sql.NullString{String: data.Timezone, Valid: data.Timezone != ""}),
+		`updated_at = now()`,
+		sq.Assign(`updated_by`, xcontext.GetUserIDFromContext(ctx)),
+	).Where(
+		sq.Equal(`id`, data.UserID),
+		sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+		sq.IsNull(`deleted_at`),
+	)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	res, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return xerror.ErrDataNotFound
+	}
+
+	return nil
+}
+
+func (repo *UserRepository) UpdateSalary(ctx context.Context, data models.UserSalary) error {
+	sq := sqlbuilder.NewUpdateBuilder()
+	sq.Update(`hr.users`).Set(
+		sq.Assign(`salary`, data.Salary),
+		sq.Assign(`salary_currency`, data.Currency),
+		sq.Assign(`payout_currency`, sql.NullString{String: data.PayoutCurrency, Valid: data.PayoutCurrency != ""}),
+		`updated_at = now()`,
+		sq.Assign(`updated_by`, xcontext.GetUserIDFromContext(ctx)),
+	).Where(
+		sq.Equal(`id`, data.UserID),
+		sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+		sq.IsNull(`deleted_at`),
+	)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	res, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return xerror.ErrDataNotFound
+	}
+
+	return nil
+}
+
+// UpdateEmail sets the email address of the user, an empty one removes it
+func (repo *UserRepository) UpdateEmail(ctx context.Context, userID string, email string) error {
+	sq := sqlbuilder.NewUpdateBuilder()
+	sq.Update(`hr.users`).Set(
+		sq.Assign(`email`, sql.NullString{String: email, Valid: email != ""}),
+		`updated_at = now()`,
+		sq.Assign(`updated_by`, xcontext.GetUserIDFromContext(ctx)),
+	).Where(
+		sq.Equal(`id`, userID),
+		sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+		sq.IsNull(`deleted_at`),
+	)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	res, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return xerror.ErrDataNotFound
+	}
+
+	return nil
+}
+
+func (repo *UserRepository) CreateRefreshToken(ctx context.Context, data RefreshToken) error {
+	sq := sqlbuilder.NewInsertBuilder()
+	sq.InsertInto(`hr.refresh_tokens`).
+		Cols(`id`, `company_id`, `user_id`, `session_id`, `token_hash`, `expires_at`, `created_at`).
+		Values(data.ID, xcontext.GetCompanyIDFromContext(ctx), data.UserID, data.SessionID, data.TokenHash, data.ExpiresAt, `now()`)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	_, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetRefreshTokenByHash looks the token up by its hash, which is what identifies the company of a refresh request,
+// so unlike the other queries it isn't scoped to the context company
+func (repo *UserRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (RefreshToken, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`id`, `company_id`, `user_id`, `session_id`, `token_hash`, `expires_at`, `revoked_at`, `replaced_by`).
+		From(`hr.refresh_tokens`).
+		Where(sq.Equal(`token_hash`, tokenHash))
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	var temp SQLRefreshToken
+	err := tx.QueryRowxContext(ctx, q, args...).StructScan(&temp)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return RefreshToken{}, xerror.ErrDataNotFound
+		}
+
+		return RefreshToken{}, err
+	}
+
+	result := RefreshToken{
+		ID:         temp.ID.String,
+		CompanyID:  temp.CompanyID.String,
+		UserID:     temp.UserID.String,
+		SessionID:  temp.SessionID.String,
+		TokenHash:  temp.TokenHash.String,
+		ExpiresAt:  temp.ExpiresAt.Time,
+		ReplacedBy: temp.ReplacedBy.String,
+	}
+	if temp.RevokedAt.Valid {
+		result.RevokedAt = &temp.RevokedAt.Time
+	}
+
+	return result, nil
+}
+
+// RotateRefreshToken revokes the token in favor of the next one, ErrDataNotFound is returned when the token
+// was already revoked, e.g. by a concurrent refresh with the same token
+func (repo *UserRepository) RotateRefreshToken(ctx context.Context, id string, next RefreshToken) error {
+	return dbhelper.WithTransaction(ctx, repo.deps.DB, func(ctx context.Context) error {
+		tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+		sq := sqlbuilder.NewUpdateBuilder()
+		sq.Update(`hr.refresh_tokens`).Set(
+			`revoked_at = now()`,
+			sq.Assign(`replaced_by`, next.ID),
+		).Where(
+			sq.Equal(`id`, id),
+			sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+			sq.IsNull(`revoked_at`),
+		)
+		q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+		res, err := tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			return err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return xerror.ErrDataNotFound
+		}
+
+		return repo.CreateRefreshToken(ctx, next)
+	})
+}
+
+// RevokeSession revokes the refresh tokens of the login session
+func (repo *UserRepository) RevokeSession(ctx context.Context, sessionID string) error {
+	sq := sqlbuilder.NewUpdateBuilder()
+	sq.Update(`hr.refresh_tokens`).Set(
+		`revoked_at = now()`,
+	).Where(
+		sq.Equal(`session_id`, sessionID),
+		sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+		sq.IsNull(`revoked_at`),
+	)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	_, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RevokeToken revokes the access token by its ID (jti), it's kept until the token would have expired anyway
+func (repo *UserRepository) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	sq := sqlbuilder.NewInsertBuilder()
+	sq.InsertInto(`hr.revoked_tokens`).
+		Cols(`jti`, `company_id`, `user_id`, `expires_at`, `revoked_at`).
+		Values(jti, xcontext.GetCompanyIDFromContext(ctx), xcontext.GetUserIDFromContext(ctx), expiresAt, `now()`).
+		SQL(`ON CONFLICT (jti) DO NOTHING`)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	_, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repo *UserRepository) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`count(*) > 0`).From(`hr.revoked_tokens`).Where(
+		sq.Equal(`jti`, jti),
+		sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+	)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	var revoked bool
+	err := tx.QueryRowxContext(ctx, q, args...).Scan(&revoked)
+	if err != nil {
+		return false, err
+	}
+
+	return revoked, nil
+}
+
+// GetPasswordHistory returns the latest previous password hashes of the user, newest first
+func (repo *UserRepository) GetPasswordHistory(ctx context.Context, userID string, limit int) ([]string, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`password`).From(`hr.password_history`).Where(
+		sq.Equal(`user_id`, userID),
+		sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+	).OrderBy(`created_at DESC`).Limit(limit)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	rows, err := tx.QueryxContext(ctx, q, args...)
+	if err != nil {
+		return []string{}, err
+	}
+	defer rows.Close()
+
+	var result []string
+	for rows.Next() {
+		var password string
+		err := rows.Scan(&password)
+		if err != nil {
+			return []string{}, err
+		}
+		result = append(result, password)
+	}
+
+	return result, rows.Err()
+}
+
+// UpdatePassword replaces the user's password and logs every session of the user out
+func (repo *UserRepository) UpdatePassword(ctx context.Context, data PasswordChange) error {
+	return dbhelper.WithTransaction(ctx, repo.deps.DB, func(ctx context.Context) error {
+		return repo.setPassword(ctx, data)
+	})
+}
+
+// CreatePasswordResetToken stores the reset token, replacing the unused ones issued before for the user
+func (repo *UserRepository) CreatePasswordResetToken(ctx context.Context, data PasswordResetToken) error {
+	return dbhelper.WithTransaction(ctx, repo.deps.DB, func(ctx context.Context) error {
+		tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+		db := sqlbuilder.NewDeleteBuilder()
+		db.DeleteFrom(`hr.password_reset_tokens`).Where(
+			db.Equal(`user_id`, data.UserID),
+			db.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+			db.IsNull(`used_at`),
+		)
+		q, args := db.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+		_, err := tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			return err
+		}
+
+		ib := sqlbuilder.NewInsertBuilder()
+		ib.InsertInto(`hr.password_reset_tokens`).
+			Cols(`id`, `company_id`, `user_id`, `token_hash`, `expires_at`, `created_at`, `created_by`).
+			Values(data.ID, xcontext.GetCompanyIDFromContext(ctx), data.UserID, data.TokenHash, data.ExpiresAt, `now()`, xcontext.GetUserIDFromContext(ctx))
+		q, args = ib.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+		_, err = tx.ExecContext(ctx, q, args...)
+		return err
+	})
+}
+
+// GetPasswordResetTokenByHash looks the token up by its hash, which is what identifies the company of a reset request,
+// so unlike the other queries it isn't scoped to the context company
+func (repo *UserRepository) GetPasswordResetTokenByHash(ctx context.Context, tokenHash string) (PasswordResetToken, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`id`, `company_id`, `user_id`, `token_hash`, `expires_at`, `used_at`).
+		From(`hr.password_reset_tokens`).
+		Where(sq.Equal(`token_hash`, tokenHash))
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	var temp SQLPasswordResetToken
+	err := tx.QueryRowxContext(ctx, q, args...).StructScan(&temp)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return PasswordResetToken{}, xerror.ErrDataNotFound
+		}
+
+		return PasswordResetToken{}, err
+	}
+
+	result := PasswordResetToken{
+		ID:        temp.ID.String,
+		CompanyID: temp.CompanyID.String,
+		UserID:    temp.UserID.String,
+		TokenHash: temp.TokenHash.String,
+		ExpiresAt: temp.ExpiresAt.Time,
+	}
+	if temp.UsedAt.Valid {
+		result.UsedAt = &temp.UsedAt.Time
+	}
+
+	return result, nil
+}
+
+// ResetPassword uses up the reset token and replaces the user's password, ErrDataNotFound is returned when the token
+// was already used or has expired meanwhile
+func (repo *UserRepository) ResetPassword(ctx context.Context, tokenID string, data PasswordChange) error {
+	return dbhelper.WithTransaction(ctx, repo.deps.DB, func(ctx context.Context) error {
+		tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+		sq := sqlbuilder.NewUpdateBuilder()
+		sq.Update(`hr.password_reset_tokens`).Set(
+			`used_at = now()`,
+		).Where(
+			sq.Equal(`id`, tokenID),
+			sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+			sq.IsNull(`used_at`),
+			`expires_at > now()`,
+		)
+		q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+		res, err := tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			return err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return xerror.ErrDataNotFound
+		}
+
+		return repo.setPassword(ctx, data)
+	})
+}
+
+// setPassword keeps the old password in the history, sets the new one and revokes the refresh tokens of the user,
+// it's meant to be run within a transaction
+func (repo *UserRepository) setPassword(ctx context.Context, data PasswordChange) error {
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+	companyID := xcontext.GetCompanyIDFromContext(ctx)
+
+	ib := sqlbuilder.NewInsertBuilder()
+	ib.InsertInto(`hr.password_history`).
+		Cols(`id`, `company_id`, `user_id`, `password`, `created_at`).
+		Values(uuid.NewString(), companyID, data.UserID, data.OldPassword, `now()`)
+	q, args := ib.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	_, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	ub := sqlbuilder.NewUpdateBuilder()
+	ub.Update(`hr.users`).Set(
+		ub.Assign(`password`, data.NewPassword),
+		ub.Assign(`must_change_password`, data.MustChangePassword),
+		`password_changed_at = now()`,
+		`updated_at = now()`,
+		ub.Assign(`updated_by`, xcontext.GetUserIDFromContext(ctx)),
+	).Where(
+		ub.Equal(`id`, data.UserID),
+		ub.Equal(`company_id`, companyID),
+		ub.IsNull(`deleted_at`),
+	)
+	q, args = ub.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	res, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return xerror.ErrDataNotFound
+	}
+
+	// every session logged in with the old password is logged out
+	return repo.revokeRefreshTokens(ctx, data.UserID)
+}
+
+// revokeRefreshTokens logs every session of the user out
+func (repo *UserRepository) revokeRefreshTokens(ctx context.Context, userID string) error {
+	rb := sqlbuilder.NewUpdateBuilder()
+	rb.Update(`hr.refresh_tokens`).Set(
+		`revoked_at = now()`,
+	).Where(
+		rb.Equal(`user_id`, userID),
+		rb.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+		rb.IsNull(`revoked_at`),
+	)
+	q, args := rb.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	_, err := tx.ExecContext(ctx, q, args...)
+	return err
+}
+
+// GetLoginThrottle returns the failed login attempts of the account or IP, the throttles are keyed by the login
+// company code instead of the context company, see LoginThrottleKey
+func (repo *UserRepository) GetLoginThrottle(ctx context.Context, key LoginThrottleKey) (LoginThrottle, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`scope`, `company_code`, `subject`, `failures`, `last_failure_at`, `locked_until`).
+		From(`hr.login_throttles`).
+		Where(
+			sq.Equal(`scope`, key.Scope),
+			sq.Equal(`company_code`, key.CompanyCode),
+			sq.Equal(`subject`, key.Subject),
+		)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	var temp SQLLoginThrottle
+	err := tx.QueryRowxContext(ctx, q, args...).StructScan(&temp)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return LoginThrottle{}, xerror.ErrDataNotFound
+		}
+
+		return LoginThrottle{}, err
+	}
+
+	result := LoginThrottle{
+		LoginThrottleKey: LoginThrottleKey{
+			Scope:       temp.Scope.String,
+			CompanyCode: temp.CompanyCode.String,
+			Subject:     temp.Subject.String,
+		},
+		Failures:      int(temp.Failures.Int64),
+		LastFailureAt: temp.LastFailureAt.Time,
+	}
+	if temp.LockedUntil.Valid {
+		result.LockedUntil = &temp.LockedUntil.Time
+	}
+
+	return result, nil
+}
+
+// RecordLoginFailure counts the failed attempt and returns the failures so far, the count restarts when the previous
+// failure is older than the window
+func (repo *UserRepository) RecordLoginFailure(ctx context.Context, key LoginThrottleKey, window time.Duration) (int, error) {
+	sq := sqlbuilder.NewInsertBuilder()
+	sq.InsertInto(`hr.login_throttles`).
+		Cols(`scope`, `company_code`, `subject`, `failures`, `last_failure_at`).
+		Values(key.Scope, key.CompanyCode, key.Subject, 1, `now()`).
+		SQL(`ON CONFLICT (scope, company_code, subject) DO UPDATE SET`).
+		SQL(fmt.Sprintf(`failures = CASE WHEN login_throttles.last_failure_at < now() - %s * interval '1 second' THEN 1 ELSE login_throttles.failures + 1 END,`, sq.Var(window.Seconds()))).
+		SQL(`last_failure_at = now()`).
+		Returning(`failures`)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	var failures int
+	err := tx.QueryRowxContext(ctx, q, args...).Scan(&failures)
+	if err != nil {
+		return 0, err
+	}
+
+	return failures, nil
+}
+
+func (repo *UserRepository) LockLogin(ctx context.Context, key LoginThrottleKey, until time.Time) error {
+	sq := sqlbuilder.NewUpdateBuilder()
+	sq.Update(`hr.login_throttles`).Set(
+		sq.Assign(`locked_until`, until),
+	).Where(
+		sq.Equal(`scope`, key.Scope),
+		sq.Equal(`company_code`, key.CompanyCode),
+		sq.Equal(`subject`, key.Subject),
+	)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	_, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repo *UserRepository) ClearLoginThrottle(ctx context.Context, key LoginThrottleKey) error {
+	db := sqlbuilder.NewDeleteBuilder()
+	db.DeleteFrom(`hr.login_throttles`).Where(
+		db.Equal(`scope`, key.Scope),
+		db.Equal(`company_code`, key.CompanyCode),
+		db.Equal(`subject`, key.Subject),
+	)
+	q, args := db.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	_, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UnlockAccount clears the failed login attempts of the username within the context company
+func (repo *UserRepository) UnlockAccount(ctx context.Context, username string) error {
+	db := sqlbuilder.NewDeleteBuilder()
+	db.DeleteFrom(`hr.login_throttles`).Where(
+		db.Equal(`scope`, LoginThrottleAccount),
+		fmt.Sprintf(`company_code = (SELECT code FROM hr.companies WHERE id = %s)`, db.Var(xcontext.GetCompanyIDFromContext(ctx))),
+		db.Equal(`subject`, strings.ToLower(username)),
+	)
+	q, args := db.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	_, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repo *UserRepository) GetTwoFactorSecret(ctx context.Context, userID string) (TwoFactorSecret, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`user_id`, `secret`, `confirmed_at`, `last_used_step`).
+		From(`hr.two_factor_secrets`).
+		Where(
+			sq.Equal(`user_id`, userID),
+			sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+		)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	var temp SQLTwoFactorSecret
+	err := tx.QueryRowxContext(ctx, q, args...).StructScan(&temp)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return TwoFactorSecret{}, xerror.ErrDataNotFound
+		}
+
+		return TwoFactorSecret{}, err
+	}
+
+	result := TwoFactorSecret{
+		UserID:       temp.UserID.String,
+		Secret:       temp.Secret,
+		LastUsedStep: temp.LastUsedStep.Int64,
+	}
+	if temp.ConfirmedAt.Valid {
+		result.ConfirmedAt = &temp.ConfirmedAt.Time
+	}
+
+	return result, nil
+}
+
+// SaveTwoFactorSecret stores the secret of a new enrolment, replacing an unconfirmed one. ErrDataNotFound is returned
+// when the user already has a confirmed secret.
+func (repo *UserRepository) SaveTwoFactorSecret(ctx context.Context, data TwoFactorSecret) error {
+	sq := sqlbuilder.NewInsertBuilder()
+	sq.InsertInto(`hr.two_factor_secrets`).
+		Cols(`user_id`, `company_id`, `secret`, `created_at`).
+		Values(data.UserID, xcontext.GetCompanyIDFromContext(ctx), data.Secret, `now()`).
+		SQL(`ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, created_at = EXCLUDED.created_at`).
+		SQL(`WHERE two_factor_secrets.confirmed_at IS NULL`)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	res, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return xerror.ErrDataNotFound
+	}
+
+	return nil
+}
+
+// EnableTwoFactor confirms the secret with the step of the first code, replaces the recovery codes and logs every
+// session of the user out. ErrDataNotFound is returned when it was confirmed meanwhile.
+func (repo *UserRepository) EnableTwoFactor(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) error {
+	return dbhelper.WithTransaction(ctx, repo.deps.DB, func(ctx context.Context) error {
+		tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+		companyID := xcontext.GetCompanyIDFromContext(ctx)
+
+		ub := sqlbuilder.NewUpdateBuilder()
+		ub.Update(`hr.two_factor_secrets`).Set(
+			`confirmed_at = now()`,
+			ub.Assign(`last_used_step`, step),
+		).Where(
+			ub.Equal(`user_id`, userID),
+			ub.Equal(`company_id`, companyID),
+			ub.IsNull(`confirmed_at`),
+		)
+		q, args := ub.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+		res, err := tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			return err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return xerror.ErrDataNotFound
+		}
+
+		err = repo.deleteRecoveryCodes(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if len(recoveryCodeHashes) > 0 {
+			ib := sqlbuilder.NewInsertBuilder()
+			ib.InsertInto(`hr.recovery_codes`).
+				Cols(`id`, `company_id`, `user_id`, `code_hash`, `created_at`)
+			for _, codeHash := range recoveryCodeHashes {
+				ib.Values(uuid.NewString(), companyID, userID, codeHash, `now()`)
+			}
+			q, args = ib.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+			_, err = tx.ExecContext(ctx, q, args...)
+			if err != nil {
+				return err
+			}
+		}
+
+		return repo.revokeRefreshTokens(ctx, userID)
+	})
+}
+
+// UseTwoFactorStep records the step of an accepted code, ErrDataNotFound is returned when a code of the step or a
+// later one was used before, so every code can only be used once
+func (repo *UserRepository) UseTwoFactorStep(ctx context.Context, userID string, step int64) error {
+	sq := sqlbuilder.NewUpdateBuilder()
+	sq.Update(`hr.two_factor_secrets`).Set(
+		sq.Assign(`last_used_step`, step),
+	).Where(
+		sq.Equal(`user_id`, userID),
+		sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+		sq.IsNotNull(`confirmed_at`),
+		sq.Or(
+			sq.IsNull(`last_used_step`),
+			sq.LessThan(`last_used_step`, step),
+		),
+	)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	res, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return xerror.ErrDataNotFound
+	}
+
+	return nil
+}
+
+// UseRecoveryCode uses up the recovery code, ErrDataNotFound is returned when there's no such unused code
+func (repo *UserRepository) UseRecoveryCode(ctx context.Context, userID string, codeHash string) error {
+	sq := sqlbuilder.NewUpdateBuilder()
+	sq.Update(`hr.recovery_codes`).Set(
+		`used_at = now()`,
+	).Where(
+		sq.Equal(`user_id`, userID),
+		sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+		sq.Equal(`code_hash`, codeHash),
+		sq.IsNull(`used_at`),
+	)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	res, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return xerror.ErrDataNotFound
+	}
+
+	return nil
+}
+
+// DeleteTwoFactor removes the secret and the recovery codes of the user, which disables two-factor authentication
+func (repo *UserRepository) DeleteTwoFactor(ctx context.Context, userID string) error {
+	return dbhelper.WithTransaction(ctx, repo.deps.DB, func(ctx context.Context) error {
+		tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+		err := repo.deleteRecoveryCodes(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		db := sqlbuilder.NewDeleteBuilder()
+		db.DeleteFrom(`hr.two_factor_secrets`).Where(
+			db.Equal(`user_id`, userID),
+			db.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+		)
+		q, args := db.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+		_, err = tx.ExecContext(ctx, q, args...)
+		return err
+	})
+}
+
+func (repo *UserRepository) deleteRecoveryCodes(ctx context.Context, userID string) error {
+	db := sqlbuilder.NewDeleteBuilder()
+	db.DeleteFrom(`hr.recovery_codes`).Where(
+		db.Equal(`user_id`, userID),
+		db.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+	)
+	q, args := db.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	_, err := tx.ExecContext(ctx, q, args...)
+	return err
+}
+
+func (repo *UserRepository) SetTwoFactorRequired(ctx context.Context, userID string, required bool) error {
+	sq := sqlbuilder.NewUpdateBuilder()
+	sq.Update(`hr.users`).Set(
+		sq.Assign(`two_factor_required`, required),
+		`updated_at = now()`,
+		sq.Assign(`updated_by`, xcontext.GetUserIDFromContext(ctx)),
+	).Where(
+		sq.Equal(`id`, userID),
+		sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+		sq.IsNull(`deleted_at`),
+	)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	res, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return xerror.ErrDataNotFound
+	}
+
+	return nil
+}
+
+func (repo *UserRepository) CreateLoginChallenge(ctx context.Context, data LoginChallenge) error {
+	sq := sqlbuilder.NewInsertBuilder()
+	sq.InsertInto(`hr.login_challenges`).
+		Cols(`id`, `company_id`, `company_code`, `user_id`, `token_hash`, `expires_at`, `created_at`).
+		Values(data.ID, xcontext.GetCompanyIDFromContext(ctx), data.CompanyCode, data.UserID, data.TokenHash, data.ExpiresAt, `now()`)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	_, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetLoginChallengeByHash looks the challenge up by its token hash, which is what identifies the company of the login,
+// so unlike the other queries it isn't scoped to the context company
+func (repo *UserRepository) GetLoginChallengeByHash(ctx context.Context, tokenHash string) (LoginChallenge, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`id`, `company_id`, `company_code`, `user_id`, `token_hash`, `expires_at`, `used_at`).
+		From(`hr.login_challenges`).
+		Where(sq.Equal(`token_hash`, tokenHash))
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	var temp SQLLoginChallenge
+	err := tx.QueryRowxContext(ctx, q, args...).StructScan(&temp)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return LoginChallenge{}, xerror.ErrDataNotFound
+		}
+
+		return LoginChallenge{}, err
+	}
+
+	result := LoginChallenge{
+		ID:          temp.ID.String,
+		CompanyID:   temp.CompanyID.String,
+		CompanyCode: temp.CompanyCode.String,
+		UserID:      temp.UserID.String,
+		TokenHash:   temp.TokenHash.String,
+		ExpiresAt:   temp.ExpiresAt.Time,
+	}
+	if temp.UsedAt.Valid {
+		result.UsedAt = &temp.UsedAt.Time
+	}
+
+	return result, nil
+}
+
+// CompleteLoginChallenge uses up the challenge, ErrDataNotFound is returned when it was already used or has expired
+// meanwhile
+func (repo *UserRepository) CompleteLoginChallenge(ctx context.Context, id string) error {
+	sq := sqlbuilder.NewUpdateBuilder()
+	sq.Update(`hr.login_challenges`).Set(
+		`used_at = now()`,
+	).Where(
+		sq.Equal(`id`, id),
+		sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+		sq.IsNull(`used_at`),
+		`expires_at > now()`,
+	)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	res, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return xerror.ErrDataNotFound
+	}
+
+	return nil
+}
+
+// CreateSSOState stores a started single sign-in, the company isn't known until the identity provider redirects back,
+// so unlike the other queries it isn't scoped to the context company
+func (repo *UserRepository) CreateSSOState(ctx context.Context, data SSOState) error {
+	sq := sqlbuilder.NewInsertBuilder()
+	sq.InsertInto(`hr.sso_states`).
+		Cols(`id`, `state_hash`, `nonce`, `code_verifier`, `expires_at`, `created_at`).
+		Values(data.ID, data.StateHash, data.Nonce, data.CodeVerifier, data.ExpiresAt, `now()`)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	_, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UseSSOState uses up the started single sign-in, ErrDataNotFound is returned when there's no such unused and
+// unexpired sign-in
+func (repo *UserRepository) UseSSOState(ctx context.Context, stateHash string) (SSOState, error) {
+	sq := sqlbuilder.NewUpdateBuilder()
+	sq.Update(`hr.sso_states`).Set(
+		`used_at = now()`,
+	).Where(
+		sq.Equal(`state_hash`, stateHash),
+		sq.IsNull(`used_at`),
+		`expires_at > now()`,
+	).SQL(`RETURNING id, state_hash, nonce, code_verifier, expires_at`)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	var temp SQLSSOState
+	err := tx.QueryRowxContext(ctx, q, args...).StructScan(&temp)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return SSOState{}, xerror.ErrDataNotFound
+		}
+
+		return SSOState{}, err
+	}
+
+	return SSOState{
+		ID:           temp.ID.String,
+		StateHash:    temp.StateHash.String,
+		Nonce:        temp.Nonce.String,
+		CodeVerifier: temp.CodeVerifier.String,
+		ExpiresAt:    temp.ExpiresAt.Time,
+	}, nil
+}
+
+// GetUserDetailsByIdentity returns the user the identity provider account is linked to
+func (repo *UserRepository) GetUserDetailsByIdentity(ctx context.Context, issuer string, subject string) (models.User, error) {
+	companyID := xcontext.GetCompanyIDFromContext(ctx)
+
+	identity := sqlbuilder.NewSelectBuilder()
+	identity.Select(`user_id`).
+		From(`hr.user_identities`).
+		Where(
+			identity.Equal(`issuer`, issuer),
+			identity.Equal(`subject`, subject),
+			identity.Equal(`company_id`, companyID),
+		)
+
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(`id`, `name`, `username`, `password`, `salary`, `created_at`, `updated_at`, `deleted_at`, `created_by`, `updated_by`, `must_change_password`, `two_factor_required`, twoFactorEnabledColumn).
+		From(`hr.users`).
+		Where(
+			sq.And(
+				sq.In(`id`, identity),
+				sq.Equal(`company_id`, companyID),
+				sq.IsNull(`deleted_at`),
+			),
+		)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	var sqlUser SQLUser
+	err := tx.QueryRowxContext(ctx, q, args...).StructScan(&sqlUser)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.User{}, xerror.ErrDataNotFound
+		}
+
+		return models.User{}, err
+	}
+
+	return toUser(sqlUser), nil
+}
+
+// LinkIdentity links the identity provider account to the user, an account already linked stays as is
+func (repo *UserRepository) LinkIdentity(ctx context.Context, data UserIdentity) error {
+	sq := sqlbuilder.NewInsertBuilder()
+	sq.InsertInto(`hr.user_identities`).
+		Cols(`id`, `company_id`, `user_id`, `issuer`, `subject`, `email`, `created_at`).
+		Values(uuid.NewString(), xcontext.GetCompanyIDFromContext(ctx), data.UserID, data.Issuer, data.Subject, data.Email, `now()`).
+		SQL(`ON CONFLICT (issuer, subject) DO NOTHING`)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	_, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/pkg/xerror"
+	"github.com/rahadianir/dealls/internal/pkg/xhttp"
+)
+
+type WebhookHandler struct {
+	deps         *config.CommonDependencies
+	webhookLogic WebhookLogicInterface
+}
+
+func NewWebhookHandler(deps *config.CommonDependencies, webhookLogic WebhookLogicInterface) *WebhookHandler {
+	return &WebhookHandler{
+		deps:         deps,
+		webhookLogic: webhookLogic,
+	}
+}
+
+func (handler *WebhookHandler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	var payload SubscriptionRequest
+	err := xhttp.BindJSONRequest(r, &payload)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: xerror.ErrBadRequest.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	result, err := handler.webhookLogic.CreateSubscription(r.Context(), payload)
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to create webhook subscription",
+		}, xerror.ParseErrorTypeToCodeInt(err))
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "webhook subscription created, store the secret now since it can't be shown again",
+		Data:    result,
+	}, http.StatusCreated)
+}
+
+func (handler *WebhookHandler) GetSubscriptions(w http.ResponseWriter, r *http.Request) {
+	result, err := handler.webhookLogic.GetSubscriptions(r.Context())
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to get webhook subscriptions",
+		}, xerror.ParseErrorTypeToCodeInt(err))
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "webhook subscriptions fetched",
+		Data:    result,
+	}, http.StatusOK)
+}
+
+func (handler *WebhookHandler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	err := handler.webhookLogic.DeleteSubscription(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		code := xerror.ParseErrorTypeToCodeInt(err)
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			code = http.StatusNotFound
+		}
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to delete webhook subscription",
+		}, code)
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "webhook subscription deleted",
+	}, http.StatusOK)
+}
+
+func (handler *WebhookHandler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	result, err := handler.webhookLogic.GetDeliveries(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+			Error:   err.Error(),
+			Message: "failed to get webhook deliveries",
+		}, xerror.ParseErrorTypeToCodeInt(err))
+		return
+	}
+
+	xhttp.SendJSONResponse(w, xhttp.BaseResponse{
+		Message: "webhook deliveries fetched",
+		Data:    result,
+	}, http.StatusOK)
+}
@@ -0,0 +1,390 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/pkg/xaudit"
+	"github.com/rahadianir/dealls/internal/pkg/xcontext"
+	"github.com/rahadianir/dealls/internal/pkg/xcrypto"
+	"github.com/rahadianir/dealls/internal/pkg/xerror"
+	"github.com/rahadianir/dealls/internal/pkg/xwebhook"
+	"github.com/rahadianir/dealls/internal/user"
+)
+
+// deliveriesLimit is how many of the latest delivery attempts of a subscription are shown
+const deliveriesLimit = 100
+
+type WebhookLogic struct {
+	deps        *config.CommonDependencies
+	webhookRepo WebhookRepositoryInterface
+	userRepo    user.UserRepositoryInterface
+	auditor     xaudit.Recorder
+	client      *http.Client
+	now         func() time.Time // putting it here so it's easier to be mocked/tested
+}
+
+func NewWebhookLogic(deps *config.CommonDependencies, webhookRepo WebhookRepositoryInterface, userRepo user.UserRepositoryInterface, auditor xaudit.Recorder) *WebhookLogic {
+	return &WebhookLogic{
+		deps:        deps,
+		webhookRepo: webhookRepo,
+		userRepo:    userRepo,
+		auditor:     auditor,
+		client:      newDeliveryClient(deps.Config.Webhook.Timeout, deps.Config.Webhook.AllowPrivateTargets),
+		now:         time.Now,
+	}
+}
+
+// CreateSubscription subscribes the URL to the events. The secret signing the payloads is only returned here.
+func (logic *WebhookLogic) CreateSubscription(ctx context.Context, req SubscriptionRequest) (CreatedSubscription, error) {
+	err := logic.checkAdmin(ctx)
+	if err != nil {
+		return CreatedSubscription{}, err
+	}
+
+	target, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return CreatedSubscription{}, xerror.ClientError{Err: fmt.Errorf("url must be an absolute http or https url")}
+	}
+
+	// host names are only resolved when delivering, where the dialer refuses private addresses too
+	if !logic.deps.Config.Webhook.AllowPrivateTargets && isPrivateHost(target.Hostname()) {
+		return CreatedSubscription{}, xerror.ClientError{Err: fmt.Errorf("url must not point to a loopback, link-local or private address")}
+	}
+
+	events, err := toValidEvents(req.Events)
+	if err != nil {
+		return CreatedSubscription{}, err
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to generate webhook secret", slog.Any("error", err))
+		return CreatedSubscription{}, err
+	}
+
+	// the secret has to be read back to sign the payloads, so it's encrypted rather than hashed
+	encrypted, err := xcrypto.Encrypt([]byte(secret), logic.deps.Config.App.EncryptionKey)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to encrypt webhook secret", slog.Any("error", err))
+		return CreatedSubscription{}, err
+	}
+
+	data := Subscription{
+		ID:              uuid.NewString(),
+		CompanyID:       xcontext.GetCompanyIDFromContext(ctx),
+		URL:             target.String(),
+		Events:          events,
+		EncryptedSecret: encrypted,
+		CreatedAt:       logic.now(),
+		CreatedBy:       xcontext.GetUserIDFromContext(ctx),
+	}
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionCreate,
+		Entity:   xaudit.EntityWebhookSubscription,
+		EntityID: data.ID,
+		After:    data,
+	}, func(ctx context.Context) error {
+		return logic.webhookRepo.CreateSubscription(ctx, data)
+	})
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to create webhook subscription", slog.Any("error", err))
+		return CreatedSubscription{}, err
+	}
+	logic.deps.Logger.InfoContext(ctx, "webhook subscription created", slog.String("subscription_id", data.ID), slog.Any("events", events))
+
+	return CreatedSubscription{Subscription: data, Secret: secret}, nil
+}
+
+func (logic *WebhookLogic) GetSubscriptions(ctx context.Context) ([]Subscription, error) {
+	err := logic.checkAdmin(ctx)
+	if err != nil {
+		return []Subscription{}, err
+	}
+
+	result, err := logic.webhookRepo.GetSubscriptions(ctx)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get webhook subscriptions", slog.Any("error", err))
+		return []Subscription{}, err
+	}
+
+	return result, nil
+}
+
+// DeleteSubscription stops the deliveries to the subscription, including the ones still being retried
+func (logic *WebhookLogic) DeleteSubscription(ctx context.Context, id string) error {
+	err := logic.checkAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = xaudit.Record(ctx, logic.auditor, xaudit.Entry{
+		Action:   xaudit.ActionDelete,
+		Entity:   xaudit.EntityWebhookSubscription,
+		EntityID: id,
+	}, func(ctx context.Context) error {
+		return logic.webhookRepo.DeleteSubscription(ctx, id)
+	})
+	if err != nil {
+		if errors.Is(err, xerror.ErrDataNotFound) {
+			return xerror.ClientError{Err: fmt.Errorf("webhook subscription %s not found: %w", id, err)}
+		}
+
+		logic.deps.Logger.ErrorContext(ctx, "failed to delete webhook subscription", slog.Any("error", err))
+		return err
+	}
+	logic.deps.Logger.InfoContext(ctx, "webhook subscription deleted", slog.String("subscription_id", id))
+
+	return nil
+}
+
+// GetDeliveries returns the delivery log of the subscription, the latest attempts first
+func (logic *WebhookLogic) GetDeliveries(ctx context.Context, subscriptionID string) ([]Delivery, error) {
+	err := logic.checkAdmin(ctx)
+	if err != nil {
+		return []Delivery{}, err
+	}
+
+	result, err := logic.webhookRepo.GetDeliveries(ctx, subscriptionID, deliveriesLimit)
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to get webhook deliveries", slog.Any("error", err))
+		return []Delivery{}, err
+	}
+
+	return result, nil
+}
+
+// DeliverMessage posts the message payload signed with the subscription secret and records the attempt.
+// A failed attempt is retried with an exponential backoff until WEBHOOK_MAX_ATTEMPTS is reached.
+func (logic *WebhookLogic) DeliverMessage(ctx context.Context, msg OutboxMessage) error {
+	cfg := logic.deps.Config.Webhook
+	start := logic.now()
+	delivery := Delivery{
+		ID:             uuid.NewString(),
+		MessageID:      msg.ID,
+		SubscriptionID: msg.SubscriptionID,
+		EventID:        msg.EventID,
+		EventType:      msg.EventType,
+		Attempt:        msg.Attempts + 1,
+		CreatedAt:      start,
+	}
+
+	statusCode, err := logic.post(ctx, msg, start)
+	delivery.StatusCode = statusCode
+	delivery.DurationMs = logic.now().Sub(start).Milliseconds()
+	switch {
+	case err == nil:
+		delivery.Status = DeliveryDelivered
+	case delivery.Attempt >= cfg.MaxAttempts:
+		delivery.Status = DeliveryFailed
+		delivery.Error = err.Error()
+	default:
+		delivery.Status = DeliveryRetrying
+		delivery.Error = err.Error()
+		nextAttemptAt := start.Add(retryBackoff(delivery.Attempt, cfg.RetryBackoff, cfg.RetryBackoffMax))
+		delivery.NextAttemptAt = &nextAttemptAt
+	}
+
+	// the attempt is recorded even when shutting down, so it isn't lost
+	recordErr := logic.webhookRepo.RecordDelivery(context.WithoutCancel(ctx), delivery)
+	if recordErr != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to record webhook delivery", slog.Any("error", recordErr))
+		return recordErr
+	}
+
+	if err != nil {
+		logic.deps.Logger.WarnContext(ctx, "failed to deliver webhook message", slog.String("message_id", msg.ID), slog.Int("attempt", delivery.Attempt), slog.Any("error", err))
+		return err
+	}
+
+	return nil
+}
+
+// post sends the payload, returning the response status code when there's one
+func (logic *WebhookLogic) post(ctx context.Context, msg OutboxMessage, now time.Time) (int, error) {
+	secret, err := xcrypto.Decrypt(msg.EncryptedSecret, logic.deps.Config.App.EncryptionKey)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decrypt webhook secret: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, msg.URL, bytes.NewReader(msg.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventID, msg.EventID)
+	req.Header.Set(HeaderEventType, msg.EventType)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(string(secret), timestamp, msg.Payload))
+
+	resp, err := logic.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// the response body isn't kept, the delivery log shouldn't echo what the url serves
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return resp.StatusCode, fmt.Errorf("webhook responded %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+func (logic *WebhookLogic) checkAdmin(ctx context.Context) error {
+	// check admin role of the user
+	isAdmin, err := logic.userRepo.IsAdmin(ctx, xcontext.GetUserIDFromContext(ctx))
+	if err != nil {
+		logic.deps.Logger.ErrorContext(ctx, "failed to check user admin role", slog.Any("error", err))
+		return err
+	}
+
+	if !isAdmin {
+		return xerror.AuthError{Err: fmt.Errorf("admin only operation")}
+	}
+
+	return nil
+}
+
+// errPrivateTarget is returned by the dialer of the deliveries for loopback, link-local and private addresses
+var errPrivateTarget = errors.New("webhook url resolves to a loopback, link-local or private address")
+
+// reservedPrefixes are the other ranges not reachable on the internet that netip doesn't tell apart
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // this network
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+}
+
+// newDeliveryClient returns the client posting the deliveries. Unless private targets are allowed, every connection
+// is checked once the host is resolved, so a public host name resolving (or rebinding) to an internal address and
+// redirects to one are refused as well.
+func newDeliveryClient(timeout time.Duration, allowPrivateTargets bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivateTargets {
+		dialer.Control = rejectPrivateAddress
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// no proxy, the dialer would only check the address of the proxy
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+}
+
+// rejectPrivateAddress is the dialer control hook, called with the resolved address right before connecting
+func rejectPrivateAddress(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+
+	if isPrivateAddr(addr) {
+		return fmt.Errorf("%w: %s", errPrivateTarget, addr)
+	}
+
+	return nil
+}
+
+// isPrivateHost reports whether the url host is localhost or a private IP address, other host names are checked
+// once they are resolved
+func isPrivateHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+
+	addr, err := netip.ParseAddr(host)
+	return err == nil && isPrivateAddr(addr)
+}
+
+func isPrivateAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() || addr.IsLinkLocalUnicast() || addr.IsMulticast() {
+		return true
+	}
+
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of "<timestamp>.<body>" keyed by the subscription secret,
+// receivers compute the same to check the payload came from us and wasn't replayed later
+func Sign(secret string, timestamp string, body []byte) string {
+	return xcrypto.Sign([]byte(timestamp+"."+string(body)), secret)
+}
+
+// retryBackoff is how long to wait after the failed attempt, doubled per attempt up to max
+func retryBackoff(attempt int, base time.Duration, max time.Duration) time.Duration {
+	backoff := base
+	for i := 1; i < attempt && backoff < max; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, max)
+}
+
+// toValidEvents checks the event types are known, returning them sorted without duplicates
+func toValidEvents(events []string) ([]string, error) {
+	result := []string{}
+	for _, event := range events {
+		event = strings.ToLower(strings.TrimSpace(event))
+		if !slices.Contains(xwebhook.EventTypes, event) {
+			return []string{}, xerror.ClientError{Err: fmt.Errorf("unknown event %q, valid events are %s", event, strings.Join(xwebhook.EventTypes, ", "))}
+		}
+		result = append(result, event)
+	}
+
+	if len(result) == 0 {
+		return []string{}, xerror.ClientError{Err: fmt.Errorf("at least one event is required")}
+	}
+
+	slices.Sort(result)
+	return slices.Compact(result), nil
+}
+
+// generateSecret generates a random 256-bit secret, prefixed so leaked secrets are easy to spot
+func generateSecret() (string, error) {
+	randBytes := make([]byte, 32)
+	_, err := rand.Read(randBytes)
+	if err != nil {
+		return "", err
+	}
+
+	return SecretPrefix + base64.RawURLEncoding.EncodeToString(randBytes), nil
+}
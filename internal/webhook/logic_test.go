@@ -0,0 +1,300 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/pkg/xaudit"
+	"github.com/rahadianir/dealls/internal/pkg/xcontext"
+	"github.com/rahadianir/dealls/internal/pkg/xcrypto"
+	"github.com/rahadianir/dealls/internal/pkg/xwebhook"
+	"github.com/rahadianir/dealls/internal/user"
+	"go.uber.org/mock/gomock"
+)
+
+func TestWebhookLogic_CreateSubscription(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := NewMockWebhookRepositoryInterface(ctrl)
+	mockUserRepo := user.NewMockUserRepositoryInterface(ctrl)
+	mockAuditor := xaudit.NewMockRecorder(ctrl)
+	// the change and its entry are run in the transaction the recorder begins
+	mockAuditor.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, txfunc func(context.Context) error) error {
+		return txfunc(ctx)
+	}).AnyTimes()
+	mockDeps := config.CommonDependencies{
+		Config: config.InitConfig(context.Background()),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	now := time.Date(2025, time.June, 1, 9, 0, 0, 0, time.UTC)
+
+	ctx := context.WithValue(context.Background(), xcontext.UserIDKey, "admin-id")
+	ctx = context.WithValue(ctx, xcontext.CompanyIDKey, "company-id")
+
+	type args struct {
+		ctx context.Context
+		req SubscriptionRequest
+	}
+	tests := []struct {
+		name       string
+		args       args
+		wantEvents []string
+		wantErr    bool
+		behaviour  func(a args)
+	}{
+		// TODO: Add test cases.
+		{
+			name:       "success subscribe to the events",
+			args:       args{ctx: ctx, req: SubscriptionRequest{URL: " https://example.com/hooks ", Events: []string{"reimbursement.approved", "Payroll_Period.Processed", "reimbursement.approved"}}},
+			wantEvents: []string{xwebhook.EventPayrollPeriodProcessed, xwebhook.EventReimbursementApproved},
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+				mockRepo.EXPECT().CreateSubscription(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data Subscription) error {
+					if data.URL != "https://example.com/hooks" || data.CreatedBy != "admin-id" || len(data.EncryptedSecret) == 0 {
+						t.Errorf("unexpected subscription: %+v", data)
+					}
+					return nil
+				})
+				mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, entry xaudit.Entry) error {
+					if entry.Action != xaudit.ActionCreate || entry.Entity != xaudit.EntityWebhookSubscription {
+						t.Errorf("unexpected audit log entry: %+v", entry)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name:    "failed url isn't http",
+			args:    args{ctx: ctx, req: SubscriptionRequest{URL: "ftp://example.com/hooks", Events: []string{xwebhook.EventPayrollPeriodCreated}}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+			},
+		},
+		{
+			name:    "failed url to a link-local address",
+			args:    args{ctx: ctx, req: SubscriptionRequest{URL: "http://169.254.169.254/latest/meta-data", Events: []string{xwebhook.EventPayrollPeriodCreated}}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+			},
+		},
+		{
+			name:    "failed url to localhost",
+			args:    args{ctx: ctx, req: SubscriptionRequest{URL: "http://localhost:8080/hooks", Events: []string{xwebhook.EventPayrollPeriodCreated}}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+			},
+		},
+		{
+			name:    "failed unknown event",
+			args:    args{ctx: ctx, req: SubscriptionRequest{URL: "https://example.com/hooks", Events: []string{"payroll_period.deleted"}}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+			},
+		},
+		{
+			name:    "failed no event",
+			args:    args{ctx: ctx, req: SubscriptionRequest{URL: "https://example.com/hooks"}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(true, nil)
+			},
+		},
+		{
+			name:    "failed not admin",
+			args:    args{ctx: ctx, req: SubscriptionRequest{URL: "https://example.com/hooks", Events: []string{xwebhook.EventPayrollPeriodCreated}}},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockUserRepo.EXPECT().IsAdmin(gomock.Any(), "admin-id").Return(false, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := NewWebhookLogic(&mockDeps, mockRepo, mockUserRepo, mockAuditor)
+			logic.now = func() time.Time { return now }
+			tt.behaviour(tt.args)
+			got, err := logic.CreateSubscription(tt.args.ctx, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WebhookLogic.CreateSubscription() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got.Events, tt.wantEvents) {
+				t.Errorf("WebhookLogic.CreateSubscription() events = %v, want %v", got.Events, tt.wantEvents)
+			}
+			secret, err := xcrypto.Decrypt(got.EncryptedSecret, mockDeps.Config.App.EncryptionKey)
+			if err != nil || string(secret) != got.Secret || !strings.HasPrefix(got.Secret, SecretPrefix) {
+				t.Errorf("WebhookLogic.CreateSubscription() secret doesn't match the stored one: %v", err)
+			}
+		})
+	}
+}
+
+func TestWebhookLogic_DeliverMessage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := NewMockWebhookRepositoryInterface(ctrl)
+	mockUserRepo := user.NewMockUserRepositoryInterface(ctrl)
+	mockAuditor := xaudit.NewMockRecorder(ctrl)
+	// the change and its entry are run in the transaction the recorder begins
+	mockAuditor.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, txfunc func(context.Context) error) error {
+		return txfunc(ctx)
+	}).AnyTimes()
+	mockDeps := config.CommonDependencies{
+		Config: config.InitConfig(context.Background()),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	now := time.Date(2025, time.June, 1, 9, 0, 0, 0, time.UTC)
+	cfg := mockDeps.Config.Webhook
+
+	secret := SecretPrefix + "secret"
+	encrypted, err := xcrypto.Encrypt([]byte(secret), mockDeps.Config.App.EncryptionKey)
+	if err != nil {
+		t.Fatalf("failed to encrypt secret: %v", err)
+	}
+	payload := []byte(`{"id":"event-id","type":"payroll_period.created","data":{}}`)
+
+	// the receiver responds with the status code in the path, checking the signature first,
+	// the body is never kept in the delivery log
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp := r.Header.Get(HeaderTimestamp)
+		if timestamp != strconv.FormatInt(now.Unix(), 10) || r.Header.Get(HeaderSignature) != "sha256="+Sign(secret, timestamp, body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		statusCode, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/"))
+		w.WriteHeader(statusCode)
+		_, _ = w.Write([]byte("internal receiver details"))
+	}))
+	defer receiver.Close()
+
+	message := func(status int, attempts int) OutboxMessage {
+		return OutboxMessage{
+			ID:              "message-id",
+			SubscriptionID:  "subscription-id",
+			EventID:         "event-id",
+			EventType:       xwebhook.EventPayrollPeriodCreated,
+			Payload:         payload,
+			Attempts:        attempts,
+			URL:             receiver.URL + "/" + strconv.Itoa(status),
+			EncryptedSecret: encrypted,
+		}
+	}
+	retryAt := now.Add(cfg.RetryBackoff * 2)
+	wrongSecret := message(http.StatusOK, 0)
+	wrongSecret.EncryptedSecret, err = xcrypto.Encrypt([]byte(SecretPrefix+"other"), mockDeps.Config.App.EncryptionKey)
+	if err != nil {
+		t.Fatalf("failed to encrypt secret: %v", err)
+	}
+
+	type args struct {
+		ctx context.Context
+		msg OutboxMessage
+	}
+	tests := []struct {
+		name                string
+		allowPrivateTargets bool // the receiver listens on loopback
+		args                args
+		wantErr             bool
+		behaviour           func(a args)
+	}{
+		// TODO: Add test cases.
+		{
+			name:                "success deliver a signed message",
+			allowPrivateTargets: true,
+			args:                args{ctx: context.Background(), msg: message(http.StatusOK, 0)},
+			behaviour: func(a args) {
+				mockRepo.EXPECT().RecordDelivery(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data Delivery) error {
+					if data.Status != DeliveryDelivered || data.StatusCode != http.StatusOK || data.Attempt != 1 || data.NextAttemptAt != nil {
+						t.Errorf("unexpected delivery: %+v", data)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name:                "failed attempt is retried with a backoff",
+			allowPrivateTargets: true,
+			args:                args{ctx: context.Background(), msg: message(http.StatusInternalServerError, 1)},
+			wantErr:             true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().RecordDelivery(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data Delivery) error {
+					if data.Status != DeliveryRetrying || data.StatusCode != http.StatusInternalServerError || data.Attempt != 2 || data.NextAttemptAt == nil || !data.NextAttemptAt.Equal(retryAt) || data.Error != "webhook responded 500" {
+						t.Errorf("unexpected delivery: %+v", data)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name:                "failed for good on the last attempt",
+			allowPrivateTargets: true,
+			args:                args{ctx: context.Background(), msg: message(http.StatusInternalServerError, cfg.MaxAttempts-1)},
+			wantErr:             true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().RecordDelivery(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data Delivery) error {
+					if data.Status != DeliveryFailed || data.Attempt != cfg.MaxAttempts || data.NextAttemptAt != nil || data.Error == "" {
+						t.Errorf("unexpected delivery: %+v", data)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name:    "failed private target is refused when connecting",
+			args:    args{ctx: context.Background(), msg: message(http.StatusOK, 0)},
+			wantErr: true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().RecordDelivery(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data Delivery) error {
+					if data.Status != DeliveryRetrying || data.StatusCode != 0 || !strings.Contains(data.Error, errPrivateTarget.Error()) {
+						t.Errorf("unexpected delivery: %+v", data)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name:                "failed signature with another secret",
+			allowPrivateTargets: true,
+			args:                args{ctx: context.Background(), msg: wrongSecret},
+			wantErr:             true,
+			behaviour: func(a args) {
+				mockRepo.EXPECT().RecordDelivery(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data Delivery) error {
+					if data.Status != DeliveryRetrying || data.StatusCode != http.StatusUnauthorized {
+						t.Errorf("unexpected delivery: %+v", data)
+					}
+					return nil
+				})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := NewWebhookLogic(&mockDeps, mockRepo, mockUserRepo, mockAuditor)
+			logic.client = newDeliveryClient(cfg.Timeout, tt.allowPrivateTargets)
+			logic.now = func() time.Time { return now }
+			tt.behaviour(tt.args)
+			if err := logic.DeliverMessage(tt.args.ctx, tt.args.msg); (err != nil) != tt.wantErr {
+				t.Errorf("WebhookLogic.DeliverMessage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package webhook
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// statuses of an outbox message
+const (
+	MessagePending   = "pending"
+	MessageDelivered = "delivered"
+	MessageFailed    = "failed"
+	MessageCancelled = "cancelled" // the subscription was deleted before the message was delivered
+)
+
+// statuses of a delivery attempt
+const (
+	DeliveryDelivered = "delivered"
+	DeliveryRetrying  = "retrying"
+	DeliveryFailed    = "failed"
+)
+
+// SecretPrefix starts every subscription secret so leaked secrets are easy to spot
+const SecretPrefix = "whsec_"
+
+// headers of a delivery, the signature is the HMAC-SHA256 of "<timestamp>.<body>" keyed by the subscription secret
+const (
+	HeaderEventID   = "X-Webhook-Id"
+	HeaderEventType = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Subscription is a downstream system receiving the events it subscribed to
+type Subscription struct {
+	ID              string    `json:"id"`
+	CompanyID       string    `json:"-"`
+	URL             string    `json:"url"`
+	Events          []string  `json:"events"`
+	EncryptedSecret []byte    `json:"-"`
+	CreatedAt       time.Time `json:"created_at"`
+	CreatedBy       string    `json:"created_by"`
+}
+
+type SQLSubscription struct {
+	ID              sql.NullString `db:"id"`
+	CompanyID       sql.NullString `db:"company_id"`
+	URL             sql.NullString `db:"url"`
+	Events          pq.StringArray `db:"events"`
+	EncryptedSecret []byte         `db:"secret"`
+	CreatedAt       sql.NullTime   `db:"created_at"`
+	CreatedBy       sql.NullString `db:"created_by"`
+}
+
+type SubscriptionRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+// CreatedSubscription carries the secret itself, which is only shown once
+type CreatedSubscription struct {
+	Subscription
+	Secret string `json:"secret"`
+}
+
+// Payload is the body posted to the subscriptions, the same for every subscription of the event
+type Payload struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// OutboxMessage is an event to be delivered to a subscription
+type OutboxMessage struct {
+	ID              string
+	CompanyID       string
+	SubscriptionID  string
+	EventID         string
+	EventType       string
+	Payload         json.RawMessage
+	Attempts        int
+	URL             string
+	EncryptedSecret []byte
+}
+
+type SQLOutboxMessage struct {
+	ID              sql.NullString `db:"id"`
+	CompanyID       sql.NullString `db:"company_id"`
+	SubscriptionID  sql.NullString `db:"subscription_id"`
+	EventID         sql.NullString `db:"event_id"`
+	EventType       sql.NullString `db:"event_type"`
+	Payload         []byte         `db:"payload"`
+	Attempts        sql.NullInt64  `db:"attempts"`
+	URL             sql.NullString `db:"url"`
+	EncryptedSecret []byte         `db:"secret"`
+}
+
+// Delivery is an attempt to deliver a message, kept as the delivery log
+type Delivery struct {
+	ID             string     `json:"id"`
+	MessageID      string     `json:"message_id"`
+	SubscriptionID string     `json:"subscription_id"`
+	EventID        string     `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Attempt        int        `json:"attempt"`
+	Status         string     `json:"status"`
+	StatusCode     int        `json:"status_code,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	DurationMs     int64      `json:"duration_ms"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type SQLDelivery struct {
+	ID             sql.NullString `db:"id"`
+	MessageID      sql.NullString `db:"message_id"`
+	SubscriptionID sql.NullString `db:"subscription_id"`
+	EventID        sql.NullString `db:"event_id"`
+	EventType      sql.NullString `db:"event_type"`
+	Attempt        sql.NullInt64  `db:"attempt"`
+	Status         sql.NullString `db:"status"`
+	StatusCode     sql.NullInt64  `db:"status_code"`
+	Error          sql.NullString `db:"error"`
+	DurationMs     sql.NullInt64  `db:"duration_ms"`
+	NextAttemptAt  sql.NullTime   `db:"next_attempt_at"`
+	CreatedAt      sql.NullTime   `db:"created_at"`
+}
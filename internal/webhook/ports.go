@@ -0,0 +1,26 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/rahadianir/dealls/internal/pkg/xwebhook"
+)
+
+type WebhookRepositoryInterface interface {
+	xwebhook.Outbox
+	CreateSubscription(ctx context.Context, data Subscription) error
+	GetSubscriptions(ctx context.Context) ([]Subscription, error)
+	DeleteSubscription(ctx context.Context, id string) error
+	GetDeliveries(ctx context.Context, subscriptionID string, limit int) ([]Delivery, error)
+	ClaimOutboxMessages(ctx context.Context, limit int, lease time.Duration) ([]OutboxMessage, error)
+	RecordDelivery(ctx context.Context, data Delivery) error
+}
+
+type WebhookLogicInterface interface {
+	CreateSubscription(ctx context.Context, req SubscriptionRequest) (CreatedSubscription, error)
+	GetSubscriptions(ctx context.Context) ([]Subscription, error)
+	DeleteSubscription(ctx context.Context, id string) error
+	GetDeliveries(ctx context.Context, subscriptionID string) ([]Delivery, error)
+	DeliverMessage(ctx context.Context, msg OutboxMessage) error
+}
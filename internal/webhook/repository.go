@@ -0,0 +1,353 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/lib/pq"
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/pkg/dbhelper"
+	"github.com/rahadianir/dealls/internal/pkg/xcontext"
+	"github.com/rahadianir/dealls/internal/pkg/xerror"
+	"github.com/rahadianir/dealls/internal/pkg/xwebhook"
+)
+
+type WebhookRepository struct {
+	deps *config.CommonDependencies
+}
+
+func NewWebhookRepository(deps *config.CommonDependencies) *WebhookRepository {
+	return &WebhookRepository{
+		deps: deps,
+	}
+}
+
+// Enqueue writes a message per subscription of the event to the outbox, within the transaction in the context.
+// Nothing is written when no subscription of the company subscribed to the event.
+func (repo *WebhookRepository) Enqueue(ctx context.Context, event xwebhook.Event) error {
+	eventID := uuid.NewString()
+	payload, err := json.Marshal(Payload{
+		ID:        eventID,
+		Type:      event.Type,
+		CreatedAt: time.Now().UTC(),
+		Data:      event.Data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event payload: %w", event.Type, err)
+	}
+
+	sub := sqlbuilder.NewSelectBuilder()
+	sub.Select(
+		`gen_random_uuid()`,
+		`company_id`,
+		`id`,
+		fmt.Sprintf(`%s::uuid`, sub.Var(eventID)),
+		fmt.Sprintf(`%s::varchar`, sub.Var(event.Type)),
+		fmt.Sprintf(`%s::jsonb`, sub.Var(string(payload))),
+		fmt.Sprintf(`%s::varchar`, sub.Var(MessagePending)),
+		`now()`,
+		`now()`,
+	).From(`hr.webhook_subscriptions`).Where(
+		sub.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+		sub.IsNull(`deleted_at`),
+		fmt.Sprintf(`%s = ANY(events)`, sub.Var(event.Type)),
+	)
+	q, args := sqlbuilder.Build(`INSERT INTO hr.webhook_outbox (id, company_id, subscription_id, event_id, event_type, payload, status, next_attempt_at, created_at) $?`, sub).
+		BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	_, err = tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+var subscriptionColumns = []string{`id`, `company_id`, `url`, `events`, `secret`, `created_at`, `created_by`}
+
+func (repo *WebhookRepository) CreateSubscription(ctx context.Context, data Subscription) error {
+	sq := sqlbuilder.NewInsertBuilder()
+	sq.InsertInto(`hr.webhook_subscriptions`).
+		Cols(`id`, `company_id`, `url`, `events`, `secret`, `created_at`, `created_by`).
+		Values(data.ID, xcontext.GetCompanyIDFromContext(ctx), data.URL, pq.Array(data.Events), data.EncryptedSecret, data.CreatedAt, xcontext.GetUserIDFromContext(ctx))
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	_, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetSubscriptions returns the subscriptions of the company, newest first
+func (repo *WebhookRepository) GetSubscriptions(ctx context.Context) ([]Subscription, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(subscriptionColumns...).From(`hr.webhook_subscriptions`).
+		Where(
+			sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+			sq.IsNull(`deleted_at`),
+		).
+		OrderBy(`created_at DESC`)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	rows, err := tx.QueryxContext(ctx, q, args...)
+	if err != nil {
+		return []Subscription{}, err
+	}
+	defer rows.Close()
+
+	result := []Subscription{}
+	for rows.Next() {
+		var temp SQLSubscription
+		err := rows.StructScan(&temp)
+		if err != nil {
+			repo.deps.Logger.WarnContext(ctx, "failed to scan webhook subscription", slog.Any("error", err))
+			continue
+		}
+		result = append(result, toSubscription(temp))
+	}
+
+	return result, nil
+}
+
+// DeleteSubscription deletes the subscription and cancels its undelivered messages,
+// ErrDataNotFound is returned when there's no such subscription
+func (repo *WebhookRepository) DeleteSubscription(ctx context.Context, id string) error {
+	return dbhelper.WithTransaction(ctx, repo.deps.DB, func(ctx context.Context) error {
+		tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+		sq := sqlbuilder.NewUpdateBuilder()
+		sq.Update(`hr.webhook_subscriptions`).Set(
+			`deleted_at = now()`,
+			sq.Assign(`deleted_by`, xcontext.GetUserIDFromContext(ctx)),
+		).Where(
+			sq.Equal(`id`, id),
+			sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+			sq.IsNull(`deleted_at`),
+		)
+		q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+		res, err := tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			return err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if affected == 0 {
+			return xerror.ErrDataNotFound
+		}
+
+		cancel := sqlbuilder.NewUpdateBuilder()
+		cancel.Update(`hr.webhook_outbox`).Set(
+			cancel.Assign(`status`, MessageCancelled),
+			`updated_at = now()`,
+		).Where(
+			cancel.Equal(`subscription_id`, id),
+			cancel.Equal(`status`, MessagePending),
+		)
+		q, args = cancel.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+		_, err = tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+var deliveryColumns = []string{`id`, `message_id`, `subscription_id`, `event_id`, `event_type`, `attempt`, `status`, `status_code`, `error`, `duration_ms`, `next_attempt_at`, `created_at`}
+
+// GetDeliveries returns the latest delivery attempts of the subscription, newest first
+func (repo *WebhookRepository) GetDeliveries(ctx context.Context, subscriptionID string, limit int) ([]Delivery, error) {
+	sq := sqlbuilder.NewSelectBuilder()
+	sq.Select(deliveryColumns...).From(`hr.webhook_deliveries`).
+		Where(
+			sq.Equal(`subscription_id`, subscriptionID),
+			sq.Equal(`company_id`, xcontext.GetCompanyIDFromContext(ctx)),
+		).
+		OrderBy(`created_at DESC`).Limit(limit)
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	rows, err := tx.QueryxContext(ctx, q, args...)
+	if err != nil {
+		return []Delivery{}, err
+	}
+	defer rows.Close()
+
+	result := []Delivery{}
+	for rows.Next() {
+		var temp SQLDelivery
+		err := rows.StructScan(&temp)
+		if err != nil {
+			repo.deps.Logger.WarnContext(ctx, "failed to scan webhook delivery", slog.Any("error", err))
+			continue
+		}
+		result = append(result, toDelivery(temp))
+	}
+
+	return result, nil
+}
+
+// ClaimOutboxMessages claims up to limit due messages of every company, pushing their next attempt back by the lease
+// so a message claimed by a stopped dispatcher is picked up again once the lease is over
+func (repo *WebhookRepository) ClaimOutboxMessages(ctx context.Context, limit int, lease time.Duration) ([]OutboxMessage, error) {
+	due := sqlbuilder.NewSelectBuilder()
+	due.Select(`id`).From(`hr.webhook_outbox`).
+		Where(
+			due.Equal(`status`, MessagePending),
+			due.LessEqualThan(`next_attempt_at`, time.Now()),
+		).
+		OrderBy(`next_attempt_at`).Limit(limit).
+		ForUpdate().SQL(`SKIP LOCKED`)
+
+	sq := sqlbuilder.NewUpdateBuilder()
+	sq.Update(`hr.webhook_outbox o`).
+		Set(
+			sq.Assign(`next_attempt_at`, time.Now().Add(lease)),
+			`updated_at = now()`,
+		).
+		SQL(`FROM hr.webhook_subscriptions s`).
+		Where(
+			`s.id = o.subscription_id`,
+			sq.In(`o.id`, due),
+		).
+		SQL(`RETURNING ` + strings.Join([]string{`o.id`, `o.company_id`, `o.subscription_id`, `o.event_id`, `o.event_type`, `o.payload`, `o.attempts`, `s.url`, `s.secret`}, `, `))
+	q, args := sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+
+	rows, err := tx.QueryxContext(ctx, q, args...)
+	if err != nil {
+		return []OutboxMessage{}, err
+	}
+	defer rows.Close()
+
+	result := []OutboxMessage{}
+	for rows.Next() {
+		var temp SQLOutboxMessage
+		err := rows.StructScan(&temp)
+		if err != nil {
+			return []OutboxMessage{}, err
+		}
+		result = append(result, OutboxMessage{
+			ID:              temp.ID.String,
+			CompanyID:       temp.CompanyID.String,
+			SubscriptionID:  temp.SubscriptionID.String,
+			EventID:         temp.EventID.String,
+			EventType:       temp.EventType.String,
+			Payload:         temp.Payload,
+			Attempts:        int(temp.Attempts.Int64),
+			URL:             temp.URL.String,
+			EncryptedSecret: temp.EncryptedSecret,
+		})
+	}
+
+	return result, rows.Err()
+}
+
+// RecordDelivery logs the delivery attempt and moves its message along: delivered, failed for good,
+// or pending again until the next attempt
+func (repo *WebhookRepository) RecordDelivery(ctx context.Context, data Delivery) error {
+	return dbhelper.WithTransaction(ctx, repo.deps.DB, func(ctx context.Context) error {
+		tx := dbhelper.ExtractTx(ctx, repo.deps.DB)
+		companyID := xcontext.GetCompanyIDFromContext(ctx)
+
+		var statusCode, deliveryError any
+		if data.StatusCode != 0 {
+			statusCode = data.StatusCode
+		}
+		if data.Error != "" {
+			deliveryError = data.Error
+		}
+
+		ins := sqlbuilder.NewInsertBuilder()
+		ins.InsertInto(`hr.webhook_deliveries`).
+			Cols(`id`, `company_id`, `message_id`, `subscription_id`, `event_id`, `event_type`, `attempt`, `status`, `status_code`, `error`, `duration_ms`, `next_attempt_at`, `created_at`).
+			Values(data.ID, companyID, data.MessageID, data.SubscriptionID, data.EventID, data.EventType, data.Attempt, data.Status, statusCode, deliveryError, data.DurationMs, data.NextAttemptAt, data.CreatedAt)
+		q, args := ins.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+		_, err := tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			return err
+		}
+
+		sq := sqlbuilder.NewUpdateBuilder()
+		sq.Update(`hr.webhook_outbox`).Set(
+			sq.Assign(`attempts`, data.Attempt),
+			sq.Assign(`last_error`, deliveryError),
+			`updated_at = now()`,
+		).Where(
+			sq.Equal(`id`, data.MessageID),
+			sq.Equal(`company_id`, companyID),
+		)
+		switch data.Status {
+		case DeliveryDelivered:
+			sq.SetMore(sq.Assign(`status`, MessageDelivered), sq.Assign(`delivered_at`, data.CreatedAt))
+		case DeliveryFailed:
+			sq.SetMore(sq.Assign(`status`, MessageFailed))
+		default:
+			sq.SetMore(sq.Assign(`next_attempt_at`, data.NextAttemptAt))
+		}
+		q, args = sq.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+		_, err = tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+func toSubscription(temp SQLSubscription) Subscription {
+	return Subscription{
+		ID:              temp.ID.String,
+		CompanyID:       temp.CompanyID.String,
+		URL:             temp.URL.String,
+		Events:          []string(temp.Events),
+		EncryptedSecret: temp.EncryptedSecret,
+		CreatedAt:       temp.CreatedAt.Time,
+		CreatedBy:       temp.CreatedBy.String,
+	}
+}
+
+func toDelivery(temp SQLDelivery) Delivery {
+	result := Delivery{
+		ID:             temp.ID.String,
+		MessageID:      temp.MessageID.String,
+		SubscriptionID: temp.SubscriptionID.String,
+		EventID:        temp.EventID.String,
+		EventType:      temp.EventType.String,
+		Attempt:        int(temp.Attempt.Int64),
+		Status:         temp.Status.String,
+		StatusCode:     int(temp.StatusCode.Int64),
+		Error:          temp.Error.String,
+		DurationMs:     temp.DurationMs.Int64,
+		CreatedAt:      temp.CreatedAt.Time,
+	}
+	if temp.NextAttemptAt.Valid {
+		result.NextAttemptAt = &temp.NextAttemptAt.Time
+	}
+
+	return result
+}
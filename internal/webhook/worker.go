@@ -0,0 +1,76 @@
+package webhook
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/rahadianir/dealls/internal/config"
+	"github.com/rahadianir/dealls/internal/pkg/xcontext"
+)
+
+// WebhookDispatcher delivers the outbox messages in the background
+type WebhookDispatcher struct {
+	deps         *config.CommonDependencies
+	webhookRepo  WebhookRepositoryInterface
+	webhookLogic WebhookLogicInterface
+}
+
+func NewWebhookDispatcher(deps *config.CommonDependencies, webhookRepo WebhookRepositoryInterface, webhookLogic WebhookLogicInterface) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		deps:         deps,
+		webhookRepo:  webhookRepo,
+		webhookLogic: webhookLogic,
+	}
+}
+
+// Start polls for due messages until the context is cancelled.
+// Messages claimed by a stopped dispatcher are picked up again once their lease is over.
+func (w *WebhookDispatcher) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.deps.Config.Webhook.PollInterval)
+	defer ticker.Stop()
+
+	w.deps.Logger.InfoContext(ctx, "webhook dispatcher starts")
+	for {
+		w.dispatchDueMessages(ctx)
+
+		select {
+		case <-ctx.Done():
+			w.deps.Logger.InfoContext(ctx, "webhook dispatcher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *WebhookDispatcher) dispatchDueMessages(ctx context.Context) {
+	cfg := w.deps.Config.Webhook
+	batchSize := max(cfg.BatchSize, 1)
+
+	// the lease outlasts the deliveries of the whole batch, so messages aren't claimed twice
+	lease := cfg.Timeout*time.Duration(batchSize) + time.Minute
+
+	for ctx.Err() == nil {
+		messages, err := w.webhookRepo.ClaimOutboxMessages(ctx, batchSize, lease)
+		if err != nil {
+			if ctx.Err() == nil {
+				w.deps.Logger.ErrorContext(ctx, "failed to claim webhook messages", slog.Any("error", err))
+			}
+			return
+		}
+
+		for _, msg := range messages {
+			// deliver within the company of the message, traced by the message ID
+			msgCtx := context.WithValue(ctx, xcontext.RequestIDKey, "webhook-"+msg.ID)
+			msgCtx = context.WithValue(msgCtx, xcontext.CompanyIDKey, msg.CompanyID)
+
+			// failures are logged and retried later by the logic
+			_ = w.webhookLogic.DeliverMessage(msgCtx, msg)
+		}
+
+		// last batch
+		if len(messages) < batchSize {
+			return
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 
 	"github.com/rahadianir/dealls/internal/app"
+	"github.com/rahadianir/dealls/internal/company"
 	"github.com/rahadianir/dealls/migrations"
 	"github.com/spf13/cobra"
 )
@@ -27,8 +28,58 @@ func main() {
 		},
 	}
 
+	var preview bool
+	scheduleCmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Create, activate and announce payroll periods of auto scheduled pay groups",
+		Run: func(cmd *cobra.Command, args []string) {
+			app.RunScheduler(preview)
+		},
+	}
+	scheduleCmd.Flags().BoolVar(&preview, "preview", false, "include a payroll preview in the ready to process notification")
+
+	var companyReq company.CreateCompanyRequest
+	companyCmd := &cobra.Command{
+		Use:   "company",
+		Short: "Manage companies",
+	}
+	createCompanyCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a company along with its default pay group and admin user",
+		Run: func(cmd *cobra.Command, args []string) {
+			app.CreateCompany(companyReq)
+		},
+	}
+	createCompanyCmd.Flags().StringVar(&companyReq.Code, "code", "", "company code users log in with")
+	createCompanyCmd.Flags().StringVar(&companyReq.Name, "name", "", "company name")
+	createCompanyCmd.Flags().StringVar(&companyReq.AdminUsername, "admin-username", "admin", "username of the company admin")
+	createCompanyCmd.Flags().StringVar(&companyReq.AdminPassword, "admin-password", "", "password of the company admin")
+	companyCmd.AddCommand(createCompanyCmd)
+
+	var mockIdPEmail string
+	mockIdPCmd := &cobra.Command{
+		Use:   "mock-idp",
+		Short: "Start a mock OpenID Connect identity provider for local single sign-on",
+		Run: func(cmd *cobra.Command, args []string) {
+			app.StartMockIdP(mockIdPEmail)
+		},
+	}
+	mockIdPCmd.Flags().StringVar(&mockIdPEmail, "email", "admin", "email signed in when the sign-in has no login_hint")
+
+	mockSMTPCmd := &cobra.Command{
+		Use:   "mock-smtp",
+		Short: "Start a local SMTP server logging the emails instead of delivering them",
+		Run: func(cmd *cobra.Command, args []string) {
+			app.StartMockSMTP()
+		},
+	}
+
 	rootCmd.AddCommand(migrateCmd)
 	rootCmd.AddCommand(serveHTTPCmd)
+	rootCmd.AddCommand(scheduleCmd)
+	rootCmd.AddCommand(companyCmd)
+	rootCmd.AddCommand(mockIdPCmd)
+	rootCmd.AddCommand(mockSMTPCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		log.Fatal(err)
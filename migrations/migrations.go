@@ -39,11 +39,15 @@ func generateMockData(db *sqlx.DB) {
 	}
 	defer tx.Rollback()
 
+	// mock data belongs to the default company and its monthly default pay group
+	companyID := "e0a6b1f2-7c3d-4e58-9a14-6b2d8f0c3a57"
+	payGroupID := "5d2f8a9e-3c41-4b7a-9e60-1f4c2a8b7d10"
+
 	// insert admin data
 	// setup admin role
 	roleID := uuid.New()
-	q := `INSERT INTO hr.roles (id, name, created_at) VALUES ($1, $2, now())`
-	_, err = tx.Exec(q, roleID, "admin")
+	q := `INSERT INTO hr.roles (id, company_id, name, created_at) VALUES ($1, $2, $3, now())`
+	_, err = tx.Exec(q, roleID, companyID, "admin")
 	if err != nil {
 		log.Fatal("failed to insert admin role: ", err)
 	}
@@ -59,16 +63,17 @@ func generateMockData(db *sqlx.DB) {
 		log.Fatal("failed to hash admin password: ", err)
 	}
 
-	q = `INSERT INTO hr.users (id, name, username, password, salary, created_at) VALUES ($1, $2, $2, $3, $4, now())`
-	_, err = tx.Exec(q, adminID, "admin", string(pwBytes), salary)
+	// the default admin password is only good for the first login
+	q = `INSERT INTO hr.users (id, company_id, pay_group_id, name, username, password, salary, must_change_password, created_at) VALUES ($1, $2, $6, $3, $3, $4, $5, true, now())`
+	_, err = tx.Exec(q, adminID, companyID, "admin", string(pwBytes), salary, payGroupID)
 	if err != nil {
 		log.Fatal("failed to insert admin data: ", err)
 	}
 
 	// assign admin role to admin user
 	mapID := uuid.New()
-	q = `INSERT INTO hr.user_role_map (id, user_id, role_id, created_at) VALUES ($1, $2, $3, now())`
-	_, err = tx.Exec(q, mapID, adminID, roleID)
+	q = `INSERT INTO hr.user_role_map (id, company_id, user_id, role_id, created_at) VALUES ($1, $2, $3, $4, now())`
+	_, err = tx.Exec(q, mapID, companyID, adminID, roleID)
 	if err != nil {
 		log.Fatal("failed to assign admin role to admin user: ", err)
 	}
@@ -86,11 +91,11 @@ func generateMockData(db *sqlx.DB) {
 	id3 := "cc3a57a3-79cf-438e-9dc3-3a18bd86480b"
 
 	// inserting 3 static employee data for testing
-	q = `INSERT INTO hr.users (id, name, username, password, salary, created_at) VALUES 
-	($1, 'ani', 'ani', $4, 10000000, now()),
-	($2, 'budi', 'budi', $4, 13000000, now()),
-	($3, 'coki', 'coki', $4, 17000000, now())`
-	_, err = tx.Exec(q, id1, id2, id3, string(pwBytes))
+	q = `INSERT INTO hr.users (id, company_id, pay_group_id, name, username, password, salary, created_at) VALUES 
+	($1, $5, $6, 'ani', 'ani', $4, 10000000, now()),
+	($2, $5, $6, 'budi', 'budi', $4, 13000000, now()),
+	($3, $5, $6, 'coki', 'coki', $4, 17000000, now())`
+	_, err = tx.Exec(q, id1, id2, id3, string(pwBytes), companyID, payGroupID)
 	if err != nil {
 		log.Fatal("failed to insert static employee data: ", err)
 	}
@@ -101,8 +106,8 @@ func generateMockData(db *sqlx.DB) {
 		name := generateRandomName(20)
 		salary := rand.Int64N(100000000)
 
-		q := `INSERT INTO hr.users (id, name, username, password, salary, created_at) VALUES ($1, $2, $2, $3, $4, now())`
-		_, err = tx.Exec(q, id, name, string(pwBytes), salary)
+		q := `INSERT INTO hr.users (id, company_id, pay_group_id, name, username, password, salary, created_at) VALUES ($1, $2, $6, $3, $3, $4, $5, now())`
+		_, err = tx.Exec(q, id, companyID, name, string(pwBytes), salary, payGroupID)
 		if err != nil {
 			log.Println("failed to insert data")
 			continue
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "hr"."reimbursement_receipts";
\ No newline at end of file
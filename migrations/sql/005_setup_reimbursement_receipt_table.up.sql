@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS "hr"."reimbursement_receipts" (
+    "id" UUID PRIMARY KEY,
+    "reimbursement_id" UUID NOT NULL,
+    "file_name" VARCHAR NOT NULL,
+    "content_type" VARCHAR NOT NULL,
+    "size_bytes" BIGINT NOT NULL,
+    "storage_key" VARCHAR NOT NULL,
+    "created_at" TIMESTAMPTZ NOT NULL,
+    "updated_at" TIMESTAMPTZ,
+    "deleted_at" TIMESTAMPTZ,
+    "created_by" VARCHAR DEFAULT 'admin',
+    "updated_by" VARCHAR,
+    CONSTRAINT fk_receipt_reimbursement_id
+        FOREIGN KEY (reimbursement_id)
+        REFERENCES hr.reimbursements (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_receipt_reimbursement_id ON "hr"."reimbursement_receipts" (reimbursement_id);
@@ -0,0 +1,9 @@
+ALTER TABLE "hr"."payslips"
+    DROP COLUMN IF EXISTS "reimbursement_by_category",
+    DROP COLUMN IF EXISTS "total_taxable_reimbursement";
+
+ALTER TABLE "hr"."reimbursements"
+    DROP CONSTRAINT IF EXISTS fk_reimbursement_category_id,
+    DROP COLUMN IF EXISTS "category_id";
+
+DROP TABLE IF EXISTS "hr"."reimbursement_categories";
\ No newline at end of file
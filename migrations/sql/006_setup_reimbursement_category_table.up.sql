@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS "hr"."reimbursement_categories" (
+    "id" UUID PRIMARY KEY,
+    "code" VARCHAR NOT NULL,
+    "name" VARCHAR NOT NULL,
+    "per_claim_limit" DECIMAL(12,2),
+    "per_period_limit" DECIMAL(12,2),
+    "receipt_required" BOOL NOT NULL DEFAULT false,
+    "taxable" BOOL NOT NULL DEFAULT false,
+    "created_at" TIMESTAMPTZ NOT NULL,
+    "updated_at" TIMESTAMPTZ,
+    "deleted_at" TIMESTAMPTZ,
+    "created_by" VARCHAR DEFAULT 'admin',
+    "updated_by" VARCHAR,
+    CONSTRAINT unique_reimbursement_category_code UNIQUE (code)
+);
+
+INSERT INTO "hr"."reimbursement_categories" (id, code, name, per_claim_limit, per_period_limit, receipt_required, taxable, created_at) VALUES
+    (gen_random_uuid(), 'travel', 'Travel', 2000000, 5000000, true, false, now()),
+    (gen_random_uuid(), 'medical', 'Medical', 5000000, 10000000, true, false, now()),
+    (gen_random_uuid(), 'meals', 'Meals', 150000, 1500000, false, true, now())
+ON CONFLICT (code) DO NOTHING;
+
+ALTER TABLE "hr"."reimbursements"
+    ADD COLUMN IF NOT EXISTS "category_id" UUID,
+    ADD CONSTRAINT fk_reimbursement_category_id
+        FOREIGN KEY (category_id)
+        REFERENCES hr.reimbursement_categories (id);
+
+ALTER TABLE "hr"."payslips"
+    ADD COLUMN IF NOT EXISTS "reimbursement_by_category" JSONB DEFAULT '[]',
+    ADD COLUMN IF NOT EXISTS "total_taxable_reimbursement" DECIMAL(20,2) DEFAULT 0;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE "hr"."payslips" DROP CONSTRAINT IF EXISTS only_1_payslip_per_user;
+
+DROP TABLE IF EXISTS "hr"."payroll_jobs";
\ No newline at end of file
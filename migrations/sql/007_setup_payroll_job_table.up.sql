@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS "hr"."payroll_jobs" (
+    "id" UUID PRIMARY KEY,
+    "payroll_id" UUID NOT NULL,
+    "status" VARCHAR NOT NULL DEFAULT 'pending',
+    "total" INTEGER DEFAULT 0,
+    "processed" INTEGER DEFAULT 0,
+    "errors" JSONB DEFAULT '[]',
+    "started_at" TIMESTAMPTZ,
+    "finished_at" TIMESTAMPTZ,
+    "created_at" TIMESTAMPTZ NOT NULL,
+    "updated_at" TIMESTAMPTZ,
+    "deleted_at" TIMESTAMPTZ,
+    "created_by" VARCHAR DEFAULT 'admin',
+    "updated_by" VARCHAR,
+    CONSTRAINT fk_payroll_job_payroll_id
+        FOREIGN KEY (payroll_id)
+        REFERENCES hr.payrolls (id)
+);
+
+-- a payroll period can only be calculated by one job at a time
+CREATE UNIQUE INDEX IF NOT EXISTS only_1_unfinished_job_per_payroll ON "hr"."payroll_jobs" (payroll_id) WHERE status IN ('pending', 'running');
+
+-- makes storing payslips idempotent so an interrupted job can be resumed
+ALTER TABLE "hr"."payslips" ADD CONSTRAINT only_1_payslip_per_user UNIQUE (payroll_id, user_id);
\ No newline at end of file
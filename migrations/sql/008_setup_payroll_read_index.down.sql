@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "hr"."idx_reimbursement_user_created_at";
+
+DROP INDEX IF EXISTS "hr"."idx_overtime_user_date";
+
+DROP INDEX IF EXISTS "hr"."idx_attendance_user_date";
\ No newline at end of file
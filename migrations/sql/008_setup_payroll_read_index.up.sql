@@ -0,0 +1,5 @@
+CREATE INDEX IF NOT EXISTS idx_attendance_user_date ON "hr"."attendances" (user_id, attendance_date) WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_overtime_user_date ON "hr"."overtimes" (user_id, date) WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_reimbursement_user_created_at ON "hr"."reimbursements" (user_id, created_at) WHERE deleted_at IS NULL;
\ No newline at end of file
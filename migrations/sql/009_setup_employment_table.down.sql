@@ -0,0 +1,11 @@
+ALTER TABLE "hr"."payslips"
+    DROP COLUMN IF EXISTS "review_reasons",
+    DROP COLUMN IF EXISTS "unpaid_leave_days",
+    DROP COLUMN IF EXISTS "paid_leave_days";
+
+DROP TABLE IF EXISTS "hr"."leaves";
+
+ALTER TABLE "hr"."users"
+    DROP COLUMN IF EXISTS "employment_end_date",
+    DROP COLUMN IF EXISTS "employment_start_date",
+    DROP COLUMN IF EXISTS "employment_status";
\ No newline at end of file
@@ -0,0 +1,29 @@
+ALTER TABLE "hr"."users"
+    ADD COLUMN IF NOT EXISTS "employment_status" VARCHAR NOT NULL DEFAULT 'active',
+    ADD COLUMN IF NOT EXISTS "employment_start_date" DATE,
+    ADD COLUMN IF NOT EXISTS "employment_end_date" DATE;
+
+CREATE TABLE IF NOT EXISTS "hr"."leaves" (
+    "id" UUID PRIMARY KEY,
+    "user_id" UUID NOT NULL,
+    "start_date" DATE NOT NULL,
+    "end_date" DATE NOT NULL,
+    "paid" BOOL NOT NULL DEFAULT true,
+    "reason" VARCHAR DEFAULT '',
+    "created_at" TIMESTAMPTZ NOT NULL,
+    "updated_at" TIMESTAMPTZ,
+    "deleted_at" TIMESTAMPTZ,
+    "created_by" VARCHAR DEFAULT 'admin',
+    "updated_by" VARCHAR,
+    CONSTRAINT fk_leave_user_id
+        FOREIGN KEY (user_id)
+        REFERENCES hr.users (id),
+    CONSTRAINT valid_leave_range CHECK (end_date >= start_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_leave_user_date ON "hr"."leaves" (user_id, start_date, end_date) WHERE deleted_at IS NULL;
+
+ALTER TABLE "hr"."payslips"
+    ADD COLUMN IF NOT EXISTS "paid_leave_days" INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS "unpaid_leave_days" INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS "review_reasons" JSONB NOT NULL DEFAULT '[]';
\ No newline at end of file
@@ -0,0 +1,13 @@
+ALTER TABLE "hr"."payslips"
+    DROP COLUMN IF EXISTS "total_deduction",
+    DROP COLUMN IF EXISTS "deduction_list",
+    DROP COLUMN IF EXISTS "leave_payout",
+    DROP COLUMN IF EXISTS "unused_leave_days",
+    DROP COLUMN IF EXISTS "employed_work_days",
+    DROP COLUMN IF EXISTS "payslip_type";
+
+DROP TABLE IF EXISTS "hr"."deductions";
+
+ALTER TABLE "hr"."users"
+    DROP COLUMN IF EXISTS "termination_reason",
+    DROP COLUMN IF EXISTS "annual_leave_days";
\ No newline at end of file
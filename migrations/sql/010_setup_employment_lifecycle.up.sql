@@ -0,0 +1,32 @@
+ALTER TABLE "hr"."users"
+    ADD COLUMN IF NOT EXISTS "annual_leave_days" INTEGER NOT NULL DEFAULT 12,
+    ADD COLUMN IF NOT EXISTS "termination_reason" VARCHAR;
+
+CREATE TABLE IF NOT EXISTS "hr"."deductions" (
+    "id" UUID PRIMARY KEY,
+    "user_id" UUID NOT NULL,
+    "amount" DECIMAL(12,2) NOT NULL,
+    "description" VARCHAR DEFAULT '',
+    "settled_payroll_id" UUID,
+    "created_at" TIMESTAMPTZ NOT NULL,
+    "updated_at" TIMESTAMPTZ,
+    "deleted_at" TIMESTAMPTZ,
+    "created_by" VARCHAR DEFAULT 'admin',
+    "updated_by" VARCHAR,
+    CONSTRAINT fk_deduction_user_id
+        FOREIGN KEY (user_id)
+        REFERENCES hr.users (id),
+    CONSTRAINT fk_deduction_settled_payroll_id
+        FOREIGN KEY (settled_payroll_id)
+        REFERENCES hr.payrolls (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_deduction_outstanding ON "hr"."deductions" (user_id) WHERE settled_payroll_id IS NULL AND deleted_at IS NULL;
+
+ALTER TABLE "hr"."payslips"
+    ADD COLUMN IF NOT EXISTS "payslip_type" VARCHAR NOT NULL DEFAULT 'regular',
+    ADD COLUMN IF NOT EXISTS "employed_work_days" INTEGER,
+    ADD COLUMN IF NOT EXISTS "unused_leave_days" INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS "leave_payout" DECIMAL(20,2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS "deduction_list" JSONB NOT NULL DEFAULT '[]',
+    ADD COLUMN IF NOT EXISTS "total_deduction" DECIMAL(20,2) NOT NULL DEFAULT 0;
\ No newline at end of file
@@ -0,0 +1,16 @@
+ALTER TABLE "hr"."payrolls"
+    DROP CONSTRAINT IF EXISTS only_1_active_per_pay_group,
+    DROP COLUMN IF EXISTS "pay_group_id";
+
+-- only the latest active period is kept active
+UPDATE "hr"."payrolls" SET active = NULL
+WHERE active AND id <> (SELECT id FROM "hr"."payrolls" WHERE active ORDER BY created_at DESC LIMIT 1);
+
+ALTER TABLE "hr"."payrolls" ADD CONSTRAINT only_1_active UNIQUE (active);
+
+DROP INDEX IF EXISTS "hr"."idx_user_pay_group";
+
+ALTER TABLE "hr"."users"
+    DROP COLUMN IF EXISTS "pay_group_id";
+
+DROP TABLE IF EXISTS "hr"."pay_groups";
\ No newline at end of file
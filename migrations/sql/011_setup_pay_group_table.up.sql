@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS "hr"."pay_groups" (
+    "id" UUID PRIMARY KEY,
+    "code" VARCHAR NOT NULL UNIQUE,
+    "name" VARCHAR NOT NULL,
+    "frequency" VARCHAR NOT NULL DEFAULT 'monthly',
+    "created_at" TIMESTAMPTZ NOT NULL,
+    "updated_at" TIMESTAMPTZ,
+    "deleted_at" TIMESTAMPTZ,
+    "created_by" VARCHAR DEFAULT 'admin',
+    "updated_by" VARCHAR
+);
+
+-- existing users and payroll periods belong to the default monthly pay group
+INSERT INTO "hr"."pay_groups" (id, code, name, frequency, created_at)
+VALUES ('5d2f8a9e-3c41-4b7a-9e60-1f4c2a8b7d10', 'default', 'Monthly Staff', 'monthly', now())
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE "hr"."users"
+    ADD COLUMN IF NOT EXISTS "pay_group_id" UUID NOT NULL DEFAULT '5d2f8a9e-3c41-4b7a-9e60-1f4c2a8b7d10'
+        CONSTRAINT fk_user_pay_group_id REFERENCES hr.pay_groups (id);
+
+CREATE INDEX IF NOT EXISTS idx_user_pay_group ON "hr"."users" (pay_group_id, id) WHERE deleted_at IS NULL;
+
+-- one active period per pay group instead of one for everyone
+ALTER TABLE "hr"."payrolls"
+    ADD COLUMN IF NOT EXISTS "pay_group_id" UUID NOT NULL DEFAULT '5d2f8a9e-3c41-4b7a-9e60-1f4c2a8b7d10'
+        CONSTRAINT fk_payroll_pay_group_id REFERENCES hr.pay_groups (id),
+    DROP CONSTRAINT IF EXISTS only_1_active,
+    ADD CONSTRAINT only_1_active_per_pay_group UNIQUE (pay_group_id, active);
\ No newline at end of file
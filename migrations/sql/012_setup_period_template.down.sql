@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS "hr"."idx_payroll_pay_group_start";
+
+ALTER TABLE "hr"."payrolls"
+    DROP COLUMN IF EXISTS "ready_notified_at",
+    DROP COLUMN IF EXISTS "scheduled";
+
+ALTER TABLE "hr"."pay_groups"
+    DROP COLUMN IF EXISTS "auto_schedule",
+    DROP COLUMN IF EXISTS "period_anchor_day";
\ No newline at end of file
@@ -0,0 +1,9 @@
+ALTER TABLE "hr"."pay_groups"
+    ADD COLUMN IF NOT EXISTS "period_anchor_day" INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS "auto_schedule" BOOL NOT NULL DEFAULT false;
+
+ALTER TABLE "hr"."payrolls"
+    ADD COLUMN IF NOT EXISTS "scheduled" BOOL NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS "ready_notified_at" TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_payroll_pay_group_start ON "hr"."payrolls" (pay_group_id, start_date) WHERE deleted_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE "hr"."payrolls"
+    DROP CONSTRAINT IF EXISTS valid_payroll_range;
\ No newline at end of file
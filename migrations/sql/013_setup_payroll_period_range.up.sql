@@ -0,0 +1,3 @@
+-- end dates are exclusive, existing periods are left unchecked as they predate the validation
+ALTER TABLE "hr"."payrolls"
+    ADD CONSTRAINT valid_payroll_range CHECK (end_date > start_date) NOT VALID;
\ No newline at end of file
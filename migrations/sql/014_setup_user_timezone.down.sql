@@ -0,0 +1,2 @@
+ALTER TABLE "hr"."users"
+    DROP COLUMN IF EXISTS "timezone";
\ No newline at end of file
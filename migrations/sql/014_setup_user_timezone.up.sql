@@ -0,0 +1,3 @@
+-- attendance and overtime of users without a timezone are in the company timezone
+ALTER TABLE "hr"."users"
+    ADD COLUMN IF NOT EXISTS "timezone" VARCHAR;
\ No newline at end of file
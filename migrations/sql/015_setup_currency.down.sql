@@ -0,0 +1,17 @@
+ALTER TABLE "hr"."payrolls"
+    DROP COLUMN IF EXISTS "total_salary_paid_by_currency";
+
+ALTER TABLE "hr"."payslips"
+    DROP COLUMN IF EXISTS "salary_currency",
+    DROP COLUMN IF EXISTS "original_salary",
+    DROP COLUMN IF EXISTS "payout_currency",
+    DROP COLUMN IF EXISTS "salary_exchange_rate";
+
+DROP TABLE IF EXISTS "hr"."exchange_rates";
+
+ALTER TABLE "hr"."reimbursements"
+    DROP COLUMN IF EXISTS "currency";
+
+ALTER TABLE "hr"."users"
+    DROP COLUMN IF EXISTS "salary_currency",
+    DROP COLUMN IF EXISTS "payout_currency";
\ No newline at end of file
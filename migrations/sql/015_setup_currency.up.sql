@@ -0,0 +1,33 @@
+-- existing salaries and claims are in the base currency, IDR
+ALTER TABLE "hr"."users"
+    ADD COLUMN IF NOT EXISTS "salary_currency" VARCHAR(3) NOT NULL DEFAULT 'IDR',
+    ADD COLUMN IF NOT EXISTS "payout_currency" VARCHAR(3);
+
+ALTER TABLE "hr"."reimbursements"
+    ADD COLUMN IF NOT EXISTS "currency" VARCHAR(3) NOT NULL DEFAULT 'IDR';
+
+-- 1 unit of the currency is worth rate units of the base currency within the payroll period
+CREATE TABLE IF NOT EXISTS "hr"."exchange_rates" (
+    "payroll_id" UUID NOT NULL,
+    "currency" VARCHAR(3) NOT NULL,
+    "rate" DECIMAL(20,8) NOT NULL CHECK (rate > 0),
+    "created_at" TIMESTAMPTZ NOT NULL,
+    "updated_at" TIMESTAMPTZ,
+    "created_by" VARCHAR DEFAULT 'admin',
+    "updated_by" VARCHAR,
+    PRIMARY KEY (payroll_id, currency),
+    CONSTRAINT fk_exchange_rate_payroll_id
+        FOREIGN KEY (payroll_id)
+        REFERENCES hr.payrolls (id)
+);
+
+-- payslip amounts are in the payout currency, the salary is also kept in its own currency
+ALTER TABLE "hr"."payslips"
+    ADD COLUMN IF NOT EXISTS "salary_currency" VARCHAR(3) NOT NULL DEFAULT 'IDR',
+    ADD COLUMN IF NOT EXISTS "original_salary" DECIMAL(20,2),
+    ADD COLUMN IF NOT EXISTS "payout_currency" VARCHAR(3) NOT NULL DEFAULT 'IDR',
+    ADD COLUMN IF NOT EXISTS "salary_exchange_rate" DECIMAL(20,8) NOT NULL DEFAULT 1;
+
+-- total_salary_paid is in the base currency
+ALTER TABLE "hr"."payrolls"
+    ADD COLUMN IF NOT EXISTS "total_salary_paid_by_currency" JSONB;
\ No newline at end of file
@@ -0,0 +1,27 @@
+DROP INDEX IF EXISTS "hr"."idx_user_company_username";
+
+ALTER TABLE "hr"."users" ALTER COLUMN "pay_group_id" SET DEFAULT '5d2f8a9e-3c41-4b7a-9e60-1f4c2a8b7d10';
+ALTER TABLE "hr"."payrolls" ALTER COLUMN "pay_group_id" SET DEFAULT '5d2f8a9e-3c41-4b7a-9e60-1f4c2a8b7d10';
+
+ALTER TABLE "hr"."reimbursement_categories"
+    DROP CONSTRAINT IF EXISTS unique_reimbursement_category_company_code,
+    ADD CONSTRAINT unique_reimbursement_category_code UNIQUE (code);
+
+ALTER TABLE "hr"."pay_groups"
+    DROP CONSTRAINT IF EXISTS unique_pay_group_company_code,
+    ADD CONSTRAINT pay_groups_code_key UNIQUE (code);
+
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY[
+        'users', 'roles', 'user_role_map', 'attendances', 'overtimes', 'reimbursements',
+        'reimbursement_receipts', 'reimbursement_categories', 'payrolls', 'payslips',
+        'payroll_jobs', 'leaves', 'deductions', 'pay_groups', 'exchange_rates'
+    ] LOOP
+        EXECUTE format('ALTER TABLE "hr".%I DROP COLUMN IF EXISTS "company_id"', t);
+    END LOOP;
+END $$;
+
+DROP TABLE IF EXISTS "hr"."companies";
\ No newline at end of file
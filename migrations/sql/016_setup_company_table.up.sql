@@ -0,0 +1,53 @@
+CREATE TABLE IF NOT EXISTS "hr"."companies" (
+    "id" UUID PRIMARY KEY,
+    "code" VARCHAR NOT NULL UNIQUE,
+    "name" VARCHAR NOT NULL,
+    "created_at" TIMESTAMPTZ NOT NULL,
+    "updated_at" TIMESTAMPTZ,
+    "deleted_at" TIMESTAMPTZ,
+    "created_by" VARCHAR DEFAULT 'admin',
+    "updated_by" VARCHAR
+);
+
+-- existing data belongs to the default company
+INSERT INTO "hr"."companies" (id, code, name, created_at)
+VALUES ('e0a6b1f2-7c3d-4e58-9a14-6b2d8f0c3a57', 'default', 'Default Company', now())
+ON CONFLICT DO NOTHING;
+
+-- every table is scoped to a company, the default only backfills existing rows
+-- so new rows without a company fail instead of leaking into the default one
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY[
+        'users', 'roles', 'user_role_map', 'attendances', 'overtimes', 'reimbursements',
+        'reimbursement_receipts', 'reimbursement_categories', 'payrolls', 'payslips',
+        'payroll_jobs', 'leaves', 'deductions', 'pay_groups', 'exchange_rates'
+    ] LOOP
+        EXECUTE format('ALTER TABLE "hr".%I ADD COLUMN IF NOT EXISTS "company_id" UUID NOT NULL DEFAULT %L CONSTRAINT %I REFERENCES hr.companies (id)',
+            t, 'e0a6b1f2-7c3d-4e58-9a14-6b2d8f0c3a57', 'fk_' || t || '_company_id');
+        EXECUTE format('ALTER TABLE "hr".%I ALTER COLUMN "company_id" DROP DEFAULT', t);
+    END LOOP;
+END $$;
+
+-- users and periods are given their pay group explicitly, defaulting to the pay group of the default company would
+-- put the users of every other company in it
+ALTER TABLE "hr"."users"
+    ALTER COLUMN "pay_group_id" SET NOT NULL,
+    ALTER COLUMN "pay_group_id" DROP DEFAULT;
+
+ALTER TABLE "hr"."payrolls"
+    ALTER COLUMN "pay_group_id" SET NOT NULL,
+    ALTER COLUMN "pay_group_id" DROP DEFAULT;
+
+-- codes are unique within a company
+ALTER TABLE "hr"."pay_groups"
+    DROP CONSTRAINT IF EXISTS pay_groups_code_key,
+    ADD CONSTRAINT unique_pay_group_company_code UNIQUE (company_id, code);
+
+ALTER TABLE "hr"."reimbursement_categories"
+    DROP CONSTRAINT IF EXISTS unique_reimbursement_category_code,
+    ADD CONSTRAINT unique_reimbursement_category_company_code UNIQUE (company_id, code);
+
+CREATE INDEX IF NOT EXISTS idx_user_company_username ON "hr"."users" (company_id, username) WHERE deleted_at IS NULL;
\ No newline at end of file
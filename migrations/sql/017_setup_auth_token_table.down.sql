@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "hr"."revoked_tokens";
+
+DROP TABLE IF EXISTS "hr"."refresh_tokens";
\ No newline at end of file
@@ -0,0 +1,32 @@
+-- refresh tokens of a login session share the session ID, each refresh replaces the token with a new one
+CREATE TABLE IF NOT EXISTS "hr"."refresh_tokens" (
+    "id" UUID PRIMARY KEY,
+    "company_id" UUID NOT NULL,
+    "user_id" UUID NOT NULL,
+    "session_id" UUID NOT NULL,
+    "token_hash" VARCHAR NOT NULL UNIQUE,
+    "expires_at" TIMESTAMPTZ NOT NULL,
+    "revoked_at" TIMESTAMPTZ,
+    "replaced_by" UUID,
+    "created_at" TIMESTAMPTZ NOT NULL,
+    CONSTRAINT fk_refresh_token_company_id
+        FOREIGN KEY (company_id)
+        REFERENCES hr.companies (id),
+    CONSTRAINT fk_refresh_token_user_id
+        FOREIGN KEY (user_id)
+        REFERENCES hr.users (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_token_session ON "hr"."refresh_tokens" (session_id) WHERE revoked_at IS NULL;
+
+-- access tokens revoked before they expire, rows past expires_at can be deleted
+CREATE TABLE IF NOT EXISTS "hr"."revoked_tokens" (
+    "jti" UUID PRIMARY KEY,
+    "company_id" UUID NOT NULL,
+    "user_id" UUID NOT NULL,
+    "expires_at" TIMESTAMPTZ NOT NULL,
+    "revoked_at" TIMESTAMPTZ NOT NULL,
+    CONSTRAINT fk_revoked_token_company_id
+        FOREIGN KEY (company_id)
+        REFERENCES hr.companies (id)
+);
\ No newline at end of file
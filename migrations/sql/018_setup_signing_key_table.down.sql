@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "hr"."signing_keys";
\ No newline at end of file
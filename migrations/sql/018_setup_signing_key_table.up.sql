@@ -0,0 +1,12 @@
+-- jwt signing keys shared by every company, a key signs from activates_at until the next key activates
+-- and is kept for verification until the tokens it signed have expired
+CREATE TABLE IF NOT EXISTS "hr"."signing_keys" (
+    "id" UUID PRIMARY KEY,
+    "algorithm" VARCHAR NOT NULL,
+    "private_key" BYTEA NOT NULL,
+    "public_key" BYTEA NOT NULL,
+    "activates_at" TIMESTAMPTZ NOT NULL,
+    "created_at" TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_signing_key_activates_at ON "hr"."signing_keys" (activates_at);
\ No newline at end of file
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS "hr"."password_reset_tokens";
+
+DROP TABLE IF EXISTS "hr"."password_history";
+
+ALTER TABLE "hr"."users" DROP COLUMN IF EXISTS "password_changed_at";
+ALTER TABLE "hr"."users" DROP COLUMN IF EXISTS "must_change_password";
\ No newline at end of file
@@ -0,0 +1,38 @@
+-- users created with a temporary password have to change it on their first login
+ALTER TABLE "hr"."users" ADD COLUMN IF NOT EXISTS "must_change_password" BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE "hr"."users" ADD COLUMN IF NOT EXISTS "password_changed_at" TIMESTAMPTZ;
+
+-- previous password hashes of the user, checked so recent passwords aren't reused
+CREATE TABLE IF NOT EXISTS "hr"."password_history" (
+    "id" UUID PRIMARY KEY,
+    "company_id" UUID NOT NULL,
+    "user_id" UUID NOT NULL,
+    "password" VARCHAR NOT NULL,
+    "created_at" TIMESTAMPTZ NOT NULL,
+    CONSTRAINT fk_password_history_company_id
+        FOREIGN KEY (company_id)
+        REFERENCES hr.companies (id),
+    CONSTRAINT fk_password_history_user_id
+        FOREIGN KEY (user_id)
+        REFERENCES hr.users (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_history_user ON "hr"."password_history" (user_id, created_at DESC);
+
+-- one-time password reset tokens issued by admins, only the SHA-256 hash is kept
+CREATE TABLE IF NOT EXISTS "hr"."password_reset_tokens" (
+    "id" UUID PRIMARY KEY,
+    "company_id" UUID NOT NULL,
+    "user_id" UUID NOT NULL,
+    "token_hash" VARCHAR NOT NULL UNIQUE,
+    "expires_at" TIMESTAMPTZ NOT NULL,
+    "used_at" TIMESTAMPTZ,
+    "created_at" TIMESTAMPTZ NOT NULL,
+    "created_by" VARCHAR,
+    CONSTRAINT fk_password_reset_token_company_id
+        FOREIGN KEY (company_id)
+        REFERENCES hr.companies (id),
+    CONSTRAINT fk_password_reset_token_user_id
+        FOREIGN KEY (user_id)
+        REFERENCES hr.users (id)
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "hr"."login_throttles";
\ No newline at end of file
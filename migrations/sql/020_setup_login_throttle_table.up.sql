@@ -0,0 +1,11 @@
+-- failed login attempts per account and per client IP, keyed by the login company code rather than the company ID
+-- so unknown companies and usernames are throttled just like existing ones
+CREATE TABLE IF NOT EXISTS "hr"."login_throttles" (
+    "scope" VARCHAR NOT NULL,
+    "company_code" VARCHAR NOT NULL,
+    "subject" VARCHAR NOT NULL,
+    "failures" INT NOT NULL,
+    "last_failure_at" TIMESTAMPTZ NOT NULL,
+    "locked_until" TIMESTAMPTZ,
+    PRIMARY KEY ("scope", "company_code", "subject")
+);
\ No newline at end of file
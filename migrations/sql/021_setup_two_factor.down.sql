@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS "hr"."login_challenges";
+
+DROP TABLE IF EXISTS "hr"."recovery_codes";
+
+DROP TABLE IF EXISTS "hr"."two_factor_secrets";
+
+ALTER TABLE "hr"."users" DROP COLUMN IF EXISTS "two_factor_required";
\ No newline at end of file
@@ -0,0 +1,54 @@
+-- admins can require users to enable two-factor authentication, until then they can only enrol
+ALTER TABLE "hr"."users" ADD COLUMN IF NOT EXISTS "two_factor_required" BOOLEAN NOT NULL DEFAULT false;
+
+-- TOTP secret of the user, encrypted with the JWT secret key, two-factor authentication is enabled once confirmed
+CREATE TABLE IF NOT EXISTS "hr"."two_factor_secrets" (
+    "user_id" UUID PRIMARY KEY,
+    "company_id" UUID NOT NULL,
+    "secret" BYTEA NOT NULL,
+    "confirmed_at" TIMESTAMPTZ,
+    "last_used_step" BIGINT, -- time step of the last accepted code, so a code can only be used once
+    "created_at" TIMESTAMPTZ NOT NULL,
+    CONSTRAINT fk_two_factor_secret_company_id
+        FOREIGN KEY (company_id)
+        REFERENCES hr.companies (id),
+    CONSTRAINT fk_two_factor_secret_user_id
+        FOREIGN KEY (user_id)
+        REFERENCES hr.users (id)
+);
+
+-- one-time recovery codes for users who lost their authenticator, only the SHA-256 hash is kept
+CREATE TABLE IF NOT EXISTS "hr"."recovery_codes" (
+    "id" UUID PRIMARY KEY,
+    "company_id" UUID NOT NULL,
+    "user_id" UUID NOT NULL,
+    "code_hash" VARCHAR NOT NULL,
+    "used_at" TIMESTAMPTZ,
+    "created_at" TIMESTAMPTZ NOT NULL,
+    CONSTRAINT fk_recovery_code_company_id
+        FOREIGN KEY (company_id)
+        REFERENCES hr.companies (id),
+    CONSTRAINT fk_recovery_code_user_id
+        FOREIGN KEY (user_id)
+        REFERENCES hr.users (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user ON "hr"."recovery_codes" (user_id, code_hash);
+
+-- logins waiting for the second factor, only the SHA-256 hash of the challenge token is kept
+CREATE TABLE IF NOT EXISTS "hr"."login_challenges" (
+    "id" UUID PRIMARY KEY,
+    "company_id" UUID NOT NULL,
+    "company_code" VARCHAR NOT NULL, -- login company code, for the failed attempts of the account
+    "user_id" UUID NOT NULL,
+    "token_hash" VARCHAR NOT NULL UNIQUE,
+    "expires_at" TIMESTAMPTZ NOT NULL,
+    "used_at" TIMESTAMPTZ,
+    "created_at" TIMESTAMPTZ NOT NULL,
+    CONSTRAINT fk_login_challenge_company_id
+        FOREIGN KEY (company_id)
+        REFERENCES hr.companies (id),
+    CONSTRAINT fk_login_challenge_user_id
+        FOREIGN KEY (user_id)
+        REFERENCES hr.users (id)
+);
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "hr"."sso_states";
+
+DROP TABLE IF EXISTS "hr"."user_identities";
\ No newline at end of file
@@ -0,0 +1,31 @@
+-- identity provider accounts linked to users, the subject is unique within the issuer
+CREATE TABLE IF NOT EXISTS "hr"."user_identities" (
+    "id" UUID PRIMARY KEY,
+    "company_id" UUID NOT NULL,
+    "user_id" UUID NOT NULL,
+    "issuer" VARCHAR NOT NULL,
+    "subject" VARCHAR NOT NULL,
+    "email" VARCHAR,
+    "created_at" TIMESTAMPTZ NOT NULL,
+    CONSTRAINT fk_user_identity_company_id
+        FOREIGN KEY (company_id)
+        REFERENCES hr.companies (id),
+    CONSTRAINT fk_user_identity_user_id
+        FOREIGN KEY (user_id)
+        REFERENCES hr.users (id),
+    CONSTRAINT uq_user_identity UNIQUE (issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user ON "hr"."user_identities" (user_id);
+
+-- single sign-ins started and waiting for the identity provider to redirect back, only the SHA-256 hash of the state
+-- is kept. They are made before the company of the user is known, so they aren't scoped to a company.
+CREATE TABLE IF NOT EXISTS "hr"."sso_states" (
+    "id" UUID PRIMARY KEY,
+    "state_hash" VARCHAR NOT NULL UNIQUE,
+    "nonce" VARCHAR NOT NULL,
+    "code_verifier" VARCHAR NOT NULL,
+    "expires_at" TIMESTAMPTZ NOT NULL,
+    "used_at" TIMESTAMPTZ,
+    "created_at" TIMESTAMPTZ NOT NULL
+);
\ No newline at end of file
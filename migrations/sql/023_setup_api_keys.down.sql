@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "hr"."api_keys";
\ No newline at end of file
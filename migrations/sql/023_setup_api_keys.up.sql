@@ -0,0 +1,24 @@
+-- API keys of service-to-service integrations, only the SHA-256 hash of the key is kept.
+-- A key acts on behalf of the admin who created it, limited to its scopes
+CREATE TABLE IF NOT EXISTS "hr"."api_keys" (
+    "id" UUID PRIMARY KEY,
+    "company_id" UUID NOT NULL,
+    "name" VARCHAR NOT NULL,
+    "prefix" VARCHAR NOT NULL, -- first characters of the key, to tell the keys apart
+    "key_hash" VARCHAR NOT NULL UNIQUE,
+    "scopes" TEXT[] NOT NULL,
+    "expires_at" TIMESTAMPTZ NOT NULL,
+    "last_used_at" TIMESTAMPTZ,
+    "revoked_at" TIMESTAMPTZ,
+    "revoked_by" UUID,
+    "created_at" TIMESTAMPTZ NOT NULL,
+    "created_by" UUID NOT NULL,
+    CONSTRAINT fk_api_key_company_id
+        FOREIGN KEY (company_id)
+        REFERENCES hr.companies (id),
+    CONSTRAINT fk_api_key_created_by
+        FOREIGN KEY (created_by)
+        REFERENCES hr.users (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_company ON "hr"."api_keys" (company_id, created_at);
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "hr"."audit_logs";
+
+DROP FUNCTION IF EXISTS "hr"."reject_audit_log_change";
\ No newline at end of file
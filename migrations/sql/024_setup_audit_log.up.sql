@@ -0,0 +1,38 @@
+-- append-only audit log of the data changes and admin actions of a company, the actor is empty for changes made
+-- by the system and the API key is set for changes made with one
+CREATE TABLE IF NOT EXISTS "hr"."audit_logs" (
+    "id" UUID PRIMARY KEY,
+    "company_id" UUID NOT NULL,
+    "actor_id" UUID,
+    "api_key_id" UUID,
+    "request_id" VARCHAR NOT NULL,
+    "ip" VARCHAR NOT NULL,
+    "action" VARCHAR NOT NULL,
+    "entity" VARCHAR NOT NULL,
+    "entity_id" VARCHAR NOT NULL,
+    "before" JSONB,
+    "after" JSONB,
+    "created_at" TIMESTAMPTZ NOT NULL,
+    CONSTRAINT fk_audit_log_company_id
+        FOREIGN KEY (company_id)
+        REFERENCES hr.companies (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_company ON "hr"."audit_logs" (company_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_entity ON "hr"."audit_logs" (company_id, entity, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor ON "hr"."audit_logs" (company_id, actor_id);
+
+-- entries can only be added, never changed or removed
+CREATE OR REPLACE FUNCTION "hr"."reject_audit_log_change"() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_logs_append_only
+    BEFORE UPDATE OR DELETE ON "hr"."audit_logs"
+    FOR EACH ROW EXECUTE FUNCTION "hr"."reject_audit_log_change"();
+
+CREATE TRIGGER audit_logs_no_truncate
+    BEFORE TRUNCATE ON "hr"."audit_logs"
+    FOR EACH STATEMENT EXECUTE FUNCTION "hr"."reject_audit_log_change"();
\ No newline at end of file
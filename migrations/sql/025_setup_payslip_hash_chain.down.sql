@@ -0,0 +1,8 @@
+ALTER TABLE "hr"."payrolls"
+    DROP COLUMN IF EXISTS "payslip_chain_hash",
+    DROP COLUMN IF EXISTS "payslip_chain_signature";
+
+ALTER TABLE "hr"."payslips"
+    DROP COLUMN IF EXISTS "prev_hash",
+    DROP COLUMN IF EXISTS "hash",
+    DROP COLUMN IF EXISTS "signature";
\ No newline at end of file
@@ -0,0 +1,11 @@
+-- Stored payslips are chained per payroll period in user_id order, each hash covers the payslip content
+-- and the previous hash, so editing, removing or inserting a payslip breaks the chain.
+-- The hashes are signed with PAYROLL_PAYSLIP_SIGNING_SECRET, the head of the chain is kept on the period
+ALTER TABLE "hr"."payslips"
+    ADD COLUMN IF NOT EXISTS "prev_hash" VARCHAR,
+    ADD COLUMN IF NOT EXISTS "hash" VARCHAR,
+    ADD COLUMN IF NOT EXISTS "signature" VARCHAR;
+
+ALTER TABLE "hr"."payrolls"
+    ADD COLUMN IF NOT EXISTS "payslip_chain_hash" VARCHAR,
+    ADD COLUMN IF NOT EXISTS "payslip_chain_signature" VARCHAR;
\ No newline at end of file
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "hr"."webhook_deliveries";
+
+DROP TABLE IF EXISTS "hr"."webhook_outbox";
+
+DROP TABLE IF EXISTS "hr"."webhook_subscriptions";
\ No newline at end of file
@@ -0,0 +1,65 @@
+-- Webhook subscriptions of downstream systems, the secret signing the payloads is encrypted with JWT_SECRET_KEY
+CREATE TABLE IF NOT EXISTS "hr"."webhook_subscriptions" (
+    "id" UUID PRIMARY KEY,
+    "company_id" UUID NOT NULL,
+    "url" VARCHAR NOT NULL,
+    "events" TEXT[] NOT NULL,
+    "secret" BYTEA NOT NULL,
+    "created_at" TIMESTAMPTZ NOT NULL,
+    "created_by" UUID NOT NULL,
+    "deleted_at" TIMESTAMPTZ,
+    "deleted_by" UUID,
+    CONSTRAINT fk_webhook_subscription_company_id
+        FOREIGN KEY (company_id)
+        REFERENCES hr.companies (id),
+    CONSTRAINT fk_webhook_subscription_created_by
+        FOREIGN KEY (created_by)
+        REFERENCES hr.users (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_company ON "hr"."webhook_subscriptions" (company_id, created_at) WHERE deleted_at IS NULL;
+
+-- Outbox of the events to deliver, one message per event and subscription. Messages are written in the same
+-- transaction as the business change and picked up by the dispatcher once next_attempt_at is due
+CREATE TABLE IF NOT EXISTS "hr"."webhook_outbox" (
+    "id" UUID PRIMARY KEY,
+    "company_id" UUID NOT NULL,
+    "subscription_id" UUID NOT NULL,
+    "event_id" UUID NOT NULL,
+    "event_type" VARCHAR NOT NULL,
+    "payload" JSONB NOT NULL,
+    "status" VARCHAR NOT NULL, -- pending, delivered, failed or cancelled
+    "attempts" INT NOT NULL DEFAULT 0,
+    "next_attempt_at" TIMESTAMPTZ NOT NULL,
+    "last_error" VARCHAR,
+    "delivered_at" TIMESTAMPTZ,
+    "created_at" TIMESTAMPTZ NOT NULL,
+    "updated_at" TIMESTAMPTZ,
+    CONSTRAINT fk_webhook_outbox_subscription_id
+        FOREIGN KEY (subscription_id)
+        REFERENCES hr.webhook_subscriptions (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_outbox_pending ON "hr"."webhook_outbox" (next_attempt_at) WHERE status = 'pending';
+
+-- Log of every delivery attempt
+CREATE TABLE IF NOT EXISTS "hr"."webhook_deliveries" (
+    "id" UUID PRIMARY KEY,
+    "company_id" UUID NOT NULL,
+    "message_id" UUID NOT NULL,
+    "subscription_id" UUID NOT NULL,
+    "event_id" UUID NOT NULL,
+    "event_type" VARCHAR NOT NULL,
+    "attempt" INT NOT NULL,
+    "status" VARCHAR NOT NULL, -- delivered, retrying or failed
+    "status_code" INT,
+    "error" VARCHAR,
+    "duration_ms" BIGINT NOT NULL,
+    "next_attempt_at" TIMESTAMPTZ,
+    "created_at" TIMESTAMPTZ NOT NULL,
+    CONSTRAINT fk_webhook_delivery_message_id
+        FOREIGN KEY (message_id)
+        REFERENCES hr.webhook_outbox (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription ON "hr"."webhook_deliveries" (subscription_id, created_at);
\ No newline at end of file
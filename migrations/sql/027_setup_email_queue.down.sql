@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "hr"."email_queue";
+
+ALTER TABLE "hr"."users" DROP COLUMN IF EXISTS "email";
\ No newline at end of file
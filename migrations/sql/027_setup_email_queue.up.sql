@@ -0,0 +1,32 @@
+-- email address of the users, employee emails are only sent to users having one
+ALTER TABLE "hr"."users" ADD COLUMN IF NOT EXISTS "email" VARCHAR;
+
+-- users logging in with their email already have one
+UPDATE "hr"."users" SET "email" = "username" WHERE "email" IS NULL AND "username" LIKE '%_@_%.%';
+
+-- Queue of the employee emails, rendered from the template and data when they're sent. Sensitive template data like
+-- password reset tokens is kept apart in secret, encrypted with JWT_SECRET_KEY
+CREATE TABLE IF NOT EXISTS "hr"."email_queue" (
+    "id" UUID PRIMARY KEY,
+    "company_id" UUID NOT NULL,
+    "user_id" UUID NOT NULL,
+    "recipient" VARCHAR NOT NULL,
+    "template" VARCHAR NOT NULL,
+    "data" JSONB NOT NULL,
+    "secret" BYTEA,
+    "status" VARCHAR NOT NULL, -- pending, sent or failed
+    "attempts" INT NOT NULL DEFAULT 0,
+    "next_attempt_at" TIMESTAMPTZ NOT NULL,
+    "last_error" VARCHAR,
+    "sent_at" TIMESTAMPTZ,
+    "created_at" TIMESTAMPTZ NOT NULL,
+    "updated_at" TIMESTAMPTZ,
+    CONSTRAINT fk_email_queue_company_id
+        FOREIGN KEY (company_id)
+        REFERENCES hr.companies (id),
+    CONSTRAINT fk_email_queue_user_id
+        FOREIGN KEY (user_id)
+        REFERENCES hr.users (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_queue_pending ON "hr"."email_queue" (next_attempt_at) WHERE status = 'pending';
\ No newline at end of file
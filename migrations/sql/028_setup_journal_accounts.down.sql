@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "hr"."journal_accounts";
\ No newline at end of file
@@ -0,0 +1,16 @@
+-- Journal accounts of the company's chart of accounts the payroll journal entries are posted to, account types not
+-- mapped here use the ACCOUNTING_* accounts
+CREATE TABLE IF NOT EXISTS "hr"."journal_accounts" (
+    "company_id" UUID NOT NULL,
+    "account_type" VARCHAR NOT NULL, -- salary_expense, overtime_expense, reimbursement_expense, tax_payable, net_pay_payable or deduction_receivable
+    "code" VARCHAR NOT NULL,
+    "name" VARCHAR NOT NULL,
+    "created_at" TIMESTAMPTZ NOT NULL,
+    "updated_at" TIMESTAMPTZ,
+    "created_by" VARCHAR DEFAULT 'admin',
+    "updated_by" VARCHAR,
+    PRIMARY KEY (company_id, account_type),
+    CONSTRAINT fk_journal_account_company_id
+        FOREIGN KEY (company_id)
+        REFERENCES hr.companies (id)
+);
\ No newline at end of file